@@ -17,12 +17,19 @@ export default function GameScreen() {
   const [selectedAnswer, setSelectedAnswer] = useState(null);
   const [answered, setAnswered] = useState(false);
   const [timeLeft, setTimeLeft] = useState(15);
+  const [paused, setPaused] = useState(false);
   const [questionStartTime, setQuestionStartTime] = useState(null);
+  const [answerProgress, setAnswerProgress] = useState(null);
   const [showResults, setShowResults] = useState(false);
   const [correctAnswer, setCorrectAnswer] = useState(null);
+  const [correctAnswers, setCorrectAnswers] = useState(null);
+  const [acceptedAnswers, setAcceptedAnswers] = useState(null);
+  const [numericAnswer, setNumericAnswer] = useState(null);
   const [chatMessages, setChatMessages] = useState([]);
   const [chatInput, setChatInput] = useState('');
+  const [chatError, setChatError] = useState(null); // text, or { key, fallback } from the message catalog
   const [showChat, setShowChat] = useState(true);
+  const [messages, setMessages] = useState({}); // server message catalog for the lobby's language
   
   const timerRef = useRef(null);
   const questionTimerRef = useRef(null);
@@ -49,16 +56,26 @@ export default function GameScreen() {
       }
     }
 
+    loadChatHistory();
+
     // Set up WebSocket listeners
     wsService.on('player_joined', handlePlayerJoined);
     wsService.on('player_left', handlePlayerLeft);
     wsService.on('game_started', handleGameStarted);
     wsService.on('new_question', handleNewQuestion);
     wsService.on('answer_received', handleAnswerReceived);
+    wsService.on('answer_progress', handleAnswerProgress);
     wsService.on('question_results', handleQuestionResults);
     wsService.on('game_ended', handleGameEnded);
     wsService.on('lobby_updated', handlePlayerJoined); // Also listen for lobby updates
     wsService.on('chat_message', handleChatMessage);
+    wsService.on('chat_rejected', handleChatRejected);
+    wsService.on('game_paused', handleGamePaused);
+    wsService.on('game_resumed', handleGameResumed);
+    wsService.on('rsvp_updated', handleRSVPUpdated);
+    wsService.on('start_held', handlePlayerJoined); // carries the lobby with start_held set
+    wsService.on('player_disconnected', handlePlayerJoined); // lobby snapshots with presence
+    wsService.on('player_reconnected', handlePlayerJoined);
 
     // Join lobby via WebSocket when connection is ready
     const joinWhenReady = () => {
@@ -89,9 +106,17 @@ export default function GameScreen() {
       wsService.off('game_started', handleGameStarted);
       wsService.off('new_question', handleNewQuestion);
       wsService.off('answer_received', handleAnswerReceived);
+      wsService.off('answer_progress', handleAnswerProgress);
       wsService.off('question_results', handleQuestionResults);
       wsService.off('game_ended', handleGameEnded);
       wsService.off('chat_message', handleChatMessage);
+      wsService.off('chat_rejected', handleChatRejected);
+      wsService.off('game_paused', handleGamePaused);
+      wsService.off('game_resumed', handleGameResumed);
+      wsService.off('rsvp_updated', handleRSVPUpdated);
+      wsService.off('start_held', handlePlayerJoined);
+      wsService.off('player_disconnected', handlePlayerJoined);
+      wsService.off('player_reconnected', handlePlayerJoined);
       if (timerRef.current) clearInterval(timerRef.current);
       if (questionTimerRef.current) clearInterval(questionTimerRef.current);
       // Don't disconnect WebSocket - keep it alive for navigation
@@ -107,6 +132,31 @@ export default function GameScreen() {
     }
   };
 
+  // Fill in the conversation so far; messages that also arrive live are
+  // matched by the same id and not shown twice
+  const loadChatHistory = async () => {
+    try {
+      const history = await api.getChatHistory(lobbyId);
+      const currentPlayerId = location.state?.player?.id;
+      setChatMessages((prev) => {
+        const seen = new Set(prev.map((msg) => msg.id));
+        const earlier = history
+          .map((msg) => ({
+            id: `msg_${msg.player_id}_${msg.timestamp}`,
+            playerId: msg.player_id,
+            username: msg.username,
+            message: msg.message,
+            timestamp: msg.timestamp,
+            isOwn: msg.player_id === currentPlayerId,
+          }))
+          .filter((msg) => !seen.has(msg.id));
+        return [...earlier, ...prev];
+      });
+    } catch (err) {
+      console.error('Failed to load chat history:', err);
+    }
+  };
+
   const handlePlayerJoined = (data) => {
     console.log('Player joined event received:', data);
     if (data.data && data.data.lobby) {
@@ -134,7 +184,7 @@ export default function GameScreen() {
     console.log('Question options:', questionData?.options);
     
     // Ensure question has options array
-    if (!questionData || !questionData.options || !Array.isArray(questionData.options)) {
+    if (!questionData || (questionData.type !== 'free_text' && questionData.type !== 'numeric' && !Array.isArray(questionData.options))) {
       console.error('Invalid question data received:', questionData);
       return;
     }
@@ -142,13 +192,14 @@ export default function GameScreen() {
     setQuestion(questionData);
     setSelectedAnswer(null);
     setAnswered(false);
+    setAnswerProgress(null);
     setShowResults(false);
     
     // FIX: Use server timestamp for synchronized timer
     // Store server's absolute end time and calculate remaining time from it
     // This ensures all clients are synchronized even if they receive the message at different times
-    const questionEndTime = data.data.question_end_time; // Server timestamp in milliseconds (absolute time)
-    const serverTime = data.data.server_time; // Server's current time when message was sent
+    const questionEndTime = Date.parse(data.data.question_end_time); // Server RFC3339 UTC timestamp (absolute time)
+    const serverTime = Date.parse(data.data.server_time); // Server's current time when message was sent
     const clientTime = Date.now(); // Client's current time when message received
     
     if (questionEndTime && serverTime) {
@@ -209,6 +260,41 @@ export default function GameScreen() {
     }
   };
 
+  const handleRSVPUpdated = (data) => {
+    const { invite, attendance } = data.data;
+    setLobby((prev) => ({
+      ...prev,
+      attendance,
+      invites: [...(prev.invites || []).filter((i) => i.username !== invite.username), invite],
+    }));
+  };
+
+  const handleGamePaused = (data) => {
+    setPaused(true);
+    if (questionTimerRef.current) clearInterval(questionTimerRef.current);
+    if (typeof data.data.remaining_ms === 'number') {
+      setTimeLeft(Math.floor(data.data.remaining_ms / 1000));
+    }
+  };
+
+  const handleGameResumed = (data) => {
+    setPaused(false);
+    const questionEndTime = Date.parse(data.data.question_end_time);
+    const serverTime = Date.parse(data.data.server_time);
+    if (!questionEndTime || !serverTime) return;
+
+    // The server moved the end time forward by the length of the pause
+    serverTimeOffsetRef.current = Date.now() - serverTime;
+    questionEndTimeRef.current = questionEndTime;
+    if (questionTimerRef.current) clearInterval(questionTimerRef.current);
+    questionTimerRef.current = setInterval(() => {
+      const remainingMs = questionEndTimeRef.current - (Date.now() - serverTimeOffsetRef.current);
+      const remaining = Math.max(0, Math.floor(remainingMs / 1000));
+      setTimeLeft(remaining);
+      if (remaining <= 0) clearInterval(questionTimerRef.current);
+    }, 50);
+  };
+
   const handleAnswerReceived = (data) => {
     // Update lobby state if needed
     if (data.data.lobby) {
@@ -216,9 +302,17 @@ export default function GameScreen() {
     }
   };
 
+  // How many players have answered so far; never what they answered
+  const handleAnswerProgress = (data) => {
+    setAnswerProgress({ answered: data.data.answered, total: data.data.total });
+  };
+
   const handleQuestionResults = (data) => {
     setShowResults(true);
     setCorrectAnswer(data.data.correct_answer);
+    setCorrectAnswers(data.data.correct_answers || null);
+    setAcceptedAnswers(data.data.accepted_answers || null);
+    setNumericAnswer(data.data.numeric_answer ?? null);
     setLobby((prev) => ({
       ...prev,
       players: data.data.leaderboard || prev.players,
@@ -226,6 +320,14 @@ export default function GameScreen() {
     if (questionTimerRef.current) clearInterval(questionTimerRef.current);
   };
 
+  const handleTogglePause = () => {
+    if (paused) {
+      wsService.resumeGame(lobbyId);
+    } else {
+      wsService.pauseGame(lobbyId);
+    }
+  };
+
   const handleGameStarted = (data) => {
     console.log('Game started event received:', data);
     if (data.data && data.data.lobby) {
@@ -240,6 +342,7 @@ export default function GameScreen() {
       ...prev,
       state: 'finished',
       players: data.data.final_leaderboard || prev.players,
+      stats: data.data.stats || prev.stats,
     }));
   };
 
@@ -258,10 +361,13 @@ export default function GameScreen() {
           return prev; // Don't add duplicate
         }
         
-        // If this is our own message, remove any pending temp messages with same content
-        // This replaces the optimistic update with the confirmed server message
+        // If this is our own message, remove the oldest pending temp message
+        // This replaces the optimistic update with the confirmed server message,
+        // whose text may differ once the chat filter has masked it
+        let replaced = false;
         const filtered = prev.filter(msg => {
-          if (isOwnMessage && msg.isPending && msg.message === messageData.message) {
+          if (isOwnMessage && msg.isPending && !replaced) {
+            replaced = true;
             return false; // Remove temp message
           }
           return true;
@@ -271,6 +377,7 @@ export default function GameScreen() {
           ...filtered,
           {
             id: messageId,
+            playerId: messageData.player_id,
             username: messageData.username,
             message: messageData.message,
             timestamp: messageData.timestamp || Date.now(),
@@ -288,6 +395,36 @@ export default function GameScreen() {
     }
   };
 
+  // Hide a player's chat from now on; it's no longer delivered to us either
+  const handleMutePlayer = async (targetId) => {
+    try {
+      await api.mutePlayer(lobbyId, player.id, targetId);
+      setChatMessages((prev) => prev.filter((msg) => msg.playerId !== targetId));
+    } catch (error) {
+      setChatError(error.message);
+    }
+  };
+
+  const handleReportPlayer = async (targetId) => {
+    const reason = window.prompt('Why are you reporting this player? (optional)');
+    if (reason === null) return;
+    try {
+      await api.reportPlayer(lobbyId, player.id, targetId, reason);
+      setChatError('Report sent, thank you');
+    } catch (error) {
+      setChatError(error.message);
+    }
+  };
+
+  // The chat filter refused a message sent over WebSocket
+  const handleChatRejected = (data) => {
+    setChatError({ key: `chat.${data.data?.code}`, fallback: data.data?.error || 'Message not sent' });
+    setChatMessages((prev) => {
+      const pending = prev.find(msg => msg.isPending);
+      return pending ? prev.filter(msg => msg.id !== pending.id) : prev;
+    });
+  };
+
   const handleSendChat = async (e) => {
     e.preventDefault();
     if (!chatInput.trim() || !lobby || !player) return;
@@ -311,6 +448,7 @@ export default function GameScreen() {
     
     // Clear input immediately for better UX
     setChatInput('');
+    setChatError(null);
     
     // Auto-scroll to show the new message
     setTimeout(() => {
@@ -338,6 +476,12 @@ export default function GameScreen() {
         });
       }, 5000);
     } catch (error) {
+      if (error.rejection) {
+        // Refused by the chat filter; sending it over WebSocket wouldn't help
+        setChatError({ key: `chat.${error.rejection.code}`, fallback: error.message });
+        setChatMessages((prev) => prev.filter(msg => msg.id !== tempId));
+        return;
+      }
       console.error('Failed to send chat message via REST API, trying WebSocket:', error);
       // Fallback to WebSocket if REST API fails
       try {
@@ -350,6 +494,17 @@ export default function GameScreen() {
     }
   };
 
+  // Server messages in the lobby's language; English text is the fallback
+  useEffect(() => {
+    if (!lobby?.language) return;
+    api.getMessageCatalog(lobby.language)
+      .then(setMessages)
+      .catch((error) => console.error('Failed to load message catalog:', error));
+  }, [lobby?.language]);
+
+  const t = (key, fallback, params = {}) =>
+    (messages[key] || fallback).replace(/\{(\w+)\}/g, (match, name) => params[name] ?? match);
+
   // Auto-scroll chat to bottom when new messages arrive
   useEffect(() => {
     if (chatEndRef.current) {
@@ -366,15 +521,40 @@ export default function GameScreen() {
     }
   };
 
+  const isMultiSelect = question?.type === 'multi_select';
+  const isFreeText = question?.type === 'free_text';
+  const isNumeric = question?.type === 'numeric';
+
+  const handleSelectOption = (index) => {
+    if (answered) return;
+    if (!isMultiSelect) {
+      setSelectedAnswer(index);
+      return;
+    }
+    // Select-all-that-apply: toggle the option in the selection list
+    const current = Array.isArray(selectedAnswer) ? selectedAnswer : [];
+    const next = current.includes(index)
+      ? current.filter((i) => i !== index)
+      : [...current, index];
+    setSelectedAnswer(next.length > 0 ? next : null);
+  };
+
+  const isOptionSelected = (index) =>
+    Array.isArray(selectedAnswer) ? selectedAnswer.includes(index) : selectedAnswer === index;
+
   const handleSubmitAnswer = async () => {
     if (selectedAnswer === null || answered) return;
+    if (isFreeText && String(selectedAnswer).trim() === '') return;
+    // Numeric guesses come from the input as text
+    const answer = isNumeric ? Number(selectedAnswer) : selectedAnswer;
+    if (isNumeric && !Number.isFinite(answer)) return;
 
     const responseTime = Date.now() - questionStartTime;
     setAnswered(true);
     
     try {
       // Submit via REST API (WebSocket receives updates automatically)
-      await api.submitAnswer(lobbyId, player.id, selectedAnswer, responseTime);
+      await api.submitAnswer(lobbyId, player.id, answer, responseTime);
     } catch (err) {
       console.error('Failed to submit answer:', err);
       setAnswered(false);
@@ -417,7 +597,7 @@ export default function GameScreen() {
           <p>Round {lobby.round} of {lobby.max_rounds}</p>
         </div>
         <div className="game-info">
-          <span>Players: {lobby.players?.length || 0}/8</span>
+          <span>Players: {lobby.players?.length || 0}/{lobby.max_players || 8}</span>
           <button onClick={handleLeave} className="btn btn-small btn-secondary">
             Leave
           </button>
@@ -429,7 +609,11 @@ export default function GameScreen() {
           <h2>Waiting for players...</h2>
           <div className="players-list">
             {lobby.players?.map((p) => (
-              <div key={p.id} className="player-badge">
+              <div
+                key={p.id}
+                className={`player-badge${p.online === false ? ' offline' : ''}`}
+                title={p.online === false && p.last_seen ? `Last seen ${new Date(p.last_seen).toLocaleTimeString()}` : undefined}
+              >
                 {p.username}
                 {p.id === player.id && <span className="you">(You)</span>}
               </div>
@@ -443,6 +627,18 @@ export default function GameScreen() {
           {!canStart && isHost && (
             <p className="waiting-message">Need at least 2 players to start</p>
           )}
+          {lobby.attendance && (
+            <div className="rsvp-attendance">
+              <p>
+                {t('event.rsvp_attendance', '{joined} of {expected} players who said yes have joined', lobby.attendance)}
+              </p>
+              {lobby.start_held && (
+                <p className="waiting-message">
+                  {t('event.start_held', 'Waiting for more of the players who said yes before starting.')}
+                </p>
+              )}
+            </div>
+          )}
         </div>
       )}
 
@@ -452,22 +648,67 @@ export default function GameScreen() {
             <>
               <div className="timer">
                 <div className="timer-circle">
-                  <span>{timeLeft}s</span>
+                  <span>{paused ? 'II' : `${timeLeft}s`}</span>
                 </div>
+                {isHost && (
+                  <button onClick={handleTogglePause} className="btn btn-small btn-secondary">
+                    {paused ? 'Resume' : 'Pause'}
+                  </button>
+                )}
               </div>
+              {paused && <p className="waiting-message">{t('event.game_paused', 'Game paused by the host')}</p>}
+              {answerProgress && answerProgress.total > 0 && (
+                <div className="answer-progress">
+                  <div className="answer-progress-bar">
+                    <div
+                      className="answer-progress-fill"
+                      style={{ width: `${(answerProgress.answered / answerProgress.total) * 100}%` }}
+                    />
+                  </div>
+                  <span>{t('event.answer_progress', '{answered} of {total} players answered', answerProgress)}</span>
+                </div>
+              )}
               
               <div className="question">
                 <h2>{question.text}</h2>
+                {isMultiSelect && <p className="answer-hint">Select all that apply</p>}
+                {question.media_url && question.media_type === 'image' && (
+                  <img src={question.media_url} alt="" className="question-media" />
+                )}
+                {question.media_url && question.media_type === 'audio' && (
+                  <audio src={question.media_url} controls autoPlay className="question-media" />
+                )}
                 <div className="options">
-                  {question.options && Array.isArray(question.options) && question.options.length > 0 ? (
+                  {isFreeText ? (
+                    <input
+                      type="text"
+                      className="free-text-input"
+                      placeholder="Type your answer"
+                      maxLength={200}
+                      value={selectedAnswer ?? ''}
+                      onChange={(e) => setSelectedAnswer(e.target.value === '' ? null : e.target.value)}
+                      onKeyDown={(e) => e.key === 'Enter' && handleSubmitAnswer()}
+                      disabled={answered || paused}
+                    />
+                  ) : isNumeric ? (
+                    <input
+                      type="number"
+                      className="free-text-input"
+                      placeholder="Your closest guess"
+                      value={selectedAnswer ?? ''}
+                      onChange={(e) => setSelectedAnswer(e.target.value === '' ? null : e.target.value)}
+                      onKeyDown={(e) => e.key === 'Enter' && handleSubmitAnswer()}
+                      disabled={answered || paused}
+                    />
+                  ) : question.options && Array.isArray(question.options) && question.options.length > 0 ? (
                     question.options.map((option, index) => (
                       <button
                         key={index}
-                        onClick={() => !answered && setSelectedAnswer(index)}
-                        className={`option-btn ${selectedAnswer === index ? 'selected' : ''} ${
+                        onClick={() => handleSelectOption(index)}
+                        className={`option-btn ${isOptionSelected(index) ? 'selected' : ''} ${
                           answered ? 'disabled' : ''
                         }`}
-                        disabled={answered}
+                        disabled={answered || paused}
                       >
                         {option}
                       </button>
@@ -478,7 +719,7 @@ export default function GameScreen() {
                     </p>
                   )}
                 </div>
-                {selectedAnswer !== null && !answered && (
+                {selectedAnswer !== null && !answered && !paused && (
                   <button onClick={handleSubmitAnswer} className="btn btn-primary btn-large">
                     Submit Answer
                   </button>
@@ -492,7 +733,13 @@ export default function GameScreen() {
             <div className="results-screen">
               <h2>Results</h2>
               <p className="correct-answer">
-                Correct answer: {question.options[correctAnswer]}
+                Correct answer: {isFreeText
+                  ? (acceptedAnswers || [])[0]
+                  : isNumeric
+                  ? numericAnswer
+                  : Array.isArray(correctAnswers)
+                  ? correctAnswers.map((i) => question.options[i]).join(', ')
+                  : question.options[correctAnswer]}
               </p>
               <Leaderboard players={lobby.players || []} />
             </div>
@@ -504,6 +751,35 @@ export default function GameScreen() {
         <div className="finished-screen">
           <h2>Game Finished!</h2>
           <Leaderboard players={lobby.players || []} />
+          {lobby.stats && (
+            <div className="game-stats">
+              <div className="game-stat">
+                <span className="game-stat-value">{(lobby.stats.avg_response_ms / 1000).toFixed(1)}s</span>
+                <span className="game-stat-label">Average answer time</span>
+              </div>
+              <div className="game-stat">
+                <span className="game-stat-value">
+                  {lobby.stats.rounds.length > 0
+                    ? Math.round(100 * lobby.stats.rounds.reduce((sum, r) => sum + r.participation_rate, 0) / lobby.stats.rounds.length)
+                    : 0}%
+                </span>
+                <span className="game-stat-label">Participation</span>
+              </div>
+              <div className="game-stat">
+                <span className="game-stat-value">{lobby.stats.chat_messages}</span>
+                <span className="game-stat-label">Chat messages</span>
+              </div>
+              {lobby.stats.hardest_question && (
+                <div className="game-stat game-stat-wide">
+                  <span className="game-stat-label">
+                    Hardest question (round {lobby.stats.hardest_question.round},{' '}
+                    {Math.round(100 * lobby.stats.hardest_question.accuracy)}% correct)
+                  </span>
+                  <span className="game-stat-question">{lobby.stats.hardest_question.text}</span>
+                </div>
+              )}
+            </div>
+          )}
           <button onClick={() => navigate('/')} className="btn btn-primary btn-large">
             Back to Lobby
           </button>
@@ -544,6 +820,12 @@ export default function GameScreen() {
                       <div className="chat-message-header">
                         <span className="chat-username">{msg.username}</span>
                         {msg.isOwn && <span className="chat-you-badge">You</span>}
+                        {!msg.isOwn && msg.playerId && (
+                          <span className="chat-moderation">
+                            <button type="button" onClick={() => handleMutePlayer(msg.playerId)}>Mute</button>
+                            <button type="button" onClick={() => handleReportPlayer(msg.playerId)}>Report</button>
+                          </span>
+                        )}
                         <span className="chat-timestamp">
                           {new Date(msg.timestamp).toLocaleTimeString([], { hour: '2-digit', minute: '2-digit' })}
                         </span>
@@ -556,6 +838,11 @@ export default function GameScreen() {
                 <div ref={chatEndRef} />
               </div>
               
+              {chatError && (
+                <div className="chat-error">
+                  {typeof chatError === 'string' ? chatError : t(chatError.key, chatError.fallback)}
+                </div>
+              )}
               <form onSubmit={handleSendChat} className="chat-input-form">
                 <input
                   type="text"
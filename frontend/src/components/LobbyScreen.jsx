@@ -17,6 +17,12 @@ export default function LobbyScreen() {
   const navigate = useNavigate();
 
   useEffect(() => {
+    // Invitation links open the join form for their lobby: /?join=<lobby id>
+    const invitedTo = new URLSearchParams(window.location.search).get('join');
+    if (invitedTo) {
+      setLobbyId(invitedTo);
+      setScreen('join');
+    }
     loadLobbies();
     // Connect WebSocket if not already connected and not connecting
     if (!wsService.ws || 
@@ -221,7 +227,7 @@ export default function LobbyScreen() {
                       </div>
                       <div className="lobby-item-details">
                         <span className="lobby-players">
-                          {lobby.players?.length || 0}/8 players
+                          {lobby.players?.length || 0}/{lobby.max_players || 8} players
                         </span>
                         <span className="lobby-rounds">
                           {lobby.max_rounds || 10} questions
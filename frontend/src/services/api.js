@@ -22,23 +22,122 @@ function getApiBase() {
 
 const API_BASE = getApiBase();
 
+// Set by the deployment's captcha widget when the server runs CHALLENGE_MODE=captcha
+let captchaToken = null;
+
+// Login token of the player's account, kept across reloads; null for guests
+const AUTH_TOKEN_KEY = 'authToken';
+
+export function getAuthToken() {
+  return localStorage.getItem(AUTH_TOKEN_KEY);
+}
+
+function authHeaders() {
+  const token = getAuthToken();
+  return token ? { Authorization: `Bearer ${token}` } : {};
+}
+
+// Session tokens of the seats this browser holds, by lobby: anything done as
+// a player over REST needs the one its join returned
+const SESSION_TOKEN_PREFIX = 'sessionToken:';
+
+export function getSessionToken(lobbyId) {
+  return localStorage.getItem(SESSION_TOKEN_PREFIX + lobbyId);
+}
+
+export function setSessionToken(lobbyId, token) {
+  if (token) {
+    localStorage.setItem(SESSION_TOKEN_PREFIX + lobbyId, token);
+  } else {
+    localStorage.removeItem(SESSION_TOKEN_PREFIX + lobbyId);
+  }
+}
+
+function sessionHeaders(lobbyId) {
+  const token = getSessionToken(lobbyId);
+  return token ? { 'X-Session-Token': token } : {};
+}
+
+async function authenticate(path, username, password, headers = {}) {
+  const response = await fetch(`${API_BASE}/auth/${path}`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json', ...headers },
+    body: JSON.stringify({ username, password }),
+  });
+  const body = await response.json().catch(() => ({}));
+  if (!response.ok) throw new Error(body.error || 'Failed to log in');
+  localStorage.setItem(AUTH_TOKEN_KEY, body.token);
+  return body.user;
+}
+
+function leadingZeroBits(bytes) {
+  let count = 0;
+  for (const b of bytes) {
+    if (b !== 0) return count + Math.clz32(b) - 24;
+    count += 8;
+  }
+  return count;
+}
+
+// Find a nonce so sha256(challenge + ":" + nonce) starts with `difficulty` zero bits
+async function solveProofOfWork(challenge, difficulty) {
+  const encoder = new TextEncoder();
+  for (let nonce = 0; ; nonce++) {
+    const digest = await crypto.subtle.digest('SHA-256', encoder.encode(`${challenge}:${nonce}`));
+    if (leadingZeroBits(new Uint8Array(digest)) >= difficulty) {
+      return String(nonce);
+    }
+  }
+}
+
+// Headers proving a solved anti-abuse challenge, if the server requires one
+async function challengeHeaders() {
+  const response = await fetch(`${API_BASE}/challenge`);
+  if (!response.ok) return {};
+  const challenge = await response.json();
+
+  if (challenge.mode === 'pow') {
+    const nonce = await solveProofOfWork(challenge.challenge, challenge.difficulty);
+    return { 'X-Challenge': challenge.challenge, 'X-Challenge-Nonce': nonce };
+  }
+  if (challenge.mode === 'captcha' && captchaToken) {
+    return { 'X-Captcha-Token': captchaToken };
+  }
+  return {};
+}
+
 export const api = {
+  setCaptchaToken: (token) => {
+    captchaToken = token;
+  },
+
+  // Create an account and log in as it; returns the user
+  register: async (username, password) => authenticate('register', username, password, await challengeHeaders()),
+
+  // Log in to an account; lobbies are then joined as it
+  login: async (username, password) => authenticate('login', username, password),
+
+  logout: () => {
+    localStorage.removeItem(AUTH_TOKEN_KEY);
+  },
+
   // Create a new lobby
   createLobby: async (name, maxRounds = 10) => {
     const response = await fetch(`${API_BASE}/lobbies`, {
       method: 'POST',
-      headers: { 'Content-Type': 'application/json' },
+      headers: { 'Content-Type': 'application/json', ...(await challengeHeaders()) },
       body: JSON.stringify({ name, max_rounds: maxRounds }),
     });
     if (!response.ok) throw new Error('Failed to create lobby');
     return response.json();
   },
 
-  // List all lobbies
+  // List waiting lobbies (the first page, newest first)
   listLobbies: async () => {
     const response = await fetch(`${API_BASE}/lobbies`);
     if (!response.ok) throw new Error('Failed to fetch lobbies');
-    return response.json();
+    const page = await response.json();
+    return page.lobbies;
   },
 
   // Get lobby details
@@ -48,25 +147,28 @@ export const api = {
     return response.json();
   },
 
-  // Join a lobby
+  // Join a lobby; when logged in, as the account (username is then ignored)
   joinLobby: async (lobbyId, username) => {
     const response = await fetch(`${API_BASE}/lobbies/${lobbyId}/join`, {
       method: 'POST',
-      headers: { 'Content-Type': 'application/json' },
+      headers: { 'Content-Type': 'application/json', ...authHeaders(), ...(await challengeHeaders()) },
       body: JSON.stringify({ username }),
     });
     if (!response.ok) throw new Error('Failed to join lobby');
-    return response.json();
+    const joined = await response.json();
+    setSessionToken(lobbyId, joined.session_token);
+    return joined;
   },
 
   // Leave a lobby
   leaveLobby: async (lobbyId, playerId) => {
     const response = await fetch(`${API_BASE}/lobbies/${lobbyId}/leave`, {
       method: 'POST',
-      headers: { 'Content-Type': 'application/json' },
+      headers: { 'Content-Type': 'application/json', ...sessionHeaders(lobbyId) },
       body: JSON.stringify({ player_id: playerId }),
     });
     if (!response.ok) throw new Error('Failed to leave lobby');
+    setSessionToken(lobbyId, null);
     return response.json();
   },
 
@@ -83,7 +185,7 @@ export const api = {
   submitAnswer: async (lobbyId, playerId, answer, responseTime) => {
     const response = await fetch(`${API_BASE}/lobbies/${lobbyId}/answer`, {
       method: 'POST',
-      headers: { 'Content-Type': 'application/json' },
+      headers: { 'Content-Type': 'application/json', ...sessionHeaders(lobbyId) },
       body: JSON.stringify({
         player_id: playerId,
         answer,
@@ -98,13 +200,121 @@ export const api = {
   sendChatMessage: async (lobbyId, playerId, message) => {
     const response = await fetch(`${API_BASE}/lobbies/${lobbyId}/chat`, {
       method: 'POST',
-      headers: { 'Content-Type': 'application/json' },
+      headers: { 'Content-Type': 'application/json', ...sessionHeaders(lobbyId) },
       body: JSON.stringify({
         player_id: playerId,
         message: message,
       }),
     });
-    if (!response.ok) throw new Error('Failed to send chat message');
+    if (!response.ok) {
+      const body = await response.json().catch(() => ({}));
+      const error = new Error(body.error || 'Failed to send chat message');
+      // Set when the chat filter refused the message: { code, error, retry_after_ms }
+      if (body.code) error.rejection = body;
+      throw error;
+    }
+    return response.json();
+  },
+
+  // Load the server's message catalog for a language, e.g. 'es'
+  getMessageCatalog: async (lang) => {
+    const response = await fetch(`${API_BASE}/i18n/${encodeURIComponent(lang)}`);
+    if (!response.ok) throw new Error('Failed to load message catalog');
+    const data = await response.json();
+    return data.messages || {};
+  },
+
+  // Mute a player's chat for yourself (scope 'self') or, as host, for the
+  // whole lobby (scope 'lobby')
+  mutePlayer: async (lobbyId, playerId, targetId, scope = 'self') => {
+    const response = await fetch(`${API_BASE}/lobbies/${lobbyId}/mute`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json', ...sessionHeaders(lobbyId) },
+      body: JSON.stringify({ player_id: playerId, target_id: targetId, scope }),
+    });
+    if (!response.ok) throw new Error('Failed to mute player');
+    return response.json();
+  },
+
+  // Answer a scheduled game's invitation: 'yes', 'no' or 'maybe'
+  rsvp: async (lobbyId, username, status) => {
+    const response = await fetch(`${API_BASE}/lobbies/${lobbyId}/rsvp`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ username, status }),
+    });
+    if (!response.ok) throw new Error('Failed to record RSVP');
+    return response.json();
+  },
+
+  // Report a player for admin review
+  reportPlayer: async (lobbyId, playerId, targetId, reason) => {
+    const response = await fetch(`${API_BASE}/lobbies/${lobbyId}/report`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json', ...sessionHeaders(lobbyId) },
+      body: JSON.stringify({ player_id: playerId, target_id: targetId, reason }),
+    });
+    if (!response.ok) {
+      const body = await response.json().catch(() => ({}));
+      throw new Error(body.error || 'Failed to report player');
+    }
+    return response.json();
+  },
+
+  // Load stored chat, oldest first; pass the last seen timestamp as since to
+  // get only newer messages
+  getChatHistory: async (lobbyId, since) => {
+    const query = since ? `?since=${encodeURIComponent(since)}` : '';
+    const response = await fetch(`${API_BASE}/lobbies/${lobbyId}/chat${query}`);
+    if (!response.ok) throw new Error('Failed to load chat history');
+    const history = await response.json();
+    return history.messages;
+  },
+
+  // Friends and requests of the logged-in account; pass online to list
+  // only friends online now. Changes arrive over the WebSocket as
+  // friend_request, friend_accepted, friend_online and friend_offline.
+  listFriends: async (online = false) => {
+    const response = await fetch(`${API_BASE}/friends${online ? '/online' : ''}`, { headers: authHeaders() });
+    if (!response.ok) throw new Error('Failed to load friends');
+    const data = await response.json();
+    return data.friends;
+  },
+
+  // Send a friend request, or accept the one they sent
+  addFriend: async (username) => {
+    const response = await fetch(`${API_BASE}/friends`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json', ...authHeaders() },
+      body: JSON.stringify({ username }),
+    });
+    const body = await response.json().catch(() => ({}));
+    if (!response.ok) throw new Error(body.error || 'Failed to add friend');
+    return body;
+  },
+
+  // Remove a friend, or withdraw or decline a request
+  removeFriend: async (userId) => {
+    const response = await fetch(`${API_BASE}/friends/${userId}`, {
+      method: 'DELETE',
+      headers: authHeaders(),
+    });
+    if (!response.ok) throw new Error('Failed to remove friend');
+    return response.json();
+  },
+
+  // Invite an online friend to a lobby you're in; they get a lobby_invite
+  // event with a link to join
+  inviteFriend: async (userId, lobbyId) => {
+    const response = await fetch(`${API_BASE}/friends/${userId}/invite`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json', ...authHeaders() },
+      body: JSON.stringify({ lobby_id: lobbyId }),
+    });
+    if (!response.ok) {
+      const body = await response.json().catch(() => ({}));
+      throw new Error(body.error || 'Failed to invite friend');
+    }
     return response.json();
   },
 };
@@ -1,3 +1,5 @@
+import { getAuthToken, getSessionToken, setSessionToken } from './api';
+
 class WebSocketService {
   constructor() {
     this.ws = null;
@@ -6,6 +8,8 @@ class WebSocketService {
     this.maxReconnectAttempts = 5;
     this.connectionStatus = 'disconnected'; // 'connected', 'disconnected', 'connecting', 'error'
     this.isReconnecting = false;
+    // Keep the seat's session token, for rejoining after a reload
+    this.on('session', (event) => setSessionToken(event.lobby_id, event.data.session_token));
   }
 
   getConnectionStatus() {
@@ -40,7 +44,11 @@ class WebSocketService {
       host = `${window.location.hostname}:8080`;
     }
     
-    return `${protocol}//${host}/ws`;
+    // Declare the optional payload features this client handles, and log in
+    // as the player's account if they have one
+    const token = getAuthToken();
+    const auth = token ? `&token=${encodeURIComponent(token)}` : '';
+    return `${protocol}//${host}/ws?capabilities=supports_images${auth}`;
   }
 
   connect() {
@@ -276,7 +284,7 @@ class WebSocketService {
         this.off('connected', handleConnected);
         this.send('join_lobby', {
           lobby_id: lobbyId,
-          data: { username },
+          data: { username, session_token: getSessionToken(lobbyId) },
         });
       };
       this.on('connected', handleConnected);
@@ -289,7 +297,7 @@ class WebSocketService {
     
     this.send('join_lobby', {
       lobby_id: lobbyId,
-      data: { username },
+      data: { username, session_token: getSessionToken(lobbyId) },
     });
   }
 
@@ -309,6 +317,15 @@ class WebSocketService {
     this.send('start_game', { lobby_id: lobbyId });
   }
 
+  // Only the host's pause/resume requests are honoured by the server
+  pauseGame(lobbyId) {
+    this.send('pause_game', { lobby_id: lobbyId });
+  }
+
+  resumeGame(lobbyId) {
+    this.send('resume_game', { lobby_id: lobbyId });
+  }
+
   sendChatMessage(lobbyId, playerId, message) {
     console.log('Sending chat message:', { lobbyId, playerId, message });
     console.log('WebSocket state:', this.ws?.readyState, this.ws ? 'OPEN=' + WebSocket.OPEN : 'no ws');
@@ -337,9 +354,10 @@ class WebSocketService {
   // Also support submitting via REST API (via WebSocket for real-time)
   submitAnswerViaAPI(lobbyId, playerId, answer, responseTime) {
     // This is handled by REST API, WebSocket just for receiving updates
+    const token = getSessionToken(lobbyId);
     return fetch(`http://localhost:8080/api/v1/lobbies/${lobbyId}/answer`, {
       method: 'POST',
-      headers: { 'Content-Type': 'application/json' },
+      headers: { 'Content-Type': 'application/json', ...(token ? { 'X-Session-Token': token } : {}) },
       body: JSON.stringify({
         player_id: playerId,
         answer,
@@ -0,0 +1,480 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.24.4
+// source: quiz/v1/quiz.proto
+
+package quizv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	QuizService_CreateLobby_FullMethodName  = "/quiz.v1.QuizService/CreateLobby"
+	QuizService_GetLobby_FullMethodName     = "/quiz.v1.QuizService/GetLobby"
+	QuizService_ListLobbies_FullMethodName  = "/quiz.v1.QuizService/ListLobbies"
+	QuizService_JoinLobby_FullMethodName    = "/quiz.v1.QuizService/JoinLobby"
+	QuizService_LeaveLobby_FullMethodName   = "/quiz.v1.QuizService/LeaveLobby"
+	QuizService_StartGame_FullMethodName    = "/quiz.v1.QuizService/StartGame"
+	QuizService_EndGame_FullMethodName      = "/quiz.v1.QuizService/EndGame"
+	QuizService_CancelGame_FullMethodName   = "/quiz.v1.QuizService/CancelGame"
+	QuizService_SubmitAnswer_FullMethodName = "/quiz.v1.QuizService/SubmitAnswer"
+	QuizService_StreamEvents_FullMethodName = "/quiz.v1.QuizService/StreamEvents"
+)
+
+// QuizServiceClient is the client API for QuizService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type QuizServiceClient interface {
+	CreateLobby(ctx context.Context, in *CreateLobbyRequest, opts ...grpc.CallOption) (*CreateLobbyResponse, error)
+	GetLobby(ctx context.Context, in *GetLobbyRequest, opts ...grpc.CallOption) (*Lobby, error)
+	ListLobbies(ctx context.Context, in *ListLobbiesRequest, opts ...grpc.CallOption) (*ListLobbiesResponse, error)
+	JoinLobby(ctx context.Context, in *JoinLobbyRequest, opts ...grpc.CallOption) (*JoinLobbyResponse, error)
+	LeaveLobby(ctx context.Context, in *PlayerRequest, opts ...grpc.CallOption) (*LeaveLobbyResponse, error)
+	StartGame(ctx context.Context, in *StartGameRequest, opts ...grpc.CallOption) (*StartGameResponse, error)
+	// Ends the game now and announces the results, as the host
+	EndGame(ctx context.Context, in *PlayerRequest, opts ...grpc.CallOption) (*EndGameResponse, error)
+	// Stops the game without results, as the host
+	CancelGame(ctx context.Context, in *PlayerRequest, opts ...grpc.CallOption) (*CancelGameResponse, error)
+	SubmitAnswer(ctx context.Context, in *SubmitAnswerRequest, opts ...grpc.CallOption) (*SubmitAnswerResponse, error)
+	// Streams the lobby's lobby-wide events from after_seq until the lobby
+	// closes or the call is cancelled. Events are the WebSocket ones, with
+	// the same types and data.
+	StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (QuizService_StreamEventsClient, error)
+}
+
+type quizServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewQuizServiceClient(cc grpc.ClientConnInterface) QuizServiceClient {
+	return &quizServiceClient{cc}
+}
+
+func (c *quizServiceClient) CreateLobby(ctx context.Context, in *CreateLobbyRequest, opts ...grpc.CallOption) (*CreateLobbyResponse, error) {
+	out := new(CreateLobbyResponse)
+	err := c.cc.Invoke(ctx, QuizService_CreateLobby_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *quizServiceClient) GetLobby(ctx context.Context, in *GetLobbyRequest, opts ...grpc.CallOption) (*Lobby, error) {
+	out := new(Lobby)
+	err := c.cc.Invoke(ctx, QuizService_GetLobby_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *quizServiceClient) ListLobbies(ctx context.Context, in *ListLobbiesRequest, opts ...grpc.CallOption) (*ListLobbiesResponse, error) {
+	out := new(ListLobbiesResponse)
+	err := c.cc.Invoke(ctx, QuizService_ListLobbies_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *quizServiceClient) JoinLobby(ctx context.Context, in *JoinLobbyRequest, opts ...grpc.CallOption) (*JoinLobbyResponse, error) {
+	out := new(JoinLobbyResponse)
+	err := c.cc.Invoke(ctx, QuizService_JoinLobby_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *quizServiceClient) LeaveLobby(ctx context.Context, in *PlayerRequest, opts ...grpc.CallOption) (*LeaveLobbyResponse, error) {
+	out := new(LeaveLobbyResponse)
+	err := c.cc.Invoke(ctx, QuizService_LeaveLobby_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *quizServiceClient) StartGame(ctx context.Context, in *StartGameRequest, opts ...grpc.CallOption) (*StartGameResponse, error) {
+	out := new(StartGameResponse)
+	err := c.cc.Invoke(ctx, QuizService_StartGame_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *quizServiceClient) EndGame(ctx context.Context, in *PlayerRequest, opts ...grpc.CallOption) (*EndGameResponse, error) {
+	out := new(EndGameResponse)
+	err := c.cc.Invoke(ctx, QuizService_EndGame_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *quizServiceClient) CancelGame(ctx context.Context, in *PlayerRequest, opts ...grpc.CallOption) (*CancelGameResponse, error) {
+	out := new(CancelGameResponse)
+	err := c.cc.Invoke(ctx, QuizService_CancelGame_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *quizServiceClient) SubmitAnswer(ctx context.Context, in *SubmitAnswerRequest, opts ...grpc.CallOption) (*SubmitAnswerResponse, error) {
+	out := new(SubmitAnswerResponse)
+	err := c.cc.Invoke(ctx, QuizService_SubmitAnswer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *quizServiceClient) StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (QuizService_StreamEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &QuizService_ServiceDesc.Streams[0], QuizService_StreamEvents_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &quizServiceStreamEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type QuizService_StreamEventsClient interface {
+	Recv() (*GameEvent, error)
+	grpc.ClientStream
+}
+
+type quizServiceStreamEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *quizServiceStreamEventsClient) Recv() (*GameEvent, error) {
+	m := new(GameEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// QuizServiceServer is the server API for QuizService service.
+// All implementations must embed UnimplementedQuizServiceServer
+// for forward compatibility
+type QuizServiceServer interface {
+	CreateLobby(context.Context, *CreateLobbyRequest) (*CreateLobbyResponse, error)
+	GetLobby(context.Context, *GetLobbyRequest) (*Lobby, error)
+	ListLobbies(context.Context, *ListLobbiesRequest) (*ListLobbiesResponse, error)
+	JoinLobby(context.Context, *JoinLobbyRequest) (*JoinLobbyResponse, error)
+	LeaveLobby(context.Context, *PlayerRequest) (*LeaveLobbyResponse, error)
+	StartGame(context.Context, *StartGameRequest) (*StartGameResponse, error)
+	// Ends the game now and announces the results, as the host
+	EndGame(context.Context, *PlayerRequest) (*EndGameResponse, error)
+	// Stops the game without results, as the host
+	CancelGame(context.Context, *PlayerRequest) (*CancelGameResponse, error)
+	SubmitAnswer(context.Context, *SubmitAnswerRequest) (*SubmitAnswerResponse, error)
+	// Streams the lobby's lobby-wide events from after_seq until the lobby
+	// closes or the call is cancelled. Events are the WebSocket ones, with
+	// the same types and data.
+	StreamEvents(*StreamEventsRequest, QuizService_StreamEventsServer) error
+	mustEmbedUnimplementedQuizServiceServer()
+}
+
+// UnimplementedQuizServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedQuizServiceServer struct {
+}
+
+func (UnimplementedQuizServiceServer) CreateLobby(context.Context, *CreateLobbyRequest) (*CreateLobbyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateLobby not implemented")
+}
+func (UnimplementedQuizServiceServer) GetLobby(context.Context, *GetLobbyRequest) (*Lobby, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetLobby not implemented")
+}
+func (UnimplementedQuizServiceServer) ListLobbies(context.Context, *ListLobbiesRequest) (*ListLobbiesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListLobbies not implemented")
+}
+func (UnimplementedQuizServiceServer) JoinLobby(context.Context, *JoinLobbyRequest) (*JoinLobbyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method JoinLobby not implemented")
+}
+func (UnimplementedQuizServiceServer) LeaveLobby(context.Context, *PlayerRequest) (*LeaveLobbyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LeaveLobby not implemented")
+}
+func (UnimplementedQuizServiceServer) StartGame(context.Context, *StartGameRequest) (*StartGameResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartGame not implemented")
+}
+func (UnimplementedQuizServiceServer) EndGame(context.Context, *PlayerRequest) (*EndGameResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EndGame not implemented")
+}
+func (UnimplementedQuizServiceServer) CancelGame(context.Context, *PlayerRequest) (*CancelGameResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelGame not implemented")
+}
+func (UnimplementedQuizServiceServer) SubmitAnswer(context.Context, *SubmitAnswerRequest) (*SubmitAnswerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitAnswer not implemented")
+}
+func (UnimplementedQuizServiceServer) StreamEvents(*StreamEventsRequest, QuizService_StreamEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamEvents not implemented")
+}
+func (UnimplementedQuizServiceServer) mustEmbedUnimplementedQuizServiceServer() {}
+
+// UnsafeQuizServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to QuizServiceServer will
+// result in compilation errors.
+type UnsafeQuizServiceServer interface {
+	mustEmbedUnimplementedQuizServiceServer()
+}
+
+func RegisterQuizServiceServer(s grpc.ServiceRegistrar, srv QuizServiceServer) {
+	s.RegisterService(&QuizService_ServiceDesc, srv)
+}
+
+func _QuizService_CreateLobby_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateLobbyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuizServiceServer).CreateLobby(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QuizService_CreateLobby_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuizServiceServer).CreateLobby(ctx, req.(*CreateLobbyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QuizService_GetLobby_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLobbyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuizServiceServer).GetLobby(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QuizService_GetLobby_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuizServiceServer).GetLobby(ctx, req.(*GetLobbyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QuizService_ListLobbies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListLobbiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuizServiceServer).ListLobbies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QuizService_ListLobbies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuizServiceServer).ListLobbies(ctx, req.(*ListLobbiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QuizService_JoinLobby_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(JoinLobbyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuizServiceServer).JoinLobby(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QuizService_JoinLobby_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuizServiceServer).JoinLobby(ctx, req.(*JoinLobbyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QuizService_LeaveLobby_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PlayerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuizServiceServer).LeaveLobby(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QuizService_LeaveLobby_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuizServiceServer).LeaveLobby(ctx, req.(*PlayerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QuizService_StartGame_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartGameRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuizServiceServer).StartGame(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QuizService_StartGame_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuizServiceServer).StartGame(ctx, req.(*StartGameRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QuizService_EndGame_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PlayerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuizServiceServer).EndGame(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QuizService_EndGame_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuizServiceServer).EndGame(ctx, req.(*PlayerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QuizService_CancelGame_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PlayerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuizServiceServer).CancelGame(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QuizService_CancelGame_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuizServiceServer).CancelGame(ctx, req.(*PlayerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QuizService_SubmitAnswer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitAnswerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuizServiceServer).SubmitAnswer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QuizService_SubmitAnswer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuizServiceServer).SubmitAnswer(ctx, req.(*SubmitAnswerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QuizService_StreamEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(QuizServiceServer).StreamEvents(m, &quizServiceStreamEventsServer{stream})
+}
+
+type QuizService_StreamEventsServer interface {
+	Send(*GameEvent) error
+	grpc.ServerStream
+}
+
+type quizServiceStreamEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *quizServiceStreamEventsServer) Send(m *GameEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// QuizService_ServiceDesc is the grpc.ServiceDesc for QuizService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var QuizService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "quiz.v1.QuizService",
+	HandlerType: (*QuizServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateLobby",
+			Handler:    _QuizService_CreateLobby_Handler,
+		},
+		{
+			MethodName: "GetLobby",
+			Handler:    _QuizService_GetLobby_Handler,
+		},
+		{
+			MethodName: "ListLobbies",
+			Handler:    _QuizService_ListLobbies_Handler,
+		},
+		{
+			MethodName: "JoinLobby",
+			Handler:    _QuizService_JoinLobby_Handler,
+		},
+		{
+			MethodName: "LeaveLobby",
+			Handler:    _QuizService_LeaveLobby_Handler,
+		},
+		{
+			MethodName: "StartGame",
+			Handler:    _QuizService_StartGame_Handler,
+		},
+		{
+			MethodName: "EndGame",
+			Handler:    _QuizService_EndGame_Handler,
+		},
+		{
+			MethodName: "CancelGame",
+			Handler:    _QuizService_CancelGame_Handler,
+		},
+		{
+			MethodName: "SubmitAnswer",
+			Handler:    _QuizService_SubmitAnswer_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamEvents",
+			Handler:       _QuizService_StreamEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "quiz/v1/quiz.proto",
+}
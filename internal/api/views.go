@@ -0,0 +1,439 @@
+// Package api holds the shapes lobbies, players and questions take on the
+// wire, in REST responses and WebSocket events. They're built from the
+// internal models by the mapping functions here, so a field added to a model
+// stays server-side until it's added to its view as well. Storage and admin
+// endpoints keep using the models directly.
+package api
+
+import (
+	"time"
+
+	"buildprize-game/internal/game"
+	"buildprize-game/internal/models"
+)
+
+// Player is a player as other players see them. Test players look like
+// everyone else.
+type Player struct {
+	ID       string     `json:"id"`
+	Username string     `json:"username"`
+	Score    int        `json:"score"`
+	Streak   int        `json:"streak"`
+	IsReady  bool       `json:"is_ready"`
+	IsBot    bool       `json:"is_bot,omitempty"`
+	UserID   string     `json:"user_id,omitempty"` // set for players logged in to an account
+	Online   bool       `json:"online"`            // bots and test players always are
+	LastSeen *time.Time `json:"last_seen,omitempty"`
+
+	PowerUps map[models.PowerUp]int `json:"power_ups,omitempty"` // earned and not yet used
+}
+
+// User is an account as its owner sees it: without the password hash.
+type User struct {
+	ID        string    `json:"id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func FromUser(u *models.User) *User {
+	return &User{ID: u.ID, Username: u.Username, CreatedAt: u.CreatedAt}
+}
+
+// APIKey is an API key as admins see it: without its hashes.
+type APIKey struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// When the secret from before the last rotation stops working
+	PreviousExpiresAt *time.Time `json:"previous_expires_at,omitempty"`
+	CreatedBy         string     `json:"created_by"`
+	CreatedAt         time.Time  `json:"created_at"`
+	RotatedAt         *time.Time `json:"rotated_at,omitempty"`
+	RevokedAt         *time.Time `json:"revoked_at,omitempty"`
+}
+
+func FromAPIKey(k *models.APIKey) *APIKey {
+	return &APIKey{
+		ID:                k.ID,
+		Name:              k.Name,
+		Scopes:            k.Scopes,
+		PreviousExpiresAt: k.PreviousExpiresAt,
+		CreatedBy:         k.CreatedBy,
+		CreatedAt:         k.CreatedAt,
+		RotatedAt:         k.RotatedAt,
+		RevokedAt:         k.RevokedAt,
+	}
+}
+
+// Question is a question as it's asked: without its answers, which go out
+// with the round's results.
+type Question struct {
+	ID          string              `json:"id"`
+	Type        models.QuestionType `json:"type,omitempty"`
+	Text        string              `json:"text"`
+	Options     []string            `json:"options"`
+	OptionCount int                 `json:"option_count"`
+	Category    string              `json:"category"`
+	MediaURL    string              `json:"media_url,omitempty"`
+	MediaType   models.MediaType    `json:"media_type,omitempty"`
+	Difficulty  string              `json:"difficulty,omitempty"`
+}
+
+// Poll is the host's poll with its public tally; who voted for what isn't
+// shown.
+type Poll struct {
+	ID        string            `json:"id"`
+	Question  string            `json:"question"`
+	Options   []string          `json:"options"`
+	Tally     []int             `json:"tally"`
+	Apply     models.PollAction `json:"apply,omitempty"`
+	CreatedBy string            `json:"created_by"`
+	ClosesAt  time.Time         `json:"closes_at"`
+	Closed    bool              `json:"closed"`
+	Winner    *int              `json:"winner,omitempty"`
+}
+
+// ScoringRules are the rules a lobby with its own scoring is scored by.
+type ScoringRules struct {
+	BaseScore        int     `json:"base_score"`
+	MaxTimeBonus     int     `json:"max_time_bonus"`
+	TimeBonusCurve   string  `json:"time_bonus_curve"`
+	AccuracyBonus    int     `json:"accuracy_bonus"`
+	PartialCredit    bool    `json:"partial_credit"`
+	StreakMultiplier float64 `json:"streak_multiplier"`
+	StreakCap        float64 `json:"streak_cap,omitempty"`
+	WrongPenalty     int     `json:"wrong_penalty"`
+	PositionBonuses  []int   `json:"position_bonuses,omitempty"`
+}
+
+// Lobby is a lobby as its players and the lobby listing see it.
+type Lobby struct {
+	ID              string           `json:"id"`
+	Name            string           `json:"name"`
+	Players         []*Player        `json:"players"`
+	State           models.GameState `json:"state"`
+	Phase           game.Phase       `json:"phase"`
+	CurrentQ        *Question        `json:"current_question,omitempty"`
+	Round           int              `json:"round"`
+	MaxRounds       int              `json:"max_rounds"`
+	MaxPlayers      int              `json:"max_players"`
+	QuestionTime    int              `json:"question_time"` // seconds per question
+	CreatedAt       time.Time        `json:"created_at"`
+	StartedAt       *time.Time       `json:"started_at,omitempty"`
+	FinishedAt      *time.Time       `json:"finished_at,omitempty"`
+	QuestionEnd     *time.Time       `json:"question_end,omitempty"`
+	Paused          bool             `json:"paused,omitempty"`
+	PausedAt        *time.Time       `json:"paused_at,omitempty"`
+	RemainingMs     int64            `json:"remaining_ms,omitempty"`
+	Topic           string           `json:"topic,omitempty"`
+	Timezone        string           `json:"timezone"`
+	Language        string           `json:"language"`
+	RoundType       models.MediaType `json:"round_type,omitempty"`
+	WarmUp          bool             `json:"warm_up,omitempty"`
+	Audience        bool             `json:"audience,omitempty"`
+	Prize           string           `json:"prize,omitempty"` // results are held open to disputes
+	PrizePool       *PrizePool       `json:"prize_pool,omitempty"`
+	EntryFee        int64            `json:"entry_fee,omitempty"` // in the prize pool's currency
+	Scoring         *ScoringRules    `json:"scoring,omitempty"`   // the lobby's own scoring rules
+	WagerRounds     []int            `json:"wager_rounds,omitempty"`
+	FinalWager      bool             `json:"final_wager,omitempty"`
+	Poll            *Poll            `json:"poll,omitempty"`
+	CategoryWeights map[string]int   `json:"category_weights,omitempty"`
+	CategoryMix     map[string]int   `json:"category_mix,omitempty"`
+	Stats           *GameStats       `json:"stats,omitempty"` // once the game has finished
+	StartsAt        *time.Time       `json:"starts_at,omitempty"`
+	StartHeld       bool             `json:"start_held,omitempty"`
+	RSVPQuorum      int              `json:"rsvp_quorum,omitempty"`
+	Invites         []Invite         `json:"invites,omitempty"`
+	Attendance      *Attendance      `json:"attendance,omitempty"` // for scheduled games
+}
+
+// GameStats is sent as stored: it holds nothing players shouldn't see once
+// the game is over.
+type GameStats = models.GameStats
+
+// PrizeResult is sent as stored: a prize game's standings and disputes are
+// public to let players check them. Claim codes and payout destinations are
+// the exception; see FromPrizeResult.
+type PrizeResult = models.PrizeResult
+
+// PrizePool is sent as it is: what a lobby plays for is public.
+type PrizePool = models.PrizePool
+
+// PlayerStats is sent as stored: an account's lifetime totals are public,
+// like its place on a leaderboard.
+type PlayerStats = models.PlayerStats
+
+// AccountStreak is sent as stored, like PlayerStats.
+type AccountStreak = models.AccountStreak
+
+// Profile is an account as anyone may see it, with its lifetime stats and
+// its day and win streaks.
+type Profile struct {
+	*User
+	Stats  *PlayerStats   `json:"stats"`
+	Streak *AccountStreak `json:"streak"`
+}
+
+// Invite and Attendance are sent as they are: a scheduled game's RSVPs are
+// shown to everyone in the lobby.
+type (
+	Invite     = models.Invite
+	Attendance = models.Attendance
+)
+
+// ChatMessage is a chat message, as in chat_message events.
+type ChatMessage struct {
+	ID        int64  `json:"id,omitempty"` // unset if the message couldn't be stored
+	PlayerID  string `json:"player_id"`
+	Username  string `json:"username"`
+	Message   string `json:"message"`
+	Timestamp string `json:"timestamp"`
+}
+
+// LobbyState is what a client needs to resync with a lobby after
+// reconnecting: the lobby, the open question and its timer, the leaderboard
+// and the recent chat. Times are formatted as in new_question events.
+type LobbyState struct {
+	Lobby           *Lobby        `json:"lobby"`
+	Round           int           `json:"round"`
+	Question        *Question     `json:"question,omitempty"` // only while a question is open
+	TimeLeft        int           `json:"time_left"`          // seconds
+	QuestionEndTime string        `json:"question_end_time,omitempty"`
+	Leaderboard     []*Player     `json:"leaderboard"`
+	RecentChat      []ChatMessage `json:"recent_chat"`
+	ServerTime      string        `json:"server_time"`
+}
+
+func FromPlayer(p *models.Player) *Player {
+	if p == nil {
+		return nil
+	}
+	return &Player{
+		ID:       p.ID,
+		Username: p.Username,
+		Score:    p.Score,
+		Streak:   p.Streak,
+		IsReady:  p.IsReady,
+		IsBot:    p.IsBot,
+		UserID:   p.UserID,
+		Online:   p.Online || !p.IsHuman(),
+		LastSeen: copyTime(p.LastSeen),
+		PowerUps: copyPowerUps(p.PowerUps),
+	}
+}
+
+// FromPlayers maps players in order, e.g. a leaderboard.
+func FromPlayers(players []*models.Player) []*Player {
+	views := make([]*Player, len(players))
+	for i, p := range players {
+		views[i] = FromPlayer(p)
+	}
+	return views
+}
+
+func FromQuestion(q *models.Question) *Question {
+	if q == nil {
+		return nil
+	}
+	return &Question{
+		ID:          q.ID,
+		Type:        q.Type,
+		Text:        q.Text,
+		Options:     append([]string(nil), q.Options...),
+		OptionCount: q.OptionCount,
+		Category:    q.Category,
+		MediaURL:    q.MediaURL,
+		MediaType:   q.MediaType,
+		Difficulty:  q.Difficulty,
+	}
+}
+
+func FromScoring(c *models.ScoringConfig) *ScoringRules {
+	if c == nil {
+		return nil
+	}
+	curve := c.TimeBonusCurve
+	if curve == "" {
+		curve = models.TimeBonusLinear
+	}
+	return &ScoringRules{
+		BaseScore:        c.BaseScore,
+		MaxTimeBonus:     c.MaxTimeBonus,
+		TimeBonusCurve:   curve,
+		AccuracyBonus:    c.AccuracyBonus,
+		PartialCredit:    c.PartialCredit,
+		StreakMultiplier: c.StreakMultiplier,
+		StreakCap:        c.StreakCap,
+		WrongPenalty:     c.WrongPenalty,
+		PositionBonuses:  append([]int(nil), c.PositionBonuses...),
+	}
+}
+
+func FromPoll(p *models.Poll) *Poll {
+	if p == nil {
+		return nil
+	}
+	view := &Poll{
+		ID:        p.ID,
+		Question:  p.Question,
+		Options:   append([]string(nil), p.Options...),
+		Tally:     append([]int(nil), p.Tally...),
+		Apply:     p.Apply,
+		CreatedBy: p.CreatedBy,
+		ClosesAt:  p.ClosesAt,
+		Closed:    p.Closed,
+	}
+	if p.Winner != nil {
+		winner := *p.Winner
+		view.Winner = &winner
+	}
+	return view
+}
+
+func FromChatMessage(m models.ChatMessage) ChatMessage {
+	return ChatMessage{
+		ID:        m.ID,
+		PlayerID:  m.PlayerID,
+		Username:  m.Username,
+		Message:   m.Message,
+		Timestamp: models.FormatTimestamp(m.SentAt),
+	}
+}
+
+// FromChatMessages maps messages in order.
+func FromChatMessages(messages []models.ChatMessage) []ChatMessage {
+	views := make([]ChatMessage, len(messages))
+	for i, m := range messages {
+		views[i] = FromChatMessage(m)
+	}
+	return views
+}
+
+// FromLobby maps a lobby the caller holds the lock on. The view shares
+// nothing with the lobby, so it can be encoded after the lock is released.
+func FromLobby(l *models.Lobby) *Lobby {
+	return &Lobby{
+		ID:              l.ID,
+		Name:            l.Name,
+		Players:         FromPlayers(l.Players),
+		State:           l.State,
+		Phase:           l.Phase,
+		CurrentQ:        FromQuestion(l.CurrentQ),
+		Round:           l.Round,
+		MaxRounds:       l.MaxRounds,
+		MaxPlayers:      l.MaxPlayers,
+		QuestionTime:    int(l.QuestionDuration().Seconds()),
+		CreatedAt:       l.CreatedAt,
+		StartedAt:       copyTime(l.StartedAt),
+		FinishedAt:      copyTime(l.FinishedAt),
+		QuestionEnd:     copyTime(l.QuestionEnd),
+		Paused:          l.Paused,
+		PausedAt:        copyTime(l.PausedAt),
+		RemainingMs:     l.RemainingMs,
+		Topic:           l.Topic,
+		Timezone:        l.Timezone,
+		Language:        l.Language,
+		RoundType:       l.RoundType,
+		WarmUp:          l.WarmUp,
+		Audience:        l.Audience,
+		Prize:           l.Prize,
+		PrizePool:       l.PrizePool,
+		EntryFee:        l.EntryFee,
+		Scoring:         FromScoring(l.Scoring),
+		WagerRounds:     append([]int(nil), l.WagerRounds...),
+		FinalWager:      l.FinalWager,
+		Poll:            FromPoll(l.Poll),
+		CategoryWeights: copyCounts(l.CategoryWeights),
+		CategoryMix:     copyCounts(l.CategoryMix),
+		Stats:           l.Stats, // replaced, never modified, once set
+		StartsAt:        copyTime(l.StartsAt),
+		StartHeld:       l.StartHeld,
+		RSVPQuorum:      l.RSVPQuorum,
+		Invites:         fromInvites(l.Invites),
+		Attendance:      fromAttendance(l),
+	}
+}
+
+func fromInvites(invites []*models.Invite) []Invite {
+	if len(invites) == 0 {
+		return nil
+	}
+	views := make([]Invite, len(invites))
+	for i, invite := range invites {
+		views[i] = *invite // RespondedAt is replaced, never modified
+	}
+	return views
+}
+
+func fromAttendance(l *models.Lobby) *Attendance {
+	if l.StartsAt == nil {
+		return nil
+	}
+	return l.Attendance()
+}
+
+// LobbySnapshot maps a lobby, taking its lock.
+func LobbySnapshot(l *models.Lobby) *Lobby {
+	l.Lock()
+	defer l.Unlock()
+	return FromLobby(l)
+}
+
+// FromLobbies maps lobbies nothing else holds, such as ones just loaded from
+// the repository.
+func FromLobbies(lobbies []*models.Lobby) []*Lobby {
+	views := make([]*Lobby, len(lobbies))
+	for i, l := range lobbies {
+		views[i] = FromLobby(l)
+	}
+	return views
+}
+
+// FromPrizeResult copies a prize game's results without their claim codes
+// and payout destinations, which only the finisher owed a share and admins
+// may see.
+func FromPrizeResult(r *models.PrizeResult) *PrizeResult {
+	if r == nil {
+		return nil
+	}
+	copied := *r
+	copied.Allocations = make([]*models.Allocation, len(r.Allocations))
+	for i, allocation := range r.Allocations {
+		public := *allocation
+		public.ClaimCode = ""
+		public.Destination = ""
+		copied.Allocations[i] = &public
+	}
+	return &copied
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	copied := *t
+	return &copied
+}
+
+func copyCounts(counts map[string]int) map[string]int {
+	if counts == nil {
+		return nil
+	}
+	copied := make(map[string]int, len(counts))
+	for k, v := range counts {
+		copied[k] = v
+	}
+	return copied
+}
+
+func copyPowerUps(powerUps map[models.PowerUp]int) map[models.PowerUp]int {
+	if len(powerUps) == 0 {
+		return nil
+	}
+	copied := make(map[models.PowerUp]int, len(powerUps))
+	for k, v := range powerUps {
+		copied[k] = v
+	}
+	return copied
+}
@@ -0,0 +1,17 @@
+// Package archive keeps data moved out of the primary database, such as
+// finished games' event logs, in cold storage: an S3-compatible bucket.
+package archive
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrNotFound = errors.New("archived object not found")
+
+// Store holds archived objects by key. Put replaces any object stored
+// under the key; Get returns ErrNotFound for a key with none.
+type Store interface {
+	Put(ctx context.Context, key string, body []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
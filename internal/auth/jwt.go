@@ -0,0 +1,87 @@
+// Package auth issues and verifies the JSON Web Tokens (HS256) that
+// registered players authenticate with, and the session tokens that tie a
+// connection to the lobby seat it joined.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// header is the only JOSE header tokens are issued with, and the only one
+// accepted: there's no algorithm negotiation to downgrade.
+var header = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Claims are what a token asserts: the account it was issued to and when
+// it expires, as Unix seconds.
+type Claims struct {
+	Subject   string `json:"sub"` // user ID
+	Username  string `json:"name"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Issuer signs and verifies tokens with a shared secret.
+type Issuer struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewIssuer(secret []byte, ttl time.Duration) *Issuer {
+	return &Issuer{secret: secret, ttl: ttl}
+}
+
+// Issue returns a token for the account, valid for the issuer's TTL from
+// now, and when it expires.
+func (i *Issuer) Issue(userID, username string, now time.Time) (string, time.Time, error) {
+	expiresAt := now.Add(i.ttl)
+	payload, err := json.Marshal(Claims{
+		Subject:   userID,
+		Username:  username,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	signed := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signed + "." + i.sign(signed), expiresAt, nil
+}
+
+// Verify checks a token's signature and expiry and returns its claims.
+func (i *Issuer) Verify(token string, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != header {
+		return nil, ErrInvalidToken
+	}
+	expected := i.sign(parts[0] + "." + parts[1])
+	if !hmac.Equal([]byte(parts[2]), []byte(expected)) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Subject == "" {
+		return nil, ErrInvalidToken
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
+
+func (i *Issuer) sign(signed string) string {
+	mac := hmac.New(sha256.New, i.secret)
+	mac.Write([]byte(signed))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidSession = errors.New("missing or invalid player session")
+
+// Sessions signs the session tokens that hold a player's seat in a lobby.
+// A join returns one, and acting as that player afterwards needs it, so
+// knowing someone's player ID isn't enough to answer or chat as them.
+// Tokens last as long as the lobby does.
+type Sessions struct {
+	key []byte
+}
+
+// NewSessions derives the session key from secret, so session tokens and
+// login tokens signed with the same secret can't stand in for each other.
+func NewSessions(secret []byte) *Sessions {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("player session"))
+	return &Sessions{key: mac.Sum(nil)}
+}
+
+// Issue returns the session token for a player seated in a lobby.
+func (s *Sessions) Issue(lobbyID, playerID string) string {
+	return playerID + "." + s.sign(lobbyID, playerID)
+}
+
+// Verify checks a session token was issued for a player in lobbyID and
+// returns the player's ID.
+func (s *Sessions) Verify(token, lobbyID string) (string, error) {
+	playerID, signature, ok := strings.Cut(token, ".")
+	if !ok || playerID == "" {
+		return "", ErrInvalidSession
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.sign(lobbyID, playerID))) {
+		return "", ErrInvalidSession
+	}
+	return playerID, nil
+}
+
+func (s *Sessions) sign(lobbyID, playerID string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(lobbyID + "\x00" + playerID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
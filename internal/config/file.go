@@ -0,0 +1,234 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"buildprize-game/internal/secrets"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Where a setting's value came from.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceSecrets = "secrets" // a secret provider other than the environment
+)
+
+// Setting is one configuration value as it was loaded.
+type Setting struct {
+	Name   string // the environment variable, e.g. "MAX_LOBBY_SIZE"
+	Value  string
+	Source string
+	Secret bool
+}
+
+// source resolves settings from the environment, then the config file,
+// then their defaults, and remembers every one it resolved.
+type source struct {
+	file     map[string]string // by environment variable name
+	secrets  *secrets.Store
+	settings []Setting
+	errs     []error
+}
+
+// readFile reads a YAML (.yaml, .yml) or TOML (.toml) config file. Keys are
+// the environment variable names in any case, e.g. "max_lobby_size";
+// nested tables join their keys with "_", so chat: {max_length: 200} sets
+// CHAT_MAX_LENGTH. Lists become comma-separated values.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("config file %s: use a .yaml, .yml or .toml file", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	if err := flatten(values, "", raw); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+func flatten(values map[string]string, prefix string, raw map[string]interface{}) error {
+	for key, value := range raw {
+		name := prefix + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+		if table, ok := value.(map[string]interface{}); ok {
+			if err := flatten(values, name+"_", table); err != nil {
+				return err
+			}
+			continue
+		}
+		var text string
+		var err error
+		if list, ok := value.([]interface{}); ok {
+			text, err = joinList(list)
+		} else {
+			text, err = scalar(value)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", strings.ToLower(name), err)
+		}
+		if _, ok := values[name]; ok {
+			return fmt.Errorf("%s is set twice", strings.ToLower(name))
+		}
+		values[name] = text
+	}
+	return nil
+}
+
+func scalar(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("unsupported value %v", value)
+}
+
+func joinList(list []interface{}) (string, error) {
+	items := make([]string, len(list))
+	for i, item := range list {
+		text, err := scalar(item)
+		if err != nil {
+			return "", err
+		}
+		items[i] = text
+	}
+	return strings.Join(items, ","), nil
+}
+
+// lookup returns a setting's value and where it came from.
+func (s *source) lookup(key, defaultValue string) (string, string) {
+	if value := os.Getenv(key); value != "" {
+		return value, SourceEnv
+	}
+	if value, ok := s.file[key]; ok && value != "" {
+		return value, SourceFile
+	}
+	return defaultValue, SourceDefault
+}
+
+func (s *source) record(key, value, from string, secret bool) {
+	s.settings = append(s.settings, Setting{Name: key, Value: value, Source: from, Secret: secret})
+}
+
+func (s *source) getEnv(key, defaultValue string) string {
+	value, from := s.lookup(key, defaultValue)
+	s.record(key, value, from, false)
+	return value
+}
+
+func (s *source) getEnvAsInt(key string, defaultValue int) int {
+	value, from := s.lookup(key, strconv.Itoa(defaultValue))
+	intValue, err := strconv.Atoi(value)
+	if err != nil {
+		s.errs = append(s.errs, fmt.Errorf("%s: %q is not a whole number", key, value))
+		intValue = defaultValue
+	}
+	s.record(key, strconv.Itoa(intValue), from, false)
+	return intValue
+}
+
+func (s *source) getEnvAsBool(key string, defaultValue bool) bool {
+	value, from := s.lookup(key, strconv.FormatBool(defaultValue))
+	boolValue, err := strconv.ParseBool(value)
+	if err != nil {
+		s.errs = append(s.errs, fmt.Errorf("%s: %q is not true or false", key, value))
+		boolValue = defaultValue
+	}
+	s.record(key, strconv.FormatBool(boolValue), from, false)
+	return boolValue
+}
+
+// getSecret reads a secret through the secret providers, falling back on
+// the config file and then the default.
+func (s *source) getSecret(key, defaultValue string) string {
+	fallback, from := defaultValue, SourceDefault
+	if value, ok := s.file[key]; ok && value != "" {
+		fallback, from = value, SourceFile
+	}
+	value := s.secrets.Get(key, fallback)
+	if value != fallback {
+		from = SourceSecrets
+		if os.Getenv(key) == value {
+			from = SourceEnv
+		}
+	}
+	s.record(key, value, from, true)
+	return value
+}
+
+// err reports values that couldn't be parsed and config file keys that
+// aren't settings, which are most likely typos.
+func (s *source) err() error {
+	known := make(map[string]bool, len(s.settings))
+	for _, setting := range s.settings {
+		known[setting.Name] = true
+	}
+	var unknown []string
+	for key := range s.file {
+		if !known[key] {
+			unknown = append(unknown, strings.ToLower(key))
+		}
+	}
+	sort.Strings(unknown)
+	errs := s.errs
+	if len(unknown) > 0 {
+		errs = append(errs, fmt.Errorf("unknown settings in the config file: %s", strings.Join(unknown, ", ")))
+	}
+	return errors.Join(errs...)
+}
+
+// Settings lists every setting as loaded, in the order they were read.
+func (c *Config) Settings() []Setting {
+	return c.settings
+}
+
+// Print writes the effective configuration, one NAME=value line per
+// setting with where it came from. Secrets are masked. With changedOnly,
+// settings left at their defaults are skipped.
+func (c *Config) Print(w io.Writer, changedOnly bool) {
+	for _, setting := range c.settings {
+		if changedOnly && setting.Source == SourceDefault {
+			continue
+		}
+		value := setting.Value
+		if setting.Secret && value != "" {
+			value = "********"
+		}
+		fmt.Fprintf(w, "%s=%s (%s)\n", setting.Name, value, setting.Source)
+	}
+}
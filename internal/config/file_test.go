@@ -0,0 +1,90 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"buildprize-game/internal/config"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// A config file's settings apply unless the environment overrides them,
+// YAML and TOML alike, and the effective config says where each came from.
+func TestConfigFileWithEnvOverrides(t *testing.T) {
+	for _, key := range []string{"STORAGE", "MAX_LOBBY_SIZE", "CHAT_MAX_LENGTH", "IP_DENY_LIST", "ADMIN_TOKEN"} {
+		t.Setenv(key, "")
+	}
+	t.Setenv("MAX_LOBBY_SIZE", "6")
+
+	files := map[string]string{
+		"quiz.yaml": "storage: memory\nmax_lobby_size: 4\nchat:\n  max_length: 200\nip_deny_list: [10.0.0.1, 10.0.0.2]\nadmin_token: s3cret\n",
+		"quiz.toml": "storage = \"memory\"\nmax_lobby_size = 4\nip_deny_list = [\"10.0.0.1\", \"10.0.0.2\"]\nadmin_token = \"s3cret\"\n\n[chat]\nmax_length = 200\n",
+	}
+	for name, content := range files {
+		cfg, err := config.Load(writeConfigFile(t, name, content))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if cfg.StorageBackend != "memory" || cfg.ChatMaxLength != 200 || cfg.IPDenyList != "10.0.0.1,10.0.0.2" || cfg.AdminToken != "s3cret" {
+			t.Fatalf("%s: file settings not applied: %+v", name, cfg)
+		}
+		if cfg.MaxLobbySize != 6 {
+			t.Fatalf("%s: expected MAX_LOBBY_SIZE from the environment, got %d", name, cfg.MaxLobbySize)
+		}
+
+		var out strings.Builder
+		cfg.Print(&out, true)
+		for _, line := range []string{"STORAGE=memory (file)", "MAX_LOBBY_SIZE=6 (env)", "ADMIN_TOKEN=******** (file)"} {
+			if !strings.Contains(out.String(), line+"\n") {
+				t.Fatalf("%s: effective config is missing %q:\n%s", name, line, out.String())
+			}
+		}
+		if strings.Contains(out.String(), "s3cret") || strings.Contains(out.String(), "(default)") {
+			t.Fatalf("%s: effective config shows a secret or a default:\n%s", name, out.String())
+		}
+	}
+}
+
+// Every problem is reported at once: unknown keys, unparsable values and
+// out-of-range or missing settings.
+func TestConfigValidation(t *testing.T) {
+	for _, key := range []string{"STORAGE", "MAX_LOBBY_SIZE", "QUESTION_TIME", "CHALLENGE_MODE", "REDIS_STORAGE_URL", "REDIS_URL"} {
+		t.Setenv(key, "")
+	}
+
+	path := writeConfigFile(t, "quiz.yaml", "storage: redis\nmax_lobby_size: 1\nquestion_time: soon\nchallenge_mode: captcha\nmax_lobby_sise: 4\n")
+	_, err := config.Load(path)
+	if err == nil {
+		t.Fatal("Expected an invalid config file to be rejected")
+	}
+	for _, want := range []string{
+		"max_lobby_sise",
+		"QUESTION_TIME",
+		"MAX_LOBBY_SIZE must be at least 2",
+		"REDIS_STORAGE_URL",
+		"CAPTCHA_VERIFY_URL",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("Expected the error to mention %s, got:\n%v", want, err)
+		}
+	}
+
+	if _, err := config.Load(writeConfigFile(t, "quiz.json", "{}")); err == nil {
+		t.Fatal("Expected an unsupported config file format to be rejected")
+	}
+
+	// Without a file, the defaults are valid
+	if _, err := config.Load(""); err != nil {
+		t.Fatalf("Expected the defaults to be valid: %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Validate checks for settings that are missing, out of range or not one
+// of their options, and reports all of them.
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+	oneOf := func(name, value string, options ...string) {
+		for _, option := range options {
+			if value == option {
+				return
+			}
+		}
+		fail("%s %q: use %s", name, value, strings.Join(quoted(options), ", "))
+	}
+
+	if c.Port == "" && c.ListenAddrs == "" {
+		fail("PORT or LISTEN_ADDRS is required")
+	}
+	oneOf("STORAGE", c.StorageBackend, "postgres", "redis", "memory")
+	if c.StorageBackend == "postgres" && c.DatabaseURL == "" {
+		fail("DATABASE_URL is required with STORAGE=postgres")
+	}
+	if c.StorageBackend == "redis" && c.RedisStorageURL == "" {
+		fail("REDIS_STORAGE_URL or REDIS_URL is required with STORAGE=redis")
+	}
+
+	if c.MaxLobbySize < 2 {
+		fail("MAX_LOBBY_SIZE must be at least 2")
+	}
+	if c.QuestionTime < 1 {
+		fail("QUESTION_TIME must be at least 1 second")
+	}
+	if c.ChatMaxLength < 0 || c.ChatMaxLength > 300 {
+		fail("CHAT_MAX_LENGTH must be at most 300")
+	}
+	if c.WSCompression && (c.WSCompressionLevel < 1 || c.WSCompressionLevel > 9) {
+		fail("WS_COMPRESSION_LEVEL must be 1 to 9")
+	}
+	for _, setting := range []struct {
+		name  string
+		value int
+	}{
+		{"MAX_AUDIENCE_SIZE", c.MaxAudience},
+		{"DEMO_LOBBIES", c.DemoLobbies},
+		{"ANSWER_GRACE_MS", c.AnswerGraceMs},
+		{"TIMER_TICK_MS", c.TimerTickMs},
+		{"WAGER_SECONDS", c.WagerSeconds},
+		{"FINAL_QUESTION_SECONDS", c.FinalQuestionSeconds},
+		{"CHAT_RETENTION_HOURS", c.ChatRetentionHours},
+		{"STREAK_DAILY_XP", c.StreakDailyXP},
+		{"STREAK_DAILY_COINS", c.StreakDailyCoins},
+		{"STREAK_WIN_XP", c.StreakWinXP},
+		{"STREAK_WIN_COINS", c.StreakWinCoins},
+		{"STREAK_BONUS_CAP", c.StreakBonusCap},
+		{"GAME_EVENT_RETENTION_HOURS", c.GameEventRetentionHours},
+		{"CHAT_RATE_LIMIT", c.ChatRateLimit},
+		{"CHAT_RATE_WINDOW_SECONDS", c.ChatRateWindowSeconds},
+		{"REPORT_MUTE_THRESHOLD", c.ReportMuteThreshold},
+		{"REPORT_MUTE_MINUTES", c.ReportMuteMinutes},
+		{"PRIZE_DISPUTE_WINDOW_MINUTES", c.PrizeDisputeWindowMinutes},
+		{"SCHEDULED_START_GRACE_MINUTES", c.ScheduledStartGraceMinutes},
+		{"ABANDONED_LOBBY_MINUTES", c.AbandonedLobbyMinutes},
+		{"ABANDONED_GAME_MINUTES", c.AbandonedGameMinutes},
+		{"LOBBY_IDLE_MINUTES", c.LobbyIdleMinutes},
+		{"LOBBY_IDLE_WARNING_SECONDS", c.LobbyIdleWarningSeconds},
+		{"DIFFICULTY_CALIBRATION_MINUTES", c.DifficultyCalibrationMinutes},
+		{"REDIS_STATE_TTL", c.RedisStateTTL},
+		{"GAME_HOOK_TIMEOUT", c.GameHookTimeout},
+		{"GAME_HOOK_WORKERS", c.GameHookWorkers},
+		{"LOBBY_WEBHOOK_TIMEOUT", c.LobbyWebhookTimeout},
+		{"PAYOUT_TIMEOUT", c.PayoutTimeout},
+		{"AUTH_TOKEN_TTL_HOURS", c.AuthTokenTTLHours},
+		{"SECRETS_REFRESH_INTERVAL", c.SecretsRefreshInterval},
+		{"SHUTDOWN_DRAIN_SECONDS", c.ShutdownDrainSeconds},
+		{"SHUTDOWN_TIMEOUT", c.ShutdownTimeout},
+		{"PUBLIC_API_CACHE_SECONDS", c.PublicAPICacheSeconds},
+	} {
+		if setting.value < 0 {
+			fail("%s can't be negative", setting.name)
+		}
+	}
+
+	oneOf("CHALLENGE_MODE", c.ChallengeMode, "", "pow", "captcha")
+	if c.ChallengeMode == "pow" && (c.PowDifficulty < 1 || c.PowDifficulty > 32) {
+		fail("POW_DIFFICULTY must be 1 to 32")
+	}
+	if c.ChallengeMode == "captcha" && (c.CaptchaVerifyURL == "" || c.CaptchaSecret == "") {
+		fail("CHALLENGE_MODE=captcha requires CAPTCHA_VERIFY_URL and CAPTCHA_SECRET")
+	}
+	if c.BlockedCountries != "" && c.GeoIPHeader == "" && c.GeoIPLookupURL == "" {
+		fail("BLOCKED_COUNTRIES requires GEOIP_HEADER or GEOIP_LOOKUP_URL")
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		fail("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if c.TLSCertFile != "" && c.AutocertDomains != "" {
+		fail("use TLS_CERT_FILE or AUTOCERT_DOMAINS, not both")
+	}
+	if c.AutocertDomains != "" && c.AutocertCacheDir == "" {
+		fail("AUTOCERT_DOMAINS requires AUTOCERT_CACHE_DIR")
+	}
+	if c.HTTPRedirectAddr != "" && !c.TLSEnabled() {
+		fail("HTTP_REDIRECT_ADDR requires TLS_CERT_FILE or AUTOCERT_DOMAINS")
+	}
+	oneOf("PAYOUT_PROVIDER", c.PayoutProvider, "", "stripe", "paypal", "wallet")
+	if c.ArchiveS3Bucket != "" && (c.ArchiveS3Endpoint == "" || c.ArchiveS3AccessKeyID == "" || c.ArchiveS3SecretKey == "") {
+		fail("ARCHIVE_S3_BUCKET requires ARCHIVE_S3_ENDPOINT, ARCHIVE_S3_ACCESS_KEY_ID and ARCHIVE_S3_SECRET_ACCESS_KEY")
+	}
+
+	return errors.Join(errs...)
+}
+
+func quoted(options []string) []string {
+	quoted := make([]string, len(options))
+	for i, option := range options {
+		quoted[i] = fmt.Sprintf("%q", option)
+	}
+	return quoted
+}
+
+// TLSEnabled reports whether the game's listeners serve HTTPS.
+func (c *Config) TLSEnabled() bool {
+	return c.TLSCertFile != "" || c.AutocertDomains != ""
+}
@@ -0,0 +1,155 @@
+// Package cron reads the five-field schedules recurring events are defined
+// with, e.g. "0 20 * * 5" for every Friday at 8pm:
+//
+//	minute (0-59) hour (0-23) day-of-month (1-31) month (1-12) day-of-week (0-7, 0 and 7 are Sunday)
+//
+// Each field is "*", a number, a range "a-b" or a comma-separated list of
+// them, optionally stepped with "/n" ("*/15", "9-17/2"). As in standard cron,
+// when both day fields are restricted a day matching either one runs.
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// searchLimit bounds how far ahead Next looks, so a schedule that never
+// comes round (February 30th) ends the search.
+const searchLimit = 5 * 366 * 24 * time.Hour
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	minutes, hours, days, months, weekdays uint64 // bit n set when n matches
+
+	anyDay, anyWeekday bool
+}
+
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// Parse reads a five-field cron expression.
+func Parse(expr string) (*Schedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("%w: %q needs %d fields", ErrInvalidSchedule, expr, len(fields))
+	}
+	var sets [5]uint64
+	for i, part := range parts {
+		set, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, err
+		}
+		sets[i] = set
+	}
+	// Sunday is both 0 and 7
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+	return &Schedule{
+		minutes:    sets[0],
+		hours:      sets[1],
+		days:       sets[2],
+		months:     sets[3],
+		weekdays:   sets[4],
+		anyDay:     parts[2] == "*",
+		anyWeekday: parts[4] == "*",
+	}, nil
+}
+
+func parseField(spec string, f field) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(spec, ",") {
+		rangeSpec, stepSpec, stepped := strings.Cut(item, "/")
+		step := 1
+		if stepped {
+			n, err := strconv.Atoi(stepSpec)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("%w: bad step in %s %q", ErrInvalidSchedule, f.name, item)
+			}
+			step = n
+		}
+
+		low, high := f.min, f.max
+		if rangeSpec != "*" {
+			lowSpec, highSpec, isRange := strings.Cut(rangeSpec, "-")
+			var err error
+			if low, err = strconv.Atoi(lowSpec); err != nil {
+				return 0, fmt.Errorf("%w: bad %s %q", ErrInvalidSchedule, f.name, item)
+			}
+			high = low
+			if isRange {
+				if high, err = strconv.Atoi(highSpec); err != nil {
+					return 0, fmt.Errorf("%w: bad %s %q", ErrInvalidSchedule, f.name, item)
+				}
+			} else if stepped {
+				high = f.max
+			}
+		}
+		if low < f.min || high > f.max || low > high {
+			return 0, fmt.Errorf("%w: %s %q is outside %d-%d", ErrInvalidSchedule, f.name, item, f.min, f.max)
+		}
+		for n := low; n <= high; n += step {
+			set |= 1 << n
+		}
+	}
+	return set, nil
+}
+
+// Next returns the first time after after that the schedule runs, in loc's
+// wall-clock time, or the zero time if it never does. Times skipped by a
+// daylight saving change don't run.
+func (s *Schedule) Next(after time.Time, loc *time.Location) time.Time {
+	t := after.In(loc).Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(searchLimit)
+	for t.Before(limit) {
+		y, m, d := t.Date()
+		switch {
+		case s.months&(1<<uint(m)) == 0:
+			t = later(t, time.Date(y, m+1, 1, 0, 0, 0, 0, loc))
+		case !s.matchDay(t):
+			t = later(t, time.Date(y, m, d+1, 0, 0, 0, 0, loc))
+		case s.hours&(1<<uint(t.Hour())) == 0:
+			// Counted in elapsed minutes: the wall-clock hour after it may
+			// not exist
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+		case s.minutes&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// later returns next, the start of a later month or day, unless a daylight
+// saving change moved it back to t or before; then it steps a minute on.
+func later(t, next time.Time) time.Time {
+	if next.After(t) {
+		return next
+	}
+	return t.Add(time.Minute)
+}
+
+func (s *Schedule) matchDay(t time.Time) bool {
+	day := s.days&(1<<uint(t.Day())) != 0
+	weekday := s.weekdays&(1<<uint(t.Weekday())) != 0
+	if s.anyDay || s.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
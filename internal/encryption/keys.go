@@ -0,0 +1,134 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrUnknownKey = errors.New("unknown encryption key")
+	ErrNoKeys     = errors.New("no encryption keys configured")
+)
+
+// DataKey is a fresh data encryption key, in plaintext for immediate use and
+// wrapped by the master key for storage next to the ciphertext.
+type DataKey struct {
+	KeyID     string
+	Plaintext []byte
+	Wrapped   []byte
+}
+
+// KeyProvider is the KMS-style interface the envelope helper uses. Master
+// keys never leave the provider; only data keys are wrapped and unwrapped.
+// A provider backed by a remote KMS (AWS KMS, GCP KMS, Vault transit) only
+// needs to implement these two calls.
+type KeyProvider interface {
+	// GenerateDataKey returns a new 256-bit data key wrapped by the current
+	// master key.
+	GenerateDataKey() (*DataKey, error)
+	// DecryptDataKey unwraps a data key with the named master key, which may
+	// be a retired one.
+	DecryptDataKey(keyID string, wrapped []byte) ([]byte, error)
+	// CurrentKeyID names the master key new data keys are wrapped with.
+	CurrentKeyID() string
+}
+
+// LocalKeyProvider keeps master keys in process memory, loaded from
+// configuration. The first key is current; the rest stay available to
+// decrypt data written before a rotation.
+type LocalKeyProvider struct {
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+// ParseLocalKeys reads a comma-separated list of "id:base64key" pairs, e.g.
+// "2024-06:q83v...,2024-01:Zm9v...". Each key must decode to 32 bytes.
+func ParseLocalKeys(list string) (*LocalKeyProvider, error) {
+	provider := &LocalKeyProvider{keys: make(map[string]cipher.AEAD)}
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("key entry %q is not id:base64key", entry)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("key %s must be 32 bytes, got %d", id, len(key))
+		}
+		if _, exists := provider.keys[id]; exists {
+			return nil, fmt.Errorf("duplicate key id %s", id)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		provider.keys[id] = aead
+		if provider.current == "" {
+			provider.current = id
+		}
+	}
+	if provider.current == "" {
+		return nil, ErrNoKeys
+	}
+	return provider, nil
+}
+
+func (p *LocalKeyProvider) CurrentKeyID() string {
+	return p.current
+}
+
+func (p *LocalKeyProvider) GenerateDataKey() (*DataKey, error) {
+	plaintext := make([]byte, 32)
+	if _, err := rand.Read(plaintext); err != nil {
+		return nil, err
+	}
+	wrapped, err := seal(p.keys[p.current], plaintext, []byte(p.current))
+	if err != nil {
+		return nil, err
+	}
+	return &DataKey{KeyID: p.current, Plaintext: plaintext, Wrapped: wrapped}, nil
+}
+
+func (p *LocalKeyProvider) DecryptDataKey(keyID string, wrapped []byte) ([]byte, error) {
+	aead, ok := p.keys[keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return open(aead, wrapped, []byte(keyID))
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts with a random nonce, returned as a prefix of the ciphertext.
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
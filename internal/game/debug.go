@@ -0,0 +1,6 @@
+//go:build debug
+
+package game
+
+// Debug enables invariant checks.
+const Debug = true
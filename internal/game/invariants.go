@@ -0,0 +1,61 @@
+package game
+
+import "fmt"
+
+// Snapshot is the slice of lobby state the invariants are checked against.
+type Snapshot struct {
+	Phase        Phase
+	Round        int
+	MaxRounds    int
+	HasQuestion  bool // a question is set
+	QuestionOpen bool // the question has an end time
+	Paused       bool
+}
+
+// CheckInvariants reports the first rule s breaks, or nil.
+func CheckInvariants(s Snapshot) error {
+	if _, ok := transitions[s.Phase]; !ok {
+		return fmt.Errorf("unknown phase %q", s.Phase)
+	}
+	if s.Paused && !s.Phase.Running() {
+		return fmt.Errorf("paused in phase %s", s.Phase)
+	}
+	if s.Phase == Question {
+		if !s.HasQuestion || !s.QuestionOpen {
+			return fmt.Errorf("phase %s without an open question", s.Phase)
+		}
+		if s.Round < 1 || s.Round > s.MaxRounds {
+			return fmt.Errorf("phase %s in round %d of %d", s.Phase, s.Round, s.MaxRounds)
+		}
+	} else if s.HasQuestion {
+		return fmt.Errorf("question set in phase %s", s.Phase)
+	}
+
+	switch s.Phase {
+	case Waiting:
+		if s.Round != 0 {
+			return fmt.Errorf("phase %s in round %d", s.Phase, s.Round)
+		}
+	case Countdown, Intermission, Wager:
+		if s.Round < 1 || s.Round > s.MaxRounds {
+			return fmt.Errorf("phase %s in round %d of %d", s.Phase, s.Round, s.MaxRounds)
+		}
+	case Results:
+		// The round counter has already moved on to the next round
+		if s.Round < 2 || s.Round > s.MaxRounds+1 {
+			return fmt.Errorf("phase %s in round %d of %d", s.Phase, s.Round, s.MaxRounds)
+		}
+	}
+	return nil
+}
+
+// Assert panics if s breaks an invariant. It only checks in builds tagged
+// debug (go test -tags debug) and compiles to nothing otherwise.
+func Assert(s Snapshot) {
+	if !Debug {
+		return
+	}
+	if err := CheckInvariants(s); err != nil {
+		panic("game invariant violated: " + err.Error())
+	}
+}
@@ -0,0 +1,73 @@
+// Package game defines the lobby lifecycle as an explicit state machine:
+//
+//	waiting → countdown → question → results → intermission → question → … → finished
+//
+// Wager rounds open with a wager phase before their question, entered from
+// countdown or intermission.
+//
+// Every phase change goes through Transition, so a step the lifecycle doesn't
+// allow (answering once results are out, starting twice) fails loudly instead
+// of depending on whichever fields happen to be set.
+package game
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Phase string
+
+const (
+	// Waiting lobbies are open for players to join.
+	Waiting Phase = "waiting"
+	// Countdown runs between the host starting the game and the first question.
+	Countdown Phase = "countdown"
+	// Question is the only phase in which answers are accepted.
+	Question Phase = "question"
+	// Results follows each question while its results are shown.
+	Results Phase = "results"
+	// Intermission is the gap between results and the next question.
+	Intermission Phase = "intermission"
+	// Wager precedes a wager round's question while players stake points on it.
+	Wager Phase = "wager"
+	// Finished games are over for good.
+	Finished Phase = "finished"
+)
+
+var ErrInvalidTransition = errors.New("invalid phase transition")
+
+// transitions lists the phases each phase may move to. Any running phase may
+// jump straight to Finished when a game is force-ended.
+var transitions = map[Phase][]Phase{
+	Waiting:      {Countdown},
+	Countdown:    {Question, Wager, Finished},
+	Question:     {Results, Finished},
+	Results:      {Intermission, Finished},
+	Intermission: {Question, Wager, Finished},
+	Wager:        {Question, Finished},
+	Finished:     nil,
+}
+
+// CanTransition reports whether the lifecycle allows moving from one phase
+// to the other.
+func CanTransition(from, to Phase) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition returns ErrInvalidTransition unless from may move to to.
+func Transition(from, to Phase) error {
+	if !CanTransition(from, to) {
+		return fmt.Errorf("%w: %s → %s", ErrInvalidTransition, from, to)
+	}
+	return nil
+}
+
+// Running reports whether a game is under way: started and not finished.
+func (p Phase) Running() bool {
+	return p != Waiting && p != Finished && p != ""
+}
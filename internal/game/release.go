@@ -0,0 +1,6 @@
+//go:build !debug
+
+package game
+
+// Debug enables invariant checks; build with -tags debug to turn it on.
+const Debug = false
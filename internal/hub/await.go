@@ -0,0 +1,46 @@
+package hub
+
+// seqWaiter is woken with the lobby's sequence number once a lobby-wide
+// event after afterSeq has been broadcast.
+type seqWaiter struct {
+	afterSeq uint64
+	woken    chan uint64
+	done     <-chan struct{}
+}
+
+// Await returns a channel that receives the lobby's sequence number once
+// it's past afterSeq: straight away if it already is, otherwise when the
+// next event is broadcast, after it has been recorded in the event log.
+// Closing done gives up the wait; a waiter nobody reads is dropped on the
+// next event or within a second of done closing.
+func (lh *LobbyHub) Await(afterSeq uint64, done <-chan struct{}) <-chan uint64 {
+	w := seqWaiter{afterSeq: afterSeq, woken: make(chan uint64, 1), done: done}
+	select {
+	case lh.waiting <- w:
+	case <-lh.stopped:
+	case <-done:
+	}
+	return w.woken
+}
+
+// wake sends the current sequence number to the waiters it's now past and
+// keeps the rest; called from run.
+func (lh *LobbyHub) wake() {
+	kept := lh.waiters[:0]
+	for _, w := range lh.waiters {
+		select {
+		case <-w.done:
+			continue
+		default:
+		}
+		if lh.seq > w.afterSeq {
+			w.woken <- lh.seq
+			continue
+		}
+		kept = append(kept, w)
+	}
+	for i := len(kept); i < len(lh.waiters); i++ {
+		lh.waiters[i] = seqWaiter{}
+	}
+	lh.waiters = kept
+}
@@ -0,0 +1,80 @@
+package hub_test
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+)
+
+// Lobby snapshots go out as deltas against the last one queued from the
+// lobby's run loop. Events sent from other goroutines don't move it, and a
+// dropped frame makes the next snapshot full.
+func TestLobbyDeltas(t *testing.T) {
+	gameHub := hub.NewHub()
+	lobby := models.NewLobby("Deltas", 3)
+	lobbyHub := gameHub.CreateLobbyHub(lobby)
+	client := &hub.Client{ID: "delta", LobbyID: lobby.ID, Send: make(chan []byte, 64),
+		Capabilities: &models.ClientCapabilities{DeltaUpdates: true, Images: true}}
+	lobbyHub.Register(client)
+
+	snapshot := func(round int) map[string]interface{} {
+		return map[string]interface{}{"lobby": map[string]interface{}{"id": lobby.ID, "name": "Deltas", "round": round}}
+	}
+	next := func() map[string]interface{} {
+		t.Helper()
+		waitFor(t, func() bool { return len(client.Send) > 0 })
+		var event struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		json.Unmarshal(<-client.Send, &event)
+		return event.Data
+	}
+
+	lobbyHub.Publish(&models.GameEvent{Type: "lobby_updated", LobbyID: lobby.ID, Data: snapshot(1)})
+	if data := next(); data["lobby"] == nil {
+		t.Fatalf("Expected the first snapshot in full, got %v", data)
+	}
+	lobbyHub.Publish(&models.GameEvent{Type: "lobby_updated", LobbyID: lobby.ID, Data: snapshot(2)})
+	if delta, _ := next()["lobby_delta"].(map[string]interface{}); len(delta) != 1 || delta["round"] != 2.0 {
+		t.Fatalf("Expected a delta of the round, got %v", delta)
+	}
+
+	// Sent directly from elsewhere, concurrently with the run loop: the
+	// snapshot goes out in full and deltas stay against round 2
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.Offer(&models.GameEvent{Type: "lobby_invite", LobbyID: lobby.ID, Data: snapshot(9)})
+		}()
+	}
+	wg.Wait()
+	for i := 0; i < 8; i++ {
+		if data := next(); data["lobby"] == nil {
+			t.Fatalf("Expected an offered snapshot in full, got %v", data)
+		}
+	}
+	lobbyHub.Publish(&models.GameEvent{Type: "lobby_updated", LobbyID: lobby.ID, Data: snapshot(2)})
+	if data := next(); data["lobby_delta"] == nil || len(data["lobby_delta"].(map[string]interface{})) != 0 {
+		t.Fatalf("Expected an empty delta against round 2, got %v", data)
+	}
+
+	// A snapshot dropped on a full queue never reaches the client
+	for len(client.Send) < cap(client.Send) {
+		client.Send <- []byte("{}")
+	}
+	if lobbyHub.SendTo(client, &models.GameEvent{Type: "lobby_updated", LobbyID: lobby.ID, Data: snapshot(3)}) {
+		t.Fatal("Expected the snapshot dropped on a full queue")
+	}
+	for len(client.Send) > 0 {
+		<-client.Send
+	}
+	lobbyHub.Publish(&models.GameEvent{Type: "lobby_updated", LobbyID: lobby.ID, Data: snapshot(3)})
+	if data := next(); data["lobby"] == nil {
+		t.Fatalf("Expected a full snapshot after a dropped one, got %v", data)
+	}
+}
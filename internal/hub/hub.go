@@ -12,14 +12,61 @@ import (
 type Hub struct {
 	lobbies map[string]*LobbyHub
 	mu      sync.RWMutex
+
+	// Cross-instance relay; broadcaster is nil when the server runs alone
+	// (see relay.go). mirrors holds lobbies hosted elsewhere that have
+	// connections here and is guarded by mu.
+	relayMu     sync.RWMutex
+	broadcaster Broadcaster
+	commands    CommandHandler
+	mirrors     map[string]*LobbyHub
+
+	// Logged-in connections by account, in a lobby or not (see users.go)
+	usersMu sync.RWMutex
+	users   map[string]map[string]*WebSocketClient
+
+	eventLog EventLog // guarded by mu; nil records nothing
+}
+
+// EventLog records each lobby-wide event of the lobbies hosted here once
+// it's stamped, in sequence order, e.g. to persist them. Record is called
+// from the lobby's run loop, so it must not block.
+type EventLog interface {
+	Record(event *models.GameEvent)
 }
 type LobbyHub struct {
 	lobby      *models.Lobby
 	clients    map[string]*WebSocketClient
 	register   chan *WebSocketClient
 	unregister chan *WebSocketClient
-	broadcast  chan []byte
+	broadcast  chan *models.GameEvent
+	direct     chan directEvent
+	relayed    chan relayedEvent // events from the hosting instance; mirrors only
+	synced     chan chan struct{}
+	waiting    chan seqWaiter
+	resumes    chan resumeRequest // hosted lobbies only
 	mu         sync.RWMutex
+
+	hub      *Hub
+	eventLog EventLog      // nil for mirrors, whose host records their events
+	mirror   bool          // lobby is hosted on another instance
+	stopped  chan struct{} // closed once a mirror is released; nil for hosted lobbies
+
+	// Owned by run(): the loop is the single writer for the lobby, so every
+	// event gets its sequence number and timestamp in delivery order.
+	seq         uint64
+	lastEventAt time.Time
+	waiters     []seqWaiter // long polls waiting for the next event
+	recent      *eventRing  // for reconnecting connections; nil for mirrors
+}
+
+// directEvent is an event addressed to one connection, or to every
+// connection of one player when client is nil.
+type directEvent struct {
+	client    *WebSocketClient
+	playerID  string
+	event     *models.GameEvent
+	delivered chan bool
 }
 
 type WebSocketClient struct {
@@ -28,6 +75,24 @@ type WebSocketClient struct {
 	PlayerID string
 	Send     chan []byte
 	Hub      *LobbyHub
+
+	// Declared at connect; nil for clients that declared none.
+	Capabilities *models.ClientCapabilities
+
+	// The account the connection logged in as at connect; empty for guests.
+	UserID string
+
+	// Last full lobby snapshot queued from the lobby's run loop, which deltas
+	// are computed against; nil after a dropped frame. Owned by the run loop.
+	lastLobby map[string]interface{}
+
+	// Guards closing Send against events queued from outside the lobby's run
+	// loop, which are only sent to logged-in connections
+	sendMu     sync.Mutex
+	sendClosed bool
+
+	stats    sendStats
+	protocol protocolState
 }
 
 type Client = WebSocketClient
@@ -35,7 +100,9 @@ type Client = WebSocketClient
 type LobbyHubInterface interface {
 	Register(client *WebSocketClient)
 	Unregister(client *WebSocketClient)
-	Broadcast(data []byte)
+	Publish(event *models.GameEvent)
+	SendTo(client *WebSocketClient, event *models.GameEvent) bool
+	SendToPlayer(playerID string, event *models.GameEvent) bool
 	GetLobby() *models.Lobby
 	GetClients() map[string]*WebSocketClient
 }
@@ -43,6 +110,8 @@ type LobbyHubInterface interface {
 func NewHub() *Hub {
 	return &Hub{
 		lobbies: make(map[string]*LobbyHub),
+		mirrors: make(map[string]*LobbyHub),
+		users:   make(map[string]map[string]*WebSocketClient),
 	}
 }
 
@@ -61,19 +130,65 @@ func (h *Hub) CreateLobbyHub(lobby *models.Lobby) *LobbyHub {
 		clients:    make(map[string]*WebSocketClient),
 		register:   make(chan *WebSocketClient),
 		unregister: make(chan *WebSocketClient),
-		broadcast:  make(chan []byte),
+		broadcast:  make(chan *models.GameEvent),
+		direct:     make(chan directEvent),
+		synced:     make(chan chan struct{}),
+		waiting:    make(chan seqWaiter),
+		resumes:    make(chan resumeRequest),
+		hub:        h,
+		eventLog:   h.eventLog,
+		recent:     &eventRing{},
 	}
 
 	h.lobbies[lobby.ID] = lobbyHub
 	go lobbyHub.run()
+	h.subscribeCommands(lobby.ID)
 
 	return lobbyHub
 }
 
+// SetEventLog sets where the lobbies created from now on record their
+// events.
+func (h *Hub) SetEventLog(eventLog EventLog) {
+	h.mu.Lock()
+	h.eventLog = eventLog
+	h.mu.Unlock()
+}
+
 func (h *Hub) RemoveLobbyHub(lobbyID string) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	delete(h.lobbies, lobbyID)
+	h.unsubscribe(commandsChannel(lobbyID))
+}
+
+// HubStats is a point-in-time count of what the hub is serving.
+type HubStats struct {
+	Lobbies         int `json:"lobbies"`
+	GamesInProgress int `json:"games_in_progress"`
+	Connections     int `json:"connections"`
+	MirroredLobbies int `json:"mirrored_lobbies,omitempty"` // hosted elsewhere, relayed here
+}
+
+func (h *Hub) Stats() HubStats {
+	var stats HubStats
+	for _, lobbyHub := range h.GetAllLobbies() {
+		stats.Lobbies++
+		stats.Connections += len(lobbyHub.GetClients())
+		lobby := lobbyHub.GetLobby()
+		lobby.Lock()
+		if lobby.Phase.Running() {
+			stats.GamesInProgress++
+		}
+		lobby.Unlock()
+	}
+	h.mu.RLock()
+	for _, mirror := range h.mirrors {
+		stats.MirroredLobbies++
+		stats.Connections += len(mirror.GetClients())
+	}
+	h.mu.RUnlock()
+	return stats
 }
 
 func (h *Hub) GetAllLobbies() map[string]*LobbyHub {
@@ -96,66 +211,225 @@ func (lh *LobbyHub) run() {
 			log.Printf("Player connection %s (player: %s) registered with lobby %s", client.ID, client.PlayerID, lh.lobby.ID)
 
 		case client := <-lh.unregister:
-			lh.mu.Lock()
-			wasRegistered := false
-			if _, ok := lh.clients[client.ID]; ok {
-				delete(lh.clients, client.ID)
-				close(client.Send)
-				wasRegistered = true
-			}
-			remainingConnections := len(lh.clients)
-			lh.mu.Unlock()
-			if wasRegistered {
-				log.Printf("Player connection %s (player: %s) left lobby %s - %d connection(s) remaining", client.ID, client.PlayerID, lh.lobby.ID, remainingConnections)
-			} else {
-				log.Printf("Player connection %s was not registered in lobby %s (already removed?)", client.ID, lh.lobby.ID)
+			if lh.unregisterClient(client) {
+				return
 			}
 
-		case message := <-lh.broadcast:
-			lh.mu.RLock()
-			clientCount := len(lh.clients)
-			log.Printf("LobbyHub: Broadcasting message to %d clients in lobby %s", clientCount, lh.lobby.ID)
-
-			// Collect clients that need to be removed
-			var clientsToRemove []string
-			successCount := 0
-			for clientID, client := range lh.clients {
-				select {
-				case client.Send <- message:
-					successCount++
-					// Log chat messages being sent
-					var msgData map[string]interface{}
-					if err := json.Unmarshal(message, &msgData); err == nil {
-						if msgType, ok := msgData["type"].(string); ok && msgType == "chat_message" {
-							log.Printf("  Sent chat_message to client %s (player: %s)", clientID, client.PlayerID)
-						}
-					}
-				default:
-					// Client's send channel is full, mark for removal
-					log.Printf("  Client %s send channel full, marking for removal", clientID)
-					clientsToRemove = append(clientsToRemove, client.ID)
-				}
+		case event := <-lh.broadcast:
+			lh.stamp(event, !event.Ephemeral)
+			message, err := json.Marshal(event)
+			if err != nil {
+				log.Printf("LobbyHub: Error marshaling %s event for lobby %s: %v", event.Type, lh.lobby.ID, err)
+				continue
+			}
+			if event.Ephemeral {
+				lh.fanOut(message, event.Seq, event.Type, event.SkipPlayers)
+				lh.relay(event.Seq, message, event.SkipPlayers)
+				continue
 			}
-			log.Printf("LobbyHub: Successfully queued message to %d/%d clients", successCount, clientCount)
-			lh.mu.RUnlock()
-
-			if len(clientsToRemove) > 0 {
-				lh.mu.Lock()
-				for _, clientID := range clientsToRemove {
-					if client, ok := lh.clients[clientID]; ok {
-						close(client.Send)
-						delete(lh.clients, clientID)
-					}
-				}
-				lh.mu.Unlock()
+			if lh.eventLog != nil {
+				lh.eventLog.Record(event)
 			}
+			lh.fanOut(message, event.Seq, event.Type, event.SkipPlayers)
+			lh.recent.add(bufferedEvent{seq: event.Seq, message: message, skip: event.SkipPlayers})
+			lh.relay(event.Seq, message, event.SkipPlayers)
+			lh.wake()
+
+		case relayed := <-lh.relayed:
+			// Already stamped by the instance hosting the lobby
+			lh.seq = relayed.Seq
+			lh.fanOut(relayed.Message, relayed.Seq, "", relayed.SkipPlayers)
+
+		case d := <-lh.direct:
+			d.delivered <- lh.deliver(d)
+
+		case req := <-lh.resumes:
+			req.result <- lh.resume(req)
+
+		case done := <-lh.synced:
+			close(done)
+
+		case w := <-lh.waiting:
+			lh.waiters = append(lh.waiters, w)
+			lh.wake()
 
 		case <-ticker.C:
+			lh.wake() // drops the waiters that gave up
 		}
 	}
 }
 
+// unregisterClient drops a connection. It reports true when the lobby was a
+// mirror that lost its last connection and has been released, ending run.
+func (lh *LobbyHub) unregisterClient(client *WebSocketClient) bool {
+	lh.mu.Lock()
+	wasRegistered := false
+	if _, ok := lh.clients[client.ID]; ok {
+		delete(lh.clients, client.ID)
+		client.closeSend()
+		wasRegistered = true
+	}
+	remainingConnections := len(lh.clients)
+	lh.mu.Unlock()
+	if wasRegistered {
+		log.Printf("Player connection %s (player: %s) left lobby %s - %d connection(s) remaining", client.ID, client.PlayerID, lh.lobby.ID, remainingConnections)
+	} else {
+		log.Printf("Player connection %s was not registered in lobby %s (already removed?)", client.ID, lh.lobby.ID)
+	}
+	return lh.mirror && remainingConnections == 0 && lh.hub.releaseMirror(lh)
+}
+
+// fanOut queues an encoded lobby-wide event on every connection but those of
+// the skipped players, dropping connections that have fallen too far behind.
+// Skipped connections that speak protocol version 2 get an event_skipped
+// notice in its place.
+func (lh *LobbyHub) fanOut(message []byte, seq uint64, eventType string, skip []string) {
+	lh.mu.RLock()
+	clientCount := len(lh.clients)
+	log.Printf("LobbyHub: Broadcasting message to %d clients in lobby %s", clientCount, lh.lobby.ID)
+
+	// Collect clients that need to be removed
+	var clientsToRemove []string
+	var notice []byte
+	successCount := 0
+	for clientID, client := range lh.clients {
+		payload := message
+		if skipped(skip, client.PlayerID) {
+			if client.ProtocolVersion() < 2 {
+				continue
+			}
+			if notice == nil {
+				notice = skippedNotice(lh.lobby.ID, seq)
+			}
+			payload = notice
+		}
+		payload, snapshot, err := lobbyPayload(client, payload)
+		if err != nil {
+			log.Printf("  Client %s: error tailoring %s event: %v", clientID, eventType, err)
+			continue
+		}
+		select {
+		case client.Send <- payload:
+			client.sent(snapshot, true)
+			successCount++
+			client.stats.queued.Add(1)
+			lh.checkDegraded(client)
+			// Log chat messages being sent
+			if eventType == "chat_message" {
+				log.Printf("  Sent chat_message to client %s (player: %s)", clientID, client.PlayerID)
+			}
+		default:
+			// Client's send channel is full, mark for removal
+			log.Printf("  Client %s send channel full, marking for removal", clientID)
+			client.sent(snapshot, false)
+			client.stats.dropped.Add(1)
+			clientsToRemove = append(clientsToRemove, client.ID)
+		}
+	}
+	log.Printf("LobbyHub: Successfully queued message to %d/%d clients", successCount, clientCount)
+	lh.mu.RUnlock()
+
+	if len(clientsToRemove) > 0 {
+		lh.mu.Lock()
+		for _, clientID := range clientsToRemove {
+			if client, ok := lh.clients[clientID]; ok {
+				client.closeSend()
+				delete(lh.clients, clientID)
+			}
+		}
+		lh.mu.Unlock()
+	}
+}
+
+func skipped(skip []string, playerID string) bool {
+	for _, id := range skip {
+		if playerID != "" && id == playerID {
+			return true
+		}
+	}
+	return false
+}
+
+// deliver sends a direct event, reporting whether any connection took it.
+func (lh *LobbyHub) deliver(d directEvent) bool {
+	lh.stamp(d.event, false)
+	message, err := json.Marshal(d.event)
+	if err != nil {
+		log.Printf("LobbyHub: Error marshaling %s event for lobby %s: %v", d.event.Type, lh.lobby.ID, err)
+		return false
+	}
+
+	lh.mu.RLock()
+	defer lh.mu.RUnlock()
+	delivered := false
+	for _, client := range lh.clients {
+		if d.client != nil && client.ID != d.client.ID {
+			continue
+		}
+		if d.client == nil && client.PlayerID != d.playerID {
+			continue
+		}
+		payload, snapshot, err := lobbyPayload(client, message)
+		if err != nil {
+			log.Printf("  Client %s: error tailoring %s event: %v", client.ID, d.event.Type, err)
+			continue
+		}
+		select {
+		case client.Send <- payload:
+			client.sent(snapshot, true)
+			delivered = true
+			client.stats.queued.Add(1)
+			lh.checkDegraded(client)
+		default:
+			log.Printf("  Client %s send channel full, %s event not delivered", client.ID, d.event.Type)
+			client.sent(snapshot, false)
+			client.stats.dropped.Add(1)
+		}
+	}
+	return delivered
+}
+
+// encodeData replaces the event's data with its JSON encoding.
+func encodeData(event *models.GameEvent) bool {
+	if _, ok := event.Data.(json.RawMessage); ok {
+		return true
+	}
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		log.Printf("LobbyHub: Error marshaling %s event data: %v", event.Type, err)
+		return false
+	}
+	event.Data = json.RawMessage(data)
+	return true
+}
+
+// stamp assigns the event's sequence number and timestamp. Lobby-wide events
+// advance the sequence, so a gap tells a client it missed a broadcast; events
+// for a single connection carry the current sequence without advancing it.
+// Timestamps never go backwards, even if the wall clock does.
+func (lh *LobbyHub) stamp(event *models.GameEvent, advance bool) {
+	if advance {
+		lh.seq++
+	}
+	event.Seq = lh.seq
+
+	now := models.Now()
+	if now.Before(lh.lastEventAt) {
+		now = lh.lastEventAt
+	}
+	lh.lastEventAt = now
+	event.Timestamp = now
+}
+
 func (lh *LobbyHub) Register(client *WebSocketClient) {
+	lh.addClient(client)
+	select {
+	case lh.register <- client:
+	default:
+	}
+}
+
+func (lh *LobbyHub) addClient(client *WebSocketClient) {
 	log.Printf("Registering player connection %s (player: %s) with lobby %s", client.ID, client.PlayerID, lh.lobby.ID)
 	lh.mu.Lock()
 	if existing, ok := lh.clients[client.ID]; ok {
@@ -163,25 +437,71 @@ func (lh *LobbyHub) Register(client *WebSocketClient) {
 		log.Printf("  Existing client Send channel: %p, New client Send channel: %p", existing.Send, client.Send)
 		if existing.Send != client.Send {
 			log.Printf("  Closing old connection's Send channel")
-			close(existing.Send)
+			existing.closeSend()
 		}
 	}
 	lh.clients[client.ID] = client
 	clientCount := len(lh.clients)
 	lh.mu.Unlock()
 	log.Printf("Lobby %s now has %d registered connection(s)", lh.lobby.ID, clientCount)
+}
+
+func (lh *LobbyHub) Unregister(client *WebSocketClient) {
 	select {
-	case lh.register <- client:
-	default:
+	case lh.unregister <- client:
+	case <-lh.stopped:
 	}
 }
 
-func (lh *LobbyHub) Unregister(client *WebSocketClient) {
-	lh.unregister <- client
+// Publish delivers an event to every connection in the lobby, in the order
+// events are published. The event data is encoded before Publish returns,
+// so callers holding the lobby lock publish a consistent snapshot.
+func (lh *LobbyHub) Publish(event *models.GameEvent) {
+	if !encodeData(event) {
+		return
+	}
+	select {
+	case lh.broadcast <- event:
+	case <-lh.stopped:
+	}
+}
+
+// SendTo delivers an event to one connection, ordered with the lobby's
+// broadcasts. It reports false if the connection is gone or backed up.
+func (lh *LobbyHub) SendTo(client *WebSocketClient, event *models.GameEvent) bool {
+	if !encodeData(event) {
+		return false
+	}
+	return lh.sendDirect(directEvent{client: client, event: event, delivered: make(chan bool, 1)})
+}
+
+// SendToPlayer delivers an event to every connection of a player. It reports
+// false if none of them took it.
+func (lh *LobbyHub) SendToPlayer(playerID string, event *models.GameEvent) bool {
+	if !encodeData(event) {
+		return false
+	}
+	return lh.sendDirect(directEvent{playerID: playerID, event: event, delivered: make(chan bool, 1)})
 }
 
-func (lh *LobbyHub) Broadcast(data []byte) {
-	lh.broadcast <- data
+// Sync returns once every event published before it was called has been
+// stamped, recorded in the event log and queued on the connections.
+func (lh *LobbyHub) Sync() {
+	done := make(chan struct{})
+	select {
+	case lh.synced <- done:
+		<-done
+	case <-lh.stopped:
+	}
+}
+
+func (lh *LobbyHub) sendDirect(d directEvent) bool {
+	select {
+	case lh.direct <- d:
+		return <-d.delivered
+	case <-lh.stopped:
+		return false
+	}
 }
 
 func (lh *LobbyHub) GetLobby() *models.Lobby {
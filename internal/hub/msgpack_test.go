@@ -0,0 +1,57 @@
+package hub_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+
+	"github.com/ugorji/go/codec"
+)
+
+// MessagePack connections are sent every event as MessagePack, including
+// those sent to them directly, and their frames read as the JSON messages
+// they stand for.
+func TestMsgPackFrames(t *testing.T) {
+	handle := &codec.MsgpackHandle{WriteExt: true}
+	var frame []byte
+	codec.NewEncoderBytes(&frame, handle).Encode(map[string]interface{}{
+		"type":     "submit_answer",
+		"lobby_id": "lobby-1",
+		"data":     map[string]interface{}{"answer": 2, "response_time": 1500, "text": "Cheetah"},
+	})
+	message, err := hub.MsgPackToJSON(frame)
+	if err != nil {
+		t.Fatalf("MsgPackToJSON: %v", err)
+	}
+	var msg struct {
+		Type    string                 `json:"type"`
+		LobbyID string                 `json:"lobby_id"`
+		Data    map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(message, &msg); err != nil {
+		t.Fatalf("Unmarshal %s: %v", message, err)
+	}
+	if msg.Type != "submit_answer" || msg.LobbyID != "lobby-1" || msg.Data["answer"] != 2.0 || msg.Data["text"] != "Cheetah" {
+		t.Fatalf("Expected the message as sent, got %+v", msg)
+	}
+	if _, err := hub.MsgPackToJSON([]byte{0xc1}); err == nil {
+		t.Fatal("Expected an error for a malformed frame")
+	}
+
+	client := &hub.Client{ID: "c1", Send: make(chan []byte, 1), Capabilities: &models.ClientCapabilities{MsgPack: true, Images: true}}
+	if !client.Binary() {
+		t.Fatal("Expected a MessagePack connection to be binary")
+	}
+	if !client.Offer(&models.GameEvent{Type: "join_rejected", LobbyID: "lobby-1", Data: map[string]interface{}{"error": "lobby is full"}}) {
+		t.Fatal("Expected the event queued")
+	}
+	var event map[string]interface{}
+	if err := codec.NewDecoderBytes(<-client.Send, handle).Decode(&event); err != nil {
+		t.Fatalf("Expected a MessagePack payload: %v", err)
+	}
+	if event["type"] != "join_rejected" {
+		t.Fatalf("Expected join_rejected, got %v", event["type"])
+	}
+}
@@ -0,0 +1,177 @@
+package hub
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+
+	"buildprize-game/internal/models"
+
+	"github.com/ugorji/go/codec"
+)
+
+// WriteExt selects the current MessagePack spec (str and bin types), which
+// JavaScript decoders expect. Maps decode with string keys, as in JSON.
+var msgpackHandle = &codec.MsgpackHandle{WriteExt: true}
+
+func init() {
+	msgpackHandle.RawToString = true
+	msgpackHandle.MapType = reflect.TypeOf(map[string]interface{}(nil))
+}
+
+// Binary reports whether the connection's frames are MessagePack-encoded
+// binary frames rather than JSON text.
+func (c *WebSocketClient) Binary() bool {
+	return c.Capabilities != nil && c.Capabilities.MsgPack
+}
+
+// Encode tailors a JSON message to the connection the way its events are,
+// for messages written to it directly.
+func (c *WebSocketClient) Encode(message []byte) ([]byte, error) {
+	return payloadFor(c, message)
+}
+
+// Offer sends an event to this connection alone, whether or not it's
+// registered with a lobby, dropping it and reporting false if the
+// connection is backed up or closed.
+func (c *WebSocketClient) Offer(event *models.GameEvent) bool {
+	message, err := json.Marshal(event)
+	if err != nil {
+		return false
+	}
+	payload, err := payloadFor(c, message)
+	if err != nil {
+		log.Printf("Client %s: error tailoring %s event: %v", c.ID, event.Type, err)
+		return false
+	}
+	return c.offer(payload)
+}
+
+// MsgPackToJSON rewrites a MessagePack frame from a client as the JSON
+// message it stands for, so binary and text frames share one message
+// schema and are read the same way.
+func MsgPackToJSON(frame []byte) ([]byte, error) {
+	var doc interface{}
+	if err := codec.NewDecoderBytes(frame, msgpackHandle).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// payloadFor tailors an encoded event to a connection's capabilities, from
+// any goroutine. A lobby snapshot in it goes out in full and isn't what the
+// connection's deltas are computed against; only events from the lobby's
+// run loop, tailored with lobbyPayload, carry those.
+func payloadFor(client *WebSocketClient, message []byte) ([]byte, error) {
+	payload, _, err := tailor(client.Capabilities, message, nil)
+	return payload, err
+}
+
+// lobbyPayload tailors an event on the lobby's run loop, sending a lobby
+// snapshot in it as a delta against the last one queued to the connection.
+// The caller passes the snapshot returned to sent once it knows whether the
+// payload was queued.
+func lobbyPayload(client *WebSocketClient, message []byte) ([]byte, map[string]interface{}, error) {
+	return tailor(client.Capabilities, message, client.lastLobby)
+}
+
+// sent records what became of a lobbyPayload: queued, the connection's next
+// delta is against snapshot; dropped, the client has missed a frame, so the
+// next snapshot goes out in full.
+func (c *WebSocketClient) sent(snapshot map[string]interface{}, queued bool) {
+	if queued {
+		c.lastLobby = snapshot
+	} else {
+		c.lastLobby = nil
+	}
+}
+
+// tailor adapts an encoded event to caps, replacing a lobby snapshot with a
+// delta against base when there is one. It returns the payload and the
+// snapshot the client holds once it's applied the payload. Clients that
+// declared no capabilities get the JSON event unchanged.
+func tailor(caps *models.ClientCapabilities, message []byte, base map[string]interface{}) ([]byte, map[string]interface{}, error) {
+	if caps == nil || (caps.Images && !caps.DeltaUpdates && !caps.MsgPack) {
+		return message, base, nil
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(message, &doc); err != nil {
+		return nil, nil, err
+	}
+	if !caps.Images {
+		stripImages(doc)
+	}
+	snapshot := base
+	if caps.DeltaUpdates {
+		snapshot = applyLobbyDelta(doc, base)
+	}
+
+	if caps.MsgPack {
+		var out []byte
+		if err := codec.NewEncoderBytes(&out, msgpackHandle).Encode(doc); err != nil {
+			return nil, nil, err
+		}
+		return out, snapshot, nil
+	}
+	payload, err := json.Marshal(doc)
+	return payload, snapshot, err
+}
+
+// stripImages removes picture media from every question in the payload.
+// The question text still goes out, flagged so the client can say why.
+func stripImages(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if v["media_type"] == string(models.MediaImage) {
+			delete(v, "media_url")
+			delete(v, "media_type")
+			v["media_omitted"] = true
+		}
+		for _, child := range v {
+			stripImages(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			stripImages(child)
+		}
+	}
+}
+
+// applyLobbyDelta replaces a full lobby snapshot with just the fields that
+// changed since previous, and returns the snapshot, or previous for events
+// without one. Events reach each connection in order, so the client can
+// apply deltas as they arrive.
+func applyLobbyDelta(doc map[string]interface{}, previous map[string]interface{}) map[string]interface{} {
+	data, ok := doc["data"].(map[string]interface{})
+	if !ok {
+		return previous
+	}
+	lobby, ok := data["lobby"].(map[string]interface{})
+	if !ok {
+		return previous
+	}
+	if previous == nil || previous["id"] != lobby["id"] {
+		return lobby
+	}
+
+	changed := make(map[string]interface{})
+	for key, value := range lobby {
+		if !reflect.DeepEqual(previous[key], value) {
+			changed[key] = value
+		}
+	}
+	var removed []string
+	for key := range previous {
+		if _, ok := lobby[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+
+	delete(data, "lobby")
+	data["lobby_delta"] = changed
+	if len(removed) > 0 {
+		data["lobby_removed"] = removed
+	}
+	return lobby
+}
@@ -0,0 +1,57 @@
+package hub
+
+import (
+	"encoding/json"
+	"sync/atomic"
+
+	"buildprize-game/internal/models"
+)
+
+// Message-format versions. Connections that never send a hello speak
+// version 1, the protocol from before the handshake. Version 2 adds
+// acknowledgements for client messages and event_skipped notices, so the
+// sequence numbers a connection sees have no gaps unless it missed events.
+const (
+	ProtocolVersion    = 2
+	MinProtocolVersion = 1
+)
+
+// protocolState is what a connection settled on in its hello. It's written
+// by the connection's read loop and read by the lobby loop, so it's atomic.
+type protocolState struct {
+	version atomic.Int32 // 0 until the hello
+	acks    atomic.Bool
+}
+
+// SetProtocol records the version and options a connection negotiated.
+func (c *WebSocketClient) SetProtocol(version int, acks bool) {
+	c.protocol.version.Store(int32(version))
+	c.protocol.acks.Store(acks)
+}
+
+// ProtocolVersion is the message-format version the connection speaks.
+func (c *WebSocketClient) ProtocolVersion() int {
+	if v := int(c.protocol.version.Load()); v > 0 {
+		return v
+	}
+	return MinProtocolVersion
+}
+
+// Acks reports whether the connection asked for its messages to be
+// acknowledged.
+func (c *WebSocketClient) Acks() bool {
+	return c.protocol.acks.Load()
+}
+
+// skippedNotice is the event_skipped message that stands in for a
+// lobby-wide event withheld from a connection, such as a muted player's
+// chat message.
+func skippedNotice(lobbyID string, seq uint64) []byte {
+	notice, _ := json.Marshal(&models.GameEvent{
+		Type:      "event_skipped",
+		LobbyID:   lobbyID,
+		Seq:       seq,
+		Timestamp: models.Now(),
+	})
+	return notice
+}
@@ -0,0 +1,46 @@
+package hub_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+)
+
+// A lobby-wide event withheld from a player reaches their version 2
+// connections as an event_skipped notice with its seq, so the sequence has
+// no gaps, and is left out for version 1 connections as before.
+func TestSkippedEventNotice(t *testing.T) {
+	gameHub := hub.NewHub()
+	lobby := models.NewLobby("Protocol", 3)
+	lobbyHub := gameHub.CreateLobbyHub(lobby)
+	legacy := &hub.Client{ID: "legacy", LobbyID: lobby.ID, PlayerID: "p1", Send: make(chan []byte, 8)}
+	current := &hub.Client{ID: "current", LobbyID: lobby.ID, PlayerID: "p2", Send: make(chan []byte, 8)}
+	current.SetProtocol(hub.ProtocolVersion, true)
+	lobbyHub.Register(legacy)
+	lobbyHub.Register(current)
+	if legacy.ProtocolVersion() != 1 || legacy.Acks() || current.ProtocolVersion() != 2 || !current.Acks() {
+		t.Fatalf("Unexpected protocols: legacy %d, current %d", legacy.ProtocolVersion(), current.ProtocolVersion())
+	}
+
+	lobbyHub.Publish(&models.GameEvent{Type: "chat_message", LobbyID: lobby.ID, SkipPlayers: []string{"p1", "p2"}})
+	lobbyHub.Publish(&models.GameEvent{Type: "lobby_update", LobbyID: lobby.ID})
+	lobbyHub.Sync()
+
+	var got []string
+	for len(current.Send) > 0 {
+		var event models.GameEvent
+		json.Unmarshal(<-current.Send, &event)
+		got = append(got, event.Type)
+		if event.Seq != uint64(len(got)) {
+			t.Fatalf("Expected seq %d for %s, got %d", len(got), event.Type, event.Seq)
+		}
+	}
+	if len(got) != 2 || got[0] != "event_skipped" || got[1] != "lobby_update" {
+		t.Fatalf("Expected event_skipped then lobby_update, got %v", got)
+	}
+	if len(legacy.Send) != 1 {
+		t.Fatalf("Expected only lobby_update for a version 1 connection, got %d events", len(legacy.Send))
+	}
+}
@@ -0,0 +1,96 @@
+package hub
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisTimeout = 2 * time.Second
+
+// RedisBroadcaster relays lobby traffic over Redis pub/sub. All channels
+// share one subscription connection; go-redis reconnects it and
+// resubscribes on its own after a network failure.
+type RedisBroadcaster struct {
+	client *redis.Client
+	pubsub *redis.PubSub
+
+	mu       sync.RWMutex
+	handlers map[string]func(payload []byte)
+}
+
+// NewRedisBroadcaster connects to the Redis server at url, e.g.
+// "redis://:password@localhost:6379/0".
+func NewRedisBroadcaster(url string) (*RedisBroadcaster, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	client := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	b := &RedisBroadcaster{
+		client:   client,
+		pubsub:   client.Subscribe(context.Background()),
+		handlers: make(map[string]func(payload []byte)),
+	}
+	go b.dispatch()
+	return b, nil
+}
+
+// dispatch hands each message to its channel's handler, one at a time, so
+// per-channel order is kept.
+func (b *RedisBroadcaster) dispatch() {
+	for msg := range b.pubsub.Channel() {
+		b.mu.RLock()
+		handler := b.handlers[msg.Channel]
+		b.mu.RUnlock()
+		if handler != nil {
+			handler([]byte(msg.Payload))
+		}
+	}
+}
+
+func (b *RedisBroadcaster) Publish(channel string, payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	return b.client.Publish(ctx, channel, payload).Err()
+}
+
+func (b *RedisBroadcaster) Subscribe(channel string, handler func(payload []byte)) error {
+	b.mu.Lock()
+	b.handlers[channel] = handler
+	b.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	return b.pubsub.Subscribe(ctx, channel)
+}
+
+func (b *RedisBroadcaster) Unsubscribe(channel string) error {
+	b.mu.Lock()
+	delete(b.handlers, channel)
+	b.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	return b.pubsub.Unsubscribe(ctx, channel)
+}
+
+// Ping checks the connection to Redis.
+func (b *RedisBroadcaster) Ping(ctx context.Context) error {
+	return b.client.Ping(ctx).Err()
+}
+
+func (b *RedisBroadcaster) Close() error {
+	b.pubsub.Close()
+	return b.client.Close()
+}
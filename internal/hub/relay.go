@@ -0,0 +1,205 @@
+package hub
+
+import (
+	"encoding/json"
+	"log"
+
+	"buildprize-game/internal/models"
+)
+
+// Broadcaster carries lobby traffic between server instances, so a lobby
+// hosted on one instance can have connections on any of them. Messages on a
+// channel must reach its handler in the order they were published.
+type Broadcaster interface {
+	Publish(channel string, payload []byte) error
+	Subscribe(channel string, handler func(payload []byte)) error
+	Unsubscribe(channel string) error
+	Close() error
+}
+
+// CommandHandler runs a client message forwarded from another instance
+// against a lobby hosted here. playerID is the player the forwarding
+// connection joined as.
+type CommandHandler func(lobbyID, playerID string, message []byte)
+
+// The instance hosting a lobby publishes its lobby-wide events on the events
+// channel; instances with mirror connections publish client messages on the
+// commands channel for the host to run.
+func eventsChannel(lobbyID string) string   { return "lobby:" + lobbyID + ":events" }
+func commandsChannel(lobbyID string) string { return "lobby:" + lobbyID + ":commands" }
+
+// relayedEvent is a stamped lobby-wide event. Mirrors take the host's
+// sequence number so gaps mean the same thing on every instance.
+type relayedEvent struct {
+	Seq         uint64          `json:"seq"`
+	Message     json.RawMessage `json:"message"`
+	SkipPlayers []string        `json:"skip_players,omitempty"`
+}
+
+type relayedCommand struct {
+	PlayerID string          `json:"player_id"`
+	Message  json.RawMessage `json:"message"`
+}
+
+// Events arriving faster than a mirror's loop can fan them out are dropped
+// rather than stalling every other lobby's relay.
+const relayBuffer = 256
+
+// SetBroadcaster connects the hub to other instances. It must be called
+// before any lobby is created.
+func (h *Hub) SetBroadcaster(b Broadcaster) {
+	h.relayMu.Lock()
+	h.broadcaster = b
+	h.relayMu.Unlock()
+}
+
+// SetCommandHandler sets what runs client messages forwarded to lobbies
+// hosted here.
+func (h *Hub) SetCommandHandler(handler CommandHandler) {
+	h.relayMu.Lock()
+	h.commands = handler
+	h.relayMu.Unlock()
+}
+
+// Relayed reports whether the hub shares lobbies with other instances.
+func (h *Hub) Relayed() bool {
+	return h.getBroadcaster() != nil
+}
+
+func (h *Hub) getBroadcaster() Broadcaster {
+	h.relayMu.RLock()
+	defer h.relayMu.RUnlock()
+	return h.broadcaster
+}
+
+func (h *Hub) subscribeCommands(lobbyID string) {
+	b := h.getBroadcaster()
+	if b == nil {
+		return
+	}
+	err := b.Subscribe(commandsChannel(lobbyID), func(payload []byte) {
+		var cmd relayedCommand
+		if err := json.Unmarshal(payload, &cmd); err != nil {
+			log.Printf("Relay: Bad command for lobby %s: %v", lobbyID, err)
+			return
+		}
+		h.relayMu.RLock()
+		handler := h.commands
+		h.relayMu.RUnlock()
+		if handler != nil {
+			handler(lobbyID, cmd.PlayerID, cmd.Message)
+		}
+	})
+	if err != nil {
+		log.Printf("Relay: Failed to subscribe to commands for lobby %s: %v", lobbyID, err)
+	}
+}
+
+func (h *Hub) unsubscribe(channel string) {
+	if b := h.getBroadcaster(); b != nil {
+		if err := b.Unsubscribe(channel); err != nil {
+			log.Printf("Relay: Failed to unsubscribe from %s: %v", channel, err)
+		}
+	}
+}
+
+// JoinMirror registers a connection for a lobby hosted on another instance.
+// The first connection creates the mirror, which relays the host's events
+// to its connections until the last one leaves. lobby is the stored copy,
+// used for lookups only: the host's state is never changed through it.
+func (h *Hub) JoinMirror(lobby *models.Lobby, client *WebSocketClient) *LobbyHub {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	mirror := h.mirrors[lobby.ID]
+	if mirror == nil {
+		mirror = &LobbyHub{
+			lobby:      lobby,
+			clients:    make(map[string]*WebSocketClient),
+			register:   make(chan *WebSocketClient),
+			unregister: make(chan *WebSocketClient),
+			broadcast:  make(chan *models.GameEvent),
+			direct:     make(chan directEvent),
+			relayed:    make(chan relayedEvent, relayBuffer),
+			synced:     make(chan chan struct{}),
+			waiting:    make(chan seqWaiter),
+			hub:        h,
+			mirror:     true,
+			stopped:    make(chan struct{}),
+		}
+		if err := h.getBroadcaster().Subscribe(eventsChannel(lobby.ID), mirror.receive); err != nil {
+			log.Printf("Relay: Failed to subscribe to events for lobby %s: %v", lobby.ID, err)
+		}
+		h.mirrors[lobby.ID] = mirror
+		go mirror.run()
+		log.Printf("Relay: Mirroring lobby %s hosted on another instance", lobby.ID)
+	}
+	// Registered under the hub lock so the mirror can't be released between
+	// lookup and registration
+	mirror.Register(client)
+	return mirror
+}
+
+// releaseMirror drops a mirror with no connections left, reporting whether
+// it did.
+func (h *Hub) releaseMirror(mirror *LobbyHub) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(mirror.GetClients()) > 0 || h.mirrors[mirror.lobby.ID] != mirror {
+		return false
+	}
+	delete(h.mirrors, mirror.lobby.ID)
+	h.unsubscribe(eventsChannel(mirror.lobby.ID))
+	close(mirror.stopped)
+	log.Printf("Relay: Stopped mirroring lobby %s", mirror.lobby.ID)
+	return true
+}
+
+// receive queues an event published by the lobby's host.
+func (lh *LobbyHub) receive(payload []byte) {
+	var event relayedEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		log.Printf("Relay: Bad event for lobby %s: %v", lh.lobby.ID, err)
+		return
+	}
+	select {
+	case lh.relayed <- event:
+	case <-lh.stopped:
+	default:
+		log.Printf("Relay: Lobby %s mirror is backed up, dropped event %d", lh.lobby.ID, event.Seq)
+	}
+}
+
+// relay publishes a lobby-wide event for mirrors on other instances. It runs
+// in the lobby's loop, so events leave in sequence order.
+func (lh *LobbyHub) relay(seq uint64, message []byte, skip []string) {
+	if lh.mirror {
+		return
+	}
+	b := lh.hub.getBroadcaster()
+	if b == nil {
+		return
+	}
+	payload, err := json.Marshal(relayedEvent{Seq: seq, Message: message, SkipPlayers: skip})
+	if err == nil {
+		err = b.Publish(eventsChannel(lh.lobby.ID), payload)
+	}
+	if err != nil {
+		log.Printf("Relay: Failed to publish event %d for lobby %s: %v", seq, lh.lobby.ID, err)
+	}
+}
+
+// IsMirror reports whether the lobby is hosted on another instance.
+func (lh *LobbyHub) IsMirror() bool {
+	return lh.mirror
+}
+
+// Forward sends a client message from a mirror connection to the instance
+// hosting the lobby.
+func (lh *LobbyHub) Forward(playerID string, message []byte) error {
+	payload, err := json.Marshal(relayedCommand{PlayerID: playerID, Message: message})
+	if err != nil {
+		return err
+	}
+	return lh.hub.getBroadcaster().Publish(commandsChannel(lh.lobby.ID), payload)
+}
@@ -0,0 +1,114 @@
+package hub_test
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+)
+
+// memoryBroadcaster stands in for Redis pub/sub, delivering synchronously.
+type memoryBroadcaster struct {
+	mu       sync.Mutex
+	handlers map[string]func([]byte)
+}
+
+func (b *memoryBroadcaster) Publish(channel string, payload []byte) error {
+	b.mu.Lock()
+	handler := b.handlers[channel]
+	b.mu.Unlock()
+	if handler != nil {
+		handler(payload)
+	}
+	return nil
+}
+
+func (b *memoryBroadcaster) Subscribe(channel string, handler func([]byte)) error {
+	b.mu.Lock()
+	b.handlers[channel] = handler
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *memoryBroadcaster) Unsubscribe(channel string) error {
+	b.mu.Lock()
+	delete(b.handlers, channel)
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *memoryBroadcaster) Close() error { return nil }
+
+// A lobby hosted on one instance relays its events to connections on another
+// and runs the messages they forward.
+func TestLobbyRelayBetweenInstances(t *testing.T) {
+	bus := &memoryBroadcaster{handlers: make(map[string]func([]byte))}
+	host, replica := hub.NewHub(), hub.NewHub()
+	host.SetBroadcaster(bus)
+	replica.SetBroadcaster(bus)
+
+	type forwarded struct{ lobbyID, playerID, msgType string }
+	commands := make(chan forwarded, 1)
+	host.SetCommandHandler(func(lobbyID, playerID string, message []byte) {
+		var msg struct {
+			Type string `json:"type"`
+		}
+		json.Unmarshal(message, &msg)
+		commands <- forwarded{lobbyID, playerID, msg.Type}
+	})
+
+	lobby := models.NewLobby("Relayed", 3)
+	player := lobby.AddPlayer("remote")
+	lobbyHub := host.CreateLobbyHub(lobby)
+
+	// The replica only knows the stored copy
+	stored := models.NewLobby("Relayed", 3)
+	stored.ID = lobby.ID
+	client := &hub.Client{ID: "replica-conn", LobbyID: lobby.ID, PlayerID: player.ID, Send: make(chan []byte, 8)}
+	mirror := replica.JoinMirror(stored, client)
+	if !mirror.IsMirror() || replica.GetLobbyHub(lobby.ID) != nil {
+		t.Fatal("Mirror should be kept apart from hosted lobbies")
+	}
+
+	for i := 0; i < 2; i++ {
+		lobbyHub.Publish(&models.GameEvent{Type: "chat_message", LobbyID: lobby.ID, Data: map[string]interface{}{"n": i}})
+	}
+	for want := uint64(1); want <= 2; want++ {
+		select {
+		case payload := <-client.Send:
+			var event models.GameEvent
+			if err := json.Unmarshal(payload, &event); err != nil {
+				t.Fatalf("Decode relayed event: %v", err)
+			}
+			if event.Type != "chat_message" || event.Seq != want {
+				t.Fatalf("Expected chat_message seq %d, got %s seq %d", want, event.Type, event.Seq)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Relayed event %d never arrived", want)
+		}
+	}
+
+	if err := mirror.Forward(player.ID, []byte(`{"type":"submit_answer","data":{"answer":1}}`)); err != nil {
+		t.Fatalf("Forward: %v", err)
+	}
+	select {
+	case got := <-commands:
+		if got != (forwarded{lobby.ID, player.ID, "submit_answer"}) {
+			t.Fatalf("Unexpected forwarded command %+v", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Forwarded command never reached the host")
+	}
+
+	mirror.Unregister(client)
+	deadline := time.Now().Add(2 * time.Second)
+	for replica.Stats().MirroredLobbies != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Mirror was not released after its last connection left")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,123 @@
+package hub
+
+// ReplayBufferSize is how many of its latest lobby-wide events a hosted
+// lobby keeps for reconnecting connections to catch up on.
+const ReplayBufferSize = 256
+
+// bufferedEvent is a lobby-wide event as it was fanned out.
+type bufferedEvent struct {
+	seq     uint64
+	message []byte
+	skip    []string
+}
+
+// eventRing holds the latest ReplayBufferSize events, oldest first from
+// next. Owned by the lobby's run loop.
+type eventRing struct {
+	events [ReplayBufferSize]bufferedEvent
+	next   int
+	size   int
+}
+
+func (r *eventRing) add(event bufferedEvent) {
+	r.events[r.next] = event
+	r.next = (r.next + 1) % len(r.events)
+	if r.size < len(r.events) {
+		r.size++
+	}
+}
+
+// since returns the buffered events after afterSeq, oldest first, and
+// whether they're all of them.
+func (r *eventRing) since(afterSeq, seq uint64) ([]bufferedEvent, bool) {
+	if afterSeq > seq {
+		// Not a position in this lobby's stream
+		return nil, false
+	}
+	var missed []bufferedEvent
+	for i := 0; i < r.size; i++ {
+		event := r.events[(r.next-r.size+i+len(r.events))%len(r.events)]
+		if event.seq > afterSeq {
+			missed = append(missed, event)
+		}
+	}
+	if len(missed) == 0 {
+		return nil, afterSeq == seq
+	}
+	return missed, missed[0].seq == afterSeq+1
+}
+
+// ResumeResult is how a reconnecting connection caught up.
+type ResumeResult struct {
+	// Events replayed to the connection
+	Replayed int
+	// False when the events it missed were no longer all buffered, or
+	// didn't fit in its queue; the client then needs a fresh snapshot
+	Complete bool
+	// The lobby's sequence number once the connection caught up
+	Seq uint64
+}
+
+type resumeRequest struct {
+	client   *WebSocketClient
+	afterSeq uint64
+	result   chan ResumeResult
+}
+
+// RegisterFrom registers a connection that has seen the lobby's events up
+// to afterSeq and queues the buffered ones it missed ahead of any new ones,
+// so it picks up where it left off. Lobby-wide events withheld from its
+// player are replayed as they were sent: left out, or as event_skipped
+// notices on protocol version 2 connections.
+func (lh *LobbyHub) RegisterFrom(client *WebSocketClient, afterSeq uint64) ResumeResult {
+	if lh.recent == nil {
+		// Mirrors keep no events to replay
+		lh.Register(client)
+		return ResumeResult{}
+	}
+	req := resumeRequest{client: client, afterSeq: afterSeq, result: make(chan ResumeResult, 1)}
+	select {
+	case lh.resumes <- req:
+		return <-req.result
+	case <-lh.stopped:
+		return ResumeResult{}
+	}
+}
+
+// resume registers and catches up a connection from the run loop, where no
+// broadcast can slip in between the two.
+func (lh *LobbyHub) resume(req resumeRequest) ResumeResult {
+	lh.addClient(req.client)
+	result := ResumeResult{Seq: lh.seq}
+	missed, complete := lh.recent.since(req.afterSeq, lh.seq)
+
+	lh.mu.RLock()
+	defer lh.mu.RUnlock()
+	client := req.client
+	for _, event := range missed {
+		payload := event.message
+		if skipped(event.skip, client.PlayerID) {
+			if client.ProtocolVersion() < 2 {
+				continue
+			}
+			payload = skippedNotice(lh.lobby.ID, event.seq)
+		}
+		payload, snapshot, err := lobbyPayload(client, payload)
+		if err != nil {
+			complete = false
+			continue
+		}
+		select {
+		case client.Send <- payload:
+			client.sent(snapshot, true)
+			result.Replayed++
+			client.stats.queued.Add(1)
+		default:
+			client.sent(snapshot, false)
+			client.stats.dropped.Add(1)
+			return result
+		}
+	}
+	result.Complete = complete
+	return result
+}
@@ -0,0 +1,48 @@
+package hub_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+)
+
+// A reconnecting connection is replayed the lobby-wide events after the
+// last seq it saw, in order and ahead of new ones, and told when they're no
+// longer all buffered.
+func TestResumeReplaysMissedEvents(t *testing.T) {
+	gameHub := hub.NewHub()
+	lobby := models.NewLobby("Resume", 3)
+	lobbyHub := gameHub.CreateLobbyHub(lobby)
+	for i := 0; i < 5; i++ {
+		lobbyHub.Publish(&models.GameEvent{Type: "lobby_update", LobbyID: lobby.ID})
+	}
+
+	client := &hub.Client{ID: "back", LobbyID: lobby.ID, Send: make(chan []byte, 16)}
+	result := lobbyHub.RegisterFrom(client, 2)
+	if result.Replayed != 3 || !result.Complete || result.Seq != 5 {
+		t.Fatalf("Expected events 3 to 5 replayed, got %+v", result)
+	}
+	lobbyHub.Publish(&models.GameEvent{Type: "lobby_update", LobbyID: lobby.ID})
+	lobbyHub.Sync()
+	for want := uint64(3); want <= 6; want++ {
+		var event models.GameEvent
+		json.Unmarshal(<-client.Send, &event)
+		if event.Seq != want {
+			t.Fatalf("Expected seq %d, got %d", want, event.Seq)
+		}
+	}
+
+	for i := 0; i < hub.ReplayBufferSize; i++ {
+		lobbyHub.Publish(&models.GameEvent{Type: "lobby_update", LobbyID: lobby.ID})
+	}
+	late := &hub.Client{ID: "late", LobbyID: lobby.ID, Send: make(chan []byte, hub.ReplayBufferSize)}
+	if result := lobbyHub.RegisterFrom(late, 3); result.Complete || result.Replayed != hub.ReplayBufferSize {
+		t.Fatalf("Expected a partial replay of the whole buffer, got %+v", result)
+	}
+	current := &hub.Client{ID: "current", LobbyID: lobby.ID, Send: make(chan []byte, 1)}
+	if result := lobbyHub.RegisterFrom(current, 6+hub.ReplayBufferSize); !result.Complete || result.Replayed != 0 {
+		t.Fatalf("Expected nothing to replay for an up-to-date client, got %+v", result)
+	}
+}
@@ -0,0 +1,136 @@
+package hub
+
+import (
+	"encoding/json"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"buildprize-game/internal/models"
+)
+
+// A connection is degraded once its send queue is half full or a single
+// write takes this long, and recovers once the queue is back under a quarter
+// full with fast writes. The hysteresis keeps a borderline network from
+// flapping between hints.
+const degradedWriteLatency = 500 * time.Millisecond
+
+// sendStats tracks a connection's send queue. The lobby loop counts queued
+// and dropped messages and the connection's write pump records writes, so
+// every field is atomic.
+type sendStats struct {
+	queued       atomic.Int64
+	dropped      atomic.Int64
+	lastWriteNs  atomic.Int64 // duration of the most recent write
+	lastWriteAt  atomic.Int64 // unix nanoseconds
+	degraded     atomic.Bool
+	degradedHint atomic.Int64 // connection_degraded hints sent
+}
+
+// ClientSendStats is a point-in-time view of one connection's send queue.
+type ClientSendStats struct {
+	ClientID           string     `json:"client_id"`
+	LobbyID            string     `json:"lobby_id"`
+	PlayerID           string     `json:"player_id,omitempty"`
+	QueueDepth         int        `json:"queue_depth"`
+	QueueCapacity      int        `json:"queue_capacity"`
+	Queued             int64      `json:"queued"`
+	Dropped            int64      `json:"dropped"`
+	LastWriteLatencyMs float64    `json:"last_write_latency_ms"`
+	LastWriteAt        *time.Time `json:"last_write_at,omitempty"`
+	Degraded           bool       `json:"degraded"`
+	DegradedHints      int64      `json:"degraded_hints"`
+}
+
+// RecordWrite notes how long writing one message to the network took. The
+// connection's write pump calls it after every message.
+func (c *WebSocketClient) RecordWrite(latency time.Duration) {
+	c.stats.lastWriteNs.Store(int64(latency))
+	c.stats.lastWriteAt.Store(time.Now().UnixNano())
+}
+
+// SendStats reports the connection's send queue health.
+func (c *WebSocketClient) SendStats() ClientSendStats {
+	stats := ClientSendStats{
+		ClientID:           c.ID,
+		LobbyID:            c.LobbyID,
+		PlayerID:           c.PlayerID,
+		QueueDepth:         len(c.Send),
+		QueueCapacity:      cap(c.Send),
+		Queued:             c.stats.queued.Load(),
+		Dropped:            c.stats.dropped.Load(),
+		LastWriteLatencyMs: float64(c.stats.lastWriteNs.Load()) / float64(time.Millisecond),
+		Degraded:           c.stats.degraded.Load(),
+		DegradedHints:      c.stats.degradedHint.Load(),
+	}
+	if at := c.stats.lastWriteAt.Load(); at != 0 {
+		t := time.Unix(0, at).UTC()
+		stats.LastWriteAt = &t
+	}
+	return stats
+}
+
+// checkDegraded flags a connection that has fallen behind and tells it so
+// with a connection_degraded event, ahead of anything that would otherwise
+// drop it. It runs in the lobby's loop, after a message was queued.
+func (lh *LobbyHub) checkDegraded(client *WebSocketClient) {
+	depth, capacity := len(client.Send), cap(client.Send)
+	latency := time.Duration(client.stats.lastWriteNs.Load())
+	slow := latency >= degradedWriteLatency
+
+	if !client.stats.degraded.Load() {
+		if depth*2 < capacity && !slow {
+			return
+		}
+		client.stats.degraded.Store(true)
+	} else {
+		if depth*4 < capacity && !slow {
+			client.stats.degraded.Store(false)
+		}
+		return
+	}
+
+	reason := "queue_backlog"
+	if slow {
+		reason = "slow_writes"
+	}
+	log.Printf("LobbyHub: Connection %s (player: %s) in lobby %s degraded: %s, %d/%d queued, last write %s",
+		client.ID, client.PlayerID, lh.lobby.ID, reason, depth, capacity, latency)
+
+	event := &models.GameEvent{
+		Type:    "connection_degraded",
+		LobbyID: lh.lobby.ID,
+		Data: map[string]interface{}{
+			"reason":                reason,
+			"queue_depth":           depth,
+			"queue_capacity":        capacity,
+			"last_write_latency_ms": latency.Milliseconds(),
+			"dropped":               client.stats.dropped.Load(),
+		},
+	}
+	lh.stamp(event, false)
+	message, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	payload, err := payloadFor(client, message)
+	if err != nil {
+		return
+	}
+	select {
+	case client.Send <- payload:
+		client.stats.degradedHint.Add(1)
+	default:
+	}
+}
+
+// SendStats reports every connection's send queue in the lobby.
+func (lh *LobbyHub) SendStats() []ClientSendStats {
+	lh.mu.RLock()
+	defer lh.mu.RUnlock()
+	stats := make([]ClientSendStats, 0, len(lh.clients))
+	for _, client := range lh.clients {
+		stats = append(stats, client.SendStats())
+	}
+	return stats
+}
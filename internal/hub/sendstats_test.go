@@ -0,0 +1,58 @@
+package hub_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+)
+
+// A connection whose queue backs up gets one connection_degraded hint, shows
+// up as degraded in its stats and recovers once it catches up.
+func TestSlowConnectionDegraded(t *testing.T) {
+	gameHub := hub.NewHub()
+	lobby := models.NewLobby("Slow", 3)
+	lobbyHub := gameHub.CreateLobbyHub(lobby)
+	client := &hub.Client{ID: "slow", LobbyID: lobby.ID, Send: make(chan []byte, 8)}
+	lobbyHub.Register(client)
+
+	for i := 0; i < 4; i++ {
+		lobbyHub.Publish(&models.GameEvent{Type: "chat_message", LobbyID: lobby.ID, Data: map[string]interface{}{"n": i}})
+	}
+	// Publish returns once the loop has the event, not once it's queued
+	waitFor(t, func() bool { return client.SendStats().DegradedHints == 1 })
+
+	stats := client.SendStats()
+	if stats.Queued != 4 || stats.DegradedHints != 1 || stats.QueueDepth != 5 {
+		t.Fatalf("Unexpected stats for a backed-up connection: %+v", stats)
+	}
+	var types []string
+	for len(client.Send) > 0 {
+		var event models.GameEvent
+		json.Unmarshal(<-client.Send, &event)
+		types = append(types, event.Type)
+	}
+	if types[len(types)-1] != "connection_degraded" {
+		t.Fatalf("Expected the hint after the backlog, got %v", types)
+	}
+
+	client.RecordWrite(2 * time.Millisecond)
+	lobbyHub.Publish(&models.GameEvent{Type: "chat_message", LobbyID: lobby.ID})
+	waitFor(t, func() bool { return !lobbyHub.SendStats()[0].Degraded })
+	if stats := client.SendStats(); stats.DegradedHints != 1 || stats.LastWriteAt == nil {
+		t.Fatalf("Unexpected stats after recovering: %+v", stats)
+	}
+}
+
+func waitFor(t *testing.T, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatal("Condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
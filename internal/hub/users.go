@@ -0,0 +1,120 @@
+package hub
+
+import (
+	"encoding/json"
+	"log"
+
+	"buildprize-game/internal/models"
+)
+
+// Logged-in connections are also tracked by account, whether or not they've
+// joined a lobby, so players can be reached outside their lobby: friend
+// invitations and friends' presence. Only connections to this instance are
+// known.
+
+// ConnectUser counts a logged-in connection towards its account being
+// online, and reports whether it's the account's first.
+func (h *Hub) ConnectUser(client *WebSocketClient) bool {
+	if client.UserID == "" {
+		return false
+	}
+	h.usersMu.Lock()
+	defer h.usersMu.Unlock()
+	conns := h.users[client.UserID]
+	if conns == nil {
+		conns = make(map[string]*WebSocketClient)
+		h.users[client.UserID] = conns
+	}
+	conns[client.ID] = client
+	return len(conns) == 1
+}
+
+// DisconnectUser drops a logged-in connection, and reports whether it was
+// the account's last.
+func (h *Hub) DisconnectUser(client *WebSocketClient) bool {
+	if client.UserID == "" {
+		return false
+	}
+	h.usersMu.Lock()
+	defer h.usersMu.Unlock()
+	conns, ok := h.users[client.UserID]
+	if !ok {
+		return false
+	}
+	if _, ok := conns[client.ID]; !ok {
+		return false
+	}
+	delete(conns, client.ID)
+	if len(conns) > 0 {
+		return false
+	}
+	delete(h.users, client.UserID)
+	return true
+}
+
+// UserOnline reports whether an account has a connection open.
+func (h *Hub) UserOnline(userID string) bool {
+	h.usersMu.RLock()
+	defer h.usersMu.RUnlock()
+	return len(h.users[userID]) > 0
+}
+
+// SendToUser delivers an event to every open connection of an account,
+// reporting false if none of them took it. The event carries no sequence
+// number, as it isn't part of any lobby's stream.
+func (h *Hub) SendToUser(userID string, event *models.GameEvent) bool {
+	if !encodeData(event) {
+		return false
+	}
+	event.Timestamp = models.Now()
+	message, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Hub: Error marshaling %s event for user %s: %v", event.Type, userID, err)
+		return false
+	}
+
+	h.usersMu.RLock()
+	defer h.usersMu.RUnlock()
+	delivered := false
+	for _, client := range h.users[userID] {
+		payload, err := payloadFor(client, message)
+		if err != nil {
+			log.Printf("  Client %s: error tailoring %s event: %v", client.ID, event.Type, err)
+			continue
+		}
+		if client.offer(payload) {
+			delivered = true
+			client.stats.queued.Add(1)
+		} else {
+			client.stats.dropped.Add(1)
+		}
+	}
+	return delivered
+}
+
+// offer queues a payload without blocking, reporting false if the
+// connection's queue is full or already closed.
+func (c *WebSocketClient) offer(payload []byte) bool {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if c.sendClosed {
+		return false
+	}
+	select {
+	case c.Send <- payload:
+		return true
+	default:
+		return false
+	}
+}
+
+// closeSend closes the connection's queue, which ends its writer. Closing
+// it again does nothing.
+func (c *WebSocketClient) closeSend() {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if !c.sendClosed {
+		c.sendClosed = true
+		close(c.Send)
+	}
+}
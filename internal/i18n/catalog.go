@@ -0,0 +1,183 @@
+// Package i18n holds the server's localized string catalogs: the text for
+// system messages and error codes the server sends, so clients can show them
+// in a lobby's language without shipping their own translations.
+package i18n
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// DefaultLanguage is used for lobbies that don't set one, and fills in any
+// message a catalog lacks.
+const DefaultLanguage = "en"
+
+var ErrUnsupportedLanguage = errors.New("unsupported language")
+
+// Catalog maps message keys to text. Text may hold {placeholders} for the
+// client to fill in, e.g. {username} for the player an event is about.
+type Catalog map[string]string
+
+// Keys are the code a message describes, prefixed by where it comes from:
+// chat.<ChatRejection code>, connection.<connection_degraded reason>, or
+// event.<event type> for system messages about lobby events.
+var catalogs = map[string]Catalog{
+	"en": {
+		"chat.empty":                 "Your message is empty.",
+		"chat.too_long":              "Your message is too long.",
+		"chat.rate_limited":          "You're sending messages too quickly. Try again in a moment.",
+		"chat.muted":                 "You are muted in this lobby.",
+		"connection.queue_backlog":   "Your connection is falling behind.",
+		"connection.slow_writes":     "Your connection is slow.",
+		"event.player_joined":        "{username} joined the lobby.",
+		"event.player_left":          "{username} left the lobby.",
+		"event.player_muted":         "{username} was muted by the host.",
+		"event.player_muted_reports": "{username} was muted after reports from other players.",
+		"event.player_unmuted":       "{username} can chat again.",
+		"event.game_started":         "The game has started!",
+		"event.game_paused":          "Game paused by the host.",
+		"event.game_resumed":         "The game has resumed.",
+		"event.game_ended":           "Game over!",
+		"event.answer_progress":      "{answered} of {total} players answered",
+		"event.question_results":     "Time's up!",
+		"event.rsvp_attendance":      "{joined} of {expected} players who said yes have joined",
+		"event.start_held":           "Waiting for more of the players who said yes before starting.",
+		"event.start_reminder":       "The game starts in {starts_in_seconds} seconds.",
+		"event.game_cancelled":       "Not enough players joined, so the game was cancelled.",
+	},
+	"es": {
+		"chat.empty":                 "Tu mensaje está vacío.",
+		"chat.too_long":              "Tu mensaje es demasiado largo.",
+		"chat.rate_limited":          "Estás enviando mensajes demasiado rápido. Inténtalo de nuevo en un momento.",
+		"chat.muted":                 "Estás silenciado en esta sala.",
+		"connection.queue_backlog":   "Tu conexión se está quedando atrás.",
+		"connection.slow_writes":     "Tu conexión es lenta.",
+		"event.player_joined":        "{username} se unió a la sala.",
+		"event.player_left":          "{username} salió de la sala.",
+		"event.player_muted":         "El anfitrión silenció a {username}.",
+		"event.player_muted_reports": "{username} fue silenciado tras las denuncias de otros jugadores.",
+		"event.player_unmuted":       "{username} puede volver a chatear.",
+		"event.game_started":         "¡La partida ha comenzado!",
+		"event.game_paused":          "El anfitrión pausó la partida.",
+		"event.game_resumed":         "La partida se ha reanudado.",
+		"event.game_ended":           "¡Fin de la partida!",
+		"event.answer_progress":      "{answered} de {total} jugadores han respondido",
+		"event.question_results":     "¡Se acabó el tiempo!",
+		"event.rsvp_attendance":      "Se han unido {joined} de los {expected} jugadores que confirmaron",
+		"event.start_held":           "Esperando a más jugadores que confirmaron antes de empezar.",
+		"event.start_reminder":       "La partida empieza en {starts_in_seconds} segundos.",
+		"event.game_cancelled":       "No se unieron suficientes jugadores, así que la partida se canceló.",
+	},
+	"fr": {
+		"chat.empty":                 "Votre message est vide.",
+		"chat.too_long":              "Votre message est trop long.",
+		"chat.rate_limited":          "Vous envoyez des messages trop vite. Réessayez dans un instant.",
+		"chat.muted":                 "Vous êtes en sourdine dans ce salon.",
+		"connection.queue_backlog":   "Votre connexion prend du retard.",
+		"connection.slow_writes":     "Votre connexion est lente.",
+		"event.player_joined":        "{username} a rejoint le salon.",
+		"event.player_left":          "{username} a quitté le salon.",
+		"event.player_muted":         "L'hôte a mis {username} en sourdine.",
+		"event.player_muted_reports": "{username} a été mis en sourdine après des signalements d'autres joueurs.",
+		"event.player_unmuted":       "{username} peut de nouveau discuter.",
+		"event.game_started":         "La partie a commencé !",
+		"event.game_paused":          "Partie mise en pause par l'hôte.",
+		"event.game_resumed":         "La partie a repris.",
+		"event.game_ended":           "Partie terminée !",
+		"event.answer_progress":      "{answered} joueurs sur {total} ont répondu",
+		"event.question_results":     "Temps écoulé !",
+		"event.rsvp_attendance":      "{joined} des {expected} joueurs ayant confirmé sont là",
+		"event.start_held":           "En attente d'autres joueurs ayant confirmé avant de commencer.",
+		"event.start_reminder":       "La partie commence dans {starts_in_seconds} secondes.",
+		"event.game_cancelled":       "Pas assez de joueurs ont rejoint, la partie a été annulée.",
+	},
+	"de": {
+		"chat.empty":                 "Deine Nachricht ist leer.",
+		"chat.too_long":              "Deine Nachricht ist zu lang.",
+		"chat.rate_limited":          "Du sendest Nachrichten zu schnell. Versuche es gleich noch einmal.",
+		"chat.muted":                 "Du bist in dieser Lobby stummgeschaltet.",
+		"connection.queue_backlog":   "Deine Verbindung kommt nicht hinterher.",
+		"connection.slow_writes":     "Deine Verbindung ist langsam.",
+		"event.player_joined":        "{username} ist der Lobby beigetreten.",
+		"event.player_left":          "{username} hat die Lobby verlassen.",
+		"event.player_muted":         "{username} wurde vom Host stummgeschaltet.",
+		"event.player_muted_reports": "{username} wurde nach Meldungen anderer Spieler stummgeschaltet.",
+		"event.player_unmuted":       "{username} kann wieder chatten.",
+		"event.game_started":         "Das Spiel hat begonnen!",
+		"event.game_paused":          "Spiel vom Host pausiert.",
+		"event.game_resumed":         "Das Spiel geht weiter.",
+		"event.game_ended":           "Spiel vorbei!",
+		"event.answer_progress":      "{answered} von {total} Spielern haben geantwortet",
+		"event.question_results":     "Die Zeit ist um!",
+		"event.rsvp_attendance":      "{joined} von {expected} zugesagten Spielern sind da",
+		"event.start_held":           "Warte vor dem Start auf weitere Spieler, die zugesagt haben.",
+		"event.start_reminder":       "Das Spiel beginnt in {starts_in_seconds} Sekunden.",
+		"event.game_cancelled":       "Es sind nicht genug Spieler beigetreten, daher wurde das Spiel abgesagt.",
+	},
+	"pt": {
+		"chat.empty":                 "Sua mensagem está vazia.",
+		"chat.too_long":              "Sua mensagem é longa demais.",
+		"chat.rate_limited":          "Você está enviando mensagens rápido demais. Tente de novo em instantes.",
+		"chat.muted":                 "Você está silenciado nesta sala.",
+		"connection.queue_backlog":   "Sua conexão está ficando para trás.",
+		"connection.slow_writes":     "Sua conexão está lenta.",
+		"event.player_joined":        "{username} entrou na sala.",
+		"event.player_left":          "{username} saiu da sala.",
+		"event.player_muted":         "O anfitrião silenciou {username}.",
+		"event.player_muted_reports": "{username} foi silenciado após denúncias de outros jogadores.",
+		"event.player_unmuted":       "{username} pode conversar de novo.",
+		"event.game_started":         "A partida começou!",
+		"event.game_paused":          "Partida pausada pelo anfitrião.",
+		"event.game_resumed":         "A partida foi retomada.",
+		"event.game_ended":           "Fim de jogo!",
+		"event.answer_progress":      "{answered} de {total} jogadores responderam",
+		"event.question_results":     "O tempo acabou!",
+		"event.rsvp_attendance":      "{joined} de {expected} jogadores confirmados entraram",
+		"event.start_held":           "Aguardando mais jogadores confirmados antes de começar.",
+		"event.start_reminder":       "A partida começa em {starts_in_seconds} segundos.",
+		"event.game_cancelled":       "Não entraram jogadores suficientes, então a partida foi cancelada.",
+	},
+}
+
+// Languages returns the languages with a catalog, in alphabetical order.
+func Languages() []string {
+	languages := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Resolve maps a language tag such as "pt-BR" or "FR" to the catalog
+// language serving it, trying the base language when the region has none.
+func Resolve(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if _, ok := catalogs[tag]; ok {
+		return tag, nil
+	}
+	if base, _, found := strings.Cut(tag, "-"); found {
+		if _, ok := catalogs[base]; ok {
+			return base, nil
+		}
+	}
+	return "", ErrUnsupportedLanguage
+}
+
+// Lookup returns a copy of lang's catalog, with DefaultLanguage text for any
+// key it doesn't translate, so every key is always present.
+func Lookup(lang string) (Catalog, error) {
+	resolved, err := Resolve(lang)
+	if err != nil {
+		return nil, err
+	}
+	catalog := make(Catalog, len(catalogs[DefaultLanguage]))
+	for key, text := range catalogs[DefaultLanguage] {
+		catalog[key] = text
+	}
+	for key, text := range catalogs[resolved] {
+		catalog[key] = text
+	}
+	return catalog, nil
+}
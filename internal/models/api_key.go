@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scopes an API key can be issued with.
+const (
+	// The admin API's reads, and every admin request
+	ScopeAdminRead  = "admin:read"
+	ScopeAdminWrite = "admin:write"
+	// Issuing, rotating and revoking API keys. A key with it can issue
+	// itself any other scope
+	ScopeAPIKeys = "api_keys"
+	// Creating lobbies that register a webhook_url
+	ScopeWebhooks = "webhooks"
+	// The gRPC API: managing lobbies, playing and streaming their events
+	ScopeLobbies = "lobbies"
+)
+
+// APIKeyScopes lists every scope, in the order they're documented.
+var APIKeyScopes = []string{ScopeAdminRead, ScopeAdminWrite, ScopeAPIKeys, ScopeWebhooks, ScopeLobbies}
+
+// APIKey lets an integration call the admin API, or register lobby
+// webhooks, as itself rather than with the shared admin token. Only the
+// SHA-256 of its secret is kept; the key is shown once, when it's issued or
+// rotated.
+type APIKey struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	Hash   string   `json:"hash"`
+	// The hash from before the last rotation, still accepted until
+	// PreviousExpiresAt so callers can switch over
+	PreviousHash      string     `json:"previous_hash,omitempty"`
+	PreviousExpiresAt *time.Time `json:"previous_expires_at,omitempty"`
+
+	CreatedBy string     `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	RotatedAt *time.Time `json:"rotated_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+func NewAPIKey(name string, scopes []string, createdBy string) *APIKey {
+	return &APIKey{
+		ID:        uuid.New().String(),
+		Name:      name,
+		Scopes:    scopes,
+		CreatedBy: createdBy,
+		CreatedAt: Now(),
+	}
+}
+
+// HasScope reports whether the key was issued scope. admin:write includes
+// admin:read.
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || (s == ScopeAdminWrite && scope == ScopeAdminRead) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidScope reports whether scope is one keys can be issued.
+func ValidScope(scope string) bool {
+	for _, s := range APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
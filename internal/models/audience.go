@@ -0,0 +1,27 @@
+package models
+
+// AudienceMember is a spectator who answers along in an audience lobby.
+// Members aren't lobby players: their answers are only aggregated, and their
+// scores only surface in shout-outs.
+type AudienceMember struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Score    int    `json:"score"`
+	Answered int    `json:"answered"`
+}
+
+// AudienceScore is one audience member named in a shout-out.
+type AudienceScore struct {
+	Username string `json:"username"`
+	Score    int    `json:"score"`
+}
+
+// AudienceSummary aggregates the audience's answers to one round.
+type AudienceSummary struct {
+	Round          int             `json:"round"`
+	Members        int             `json:"members"`
+	Answered       int             `json:"answered"`
+	CorrectPercent float64         `json:"correct_percent"`
+	OptionPercents []float64       `json:"option_percents,omitempty"` // share of answers choosing each option
+	TopScorers     []AudienceScore `json:"top_scorers,omitempty"`     // overall, when shout-outs are enabled
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Actions recorded in the admin audit log.
+const (
+	AdminForceEnd           = "force_end"
+	AdminCancelGame         = "cancel_game"
+	AdminRecomputeScores    = "recompute_scores"
+	AdminResolveDispute     = "resolve_dispute"
+	AdminCreateScoring      = "create_scoring_config"
+	AdminActivateScoring    = "activate_scoring_config"
+	AdminCalibrateQuestions = "calibrate_questions"
+	AdminCreateEvent        = "create_recurring_event"
+	AdminDeleteEvent        = "delete_recurring_event"
+	AdminCreateSandbox      = "create_sandbox_lobby"
+	AdminAddTestPlayer      = "add_test_player"
+	AdminInjectAnswer       = "inject_answer"
+	AdminRetryPayout        = "retry_payout"
+	AdminCreateAPIKey       = "create_api_key"
+	AdminRotateAPIKey       = "rotate_api_key"
+	AdminRevokeAPIKey       = "revoke_api_key"
+)
+
+// AdminAction records a privileged change made through the admin API: who
+// made it, what it acted on and when.
+type AdminAction struct {
+	ID         int64                  `json:"id"`
+	Action     string                 `json:"action"`
+	Actor      string                 `json:"actor"`
+	Target     string                 `json:"target"` // e.g. the lobby ID for lobby actions
+	Details    map[string]interface{} `json:"details,omitempty"`
+	RemoteAddr string                 `json:"remote_addr,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// AdminActionFilter selects audit log entries; empty fields match any.
+type AdminActionFilter struct {
+	Action string
+	Actor  string
+	Target string
+}
+
+// Matches reports whether the entry is one the filter selects.
+func (f AdminActionFilter) Matches(action *AdminAction) bool {
+	return (f.Action == "" || action.Action == f.Action) &&
+		(f.Actor == "" || action.Actor == f.Actor) &&
+		(f.Target == "" || action.Target == f.Target)
+}
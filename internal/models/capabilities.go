@@ -0,0 +1,29 @@
+package models
+
+import "strings"
+
+// ClientCapabilities are the optional features a client declares when it
+// connects. The server tailors each connection's payloads to them.
+type ClientCapabilities struct {
+	DeltaUpdates bool `json:"supports_delta_updates"` // lobby snapshots after the first are sent as changed fields only
+	MsgPack      bool `json:"supports_msgpack"`       // events are MessagePack-encoded binary frames instead of JSON text
+	Images       bool `json:"supports_images"`        // picture questions are sent with their media
+}
+
+// ParseCapabilities reads a comma-separated capability list such as
+// "supports_images,supports_msgpack". Unknown names are ignored so newer
+// clients can connect to older servers.
+func ParseCapabilities(list string) *ClientCapabilities {
+	caps := &ClientCapabilities{}
+	for _, name := range strings.Split(list, ",") {
+		switch strings.TrimSpace(name) {
+		case "supports_delta_updates":
+			caps.DeltaUpdates = true
+		case "supports_msgpack":
+			caps.MsgPack = true
+		case "supports_images":
+			caps.Images = true
+		}
+	}
+	return caps
+}
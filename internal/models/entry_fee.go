@@ -0,0 +1,100 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// EntryPayment is a completed payment of a lobby's entry fee, and the seat
+// it paid for once it has been used to join.
+type EntryPayment struct {
+	PaymentID string    `json:"payment_id"`
+	Amount    int64     `json:"amount"`
+	PlayerID  string    `json:"player_id,omitempty"`
+	Username  string    `json:"username,omitempty"`
+	PaidAt    time.Time `json:"paid_at"`
+}
+
+// RecordEntryPayment keeps a completed entry payment, returning the one
+// already kept under its ID if there is one.
+func (l *Lobby) RecordEntryPayment(paymentID string, amount int64) *EntryPayment {
+	if payment := l.EntryPayments[paymentID]; payment != nil {
+		return payment
+	}
+	if l.EntryPayments == nil {
+		l.EntryPayments = make(map[string]*EntryPayment)
+	}
+	payment := &EntryPayment{PaymentID: paymentID, Amount: amount, PaidAt: Now()}
+	l.EntryPayments[paymentID] = payment
+	return payment
+}
+
+// SeatEntryPayment ties an entry payment to the player it let in. The first
+// time the payment is used, the entry fee is added to the prize pool; a
+// player who left and comes back with the same payment doesn't add it
+// again. The pool is replaced rather than changed, since views of it may be
+// read outside the lock.
+func (l *Lobby) SeatEntryPayment(payment *EntryPayment, player *Player) {
+	if payment.PlayerID == "" && l.PrizePool != nil {
+		pool := *l.PrizePool
+		pool.Amount += l.EntryFee
+		l.PrizePool = &pool
+	}
+	payment.PlayerID = player.ID
+	payment.Username = player.Username
+}
+
+// RefundStatus is where the refund of an entry payment stands.
+type RefundStatus string
+
+const (
+	RefundPending  RefundStatus = "pending" // owed; to be sent, or sent without an answer
+	RefundRefunded RefundStatus = "refunded"
+	RefundFailed   RefundStatus = "failed" // refused by the provider; to be settled by hand
+)
+
+// EntryRefund is an entry payment owed back because its lobby closed
+// without playing the game out. It's kept apart from the lobby so it
+// outlives it, and retried until the provider has refunded the payment.
+type EntryRefund struct {
+	LobbyID   string       `json:"lobby_id"`
+	PaymentID string       `json:"payment_id"`
+	Amount    int64        `json:"amount"`
+	Currency  string       `json:"currency"`
+	PlayerID  string       `json:"player_id,omitempty"`
+	Username  string       `json:"username,omitempty"`
+	Reason    string       `json:"reason"`   // why the lobby closed, e.g. "cancelled"
+	Provider  string       `json:"provider"` // the payment provider it was paid through
+	Status    RefundStatus `json:"status"`
+	Reference string       `json:"reference,omitempty"` // the provider's refund
+	Attempts  int          `json:"attempts"`
+	Error     string       `json:"error,omitempty"` // the last attempt's, if it failed
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// EntryRefunds lists the refunds owed for a lobby's entry payments. Call
+// with the lobby locked.
+func (l *Lobby) EntryRefunds(reason string) []*EntryRefund {
+	refunds := make([]*EntryRefund, 0, len(l.EntryPayments))
+	now := Now()
+	for _, payment := range l.EntryPayments {
+		refund := &EntryRefund{
+			LobbyID:   l.ID,
+			PaymentID: payment.PaymentID,
+			Amount:    payment.Amount,
+			PlayerID:  payment.PlayerID,
+			Username:  payment.Username,
+			Reason:    reason,
+			Status:    RefundPending,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if l.PrizePool != nil {
+			refund.Currency = l.PrizePool.Currency
+		}
+		refunds = append(refunds, refund)
+	}
+	sort.Slice(refunds, func(i, j int) bool { return refunds[i].PaymentID < refunds[j].PaymentID })
+	return refunds
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecurringEvent is a game played on a schedule, e.g. Friday Night Trivia
+// every week at 8pm. Ahead of each start a scheduled lobby is opened for it
+// with the event's settings.
+type RecurringEvent struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Five-field cron expression in Timezone's wall-clock time, e.g.
+	// "0 20 * * 5" (see internal/cron)
+	Schedule string `json:"schedule"`
+	Timezone string `json:"timezone"`
+	// The lobby opens this long before each start
+	LeadMinutes int `json:"lead_minutes"`
+
+	// Settings of the lobbies it opens
+	MaxRounds  int    `json:"max_rounds"`
+	MaxPlayers int    `json:"max_players,omitempty"`
+	Topic      string `json:"topic,omitempty"`
+	Language   string `json:"language,omitempty"`
+	RSVPQuorum int    `json:"rsvp_quorum,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+
+	// The latest start a lobby was opened for, and that lobby
+	LastStartsAt *time.Time `json:"last_starts_at,omitempty"`
+	LastLobbyID  string     `json:"last_lobby_id,omitempty"`
+}
+
+func NewRecurringEvent(name, schedule string) *RecurringEvent {
+	return &RecurringEvent{
+		ID:        uuid.New().String(),
+		Name:      name,
+		Schedule:  schedule,
+		Timezone:  DefaultTimezone,
+		CreatedAt: Now(),
+	}
+}
+
+// Occurrence is one upcoming start of a recurring event, with the lobby
+// opened for it once there is one.
+type Occurrence struct {
+	EventID  string    `json:"event_id"`
+	Name     string    `json:"name"`
+	StartsAt time.Time `json:"starts_at"`
+	Timezone string    `json:"timezone"`
+	Topic    string    `json:"topic,omitempty"`
+	LobbyID  string    `json:"lobby_id,omitempty"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// FriendStatus is how far a friendship between two accounts has got.
+type FriendStatus string
+
+const (
+	FriendRequested FriendStatus = "requested" // waiting on the addressee
+	FriendAccepted  FriendStatus = "accepted"
+)
+
+// Friendship ties two accounts. One asks, and it's accepted when the other
+// asks back.
+type Friendship struct {
+	RequesterID string       `json:"requester_id"`
+	AddresseeID string       `json:"addressee_id"`
+	Status      FriendStatus `json:"status"`
+	CreatedAt   time.Time    `json:"created_at"`
+	AcceptedAt  *time.Time   `json:"accepted_at,omitempty"`
+}
+
+// Other returns the account on the other side of the friendship from userID.
+func (f *Friendship) Other(userID string) string {
+	if f.RequesterID == userID {
+		return f.AddresseeID
+	}
+	return f.RequesterID
+}
+
+// Friend is a friendship as one side of it sees it.
+type Friend struct {
+	UserID   string       `json:"user_id"`
+	Username string       `json:"username"`
+	Status   FriendStatus `json:"status"`
+	Incoming bool         `json:"incoming,omitempty"` // a request waiting on this side to accept
+	Online   bool         `json:"online"`             // only shown for accepted friends
+	Since    time.Time    `json:"since"`              // requested, or accepted once it is
+}
@@ -0,0 +1,145 @@
+package models
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// MaxFreeTextLength bounds typed answers so matching stays cheap.
+const MaxFreeTextLength = 200
+
+var leadingArticles = []string{"the ", "a ", "an "}
+
+// MatchesFreeText reports whether a typed answer matches any accepted answer.
+// Both sides are normalized (case, punctuation, whitespace, leading article)
+// and then compared with a Levenshtein distance that scales with the length
+// of the accepted answer, so short answers must be exact and longer ones
+// tolerate a typo or two.
+func MatchesFreeText(answer string, accepted []string) bool {
+	given := normalizeAnswer(answer)
+	if given == "" {
+		return false
+	}
+
+	for _, candidate := range accepted {
+		want := normalizeAnswer(candidate)
+		if want == "" {
+			continue
+		}
+		if given == want {
+			return true
+		}
+		if levenshtein(given, want) <= typoAllowance(want) {
+			return true
+		}
+	}
+	return false
+}
+
+// Words ignored when fingerprinting question text.
+var fingerprintStopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "of": true, "is": true, "are": true,
+	"was": true, "which": true, "what": true, "who": true, "in": true, "on": true,
+}
+
+// QuestionFingerprint hashes question text so that rewordings differing only
+// in case, punctuation, word order or filler words collide. Used to spot
+// duplicate questions across the bank and generated batches.
+func QuestionFingerprint(text string) string {
+	var words []string
+	for _, word := range strings.Fields(normalizeAnswer(text)) {
+		if !fingerprintStopWords[word] {
+			words = append(words, word)
+		}
+	}
+	sort.Strings(words)
+	sum := sha1.Sum([]byte(strings.Join(words, " ")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// MentionsAnswer reports whether answer appears as whole words in text,
+// after the same normalization used for free-text matching.
+func MentionsAnswer(text, answer string) bool {
+	want := normalizeAnswer(answer)
+	if want == "" {
+		return false
+	}
+	return strings.Contains(" "+normalizeAnswer(text)+" ", " "+want+" ")
+}
+
+// SameOption reports whether two option labels are equal once normalized,
+// e.g. "Paris" and "paris.".
+func SameOption(a, b string) bool {
+	na := normalizeAnswer(a)
+	return na != "" && na == normalizeAnswer(b)
+}
+
+func typoAllowance(s string) int {
+	allowance := len([]rune(s)) / 5
+	if allowance > 3 {
+		allowance = 3
+	}
+	return allowance
+}
+
+func normalizeAnswer(s string) string {
+	var b strings.Builder
+	lastSpace := true
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			lastSpace = false
+		case unicode.IsSpace(r) || r == '-' || r == '_':
+			if !lastSpace {
+				b.WriteRune(' ')
+				lastSpace = true
+			}
+		}
+	}
+	normalized := strings.TrimSpace(b.String())
+
+	for _, article := range leadingArticles {
+		if strings.HasPrefix(normalized, article) && len(normalized) > len(article) {
+			normalized = normalized[len(article):]
+			break
+		}
+	}
+	return normalized
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	m := a
+	if b < m {
+		m = b
+	}
+	if c < m {
+		m = c
+	}
+	return m
+}
@@ -1,7 +1,16 @@
 package models
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
 	"time"
+
+	"buildprize-game/internal/game"
+
 	"github.com/google/uuid"
 )
 
@@ -19,14 +28,298 @@ type Player struct {
 	Score    int    `json:"score"`
 	Streak   int    `json:"streak"`
 	IsReady  bool   `json:"is_ready"`
+	IsTest   bool   `json:"is_test,omitempty"` // spawned by an admin in a sandbox lobby
+	IsBot    bool   `json:"is_bot,omitempty"`  // demo-mode bot
+	UserID   string `json:"user_id,omitempty"` // the account the player joined as; empty for guests
+
+	// Power-ups earned and not yet used, by kind
+	PowerUps map[PowerUp]int `json:"power_ups,omitempty"`
+
+	// Whether the player has an open WebSocket connection, kept by the server
+	// that hosts the lobby rather than stored, and when they last connected
+	// or dropped off.
+	Online   bool       `json:"-"`
+	LastSeen *time.Time `json:"last_seen,omitempty"`
 }
 
+// IsHuman reports whether the player is a real participant, i.e. neither a
+// test player nor a bot. Only human players count towards stats.
+func (p *Player) IsHuman() bool {
+	return !p.IsTest && !p.IsBot
+}
+
+type MediaType string
+
+const (
+	MediaImage MediaType = "image"
+	MediaAudio MediaType = "audio"
+)
+
+type QuestionType string
+
+const (
+	SingleChoice QuestionType = "single_choice"
+	TrueFalse    QuestionType = "true_false"
+	MultiSelect  QuestionType = "multi_select" // select all that apply
+	FreeText     QuestionType = "free_text"    // typed answer, fuzzy-matched against AcceptedAnswers
+	Numeric      QuestionType = "numeric"      // closest number wins, scored by proximity to NumericAnswer
+)
+
 type Question struct {
-	ID       string   `json:"id"`
-	Text     string   `json:"text"`
-	Options  []string `json:"options"`
-	Correct  int      `json:"correct"`
-	Category string   `json:"category"`
+	ID              string       `json:"id"`
+	Type            QuestionType `json:"type,omitempty"` // empty means SingleChoice
+	Text            string       `json:"text"`
+	Options         []string     `json:"options"`
+	OptionCount     int          `json:"option_count"` // len(Options), between MinOptions and MaxOptions
+	Correct         int          `json:"correct"`
+	CorrectAnswers  []int        `json:"correct_answers,omitempty"`  // MultiSelect only
+	AcceptedAnswers []string     `json:"accepted_answers,omitempty"` // FreeText only; the first is the canonical answer
+	NumericAnswer   float64      `json:"numeric_answer,omitempty"`   // Numeric only; the true value
+	Tolerance       float64      `json:"tolerance,omitempty"`        // Numeric only; guesses further off score nothing, defaults to the answer's size
+	Category        string       `json:"category"`
+	MediaURL        string       `json:"media_url,omitempty"`
+	MediaType       MediaType    `json:"media_type,omitempty"`
+	Difficulty      string       `json:"difficulty,omitempty"` // one of the Difficulty labels, if labelled
+}
+
+const (
+	MinOptions = 2
+	MaxOptions = 6
+)
+
+// Difficulty labels, calibrated from how players actually do.
+const (
+	DifficultyEasy   = "easy"
+	DifficultyMedium = "medium"
+	DifficultyHard   = "hard"
+)
+
+var (
+	ErrInvalidQuestion = errors.New("invalid question")
+	ErrInvalidAnswer   = errors.New("invalid answer for question")
+)
+
+// SubmittedAnswer is a player's answer in the shape its question type expects:
+// Choice for single-choice and true/false, Choices for multi-select, Text
+// for free-text and Number for numeric questions.
+type SubmittedAnswer struct {
+	Choice  int     `json:"choice"`
+	Choices []int   `json:"choices,omitempty"`
+	Text    string  `json:"text,omitempty"`
+	Number  float64 `json:"number,omitempty"`
+}
+
+// ParseSubmittedAnswer converts a decoded JSON answer value (a number, an
+// array of numbers or a string) into a SubmittedAnswer.
+func ParseSubmittedAnswer(raw interface{}) (SubmittedAnswer, error) {
+	switch v := raw.(type) {
+	case string:
+		return SubmittedAnswer{Text: v}, nil
+	case float64:
+		return SubmittedAnswer{Choice: int(v), Number: v}, nil
+	case int:
+		return SubmittedAnswer{Choice: v, Number: float64(v)}, nil
+	case []interface{}:
+		choices := make([]int, 0, len(v))
+		for _, item := range v {
+			n, ok := item.(float64)
+			if !ok {
+				return SubmittedAnswer{}, ErrInvalidAnswer
+			}
+			choices = append(choices, int(n))
+		}
+		return SubmittedAnswer{Choices: choices}, nil
+	case []int:
+		return SubmittedAnswer{Choices: v}, nil
+	}
+	return SubmittedAnswer{}, ErrInvalidAnswer
+}
+
+func (q *Question) QuestionType() QuestionType {
+	if q.Type == "" {
+		return SingleChoice
+	}
+	return q.Type
+}
+
+// Validate checks that a question can be served: non-empty text, between
+// MinOptions and MaxOptions distinct options, and a correct index in range.
+func (q *Question) Validate() error {
+	if strings.TrimSpace(q.Text) == "" {
+		return fmt.Errorf("%w: empty text", ErrInvalidQuestion)
+	}
+	switch q.Difficulty {
+	case "", DifficultyEasy, DifficultyMedium, DifficultyHard:
+	default:
+		return fmt.Errorf("%w: unknown difficulty %q", ErrInvalidQuestion, q.Difficulty)
+	}
+	if q.QuestionType() == FreeText {
+		if len(q.Options) > 0 {
+			return fmt.Errorf("%w: free-text questions take no options", ErrInvalidQuestion)
+		}
+		if len(q.AcceptedAnswers) == 0 {
+			return fmt.Errorf("%w: free-text question has no accepted answers", ErrInvalidQuestion)
+		}
+		for _, accepted := range q.AcceptedAnswers {
+			if normalizeAnswer(accepted) == "" {
+				return fmt.Errorf("%w: empty accepted answer", ErrInvalidQuestion)
+			}
+		}
+		return nil
+	}
+	if q.QuestionType() == Numeric {
+		if len(q.Options) > 0 {
+			return fmt.Errorf("%w: numeric questions take no options", ErrInvalidQuestion)
+		}
+		if !isFinite(q.NumericAnswer) || !isFinite(q.Tolerance) || q.Tolerance < 0 {
+			return fmt.Errorf("%w: numeric answer and tolerance must be finite, tolerance not negative", ErrInvalidQuestion)
+		}
+		return nil
+	}
+	if len(q.Options) < MinOptions || len(q.Options) > MaxOptions {
+		return fmt.Errorf("%w: %d options, want %d-%d", ErrInvalidQuestion, len(q.Options), MinOptions, MaxOptions)
+	}
+	seen := make(map[string]bool)
+	for _, opt := range q.Options {
+		key := strings.ToLower(strings.TrimSpace(opt))
+		if key == "" {
+			return fmt.Errorf("%w: empty option", ErrInvalidQuestion)
+		}
+		if seen[key] {
+			return fmt.Errorf("%w: duplicate option %q", ErrInvalidQuestion, opt)
+		}
+		seen[key] = true
+	}
+	switch q.QuestionType() {
+	case SingleChoice:
+	case TrueFalse:
+		if len(q.Options) != 2 {
+			return fmt.Errorf("%w: true/false questions need exactly 2 options", ErrInvalidQuestion)
+		}
+	case MultiSelect:
+		if len(q.CorrectAnswers) == 0 {
+			return fmt.Errorf("%w: multi-select question has no correct answers", ErrInvalidQuestion)
+		}
+		seenIndex := make(map[int]bool)
+		for _, idx := range q.CorrectAnswers {
+			if !q.HasOption(idx) || seenIndex[idx] {
+				return fmt.Errorf("%w: bad correct answer index %d", ErrInvalidQuestion, idx)
+			}
+			seenIndex[idx] = true
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidQuestion, q.Type)
+	}
+	if q.Correct < 0 || q.Correct >= len(q.Options) {
+		return fmt.Errorf("%w: correct index %d out of range", ErrInvalidQuestion, q.Correct)
+	}
+	return nil
+}
+
+// CheckAnswer validates that an answer has the right shape for the question.
+func (q *Question) CheckAnswer(a SubmittedAnswer) error {
+	if q.QuestionType() == FreeText {
+		if len(a.Choices) > 0 || strings.TrimSpace(a.Text) == "" || len(a.Text) > MaxFreeTextLength {
+			return ErrInvalidAnswer
+		}
+		return nil
+	}
+	if a.Text != "" {
+		return ErrInvalidAnswer
+	}
+	if q.QuestionType() == Numeric {
+		if len(a.Choices) > 0 || !isFinite(a.Number) {
+			return ErrInvalidAnswer
+		}
+		return nil
+	}
+	if q.QuestionType() != MultiSelect {
+		if len(a.Choices) > 0 || !q.HasOption(a.Choice) {
+			return ErrInvalidAnswer
+		}
+		return nil
+	}
+
+	if len(a.Choices) == 0 {
+		return ErrInvalidAnswer
+	}
+	seen := make(map[int]bool)
+	for _, idx := range a.Choices {
+		if !q.HasOption(idx) || seen[idx] {
+			return ErrInvalidAnswer
+		}
+		seen[idx] = true
+	}
+	return nil
+}
+
+// MultiSelectHits counts how many of the selected choices are correct and
+// how many are wrong.
+func (q *Question) MultiSelectHits(a SubmittedAnswer) (hits, misses int) {
+	correct := make(map[int]bool)
+	for _, idx := range q.CorrectAnswers {
+		correct[idx] = true
+	}
+	for _, idx := range a.Choices {
+		if correct[idx] {
+			hits++
+		} else {
+			misses++
+		}
+	}
+	return hits, misses
+}
+
+// IsCorrect reports whether the answer is fully correct. For multi-select
+// questions every correct option, and nothing else, must be selected; numeric
+// guesses must be within NumericCorrectShare of the tolerance.
+func (q *Question) IsCorrect(a SubmittedAnswer) bool {
+	if q.QuestionType() == FreeText {
+		return MatchesFreeText(a.Text, q.AcceptedAnswers)
+	}
+	if q.QuestionType() == Numeric {
+		return q.Distance(a) <= q.NumericTolerance()*NumericCorrectShare
+	}
+	if q.QuestionType() == MultiSelect {
+		hits, misses := q.MultiSelectHits(a)
+		return misses == 0 && hits == len(q.CorrectAnswers)
+	}
+	return a.Choice == q.Correct
+}
+
+// Shuffled returns a copy of the question with its options in random order
+// and Correct remapped to match. The receiver is left untouched so shared
+// question bank entries are never mutated.
+func (q *Question) Shuffled() *Question {
+	shuffled := *q
+	if q.QuestionType() == TrueFalse {
+		// Keep "True" before "False".
+		shuffled.OptionCount = len(shuffled.Options)
+		return &shuffled
+	}
+
+	shuffled.Options = make([]string, len(q.Options))
+	newIndexOf := make(map[int]int, len(q.Options))
+	perm := rand.Perm(len(q.Options))
+	for newIndex, oldIndex := range perm {
+		shuffled.Options[newIndex] = q.Options[oldIndex]
+		newIndexOf[oldIndex] = newIndex
+	}
+	shuffled.Correct = newIndexOf[q.Correct]
+	if len(q.CorrectAnswers) > 0 {
+		shuffled.CorrectAnswers = make([]int, len(q.CorrectAnswers))
+		for i, idx := range q.CorrectAnswers {
+			shuffled.CorrectAnswers[i] = newIndexOf[idx]
+		}
+	}
+	shuffled.OptionCount = len(shuffled.Options)
+	return &shuffled
+}
+
+// HasOption reports whether index refers to one of the question's options.
+func (q *Question) HasOption(index int) bool {
+	return index >= 0 && index < len(q.Options)
 }
 
 type Answer struct {
@@ -36,24 +329,184 @@ type Answer struct {
 }
 
 type Lobby struct {
-	ID          string     `json:"id"`
-	Name        string     `json:"name"`
-	Players     []*Player  `json:"players"`
-	State       GameState  `json:"state"`
-	CurrentQ    *Question  `json:"current_question,omitempty"`
-	Round       int        `json:"round"`
-	MaxRounds   int        `json:"max_rounds"`
-	CreatedAt   time.Time  `json:"created_at"`
-	StartedAt   *time.Time `json:"started_at,omitempty"`
-	FinishedAt  *time.Time `json:"finished_at,omitempty"`
-	QuestionEnd *time.Time `json:"question_end,omitempty"`
+	ID            string     `json:"id"`
+	Name          string     `json:"name"`
+	Players       []*Player  `json:"players"`
+	State         GameState  `json:"state"` // coarse view of Phase, kept for clients and storage
+	Phase         game.Phase `json:"phase"`
+	CurrentQ      *Question  `json:"current_question,omitempty"`
+	Round         int        `json:"round"`
+	MaxRounds     int        `json:"max_rounds"`
+	MaxPlayers    int        `json:"max_players"`
+	QuestionTime  int        `json:"question_time,omitempty"` // seconds per question; 0 means DefaultQuestionTime
+	CreatedAt     time.Time  `json:"created_at"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+	QuestionStart *time.Time `json:"question_start,omitempty"`
+	QuestionEnd   *time.Time `json:"question_end,omitempty"`
+	Paused        bool       `json:"paused,omitempty"`
+	PausedAt      *time.Time `json:"paused_at,omitempty"`
+	RemainingMs   int64      `json:"remaining_ms,omitempty"` // time left on the round timer while paused
+	Topic         string     `json:"topic,omitempty"`
+	Timezone      string     `json:"timezone"` // IANA name for local displays and daily boundaries
+	Language      string     `json:"language"` // catalog language for system messages, see internal/i18n
+	RoundType     MediaType  `json:"round_type,omitempty"`
+	Sandbox       bool       `json:"sandbox,omitempty"`  // admin test-drive lobby, hidden from listings and stats
+	Demo          bool       `json:"demo,omitempty"`     // always-open demo lobby seated with bots
+	WarmUp        bool       `json:"warm_up,omitempty"`  // serves warm-up questions while waiting
+	Audience      bool       `json:"audience,omitempty"` // accepts audience members alongside the featured players
+
+	// ScoringConfig version the game is scored with, fixed at game start.
+	ScoringVersion string `json:"scoring_version,omitempty"`
+	// The lobby's own scoring rules, set at creation; games with them are
+	// recorded as LobbyScoringVersion.
+	Scoring *ScoringConfig `json:"scoring,omitempty"`
+
+	// The host's current or most recent poll.
+	Poll *Poll `json:"poll,omitempty"`
+
+	// Host-assigned category weights (e.g. {"Sports": 50, "Music": 30, "any": 20})
+	// and the category mix actually served so far.
+	CategoryWeights map[string]int `json:"category_weights,omitempty"`
+	CategoryMix     map[string]int `json:"category_mix,omitempty"`
+	CategoryPlan    []string       `json:"-"` // one category per round, built at game start
+
+	// Questions prepared ahead of time (e.g. generated for Topic),
+	// served before falling back to the built-in question bank.
+	QuestionQueue []*Question `json:"-"`
+
+	// Players who have answered the current question, the power-ups used on
+	// it and the guesses at a numeric one, by player ID, and the players who
+	// answered it correctly in the order they did; reset by SetQuestion.
+	Answered     map[string]bool        `json:"-"`
+	PowerUpsUsed map[string]*PowerUpUse `json:"-"`
+	Guesses      map[string]float64     `json:"-"`
+	CorrectOrder []string               `json:"-"`
+
+	// Rounds that open with a wager phase, and the stakes placed in the
+	// current one by player ID
+	WagerRounds []int             `json:"wager_rounds,omitempty"`
+	Wagers      map[string]*Wager `json:"-"`
+
+	// Whether the last round is a final round with hidden wagers, and the
+	// answers held back until its results by player ID
+	FinalWager   bool                    `json:"final_wager,omitempty"`
+	FinalAnswers map[string]*FinalAnswer `json:"-"`
+
+	// The last MaxRecentChat chat messages, oldest first, for clients
+	// catching up after a reconnect.
+	RecentChat []ChatMessage `json:"-"`
+
+	// playerID -> when their chat messages in the current rate window were sent
+	ChatSentAt map[string][]time.Time `json:"-"`
+
+	// Chat mutes: players muted lobby-wide, by the host or after reports
+	// (playerID -> until, zero until unmuted), and the players each player
+	// has muted for themselves
+	ChatMutes     map[string]time.Time       `json:"-"`
+	PersonalMutes map[string]map[string]bool `json:"-"`
+
+	// playerID -> the players who have reported them in this lobby
+	Reporters map[string]map[string]bool `json:"-"`
+
+	// Chat messages sent in the lobby, and each round's answers so far
+	ChatCount int          `json:"-"`
+	Tallies   []RoundTally `json:"-"`
+
+	// Set once the game has finished
+	Stats *GameStats `json:"stats,omitempty"`
+
+	// Scheduled games start on their own at StartsAt, once RSVPQuorum
+	// players who said yes have joined; StartHeld is set while a start that
+	// came due waits for them. With OpenRSVP anyone may RSVP, not just the
+	// invited players.
+	StartsAt   *time.Time `json:"starts_at,omitempty"`
+	Invites    []*Invite  `json:"invites,omitempty"`
+	OpenRSVP   bool       `json:"open_rsvp,omitempty"`
+	RSVPQuorum int        `json:"rsvp_quorum,omitempty"`
+	StartHeld  bool       `json:"start_held,omitempty"`
+
+	// Lifecycle events are posted here, signed (see services.LobbyWebhooks).
+	// Set at creation and never changed, so it's read without the lock.
+	WebhookURL string `json:"webhook_url,omitempty"`
+
+	// What the winner of a prize game is awarded. Its results are held open
+	// to disputes before payouts are recorded (see PrizeResult).
+	Prize string `json:"prize,omitempty"`
+	// Money shared between the top finishers, with the same results hold
+	PrizePool *PrizePool `json:"prize_pool,omitempty"`
+	// Charged to join, in the prize pool's currency, and added to the pool
+	// as seats are paid for. Set at creation and never changed, so it's
+	// read without the lock.
+	EntryFee      int64                    `json:"entry_fee,omitempty"`
+	EntryPayments map[string]*EntryPayment `json:"entry_payments,omitempty"` // payment ID -> payment
+
+	// When a player last joined or chatted, zero meaning CreatedAt, and
+	// whether the lobby has been warned it's closing for lack of either
+	LastActivity time.Time `json:"-"`
+	IdleWarned   bool      `json:"-"`
+
+	connections map[string]map[string]bool // playerID -> open connection IDs
+
+	// Guards every field above once the lobby is shared between HTTP and
+	// WebSocket handlers and game timers. Lobby methods don't lock; callers do.
+	mu sync.Mutex
 }
 
+// GameEvent is a message pushed to lobby clients. Seq and Timestamp are
+// assigned by the lobby's event loop: Seq increases by one per lobby-wide
+// event and Timestamp never decreases within a lobby.
 type GameEvent struct {
 	Type      string      `json:"type"`
 	LobbyID   string      `json:"lobby_id"`
+	Seq       uint64      `json:"seq"`
 	Data      interface{} `json:"data"`
 	Timestamp time.Time   `json:"timestamp"`
+
+	// Players whose connections don't receive a lobby-wide event, such as
+	// those who muted a chat message's sender. It still takes a seq number.
+	SkipPlayers []string `json:"-"`
+
+	// Sent to the whole lobby but only of use as it happens, like a timer
+	// tick: it carries the current seq without advancing it, and isn't
+	// logged or replayed.
+	Ephemeral bool `json:"-"`
+}
+
+// GameArchive points to a lobby's event log once it has been moved out of
+// the database to cold storage, under Key.
+type GameArchive struct {
+	LobbyID     string    `json:"lobby_id"`
+	Key         string    `json:"key"`
+	Events      int       `json:"events"`
+	LastSeq     uint64    `json:"last_seq"`
+	LastEventAt time.Time `json:"last_event_at"`
+	ArchivedAt  time.Time `json:"archived_at"`
+}
+
+// MaxRecentChat is how many chat messages a lobby keeps for resyncing clients.
+const MaxRecentChat = 50
+
+// ChatMessage is a chat message posted to a lobby, already normalized.
+type ChatMessage struct {
+	ID       int64     `json:"id"` // assigned by the repository
+	LobbyID  string    `json:"lobby_id"`
+	PlayerID string    `json:"player_id"`
+	Username string    `json:"username"`
+	Message  string    `json:"message"`
+	SentAt   time.Time `json:"sent_at"`
+}
+
+// DefaultQuestionTime is how long a question is open in lobbies that don't
+// set their own question time.
+const DefaultQuestionTime = 15 * time.Second
+
+// QuestionDuration is how long each of the lobby's questions is open.
+func (l *Lobby) QuestionDuration() time.Duration {
+	if l.QuestionTime > 0 {
+		return time.Duration(l.QuestionTime) * time.Second
+	}
+	return DefaultQuestionTime
 }
 
 func NewLobby(name string, maxRounds int) *Lobby {
@@ -62,10 +515,31 @@ func NewLobby(name string, maxRounds int) *Lobby {
 		Name:      name,
 		Players:   make([]*Player, 0),
 		State:     Waiting,
+		Phase:     game.Waiting,
 		Round:     0,
 		MaxRounds: maxRounds,
-		CreatedAt: time.Now(),
+		CreatedAt: Now(),
+	}
+}
+
+func (l *Lobby) Lock() {
+	l.mu.Lock()
+}
+
+func (l *Lobby) Unlock() {
+	l.mu.Unlock()
+}
+
+// Snapshot returns the lobby as JSON, taken under the lock. Use it when
+// handing the lobby to anything that encodes it later, like HTTP responses.
+func (l *Lobby) Snapshot() json.RawMessage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	data, err := json.Marshal(l)
+	if err != nil {
+		return json.RawMessage("null")
 	}
+	return data
 }
 
 func (l *Lobby) AddPlayer(username string) *Player {
@@ -80,10 +554,24 @@ func (l *Lobby) AddPlayer(username string) *Player {
 	return player
 }
 
+func (l *Lobby) AddTestPlayer(username string) *Player {
+	player := l.AddPlayer(username)
+	player.IsTest = true
+	return player
+}
+
+func (l *Lobby) AddBot(username string) *Player {
+	player := l.AddPlayer(username)
+	player.IsBot = true
+	player.IsReady = true
+	return player
+}
+
 func (l *Lobby) RemovePlayer(playerID string) bool {
 	for i, player := range l.Players {
 		if player.ID == playerID {
 			l.Players = append(l.Players[:i], l.Players[i+1:]...)
+			delete(l.connections, playerID)
 			return true
 		}
 	}
@@ -99,30 +587,334 @@ func (l *Lobby) GetPlayer(playerID string) *Player {
 	return nil
 }
 
+// FindConfusablePlayer returns a player whose username looks like username,
+// or nil if none does.
+func (l *Lobby) FindConfusablePlayer(username string) *Player {
+	skeleton := UsernameSkeleton(username)
+	for _, player := range l.Players {
+		if UsernameSkeleton(player.Username) == skeleton {
+			return player
+		}
+	}
+	return nil
+}
+
+// FindUserPlayer returns the player seated as the account userID, or nil.
+func (l *Lobby) FindUserPlayer(userID string) *Player {
+	for _, player := range l.Players {
+		if player.UserID == userID {
+			return player
+		}
+	}
+	return nil
+}
+
+// IsFull reports whether the lobby has reached its player cap. A zero cap
+// (lobbies persisted before capacities existed) falls back to 8.
+func (l *Lobby) IsFull() bool {
+	limit := l.MaxPlayers
+	if limit == 0 {
+		limit = 8
+	}
+	return len(l.Players) >= limit
+}
+
 func (l *Lobby) CanStart() bool {
-	return len(l.Players) >= 2 && l.State == Waiting
+	return len(l.Players) >= 2 && l.Phase == game.Waiting
 }
 
-func (l *Lobby) StartGame() {
-	l.State = InProgress
+// StartGame moves a waiting lobby into the countdown before round 1.
+func (l *Lobby) StartGame() error {
+	round := l.Round
 	l.Round = 1
-	now := time.Now()
+	if err := l.Advance(game.Countdown); err != nil {
+		l.Round = round
+		return err
+	}
+	now := Now()
 	l.StartedAt = &now
+	return nil
 }
 
 func (l *Lobby) NextRound() {
 	l.Round++
-	if l.Round > l.MaxRounds {
-		l.State = Finished
+}
+
+// Advance moves the lobby to the next lifecycle phase, keeping State in step.
+// The caller sets up the fields the new phase needs (e.g. the question) first.
+func (l *Lobby) Advance(to game.Phase) error {
+	if err := game.Transition(l.Phase, to); err != nil {
+		return err
+	}
+	l.Phase = to
+	l.State = StateForPhase(to)
+	l.CheckInvariants()
+	return nil
+}
+
+// CheckInvariants panics if the lobby is in an inconsistent state. It only
+// checks in debug builds; see game.Assert.
+func (l *Lobby) CheckInvariants() {
+	game.Assert(game.Snapshot{
+		Phase:        l.Phase,
+		Round:        l.Round,
+		MaxRounds:    l.MaxRounds,
+		HasQuestion:  l.CurrentQ != nil,
+		QuestionOpen: l.QuestionEnd != nil,
+		Paused:       l.Paused,
+	})
+}
+
+// StateForPhase maps a lifecycle phase onto the coarser GameState.
+func StateForPhase(phase game.Phase) GameState {
+	switch phase {
+	case game.Waiting:
+		return Waiting
+	case game.Finished:
+		return Finished
 	}
+	return InProgress
+}
+
+// PhaseForState picks a phase for lobbies stored before phases existed. A
+// game in progress is resumed between rounds.
+func PhaseForState(state GameState) game.Phase {
+	switch state {
+	case Waiting:
+		return game.Waiting
+	case Finished:
+		return game.Finished
+	}
+	return game.Intermission
 }
 
 func (l *Lobby) SetQuestion(question *Question, duration time.Duration) {
 	l.CurrentQ = question
-	endTime := time.Now().Add(duration)
+	l.Answered = make(map[string]bool)
+	l.PowerUpsUsed = nil
+	l.Guesses = nil
+	l.CorrectOrder = nil
+	startTime := Now()
+	endTime := startTime.Add(duration)
+	l.QuestionStart = &startTime
 	l.QuestionEnd = &endTime
 }
 
+// ResponseTimeAt returns how long after the current question was shown an
+// answer arriving at t took, in milliseconds, clamped to the question window.
+func (l *Lobby) ResponseTimeAt(t time.Time) int64 {
+	if l.QuestionStart == nil || l.QuestionEnd == nil {
+		return 0
+	}
+	if t.After(*l.QuestionEnd) {
+		t = *l.QuestionEnd
+	}
+	elapsed := t.Sub(*l.QuestionStart).Milliseconds()
+	if elapsed < 0 {
+		return 0
+	}
+	return elapsed
+}
+
+// IsHost reports whether playerID is the host: the longest-seated player,
+// the same one the lobby screen lets start the game.
+func (l *Lobby) IsHost(playerID string) bool {
+	return len(l.Players) > 0 && l.Players[0].ID == playerID
+}
+
+// CanPause reports whether the game is running and not already paused.
+func (l *Lobby) CanPause() bool {
+	return l.Phase.Running() && !l.Paused
+}
+
+// Pause freezes the lobby with remaining time left on the round timer.
+func (l *Lobby) Pause(remaining time.Duration) {
+	now := Now()
+	l.Paused = true
+	l.PausedAt = &now
+	l.RemainingMs = remaining.Milliseconds()
+	l.CheckInvariants()
+}
+
+// Resume unfreezes the lobby and returns the time left on the round timer.
+// An open question's window moves forward by the time spent paused, so
+// response times exclude the pause.
+func (l *Lobby) Resume() time.Duration {
+	now := Now()
+	remaining := time.Duration(l.RemainingMs) * time.Millisecond
+	if l.QuestionStart != nil && l.PausedAt != nil {
+		start := l.QuestionStart.Add(now.Sub(*l.PausedAt))
+		l.QuestionStart = &start
+	}
+	if l.QuestionEnd != nil {
+		end := now.Add(remaining)
+		l.QuestionEnd = &end
+	}
+	l.Paused = false
+	l.PausedAt = nil
+	l.RemainingMs = 0
+	l.CheckInvariants()
+	return remaining
+}
+
+// NextQueuedQuestion pops the next prepared question, or returns nil
+// when the queue is empty.
+func (l *Lobby) NextQueuedQuestion() *Question {
+	if len(l.QuestionQueue) == 0 {
+		return nil
+	}
+	q := l.QuestionQueue[0]
+	l.QuestionQueue = l.QuestionQueue[1:]
+	return q
+}
+
+// PlannedCategory returns the category planned for the current round, or
+// "" when the lobby has no category plan.
+func (l *Lobby) PlannedCategory() string {
+	if l.Round < 1 || l.Round > len(l.CategoryPlan) {
+		return ""
+	}
+	return l.CategoryPlan[l.Round-1]
+}
+
+// PlanCategory serves category in every round from round from onwards,
+// leaving earlier rounds as planned.
+func (l *Lobby) PlanCategory(from int, category string) {
+	for len(l.CategoryPlan) < l.MaxRounds {
+		l.CategoryPlan = append(l.CategoryPlan, "")
+	}
+	if from < 1 {
+		from = 1
+	}
+	for round := from; round <= l.MaxRounds; round++ {
+		l.CategoryPlan[round-1] = category
+	}
+}
+
+func (l *Lobby) RecordCategory(category string) {
+	if l.CategoryMix == nil {
+		l.CategoryMix = make(map[string]int)
+	}
+	l.CategoryMix[category]++
+}
+
+// MutedBy returns the players who have muted playerID for themselves.
+func (l *Lobby) MutedBy(playerID string) []string {
+	var muters []string
+	for muter, muted := range l.PersonalMutes {
+		if muted[playerID] {
+			muters = append(muters, muter)
+		}
+	}
+	return muters
+}
+
+// TallyRound starts counting answers to the current question.
+func (l *Lobby) TallyRound() {
+	l.Tallies = append(l.Tallies, RoundTally{Round: l.Round, Question: l.CurrentQ, Players: len(l.Players)})
+}
+
+// TallyAnswer counts an answer to the current round's question.
+func (l *Lobby) TallyAnswer(correct bool, responseMs int64) {
+	if len(l.Tallies) == 0 || l.Tallies[len(l.Tallies)-1].Round != l.Round {
+		return
+	}
+	tally := &l.Tallies[len(l.Tallies)-1]
+	tally.Answered++
+	tally.ResponseMs += responseMs
+	if correct {
+		tally.Correct++
+	}
+}
+
+// GameStats summarises the rounds played so far. The hardest question is
+// the one with the lowest accuracy among those anyone answered, the
+// earliest on a tie.
+func (l *Lobby) GameStats() *GameStats {
+	stats := &GameStats{Rounds: []RoundParticipation{}, ChatMessages: l.ChatCount}
+	answered, responseMs := 0, int64(0)
+	for _, tally := range l.Tallies {
+		round := RoundParticipation{Round: tally.Round, Players: tally.Players, Answered: tally.Answered}
+		if tally.Players > 0 {
+			round.Rate = float64(tally.Answered) / float64(tally.Players)
+		}
+		stats.Rounds = append(stats.Rounds, round)
+		answered += tally.Answered
+		responseMs += tally.ResponseMs
+
+		if tally.Answered == 0 || tally.Question == nil {
+			continue
+		}
+		accuracy := float64(tally.Correct) / float64(tally.Answered)
+		if stats.HardestQuestion == nil || accuracy < stats.HardestQuestion.Accuracy {
+			stats.HardestQuestion = &QuestionAccuracy{
+				Round:      tally.Round,
+				QuestionID: tally.Question.ID,
+				Text:       tally.Question.Text,
+				Category:   tally.Question.Category,
+				Answered:   tally.Answered,
+				Correct:    tally.Correct,
+				Accuracy:   accuracy,
+			}
+		}
+	}
+	if answered > 0 {
+		stats.AvgResponseMs = responseMs / int64(answered)
+	}
+	return stats
+}
+
+func (l *Lobby) MarkAnswered(playerID string) {
+	if l.Answered == nil {
+		l.Answered = make(map[string]bool)
+	}
+	l.Answered[playerID] = true
+}
+
+func (l *Lobby) HasAnswered(playerID string) bool {
+	return l.Answered[playerID]
+}
+
+// RecordCorrect notes a correct answer to the current question, returning
+// its position among the correct answers so far, from 1.
+func (l *Lobby) RecordCorrect(playerID string) int {
+	l.CorrectOrder = append(l.CorrectOrder, playerID)
+	return len(l.CorrectOrder)
+}
+
+// IsQuestionActive reports whether answers are accepted: only in the
+// question phase, while unpaused and before the question's end time.
 func (l *Lobby) IsQuestionActive() bool {
-	return l.CurrentQ != nil && l.QuestionEnd != nil && time.Now().Before(*l.QuestionEnd)
+	return l.Phase == game.Question && l.CurrentQ != nil && l.QuestionEnd != nil && !l.Paused && time.Now().Before(*l.QuestionEnd)
+}
+
+// QuestionTimeLeft is how long the current question has left to run, frozen
+// while the game is paused and never negative.
+func (l *Lobby) QuestionTimeLeft() time.Duration {
+	if l.Paused {
+		return time.Duration(l.RemainingMs) * time.Millisecond
+	}
+	if l.QuestionEnd == nil {
+		return 0
+	}
+	if left := time.Until(*l.QuestionEnd); left > 0 {
+		return left
+	}
+	return 0
+}
+
+// AddChatMessage appends a message to the recent chat, dropping the oldest
+// beyond MaxRecentChat.
+func (l *Lobby) AddChatMessage(msg ChatMessage) {
+	l.ChatCount++
+	l.RecentChat = append(l.RecentChat, msg)
+	if excess := len(l.RecentChat) - MaxRecentChat; excess > 0 {
+		l.RecentChat = append([]ChatMessage(nil), l.RecentChat[excess:]...)
+	}
+}
+
+// AcceptsAnswersAt reports whether an answer arriving at t is accepted: as
+// IsQuestionActive, but until grace past the question's end time.
+func (l *Lobby) AcceptsAnswersAt(t time.Time, grace time.Duration) bool {
+	return l.Phase == game.Question && l.CurrentQ != nil && l.QuestionEnd != nil && !l.Paused && !t.After(l.QuestionEnd.Add(grace))
 }
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// PendingNotification is a personal event that couldn't be delivered because
+// the player had no open connection. It is delivered on their next connect.
+type PendingNotification struct {
+	ID        int64           `json:"id"`
+	PlayerID  string          `json:"player_id"`
+	LobbyID   string          `json:"lobby_id"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
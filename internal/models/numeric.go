@@ -0,0 +1,48 @@
+package models
+
+import "math"
+
+// NumericCorrectShare is how close a numeric guess must be, as a share of
+// the question's tolerance, to count as correct.
+const NumericCorrectShare = 0.1
+
+// ClosestBonuses are the points added, by place, for the closest guesses at
+// a numeric question once it closes.
+var ClosestBonuses = []int{50, 25, 10}
+
+// ClosestGuess is a player's place among the guesses at a numeric question,
+// closest first; guesses equally close share a place.
+type ClosestGuess struct {
+	PlayerID string  `json:"player_id"`
+	Username string  `json:"username"`
+	Guess    float64 `json:"guess"`
+	Rank     int     `json:"rank"`
+	Bonus    int     `json:"bonus"`
+}
+
+// NumericTolerance is how far off a guess at a numeric question can be and
+// still score: the question's Tolerance, or the size of its answer if unset.
+func (q *Question) NumericTolerance() float64 {
+	if q.Tolerance > 0 {
+		return q.Tolerance
+	}
+	if size := math.Abs(q.NumericAnswer); size > 0 {
+		return size
+	}
+	return 1
+}
+
+// Distance is how far a numeric guess is from the answer.
+func (q *Question) Distance(a SubmittedAnswer) float64 {
+	return math.Abs(a.Number - q.NumericAnswer)
+}
+
+// Proximity is how close a numeric guess is, from 1 for the exact answer
+// down to 0 for one a whole tolerance or more off.
+func (q *Question) Proximity(a SubmittedAnswer) float64 {
+	return math.Max(0, 1-q.Distance(a)/q.NumericTolerance())
+}
+
+func isFinite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PollAction is what a closed poll's winning option changes in the lobby.
+type PollAction string
+
+const (
+	PollNoAction PollAction = ""
+	// PollCategory serves the winning category in the upcoming rounds.
+	PollCategory PollAction = "category"
+)
+
+var ErrInvalidPoll = errors.New("invalid poll")
+
+// Poll is a quick vote the host puts to the lobby. Votes are tallied
+// server-side; the tally is public but who voted for what is not.
+type Poll struct {
+	ID        string     `json:"id"`
+	Question  string     `json:"question"`
+	Options   []string   `json:"options"`
+	Tally     []int      `json:"tally"`
+	Apply     PollAction `json:"apply,omitempty"`
+	CreatedBy string     `json:"created_by"`
+	ClosesAt  time.Time  `json:"closes_at"`
+	Closed    bool       `json:"closed"`
+	Winner    *int       `json:"winner,omitempty"` // option index; unset until closed, or if nobody voted
+
+	Votes map[string]int `json:"-"` // playerID -> option index
+}
+
+func NewPoll(question string, options []string, apply PollAction, createdBy string, duration time.Duration) (*Poll, error) {
+	question = strings.TrimSpace(question)
+	if question == "" || len(options) < MinOptions || len(options) > MaxOptions {
+		return nil, ErrInvalidPoll
+	}
+	if apply != PollNoAction && apply != PollCategory {
+		return nil, ErrInvalidPoll
+	}
+	trimmed := make([]string, len(options))
+	for i, opt := range options {
+		trimmed[i] = strings.TrimSpace(opt)
+		if trimmed[i] == "" {
+			return nil, ErrInvalidPoll
+		}
+		for j := 0; j < i; j++ {
+			if SameOption(trimmed[i], trimmed[j]) {
+				return nil, ErrInvalidPoll
+			}
+		}
+	}
+
+	return &Poll{
+		ID:        uuid.New().String(),
+		Question:  question,
+		Options:   trimmed,
+		Tally:     make([]int, len(trimmed)),
+		Apply:     apply,
+		CreatedBy: createdBy,
+		ClosesAt:  Now().Add(duration),
+		Votes:     make(map[string]int),
+	}, nil
+}
+
+// HasVoted reports whether the player already voted.
+func (p *Poll) HasVoted(playerID string) bool {
+	_, ok := p.Votes[playerID]
+	return ok
+}
+
+// Vote records a player's vote; the caller checks the poll is open and the
+// player hasn't voted.
+func (p *Poll) Vote(playerID string, option int) {
+	p.Votes[playerID] = option
+	p.Tally[option]++
+}
+
+// Close ends the poll and picks the winner: the most votes, ties going to
+// the option listed first.
+func (p *Poll) Close() {
+	p.Closed = true
+	best := 0
+	for i, count := range p.Tally {
+		if count > best {
+			best = count
+			winner := i
+			p.Winner = &winner
+		}
+	}
+}
+
+// WinningOption returns the winning option's text, or "" if nobody voted.
+func (p *Poll) WinningOption() string {
+	if p.Winner == nil {
+		return ""
+	}
+	return p.Options[*p.Winner]
+}
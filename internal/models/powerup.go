@@ -0,0 +1,65 @@
+package models
+
+import "errors"
+
+var ErrUnknownPowerUp = errors.New("unknown power-up")
+
+// PowerUp is a one-off advantage a player earns during a game and spends on
+// a question of their choosing.
+type PowerUp string
+
+const (
+	// FiftyFifty takes two wrong options off the player's question.
+	FiftyFifty PowerUp = "fifty_fifty"
+	// Freeze stops the player's clock: their answer is timed as if given
+	// when the power-up was used.
+	Freeze PowerUp = "freeze"
+	// DoublePoints doubles what the player's answer scores, if it scores.
+	DoublePoints PowerUp = "double_points"
+)
+
+func ParsePowerUp(s string) (PowerUp, error) {
+	switch p := PowerUp(s); p {
+	case FiftyFifty, Freeze, DoublePoints:
+		return p, nil
+	}
+	return "", ErrUnknownPowerUp
+}
+
+// ApplyScore returns score as changed by the power-up.
+func (p PowerUp) ApplyScore(score int) int {
+	if p == DoublePoints && score > 0 {
+		return 2 * score
+	}
+	return score
+}
+
+// PowerUpUse is a power-up a player has used on the current question.
+type PowerUpUse struct {
+	PlayerID string  `json:"player_id"`
+	Username string  `json:"username"`
+	PowerUp  PowerUp `json:"power_up"`
+	Removed  []int   `json:"-"` // options FiftyFifty took away
+	FrozenAt int64   `json:"-"` // the response time Freeze locked in, in milliseconds
+}
+
+// AwardPowerUp adds a power-up to the player's inventory.
+func (p *Player) AwardPowerUp(powerUp PowerUp) {
+	if p.PowerUps == nil {
+		p.PowerUps = make(map[PowerUp]int)
+	}
+	p.PowerUps[powerUp]++
+}
+
+// TakePowerUp spends one of the player's power-ups, reporting false if they
+// have none of that kind.
+func (p *Player) TakePowerUp(powerUp PowerUp) bool {
+	if p.PowerUps[powerUp] <= 0 {
+		return false
+	}
+	p.PowerUps[powerUp]--
+	if p.PowerUps[powerUp] == 0 {
+		delete(p.PowerUps, powerUp)
+	}
+	return true
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Connect records one of a player's connections, returning the player and
+// whether it brought them online. It returns nil for a player who isn't
+// in the lobby.
+func (l *Lobby) Connect(playerID, connID string, now time.Time) (*Player, bool) {
+	player := l.GetPlayer(playerID)
+	if player == nil {
+		return nil, false
+	}
+	if l.connections == nil {
+		l.connections = make(map[string]map[string]bool)
+	}
+	conns := l.connections[playerID]
+	if conns == nil {
+		conns = make(map[string]bool)
+		l.connections[playerID] = conns
+	}
+	conns[connID] = true
+	if player.Online {
+		return player, false
+	}
+	player.Online = true
+	player.LastSeen = &now
+	return player, true
+}
+
+// Disconnect forgets one of a player's connections, returning the player and
+// whether it was their last one. Connections it doesn't know are ignored.
+func (l *Lobby) Disconnect(playerID, connID string, now time.Time) (*Player, bool) {
+	player := l.GetPlayer(playerID)
+	conns := l.connections[playerID]
+	if player == nil || !conns[connID] {
+		return player, false
+	}
+	delete(conns, connID)
+	if len(conns) > 0 {
+		return player, false
+	}
+	delete(l.connections, playerID)
+	player.Online = false
+	player.LastSeen = &now
+	return player, true
+}
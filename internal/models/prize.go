@@ -0,0 +1,273 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// MaxDisputeReasonLength caps the reason a player gives for a dispute, in
+// characters.
+const MaxDisputeReasonLength = 1000
+
+// ResultsStatus is where a prize game's results are on the way to a payout.
+type ResultsStatus string
+
+const (
+	ResultsProvisional ResultsStatus = "provisional" // open to disputes
+	ResultsFinalized   ResultsStatus = "finalized"   // payouts recorded
+)
+
+// DisputeStatus is an admin's decision on a dispute.
+type DisputeStatus string
+
+const (
+	DisputeOpen     DisputeStatus = "open"
+	DisputeUpheld   DisputeStatus = "upheld"
+	DisputeRejected DisputeStatus = "rejected"
+)
+
+// PrizeResult is the outcome of a prize game, kept apart from its lobby so
+// it outlives it. Results stay provisional until DisputeUntil has passed
+// and every dispute is resolved, then the winners' payouts are recorded and
+// prize pool claim codes issued.
+type PrizeResult struct {
+	LobbyID      string        `json:"lobby_id"`
+	LobbyName    string        `json:"lobby_name"`
+	Prize        string        `json:"prize"`
+	Status       ResultsStatus `json:"status"`
+	EndedAt      time.Time     `json:"ended_at"`
+	DisputeUntil time.Time     `json:"dispute_until"`
+	Standings    []Standing    `json:"standings"` // human players, best first
+	Disputes     []*Dispute    `json:"disputes,omitempty"`
+	Payouts      []*Payout     `json:"payouts,omitempty"`
+	FinalizedAt  *time.Time    `json:"finalized_at,omitempty"`
+
+	// The lobby's prize pool and each top finisher's share of it
+	PrizePool   *PrizePool    `json:"prize_pool,omitempty"`
+	Allocations []*Allocation `json:"allocations,omitempty"`
+}
+
+// Standing is a player's place in a prize game's results. Tied players
+// share a rank.
+type Standing struct {
+	Rank     int    `json:"rank"`
+	PlayerID string `json:"player_id"`
+	Username string `json:"username"`
+	UserID   string `json:"user_id,omitempty"` // the account the player joined as
+	Score    int    `json:"score"`
+}
+
+// Dispute is a player's challenge to a prize game's provisional results.
+type Dispute struct {
+	ID         int           `json:"id"` // 1-based, per result
+	PlayerID   string        `json:"player_id"`
+	Username   string        `json:"username"`
+	Reason     string        `json:"reason"`
+	Status     DisputeStatus `json:"status"`
+	CreatedAt  time.Time     `json:"created_at"`
+	Resolution string        `json:"resolution,omitempty"` // the admin's note
+	ResolvedBy string        `json:"resolved_by,omitempty"`
+	ResolvedAt *time.Time    `json:"resolved_at,omitempty"`
+
+	// Scoring version the game was recomputed with when the dispute was upheld
+	RescoredWith string `json:"rescored_with,omitempty"`
+}
+
+// Payout records a prize owed to a winner once results are final.
+type Payout struct {
+	PlayerID   string    `json:"player_id"`
+	Username   string    `json:"username"`
+	UserID     string    `json:"user_id,omitempty"`
+	Rank       int       `json:"rank"`
+	Prize      string    `json:"prize"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// RankStandings orders standings by score, best first, and numbers their
+// ranks.
+func RankStandings(standings []Standing) {
+	for i := 1; i < len(standings); i++ {
+		for j := i; j > 0 && standings[j].Score > standings[j-1].Score; j-- {
+			standings[j], standings[j-1] = standings[j-1], standings[j]
+		}
+	}
+	for i := range standings {
+		standings[i].Rank = i + 1
+		if i > 0 && standings[i].Score == standings[i-1].Score {
+			standings[i].Rank = standings[i-1].Rank
+		}
+	}
+}
+
+// FindDispute returns the dispute with the given ID, or nil.
+func (r *PrizeResult) FindDispute(id int) *Dispute {
+	for _, dispute := range r.Disputes {
+		if dispute.ID == id {
+			return dispute
+		}
+	}
+	return nil
+}
+
+// OpenDisputes counts disputes awaiting an admin's decision.
+func (r *PrizeResult) OpenDisputes() int {
+	open := 0
+	for _, dispute := range r.Disputes {
+		if dispute.Status == DisputeOpen {
+			open++
+		}
+	}
+	return open
+}
+
+// Standing returns a player's standing, or nil if they didn't play.
+func (r *PrizeResult) Standing(playerID string) *Standing {
+	for i := range r.Standings {
+		if r.Standings[i].PlayerID == playerID {
+			return &r.Standings[i]
+		}
+	}
+	return nil
+}
+
+var ErrInvalidPrizePool = errors.New("invalid prize pool")
+
+// MaxPrizePlaces bounds how many finishers a prize pool is split between.
+const MaxPrizePlaces = 10
+
+// DefaultPrizeSplit is how a prize pool is shared when its lobby doesn't
+// say: 70% to the winner, 20% to second and 10% to third.
+var DefaultPrizeSplit = []int{70, 20, 10}
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// PrizePool is money shared between a prize game's top finishers.
+type PrizePool struct {
+	Amount   int64  `json:"amount"`   // in the currency's minor unit, e.g. cents
+	Currency string `json:"currency"` // ISO 4217 code, e.g. "USD"
+	Split    []int  `json:"split"`    // percent for first, second, ...; adds up to 100
+}
+
+// Validate checks the pool, filling in DefaultPrizeSplit when no split is
+// given. The amount may be 0 for a pool funded by entry fees alone.
+func (p *PrizePool) Validate() error {
+	if p.Amount < 0 {
+		return fmt.Errorf("%w: amount can't be negative", ErrInvalidPrizePool)
+	}
+	if !currencyPattern.MatchString(p.Currency) {
+		return fmt.Errorf("%w: currency must be a three-letter ISO 4217 code", ErrInvalidPrizePool)
+	}
+	if len(p.Split) == 0 {
+		p.Split = append([]int(nil), DefaultPrizeSplit...)
+	}
+	if len(p.Split) > MaxPrizePlaces {
+		return fmt.Errorf("%w: at most %d places", ErrInvalidPrizePool, MaxPrizePlaces)
+	}
+	total := 0
+	for _, percent := range p.Split {
+		if percent <= 0 {
+			return fmt.Errorf("%w: every place needs a positive share", ErrInvalidPrizePool)
+		}
+		total += percent
+	}
+	if total != 100 {
+		return fmt.Errorf("%w: split must add up to 100", ErrInvalidPrizePool)
+	}
+	return nil
+}
+
+// AllocationStatus is where a finisher's share of a prize pool is on the way
+// to being paid.
+type AllocationStatus string
+
+const (
+	AllocationPending   AllocationStatus = "pending"   // results still provisional
+	AllocationUnclaimed AllocationStatus = "unclaimed" // claim code issued
+	AllocationClaimed   AllocationStatus = "claimed"
+)
+
+// PayoutStatus is where the automatic payout of a claimed share stands.
+type PayoutStatus string
+
+const (
+	PayoutSending PayoutStatus = "sending" // being sent to the provider
+	PayoutPending PayoutStatus = "pending" // accepted by the provider, not yet confirmed
+	PayoutPaid    PayoutStatus = "paid"
+	PayoutFailed  PayoutStatus = "failed"  // refused by the provider, so nothing was paid
+	PayoutUnknown PayoutStatus = "unknown" // sent without an answer; to be reconciled with the provider
+)
+
+// Allocation is a finisher's share of a prize pool. Its claim code is issued
+// once the results are final and only ever shown to the finisher and admins,
+// like the destination it was claimed to.
+type Allocation struct {
+	Rank      int              `json:"rank"`
+	PlayerID  string           `json:"player_id"`
+	Username  string           `json:"username"`
+	UserID    string           `json:"user_id,omitempty"`
+	Amount    int64            `json:"amount"`
+	Currency  string           `json:"currency"`
+	Status    AllocationStatus `json:"status"`
+	ClaimCode string           `json:"claim_code,omitempty"`
+	ClaimedAt *time.Time       `json:"claimed_at,omitempty"`
+
+	// Set when the share is claimed with a payout provider configured
+	Destination     string       `json:"destination,omitempty"` // account, email or address to pay
+	PayoutProvider  string       `json:"payout_provider,omitempty"`
+	PayoutStatus    PayoutStatus `json:"payout_status,omitempty"`
+	PayoutReference string       `json:"payout_reference,omitempty"` // the provider's ID for the payout
+	PayoutError     string       `json:"payout_error,omitempty"`
+	PayoutAttempts  int          `json:"payout_attempts,omitempty"`
+	PaidAt          *time.Time   `json:"paid_at,omitempty"`
+}
+
+// Allocate shares the pool between ranked standings. Tied finishers split
+// the places they cover evenly. What rounding or places nobody finished in
+// leave over goes to the first allocation.
+func (p *PrizePool) Allocate(standings []Standing) []*Allocation {
+	var allocations []*Allocation
+	var allocated int64
+	for start := 0; start < len(standings); {
+		end := start
+		for end < len(standings) && standings[end].Rank == standings[start].Rank {
+			end++
+		}
+		percent := 0
+		for place := start; place < end && place < len(p.Split); place++ {
+			percent += p.Split[place]
+		}
+		if percent == 0 {
+			break
+		}
+		share := p.Amount * int64(percent) / 100 / int64(end-start)
+		for _, standing := range standings[start:end] {
+			allocations = append(allocations, &Allocation{
+				Rank:     standing.Rank,
+				PlayerID: standing.PlayerID,
+				Username: standing.Username,
+				UserID:   standing.UserID,
+				Amount:   share,
+				Currency: p.Currency,
+				Status:   AllocationPending,
+			})
+			allocated += share
+		}
+		start = end
+	}
+	if len(allocations) > 0 {
+		allocations[0].Amount += p.Amount - allocated
+	}
+	return allocations
+}
+
+// FindAllocation returns a player's share of the prize pool, or nil.
+func (r *PrizeResult) FindAllocation(playerID string) *Allocation {
+	for _, allocation := range r.Allocations {
+		if allocation.PlayerID == playerID {
+			return allocation
+		}
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// MaxReportReasonLength caps the reason a player gives for a report, in
+// characters.
+const MaxReportReasonLength = 500
+
+// PlayerReport is one player's report of another in a lobby, kept for admin
+// review.
+type PlayerReport struct {
+	ID               int64     `json:"id"`
+	LobbyID          string    `json:"lobby_id"`
+	ReporterID       string    `json:"reporter_id"`
+	ReporterUsername string    `json:"reporter_username"`
+	PlayerID         string    `json:"player_id"` // the reported player
+	Username         string    `json:"username"`
+	Reason           string    `json:"reason,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
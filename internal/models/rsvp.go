@@ -0,0 +1,96 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// RSVPStatus is an invited player's answer to a scheduled game's invitation.
+type RSVPStatus string
+
+const (
+	RSVPPending RSVPStatus = "pending" // invited, no answer yet
+	RSVPYes     RSVPStatus = "yes"
+	RSVPNo      RSVPStatus = "no"
+	RSVPMaybe   RSVPStatus = "maybe"
+)
+
+var ErrInvalidRSVP = errors.New("rsvp must be yes, no or maybe")
+
+// ParseRSVP checks an RSVP a player sent.
+func ParseRSVP(status string) (RSVPStatus, error) {
+	switch s := RSVPStatus(strings.ToLower(strings.TrimSpace(status))); s {
+	case RSVPYes, RSVPNo, RSVPMaybe:
+		return s, nil
+	}
+	return "", ErrInvalidRSVP
+}
+
+// Invite is a player invited to a scheduled game, by the normalized
+// username they're expected to join under, and their RSVP.
+type Invite struct {
+	Username    string     `json:"username"`
+	Status      RSVPStatus `json:"status"`
+	RespondedAt *time.Time `json:"responded_at,omitempty"`
+}
+
+// Attendance compares who said they're coming to a scheduled game with who
+// has joined.
+type Attendance struct {
+	Invited  int `json:"invited"`
+	Yes      int `json:"yes"`
+	Maybe    int `json:"maybe"`
+	No       int `json:"no"`
+	Pending  int `json:"pending"`
+	Expected int `json:"expected"`         // players who said yes
+	Joined   int `json:"joined"`           // of them, those seated now
+	Quorum   int `json:"quorum,omitempty"` // joined players the scheduled start waits for
+}
+
+// FindInvite returns username's invite, or nil if they weren't invited.
+func (l *Lobby) FindInvite(username string) *Invite {
+	for _, invite := range l.Invites {
+		if strings.EqualFold(invite.Username, username) {
+			return invite
+		}
+	}
+	return nil
+}
+
+// Attendance tallies the lobby's RSVPs against its players.
+func (l *Lobby) Attendance() *Attendance {
+	attendance := &Attendance{Invited: len(l.Invites), Quorum: l.RSVPQuorum}
+	for _, invite := range l.Invites {
+		switch invite.Status {
+		case RSVPYes:
+			attendance.Yes++
+			if l.playerNamed(invite.Username) {
+				attendance.Joined++
+			}
+		case RSVPMaybe:
+			attendance.Maybe++
+		case RSVPNo:
+			attendance.No++
+		default:
+			attendance.Pending++
+		}
+	}
+	attendance.Expected = attendance.Yes
+	return attendance
+}
+
+// QuorumReached reports whether enough players who said yes have joined for
+// a scheduled start, always true without a quorum.
+func (l *Lobby) QuorumReached() bool {
+	return l.RSVPQuorum == 0 || l.Attendance().Joined >= l.RSVPQuorum
+}
+
+func (l *Lobby) playerNamed(username string) bool {
+	for _, player := range l.Players {
+		if strings.EqualFold(player.Username, username) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,284 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"time"
+)
+
+var ErrInvalidScoringConfig = errors.New("invalid scoring config")
+
+var scoringVersionPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,32}$`)
+
+// ScoringConfig is one version of the scoring rules. Versions are stored and
+// never edited once created; a rule change gets a new version, and every game
+// records the version it was scored with so it can be recomputed under the
+// exact rules in effect.
+type ScoringConfig struct {
+	Version        string `json:"version"`
+	Name           string `json:"name"`
+	BaseScore      int    `json:"base_score"`
+	MaxTimeBonus   int    `json:"max_time_bonus"`
+	TimeBonusCurve string `json:"time_bonus_curve,omitempty"` // how the time bonus shrinks; linear when empty
+	AccuracyBonus  int    `json:"accuracy_bonus"`
+	PartialCredit  bool   `json:"partial_credit"` // multi-select picks earn a share of the base score
+
+	// Share of a correct answer's score added per correct answer before it
+	// in the streak, e.g. 0.1 scores the fourth in a row at x1.3, up to
+	// StreakCap (uncapped when zero).
+	StreakMultiplier float64 `json:"streak_multiplier,omitempty"`
+	StreakCap        float64 `json:"streak_cap,omitempty"`
+	// Points taken off for a wrong answer. Totals never go below zero.
+	WrongPenalty int `json:"wrong_penalty,omitempty"`
+	// Bonus points for the first, second and later correct answers to a
+	// question, in the order they arrived; each no more than the one before.
+	PositionBonuses []int `json:"position_bonuses,omitempty"`
+
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Time bonus curves.
+const (
+	TimeBonusLinear      = "linear"      // one point less per second taken
+	TimeBonusExponential = "exponential" // halves every TimeBonusHalfLife
+	TimeBonusNone        = "none"
+)
+
+const TimeBonusHalfLife = 5 * time.Second
+
+// LobbyScoringVersion is the version recorded for games scored with their
+// lobby's own rules (see ScoringRules). It can't be used for a stored version.
+const LobbyScoringVersion = "lobby"
+
+// MaxStreakMultiplier bounds ScoringConfig.StreakMultiplier.
+const MaxStreakMultiplier = 1.0
+
+// MaxPositionBonuses bounds how many correct answers a question's position
+// bonuses reach.
+const MaxPositionBonuses = 10
+
+// DefaultScoringConfig is the built-in version, used until another one is
+// activated.
+var DefaultScoringConfig = ScoringConfig{
+	Version:          "v1.1",
+	Name:             "Standard with streaks",
+	BaseScore:        100,
+	MaxTimeBonus:     50,
+	AccuracyBonus:    25,
+	PartialCredit:    true,
+	StreakMultiplier: 0.1,
+	StreakCap:        2,
+}
+
+// LegacyScoringConfig is the original built-in version, without streaks. It
+// scores games recorded before versions were stored.
+var LegacyScoringConfig = ScoringConfig{
+	Version:       "v1",
+	Name:          "Standard",
+	BaseScore:     100,
+	MaxTimeBonus:  50,
+	AccuracyBonus: 25,
+	PartialCredit: true,
+}
+
+// BuiltInScoringConfig returns a copy of the built-in version called version.
+func BuiltInScoringConfig(version string) (*ScoringConfig, bool) {
+	for _, builtIn := range []ScoringConfig{DefaultScoringConfig, LegacyScoringConfig} {
+		if builtIn.Version == version {
+			return &builtIn, true
+		}
+	}
+	return nil, false
+}
+
+func (c *ScoringConfig) Validate() error {
+	if !scoringVersionPattern.MatchString(c.Version) {
+		return fmt.Errorf("%w: version must be 1-32 letters, digits, '.', '_' or '-'", ErrInvalidScoringConfig)
+	}
+	if c.Version == LobbyScoringVersion {
+		return fmt.Errorf("%w: version %q is reserved for lobby scoring rules", ErrInvalidScoringConfig, LobbyScoringVersion)
+	}
+	return c.ValidateRules()
+}
+
+// ValidateRules checks the scoring rules alone, without the version.
+func (c *ScoringConfig) ValidateRules() error {
+	if c.BaseScore <= 0 {
+		return fmt.Errorf("%w: base_score must be positive", ErrInvalidScoringConfig)
+	}
+	if c.MaxTimeBonus < 0 || c.AccuracyBonus < 0 {
+		return fmt.Errorf("%w: bonuses can't be negative", ErrInvalidScoringConfig)
+	}
+	switch c.TimeBonusCurve {
+	case "", TimeBonusLinear, TimeBonusExponential, TimeBonusNone:
+	default:
+		return fmt.Errorf("%w: time_bonus_curve must be %q, %q or %q", ErrInvalidScoringConfig, TimeBonusLinear, TimeBonusExponential, TimeBonusNone)
+	}
+	if c.StreakMultiplier < 0 || c.StreakMultiplier > MaxStreakMultiplier {
+		return fmt.Errorf("%w: streak_multiplier must be between 0 and %g", ErrInvalidScoringConfig, MaxStreakMultiplier)
+	}
+	if c.StreakCap != 0 && c.StreakCap < 1 {
+		return fmt.Errorf("%w: streak_cap must be at least 1", ErrInvalidScoringConfig)
+	}
+	if c.WrongPenalty < 0 || c.WrongPenalty > c.BaseScore {
+		return fmt.Errorf("%w: wrong_penalty must be between 0 and base_score", ErrInvalidScoringConfig)
+	}
+	if len(c.PositionBonuses) > MaxPositionBonuses {
+		return fmt.Errorf("%w: at most %d position_bonuses", ErrInvalidScoringConfig, MaxPositionBonuses)
+	}
+	for i, bonus := range c.PositionBonuses {
+		if bonus < 0 || (i > 0 && bonus > c.PositionBonuses[i-1]) {
+			return fmt.Errorf("%w: position_bonuses can't be negative or grow", ErrInvalidScoringConfig)
+		}
+	}
+	return nil
+}
+
+// Score scores an answer to question, given responseTime in milliseconds
+// and the player's streak of correct answers before this one. Wrong answers
+// score minus the penalty, if there is one.
+func (c *ScoringConfig) Score(question *Question, answer SubmittedAnswer, responseTime int64, streak int) int {
+	if c.PartialCredit && question.QuestionType() == MultiSelect && !question.IsCorrect(answer) {
+		// Partial credit: each correct pick earns its share of the base score,
+		// each wrong pick takes one share away. No bonuses unless fully correct.
+		hits, misses := question.MultiSelectHits(answer)
+		share := c.BaseScore / len(question.CorrectAnswers)
+		if hits > misses {
+			return (hits - misses) * share
+		}
+	}
+
+	if question.QuestionType() == Numeric && !question.IsCorrect(answer) {
+		// Near guesses earn their proximity's share of the base score, again
+		// without bonuses
+		if proximity := question.Proximity(answer); proximity > 0 {
+			return int(math.Round(proximity * float64(c.BaseScore)))
+		}
+	}
+
+	return c.ScoreResult(question.IsCorrect(answer), responseTime, streak)
+}
+
+// ScoreResult scores an answer known only to be right or wrong, for answer
+// history stored without the question it was given to.
+func (c *ScoringConfig) ScoreResult(correct bool, responseTime int64, streak int) int {
+	if !correct {
+		return -c.WrongPenalty
+	}
+	score := c.BaseScore + c.timeBonus(responseTime) + c.AccuracyBonus
+	return int(math.Round(float64(score) * c.Multiplier(streak)))
+}
+
+// PositionBonus is the bonus for the position'th correct answer to a
+// question, counting from 1; wrong answers, at position 0, get none.
+func (c *ScoringConfig) PositionBonus(position int) int {
+	if position < 1 || position > len(c.PositionBonuses) {
+		return 0
+	}
+	return c.PositionBonuses[position-1]
+}
+
+// Multiplier is what a correct answer is multiplied by after streak correct
+// answers in a row.
+func (c *ScoringConfig) Multiplier(streak int) float64 {
+	multiplier := 1 + c.StreakMultiplier*float64(streak)
+	if c.StreakCap > 0 && multiplier > c.StreakCap {
+		return c.StreakCap
+	}
+	return multiplier
+}
+
+func (c *ScoringConfig) timeBonus(responseTime int64) int {
+	switch c.TimeBonusCurve {
+	case TimeBonusNone:
+		return 0
+	case TimeBonusExponential:
+		halvings := float64(responseTime) / float64(TimeBonusHalfLife.Milliseconds())
+		return int(math.Round(float64(c.MaxTimeBonus) * math.Pow(0.5, math.Max(0, halvings))))
+	}
+	return int(math.Max(0, float64(int64(c.MaxTimeBonus)-(responseTime/1000))))
+}
+
+// AddScore adds an answer's score to a total, which never goes below zero.
+func AddScore(total, score int) int {
+	if total+score < 0 {
+		return 0
+	}
+	return total + score
+}
+
+// ScoringRules are the scoring settings a lobby can be created with. Unset
+// rules are taken from the version the lobby would otherwise be scored with.
+type ScoringRules struct {
+	BaseScore        *int     `json:"base_score"`
+	MaxTimeBonus     *int     `json:"max_time_bonus"`
+	TimeBonusCurve   *string  `json:"time_bonus_curve"`
+	AccuracyBonus    *int     `json:"accuracy_bonus"`
+	PartialCredit    *bool    `json:"partial_credit"`
+	StreakMultiplier *float64 `json:"streak_multiplier"`
+	StreakCap        *float64 `json:"streak_cap"`
+	WrongPenalty     *int     `json:"wrong_penalty"`
+	PositionBonuses  *[]int   `json:"position_bonuses"`
+}
+
+// Apply returns base with the rules set here, as lobby scoring rules.
+func (r *ScoringRules) Apply(base ScoringConfig) (*ScoringConfig, error) {
+	config := base
+	config.Version = LobbyScoringVersion
+	config.Name = "Custom"
+	config.CreatedBy = ""
+	config.CreatedAt = time.Time{}
+	if r.BaseScore != nil {
+		config.BaseScore = *r.BaseScore
+	}
+	if r.MaxTimeBonus != nil {
+		config.MaxTimeBonus = *r.MaxTimeBonus
+	}
+	if r.TimeBonusCurve != nil {
+		config.TimeBonusCurve = *r.TimeBonusCurve
+	}
+	if r.AccuracyBonus != nil {
+		config.AccuracyBonus = *r.AccuracyBonus
+	}
+	if r.PartialCredit != nil {
+		config.PartialCredit = *r.PartialCredit
+	}
+	if r.StreakMultiplier != nil {
+		config.StreakMultiplier = *r.StreakMultiplier
+	}
+	if r.StreakCap != nil {
+		config.StreakCap = *r.StreakCap
+	}
+	if r.WrongPenalty != nil {
+		config.WrongPenalty = *r.WrongPenalty
+	}
+	if r.PositionBonuses != nil {
+		config.PositionBonuses = append([]int(nil), *r.PositionBonuses...)
+	}
+	if err := config.ValidateRules(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Scoring audit actions.
+const (
+	ScoringCreated    = "created"
+	ScoringActivated  = "activated"
+	ScoringRecomputed = "recomputed"
+)
+
+// ScoringAuditEntry records a change to scoring: a version created or
+// activated, or a game's scores rewritten by recomputation.
+type ScoringAuditEntry struct {
+	ID        int64                  `json:"id"`
+	Action    string                 `json:"action"`
+	Version   string                 `json:"version"`
+	LobbyID   string                 `json:"lobby_id,omitempty"`
+	Actor     string                 `json:"actor"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
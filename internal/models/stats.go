@@ -0,0 +1,128 @@
+package models
+
+import "time"
+
+// AnswerRecord is one persisted answer, kept for player analytics.
+type AnswerRecord struct {
+	ID             int64           `json:"id,omitempty"`
+	PlayerID       string          `json:"player_id"`
+	Username       string          `json:"username"`
+	UserID         string          `json:"user_id,omitempty"` // the player's account, if they were logged in
+	LobbyID        string          `json:"lobby_id"`
+	Round          int             `json:"round"`
+	QuestionID     string          `json:"question_id"`
+	Category       string          `json:"category"`
+	Question       *Question       `json:"question,omitempty"` // as served, so the answer can be re-scored
+	Answer         SubmittedAnswer `json:"answer"`
+	Correct        bool            `json:"correct"`
+	Score          int             `json:"score"`
+	ScoringVersion string          `json:"scoring_version,omitempty"`
+	ResponseTime   int64           `json:"response_time"`         // milliseconds, stopped early by Freeze
+	PowerUp        PowerUp         `json:"power_up,omitempty"`    // used on the question
+	Position       int             `json:"position,omitempty"`    // among the question's correct answers, from 1; 0 when wrong
+	WagerDelta     int             `json:"wager_delta,omitempty"` // the settled wager, on a wager round
+	AnsweredAt     time.Time       `json:"answered_at"`
+}
+
+// QuestionPerformance summarises how players did on one question across
+// every game it was asked in.
+type QuestionPerformance struct {
+	QuestionID       string  `json:"question_id"`
+	Answered         int     `json:"answered"`
+	Correct          int     `json:"correct"`
+	Accuracy         float64 `json:"accuracy"` // 0..1
+	MedianResponseMs int64   `json:"median_response_ms"`
+}
+
+// CategoryMastery summarises how well a player does in one category.
+type CategoryMastery struct {
+	Category string  `json:"category"`
+	Answered int     `json:"answered"`
+	Correct  int     `json:"correct"`
+	Accuracy float64 `json:"accuracy"` // 0..1
+}
+
+// PracticeRecommendation is a suggested practice lobby configuration.
+type PracticeRecommendation struct {
+	Categories      []string       `json:"categories"`
+	CategoryWeights map[string]int `json:"category_weights"`
+	Difficulty      string         `json:"difficulty"`
+	MaxRounds       int            `json:"max_rounds"`
+	Reason          string         `json:"reason"`
+}
+
+// GameStats summarises a finished game for the end screen. It's sent with
+// game_ended and stored with the lobby.
+type GameStats struct {
+	AvgResponseMs   int64                `json:"avg_response_ms"` // over every answer given
+	HardestQuestion *QuestionAccuracy    `json:"hardest_question,omitempty"`
+	Rounds          []RoundParticipation `json:"rounds"`
+	ChatMessages    int                  `json:"chat_messages"`
+}
+
+// QuestionAccuracy is how well a round's question was answered.
+type QuestionAccuracy struct {
+	Round      int     `json:"round"`
+	QuestionID string  `json:"question_id"`
+	Text       string  `json:"text"`
+	Category   string  `json:"category"`
+	Answered   int     `json:"answered"`
+	Correct    int     `json:"correct"`
+	Accuracy   float64 `json:"accuracy"` // 0..1
+}
+
+// RoundParticipation is how many of the players seated when a round started
+// answered it.
+type RoundParticipation struct {
+	Round    int     `json:"round"`
+	Players  int     `json:"players"`
+	Answered int     `json:"answered"`
+	Rate     float64 `json:"participation_rate"` // 0..1
+}
+
+// RoundTally counts a round's answers as they arrive, for GameStats.
+type RoundTally struct {
+	Round      int
+	Question   *Question
+	Players    int
+	Answered   int
+	Correct    int
+	ResponseMs int64 // total over the round's answers
+}
+
+// PlayerStats are an account's lifetime totals over the games it finished,
+// counting only answers and games outside sandboxes. CorrectRate and
+// AvgResponseMs are derived from the totals by Summarize.
+type PlayerStats struct {
+	UserID          string     `json:"user_id"`
+	Username        string     `json:"username,omitempty"` // the account's, filled in when read rather than stored
+	GamesPlayed     int        `json:"games_played"`
+	Wins            int        `json:"wins"` // finished first, ties included
+	Answered        int        `json:"answered"`
+	Correct         int        `json:"correct"`
+	CorrectRate     float64    `json:"correct_rate"` // 0..1
+	TotalResponseMs int64      `json:"total_response_ms"`
+	AvgResponseMs   int64      `json:"avg_response_ms"`
+	BestStreak      int        `json:"best_streak"`          // most correct answers in a row within a game
+	UpdatedAt       *time.Time `json:"updated_at,omitempty"` // when a game was last added
+}
+
+// Add folds one game's totals into s, keeping the better best streak.
+func (s *PlayerStats) Add(game *PlayerStats) {
+	s.GamesPlayed += game.GamesPlayed
+	s.Wins += game.Wins
+	s.Answered += game.Answered
+	s.Correct += game.Correct
+	s.TotalResponseMs += game.TotalResponseMs
+	s.BestStreak = max(s.BestStreak, game.BestStreak)
+	s.UpdatedAt = game.UpdatedAt
+}
+
+// Summarize fills CorrectRate and AvgResponseMs from the totals.
+func (s *PlayerStats) Summarize() {
+	s.CorrectRate, s.AvgResponseMs = 0, 0
+	if s.Answered > 0 {
+		s.CorrectRate = float64(s.Correct) / float64(s.Answered)
+		s.AvgResponseMs = s.TotalResponseMs / int64(s.Answered)
+	}
+}
@@ -0,0 +1,111 @@
+package models
+
+import "time"
+
+// StreakBonuses are the XP and coins accounts earn for keeping streaks
+// going across games. The first game an account finishes each day earns the
+// daily bonus times its day streak; a win extending a win streak earns the
+// win bonus times the wins before it. Streaks count towards a bonus up to
+// Cap days or wins, or without limit when Cap is zero.
+type StreakBonuses struct {
+	DailyXP    int64 `json:"daily_xp"`
+	DailyCoins int64 `json:"daily_coins"`
+	WinXP      int64 `json:"win_xp"`
+	WinCoins   int64 `json:"win_coins"`
+	Cap        int   `json:"cap"`
+}
+
+// DefaultStreakBonuses are used unless the server is configured otherwise.
+var DefaultStreakBonuses = StreakBonuses{DailyXP: 10, DailyCoins: 5, WinXP: 5, WinCoins: 2, Cap: 7}
+
+// DayStreakMilestones and WinStreakMilestones are the streak lengths
+// announced with account_streak_milestone.
+var (
+	DayStreakMilestones = map[int]bool{3: true, 7: true, 30: true, 100: true}
+	WinStreakMilestones = map[int]bool{3: true, 5: true, 10: true}
+)
+
+// AccountStreak is an account's run of days played and of games won across
+// the games it finished, counted like PlayerStats, and the bonuses the runs
+// earned it. Days are calendar days in UTC.
+type AccountStreak struct {
+	UserID        string     `json:"user_id"`
+	DayStreak     int        `json:"day_streak"` // days in a row with a game finished, up to LastPlayedOn
+	BestDayStreak int        `json:"best_day_streak"`
+	WinStreak     int        `json:"win_streak"` // games won in a row, ties included
+	BestWinStreak int        `json:"best_win_streak"`
+	LastPlayedOn  string     `json:"last_played_on,omitempty"` // YYYY-MM-DD
+	XP            int64      `json:"xp"`                       // bonus XP earned, in total
+	Coins         int64      `json:"coins"`                    // bonus coins earned, in total
+	UpdatedAt     *time.Time `json:"updated_at,omitempty"`
+}
+
+// StreakAward is what one finished game added to an account's streaks: the
+// bonuses it earned and the milestones it reached, 0 for none.
+type StreakAward struct {
+	XP           int64
+	Coins        int64
+	DayMilestone int
+	WinMilestone int
+}
+
+// Current returns the streak as of now: a day streak whose last day was
+// before yesterday has been broken, though it's only reset when the next
+// game is recorded.
+func (s AccountStreak) Current(now time.Time) AccountStreak {
+	yesterday := now.UTC().AddDate(0, 0, -1).Format(time.DateOnly)
+	if s.LastPlayedOn < yesterday {
+		s.DayStreak = 0
+	}
+	return s
+}
+
+// Record adds a game finished at finishedAt to the streaks, won or not, and
+// credits the bonuses it earned.
+func (s *AccountStreak) Record(finishedAt time.Time, won bool, bonuses StreakBonuses) StreakAward {
+	var award StreakAward
+	day := finishedAt.UTC()
+	today := day.Format(time.DateOnly)
+	// A game recorded late, after one on a later day, doesn't start a new day
+	if today > s.LastPlayedOn {
+		if s.LastPlayedOn == day.AddDate(0, 0, -1).Format(time.DateOnly) {
+			s.DayStreak++
+		} else {
+			s.DayStreak = 1
+		}
+		s.LastPlayedOn = today
+		s.BestDayStreak = max(s.BestDayStreak, s.DayStreak)
+		counted := bonuses.counted(s.DayStreak)
+		award.XP += bonuses.DailyXP * counted
+		award.Coins += bonuses.DailyCoins * counted
+		if DayStreakMilestones[s.DayStreak] {
+			award.DayMilestone = s.DayStreak
+		}
+	}
+
+	if won {
+		s.WinStreak++
+		s.BestWinStreak = max(s.BestWinStreak, s.WinStreak)
+		counted := bonuses.counted(s.WinStreak - 1)
+		award.XP += bonuses.WinXP * counted
+		award.Coins += bonuses.WinCoins * counted
+		if WinStreakMilestones[s.WinStreak] {
+			award.WinMilestone = s.WinStreak
+		}
+	} else {
+		s.WinStreak = 0
+	}
+
+	s.XP += award.XP
+	s.Coins += award.Coins
+	s.UpdatedAt = &finishedAt
+	return award
+}
+
+// counted caps a streak length at the bonuses' Cap.
+func (b StreakBonuses) counted(streak int) int64 {
+	if b.Cap > 0 && streak > b.Cap {
+		streak = b.Cap
+	}
+	return int64(streak)
+}
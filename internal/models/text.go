@@ -0,0 +1,203 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Length limits, counted in user-perceived characters (see Graphemes), so an
+// accented letter or a flag emoji counts once however it's encoded.
+const (
+	MaxUsernameLength    = 20
+	MaxChatMessageLength = 300
+)
+
+var (
+	ErrUsernameEmpty        = errors.New("username is empty")
+	ErrUsernameTooLong      = errors.New("username is longer than 20 characters")
+	ErrUsernameInvisible    = errors.New("username contains control or invisible characters")
+	ErrUsernameMixedScripts = errors.New("username mixes letters from different scripts, e.g. Latin and Cyrillic")
+	ErrUsernameConfusable   = errors.New("username is taken or looks like an existing player's")
+
+	ErrChatMessageEmpty   = errors.New("chat message is empty")
+	ErrChatMessageTooLong = errors.New("chat message is longer than 300 characters")
+)
+
+// NormalizeUsername returns the canonical form of a username: NFC-normalized
+// with surrounding whitespace trimmed and inner runs collapsed to one space.
+// Control and invisible formatting characters (zero-width spaces, bidi
+// overrides) are rejected rather than stripped, as are letters from more
+// than one script outside the combinations a language writes together.
+func NormalizeUsername(name string) (string, error) {
+	name = norm.NFC.String(name)
+	for _, r := range name {
+		if unicode.IsControl(r) || unicode.Is(unicode.Cf, r) {
+			return "", ErrUsernameInvisible
+		}
+	}
+	name = strings.Join(strings.Fields(name), " ")
+
+	switch n := Graphemes(name); {
+	case n == 0:
+		return "", ErrUsernameEmpty
+	case n > MaxUsernameLength:
+		return "", ErrUsernameTooLong
+	}
+	if mixedScripts(name) {
+		return "", ErrUsernameMixedScripts
+	}
+	return name, nil
+}
+
+// NormalizeChatMessage NFC-normalizes a chat message, drops control and bidi
+// override characters (which can make text render misleadingly) and trims
+// it. Newlines and tabs become spaces; zero-width joiners stay for emoji.
+func NormalizeChatMessage(text string) (string, error) {
+	text = norm.NFC.String(text)
+	text = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n' || r == '\t':
+			return ' '
+		case unicode.IsControl(r), isBidiControl(r):
+			return -1
+		}
+		return r
+	}, text)
+	text = strings.TrimSpace(text)
+
+	switch n := Graphemes(text); {
+	case n == 0:
+		return "", ErrChatMessageEmpty
+	case n > MaxChatMessageLength:
+		return "", ErrChatMessageTooLong
+	}
+	return text, nil
+}
+
+// UsernameSkeleton maps a username onto a form in which names that look
+// alike are equal: compatibility-normalized, case-folded, and with lookalike
+// letters from other scripts and digits replaced by the Latin letter they
+// resemble (the common cases from Unicode's confusables data). "Аlice" with
+// a Cyrillic А, "ALICE" and "a1ice" all share alice's skeleton.
+func UsernameSkeleton(name string) string {
+	name = norm.NFKC.String(name)
+	var b strings.Builder
+	for _, r := range name {
+		if latin, ok := confusables[r]; ok {
+			b.WriteRune(latin)
+			continue
+		}
+		r = unicode.ToLower(r)
+		if latin, ok := confusables[r]; ok {
+			r = latin
+		}
+		b.WriteRune(r)
+	}
+	skeleton := strings.ReplaceAll(b.String(), "rn", "m")
+	return strings.Join(strings.Fields(skeleton), " ")
+}
+
+// confusables maps characters commonly used to impersonate Latin names to
+// the lowercase Latin letter they resemble. Uppercase forms are listed
+// separately since their lowercase forms often look different (Cyrillic В
+// and в). i, l, 1 and | all map to l, as a capital I looks like all of them.
+var confusables = map[rune]rune{
+	// Cyrillic
+	'А': 'a', 'В': 'b', 'Е': 'e', 'К': 'k', 'М': 'm', 'Н': 'h', 'О': 'o', 'Р': 'p',
+	'С': 'c', 'Т': 't', 'Х': 'x', 'У': 'y', 'І': 'l', 'Ј': 'j', 'Ѕ': 's',
+	'а': 'a', 'е': 'e', 'о': 'o', 'р': 'p', 'с': 'c', 'у': 'y', 'х': 'x',
+	'і': 'l', 'ӏ': 'l', 'ј': 'j', 'ѕ': 's', 'ԁ': 'd', 'һ': 'h', 'ԛ': 'q', 'ԝ': 'w',
+	// Greek
+	'Α': 'a', 'Β': 'b', 'Ε': 'e', 'Ζ': 'z', 'Η': 'h', 'Ι': 'l', 'Κ': 'k', 'Μ': 'm',
+	'Ν': 'n', 'Ο': 'o', 'Ρ': 'p', 'Τ': 't', 'Υ': 'y', 'Χ': 'x',
+	'α': 'a', 'ο': 'o', 'ρ': 'p', 'ν': 'v', 'κ': 'k', 'ι': 'l', 'υ': 'u',
+	// Latin lookalikes
+	'i': 'l', 'ı': 'l', '1': 'l', '|': 'l', '0': 'o', 'ɡ': 'g',
+}
+
+// Scripts told apart when checking a username for mixed scripts. Han, kana
+// and Hangul can appear together (Japanese and Korean names); any other
+// pair can't.
+var usernameScripts = []struct {
+	name  string
+	table *unicode.RangeTable
+}{
+	{"Latin", unicode.Latin},
+	{"Cyrillic", unicode.Cyrillic},
+	{"Greek", unicode.Greek},
+	{"Armenian", unicode.Armenian},
+	{"Georgian", unicode.Georgian},
+	{"Arabic", unicode.Arabic},
+	{"Hebrew", unicode.Hebrew},
+	{"Devanagari", unicode.Devanagari},
+	{"Thai", unicode.Thai},
+	{"CJK", unicode.Han},
+	{"CJK", unicode.Hiragana},
+	{"CJK", unicode.Katakana},
+	{"CJK", unicode.Hangul},
+}
+
+func mixedScripts(name string) bool {
+	seen := ""
+	for _, r := range name {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		for _, script := range usernameScripts {
+			if !unicode.Is(script.table, r) {
+				continue
+			}
+			if seen != "" && seen != script.name {
+				return true
+			}
+			seen = script.name
+			break
+		}
+	}
+	return false
+}
+
+func isBidiControl(r rune) bool {
+	return (r >= '\u202A' && r <= '\u202E') || (r >= '\u2066' && r <= '\u2069') ||
+		r == '\u200E' || r == '\u200F' || r == '\u061C'
+}
+
+// Graphemes counts the user-perceived characters in s. It approximates
+// Unicode's extended grapheme clusters: combining marks, variation
+// selectors, emoji skin-tone modifiers and tag characters attach to the
+// character before them, a zero-width joiner glues the characters on either
+// side together, and regional indicators pair up into flags.
+func Graphemes(s string) int {
+	count := 0
+	joined := false
+	regional := 0
+	for _, r := range s {
+		switch {
+		case r == '\u200D':
+			joined = true
+			continue
+		case unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc),
+			r >= '\uFE00' && r <= '\uFE0F',
+			r >= 0xE0100 && r <= 0xE01EF,
+			r >= 0x1F3FB && r <= 0x1F3FF,
+			r >= 0xE0020 && r <= 0xE007F:
+			continue
+		case r >= 0x1F1E6 && r <= 0x1F1FF:
+			regional++
+			if regional%2 == 0 {
+				continue
+			}
+		default:
+			regional = 0
+		}
+		if joined {
+			joined = false
+			continue
+		}
+		count++
+	}
+	return count
+}
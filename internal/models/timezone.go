@@ -0,0 +1,63 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// DefaultTimezone is used for lobbies that don't set one.
+const DefaultTimezone = "UTC"
+
+// TimestampFormat is RFC3339 with millisecond precision. Timestamps sent
+// as strings use it so clients can parse them with Date.parse.
+const TimestampFormat = "2006-01-02T15:04:05.000Z07:00"
+
+var ErrInvalidTimezone = errors.New("invalid timezone")
+
+// Now returns the current time in UTC. All stored and returned timestamps
+// go through it so they serialize as RFC3339 UTC.
+func Now() time.Time {
+	return time.Now().UTC()
+}
+
+// FormatTimestamp formats t as an RFC3339 UTC string.
+func FormatTimestamp(t time.Time) string {
+	return t.UTC().Format(TimestampFormat)
+}
+
+// ValidateTimezone checks that name is an IANA timezone such as
+// "Europe/London". An empty name means DefaultTimezone.
+func ValidateTimezone(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return ErrInvalidTimezone
+	}
+	return nil
+}
+
+// Location returns the lobby's timezone, falling back to UTC.
+func (l *Lobby) Location() *time.Location {
+	if l.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(l.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// LocalTime converts t into the lobby's timezone, for display.
+func (l *Lobby) LocalTime(t time.Time) time.Time {
+	return t.In(l.Location())
+}
+
+// DayStart returns the start of the lobby-local day containing t, in UTC.
+// Daily challenges roll over at this boundary.
+func (l *Lobby) DayStart(t time.Time) time.Time {
+	local := l.LocalTime(t)
+	year, month, day := local.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, local.Location()).UTC()
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// User is a registered account. Players who join a lobby logged in are tied
+// to it, so their scores, history and prizes follow the account rather than
+// a throwaway username.
+type User struct {
+	ID           string    `json:"id"`
+	Username     string    `json:"username"` // normalized, unique ignoring case
+	PasswordHash string    `json:"password_hash"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func NewUser(username, passwordHash string) *User {
+	return &User{
+		ID:           uuid.New().String(),
+		Username:     username,
+		PasswordHash: passwordHash,
+		CreatedAt:    Now(),
+	}
+}
@@ -0,0 +1,42 @@
+package models
+
+// Wager is a player's stake on a wager round's question. A correct answer
+// doubles the stake, winning it again; a wrong answer or none loses it.
+type Wager struct {
+	PlayerID string `json:"player_id"`
+	Username string `json:"username"`
+	Stake    int    `json:"stake"`
+	Won      bool   `json:"won"`
+	Delta    int    `json:"delta"` // the score change, once settled
+	AnswerID int64  `json:"-"`     // the recorded answer the delta is saved with
+}
+
+// IsWagerRound reports whether round opens with a wager phase, as the final
+// round always does.
+func (l *Lobby) IsWagerRound(round int) bool {
+	if l.IsFinalRound(round) {
+		return true
+	}
+	for _, r := range l.WagerRounds {
+		if r == round {
+			return true
+		}
+	}
+	return false
+}
+
+// FinalAnswer is a player's answer to the final round's question, kept from
+// the lobby until the round's results reveal it.
+type FinalAnswer struct {
+	PlayerID string          `json:"player_id"`
+	Username string          `json:"username"`
+	Answer   SubmittedAnswer `json:"answer"`
+	Correct  bool            `json:"correct"`
+	Score    int             `json:"score"`
+}
+
+// IsFinalRound reports whether round is the last round of a lobby playing a
+// final round, whose wagers and answers stay hidden until its results.
+func (l *Lobby) IsFinalRound(round int) bool {
+	return l.FinalWager && round == l.MaxRounds
+}
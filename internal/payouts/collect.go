@@ -0,0 +1,70 @@
+package payouts
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Collector takes payments, such as lobby entry fees, through the same
+// provider prizes are paid out with. Providers that can only pay out don't
+// implement it.
+type Collector interface {
+	// CreatePayment starts a payment for the player to complete: with
+	// Stripe.js and the client secret, or at PayPal's checkout URL.
+	CreatePayment(ctx context.Context, req PaymentRequest) (*Payment, error)
+	// ConfirmPayment looks a payment up, capturing it first if the player
+	// has approved it and the provider needs that.
+	ConfirmPayment(ctx context.Context, reference string) (*Payment, error)
+	// RefundPayment gives a completed payment back in full. Retries with
+	// the same idempotency key refund it once; an error wrapping
+	// ErrRejected means nothing was refunded, any other leaves it unknown.
+	// The result is paid once the money is on its way back.
+	RefundPayment(ctx context.Context, reference, idempotencyKey string) (*Result, error)
+}
+
+// PaymentRequest is a payment to collect from a player.
+type PaymentRequest struct {
+	LobbyID     string
+	Username    string
+	Amount      int64  // in the currency's minor unit, e.g. cents
+	Currency    string // ISO 4217, upper case
+	Description string
+}
+
+// Payment is a payment as the provider has it. Status is paid once the
+// money has been received.
+type Payment struct {
+	Reference    string `json:"payment_id"`
+	Status       Status `json:"status"`
+	LobbyID      string `json:"lobby_id"`
+	Amount       int64  `json:"amount"`
+	Currency     string `json:"currency"`
+	ClientSecret string `json:"client_secret,omitempty"` // Stripe: confirm it with Stripe.js
+	CheckoutURL  string `json:"checkout_url,omitempty"`  // PayPal: where the player approves it
+}
+
+// minorAmount reads a decimal amount as minor units, e.g. "12.50" USD as
+// 1250.
+func minorAmount(value, currency string) (int64, error) {
+	whole, fraction, _ := strings.Cut(value, ".")
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	if zeroDecimal[currency] {
+		if strings.Trim(fraction, "0") != "" {
+			return 0, fmt.Errorf("invalid amount %q for %s", value, currency)
+		}
+		return units, nil
+	}
+	if len(fraction) > 2 {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	cents, err := strconv.ParseInt((fraction + "00")[:2], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	return units*100 + cents, nil
+}
@@ -0,0 +1,102 @@
+// Package payouts sends claimed prize pool shares to the players who won
+// them through a payment provider: Stripe Connect transfers, PayPal payouts
+// or a crypto wallet service. Stripe and PayPal also collect payments, such
+// as lobby entry fees (see Collector).
+package payouts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+var (
+	ErrInvalidDestination = errors.New("invalid payout destination")
+	ErrInvalidSignature   = errors.New("invalid webhook signature")
+
+	// ErrRejected marks a request the provider turned down, so nothing was
+	// paid. Any other Send error, such as a timeout or a server error,
+	// leaves it unknown whether the payout went through.
+	ErrRejected = errors.New("rejected by the provider")
+)
+
+// Status is where a payout stands with its provider.
+type Status string
+
+const (
+	StatusPending Status = "pending" // accepted, not yet confirmed
+	StatusPaid    Status = "paid"
+	StatusFailed  Status = "failed"
+	StatusUnknown Status = "unknown" // sent, but the provider's answer was lost
+)
+
+// Request is one payout: a claimed share of a lobby's prize pool.
+type Request struct {
+	LobbyID     string
+	PlayerID    string
+	Amount      int64  // in the currency's minor unit, e.g. cents
+	Currency    string // ISO 4217, upper case
+	Destination string // the provider's account, email or address to pay
+	// IdempotencyKey is the same when a request is retried, so the provider
+	// pays it at most once
+	IdempotencyKey string
+}
+
+// Result is how a provider answered a payout request.
+type Result struct {
+	Reference string // the provider's ID for the payout
+	Status    Status
+}
+
+// Update is a change of a payout's status reported by the provider's
+// webhook.
+type Update struct {
+	LobbyID   string
+	PlayerID  string
+	Reference string
+	Status    Status
+	Error     string // why it failed
+}
+
+// Provider pays out prize pool shares. Send may take a while; a payout that
+// isn't settled right away is reported pending and confirmed later by the
+// provider's webhook.
+type Provider interface {
+	Name() string
+	// CheckDestination validates where a player asked to be paid.
+	CheckDestination(destination string) error
+	Send(ctx context.Context, req Request) (*Result, error)
+	// ParseWebhook verifies a webhook delivery and returns the status update
+	// it carries, or nil for events that aren't about payouts.
+	ParseWebhook(ctx context.Context, header http.Header, body []byte) (*Update, error)
+}
+
+// statusError wraps the error for a provider's HTTP error status in
+// ErrRejected when the status means the request was refused: a client error
+// other than a timeout, a conflict with the same request still in flight or
+// a rate limit, which the same request can get past later.
+func statusError(status int, err error) error {
+	if status >= 400 && status < 500 && status != 408 && status != 409 && status != 429 {
+		return fmt.Errorf("%w: %v", ErrRejected, err)
+	}
+	return err
+}
+
+// zeroDecimal lists currencies with no minor unit, whose amounts the
+// providers take as they are.
+var zeroDecimal = map[string]bool{
+	"BIF": true, "CLP": true, "DJF": true, "GNF": true, "HUF": true, "JPY": true, "KMF": true,
+	"KRW": true, "MGA": true, "PYG": true, "RWF": true, "TWD": true, "UGX": true, "VND": true,
+	"VUV": true, "XAF": true, "XOF": true, "XPF": true,
+}
+
+// decimalAmount writes an amount in minor units as a decimal, e.g. 1250 USD
+// as "12.50".
+func decimalAmount(amount int64, currency string) string {
+	if zeroDecimal[currency] {
+		return strconv.FormatInt(amount, 10)
+	}
+	return fmt.Sprintf("%d.%02d", amount/100, amount%100)
+}
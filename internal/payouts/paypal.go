@@ -0,0 +1,337 @@
+package payouts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	paypalAPI        = "https://api-m.paypal.com"
+	paypalSandboxAPI = "https://api-m.sandbox.paypal.com"
+)
+
+// PayPalProvider pays players by email with PayPal Payouts. Payouts are
+// pending until PayPal's webhook confirms them succeeded or failed. It
+// collects payments with Orders, captured once the player approves them.
+type PayPalProvider struct {
+	clientID     string
+	clientSecret string
+	webhookID    string // the webhook deliveries are verified against
+	baseURL      string
+	client       *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+func NewPayPalProvider(clientID, clientSecret, webhookID string, sandbox bool, timeout time.Duration) *PayPalProvider {
+	baseURL := paypalAPI
+	if sandbox {
+		baseURL = paypalSandboxAPI
+	}
+	return &PayPalProvider{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		webhookID:    webhookID,
+		baseURL:      baseURL,
+		client:       &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *PayPalProvider) Name() string { return "paypal" }
+
+func (p *PayPalProvider) CheckDestination(destination string) error {
+	address, err := mail.ParseAddress(destination)
+	if err != nil || address.Address != destination {
+		return fmt.Errorf("%w: expected a PayPal account email", ErrInvalidDestination)
+	}
+	return nil
+}
+
+func (p *PayPalProvider) Send(ctx context.Context, req Request) (*Result, error) {
+	payout := map[string]interface{}{
+		"sender_batch_header": map[string]string{
+			"sender_batch_id": req.IdempotencyKey,
+			"email_subject":   "You won a quiz prize",
+		},
+		"items": []map[string]interface{}{{
+			"recipient_type": "EMAIL",
+			"receiver":       req.Destination,
+			"amount": map[string]string{
+				"value":    decimalAmount(req.Amount, req.Currency),
+				"currency": req.Currency,
+			},
+			"sender_item_id": req.LobbyID + ":" + req.PlayerID,
+		}},
+	}
+	var body struct {
+		BatchHeader struct {
+			PayoutBatchID string `json:"payout_batch_id"`
+		} `json:"batch_header"`
+	}
+	if err := p.call(ctx, "POST", "/v1/payments/payouts", payout, "", &body); err != nil {
+		return nil, err
+	}
+	return &Result{Reference: body.BatchHeader.PayoutBatchID, Status: StatusPending}, nil
+}
+
+// ParseWebhook has PayPal verify the delivery's signature headers, then
+// reads payout item events.
+func (p *PayPalProvider) ParseWebhook(ctx context.Context, header http.Header, body []byte) (*Update, error) {
+	verification := map[string]interface{}{
+		"auth_algo":         header.Get("PAYPAL-AUTH-ALGO"),
+		"cert_url":          header.Get("PAYPAL-CERT-URL"),
+		"transmission_id":   header.Get("PAYPAL-TRANSMISSION-ID"),
+		"transmission_sig":  header.Get("PAYPAL-TRANSMISSION-SIG"),
+		"transmission_time": header.Get("PAYPAL-TRANSMISSION-TIME"),
+		"webhook_id":        p.webhookID,
+		"webhook_event":     json.RawMessage(body),
+	}
+	var verified struct {
+		VerificationStatus string `json:"verification_status"`
+	}
+	if err := p.call(ctx, "POST", "/v1/notifications/verify-webhook-signature", verification, "", &verified); err != nil {
+		return nil, err
+	}
+	if verified.VerificationStatus != "SUCCESS" {
+		return nil, ErrInvalidSignature
+	}
+
+	var event struct {
+		EventType string `json:"event_type"`
+		Resource  struct {
+			PayoutBatchID string `json:"payout_batch_id"`
+			PayoutItem    struct {
+				SenderItemID string `json:"sender_item_id"`
+			} `json:"payout_item"`
+			Errors struct {
+				Message string `json:"message"`
+			} `json:"errors"`
+		} `json:"resource"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, err
+	}
+	outcome, isItem := strings.CutPrefix(event.EventType, "PAYMENT.PAYOUTS-ITEM.")
+	lobbyID, playerID, ok := strings.Cut(event.Resource.PayoutItem.SenderItemID, ":")
+	if !isItem || !ok {
+		return nil, nil
+	}
+
+	update := &Update{LobbyID: lobbyID, PlayerID: playerID, Reference: event.Resource.PayoutBatchID}
+	switch outcome {
+	case "SUCCEEDED":
+		update.Status = StatusPaid
+	case "FAILED", "BLOCKED", "DENIED", "RETURNED", "REFUNDED", "CANCELED", "UNCLAIMED":
+		update.Status = StatusFailed
+		update.Error = strings.ToLower(outcome)
+		if event.Resource.Errors.Message != "" {
+			update.Error += ": " + event.Resource.Errors.Message
+		}
+	default:
+		return nil, nil
+	}
+	return update, nil
+}
+
+// paypalOrder is the part of an order payments are read from.
+type paypalOrder struct {
+	ID            string `json:"id"`
+	Status        string `json:"status"`
+	PurchaseUnits []struct {
+		CustomID string `json:"custom_id"`
+		Amount   struct {
+			CurrencyCode string `json:"currency_code"`
+			Value        string `json:"value"`
+		} `json:"amount"`
+		Payments struct {
+			Captures []struct {
+				ID string `json:"id"`
+			} `json:"captures"`
+		} `json:"payments"`
+	} `json:"purchase_units"`
+	Links []struct {
+		Rel  string `json:"rel"`
+		Href string `json:"href"`
+	} `json:"links"`
+}
+
+// CreatePayment creates an order for the player to approve at the returned
+// checkout URL.
+func (p *PayPalProvider) CreatePayment(ctx context.Context, req PaymentRequest) (*Payment, error) {
+	order := map[string]interface{}{
+		"intent": "CAPTURE",
+		"purchase_units": []map[string]interface{}{{
+			"custom_id":   req.LobbyID,
+			"description": req.Description,
+			"amount": map[string]string{
+				"currency_code": req.Currency,
+				"value":         decimalAmount(req.Amount, req.Currency),
+			},
+		}},
+	}
+	var created paypalOrder
+	if err := p.call(ctx, "POST", "/v2/checkout/orders", order, "", &created); err != nil {
+		return nil, err
+	}
+	payment := &Payment{Reference: created.ID, Status: StatusPending, LobbyID: req.LobbyID, Amount: req.Amount, Currency: req.Currency}
+	for _, link := range created.Links {
+		if link.Rel == "approve" || link.Rel == "payer-action" {
+			payment.CheckoutURL = link.Href
+		}
+	}
+	return payment, nil
+}
+
+func (p *PayPalProvider) ConfirmPayment(ctx context.Context, reference string) (*Payment, error) {
+	path := "/v2/checkout/orders/" + url.PathEscape(reference)
+	var order paypalOrder
+	if err := p.call(ctx, "GET", path, nil, "", &order); err != nil {
+		return nil, err
+	}
+	if len(order.PurchaseUnits) != 1 {
+		return nil, fmt.Errorf("paypal order %s has %d purchase units", reference, len(order.PurchaseUnits))
+	}
+	unit := order.PurchaseUnits[0]
+	amount, err := minorAmount(unit.Amount.Value, unit.Amount.CurrencyCode)
+	if err != nil {
+		return nil, err
+	}
+
+	status := order.Status
+	if status == "APPROVED" {
+		var captured paypalOrder
+		if err := p.call(ctx, "POST", path+"/capture", map[string]interface{}{}, "", &captured); err != nil {
+			return nil, err
+		}
+		status = captured.Status
+	}
+	payment := &Payment{Reference: order.ID, Status: StatusPending, LobbyID: unit.CustomID, Amount: amount, Currency: unit.Amount.CurrencyCode}
+	switch status {
+	case "COMPLETED":
+		payment.Status = StatusPaid
+	case "VOIDED":
+		payment.Status = StatusFailed
+	}
+	return payment, nil
+}
+
+// RefundPayment refunds the capture of a completed order in full.
+func (p *PayPalProvider) RefundPayment(ctx context.Context, reference, idempotencyKey string) (*Result, error) {
+	var order paypalOrder
+	if err := p.call(ctx, "GET", "/v2/checkout/orders/"+url.PathEscape(reference), nil, "", &order); err != nil {
+		return nil, err
+	}
+	if len(order.PurchaseUnits) != 1 || len(order.PurchaseUnits[0].Payments.Captures) != 1 {
+		return nil, fmt.Errorf("%w: paypal order %s has no capture to refund", ErrRejected, reference)
+	}
+	capture := order.PurchaseUnits[0].Payments.Captures[0].ID
+	var refund struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+	}
+	if err := p.call(ctx, "POST", "/v2/payments/captures/"+url.PathEscape(capture)+"/refund", map[string]interface{}{}, idempotencyKey, &refund); err != nil {
+		return nil, err
+	}
+	switch refund.Status {
+	case "COMPLETED":
+		return &Result{Reference: refund.ID, Status: StatusPaid}, nil
+	case "CANCELLED", "FAILED":
+		return nil, fmt.Errorf("%w: paypal refund %s %s", ErrRejected, refund.ID, strings.ToLower(refund.Status))
+	}
+	return &Result{Reference: refund.ID, Status: StatusPending}, nil
+}
+
+// call makes a JSON request to the PayPal API with an access token; GET
+// requests have no body. A request ID makes retries of the request
+// idempotent.
+func (p *PayPalProvider) call(ctx context.Context, method, path string, request interface{}, requestID string, response interface{}) error {
+	token, err := p.token(ctx)
+	if err != nil {
+		return err
+	}
+	var body io.Reader
+	if request != nil {
+		payload, err := json.Marshal(request)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(payload)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, p.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+	httpReq.Header.Set("Content-Type", "application/json")
+	if requestID != "" {
+		httpReq.Header.Set("PayPal-Request-Id", requestID)
+	}
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("paypal request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var failure struct {
+			Name    string `json:"name"`
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&failure)
+		err := fmt.Errorf("paypal returned status %d for %s: %s", resp.StatusCode, path, failure.Message)
+		if failure.Name == "SENDER_BATCH_ID_ALREADY_USED" || failure.Name == "DUPLICATE_REQUEST_ID" {
+			// An earlier request with this idempotency key got through
+			return err
+		}
+		return statusError(resp.StatusCode, err)
+	}
+	return json.NewDecoder(resp.Body).Decode(response)
+}
+
+// token returns an OAuth access token, fetching a new one shortly before
+// the last expires.
+func (p *PayPalProvider) token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.accessToken != "" && time.Now().Before(p.expiresAt) {
+		return p.accessToken, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/v1/oauth2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	httpReq.SetBasicAuth(p.clientID, p.clientSecret)
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("paypal token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("paypal token request returned status %d", resp.StatusCode)
+	}
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"` // seconds
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	p.accessToken = body.AccessToken
+	p.expiresAt = time.Now().Add(time.Duration(body.ExpiresIn)*time.Second - time.Minute)
+	return p.accessToken, nil
+}
@@ -0,0 +1,257 @@
+package payouts
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const stripeAPI = "https://api.stripe.com"
+
+// stripeSignatureTolerance is how old a webhook delivery may be, against
+// replays.
+const stripeSignatureTolerance = 5 * time.Minute
+
+var stripeAccountPattern = regexp.MustCompile(`^acct_[A-Za-z0-9]{6,64}$`)
+
+// StripeProvider pays players with Stripe Connect transfers to their
+// connected account ("acct_..."). Transfers settle to the account's balance
+// at once, so they're paid when sent; the webhook reports reversals. It
+// collects payments with PaymentIntents.
+type StripeProvider struct {
+	secretKey     string
+	webhookSecret string // signs webhook deliveries ("whsec_...")
+	baseURL       string
+	client        *http.Client
+}
+
+func NewStripeProvider(secretKey, webhookSecret string, timeout time.Duration) *StripeProvider {
+	return &StripeProvider{
+		secretKey:     secretKey,
+		webhookSecret: webhookSecret,
+		baseURL:       stripeAPI,
+		client:        &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *StripeProvider) Name() string { return "stripe" }
+
+func (p *StripeProvider) CheckDestination(destination string) error {
+	if !stripeAccountPattern.MatchString(destination) {
+		return fmt.Errorf("%w: expected a Stripe connected account ID", ErrInvalidDestination)
+	}
+	return nil
+}
+
+func (p *StripeProvider) Send(ctx context.Context, req Request) (*Result, error) {
+	form := url.Values{}
+	form.Set("amount", strconv.FormatInt(req.Amount, 10))
+	form.Set("currency", strings.ToLower(req.Currency))
+	form.Set("destination", req.Destination)
+	form.Set("metadata[lobby_id]", req.LobbyID)
+	form.Set("metadata[player_id]", req.PlayerID)
+
+	var transfer struct {
+		ID string `json:"id"`
+	}
+	if err := p.call(ctx, "POST", "/v1/transfers", form, req.IdempotencyKey, &transfer); err != nil {
+		return nil, err
+	}
+	return &Result{Reference: transfer.ID, Status: StatusPaid}, nil
+}
+
+// stripePaymentIntent is the part of a PaymentIntent payments are read from.
+type stripePaymentIntent struct {
+	ID             string            `json:"id"`
+	Status         string            `json:"status"`
+	Amount         int64             `json:"amount"`
+	AmountReceived int64             `json:"amount_received"`
+	Currency       string            `json:"currency"`
+	ClientSecret   string            `json:"client_secret"`
+	Metadata       map[string]string `json:"metadata"`
+}
+
+func (intent *stripePaymentIntent) payment() *Payment {
+	payment := &Payment{
+		Reference:    intent.ID,
+		Status:       StatusPending,
+		LobbyID:      intent.Metadata["lobby_id"],
+		Amount:       intent.Amount,
+		Currency:     strings.ToUpper(intent.Currency),
+		ClientSecret: intent.ClientSecret,
+	}
+	switch intent.Status {
+	case "succeeded":
+		payment.Status = StatusPaid
+		payment.Amount = intent.AmountReceived
+	case "canceled":
+		payment.Status = StatusFailed
+	}
+	return payment
+}
+
+// CreatePayment creates a PaymentIntent for the player to confirm with
+// Stripe.js and the returned client secret.
+func (p *StripeProvider) CreatePayment(ctx context.Context, req PaymentRequest) (*Payment, error) {
+	form := url.Values{}
+	form.Set("amount", strconv.FormatInt(req.Amount, 10))
+	form.Set("currency", strings.ToLower(req.Currency))
+	form.Set("description", req.Description)
+	form.Set("automatic_payment_methods[enabled]", "true")
+	form.Set("metadata[lobby_id]", req.LobbyID)
+	form.Set("metadata[username]", req.Username)
+
+	var intent stripePaymentIntent
+	if err := p.call(ctx, "POST", "/v1/payment_intents", form, "", &intent); err != nil {
+		return nil, err
+	}
+	return intent.payment(), nil
+}
+
+func (p *StripeProvider) ConfirmPayment(ctx context.Context, reference string) (*Payment, error) {
+	if !strings.HasPrefix(reference, "pi_") {
+		return nil, fmt.Errorf("%q is not a Stripe PaymentIntent ID", reference)
+	}
+	var intent stripePaymentIntent
+	if err := p.call(ctx, "GET", "/v1/payment_intents/"+url.PathEscape(reference), nil, "", &intent); err != nil {
+		return nil, err
+	}
+	payment := intent.payment()
+	payment.ClientSecret = ""
+	return payment, nil
+}
+
+// RefundPayment refunds a PaymentIntent in full.
+func (p *StripeProvider) RefundPayment(ctx context.Context, reference, idempotencyKey string) (*Result, error) {
+	if !strings.HasPrefix(reference, "pi_") {
+		return nil, fmt.Errorf("%w: %q is not a Stripe PaymentIntent ID", ErrRejected, reference)
+	}
+	form := url.Values{}
+	form.Set("payment_intent", reference)
+	var refund struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+	}
+	if err := p.call(ctx, "POST", "/v1/refunds", form, idempotencyKey, &refund); err != nil {
+		return nil, err
+	}
+	switch refund.Status {
+	case "succeeded":
+		return &Result{Reference: refund.ID, Status: StatusPaid}, nil
+	case "failed", "canceled":
+		return nil, fmt.Errorf("%w: stripe refund %s %s", ErrRejected, refund.ID, refund.Status)
+	}
+	return &Result{Reference: refund.ID, Status: StatusPending}, nil
+}
+
+// call makes a Stripe API request, form-encoded, and decodes the response.
+func (p *StripeProvider) call(ctx context.Context, method, path string, form url.Values, idempotencyKey string, response interface{}) error {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, p.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	httpReq.SetBasicAuth(p.secretKey, "")
+	if form != nil {
+		httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if idempotencyKey != "" {
+		httpReq.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("stripe request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		var failure struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&failure)
+		return statusError(resp.StatusCode, fmt.Errorf("stripe returned status %d for %s: %s", resp.StatusCode, path, failure.Error.Message))
+	}
+	return json.NewDecoder(resp.Body).Decode(response)
+}
+
+// ParseWebhook checks the Stripe-Signature header ("t=...,v1=...", an
+// HMAC-SHA256 of the timestamp and body) and reads transfer events.
+func (p *StripeProvider) ParseWebhook(ctx context.Context, header http.Header, body []byte) (*Update, error) {
+	if err := p.verify(header.Get("Stripe-Signature"), body, time.Now()); err != nil {
+		return nil, err
+	}
+
+	var event struct {
+		Type string `json:"type"`
+		Data struct {
+			Object struct {
+				ID       string            `json:"id"`
+				Reversed bool              `json:"reversed"`
+				Metadata map[string]string `json:"metadata"`
+			} `json:"object"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(event.Type, "transfer.") || event.Data.Object.Metadata["lobby_id"] == "" {
+		return nil, nil
+	}
+
+	transfer := event.Data.Object
+	update := &Update{
+		LobbyID:   transfer.Metadata["lobby_id"],
+		PlayerID:  transfer.Metadata["player_id"],
+		Reference: transfer.ID,
+		Status:    StatusPaid,
+	}
+	if event.Type == "transfer.reversed" || transfer.Reversed {
+		update.Status = StatusFailed
+		update.Error = "transfer reversed"
+	}
+	return update, nil
+}
+
+func (p *StripeProvider) verify(signature string, body []byte, now time.Time) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(signature, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || now.Sub(time.Unix(seconds, 0)).Abs() > stripeSignatureTolerance {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(p.webhookSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+	for _, candidate := range signatures {
+		if decoded, err := hex.DecodeString(candidate); err == nil && hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
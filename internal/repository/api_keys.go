@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"database/sql"
+
+	"buildprize-game/internal/models"
+
+	"github.com/lib/pq"
+)
+
+func (r *PostgresRepository) SaveAPIKey(key *models.APIKey) error {
+	_, err := r.db.Exec(`
+		INSERT INTO api_keys (id, name, scopes, hash, previous_hash, previous_expires_at, created_by, created_at, rotated_at, revoked_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			scopes = EXCLUDED.scopes,
+			hash = EXCLUDED.hash,
+			previous_hash = EXCLUDED.previous_hash,
+			previous_expires_at = EXCLUDED.previous_expires_at,
+			rotated_at = EXCLUDED.rotated_at,
+			revoked_at = EXCLUDED.revoked_at
+	`, key.ID, key.Name, pq.Array(key.Scopes), key.Hash, key.PreviousHash, key.PreviousExpiresAt,
+		key.CreatedBy, key.CreatedAt, key.RotatedAt, key.RevokedAt)
+	return err
+}
+
+const selectAPIKeys = `
+	SELECT id, name, scopes, hash, previous_hash, previous_expires_at, created_by, created_at, rotated_at, revoked_at
+	FROM api_keys`
+
+func (r *PostgresRepository) GetAPIKey(keyID string) (*models.APIKey, error) {
+	key, err := scanAPIKey(r.db.QueryRow(selectAPIKeys+" WHERE id = $1", keyID))
+	if err == sql.ErrNoRows {
+		return nil, ErrAPIKeyNotFound
+	}
+	return key, err
+}
+
+func (r *PostgresRepository) ListAPIKeys() ([]*models.APIKey, error) {
+	rows, err := r.db.Query(selectAPIKeys + " ORDER BY created_at")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]*models.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func scanAPIKey(row interface{ Scan(...interface{}) error }) (*models.APIKey, error) {
+	var key models.APIKey
+	var previousExpiresAt, rotatedAt, revokedAt sql.NullTime
+	if err := row.Scan(&key.ID, &key.Name, pq.Array(&key.Scopes), &key.Hash, &key.PreviousHash, &previousExpiresAt,
+		&key.CreatedBy, &key.CreatedAt, &rotatedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	if previousExpiresAt.Valid {
+		key.PreviousExpiresAt = &previousExpiresAt.Time
+	}
+	if rotatedAt.Valid {
+		key.RotatedAt = &rotatedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return &key, nil
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"buildprize-game/internal/models"
+)
+
+func (r *PostgresRepository) SaveAdminAction(action *models.AdminAction) error {
+	var details interface{} // NULL without details
+	if len(action.Details) > 0 {
+		data, err := json.Marshal(action.Details)
+		if err != nil {
+			return err
+		}
+		details = data
+	}
+	remoteAddr, err := sealText(r.enc, action.RemoteAddr, adminAuditRemoteAddr)
+	if err != nil {
+		return err
+	}
+	return r.db.QueryRow(`
+		INSERT INTO admin_audit (action, actor, target, details, remote_addr, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, action.Action, action.Actor, action.Target, details, remoteAddr, action.CreatedAt).Scan(&action.ID)
+}
+
+// ListAdminActions returns the most recent entries the filter selects,
+// newest first.
+func (r *PostgresRepository) ListAdminActions(filter models.AdminActionFilter, limit int) ([]*models.AdminAction, error) {
+	rows, err := r.db.Query(`
+		SELECT id, action, actor, target, details, remote_addr, created_at
+		FROM admin_audit
+		WHERE ($1 = '' OR action = $1) AND ($2 = '' OR actor = $2) AND ($3 = '' OR target = $3)
+		ORDER BY id DESC
+		LIMIT $4
+	`, filter.Action, filter.Actor, filter.Target, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	actions := make([]*models.AdminAction, 0)
+	for rows.Next() {
+		var action models.AdminAction
+		var details []byte
+		if err := rows.Scan(&action.ID, &action.Action, &action.Actor, &action.Target, &details, &action.RemoteAddr, &action.CreatedAt); err != nil {
+			return nil, err
+		}
+		if len(details) > 0 {
+			json.Unmarshal(details, &action.Details)
+		}
+		if action.RemoteAddr, err = openText(r.enc, action.RemoteAddr, adminAuditRemoteAddr); err != nil {
+			return nil, fmt.Errorf("failed to decrypt admin action %d: %w", action.ID, err)
+		}
+		action.CreatedAt = action.CreatedAt.UTC()
+		actions = append(actions, &action)
+	}
+	return actions, rows.Err()
+}
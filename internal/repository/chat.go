@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"time"
+
+	"buildprize-game/internal/models"
+)
+
+// Chat history page sizes for ListChatMessages.
+const (
+	DefaultChatHistoryLimit = 50
+	MaxChatHistoryLimit     = 200
+)
+
+// recentChat picks the last limit messages sent after since from messages
+// held oldest first, for the repositories that load a lobby's chat in full.
+// The result is copied so it can be handed out.
+func recentChat(messages []*models.ChatMessage, since time.Time, limit int) []*models.ChatMessage {
+	start := len(messages)
+	for start > 0 && messages[start-1].SentAt.After(since) && len(messages)-start < limit {
+		start--
+	}
+	recent := make([]*models.ChatMessage, 0, len(messages)-start)
+	for _, msg := range messages[start:] {
+		copied := *msg
+		recent = append(recent, &copied)
+	}
+	return recent
+}
@@ -0,0 +1,293 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"buildprize-game/internal/encryption"
+	"buildprize-game/internal/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Columns holding personal data, used as encryption context
+const (
+	pendingNotificationsData = "pending_notifications.data"
+	adminAuditRemoteAddr     = "admin_audit.remote_addr"
+)
+
+// SetEncryption enables at-rest encryption of sensitive columns. Rows written
+// before it was enabled stay readable and are encrypted by
+// RotateEncryptedFields.
+func (r *PostgresRepository) SetEncryption(envelope *encryption.Envelope) {
+	r.enc = envelope
+}
+
+// sealJSON encrypts a JSON value under enc, if set. The ciphertext is stored
+// as a JSON string so the column type doesn't change.
+func sealJSON(enc *encryption.Envelope, data []byte, context string) ([]byte, error) {
+	if enc == nil || data == nil {
+		return data, nil
+	}
+	sealed, err := enc.Encrypt(data, context)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(sealed)
+}
+
+func openJSON(enc *encryption.Envelope, data []byte, context string) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte(`"`+encryption.Prefix)) {
+		return data, nil
+	}
+	if enc == nil {
+		return nil, encryption.ErrNoKeys
+	}
+	var sealed string
+	if err := json.Unmarshal(data, &sealed); err != nil {
+		return nil, err
+	}
+	return enc.Decrypt(sealed, context)
+}
+
+// sealText encrypts a text value under enc, if set. Empty values stay empty.
+func sealText(enc *encryption.Envelope, value, context string) (string, error) {
+	if enc == nil || value == "" {
+		return value, nil
+	}
+	return enc.Encrypt([]byte(value), context)
+}
+
+func openText(enc *encryption.Envelope, value, context string) (string, error) {
+	if !encryption.IsEncrypted(value) {
+		return value, nil
+	}
+	if enc == nil {
+		return "", encryption.ErrNoKeys
+	}
+	plaintext, err := enc.Decrypt(value, context)
+	return string(plaintext), err
+}
+
+// needsResealing reports whether stored data is plaintext or wrapped by a
+// retired master key.
+func needsResealing(enc *encryption.Envelope, data []byte) bool {
+	var value string
+	return json.Unmarshal(data, &value) != nil || enc.NeedsRotation(value)
+}
+
+// RotateEncryptedFields re-encrypts values that are still plaintext or were
+// wrapped by a retired master key. Once it has run, retired keys can be
+// removed from the configuration.
+func (r *PostgresRepository) RotateEncryptedFields() (int, error) {
+	if r.enc == nil {
+		return 0, nil
+	}
+	rotated, err := r.rotatePendingNotifications()
+	if err != nil {
+		return rotated, err
+	}
+	addrs, err := r.rotateAdminAddrs()
+	return rotated + addrs, err
+}
+
+func (r *PostgresRepository) rotatePendingNotifications() (int, error) {
+	rows, err := r.db.Query(`SELECT id, data FROM pending_notifications WHERE data IS NOT NULL`)
+	if err != nil {
+		return 0, err
+	}
+	type staleRow struct {
+		id   int64
+		data []byte
+	}
+	var stale []staleRow
+	for rows.Next() {
+		var row staleRow
+		if err := rows.Scan(&row.id, &row.data); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if needsResealing(r.enc, row.data) {
+			stale = append(stale, row)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	rotated := 0
+	for _, row := range stale {
+		plaintext, err := openJSON(r.enc, row.data, pendingNotificationsData)
+		if err != nil {
+			log.Printf("Failed to decrypt pending notification %d: %v", row.id, err)
+			continue
+		}
+		sealed, err := sealJSON(r.enc, plaintext, pendingNotificationsData)
+		if err != nil {
+			return rotated, err
+		}
+		if _, err := r.db.Exec(`UPDATE pending_notifications SET data = $1 WHERE id = $2`, sealed, row.id); err != nil {
+			return rotated, err
+		}
+		rotated++
+	}
+	return rotated, nil
+}
+
+func (r *PostgresRepository) rotateAdminAddrs() (int, error) {
+	rows, err := r.db.Query(`SELECT id, remote_addr FROM admin_audit WHERE remote_addr <> ''`)
+	if err != nil {
+		return 0, err
+	}
+	type staleRow struct {
+		id   int64
+		addr string
+	}
+	var stale []staleRow
+	for rows.Next() {
+		var row staleRow
+		if err := rows.Scan(&row.id, &row.addr); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if r.enc.NeedsRotation(row.addr) {
+			stale = append(stale, row)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	rotated := 0
+	for _, row := range stale {
+		addr, err := openText(r.enc, row.addr, adminAuditRemoteAddr)
+		if err != nil {
+			log.Printf("Failed to decrypt admin action %d: %v", row.id, err)
+			continue
+		}
+		sealed, err := sealText(r.enc, addr, adminAuditRemoteAddr)
+		if err != nil {
+			return rotated, err
+		}
+		if _, err := r.db.Exec(`UPDATE admin_audit SET remote_addr = $1 WHERE id = $2`, sealed, row.id); err != nil {
+			return rotated, err
+		}
+		rotated++
+	}
+	return rotated, nil
+}
+
+// SetEncryption enables at-rest encryption of pending notification data and
+// admin audit addresses.
+func (r *RedisRepository) SetEncryption(envelope *encryption.Envelope) {
+	r.enc = envelope
+}
+
+// RotateEncryptedFields re-encrypts pending notifications and admin audit
+// addresses that are still plaintext or were wrapped by a retired master key.
+func (r *RedisRepository) RotateEncryptedFields() (int, error) {
+	if r.enc == nil {
+		return 0, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	lobbyIDs, err := r.client.ZRange(ctx, lobbiesKey, 0, -1).Result()
+	if err != nil {
+		return 0, err
+	}
+	rotated := 0
+	for _, lobbyID := range lobbyIDs {
+		keys, err := r.client.SMembers(ctx, lobbyNotifiedKey(lobbyID)).Result()
+		if err != nil {
+			return rotated, err
+		}
+		for _, key := range keys {
+			values, err := r.client.LRange(ctx, key, 0, -1).Result()
+			if err != nil {
+				return rotated, err
+			}
+			for i, value := range values {
+				var n models.PendingNotification
+				if err := json.Unmarshal([]byte(value), &n); err != nil || !needsResealing(r.enc, n.Data) {
+					continue
+				}
+				plaintext, err := openJSON(r.enc, n.Data, pendingNotificationsData)
+				if err != nil {
+					log.Printf("Failed to decrypt pending notification %d: %v", n.ID, err)
+					continue
+				}
+				if n.Data, err = sealJSON(r.enc, plaintext, pendingNotificationsData); err != nil {
+					return rotated, err
+				}
+				data, err := json.Marshal(&n)
+				if err != nil {
+					return rotated, err
+				}
+				// The list may have been taken meanwhile; its entries went out as they were
+				if err := r.client.LSet(ctx, key, int64(i), data).Err(); err != nil {
+					continue
+				}
+				rotated++
+			}
+		}
+	}
+	addrs, err := r.rotateAdminAddrs(ctx)
+	return rotated + addrs, err
+}
+
+// rotateAdminAddrs rewrites the audit log in one transaction, watched so an
+// entry pushed meanwhile doesn't shift the entries being rewritten. It's
+// retried when that happens.
+func (r *RedisRepository) rotateAdminAddrs(ctx context.Context) (int, error) {
+	for attempt := 0; ; attempt++ {
+		rotated := 0
+		err := r.client.Watch(ctx, func(tx *redis.Tx) error {
+			values, err := tx.LRange(ctx, adminAuditKey, 0, -1).Result()
+			if err != nil {
+				return err
+			}
+			updates := make(map[int64][]byte)
+			for i, value := range values {
+				var action models.AdminAction
+				if err := json.Unmarshal([]byte(value), &action); err != nil || action.RemoteAddr == "" || !r.enc.NeedsRotation(action.RemoteAddr) {
+					continue
+				}
+				addr, err := openText(r.enc, action.RemoteAddr, adminAuditRemoteAddr)
+				if err != nil {
+					log.Printf("Failed to decrypt admin action %d: %v", action.ID, err)
+					continue
+				}
+				if action.RemoteAddr, err = sealText(r.enc, addr, adminAuditRemoteAddr); err != nil {
+					return err
+				}
+				if updates[int64(i)], err = json.Marshal(&action); err != nil {
+					return err
+				}
+			}
+			if len(updates) == 0 {
+				return nil
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				for i, data := range updates {
+					pipe.LSet(ctx, adminAuditKey, i, data)
+				}
+				return nil
+			})
+			if err == nil {
+				rotated = len(updates)
+			}
+			return err
+		}, adminAuditKey)
+		if errors.Is(err, redis.TxFailedErr) && attempt < 5 {
+			continue
+		}
+		return rotated, err
+	}
+}
@@ -0,0 +1,171 @@
+package repository_test
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/encryption"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/repository"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+)
+
+type encryptedRepository interface {
+	repository.Repository
+	SetEncryption(envelope *encryption.Envelope)
+	RotateEncryptedFields() (int, error)
+}
+
+// envelope returns an envelope over "id:key" master keys, current first.
+func envelope(t *testing.T, keys ...string) *encryption.Envelope {
+	t.Helper()
+	provider, err := encryption.ParseLocalKeys(strings.Join(keys, ","))
+	if err != nil {
+		t.Fatalf("ParseLocalKeys: %v", err)
+	}
+	return encryption.NewEnvelope(provider)
+}
+
+func masterKey(id string) string {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return id + ":" + base64.StdEncoding.EncodeToString(key)
+}
+
+func TestRedisEncryptedFields(t *testing.T) {
+	server := miniredis.RunT(t)
+	repo, err := repository.NewRedisRepository("redis://"+server.Addr(), time.Hour)
+	if err != nil {
+		t.Fatalf("NewRedisRepository: %v", err)
+	}
+	testEncryptedFields(t, repo, func(id int64) string {
+		values, err := server.List("admin:audit")
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		for _, value := range values {
+			var action models.AdminAction
+			if json.Unmarshal([]byte(value), &action) == nil && action.ID == id {
+				return action.RemoteAddr
+			}
+		}
+		t.Fatalf("Admin action %d isn't stored", id)
+		return ""
+	})
+}
+
+func TestPostgresEncryptedFields(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	repo, err := repository.NewPostgresRepository(url)
+	if err != nil {
+		t.Fatalf("NewPostgresRepository: %v", err)
+	}
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	testEncryptedFields(t, repo, func(id int64) string {
+		var addr string
+		if err := db.QueryRow(`SELECT remote_addr FROM admin_audit WHERE id = $1`, id).Scan(&addr); err != nil {
+			t.Fatalf("Read admin action %d: %v", id, err)
+		}
+		return addr
+	})
+}
+
+// testEncryptedFields checks that admin audit addresses are stored sealed
+// and read back in the clear, and that rotation seals plaintext rows and
+// re-wraps ones under a retired key. storedAddr reads an entry's remote_addr
+// as stored.
+func testEncryptedFields(t *testing.T, repo encryptedRepository, storedAddr func(id int64) string) {
+	target := uuid.New().String()
+	save := func(addr string) *models.AdminAction {
+		t.Helper()
+		action := &models.AdminAction{Action: "kick", Actor: "admin", Target: target, RemoteAddr: addr, CreatedAt: models.Now()}
+		if err := repo.SaveAdminAction(action); err != nil {
+			t.Fatalf("SaveAdminAction: %v", err)
+		}
+		return action
+	}
+	listed := func() map[int64]string {
+		t.Helper()
+		actions, err := repo.ListAdminActions(models.AdminActionFilter{Target: target}, 100)
+		if err != nil {
+			t.Fatalf("ListAdminActions: %v", err)
+		}
+		addrs := make(map[int64]string)
+		for _, action := range actions {
+			addrs[action.ID] = action.RemoteAddr
+		}
+		return addrs
+	}
+
+	// Written before encryption was enabled
+	legacy := save("198.51.100.4")
+	if got := storedAddr(legacy.ID); got != "198.51.100.4" {
+		t.Fatalf("Stored %q without encryption", got)
+	}
+
+	oldKey, newKey := masterKey("2024-01"), masterKey("2024-06")
+	repo.SetEncryption(envelope(t, oldKey))
+	sealed := save("203.0.113.9")
+	unknown := save("")
+	if got := storedAddr(sealed.ID); !encryption.IsEncrypted(got) || strings.Contains(got, "203.0.113.9") {
+		t.Fatalf("Stored %q, want it encrypted", got)
+	}
+	if got := storedAddr(unknown.ID); got != "" {
+		t.Fatalf("Stored %q for an entry without an address", got)
+	}
+	if addrs := listed(); addrs[legacy.ID] != "198.51.100.4" || addrs[sealed.ID] != "203.0.113.9" || addrs[unknown.ID] != "" {
+		t.Fatalf("Read back %v", addrs)
+	}
+
+	rotated, err := repo.RotateEncryptedFields()
+	if err != nil || rotated < 1 {
+		t.Fatalf("RotateEncryptedFields: %d, %v", rotated, err)
+	}
+	if got := storedAddr(legacy.ID); !encryption.IsEncrypted(got) {
+		t.Fatalf("A plaintext address is left after rotation: %q", got)
+	}
+	before := storedAddr(sealed.ID)
+
+	// A new current key re-wraps everything written under the old one
+	repo.SetEncryption(envelope(t, newKey, oldKey))
+	rotated, err = repo.RotateEncryptedFields()
+	if err != nil || rotated < 2 {
+		t.Fatalf("RotateEncryptedFields under a new key: %d, %v", rotated, err)
+	}
+	if got := storedAddr(sealed.ID); got == before || !strings.HasPrefix(got, encryption.Prefix+"2024-06:") {
+		t.Fatalf("Stored %q after rotating to the new key", got)
+	}
+	if rotated, err := repo.RotateEncryptedFields(); err != nil || rotated != 0 {
+		t.Fatalf("RotateEncryptedFields with nothing to do: %d, %v", rotated, err)
+	}
+
+	// Once rotated, the old key can be dropped
+	repo.SetEncryption(envelope(t, newKey))
+	if addrs := listed(); addrs[legacy.ID] != "198.51.100.4" || addrs[sealed.ID] != "203.0.113.9" {
+		t.Fatalf("Read back %v with only the new key", addrs)
+	}
+	repo.SetEncryption(nil)
+	if _, err := repo.ListAdminActions(models.AdminActionFilter{Target: target}, 100); !errors.Is(err, encryption.ErrNoKeys) {
+		t.Fatalf("ListAdminActions without keys: %v", err)
+	}
+	repo.SetEncryption(envelope(t, masterKey("other")))
+	if _, err := repo.ListAdminActions(models.AdminActionFilter{Target: target}, 100); err == nil {
+		t.Fatal("Read encrypted addresses with the wrong key")
+	}
+}
@@ -4,4 +4,20 @@ import "errors"
 
 var (
 	ErrLobbyNotFound = errors.New("lobby not found")
+
+	ErrScoringConfigNotFound = errors.New("scoring config not found")
+	ErrScoringVersionExists  = errors.New("scoring version already exists")
+
+	ErrPrizeResultNotFound = errors.New("prize result not found")
+
+	ErrUserNotFound  = errors.New("user not found")
+	ErrUsernameTaken = errors.New("username is already registered")
+
+	ErrFriendshipNotFound = errors.New("friendship not found")
+
+	ErrEventNotFound = errors.New("recurring event not found")
+
+	ErrAPIKeyNotFound = errors.New("API key not found")
+
+	ErrGameArchiveNotFound = errors.New("game archive not found")
 )
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"buildprize-game/internal/models"
+)
+
+func (r *PostgresRepository) SaveRecurringEvent(event *models.RecurringEvent) error {
+	_, err := r.db.Exec(`
+		INSERT INTO recurring_events (id, name, schedule, timezone, lead_minutes, max_rounds, max_players, topic, language, rsvp_quorum, created_at, last_starts_at, last_lobby_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			schedule = EXCLUDED.schedule,
+			timezone = EXCLUDED.timezone,
+			lead_minutes = EXCLUDED.lead_minutes,
+			max_rounds = EXCLUDED.max_rounds,
+			max_players = EXCLUDED.max_players,
+			topic = EXCLUDED.topic,
+			language = EXCLUDED.language,
+			rsvp_quorum = EXCLUDED.rsvp_quorum
+	`, event.ID, event.Name, event.Schedule, event.Timezone, event.LeadMinutes, event.MaxRounds, event.MaxPlayers,
+		event.Topic, event.Language, event.RSVPQuorum, event.CreatedAt, event.LastStartsAt, event.LastLobbyID)
+	return err
+}
+
+func (r *PostgresRepository) ListRecurringEvents() ([]*models.RecurringEvent, error) {
+	rows, err := r.db.Query(`
+		SELECT id, name, schedule, timezone, lead_minutes, max_rounds, max_players, topic, language, rsvp_quorum, created_at, last_starts_at, last_lobby_id
+		FROM recurring_events ORDER BY created_at
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]*models.RecurringEvent, 0)
+	for rows.Next() {
+		var e models.RecurringEvent
+		var lastStartsAt sql.NullTime
+		if err := rows.Scan(&e.ID, &e.Name, &e.Schedule, &e.Timezone, &e.LeadMinutes, &e.MaxRounds, &e.MaxPlayers,
+			&e.Topic, &e.Language, &e.RSVPQuorum, &e.CreatedAt, &lastStartsAt, &e.LastLobbyID); err != nil {
+			return nil, err
+		}
+		if lastStartsAt.Valid {
+			e.LastStartsAt = &lastStartsAt.Time
+		}
+		events = append(events, &e)
+	}
+	return events, rows.Err()
+}
+
+func (r *PostgresRepository) DeleteRecurringEvent(eventID string) error {
+	result, err := r.db.Exec("DELETE FROM recurring_events WHERE id = $1", eventID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrEventNotFound
+	}
+	return nil
+}
+
+// ClaimRecurringEvent claims the start in a single conditional update, so
+// instances racing for it can't both win.
+func (r *PostgresRepository) ClaimRecurringEvent(eventID string, startsAt time.Time, lobbyID string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE recurring_events SET last_starts_at = $2, last_lobby_id = $3
+		WHERE id = $1 AND (last_starts_at IS NULL OR last_starts_at < $2)
+	`, eventID, startsAt, lobbyID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
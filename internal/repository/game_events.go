@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"buildprize-game/internal/models"
+)
+
+// SaveGameEvents stores a batch of events in one transaction.
+func (r *PostgresRepository) SaveGameEvents(events []*models.GameEvent) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO game_events (lobby_id, seq, type, payload, emitted_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, event := range events {
+		payload, err := eventPayload(event)
+		if err != nil {
+			return err
+		}
+		if _, err := stmt.Exec(event.LobbyID, event.Seq, event.Type, payload, event.Timestamp); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresRepository) ListGameEvents(lobbyID string, afterSeq uint64, limit int) ([]*models.GameEvent, error) {
+	rows, err := r.db.Query(`
+		SELECT lobby_id, seq, type, payload, emitted_at FROM game_events
+		WHERE lobby_id = $1 AND seq > $2
+		ORDER BY id
+		LIMIT $3
+	`, lobbyID, afterSeq, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]*models.GameEvent, 0)
+	for rows.Next() {
+		var event models.GameEvent
+		var payload []byte
+		if err := rows.Scan(&event.LobbyID, &event.Seq, &event.Type, &payload, &event.Timestamp); err != nil {
+			return nil, err
+		}
+		if payload != nil {
+			event.Data = json.RawMessage(payload)
+		}
+		event.Timestamp = event.Timestamp.UTC()
+		events = append(events, &event)
+	}
+	return events, rows.Err()
+}
+
+func (r *PostgresRepository) DeleteGameEventsOlderThan(duration time.Duration) (int, error) {
+	result, err := r.db.Exec("DELETE FROM game_events WHERE emitted_at < $1", time.Now().Add(-duration))
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := result.RowsAffected()
+	return int(deleted), err
+}
+
+// ListGameEventLobbies returns lobbies whose last event was before before,
+// longest idle first.
+func (r *PostgresRepository) ListGameEventLobbies(before time.Time, limit int) ([]string, error) {
+	rows, err := r.db.Query(`
+		SELECT lobby_id FROM game_events
+		GROUP BY lobby_id
+		HAVING MAX(emitted_at) < $1
+		ORDER BY MAX(emitted_at)
+		LIMIT $2
+	`, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lobbyIDs := make([]string, 0)
+	for rows.Next() {
+		var lobbyID string
+		if err := rows.Scan(&lobbyID); err != nil {
+			return nil, err
+		}
+		lobbyIDs = append(lobbyIDs, lobbyID)
+	}
+	return lobbyIDs, rows.Err()
+}
+
+func (r *PostgresRepository) DeleteGameEvents(lobbyID string) error {
+	_, err := r.db.Exec("DELETE FROM game_events WHERE lobby_id = $1", lobbyID)
+	return err
+}
+
+func (r *PostgresRepository) SaveGameArchive(archive *models.GameArchive) error {
+	_, err := r.db.Exec(`
+		INSERT INTO game_archives (lobby_id, object_key, events, last_seq, last_event_at, archived_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (lobby_id) DO UPDATE SET
+			object_key = EXCLUDED.object_key, events = EXCLUDED.events, last_seq = EXCLUDED.last_seq,
+			last_event_at = EXCLUDED.last_event_at, archived_at = EXCLUDED.archived_at
+	`, archive.LobbyID, archive.Key, archive.Events, archive.LastSeq, archive.LastEventAt, archive.ArchivedAt)
+	return err
+}
+
+func (r *PostgresRepository) GetGameArchive(lobbyID string) (*models.GameArchive, error) {
+	var archive models.GameArchive
+	err := r.db.QueryRow(`
+		SELECT lobby_id, object_key, events, last_seq, last_event_at, archived_at
+		FROM game_archives WHERE lobby_id = $1
+	`, lobbyID).Scan(&archive.LobbyID, &archive.Key, &archive.Events, &archive.LastSeq, &archive.LastEventAt, &archive.ArchivedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrGameArchiveNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	archive.LastEventAt = archive.LastEventAt.UTC()
+	archive.ArchivedAt = archive.ArchivedAt.UTC()
+	return &archive, nil
+}
+
+// eventPayload is the event's data as JSON. Events from the hub carry it
+// encoded already.
+func eventPayload(event *models.GameEvent) ([]byte, error) {
+	if raw, ok := event.Data.(json.RawMessage); ok {
+		return raw, nil
+	}
+	if event.Data == nil {
+		return nil, nil
+	}
+	return json.Marshal(event.Data)
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"buildprize-game/internal/models"
+)
+
+// LobbySort orders a lobby listing.
+type LobbySort string
+
+const (
+	SortNewest  LobbySort = "newest" // created most recently first (default)
+	SortOldest  LobbySort = "oldest"
+	SortName    LobbySort = "name"    // case-insensitive, A to Z
+	SortPlayers LobbySort = "players" // most players first
+)
+
+const (
+	DefaultLobbyPageSize = 50
+	MaxLobbyPageSize     = 100
+)
+
+var ErrInvalidLobbyQuery = errors.New("invalid lobby query")
+
+// LobbyQuery selects a page of lobbies. Sandbox lobbies are never listed.
+// Ties in the sort order go to the newer lobby, then the lower ID, so pages
+// don't overlap while the listing is unchanged.
+type LobbyQuery struct {
+	State    models.GameState // empty lists every state
+	HasSpace bool             // only lobbies with a free seat
+	Sort     LobbySort        // empty means SortNewest
+	Limit    int              // 1 to MaxLobbyPageSize; 0 means DefaultLobbyPageSize
+	Offset   int
+}
+
+// LobbyPage is one page of a lobby listing and how many lobbies match in all.
+type LobbyPage struct {
+	Lobbies []*models.Lobby
+	Total   int
+}
+
+// WaitingLobbies is the lobby browser's default listing: the newest waiting
+// lobbies.
+var WaitingLobbies = LobbyQuery{State: models.Waiting}
+
+// Normalize fills in defaults and checks the query.
+func (q LobbyQuery) Normalize() (LobbyQuery, error) {
+	switch q.State {
+	case "", models.Waiting, models.InProgress, models.Finished:
+	default:
+		return q, ErrInvalidLobbyQuery
+	}
+	switch q.Sort {
+	case "":
+		q.Sort = SortNewest
+	case SortNewest, SortOldest, SortName, SortPlayers:
+	default:
+		return q, ErrInvalidLobbyQuery
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultLobbyPageSize
+	}
+	if q.Limit < 0 || q.Limit > MaxLobbyPageSize || q.Offset < 0 {
+		return q, ErrInvalidLobbyQuery
+	}
+	return q, nil
+}
+
+// pageLobbies filters, sorts and pages lobbies loaded in full, for the
+// repositories that can't do it in a query. Sandbox lobbies are expected to
+// be left out already.
+func pageLobbies(lobbies []*models.Lobby, q LobbyQuery) *LobbyPage {
+	matched := make([]*models.Lobby, 0, len(lobbies))
+	for _, lobby := range lobbies {
+		if q.State != "" && lobby.State != q.State {
+			continue
+		}
+		if q.HasSpace && lobby.IsFull() {
+			continue
+		}
+		matched = append(matched, lobby)
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		a, b := matched[i], matched[j]
+		switch q.Sort {
+		case SortOldest:
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+			return a.ID < b.ID
+		case SortName:
+			if an, bn := strings.ToLower(a.Name), strings.ToLower(b.Name); an != bn {
+				return an < bn
+			}
+		case SortPlayers:
+			if len(a.Players) != len(b.Players) {
+				return len(a.Players) > len(b.Players)
+			}
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+
+	page := &LobbyPage{Lobbies: make([]*models.Lobby, 0), Total: len(matched)}
+	if q.Offset < len(matched) {
+		end := q.Offset + q.Limit
+		if end > len(matched) {
+			end = len(matched)
+		}
+		page.Lobbies = matched[q.Offset:end]
+	}
+	return page
+}
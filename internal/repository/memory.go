@@ -0,0 +1,840 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"buildprize-game/internal/models"
+)
+
+// InMemoryRepository keeps everything in process memory, for local
+// development and tests that don't need a database. Nothing survives a
+// restart. Lobbies are stored encoded, as a real save would, so callers
+// can't share state with the repository by accident.
+type InMemoryRepository struct {
+	mu      sync.Mutex
+	lobbies map[string]*storedLobby
+	answers []*models.AnswerRecord // in the order recorded
+
+	notifications  map[string][]*models.PendingNotification // lobbyID + "/" + playerID
+	notificationID int64
+
+	chat   map[string][]*models.ChatMessage // lobbyID -> messages, oldest first
+	chatID int64
+
+	gameEvents   map[string][]models.GameEvent // lobbyID -> events, in the order broadcast
+	gameArchives map[string]models.GameArchive // by lobby ID
+
+	scoring       map[string]*models.ScoringConfig
+	activeScoring string
+	scoringAudit  []*models.ScoringAuditEntry
+
+	reports []*models.PlayerReport // in the order filed
+
+	adminActions []*models.AdminAction // in the order made
+
+	prizeResults map[string][]byte // lobbyID -> encoded result
+
+	entryRefunds map[string]models.EntryRefund // lobbyID + "/" + paymentID
+
+	users     map[string]models.User // by ID
+	usernames map[string]string      // lowercased username -> user ID
+
+	playerStats map[string]models.PlayerStats // by user ID
+
+	accountStreaks map[string]models.AccountStreak // by user ID
+
+	friendships map[string]models.Friendship // by the pair's user IDs, lower first
+
+	events map[string]models.RecurringEvent // by ID
+
+	apiKeys map[string]models.APIKey // by ID
+}
+
+type storedLobby struct {
+	data       []byte
+	state      models.GameState
+	sandbox    bool
+	createdAt  time.Time
+	finishedAt *time.Time
+}
+
+// NewInMemoryRepository returns an empty repository with the built-in
+// scoring version active.
+func NewInMemoryRepository() *InMemoryRepository {
+	config := models.DefaultScoringConfig
+	config.CreatedBy = "system"
+	return &InMemoryRepository{
+		lobbies:        make(map[string]*storedLobby),
+		notifications:  make(map[string][]*models.PendingNotification),
+		chat:           make(map[string][]*models.ChatMessage),
+		prizeResults:   make(map[string][]byte),
+		entryRefunds:   make(map[string]models.EntryRefund),
+		users:          make(map[string]models.User),
+		usernames:      make(map[string]string),
+		playerStats:    make(map[string]models.PlayerStats),
+		accountStreaks: make(map[string]models.AccountStreak),
+		friendships:    make(map[string]models.Friendship),
+		events:         make(map[string]models.RecurringEvent),
+		apiKeys:        make(map[string]models.APIKey),
+		gameEvents:     make(map[string][]models.GameEvent),
+		gameArchives:   make(map[string]models.GameArchive),
+		scoring:        map[string]*models.ScoringConfig{config.Version: &config},
+		activeScoring:  config.Version,
+	}
+}
+
+func (r *InMemoryRepository) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (r *InMemoryRepository) SaveLobby(lobby *models.Lobby) error {
+	data, err := json.Marshal(lobby)
+	if err != nil {
+		return err
+	}
+	stored := &storedLobby{
+		data:       data,
+		state:      lobby.State,
+		sandbox:    lobby.Sandbox,
+		createdAt:  lobby.CreatedAt,
+		finishedAt: lobby.FinishedAt,
+	}
+	r.mu.Lock()
+	r.lobbies[lobby.ID] = stored
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *InMemoryRepository) GetLobby(lobbyID string) (*models.Lobby, error) {
+	r.mu.Lock()
+	stored, ok := r.lobbies[lobbyID]
+	r.mu.Unlock()
+	if !ok {
+		return nil, ErrLobbyNotFound
+	}
+	return stored.decode()
+}
+
+func (s *storedLobby) decode() (*models.Lobby, error) {
+	var lobby models.Lobby
+	if err := json.Unmarshal(s.data, &lobby); err != nil {
+		return nil, err
+	}
+	return &lobby, nil
+}
+
+func (r *InMemoryRepository) DeleteLobby(lobbyID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.lobbies, lobbyID)
+	delete(r.chat, lobbyID)
+	for key, pending := range r.notifications {
+		if len(pending) > 0 && pending[0].LobbyID == lobbyID {
+			delete(r.notifications, key)
+		}
+	}
+	return nil
+}
+
+// ListLobbies returns the page of non-sandbox lobbies the query selects.
+func (r *InMemoryRepository) ListLobbies(query LobbyQuery) (*LobbyPage, error) {
+	query, err := query.Normalize()
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	var matched []*storedLobby
+	for _, stored := range r.lobbies {
+		if !stored.sandbox && (query.State == "" || stored.state == query.State) {
+			matched = append(matched, stored)
+		}
+	}
+	r.mu.Unlock()
+
+	lobbies := make([]*models.Lobby, 0, len(matched))
+	for _, stored := range matched {
+		lobby, err := stored.decode()
+		if err != nil {
+			return nil, err
+		}
+		lobbies = append(lobbies, lobby)
+	}
+	return pageLobbies(lobbies, query), nil
+}
+
+// DeleteFinishedGamesOlderThan deletes finished games that finished more than
+// the specified duration ago.
+func (r *InMemoryRepository) DeleteFinishedGamesOlderThan(duration time.Duration) (int, error) {
+	cutoff := time.Now().Add(-duration)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	deleted := 0
+	for id, stored := range r.lobbies {
+		if stored.state == models.Finished && stored.finishedAt != nil && stored.finishedAt.Before(cutoff) {
+			delete(r.lobbies, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (r *InMemoryRepository) SaveAnswer(record *models.AnswerRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	record.ID = int64(len(r.answers) + 1)
+	stored := *record
+	r.answers = append(r.answers, &stored)
+	return nil
+}
+
+// GetLobbyAnswers returns every answer recorded in a lobby, oldest first.
+func (r *InMemoryRepository) GetLobbyAnswers(lobbyID string) ([]*models.AnswerRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	answers := make([]*models.AnswerRecord, 0)
+	for _, record := range r.answers {
+		if record.LobbyID == lobbyID {
+			copied := *record
+			answers = append(answers, &copied)
+		}
+	}
+	sort.SliceStable(answers, func(i, j int) bool { return answers[i].Round < answers[j].Round })
+	return answers, nil
+}
+
+func (r *InMemoryRepository) UpdateAnswerScore(answerID int64, score int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if answerID >= 1 && answerID <= int64(len(r.answers)) {
+		r.answers[answerID-1].Score = score
+	}
+	return nil
+}
+
+func (r *InMemoryRepository) UpdateAnswerWager(answerID int64, delta int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if answerID >= 1 && answerID <= int64(len(r.answers)) {
+		r.answers[answerID-1].WagerDelta = delta
+	}
+	return nil
+}
+
+// GetQuestionPerformance aggregates answer history per question, in
+// question ID order.
+func (r *InMemoryRepository) GetQuestionPerformance() ([]*models.QuestionPerformance, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	byQuestion := make(map[string]*models.QuestionPerformance)
+	times := make(map[string][]int64)
+	performance := make([]*models.QuestionPerformance, 0)
+	for _, record := range r.answers {
+		p := byQuestion[record.QuestionID]
+		if p == nil {
+			p = &models.QuestionPerformance{QuestionID: record.QuestionID}
+			byQuestion[record.QuestionID] = p
+			performance = append(performance, p)
+		}
+		p.Answered++
+		if record.Correct {
+			p.Correct++
+		}
+		times[record.QuestionID] = append(times[record.QuestionID], record.ResponseTime)
+	}
+	for _, p := range performance {
+		p.Accuracy = float64(p.Correct) / float64(p.Answered)
+		p.MedianResponseMs = medianMs(times[p.QuestionID])
+	}
+	sort.Slice(performance, func(i, j int) bool { return performance[i].QuestionID < performance[j].QuestionID })
+	return performance, nil
+}
+
+// medianMs returns the median of response times, averaging the middle two
+// of an even count as Postgres' percentile_cont does. It sorts times.
+func medianMs(times []int64) int64 {
+	if len(times) == 0 {
+		return 0
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	mid := len(times) / 2
+	if len(times)%2 == 0 {
+		return (times[mid-1] + times[mid]) / 2
+	}
+	return times[mid]
+}
+
+// GetCategoryMastery aggregates answer history per category under the
+// username the player ID answered with, as the Postgres repository does.
+func (r *InMemoryRepository) GetCategoryMastery(playerID string) ([]*models.CategoryMastery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	username, found := "", false
+	for _, record := range r.answers {
+		if record.PlayerID == playerID {
+			username, found = record.Username, true
+			break
+		}
+	}
+	mastery := make([]*models.CategoryMastery, 0)
+	if !found {
+		return mastery, nil
+	}
+
+	byCategory := make(map[string]*models.CategoryMastery)
+	for _, record := range r.answers {
+		if record.Username != username {
+			continue
+		}
+		m := byCategory[record.Category]
+		if m == nil {
+			m = &models.CategoryMastery{Category: record.Category}
+			byCategory[record.Category] = m
+			mastery = append(mastery, m)
+		}
+		m.Answered++
+		if record.Correct {
+			m.Correct++
+		}
+	}
+	for _, m := range mastery {
+		m.Accuracy = float64(m.Correct) / float64(m.Answered)
+	}
+	sort.Slice(mastery, func(i, j int) bool { return mastery[i].Category < mastery[j].Category })
+	return mastery, nil
+}
+
+func (r *InMemoryRepository) SavePendingNotification(n *models.PendingNotification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notificationID++
+	n.ID = r.notificationID
+	stored := *n
+	key := n.LobbyID + "/" + n.PlayerID
+	r.notifications[key] = append(r.notifications[key], &stored)
+	return nil
+}
+
+// TakePendingNotifications removes and returns the player's pending
+// notifications, oldest first.
+func (r *InMemoryRepository) TakePendingNotifications(lobbyID, playerID string) ([]*models.PendingNotification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := lobbyID + "/" + playerID
+	notifications := r.notifications[key]
+	delete(r.notifications, key)
+	if notifications == nil {
+		notifications = make([]*models.PendingNotification, 0)
+	}
+	return notifications, nil
+}
+
+func (r *InMemoryRepository) CreateScoringConfig(config *models.ScoringConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.scoring[config.Version]; ok {
+		return ErrScoringVersionExists
+	}
+	config.CreatedAt = models.Now()
+	stored := *config
+	r.scoring[config.Version] = &stored
+	return nil
+}
+
+func (r *InMemoryRepository) GetScoringConfig(version string) (*models.ScoringConfig, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	config, ok := r.scoring[version]
+	if !ok {
+		return nil, ErrScoringConfigNotFound
+	}
+	copied := *config
+	return &copied, nil
+}
+
+// ListScoringConfigs returns every stored version, oldest first.
+func (r *InMemoryRepository) ListScoringConfigs() ([]*models.ScoringConfig, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	configs := make([]*models.ScoringConfig, 0, len(r.scoring))
+	for _, config := range r.scoring {
+		copied := *config
+		configs = append(configs, &copied)
+	}
+	sort.Slice(configs, func(i, j int) bool {
+		if !configs[i].CreatedAt.Equal(configs[j].CreatedAt) {
+			return configs[i].CreatedAt.Before(configs[j].CreatedAt)
+		}
+		return configs[i].Version < configs[j].Version
+	})
+	return configs, nil
+}
+
+func (r *InMemoryRepository) GetActiveScoringVersion() (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.activeScoring == "" {
+		return "", ErrScoringConfigNotFound
+	}
+	return r.activeScoring, nil
+}
+
+func (r *InMemoryRepository) SetActiveScoringVersion(version string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.scoring[version]; !ok {
+		return ErrScoringConfigNotFound
+	}
+	r.activeScoring = version
+	return nil
+}
+
+func (r *InMemoryRepository) SaveScoringAudit(entry *models.ScoringAuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry.ID = int64(len(r.scoringAudit) + 1)
+	stored := *entry
+	r.scoringAudit = append(r.scoringAudit, &stored)
+	return nil
+}
+
+// ListScoringAudit returns the most recent audit entries, newest first.
+func (r *InMemoryRepository) ListScoringAudit(limit int) ([]*models.ScoringAuditEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := make([]*models.ScoringAuditEntry, 0)
+	for i := len(r.scoringAudit) - 1; i >= 0 && len(entries) < limit; i-- {
+		copied := *r.scoringAudit[i]
+		entries = append(entries, &copied)
+	}
+	return entries, nil
+}
+
+func (r *InMemoryRepository) SaveChatMessage(msg *models.ChatMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.chatID++
+	msg.ID = r.chatID
+	stored := *msg
+	r.chat[msg.LobbyID] = append(r.chat[msg.LobbyID], &stored)
+	return nil
+}
+
+// ListChatMessages returns the lobby's last limit messages sent after since,
+// oldest first.
+func (r *InMemoryRepository) ListChatMessages(lobbyID string, since time.Time, limit int) ([]*models.ChatMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return recentChat(r.chat[lobbyID], since, limit), nil
+}
+
+func (r *InMemoryRepository) DeleteChatMessagesOlderThan(duration time.Duration) (int, error) {
+	cutoff := time.Now().Add(-duration)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	deleted := 0
+	for lobbyID, messages := range r.chat {
+		kept := 0
+		for kept < len(messages) && messages[kept].SentAt.Before(cutoff) {
+			kept++
+		}
+		deleted += kept
+		if kept == len(messages) {
+			delete(r.chat, lobbyID)
+		} else if kept > 0 {
+			r.chat[lobbyID] = append([]*models.ChatMessage(nil), messages[kept:]...)
+		}
+	}
+	return deleted, nil
+}
+
+func (r *InMemoryRepository) SaveGameEvents(events []*models.GameEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, event := range events {
+		r.gameEvents[event.LobbyID] = append(r.gameEvents[event.LobbyID], *event)
+	}
+	return nil
+}
+
+func (r *InMemoryRepository) ListGameEvents(lobbyID string, afterSeq uint64, limit int) ([]*models.GameEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := make([]*models.GameEvent, 0)
+	for _, event := range r.gameEvents[lobbyID] {
+		if len(events) == limit {
+			break
+		}
+		if event.Seq > afterSeq {
+			event := event
+			events = append(events, &event)
+		}
+	}
+	return events, nil
+}
+
+func (r *InMemoryRepository) DeleteGameEventsOlderThan(duration time.Duration) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cutoff := time.Now().Add(-duration)
+	deleted := 0
+	for lobbyID, events := range r.gameEvents {
+		old := 0
+		for old < len(events) && events[old].Timestamp.Before(cutoff) {
+			old++
+		}
+		deleted += old
+		if old == len(events) {
+			delete(r.gameEvents, lobbyID)
+		} else {
+			r.gameEvents[lobbyID] = events[old:]
+		}
+	}
+	return deleted, nil
+}
+
+func (r *InMemoryRepository) ListGameEventLobbies(before time.Time, limit int) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	lobbyIDs := make([]string, 0)
+	for lobbyID, events := range r.gameEvents {
+		if len(events) > 0 && events[len(events)-1].Timestamp.Before(before) {
+			lobbyIDs = append(lobbyIDs, lobbyID)
+		}
+	}
+	sort.Slice(lobbyIDs, func(i, j int) bool {
+		a, b := r.gameEvents[lobbyIDs[i]], r.gameEvents[lobbyIDs[j]]
+		return a[len(a)-1].Timestamp.Before(b[len(b)-1].Timestamp)
+	})
+	if len(lobbyIDs) > limit {
+		lobbyIDs = lobbyIDs[:limit]
+	}
+	return lobbyIDs, nil
+}
+
+func (r *InMemoryRepository) DeleteGameEvents(lobbyID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.gameEvents, lobbyID)
+	return nil
+}
+
+func (r *InMemoryRepository) SaveGameArchive(archive *models.GameArchive) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.gameArchives[archive.LobbyID] = *archive
+	return nil
+}
+
+func (r *InMemoryRepository) GetGameArchive(lobbyID string) (*models.GameArchive, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	archive, ok := r.gameArchives[lobbyID]
+	if !ok {
+		return nil, ErrGameArchiveNotFound
+	}
+	return &archive, nil
+}
+
+func (r *InMemoryRepository) SaveAdminAction(action *models.AdminAction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	action.ID = int64(len(r.adminActions) + 1)
+	stored := *action
+	r.adminActions = append(r.adminActions, &stored)
+	return nil
+}
+
+func (r *InMemoryRepository) ListAdminActions(filter models.AdminActionFilter, limit int) ([]*models.AdminAction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	actions := make([]*models.AdminAction, 0)
+	for i := len(r.adminActions) - 1; i >= 0 && len(actions) < limit; i-- {
+		if !filter.Matches(r.adminActions[i]) {
+			continue
+		}
+		copied := *r.adminActions[i]
+		actions = append(actions, &copied)
+	}
+	return actions, nil
+}
+
+func (r *InMemoryRepository) SavePlayerReport(report *models.PlayerReport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	report.ID = int64(len(r.reports) + 1)
+	stored := *report
+	r.reports = append(r.reports, &stored)
+	return nil
+}
+
+// ListPlayerReports returns the most recent reports, newest first, for one
+// lobby or, with an empty lobbyID, all of them.
+func (r *InMemoryRepository) ListPlayerReports(lobbyID string, limit int) ([]*models.PlayerReport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reports := make([]*models.PlayerReport, 0)
+	for i := len(r.reports) - 1; i >= 0 && len(reports) < limit; i-- {
+		if lobbyID != "" && r.reports[i].LobbyID != lobbyID {
+			continue
+		}
+		copied := *r.reports[i]
+		reports = append(reports, &copied)
+	}
+	return reports, nil
+}
+
+func (r *InMemoryRepository) SavePrizeResult(result *models.PrizeResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prizeResults[result.LobbyID] = data
+	return nil
+}
+
+func (r *InMemoryRepository) GetPrizeResult(lobbyID string) (*models.PrizeResult, error) {
+	r.mu.Lock()
+	data, ok := r.prizeResults[lobbyID]
+	r.mu.Unlock()
+	if !ok {
+		return nil, ErrPrizeResultNotFound
+	}
+	var result models.PrizeResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (r *InMemoryRepository) ListPrizeResults(status models.ResultsStatus) ([]*models.PrizeResult, error) {
+	r.mu.Lock()
+	encoded := make([][]byte, 0, len(r.prizeResults))
+	for _, data := range r.prizeResults {
+		encoded = append(encoded, data)
+	}
+	r.mu.Unlock()
+
+	results := make([]*models.PrizeResult, 0)
+	for _, data := range encoded {
+		var result models.PrizeResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, err
+		}
+		if status == "" || result.Status == status {
+			results = append(results, &result)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].EndedAt.After(results[j].EndedAt) })
+	return results, nil
+}
+
+func (r *InMemoryRepository) SaveEntryRefund(refund *models.EntryRefund) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entryRefunds[refund.LobbyID+"/"+refund.PaymentID] = *refund
+	return nil
+}
+
+func (r *InMemoryRepository) ListEntryRefunds(status models.RefundStatus) ([]*models.EntryRefund, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	refunds := make([]*models.EntryRefund, 0)
+	for _, refund := range r.entryRefunds {
+		if status == "" || refund.Status == status {
+			refund := refund
+			refunds = append(refunds, &refund)
+		}
+	}
+	sort.Slice(refunds, func(i, j int) bool { return refunds[i].CreatedAt.Before(refunds[j].CreatedAt) })
+	return refunds, nil
+}
+
+func (r *InMemoryRepository) CreateUser(user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := strings.ToLower(user.Username)
+	if _, taken := r.usernames[key]; taken {
+		return ErrUsernameTaken
+	}
+	r.users[user.ID] = *user
+	r.usernames[key] = user.ID
+	return nil
+}
+
+func (r *InMemoryRepository) GetUser(userID string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[userID]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return &user, nil
+}
+
+func (r *InMemoryRepository) GetUserByUsername(username string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[r.usernames[strings.ToLower(username)]]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return &user, nil
+}
+
+func (r *InMemoryRepository) AddPlayerStats(game *models.PlayerStats) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := r.playerStats[game.UserID]
+	stats.UserID = game.UserID
+	stats.Add(game)
+	r.playerStats[game.UserID] = stats
+	return nil
+}
+
+func (r *InMemoryRepository) GetPlayerStats(userID string) (*models.PlayerStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := r.playerStats[userID]
+	stats.UserID = userID
+	return &stats, nil
+}
+
+func (r *InMemoryRepository) SaveAccountStreak(streak *models.AccountStreak) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.accountStreaks[streak.UserID] = *streak
+	return nil
+}
+
+func (r *InMemoryRepository) GetAccountStreak(userID string) (*models.AccountStreak, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	streak := r.accountStreaks[userID]
+	streak.UserID = userID
+	return &streak, nil
+}
+
+func friendshipKey(userID, otherID string) string {
+	a, b := orderedPair(userID, otherID)
+	return a + "/" + b
+}
+
+func (r *InMemoryRepository) SaveFriendship(friendship *models.Friendship) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.friendships[friendshipKey(friendship.RequesterID, friendship.AddresseeID)] = *friendship
+	return nil
+}
+
+func (r *InMemoryRepository) GetFriendship(userID, otherID string) (*models.Friendship, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	friendship, ok := r.friendships[friendshipKey(userID, otherID)]
+	if !ok {
+		return nil, ErrFriendshipNotFound
+	}
+	return &friendship, nil
+}
+
+func (r *InMemoryRepository) ListFriendships(userID string) ([]*models.Friendship, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	friendships := make([]*models.Friendship, 0)
+	for _, friendship := range r.friendships {
+		if friendship.RequesterID == userID || friendship.AddresseeID == userID {
+			friendship := friendship
+			friendships = append(friendships, &friendship)
+		}
+	}
+	return friendships, nil
+}
+
+func (r *InMemoryRepository) DeleteFriendship(userID, otherID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.friendships, friendshipKey(userID, otherID))
+	return nil
+}
+
+func (r *InMemoryRepository) SaveRecurringEvent(event *models.RecurringEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events[event.ID] = *event
+	return nil
+}
+
+func (r *InMemoryRepository) ListRecurringEvents() ([]*models.RecurringEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := make([]*models.RecurringEvent, 0, len(r.events))
+	for _, event := range r.events {
+		event := event
+		events = append(events, &event)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].CreatedAt.Before(events[j].CreatedAt) })
+	return events, nil
+}
+
+func (r *InMemoryRepository) DeleteRecurringEvent(eventID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.events[eventID]; !ok {
+		return ErrEventNotFound
+	}
+	delete(r.events, eventID)
+	return nil
+}
+
+func (r *InMemoryRepository) ClaimRecurringEvent(eventID string, startsAt time.Time, lobbyID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	event, ok := r.events[eventID]
+	if !ok || (event.LastStartsAt != nil && !startsAt.After(*event.LastStartsAt)) {
+		return false, nil
+	}
+	event.LastStartsAt, event.LastLobbyID = &startsAt, lobbyID
+	r.events[eventID] = event
+	return true, nil
+}
+
+func (r *InMemoryRepository) SaveAPIKey(key *models.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *key
+	stored.Scopes = append([]string(nil), key.Scopes...)
+	r.apiKeys[key.ID] = stored
+	return nil
+}
+
+func (r *InMemoryRepository) GetAPIKey(keyID string) (*models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key, ok := r.apiKeys[keyID]
+	if !ok {
+		return nil, ErrAPIKeyNotFound
+	}
+	key.Scopes = append([]string(nil), key.Scopes...)
+	return &key, nil
+}
+
+func (r *InMemoryRepository) ListAPIKeys() ([]*models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keys := make([]*models.APIKey, 0, len(r.apiKeys))
+	for _, key := range r.apiKeys {
+		key := key
+		key.Scopes = append([]string(nil), key.Scopes...)
+		keys = append(keys, &key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys, nil
+}
@@ -1,18 +1,22 @@
 package repository
 
 import (
+	"buildprize-game/internal/encryption"
 	"buildprize-game/internal/models"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
 	"time"
 
 	_ "github.com/lib/pq"
 )
 
 type PostgresRepository struct {
-	db *sql.DB
+	db  *sql.DB
+	enc *encryption.Envelope // nil stores sensitive columns in plaintext
 }
 
 func NewPostgresRepository(databaseURL string) (*PostgresRepository, error) {
@@ -33,6 +37,11 @@ func NewPostgresRepository(databaseURL string) (*PostgresRepository, error) {
 	return &PostgresRepository{db: db}, nil
 }
 
+// Ping checks that the database is reachable.
+func (r *PostgresRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
 func createTables(db *sql.DB) error {
 	createLobbiesTable := `
 	CREATE TABLE IF NOT EXISTS lobbies (
@@ -52,6 +61,46 @@ func createTables(db *sql.DB) error {
 	addFinishedAtColumn := `
 	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS finished_at TIMESTAMP WITH TIME ZONE;
 	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS started_at TIMESTAMP WITH TIME ZONE;
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS topic VARCHAR(255) NOT NULL DEFAULT '';
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS round_type VARCHAR(20) NOT NULL DEFAULT '';
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS category_weights JSONB;
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS sandbox BOOLEAN NOT NULL DEFAULT FALSE;
+	ALTER TABLE players ADD COLUMN IF NOT EXISTS is_test BOOLEAN NOT NULL DEFAULT FALSE;
+	ALTER TABLE players ADD COLUMN IF NOT EXISTS is_bot BOOLEAN NOT NULL DEFAULT FALSE;
+	ALTER TABLE players ADD COLUMN IF NOT EXISTS user_id VARCHAR(36) NOT NULL DEFAULT '';
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS demo BOOLEAN NOT NULL DEFAULT FALSE;
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS max_players INTEGER NOT NULL DEFAULT 8;
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS paused BOOLEAN NOT NULL DEFAULT FALSE;
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS remaining_ms BIGINT NOT NULL DEFAULT 0;
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS phase VARCHAR(20) NOT NULL DEFAULT '';
+	ALTER TABLE answers ADD COLUMN IF NOT EXISTS question JSONB;
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS scoring_version VARCHAR(32) NOT NULL DEFAULT '';
+	ALTER TABLE answers ADD COLUMN IF NOT EXISTS scoring_version VARCHAR(32) NOT NULL DEFAULT '';
+	ALTER TABLE answers ADD COLUMN IF NOT EXISTS user_id VARCHAR(36) NOT NULL DEFAULT '';
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS warm_up BOOLEAN NOT NULL DEFAULT FALSE;
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS audience BOOLEAN NOT NULL DEFAULT FALSE;
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS stats JSONB;
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS language VARCHAR(16) NOT NULL DEFAULT 'en';
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS starts_at TIMESTAMP WITH TIME ZONE;
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS invites JSONB;
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS open_rsvp BOOLEAN NOT NULL DEFAULT FALSE;
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS rsvp_quorum INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS start_held BOOLEAN NOT NULL DEFAULT FALSE;
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS webhook_url TEXT NOT NULL DEFAULT '';
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS prize TEXT NOT NULL DEFAULT '';
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS question_time INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS scoring JSONB;
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS wager_rounds JSONB;
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS final_wager BOOLEAN NOT NULL DEFAULT FALSE;
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS prize_pool JSONB;
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS entry_fee BIGINT NOT NULL DEFAULT 0;
+	ALTER TABLE lobbies ADD COLUMN IF NOT EXISTS entry_payments JSONB;
+	ALTER TABLE players ADD COLUMN IF NOT EXISTS power_ups JSONB;
+	ALTER TABLE answers ADD COLUMN IF NOT EXISTS power_up VARCHAR(20) NOT NULL DEFAULT '';
+	ALTER TABLE answers ADD COLUMN IF NOT EXISTS correct_position INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE answers ADD COLUMN IF NOT EXISTS wager_delta INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE admin_audit ALTER COLUMN remote_addr TYPE TEXT;
 	`
 
 	createPlayersTable := `
@@ -65,9 +114,204 @@ func createTables(db *sql.DB) error {
 		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 	);`
 
+	// Answers outlive their lobby (no foreign key) so analytics survive cleanup
+	createAnswersTable := `
+	CREATE TABLE IF NOT EXISTS answers (
+		id BIGSERIAL PRIMARY KEY,
+		player_id VARCHAR(36) NOT NULL,
+		username VARCHAR(255) NOT NULL,
+		lobby_id VARCHAR(36) NOT NULL,
+		round INTEGER NOT NULL,
+		question_id VARCHAR(64) NOT NULL,
+		category VARCHAR(100) NOT NULL,
+		answer JSONB NOT NULL,
+		correct BOOLEAN NOT NULL,
+		score INTEGER NOT NULL,
+		response_time_ms BIGINT NOT NULL,
+		answered_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+	);`
+
+	// Personal events waiting for the player to reconnect
+	createPendingNotificationsTable := `
+	CREATE TABLE IF NOT EXISTS pending_notifications (
+		id BIGSERIAL PRIMARY KEY,
+		player_id VARCHAR(36) NOT NULL,
+		lobby_id VARCHAR(36) NOT NULL,
+		type VARCHAR(50) NOT NULL,
+		data JSONB,
+		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+	);`
+
+	// Chat history, deleted with its lobby or after the retention period
+	createChatMessagesTable := `
+	CREATE TABLE IF NOT EXISTS chat_messages (
+		id BIGSERIAL PRIMARY KEY,
+		lobby_id VARCHAR(36) NOT NULL,
+		player_id VARCHAR(36) NOT NULL,
+		username VARCHAR(255) NOT NULL,
+		message TEXT NOT NULL,
+		sent_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	);`
+
+	// Every lobby-wide event as broadcast, kept past its lobby (no foreign
+	// key) until the retention period ends; id keeps the broadcast order
+	createGameEventsTable := `
+	CREATE TABLE IF NOT EXISTS game_events (
+		id BIGSERIAL PRIMARY KEY,
+		lobby_id VARCHAR(36) NOT NULL,
+		seq BIGINT NOT NULL,
+		type VARCHAR(50) NOT NULL,
+		payload JSONB,
+		emitted_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);`
+
+	// Where event logs moved to cold storage went
+	createGameArchivesTable := `
+	CREATE TABLE IF NOT EXISTS game_archives (
+		lobby_id VARCHAR(36) PRIMARY KEY,
+		object_key TEXT NOT NULL,
+		events INTEGER NOT NULL,
+		last_seq BIGINT NOT NULL,
+		last_event_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		archived_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);`
+
+	// Player reports outlive their lobby (no foreign key) for admin review
+	createPlayerReportsTable := `
+	CREATE TABLE IF NOT EXISTS player_reports (
+		id BIGSERIAL PRIMARY KEY,
+		lobby_id VARCHAR(36) NOT NULL,
+		reporter_id VARCHAR(36) NOT NULL,
+		reporter_username VARCHAR(255) NOT NULL,
+		player_id VARCHAR(36) NOT NULL,
+		username VARCHAR(255) NOT NULL,
+		reason TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	);`
+
+	// Prize results outlive their lobby too; the result is stored whole, with
+	// its status alongside for listing
+	createPrizeResultsTable := `
+	CREATE TABLE IF NOT EXISTS prize_results (
+		lobby_id VARCHAR(36) PRIMARY KEY,
+		status VARCHAR(16) NOT NULL,
+		ended_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		data JSONB NOT NULL,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	);`
+
+	// Entry fee refunds, stored whole like prize results
+	createEntryRefundsTable := `
+	CREATE TABLE IF NOT EXISTS entry_refunds (
+		lobby_id VARCHAR(36) NOT NULL,
+		payment_id VARCHAR(255) NOT NULL,
+		status VARCHAR(16) NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		data JSONB NOT NULL,
+		PRIMARY KEY (lobby_id, payment_id)
+	);`
+
+	createUsersTable := `
+	CREATE TABLE IF NOT EXISTS users (
+		id VARCHAR(36) PRIMARY KEY,
+		username VARCHAR(255) NOT NULL,
+		password_hash TEXT NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	);`
+
+	// Lifetime totals per account, added to as each game ends
+	createPlayerStatsTable := `
+	CREATE TABLE IF NOT EXISTS player_stats (
+		user_id VARCHAR(36) PRIMARY KEY,
+		games_played INTEGER NOT NULL DEFAULT 0,
+		wins INTEGER NOT NULL DEFAULT 0,
+		answered INTEGER NOT NULL DEFAULT 0,
+		correct INTEGER NOT NULL DEFAULT 0,
+		response_ms BIGINT NOT NULL DEFAULT 0,
+		best_streak INTEGER NOT NULL DEFAULT 0,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	);`
+
+	// Day and win streaks per account, stored whole
+	createAccountStreaksTable := `
+	CREATE TABLE IF NOT EXISTS account_streaks (
+		user_id VARCHAR(36) PRIMARY KEY,
+		data JSONB NOT NULL,
+		updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+	);`
+
+	// user_a sorts before user_b, so each pair of accounts has one row
+	createFriendshipsTable := `
+	CREATE TABLE IF NOT EXISTS friendships (
+		user_a VARCHAR(36) NOT NULL,
+		user_b VARCHAR(36) NOT NULL,
+		requester_id VARCHAR(36) NOT NULL,
+		status VARCHAR(20) NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		accepted_at TIMESTAMP WITH TIME ZONE,
+		PRIMARY KEY (user_a, user_b)
+	);`
+
+	createRecurringEventsTable := `
+	CREATE TABLE IF NOT EXISTS recurring_events (
+		id VARCHAR(36) PRIMARY KEY,
+		name VARCHAR(100) NOT NULL,
+		schedule VARCHAR(100) NOT NULL,
+		timezone VARCHAR(64) NOT NULL,
+		lead_minutes INTEGER NOT NULL,
+		max_rounds INTEGER NOT NULL,
+		max_players INTEGER NOT NULL DEFAULT 0,
+		topic TEXT NOT NULL DEFAULT '',
+		language VARCHAR(16) NOT NULL DEFAULT '',
+		rsvp_quorum INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		last_starts_at TIMESTAMP WITH TIME ZONE,
+		last_lobby_id VARCHAR(36) NOT NULL DEFAULT ''
+	);`
+
+	createAPIKeysTable := `
+	CREATE TABLE IF NOT EXISTS api_keys (
+		id VARCHAR(36) PRIMARY KEY,
+		name VARCHAR(100) NOT NULL,
+		scopes TEXT[] NOT NULL,
+		hash VARCHAR(64) NOT NULL,
+		previous_hash VARCHAR(64) NOT NULL DEFAULT '',
+		previous_expires_at TIMESTAMP WITH TIME ZONE,
+		created_by VARCHAR(100) NOT NULL,
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		rotated_at TIMESTAMP WITH TIME ZONE,
+		revoked_at TIMESTAMP WITH TIME ZONE
+	);`
+
+	createAdminAuditTable := `
+	CREATE TABLE IF NOT EXISTS admin_audit (
+		id BIGSERIAL PRIMARY KEY,
+		action VARCHAR(50) NOT NULL,
+		actor VARCHAR(100) NOT NULL,
+		target VARCHAR(100) NOT NULL DEFAULT '',
+		details JSONB,
+		remote_addr TEXT NOT NULL DEFAULT '', -- encrypted with ENCRYPTION_KEYS
+		created_at TIMESTAMP WITH TIME ZONE NOT NULL
+	);`
+
 	createIndexes := `
 	CREATE INDEX IF NOT EXISTS idx_players_lobby_id ON players(lobby_id);
 	CREATE INDEX IF NOT EXISTS idx_lobbies_state ON lobbies(state);
+	CREATE INDEX IF NOT EXISTS idx_answers_player_id ON answers(player_id);
+	CREATE INDEX IF NOT EXISTS idx_answers_username ON answers(username);
+	CREATE INDEX IF NOT EXISTS idx_answers_lobby_id ON answers(lobby_id);
+	CREATE INDEX IF NOT EXISTS idx_answers_user_id ON answers(user_id) WHERE user_id <> '';
+	CREATE INDEX IF NOT EXISTS idx_pending_notifications_player ON pending_notifications(lobby_id, player_id);
+	CREATE INDEX IF NOT EXISTS idx_chat_messages_lobby ON chat_messages(lobby_id, sent_at);
+	CREATE INDEX IF NOT EXISTS idx_chat_messages_sent_at ON chat_messages(sent_at);
+	CREATE INDEX IF NOT EXISTS idx_game_events_lobby ON game_events(lobby_id, seq);
+	CREATE INDEX IF NOT EXISTS idx_game_events_emitted_at ON game_events(emitted_at);
+	CREATE INDEX IF NOT EXISTS idx_player_reports_lobby ON player_reports(lobby_id);
+	CREATE INDEX IF NOT EXISTS idx_prize_results_status ON prize_results(status, ended_at);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users(LOWER(username));
+	CREATE INDEX IF NOT EXISTS idx_players_user ON players(user_id) WHERE user_id <> '';
+	CREATE INDEX IF NOT EXISTS idx_friendships_user_b ON friendships(user_b);
+	CREATE INDEX IF NOT EXISTS idx_admin_audit_target ON admin_audit(target);
 	`
 
 	if _, err := db.Exec(createLobbiesTable); err != nil {
@@ -76,13 +320,65 @@ func createTables(db *sql.DB) error {
 	if _, err := db.Exec(createPlayersTable); err != nil {
 		return err
 	}
-	if _, err := db.Exec(createIndexes); err != nil {
+	if _, err := db.Exec(createAnswersTable); err != nil {
+		return err
+	}
+	if _, err := db.Exec(createPendingNotificationsTable); err != nil {
+		return err
+	}
+	if _, err := db.Exec(createChatMessagesTable); err != nil {
+		return err
+	}
+	if _, err := db.Exec(createGameEventsTable); err != nil {
+		return err
+	}
+	if _, err := db.Exec(createGameArchivesTable); err != nil {
+		return err
+	}
+	if _, err := db.Exec(createPlayerReportsTable); err != nil {
+		return err
+	}
+	if _, err := db.Exec(createPrizeResultsTable); err != nil {
+		return err
+	}
+	if _, err := db.Exec(createEntryRefundsTable); err != nil {
+		return err
+	}
+	if _, err := db.Exec(createUsersTable); err != nil {
+		return err
+	}
+	if _, err := db.Exec(createPlayerStatsTable); err != nil {
+		return err
+	}
+	if _, err := db.Exec(createAccountStreaksTable); err != nil {
+		return err
+	}
+	if _, err := db.Exec(createFriendshipsTable); err != nil {
+		return err
+	}
+	if _, err := db.Exec(createRecurringEventsTable); err != nil {
+		return err
+	}
+	if _, err := db.Exec(createAPIKeysTable); err != nil {
+		return err
+	}
+	if _, err := db.Exec(createAdminAuditTable); err != nil {
 		return err
 	}
-	// Add missing columns for existing databases
+	// Add missing columns for existing databases, and columns the CREATE
+	// statements above never had, before indexing them
 	if _, err := db.Exec(addFinishedAtColumn); err != nil {
 		return err
 	}
+	if _, err := db.Exec(createIndexes); err != nil {
+		return err
+	}
+	if _, err := db.Exec(createScoringTables); err != nil {
+		return err
+	}
+	if err := seedScoringConfig(db); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -96,8 +392,8 @@ func (r *PostgresRepository) SaveLobby(lobby *models.Lobby) error {
 
 	// Update or insert lobby
 	query := `
-		INSERT INTO lobbies (id, name, state, round, max_rounds, current_question, created_at, started_at, finished_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO lobbies (id, name, state, round, max_rounds, current_question, created_at, started_at, finished_at, updated_at, topic, sandbox, round_type, category_weights, demo, max_players, timezone, paused, remaining_ms, phase, scoring_version, warm_up, audience, stats, language, starts_at, invites, open_rsvp, rsvp_quorum, start_held, webhook_url, prize, question_time, scoring, wager_rounds, final_wager, prize_pool, entry_fee, entry_payments)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33, $34, $35, $36, $37, $38, $39)
 		ON CONFLICT (id) DO UPDATE SET
 			name = EXCLUDED.name,
 			state = EXCLUDED.state,
@@ -106,7 +402,36 @@ func (r *PostgresRepository) SaveLobby(lobby *models.Lobby) error {
 			current_question = EXCLUDED.current_question,
 			started_at = EXCLUDED.started_at,
 			finished_at = EXCLUDED.finished_at,
-			updated_at = EXCLUDED.updated_at
+			updated_at = EXCLUDED.updated_at,
+			topic = EXCLUDED.topic,
+			sandbox = EXCLUDED.sandbox,
+			round_type = EXCLUDED.round_type,
+			category_weights = EXCLUDED.category_weights,
+			demo = EXCLUDED.demo,
+			max_players = EXCLUDED.max_players,
+			timezone = EXCLUDED.timezone,
+			paused = EXCLUDED.paused,
+			remaining_ms = EXCLUDED.remaining_ms,
+			phase = EXCLUDED.phase,
+			scoring_version = EXCLUDED.scoring_version,
+			warm_up = EXCLUDED.warm_up,
+			audience = EXCLUDED.audience,
+			stats = EXCLUDED.stats,
+			language = EXCLUDED.language,
+			starts_at = EXCLUDED.starts_at,
+			invites = EXCLUDED.invites,
+			open_rsvp = EXCLUDED.open_rsvp,
+			rsvp_quorum = EXCLUDED.rsvp_quorum,
+			start_held = EXCLUDED.start_held,
+			webhook_url = EXCLUDED.webhook_url,
+			prize = EXCLUDED.prize,
+			question_time = EXCLUDED.question_time,
+			scoring = EXCLUDED.scoring,
+			wager_rounds = EXCLUDED.wager_rounds,
+			final_wager = EXCLUDED.final_wager,
+			prize_pool = EXCLUDED.prize_pool,
+			entry_fee = EXCLUDED.entry_fee,
+			entry_payments = EXCLUDED.entry_payments
 	`
 
 	var questionJSON interface{} // Use interface{} so we can pass NULL to PostgreSQL
@@ -122,6 +447,55 @@ func (r *PostgresRepository) SaveLobby(lobby *models.Lobby) error {
 		questionJSON = nil // NULL for PostgreSQL when no question
 	}
 
+	var weightsJSON interface{}
+	if len(lobby.CategoryWeights) > 0 {
+		if jsonBytes, err := json.Marshal(lobby.CategoryWeights); err == nil {
+			weightsJSON = jsonBytes
+		}
+	}
+
+	var statsJSON interface{}
+	if lobby.Stats != nil {
+		if jsonBytes, err := json.Marshal(lobby.Stats); err == nil {
+			statsJSON = jsonBytes
+		}
+	}
+
+	var invitesJSON interface{}
+	if len(lobby.Invites) > 0 {
+		if jsonBytes, err := json.Marshal(lobby.Invites); err == nil {
+			invitesJSON = jsonBytes
+		}
+	}
+
+	var scoringJSON interface{}
+	if lobby.Scoring != nil {
+		if jsonBytes, err := json.Marshal(lobby.Scoring); err == nil {
+			scoringJSON = jsonBytes
+		}
+	}
+
+	var prizePoolJSON interface{}
+	if lobby.PrizePool != nil {
+		if jsonBytes, err := json.Marshal(lobby.PrizePool); err == nil {
+			prizePoolJSON = jsonBytes
+		}
+	}
+
+	var entryPaymentsJSON interface{}
+	if len(lobby.EntryPayments) > 0 {
+		if jsonBytes, err := json.Marshal(lobby.EntryPayments); err == nil {
+			entryPaymentsJSON = jsonBytes
+		}
+	}
+
+	var wagerRoundsJSON interface{}
+	if len(lobby.WagerRounds) > 0 {
+		if jsonBytes, err := json.Marshal(lobby.WagerRounds); err == nil {
+			wagerRoundsJSON = jsonBytes
+		}
+	}
+
 	log.Printf("DEBUG SaveLobby: Saving lobby '%s' (ID: %s) with State: '%s' (type: %T), Round: %d", lobby.Name, lobby.ID, lobby.State, lobby.State, lobby.Round)
 	
 	_, err = tx.Exec(query,
@@ -134,7 +508,36 @@ func (r *PostgresRepository) SaveLobby(lobby *models.Lobby) error {
 		lobby.CreatedAt,
 		lobby.StartedAt,
 		lobby.FinishedAt,
-		time.Now(),
+		models.Now(),
+		lobby.Topic,
+		lobby.Sandbox,
+		lobby.RoundType,
+		weightsJSON,
+		lobby.Demo,
+		lobby.MaxPlayers,
+		lobby.Timezone,
+		lobby.Paused,
+		lobby.RemainingMs,
+		lobby.Phase,
+		lobby.ScoringVersion,
+		lobby.WarmUp,
+		lobby.Audience,
+		statsJSON,
+		lobby.Language,
+		lobby.StartsAt,
+		invitesJSON,
+		lobby.OpenRSVP,
+		lobby.RSVPQuorum,
+		lobby.StartHeld,
+		lobby.WebhookURL,
+		lobby.Prize,
+		lobby.QuestionTime,
+		scoringJSON,
+		wagerRoundsJSON,
+		lobby.FinalWager,
+		prizePoolJSON,
+		lobby.EntryFee,
+		entryPaymentsJSON,
 	)
 	if err != nil {
 		log.Printf("ERROR SaveLobby: Failed to save lobby %s: %v", lobby.ID, err)
@@ -151,10 +554,16 @@ func (r *PostgresRepository) SaveLobby(lobby *models.Lobby) error {
 
 	// Insert players
 	for _, player := range lobby.Players {
+		var powerUpsJSON interface{}
+		if len(player.PowerUps) > 0 {
+			if jsonBytes, err := json.Marshal(player.PowerUps); err == nil {
+				powerUpsJSON = jsonBytes
+			}
+		}
 		_, err = tx.Exec(`
-			INSERT INTO players (id, lobby_id, username, score, streak, is_ready, created_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7)
-		`, player.ID, lobby.ID, player.Username, player.Score, player.Streak, player.IsReady, time.Now())
+			INSERT INTO players (id, lobby_id, username, score, streak, is_ready, created_at, is_test, is_bot, user_id, power_ups)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		`, player.ID, lobby.ID, player.Username, player.Score, player.Streak, player.IsReady, models.Now(), player.IsTest, player.IsBot, player.UserID, powerUpsJSON)
 		if err != nil {
 			return err
 		}
@@ -166,17 +575,17 @@ func (r *PostgresRepository) SaveLobby(lobby *models.Lobby) error {
 func (r *PostgresRepository) GetLobby(lobbyID string) (*models.Lobby, error) {
 	// Get lobby
 	lobbyQuery := `
-		SELECT id, name, state, round, max_rounds, current_question, created_at, started_at, finished_at
+		SELECT id, name, state, round, max_rounds, current_question, created_at, started_at, finished_at, topic, sandbox, round_type, category_weights, demo, max_players, timezone, paused, remaining_ms, phase, scoring_version, warm_up, audience, stats, language, starts_at, invites, open_rsvp, rsvp_quorum, start_held, webhook_url, prize, question_time, scoring, wager_rounds, final_wager, prize_pool, entry_fee, entry_payments
 		FROM lobbies WHERE id = $1
 	`
 
 	var lobby models.Lobby
-	var questionJSON []byte
-	var startedAt, finishedAt sql.NullTime
+	var questionJSON, weightsJSON, statsJSON, invitesJSON, scoringJSON, wagerRoundsJSON, prizePoolJSON, entryPaymentsJSON []byte
+	var startedAt, finishedAt, startsAt sql.NullTime
 
 	err := r.db.QueryRow(lobbyQuery, lobbyID).Scan(
 		&lobby.ID, &lobby.Name, &lobby.State, &lobby.Round,
-		&lobby.MaxRounds, &questionJSON, &lobby.CreatedAt, &startedAt, &finishedAt,
+		&lobby.MaxRounds, &questionJSON, &lobby.CreatedAt, &startedAt, &finishedAt, &lobby.Topic, &lobby.Sandbox, &lobby.RoundType, &weightsJSON, &lobby.Demo, &lobby.MaxPlayers, &lobby.Timezone, &lobby.Paused, &lobby.RemainingMs, &lobby.Phase, &lobby.ScoringVersion, &lobby.WarmUp, &lobby.Audience, &statsJSON, &lobby.Language, &startsAt, &invitesJSON, &lobby.OpenRSVP, &lobby.RSVPQuorum, &lobby.StartHeld, &lobby.WebhookURL, &lobby.Prize, &lobby.QuestionTime, &scoringJSON, &wagerRoundsJSON, &lobby.FinalWager, &prizePoolJSON, &lobby.EntryFee, &entryPaymentsJSON,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -193,17 +602,50 @@ func (r *PostgresRepository) GetLobby(lobbyID string) (*models.Lobby, error) {
 		}
 	}
 	
-	// Set started_at and finished_at if they exist
+	if len(weightsJSON) > 0 {
+		json.Unmarshal(weightsJSON, &lobby.CategoryWeights)
+	}
+	if len(statsJSON) > 0 {
+		json.Unmarshal(statsJSON, &lobby.Stats)
+	}
+	if len(invitesJSON) > 0 {
+		json.Unmarshal(invitesJSON, &lobby.Invites)
+	}
+	if len(scoringJSON) > 0 {
+		json.Unmarshal(scoringJSON, &lobby.Scoring)
+	}
+	if len(prizePoolJSON) > 0 {
+		json.Unmarshal(prizePoolJSON, &lobby.PrizePool)
+	}
+	if len(entryPaymentsJSON) > 0 {
+		json.Unmarshal(entryPaymentsJSON, &lobby.EntryPayments)
+	}
+	if len(wagerRoundsJSON) > 0 {
+		json.Unmarshal(wagerRoundsJSON, &lobby.WagerRounds)
+	}
+
+	if lobby.Phase == "" {
+		lobby.Phase = models.PhaseForState(lobby.State)
+	}
+
+	// Set started_at and finished_at if they exist; timestamps are returned in UTC
+	lobby.CreatedAt = lobby.CreatedAt.UTC()
 	if startedAt.Valid {
-		lobby.StartedAt = &startedAt.Time
+		startedAtUTC := startedAt.Time.UTC()
+		lobby.StartedAt = &startedAtUTC
 	}
 	if finishedAt.Valid {
-		lobby.FinishedAt = &finishedAt.Time
+		finishedAtUTC := finishedAt.Time.UTC()
+		lobby.FinishedAt = &finishedAtUTC
+	}
+	if startsAt.Valid {
+		startsAtUTC := startsAt.Time.UTC()
+		lobby.StartsAt = &startsAtUTC
 	}
 
 	// Get players
 	playersQuery := `
-		SELECT id, username, score, streak, is_ready
+		SELECT id, username, score, streak, is_ready, is_test, is_bot, user_id, power_ups
 		FROM players WHERE lobby_id = $1
 		ORDER BY score DESC, username
 	`
@@ -216,10 +658,14 @@ func (r *PostgresRepository) GetLobby(lobbyID string) (*models.Lobby, error) {
 
 	for rows.Next() {
 		var player models.Player
-		err := rows.Scan(&player.ID, &player.Username, &player.Score, &player.Streak, &player.IsReady)
+		var powerUpsJSON []byte
+		err := rows.Scan(&player.ID, &player.Username, &player.Score, &player.Streak, &player.IsReady, &player.IsTest, &player.IsBot, &player.UserID, &powerUpsJSON)
 		if err != nil {
 			return nil, err
 		}
+		if len(powerUpsJSON) > 0 {
+			json.Unmarshal(powerUpsJSON, &player.PowerUps)
+		}
 		lobby.Players = append(lobby.Players, &player)
 	}
 
@@ -227,11 +673,23 @@ func (r *PostgresRepository) GetLobby(lobbyID string) (*models.Lobby, error) {
 }
 
 func (r *PostgresRepository) DeleteLobby(lobbyID string) error {
+	if _, err := r.db.Exec("DELETE FROM pending_notifications WHERE lobby_id = $1", lobbyID); err != nil {
+		return err
+	}
+	if _, err := r.db.Exec("DELETE FROM chat_messages WHERE lobby_id = $1", lobbyID); err != nil {
+		return err
+	}
 	_, err := r.db.Exec("DELETE FROM lobbies WHERE id = $1", lobbyID)
 	return err
 }
 
-func (r *PostgresRepository) ListLobbies() ([]*models.Lobby, error) {
+// ListLobbies returns the page of non-sandbox lobbies the query selects.
+func (r *PostgresRepository) ListLobbies(query LobbyQuery) (*LobbyPage, error) {
+	query, err := query.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
 	// DEBUG: First check what's actually in the database
 	debugQuery := `SELECT id, name, state, round, created_at FROM lobbies ORDER BY created_at DESC LIMIT 10`
 	debugRows, _ := r.db.Query(debugQuery)
@@ -251,35 +709,41 @@ func (r *PostgresRepository) ListLobbies() ([]*models.Lobby, error) {
 		log.Printf("DEBUG: Found %d total lobbies in database", debugCount)
 	}
 
-	// Query to get all waiting lobbies (including those with 0 players)
-	query := `
-		SELECT l.id, l.name, l.state, l.round, l.max_rounds, l.created_at
-		FROM lobbies l
-		WHERE LOWER(l.state) = 'waiting'
-		ORDER BY l.created_at DESC
-		LIMIT 50
-	`
+	// Lobby selection (including those with 0 players); the window count is
+	// the total before LIMIT/OFFSET
+	selection := NewSelect(`l.id, l.name, l.state, l.round, l.max_rounds, l.created_at, l.topic, l.round_type, l.demo, l.max_players, l.timezone, l.warm_up, l.audience, l.language,
+			COUNT(*) OVER ()`, "lobbies l").
+		Where("NOT l.sandbox")
+	if query.State != "" {
+		selection.Where("LOWER(l.state) = ?", string(query.State))
+	}
+	if query.HasSpace {
+		selection.Where("(SELECT COUNT(*) FROM players p WHERE p.lobby_id = l.id) < COALESCE(NULLIF(l.max_players, 0), 8)")
+	}
+	sqlQuery, args := selection.OrderBy(lobbyOrder[query.Sort]).Limit(query.Limit).Offset(query.Offset).SQL()
 
-	log.Printf("DEBUG: Executing query for waiting lobbies: WHERE LOWER(l.state) = 'waiting'")
-	rows, err := r.db.Query(query)
+	log.Printf("DEBUG: Executing lobby listing query: state=%q has_space=%v sort=%s limit=%d offset=%d", query.State, query.HasSpace, query.Sort, query.Limit, query.Offset)
+	rows, err := r.db.Query(sqlQuery, args...)
 	if err != nil {
 		log.Printf("ERROR: ListLobbies query failed: %v", err)
 		return nil, err
 	}
 	defer rows.Close()
 
-	lobbies := make([]*models.Lobby, 0) // Initialize as empty slice, not nil
+	page := &LobbyPage{Lobbies: make([]*models.Lobby, 0)} // Initialize as empty slice, not nil
 	for rows.Next() {
 		var lobby models.Lobby
-		err := rows.Scan(&lobby.ID, &lobby.Name, &lobby.State, &lobby.Round, &lobby.MaxRounds, &lobby.CreatedAt)
+		err := rows.Scan(&lobby.ID, &lobby.Name, &lobby.State, &lobby.Round, &lobby.MaxRounds, &lobby.CreatedAt, &lobby.Topic, &lobby.RoundType, &lobby.Demo, &lobby.MaxPlayers, &lobby.Timezone, &lobby.WarmUp, &lobby.Audience, &lobby.Language, &page.Total)
 		if err != nil {
 			log.Printf("ERROR: Failed to scan lobby row: %v", err)
 			return nil, err
 		}
+		lobby.CreatedAt = lobby.CreatedAt.UTC()
+		lobby.Phase = models.PhaseForState(lobby.State)
 		
 		// Load players for this lobby (even if 0 players, lobby should still show)
 		playersQuery := `
-			SELECT id, username, score, streak, is_ready
+			SELECT id, username, score, streak, is_ready, is_test, is_bot
 			FROM players WHERE lobby_id = $1
 			ORDER BY score DESC, username
 		`
@@ -288,18 +752,33 @@ func (r *PostgresRepository) ListLobbies() ([]*models.Lobby, error) {
 			defer playerRows.Close()
 			for playerRows.Next() {
 				var player models.Player
-				if err := playerRows.Scan(&player.ID, &player.Username, &player.Score, &player.Streak, &player.IsReady); err == nil {
+				if err := playerRows.Scan(&player.ID, &player.Username, &player.Score, &player.Streak, &player.IsReady, &player.IsTest, &player.IsBot); err == nil {
 					lobby.Players = append(lobby.Players, &player)
 				}
 			}
 		}
 		
-		log.Printf("ListLobbies: Found waiting lobby '%s' (ID: %s, State: '%s', Players: %d)", lobby.Name, lobby.ID, lobby.State, len(lobby.Players))
-		lobbies = append(lobbies, &lobby)
+		log.Printf("ListLobbies: Found lobby '%s' (ID: %s, State: '%s', Players: %d)", lobby.Name, lobby.ID, lobby.State, len(lobby.Players))
+		page.Lobbies = append(page.Lobbies, &lobby)
+	}
+	if len(page.Lobbies) == 0 && query.Offset > 0 {
+		// Past the last page there are no rows to carry the total
+		countQuery, countArgs := selection.CountSQL()
+		if err := r.db.QueryRow(countQuery, countArgs...).Scan(&page.Total); err != nil {
+			return nil, err
+		}
 	}
 
-	log.Printf("ListLobbies: Returning %d waiting lobbies", len(lobbies))
-	return lobbies, nil
+	log.Printf("ListLobbies: Returning %d of %d lobbies", len(page.Lobbies), page.Total)
+	return page, nil
+}
+
+// lobbyOrder is the ORDER BY clause for each sort, matching pageLobbies.
+var lobbyOrder = map[LobbySort]string{
+	SortNewest:  "l.created_at DESC, l.id",
+	SortOldest:  "l.created_at, l.id",
+	SortName:    "LOWER(l.name), l.created_at DESC, l.id",
+	SortPlayers: "(SELECT COUNT(*) FROM players p WHERE p.lobby_id = l.id) DESC, l.created_at DESC, l.id",
 }
 
 // DeleteFinishedGamesOlderThan deletes finished games that finished more than the specified duration ago
@@ -324,6 +803,325 @@ func (r *PostgresRepository) DeleteFinishedGamesOlderThan(duration time.Duration
 	return int(deleted), nil
 }
 
+func (r *PostgresRepository) SaveAnswer(record *models.AnswerRecord) error {
+	answerJSON, err := json.Marshal(record.Answer)
+	if err != nil {
+		return err
+	}
+	var questionJSON interface{} // NULL when the question isn't known
+	if record.Question != nil {
+		if questionJSON, err = json.Marshal(record.Question); err != nil {
+			return err
+		}
+	}
+
+	err = r.db.QueryRow(`
+		INSERT INTO answers (player_id, username, lobby_id, round, question_id, category, answer, correct, score, response_time_ms, answered_at, question, scoring_version, user_id, power_up, correct_position, wager_delta)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		RETURNING id
+	`, record.PlayerID, record.Username, record.LobbyID, record.Round, record.QuestionID, record.Category,
+		answerJSON, record.Correct, record.Score, record.ResponseTime, record.AnsweredAt, questionJSON, record.ScoringVersion, record.UserID, record.PowerUp, record.Position, record.WagerDelta).Scan(&record.ID)
+	return err
+}
+
+// GetLobbyAnswers returns every answer recorded in a lobby, oldest first.
+func (r *PostgresRepository) GetLobbyAnswers(lobbyID string) ([]*models.AnswerRecord, error) {
+	query := `
+		SELECT id, player_id, username, lobby_id, round, question_id, category, question, answer, correct, score, response_time_ms, answered_at, scoring_version, user_id, power_up, correct_position, wager_delta
+		FROM answers
+		WHERE lobby_id = $1
+		ORDER BY round, answered_at, id
+	`
+	rows, err := r.db.Query(query, lobbyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	answers := make([]*models.AnswerRecord, 0)
+	for rows.Next() {
+		var record models.AnswerRecord
+		var questionJSON, answerJSON []byte
+		if err := rows.Scan(&record.ID, &record.PlayerID, &record.Username, &record.LobbyID, &record.Round,
+			&record.QuestionID, &record.Category, &questionJSON, &answerJSON, &record.Correct, &record.Score,
+			&record.ResponseTime, &record.AnsweredAt, &record.ScoringVersion, &record.UserID, &record.PowerUp, &record.Position, &record.WagerDelta); err != nil {
+			return nil, err
+		}
+		if len(questionJSON) > 0 {
+			var question models.Question
+			if err := json.Unmarshal(questionJSON, &question); err == nil {
+				record.Question = &question
+			}
+		}
+		if err := json.Unmarshal(answerJSON, &record.Answer); err != nil {
+			return nil, err
+		}
+		record.AnsweredAt = record.AnsweredAt.UTC()
+		answers = append(answers, &record)
+	}
+
+	return answers, rows.Err()
+}
+
+func (r *PostgresRepository) UpdateAnswerScore(answerID int64, score int) error {
+	_, err := r.db.Exec("UPDATE answers SET score = $1 WHERE id = $2", score, answerID)
+	return err
+}
+
+func (r *PostgresRepository) UpdateAnswerWager(answerID int64, delta int) error {
+	_, err := r.db.Exec("UPDATE answers SET wager_delta = $1 WHERE id = $2", delta, answerID)
+	return err
+}
+
+// GetCategoryMastery aggregates answer history per category. Player IDs are
+// issued per lobby join, so history is grouped by the username the player ID
+// answered under to cover every game that player has played.
+func (r *PostgresRepository) GetCategoryMastery(playerID string) ([]*models.CategoryMastery, error) {
+	query := `
+		SELECT category, COUNT(*), COUNT(*) FILTER (WHERE correct)
+		FROM answers
+		WHERE username = (SELECT username FROM answers WHERE player_id = $1 LIMIT 1)
+		GROUP BY category
+		ORDER BY category
+	`
+	rows, err := r.db.Query(query, playerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mastery := make([]*models.CategoryMastery, 0)
+	for rows.Next() {
+		var m models.CategoryMastery
+		if err := rows.Scan(&m.Category, &m.Answered, &m.Correct); err != nil {
+			return nil, err
+		}
+		if m.Answered > 0 {
+			m.Accuracy = float64(m.Correct) / float64(m.Answered)
+		}
+		mastery = append(mastery, &m)
+	}
+
+	return mastery, rows.Err()
+}
+
+// GetQuestionPerformance aggregates answer history per question, in
+// question ID order.
+func (r *PostgresRepository) GetQuestionPerformance() ([]*models.QuestionPerformance, error) {
+	rows, err := r.db.Query(`
+		SELECT question_id, COUNT(*), COUNT(*) FILTER (WHERE correct),
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY response_time_ms)
+		FROM answers
+		GROUP BY question_id
+		ORDER BY question_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	performance := make([]*models.QuestionPerformance, 0)
+	for rows.Next() {
+		var p models.QuestionPerformance
+		var median float64
+		if err := rows.Scan(&p.QuestionID, &p.Answered, &p.Correct, &median); err != nil {
+			return nil, err
+		}
+		if p.Answered > 0 {
+			p.Accuracy = float64(p.Correct) / float64(p.Answered)
+		}
+		p.MedianResponseMs = int64(median)
+		performance = append(performance, &p)
+	}
+	return performance, rows.Err()
+}
+
+func (r *PostgresRepository) SavePendingNotification(n *models.PendingNotification) error {
+	data, err := sealJSON(r.enc, n.Data, pendingNotificationsData)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt notification: %w", err)
+	}
+	return r.db.QueryRow(`
+		INSERT INTO pending_notifications (player_id, lobby_id, type, data, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, n.PlayerID, n.LobbyID, n.Type, data, n.CreatedAt).Scan(&n.ID)
+}
+
+// TakePendingNotifications removes and returns the player's pending
+// notifications, oldest first.
+func (r *PostgresRepository) TakePendingNotifications(lobbyID, playerID string) ([]*models.PendingNotification, error) {
+	rows, err := r.db.Query(`
+		DELETE FROM pending_notifications
+		WHERE lobby_id = $1 AND player_id = $2
+		RETURNING id, player_id, lobby_id, type, data, created_at
+	`, lobbyID, playerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := make([]*models.PendingNotification, 0)
+	for rows.Next() {
+		var n models.PendingNotification
+		var data []byte
+		if err := rows.Scan(&n.ID, &n.PlayerID, &n.LobbyID, &n.Type, &data, &n.CreatedAt); err != nil {
+			return nil, err
+		}
+		if n.Data, err = openJSON(r.enc, data, pendingNotificationsData); err != nil {
+			return nil, fmt.Errorf("failed to decrypt notification %d: %w", n.ID, err)
+		}
+		n.CreatedAt = n.CreatedAt.UTC()
+		notifications = append(notifications, &n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(notifications, func(i, j int) bool { return notifications[i].ID < notifications[j].ID })
+	return notifications, nil
+}
+
+func (r *PostgresRepository) SaveChatMessage(msg *models.ChatMessage) error {
+	return r.db.QueryRow(`
+		INSERT INTO chat_messages (lobby_id, player_id, username, message, sent_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, msg.LobbyID, msg.PlayerID, msg.Username, msg.Message, msg.SentAt).Scan(&msg.ID)
+}
+
+// ListChatMessages returns the lobby's last limit messages sent after since,
+// oldest first.
+func (r *PostgresRepository) ListChatMessages(lobbyID string, since time.Time, limit int) ([]*models.ChatMessage, error) {
+	rows, err := r.db.Query(`
+		SELECT id, lobby_id, player_id, username, message, sent_at FROM (
+			SELECT id, lobby_id, player_id, username, message, sent_at
+			FROM chat_messages
+			WHERE lobby_id = $1 AND sent_at > $2
+			ORDER BY sent_at DESC, id DESC
+			LIMIT $3
+		) recent
+		ORDER BY sent_at, id
+	`, lobbyID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := make([]*models.ChatMessage, 0)
+	for rows.Next() {
+		var msg models.ChatMessage
+		if err := rows.Scan(&msg.ID, &msg.LobbyID, &msg.PlayerID, &msg.Username, &msg.Message, &msg.SentAt); err != nil {
+			return nil, err
+		}
+		msg.SentAt = msg.SentAt.UTC()
+		messages = append(messages, &msg)
+	}
+	return messages, rows.Err()
+}
+
+func (r *PostgresRepository) DeleteChatMessagesOlderThan(duration time.Duration) (int, error) {
+	result, err := r.db.Exec("DELETE FROM chat_messages WHERE sent_at < $1", time.Now().Add(-duration))
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := result.RowsAffected()
+	return int(deleted), err
+}
+
 func (r *PostgresRepository) Close() error {
 	return r.db.Close()
 }
+
+func (r *PostgresRepository) SavePlayerReport(report *models.PlayerReport) error {
+	return r.db.QueryRow(`
+		INSERT INTO player_reports (lobby_id, reporter_id, reporter_username, player_id, username, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, report.LobbyID, report.ReporterID, report.ReporterUsername, report.PlayerID, report.Username, report.Reason, report.CreatedAt).Scan(&report.ID)
+}
+
+// ListPlayerReports returns the most recent reports, newest first, for one
+// lobby or, with an empty lobbyID, all of them.
+func (r *PostgresRepository) ListPlayerReports(lobbyID string, limit int) ([]*models.PlayerReport, error) {
+	q := NewSelect("id, lobby_id, reporter_id, reporter_username, player_id, username, reason, created_at", "player_reports")
+	if lobbyID != "" {
+		q.Where("lobby_id = ?", lobbyID)
+	}
+	query, args := q.OrderBy("id DESC").Limit(limit).SQL()
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := make([]*models.PlayerReport, 0)
+	for rows.Next() {
+		var report models.PlayerReport
+		if err := rows.Scan(&report.ID, &report.LobbyID, &report.ReporterID, &report.ReporterUsername, &report.PlayerID, &report.Username, &report.Reason, &report.CreatedAt); err != nil {
+			return nil, err
+		}
+		report.CreatedAt = report.CreatedAt.UTC()
+		reports = append(reports, &report)
+	}
+	return reports, rows.Err()
+}
+
+func (r *PostgresRepository) SavePrizeResult(result *models.PrizeResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`
+		INSERT INTO prize_results (lobby_id, status, ended_at, data, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (lobby_id) DO UPDATE SET
+			status = EXCLUDED.status,
+			data = EXCLUDED.data,
+			updated_at = EXCLUDED.updated_at
+	`, result.LobbyID, result.Status, result.EndedAt, data)
+	return err
+}
+
+func (r *PostgresRepository) GetPrizeResult(lobbyID string) (*models.PrizeResult, error) {
+	var data []byte
+	err := r.db.QueryRow("SELECT data FROM prize_results WHERE lobby_id = $1", lobbyID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, ErrPrizeResultNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var result models.PrizeResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (r *PostgresRepository) ListPrizeResults(status models.ResultsStatus) ([]*models.PrizeResult, error) {
+	q := NewSelect("data", "prize_results")
+	if status != "" {
+		q.Where("status = ?", status)
+	}
+	query, args := q.OrderBy("ended_at DESC").SQL()
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]*models.PrizeResult, 0)
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var result models.PrizeResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, err
+		}
+		results = append(results, &result)
+	}
+	return results, rows.Err()
+}
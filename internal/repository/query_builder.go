@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SelectQuery composes a Postgres SELECT whose filters vary per call, such as
+// a lobby listing's. Conditions are written with ? placeholders and their
+// values passed alongside; the placeholders are numbered $1, $2, ... in the
+// order conditions were added, so values never end up in the SQL text. Every
+// ? in a condition is a placeholder, so Postgres's ? operators can't be used.
+//
+// Columns, the table, conditions and the ORDER BY clause are SQL and must be
+// constants or picked from a fixed set in code, never taken from a request.
+type SelectQuery struct {
+	columns string
+	from    string
+	where   []string
+	args    []interface{}
+	orderBy string
+	limit   *int
+	offset  *int
+}
+
+// NewSelect starts a query selecting columns from a table or join.
+func NewSelect(columns, from string) *SelectQuery {
+	return &SelectQuery{columns: columns, from: from}
+}
+
+// Where adds a condition, ANDed with the others. It panics if the number of
+// ? placeholders in cond doesn't match the number of args.
+func (q *SelectQuery) Where(cond string, args ...interface{}) *SelectQuery {
+	if n := strings.Count(cond, "?"); n != len(args) {
+		panic(fmt.Sprintf("repository: condition %q has %d placeholders but %d args", cond, n, len(args)))
+	}
+	q.where = append(q.where, cond)
+	q.args = append(q.args, args...)
+	return q
+}
+
+// OrderBy sets the ORDER BY clause.
+func (q *SelectQuery) OrderBy(clause string) *SelectQuery {
+	q.orderBy = clause
+	return q
+}
+
+// Limit sets LIMIT, passed as a parameter.
+func (q *SelectQuery) Limit(n int) *SelectQuery {
+	q.limit = &n
+	return q
+}
+
+// Offset sets OFFSET, passed as a parameter.
+func (q *SelectQuery) Offset(n int) *SelectQuery {
+	q.offset = &n
+	return q
+}
+
+// SQL returns the query and its arguments, ready for db.Query.
+func (q *SelectQuery) SQL() (string, []interface{}) {
+	var b strings.Builder
+	args := append([]interface{}(nil), q.args...)
+	b.WriteString("SELECT " + q.columns + " FROM " + q.from)
+	q.writeWhere(&b)
+	if q.orderBy != "" {
+		b.WriteString(" ORDER BY " + q.orderBy)
+	}
+	if q.limit != nil {
+		args = append(args, *q.limit)
+		b.WriteString(" LIMIT $" + strconv.Itoa(len(args)))
+	}
+	if q.offset != nil {
+		args = append(args, *q.offset)
+		b.WriteString(" OFFSET $" + strconv.Itoa(len(args)))
+	}
+	return b.String(), args
+}
+
+// CountSQL returns a query counting every row the filters match, ignoring
+// the order, limit and offset.
+func (q *SelectQuery) CountSQL() (string, []interface{}) {
+	var b strings.Builder
+	b.WriteString("SELECT COUNT(*) FROM " + q.from)
+	q.writeWhere(&b)
+	return b.String(), append([]interface{}(nil), q.args...)
+}
+
+func (q *SelectQuery) writeWhere(b *strings.Builder) {
+	if len(q.where) == 0 {
+		return
+	}
+	b.WriteString(" WHERE ")
+	n := 0
+	for i, cond := range q.where {
+		if i > 0 {
+			b.WriteString(" AND ")
+		}
+		b.WriteByte('(')
+		for _, r := range cond {
+			if r == '?' {
+				n++
+				b.WriteString("$" + strconv.Itoa(n))
+				continue
+			}
+			b.WriteRune(r)
+		}
+		b.WriteByte(')')
+	}
+}
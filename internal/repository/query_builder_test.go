@@ -0,0 +1,46 @@
+package repository_test
+
+import (
+	"fmt"
+	"testing"
+
+	"buildprize-game/internal/repository"
+)
+
+// Filter values are always bound as numbered parameters, never spliced
+// into the SQL, however many conditions a query picks up.
+func TestSelectQueryParameterizesFilters(t *testing.T) {
+	hostile := "waiting'; DROP TABLE lobbies; --"
+	q := repository.NewSelect("l.id", "lobbies l").
+		Where("NOT l.sandbox").
+		Where("l.state = ?", hostile).
+		Where("l.round BETWEEN ? AND ?", 1, 5).
+		OrderBy("l.created_at DESC").
+		Limit(10).
+		Offset(20)
+
+	sql, args := q.SQL()
+	want := "SELECT l.id FROM lobbies l WHERE (NOT l.sandbox) AND (l.state = $1) AND (l.round BETWEEN $2 AND $3) ORDER BY l.created_at DESC LIMIT $4 OFFSET $5"
+	if sql != want {
+		t.Fatalf("Unexpected SQL:\n got %s\nwant %s", sql, want)
+	}
+	if fmt.Sprint(args) != fmt.Sprint([]interface{}{hostile, 1, 5, 10, 20}) {
+		t.Fatalf("Unexpected args: %v", args)
+	}
+
+	count, countArgs := q.CountSQL()
+	if count != "SELECT COUNT(*) FROM lobbies l WHERE (NOT l.sandbox) AND (l.state = $1) AND (l.round BETWEEN $2 AND $3)" || len(countArgs) != 3 {
+		t.Fatalf("Unexpected count query %s with %v", count, countArgs)
+	}
+
+	if sql, args := repository.NewSelect("id", "lobbies").SQL(); sql != "SELECT id FROM lobbies" || len(args) != 0 {
+		t.Fatalf("Expected an unfiltered query, got %s with %v", sql, args)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Expected a placeholder and argument count mismatch to panic")
+		}
+	}()
+	repository.NewSelect("id", "lobbies").Where("state = ?")
+}
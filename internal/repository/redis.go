@@ -0,0 +1,1463 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"buildprize-game/internal/encryption"
+	"buildprize-game/internal/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisTimeout = 2 * time.Second
+
+// RedisRepository keeps game state in Redis for deployments that don't need
+// Postgres. Each lobby is a hash plus a set of its players; lobbies, answers,
+// pending notifications and chat expire ttl after their last write. Scoring
+// configs, the scoring and admin audit logs, player reports, prize results, accounts,
+// player stats, friendships, recurring events, category mastery and question
+// performance are kept until deleted, and game events for the retention
+// period, past their lobby.
+//
+// Key layout:
+//
+//	lobby:<id>                       hash: lobby (JSON, without players), state, sandbox, finished_at
+//	lobby:<id>:players               set of player JSON
+//	lobby:<id>:answers               list of answer IDs, in the order recorded
+//	lobby:<id>:notifications         set of notifications:<lobby>:<player> keys
+//	lobby:<id>:chat                  list of chat message JSON, oldest first
+//	lobbies                          sorted set of lobby IDs by creation time
+//	answer:<id>                      answer record JSON
+//	answer-player:<player id>        username the player answered under
+//	mastery:<username>               hash: <category>:answered, <category>:correct
+//	questions                        set of IDs of questions answered
+//	question:<id>                    hash: answered, correct
+//	question:<id>:times              list of response times in ms, newest first
+//	notifications:<lobby>:<player>   list of notification JSON
+//	scoring:configs                  hash: version -> config JSON
+//	scoring:active                   active scoring version
+//	scoring:audit                    list of audit entry JSON, newest first
+//	admin:audit                      list of admin action JSON, newest first
+//	reports                          list of player report JSON, newest first
+//	prize-results                    hash: lobby ID -> prize result JSON
+//	users                            hash: user ID -> user JSON
+//	usernames                        hash: lowercased username -> user ID
+//	player-stats:<user id>           hash: games_played, wins, answered, correct, response_ms, updated_at
+//	player-streaks                   sorted set of user IDs by best streak
+//	friends:<user id>                hash: other user ID -> friendship JSON, under both accounts
+//	recurring-events                 hash: event ID -> recurring event JSON
+//	game-events:<lobby id>           list of broadcast event JSON, in order
+//	game-events                      sorted set of lobby IDs by their last event's time
+type RedisRepository struct {
+	client *redis.Client
+	ttl    time.Duration
+	enc    *encryption.Envelope // nil stores notification data and admin addresses in plaintext
+}
+
+// NewRedisRepository connects to the Redis server at url, e.g.
+// "redis://:password@localhost:6379/1", and seeds the built-in scoring
+// version.
+func NewRedisRepository(url string, ttl time.Duration) (*RedisRepository, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis URL: %w", err)
+	}
+	r := &RedisRepository{client: redis.NewClient(opts), ttl: ttl}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := r.client.Ping(ctx).Err(); err != nil {
+		r.client.Close()
+		return nil, fmt.Errorf("failed to ping redis: %w", err)
+	}
+	if err := r.seedScoringConfig(ctx); err != nil {
+		r.client.Close()
+		return nil, fmt.Errorf("failed to seed scoring config: %w", err)
+	}
+	return r, nil
+}
+
+func lobbyKey(lobbyID string) string         { return "lobby:" + lobbyID }
+func playersKey(lobbyID string) string       { return "lobby:" + lobbyID + ":players" }
+func lobbyAnswersKey(lobbyID string) string  { return "lobby:" + lobbyID + ":answers" }
+func lobbyNotifiedKey(lobbyID string) string { return "lobby:" + lobbyID + ":notifications" }
+func lobbyChatKey(lobbyID string) string     { return "lobby:" + lobbyID + ":chat" }
+func answerKey(id int64) string              { return "answer:" + strconv.FormatInt(id, 10) }
+func answerPlayerKey(playerID string) string { return "answer-player:" + playerID }
+func masteryKey(username string) string      { return "mastery:" + username }
+func playerStatsKey(userID string) string    { return "player-stats:" + userID }
+func friendsKey(userID string) string        { return "friends:" + userID }
+func gameEventsKey(lobbyID string) string    { return "game-events:" + lobbyID }
+func questionKey(questionID string) string   { return "question:" + questionID }
+func questionTimesKey(questionID string) string {
+	return "question:" + questionID + ":times"
+}
+func notificationsKey(lobbyID, playerID string) string {
+	return "notifications:" + lobbyID + ":" + playerID
+}
+
+const (
+	lobbiesKey         = "lobbies"
+	answerIDKey        = "answer:next-id"
+	notificationIDKey  = "notification:next-id"
+	chatIDKey          = "chat:next-id"
+	scoringConfigsKey  = "scoring:configs"
+	activeScoringKey   = "scoring:active"
+	scoringAuditKey    = "scoring:audit"
+	scoringAuditIDKey  = "scoring:audit:next-id"
+	scoringAuditMaxLen = 10000
+	adminAuditKey      = "admin:audit"
+	adminAuditIDKey    = "admin:audit:next-id"
+	adminAuditMaxLen   = 10000
+	reportsKey         = "reports"
+	reportIDKey        = "reports:next-id"
+	reportsMaxLen      = 10000
+	questionsKey       = "questions"
+	prizeResultsKey    = "prize-results"
+	entryRefundsKey    = "entry-refunds"
+	usersKey           = "users"
+	usernamesKey       = "usernames"
+	playerStreaksKey   = "player-streaks"
+	accountStreaksKey  = "account-streaks"
+	eventsKey          = "recurring-events"
+	apiKeysKey         = "api-keys"
+	gameEventLobbies   = "game-events"
+	gameArchivesKey    = "game-archives"
+	questionTimesLen   = 1000 // medians are over a question's most recent answers
+)
+
+func (r *RedisRepository) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), redisTimeout)
+}
+
+// Ping checks that Redis is reachable.
+func (r *RedisRepository) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+func (r *RedisRepository) SaveLobby(lobby *models.Lobby) error {
+	data, err := json.Marshal(lobby)
+	if err != nil {
+		return err
+	}
+	// Players live in their own set
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	delete(fields, "players")
+	if data, err = json.Marshal(fields); err != nil {
+		return err
+	}
+
+	players := make([]interface{}, 0, len(lobby.Players))
+	for _, player := range lobby.Players {
+		encoded, err := json.Marshal(player)
+		if err != nil {
+			return err
+		}
+		players = append(players, encoded)
+	}
+
+	var finishedAt int64
+	if lobby.FinishedAt != nil {
+		finishedAt = lobby.FinishedAt.UnixMilli()
+	}
+
+	ctx, cancel := r.context()
+	defer cancel()
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, lobbyKey(lobby.ID),
+			"lobby", data,
+			"state", string(lobby.State),
+			"sandbox", strconv.FormatBool(lobby.Sandbox),
+			"finished_at", finishedAt,
+		)
+		pipe.Expire(ctx, lobbyKey(lobby.ID), r.ttl)
+		pipe.Del(ctx, playersKey(lobby.ID))
+		if len(players) > 0 {
+			pipe.SAdd(ctx, playersKey(lobby.ID), players...)
+			pipe.Expire(ctx, playersKey(lobby.ID), r.ttl)
+		}
+		pipe.ZAdd(ctx, lobbiesKey, redis.Z{Score: float64(lobby.CreatedAt.UnixMilli()), Member: lobby.ID})
+		return nil
+	})
+	return err
+}
+
+func (r *RedisRepository) GetLobby(lobbyID string) (*models.Lobby, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+
+	data, err := r.client.HGet(ctx, lobbyKey(lobbyID), "lobby").Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrLobbyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	members, err := r.client.SMembers(ctx, playersKey(lobbyID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	return decodeLobby(data, members)
+}
+
+func decodeLobby(data []byte, players []string) (*models.Lobby, error) {
+	var lobby models.Lobby
+	if err := json.Unmarshal(data, &lobby); err != nil {
+		return nil, err
+	}
+	for _, encoded := range players {
+		var player models.Player
+		if err := json.Unmarshal([]byte(encoded), &player); err != nil {
+			return nil, err
+		}
+		lobby.Players = append(lobby.Players, &player)
+	}
+	// Same order as the Postgres repository
+	sort.Slice(lobby.Players, func(i, j int) bool {
+		if lobby.Players[i].Score != lobby.Players[j].Score {
+			return lobby.Players[i].Score > lobby.Players[j].Score
+		}
+		return lobby.Players[i].Username < lobby.Players[j].Username
+	})
+	if lobby.Phase == "" {
+		lobby.Phase = models.PhaseForState(lobby.State)
+	}
+	return &lobby, nil
+}
+
+func (r *RedisRepository) DeleteLobby(lobbyID string) error {
+	ctx, cancel := r.context()
+	defer cancel()
+
+	notified, err := r.client.SMembers(ctx, lobbyNotifiedKey(lobbyID)).Result()
+	if err != nil {
+		return err
+	}
+	keys := append([]string{lobbyKey(lobbyID), playersKey(lobbyID), lobbyNotifiedKey(lobbyID), lobbyChatKey(lobbyID)}, notified...)
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, keys...)
+		pipe.ZRem(ctx, lobbiesKey, lobbyID)
+		return nil
+	})
+	return err
+}
+
+// ListLobbies returns the page of non-sandbox lobbies the query selects.
+// Every lobby in the state asked for is loaded to filter and sort them.
+// Lobbies that have expired are dropped from the index as they're found.
+func (r *RedisRepository) ListLobbies(query LobbyQuery) (*LobbyPage, error) {
+	query, err := query.Normalize()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := r.context()
+	defer cancel()
+
+	ids, err := r.client.ZRevRange(ctx, lobbiesKey, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	lobbies := make([]*models.Lobby, 0)
+	for _, id := range ids {
+		fields, err := r.client.HMGet(ctx, lobbyKey(id), "lobby", "state", "sandbox").Result()
+		if err != nil {
+			return nil, err
+		}
+		data, ok := fields[0].(string)
+		if !ok {
+			r.client.ZRem(ctx, lobbiesKey, id)
+			continue
+		}
+		if (query.State != "" && fields[1] != string(query.State)) || fields[2] == "true" {
+			continue
+		}
+		members, err := r.client.SMembers(ctx, playersKey(id)).Result()
+		if err != nil {
+			return nil, err
+		}
+		lobby, err := decodeLobby([]byte(data), members)
+		if err != nil {
+			return nil, err
+		}
+		lobbies = append(lobbies, lobby)
+	}
+	return pageLobbies(lobbies, query), nil
+}
+
+// DeleteFinishedGamesOlderThan deletes finished games that finished more than
+// the specified duration ago.
+func (r *RedisRepository) DeleteFinishedGamesOlderThan(duration time.Duration) (int, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+
+	ids, err := r.client.ZRange(ctx, lobbiesKey, 0, -1).Result()
+	if err != nil {
+		return 0, err
+	}
+	cutoff := time.Now().Add(-duration).UnixMilli()
+	deleted := 0
+	for _, id := range ids {
+		fields, err := r.client.HMGet(ctx, lobbyKey(id), "state", "finished_at").Result()
+		if err != nil {
+			return deleted, err
+		}
+		if fields[0] == nil {
+			r.client.ZRem(ctx, lobbiesKey, id)
+			continue
+		}
+		finishedAt, _ := strconv.ParseInt(fmt.Sprint(fields[1]), 10, 64)
+		if fields[0] != string(models.Finished) || finishedAt == 0 || finishedAt >= cutoff {
+			continue
+		}
+		if err := r.DeleteLobby(id); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+func (r *RedisRepository) SaveAnswer(record *models.AnswerRecord) error {
+	ctx, cancel := r.context()
+	defer cancel()
+
+	id, err := r.client.Incr(ctx, answerIDKey).Result()
+	if err != nil {
+		return err
+	}
+	record.ID = id
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	mastery := masteryKey(record.Username)
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, answerKey(id), data, r.ttl)
+		pipe.RPush(ctx, lobbyAnswersKey(record.LobbyID), id)
+		pipe.Expire(ctx, lobbyAnswersKey(record.LobbyID), r.ttl)
+		pipe.Set(ctx, answerPlayerKey(record.PlayerID), record.Username, r.ttl)
+		pipe.HIncrBy(ctx, mastery, record.Category+":answered", 1)
+		if record.Correct {
+			pipe.HIncrBy(ctx, mastery, record.Category+":correct", 1)
+		}
+		pipe.SAdd(ctx, questionsKey, record.QuestionID)
+		pipe.HIncrBy(ctx, questionKey(record.QuestionID), "answered", 1)
+		if record.Correct {
+			pipe.HIncrBy(ctx, questionKey(record.QuestionID), "correct", 1)
+		}
+		pipe.LPush(ctx, questionTimesKey(record.QuestionID), record.ResponseTime)
+		pipe.LTrim(ctx, questionTimesKey(record.QuestionID), 0, questionTimesLen-1)
+		return nil
+	})
+	return err
+}
+
+// GetLobbyAnswers returns every answer recorded in a lobby, oldest first.
+func (r *RedisRepository) GetLobbyAnswers(lobbyID string) ([]*models.AnswerRecord, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+
+	ids, err := r.client.LRange(ctx, lobbyAnswersKey(lobbyID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	answers := make([]*models.AnswerRecord, 0, len(ids))
+	if len(ids) == 0 {
+		return answers, nil
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = "answer:" + id
+	}
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue // expired
+		}
+		var record models.AnswerRecord
+		if err := json.Unmarshal([]byte(data), &record); err != nil {
+			return nil, err
+		}
+		answers = append(answers, &record)
+	}
+	sort.SliceStable(answers, func(i, j int) bool {
+		a, b := answers[i], answers[j]
+		if a.Round != b.Round {
+			return a.Round < b.Round
+		}
+		if !a.AnsweredAt.Equal(b.AnsweredAt) {
+			return a.AnsweredAt.Before(b.AnsweredAt)
+		}
+		return a.ID < b.ID
+	})
+	return answers, nil
+}
+
+func (r *RedisRepository) UpdateAnswerScore(answerID int64, score int) error {
+	return r.updateAnswer(answerID, func(record *models.AnswerRecord) { record.Score = score })
+}
+
+func (r *RedisRepository) UpdateAnswerWager(answerID int64, delta int) error {
+	return r.updateAnswer(answerID, func(record *models.AnswerRecord) { record.WagerDelta = delta })
+}
+
+// updateAnswer rewrites a recorded answer in place, keeping its expiry.
+func (r *RedisRepository) updateAnswer(answerID int64, update func(*models.AnswerRecord)) error {
+	ctx, cancel := r.context()
+	defer cancel()
+
+	data, err := r.client.Get(ctx, answerKey(answerID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil // like an UPDATE matching no rows
+	}
+	if err != nil {
+		return err
+	}
+	var record models.AnswerRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return err
+	}
+	update(&record)
+	if data, err = json.Marshal(&record); err != nil {
+		return err
+	}
+	return r.client.SetArgs(ctx, answerKey(answerID), data, redis.SetArgs{KeepTTL: true}).Err()
+}
+
+// GetQuestionPerformance aggregates answer history per question, in
+// question ID order. Medians are over each question's most recent 1000
+// answers.
+func (r *RedisRepository) GetQuestionPerformance() ([]*models.QuestionPerformance, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+
+	ids, err := r.client.SMembers(ctx, questionsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(ids)
+
+	counts := make([]*redis.MapStringStringCmd, len(ids))
+	times := make([]*redis.StringSliceCmd, len(ids))
+	_, err = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range ids {
+			counts[i] = pipe.HGetAll(ctx, questionKey(id))
+			times[i] = pipe.LRange(ctx, questionTimesKey(id), 0, -1)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	performance := make([]*models.QuestionPerformance, 0, len(ids))
+	for i, id := range ids {
+		p := &models.QuestionPerformance{QuestionID: id}
+		p.Answered, _ = strconv.Atoi(counts[i].Val()["answered"])
+		p.Correct, _ = strconv.Atoi(counts[i].Val()["correct"])
+		if p.Answered == 0 {
+			continue
+		}
+		p.Accuracy = float64(p.Correct) / float64(p.Answered)
+		ms := make([]int64, 0, len(times[i].Val()))
+		for _, raw := range times[i].Val() {
+			if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+				ms = append(ms, n)
+			}
+		}
+		p.MedianResponseMs = medianMs(ms)
+		performance = append(performance, p)
+	}
+	return performance, nil
+}
+
+// GetCategoryMastery aggregates answer history per category under the
+// username the player ID answered with, as the Postgres repository does.
+func (r *RedisRepository) GetCategoryMastery(playerID string) ([]*models.CategoryMastery, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+
+	mastery := make([]*models.CategoryMastery, 0)
+	username, err := r.client.Get(ctx, answerPlayerKey(playerID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return mastery, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	counts, err := r.client.HGetAll(ctx, masteryKey(username)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	byCategory := make(map[string]*models.CategoryMastery)
+	for field, value := range counts {
+		n, _ := strconv.Atoi(value)
+		for _, suffix := range []string{":answered", ":correct"} {
+			if len(field) <= len(suffix) || field[len(field)-len(suffix):] != suffix {
+				continue
+			}
+			category := field[:len(field)-len(suffix)]
+			m := byCategory[category]
+			if m == nil {
+				m = &models.CategoryMastery{Category: category}
+				byCategory[category] = m
+				mastery = append(mastery, m)
+			}
+			if suffix == ":answered" {
+				m.Answered = n
+			} else {
+				m.Correct = n
+			}
+		}
+	}
+	for _, m := range mastery {
+		if m.Answered > 0 {
+			m.Accuracy = float64(m.Correct) / float64(m.Answered)
+		}
+	}
+	sort.Slice(mastery, func(i, j int) bool { return mastery[i].Category < mastery[j].Category })
+	return mastery, nil
+}
+
+func (r *RedisRepository) SavePendingNotification(n *models.PendingNotification) error {
+	ctx, cancel := r.context()
+	defer cancel()
+
+	id, err := r.client.Incr(ctx, notificationIDKey).Result()
+	if err != nil {
+		return err
+	}
+	n.ID = id
+	stored := *n
+	if stored.Data, err = sealJSON(r.enc, n.Data, pendingNotificationsData); err != nil {
+		return fmt.Errorf("failed to encrypt notification: %w", err)
+	}
+	data, err := json.Marshal(&stored)
+	if err != nil {
+		return err
+	}
+
+	key := notificationsKey(n.LobbyID, n.PlayerID)
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, key, data)
+		pipe.Expire(ctx, key, r.ttl)
+		pipe.SAdd(ctx, lobbyNotifiedKey(n.LobbyID), key)
+		pipe.Expire(ctx, lobbyNotifiedKey(n.LobbyID), r.ttl)
+		return nil
+	})
+	return err
+}
+
+// TakePendingNotifications removes and returns the player's pending
+// notifications, oldest first.
+func (r *RedisRepository) TakePendingNotifications(lobbyID, playerID string) ([]*models.PendingNotification, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+
+	key := notificationsKey(lobbyID, playerID)
+	var values *redis.StringSliceCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		values = pipe.LRange(ctx, key, 0, -1)
+		pipe.Del(ctx, key)
+		pipe.SRem(ctx, lobbyNotifiedKey(lobbyID), key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	notifications := make([]*models.PendingNotification, 0, len(values.Val()))
+	for _, value := range values.Val() {
+		var n models.PendingNotification
+		if err := json.Unmarshal([]byte(value), &n); err != nil {
+			return nil, err
+		}
+		if n.Data, err = openJSON(r.enc, n.Data, pendingNotificationsData); err != nil {
+			return nil, fmt.Errorf("failed to decrypt notification %d: %w", n.ID, err)
+		}
+		notifications = append(notifications, &n)
+	}
+	return notifications, nil
+}
+
+func (r *RedisRepository) SaveChatMessage(msg *models.ChatMessage) error {
+	ctx, cancel := r.context()
+	defer cancel()
+
+	id, err := r.client.Incr(ctx, chatIDKey).Result()
+	if err != nil {
+		return err
+	}
+	msg.ID = id
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	key := lobbyChatKey(msg.LobbyID)
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, key, data)
+		pipe.Expire(ctx, key, r.ttl)
+		return nil
+	})
+	return err
+}
+
+// ListChatMessages returns the lobby's last limit messages sent after since,
+// oldest first.
+func (r *RedisRepository) ListChatMessages(lobbyID string, since time.Time, limit int) ([]*models.ChatMessage, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+
+	values, err := r.client.LRange(ctx, lobbyChatKey(lobbyID), int64(-limit), -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]*models.ChatMessage, 0, len(values))
+	for _, value := range values {
+		var msg models.ChatMessage
+		if err := json.Unmarshal([]byte(value), &msg); err != nil {
+			return nil, err
+		}
+		messages = append(messages, &msg)
+	}
+	return recentChat(messages, since, limit), nil
+}
+
+// DeleteChatMessagesOlderThan trims messages sent before the retention
+// period from the chat of every indexed lobby.
+func (r *RedisRepository) DeleteChatMessagesOlderThan(duration time.Duration) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	cutoff := time.Now().Add(-duration)
+	lobbyIDs, err := r.client.ZRange(ctx, lobbiesKey, 0, -1).Result()
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for _, lobbyID := range lobbyIDs {
+		key := lobbyChatKey(lobbyID)
+		values, err := r.client.LRange(ctx, key, 0, -1).Result()
+		if err != nil {
+			return deleted, err
+		}
+		old := 0
+		for _, value := range values {
+			var msg models.ChatMessage
+			if err := json.Unmarshal([]byte(value), &msg); err != nil || !msg.SentAt.Before(cutoff) {
+				break
+			}
+			old++
+		}
+		if old == 0 {
+			continue
+		}
+		if err := r.client.LTrim(ctx, key, int64(old), -1).Err(); err != nil {
+			return deleted, err
+		}
+		deleted += old
+	}
+	return deleted, nil
+}
+
+func (r *RedisRepository) SaveGameEvents(events []*models.GameEvent) error {
+	ctx, cancel := r.context()
+	defer cancel()
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, event := range events {
+			data, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			pipe.RPush(ctx, gameEventsKey(event.LobbyID), data)
+			pipe.ZAdd(ctx, gameEventLobbies, redis.Z{Score: float64(event.Timestamp.Unix()), Member: event.LobbyID})
+		}
+		return nil
+	})
+	return err
+}
+
+func (r *RedisRepository) ListGameEvents(lobbyID string, afterSeq uint64, limit int) ([]*models.GameEvent, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+	values, err := r.client.LRange(ctx, gameEventsKey(lobbyID), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	events := make([]*models.GameEvent, 0)
+	for _, value := range values {
+		if len(events) == limit {
+			break
+		}
+		var event models.GameEvent
+		if err := json.Unmarshal([]byte(value), &event); err != nil {
+			return nil, err
+		}
+		if event.Seq > afterSeq {
+			events = append(events, &event)
+		}
+	}
+	return events, nil
+}
+
+// ListGameEventLobbies reads the lobbies' last event times, to the second,
+// from the index of logs.
+func (r *RedisRepository) ListGameEventLobbies(before time.Time, limit int) ([]string, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+	return r.client.ZRangeByScore(ctx, gameEventLobbies, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   "(" + strconv.FormatInt(before.Unix(), 10),
+		Count: int64(limit),
+	}).Result()
+}
+
+func (r *RedisRepository) DeleteGameEvents(lobbyID string) error {
+	ctx, cancel := r.context()
+	defer cancel()
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, gameEventsKey(lobbyID))
+		pipe.ZRem(ctx, gameEventLobbies, lobbyID)
+		return nil
+	})
+	return err
+}
+
+func (r *RedisRepository) SaveGameArchive(archive *models.GameArchive) error {
+	data, err := json.Marshal(archive)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := r.context()
+	defer cancel()
+	return r.client.HSet(ctx, gameArchivesKey, archive.LobbyID, data).Err()
+}
+
+func (r *RedisRepository) GetGameArchive(lobbyID string) (*models.GameArchive, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+	data, err := r.client.HGet(ctx, gameArchivesKey, lobbyID).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrGameArchiveNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var archive models.GameArchive
+	if err := json.Unmarshal(data, &archive); err != nil {
+		return nil, err
+	}
+	return &archive, nil
+}
+
+// DeleteGameEventsOlderThan drops the logs of lobbies whose last event is
+// older than the retention period and trims old events from the rest.
+func (r *RedisRepository) DeleteGameEventsOlderThan(duration time.Duration) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	cutoff := time.Now().Add(-duration)
+	lobbyIDs, err := r.client.ZRange(ctx, gameEventLobbies, 0, -1).Result()
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for _, lobbyID := range lobbyIDs {
+		key := gameEventsKey(lobbyID)
+		values, err := r.client.LRange(ctx, key, 0, -1).Result()
+		if err != nil {
+			return deleted, err
+		}
+		old := 0
+		for _, value := range values {
+			var event models.GameEvent
+			if err := json.Unmarshal([]byte(value), &event); err != nil || !event.Timestamp.Before(cutoff) {
+				break
+			}
+			old++
+		}
+		switch {
+		case old == 0:
+			continue
+		case old == len(values):
+			_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Del(ctx, key)
+				pipe.ZRem(ctx, gameEventLobbies, lobbyID)
+				return nil
+			})
+		default:
+			err = r.client.LTrim(ctx, key, int64(old), -1).Err()
+		}
+		if err != nil {
+			return deleted, err
+		}
+		deleted += old
+	}
+	return deleted, nil
+}
+
+// seedScoringConfig stores the built-in version and activates it when no
+// version is active yet.
+func (r *RedisRepository) seedScoringConfig(ctx context.Context) error {
+	config := models.DefaultScoringConfig
+	config.CreatedBy = "system"
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if err := r.client.HSetNX(ctx, scoringConfigsKey, config.Version, data).Err(); err != nil {
+		return err
+	}
+	return r.client.SetNX(ctx, activeScoringKey, config.Version, 0).Err()
+}
+
+func (r *RedisRepository) CreateScoringConfig(config *models.ScoringConfig) error {
+	config.CreatedAt = models.Now()
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := r.context()
+	defer cancel()
+	created, err := r.client.HSetNX(ctx, scoringConfigsKey, config.Version, data).Result()
+	if err != nil {
+		return err
+	}
+	if !created {
+		return ErrScoringVersionExists
+	}
+	return nil
+}
+
+func (r *RedisRepository) GetScoringConfig(version string) (*models.ScoringConfig, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+	data, err := r.client.HGet(ctx, scoringConfigsKey, version).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrScoringConfigNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var config models.ScoringConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// ListScoringConfigs returns every stored version, oldest first.
+func (r *RedisRepository) ListScoringConfigs() ([]*models.ScoringConfig, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+	values, err := r.client.HVals(ctx, scoringConfigsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	configs := make([]*models.ScoringConfig, 0, len(values))
+	for _, value := range values {
+		var config models.ScoringConfig
+		if err := json.Unmarshal([]byte(value), &config); err != nil {
+			return nil, err
+		}
+		configs = append(configs, &config)
+	}
+	sort.Slice(configs, func(i, j int) bool {
+		if !configs[i].CreatedAt.Equal(configs[j].CreatedAt) {
+			return configs[i].CreatedAt.Before(configs[j].CreatedAt)
+		}
+		return configs[i].Version < configs[j].Version
+	})
+	return configs, nil
+}
+
+func (r *RedisRepository) GetActiveScoringVersion() (string, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+	version, err := r.client.Get(ctx, activeScoringKey).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrScoringConfigNotFound
+	}
+	return version, err
+}
+
+func (r *RedisRepository) SetActiveScoringVersion(version string) error {
+	ctx, cancel := r.context()
+	defer cancel()
+	exists, err := r.client.HExists(ctx, scoringConfigsKey, version).Result()
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrScoringConfigNotFound
+	}
+	return r.client.Set(ctx, activeScoringKey, version, 0).Err()
+}
+
+func (r *RedisRepository) SaveScoringAudit(entry *models.ScoringAuditEntry) error {
+	ctx, cancel := r.context()
+	defer cancel()
+	id, err := r.client.Incr(ctx, scoringAuditIDKey).Result()
+	if err != nil {
+		return err
+	}
+	entry.ID = id
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, scoringAuditKey, data)
+		pipe.LTrim(ctx, scoringAuditKey, 0, scoringAuditMaxLen-1)
+		return nil
+	})
+	return err
+}
+
+// ListScoringAudit returns the most recent audit entries, newest first.
+func (r *RedisRepository) ListScoringAudit(limit int) ([]*models.ScoringAuditEntry, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+	values, err := r.client.LRange(ctx, scoringAuditKey, 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*models.ScoringAuditEntry, 0, len(values))
+	for _, value := range values {
+		var entry models.ScoringAuditEntry
+		if err := json.Unmarshal([]byte(value), &entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, nil
+}
+
+func (r *RedisRepository) SaveAdminAction(action *models.AdminAction) error {
+	ctx, cancel := r.context()
+	defer cancel()
+	id, err := r.client.Incr(ctx, adminAuditIDKey).Result()
+	if err != nil {
+		return err
+	}
+	action.ID = id
+	stored := *action
+	if stored.RemoteAddr, err = sealText(r.enc, action.RemoteAddr, adminAuditRemoteAddr); err != nil {
+		return err
+	}
+	data, err := json.Marshal(&stored)
+	if err != nil {
+		return err
+	}
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, adminAuditKey, data)
+		pipe.LTrim(ctx, adminAuditKey, 0, adminAuditMaxLen-1)
+		return nil
+	})
+	return err
+}
+
+// ListAdminActions returns the most recent entries the filter selects,
+// newest first. Entries aren't indexed, so a filter reads the full list.
+func (r *RedisRepository) ListAdminActions(filter models.AdminActionFilter, limit int) ([]*models.AdminAction, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+	stop := int64(limit) - 1
+	if filter != (models.AdminActionFilter{}) {
+		stop = -1
+	}
+	values, err := r.client.LRange(ctx, adminAuditKey, 0, stop).Result()
+	if err != nil {
+		return nil, err
+	}
+	actions := make([]*models.AdminAction, 0)
+	for _, value := range values {
+		var action models.AdminAction
+		if err := json.Unmarshal([]byte(value), &action); err != nil {
+			return nil, err
+		}
+		if !filter.Matches(&action) {
+			continue
+		}
+		if action.RemoteAddr, err = openText(r.enc, action.RemoteAddr, adminAuditRemoteAddr); err != nil {
+			return nil, fmt.Errorf("failed to decrypt admin action %d: %w", action.ID, err)
+		}
+		actions = append(actions, &action)
+		if len(actions) == limit {
+			break
+		}
+	}
+	return actions, nil
+}
+
+func (r *RedisRepository) SavePlayerReport(report *models.PlayerReport) error {
+	ctx, cancel := r.context()
+	defer cancel()
+	id, err := r.client.Incr(ctx, reportIDKey).Result()
+	if err != nil {
+		return err
+	}
+	report.ID = id
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, reportsKey, data)
+		pipe.LTrim(ctx, reportsKey, 0, reportsMaxLen-1)
+		return nil
+	})
+	return err
+}
+
+// ListPlayerReports returns the most recent reports, newest first, for one
+// lobby or, with an empty lobbyID, all of them. Reports aren't indexed by
+// lobby, so a lobby's are filtered from the full list.
+func (r *RedisRepository) ListPlayerReports(lobbyID string, limit int) ([]*models.PlayerReport, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+	stop := int64(limit) - 1
+	if lobbyID != "" {
+		stop = -1
+	}
+	values, err := r.client.LRange(ctx, reportsKey, 0, stop).Result()
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]*models.PlayerReport, 0)
+	for _, value := range values {
+		var report models.PlayerReport
+		if err := json.Unmarshal([]byte(value), &report); err != nil {
+			return nil, err
+		}
+		if lobbyID != "" && report.LobbyID != lobbyID {
+			continue
+		}
+		reports = append(reports, &report)
+		if len(reports) == limit {
+			break
+		}
+	}
+	return reports, nil
+}
+
+func (r *RedisRepository) Close() error {
+	return r.client.Close()
+}
+
+func (r *RedisRepository) SavePrizeResult(result *models.PrizeResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := r.context()
+	defer cancel()
+	return r.client.HSet(ctx, prizeResultsKey, result.LobbyID, data).Err()
+}
+
+func (r *RedisRepository) GetPrizeResult(lobbyID string) (*models.PrizeResult, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+	data, err := r.client.HGet(ctx, prizeResultsKey, lobbyID).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrPrizeResultNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var result models.PrizeResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListPrizeResults reads every prize result; there's one per prize game, so
+// the hash stays small enough to filter here.
+func (r *RedisRepository) ListPrizeResults(status models.ResultsStatus) ([]*models.PrizeResult, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+	values, err := r.client.HVals(ctx, prizeResultsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*models.PrizeResult, 0)
+	for _, value := range values {
+		var result models.PrizeResult
+		if err := json.Unmarshal([]byte(value), &result); err != nil {
+			return nil, err
+		}
+		if status == "" || result.Status == status {
+			results = append(results, &result)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].EndedAt.After(results[j].EndedAt) })
+	return results, nil
+}
+
+func (r *RedisRepository) SaveEntryRefund(refund *models.EntryRefund) error {
+	data, err := json.Marshal(refund)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := r.context()
+	defer cancel()
+	return r.client.HSet(ctx, entryRefundsKey, refund.LobbyID+"/"+refund.PaymentID, data).Err()
+}
+
+// ListEntryRefunds reads every refund; they're only owed for lobbies closed
+// with entry fees paid, so the hash stays small enough to filter here.
+func (r *RedisRepository) ListEntryRefunds(status models.RefundStatus) ([]*models.EntryRefund, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+	values, err := r.client.HVals(ctx, entryRefundsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	refunds := make([]*models.EntryRefund, 0)
+	for _, value := range values {
+		var refund models.EntryRefund
+		if err := json.Unmarshal([]byte(value), &refund); err != nil {
+			return nil, err
+		}
+		if status == "" || refund.Status == status {
+			refunds = append(refunds, &refund)
+		}
+	}
+	sort.Slice(refunds, func(i, j int) bool { return refunds[i].CreatedAt.Before(refunds[j].CreatedAt) })
+	return refunds, nil
+}
+
+// CreateUser claims the username first, so two registrations racing for it
+// can't both succeed.
+func (r *RedisRepository) CreateUser(user *models.User) error {
+	data, err := json.Marshal(user)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := r.context()
+	defer cancel()
+	claimed, err := r.client.HSetNX(ctx, usernamesKey, strings.ToLower(user.Username), user.ID).Result()
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return ErrUsernameTaken
+	}
+	return r.client.HSet(ctx, usersKey, user.ID, data).Err()
+}
+
+func (r *RedisRepository) GetUser(userID string) (*models.User, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+	data, err := r.client.HGet(ctx, usersKey, userID).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var user models.User
+	if err := json.Unmarshal(data, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *RedisRepository) GetUserByUsername(username string) (*models.User, error) {
+	ctx, cancel := r.context()
+	userID, err := r.client.HGet(ctx, usernamesKey, strings.ToLower(username)).Result()
+	cancel()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.GetUser(userID)
+}
+
+// AddPlayerStats adds to the account's counters; the best streak is kept in
+// a sorted set so it's only ever raised.
+func (r *RedisRepository) AddPlayerStats(game *models.PlayerStats) error {
+	ctx, cancel := r.context()
+	defer cancel()
+	key := playerStatsKey(game.UserID)
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HIncrBy(ctx, key, "games_played", int64(game.GamesPlayed))
+		pipe.HIncrBy(ctx, key, "wins", int64(game.Wins))
+		pipe.HIncrBy(ctx, key, "answered", int64(game.Answered))
+		pipe.HIncrBy(ctx, key, "correct", int64(game.Correct))
+		pipe.HIncrBy(ctx, key, "response_ms", game.TotalResponseMs)
+		if game.UpdatedAt != nil {
+			pipe.HSet(ctx, key, "updated_at", game.UpdatedAt.Format(time.RFC3339Nano))
+		}
+		pipe.ZAddGT(ctx, playerStreaksKey, redis.Z{Score: float64(game.BestStreak), Member: game.UserID})
+		return nil
+	})
+	return err
+}
+
+func (r *RedisRepository) GetPlayerStats(userID string) (*models.PlayerStats, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+	var fields *redis.MapStringStringCmd
+	var streak *redis.FloatCmd
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		fields = pipe.HGetAll(ctx, playerStatsKey(userID))
+		streak = pipe.ZScore(ctx, playerStreaksKey, userID)
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	stats := &models.PlayerStats{UserID: userID}
+	values := fields.Val()
+	stats.GamesPlayed, _ = strconv.Atoi(values["games_played"])
+	stats.Wins, _ = strconv.Atoi(values["wins"])
+	stats.Answered, _ = strconv.Atoi(values["answered"])
+	stats.Correct, _ = strconv.Atoi(values["correct"])
+	stats.TotalResponseMs, _ = strconv.ParseInt(values["response_ms"], 10, 64)
+	stats.BestStreak = int(streak.Val())
+	if updatedAt, err := time.Parse(time.RFC3339Nano, values["updated_at"]); err == nil {
+		stats.UpdatedAt = &updatedAt
+	}
+	return stats, nil
+}
+
+func (r *RedisRepository) SaveAccountStreak(streak *models.AccountStreak) error {
+	data, err := json.Marshal(streak)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := r.context()
+	defer cancel()
+	return r.client.HSet(ctx, accountStreaksKey, streak.UserID, data).Err()
+}
+
+func (r *RedisRepository) GetAccountStreak(userID string) (*models.AccountStreak, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+	streak := &models.AccountStreak{UserID: userID}
+	data, err := r.client.HGet(ctx, accountStreaksKey, userID).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return streak, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, streak); err != nil {
+		return nil, err
+	}
+	return streak, nil
+}
+
+// SaveFriendship writes the friendship under both accounts.
+func (r *RedisRepository) SaveFriendship(friendship *models.Friendship) error {
+	data, err := json.Marshal(friendship)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := r.context()
+	defer cancel()
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, friendsKey(friendship.RequesterID), friendship.AddresseeID, data)
+		pipe.HSet(ctx, friendsKey(friendship.AddresseeID), friendship.RequesterID, data)
+		return nil
+	})
+	return err
+}
+
+func (r *RedisRepository) GetFriendship(userID, otherID string) (*models.Friendship, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+	data, err := r.client.HGet(ctx, friendsKey(userID), otherID).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrFriendshipNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var friendship models.Friendship
+	if err := json.Unmarshal(data, &friendship); err != nil {
+		return nil, err
+	}
+	return &friendship, nil
+}
+
+func (r *RedisRepository) ListFriendships(userID string) ([]*models.Friendship, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+	values, err := r.client.HVals(ctx, friendsKey(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	friendships := make([]*models.Friendship, 0, len(values))
+	for _, value := range values {
+		var friendship models.Friendship
+		if err := json.Unmarshal([]byte(value), &friendship); err != nil {
+			return nil, err
+		}
+		friendships = append(friendships, &friendship)
+	}
+	return friendships, nil
+}
+
+func (r *RedisRepository) DeleteFriendship(userID, otherID string) error {
+	ctx, cancel := r.context()
+	defer cancel()
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, friendsKey(userID), otherID)
+		pipe.HDel(ctx, friendsKey(otherID), userID)
+		return nil
+	})
+	return err
+}
+
+func (r *RedisRepository) SaveRecurringEvent(event *models.RecurringEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := r.context()
+	defer cancel()
+	return r.client.HSet(ctx, eventsKey, event.ID, data).Err()
+}
+
+func (r *RedisRepository) ListRecurringEvents() ([]*models.RecurringEvent, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+	values, err := r.client.HVals(ctx, eventsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*models.RecurringEvent, 0, len(values))
+	for _, value := range values {
+		var event models.RecurringEvent
+		if err := json.Unmarshal([]byte(value), &event); err != nil {
+			return nil, err
+		}
+		events = append(events, &event)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].CreatedAt.Before(events[j].CreatedAt) })
+	return events, nil
+}
+
+func (r *RedisRepository) DeleteRecurringEvent(eventID string) error {
+	ctx, cancel := r.context()
+	defer cancel()
+	deleted, err := r.client.HDel(ctx, eventsKey, eventID).Result()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrEventNotFound
+	}
+	return nil
+}
+
+// ClaimRecurringEvent watches the events hash, so a claim that races
+// another instance's fails its transaction instead of overwriting it.
+func (r *RedisRepository) ClaimRecurringEvent(eventID string, startsAt time.Time, lobbyID string) (bool, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+	claimed := false
+	err := r.client.Watch(ctx, func(tx *redis.Tx) error {
+		data, err := tx.HGet(ctx, eventsKey, eventID).Bytes()
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var event models.RecurringEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return err
+		}
+		if event.LastStartsAt != nil && !startsAt.After(*event.LastStartsAt) {
+			return nil
+		}
+		event.LastStartsAt, event.LastLobbyID = &startsAt, lobbyID
+		if data, err = json.Marshal(&event); err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, eventsKey, eventID, data)
+			return nil
+		})
+		claimed = err == nil
+		return err
+	}, eventsKey)
+	if errors.Is(err, redis.TxFailedErr) {
+		return false, nil
+	}
+	return claimed, err
+}
+
+func (r *RedisRepository) SaveAPIKey(key *models.APIKey) error {
+	data, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := r.context()
+	defer cancel()
+	return r.client.HSet(ctx, apiKeysKey, key.ID, data).Err()
+}
+
+func (r *RedisRepository) GetAPIKey(keyID string) (*models.APIKey, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+	data, err := r.client.HGet(ctx, apiKeysKey, keyID).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var key models.APIKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *RedisRepository) ListAPIKeys() ([]*models.APIKey, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+	values, err := r.client.HVals(ctx, apiKeysKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*models.APIKey, 0, len(values))
+	for _, value := range values {
+		var key models.APIKey
+		if err := json.Unmarshal([]byte(value), &key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, &key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys, nil
+}
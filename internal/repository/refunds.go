@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"encoding/json"
+
+	"buildprize-game/internal/models"
+)
+
+func (r *PostgresRepository) SaveEntryRefund(refund *models.EntryRefund) error {
+	data, err := json.Marshal(refund)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`
+		INSERT INTO entry_refunds (lobby_id, payment_id, status, created_at, data)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (lobby_id, payment_id) DO UPDATE SET
+			status = EXCLUDED.status,
+			data = EXCLUDED.data
+	`, refund.LobbyID, refund.PaymentID, refund.Status, refund.CreatedAt, data)
+	return err
+}
+
+func (r *PostgresRepository) ListEntryRefunds(status models.RefundStatus) ([]*models.EntryRefund, error) {
+	q := NewSelect("data", "entry_refunds")
+	if status != "" {
+		q.Where("status = ?", status)
+	}
+	query, args := q.OrderBy("created_at").SQL()
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refunds := make([]*models.EntryRefund, 0)
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var refund models.EntryRefund
+		if err := json.Unmarshal(data, &refund); err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, &refund)
+	}
+	return refunds, rows.Err()
+}
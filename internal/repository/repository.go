@@ -1,14 +1,119 @@
 package repository
 
 import (
+	"context"
 	"time"
 	"buildprize-game/internal/models"
 )
 
 type Repository interface {
+	Ping(ctx context.Context) error
+
 	SaveLobby(lobby *models.Lobby) error
 	GetLobby(lobbyID string) (*models.Lobby, error)
 	DeleteLobby(lobbyID string) error
-	ListLobbies() ([]*models.Lobby, error)
+	ListLobbies(query LobbyQuery) (*LobbyPage, error)
 	DeleteFinishedGamesOlderThan(duration time.Duration) (int, error)
+
+	SaveAnswer(record *models.AnswerRecord) error
+	GetCategoryMastery(playerID string) ([]*models.CategoryMastery, error)
+	GetLobbyAnswers(lobbyID string) ([]*models.AnswerRecord, error)
+	UpdateAnswerScore(answerID int64, score int) error
+	UpdateAnswerWager(answerID int64, delta int) error
+	GetQuestionPerformance() ([]*models.QuestionPerformance, error)
+
+	CreateScoringConfig(config *models.ScoringConfig) error
+	GetScoringConfig(version string) (*models.ScoringConfig, error)
+	ListScoringConfigs() ([]*models.ScoringConfig, error)
+	GetActiveScoringVersion() (string, error)
+	SetActiveScoringVersion(version string) error
+	SaveScoringAudit(entry *models.ScoringAuditEntry) error
+	ListScoringAudit(limit int) ([]*models.ScoringAuditEntry, error)
+
+	SavePendingNotification(notification *models.PendingNotification) error
+	TakePendingNotifications(lobbyID, playerID string) ([]*models.PendingNotification, error)
+
+	// Chat history is kept until the lobby is deleted or it's older than the
+	// retention period passed to DeleteChatMessagesOlderThan.
+	SaveChatMessage(msg *models.ChatMessage) error
+	ListChatMessages(lobbyID string, since time.Time, limit int) ([]*models.ChatMessage, error)
+	DeleteChatMessagesOlderThan(duration time.Duration) (int, error)
+
+	// Every lobby-wide event as it was broadcast, kept past its lobby until
+	// it's older than the retention period passed to
+	// DeleteGameEventsOlderThan or moved to cold storage. ListGameEvents
+	// returns a lobby's events after afterSeq, up to limit, in the order they
+	// were broadcast. ListGameEventLobbies returns up to limit lobbies whose
+	// last event was before the given time.
+	SaveGameEvents(events []*models.GameEvent) error
+	ListGameEvents(lobbyID string, afterSeq uint64, limit int) ([]*models.GameEvent, error)
+	ListGameEventLobbies(before time.Time, limit int) ([]string, error)
+	DeleteGameEvents(lobbyID string) error
+	DeleteGameEventsOlderThan(duration time.Duration) (int, error)
+
+	// Pointers to event logs moved to cold storage, kept until deleted by
+	// hand.
+	SaveGameArchive(archive *models.GameArchive) error
+	GetGameArchive(lobbyID string) (*models.GameArchive, error)
+
+	// The admin audit log is kept until deleted by hand. ListAdminActions
+	// returns the most recent entries the filter selects, newest first.
+	SaveAdminAction(action *models.AdminAction) error
+	ListAdminActions(filter models.AdminActionFilter, limit int) ([]*models.AdminAction, error)
+
+	// Player reports are kept for admin review until deleted by hand.
+	SavePlayerReport(report *models.PlayerReport) error
+	ListPlayerReports(lobbyID string, limit int) ([]*models.PlayerReport, error)
+
+	// Prize results outlive their lobby and are kept until deleted by hand.
+	// ListPrizeResults returns those with the given status, or all of them
+	// for an empty status, most recently ended first.
+	SavePrizeResult(result *models.PrizeResult) error
+	GetPrizeResult(lobbyID string) (*models.PrizeResult, error)
+	ListPrizeResults(status models.ResultsStatus) ([]*models.PrizeResult, error)
+
+	// Entry fee refunds outlive their lobby too and are kept until deleted by
+	// hand, one per lobby and payment. ListEntryRefunds returns those with
+	// the given status, or all of them for an empty status, oldest first.
+	SaveEntryRefund(refund *models.EntryRefund) error
+	ListEntryRefunds(status models.RefundStatus) ([]*models.EntryRefund, error)
+
+	// Accounts. Usernames are unique ignoring case: CreateUser fails with
+	// ErrUsernameTaken for a taken one, and GetUserByUsername matches any case.
+	CreateUser(user *models.User) error
+	GetUser(userID string) (*models.User, error)
+	GetUserByUsername(username string) (*models.User, error)
+
+	// Lifetime stats per account, kept until deleted by hand.
+	// AddPlayerStats folds one finished game into the account's totals;
+	// GetPlayerStats returns zero totals for an account with none yet.
+	AddPlayerStats(game *models.PlayerStats) error
+	GetPlayerStats(userID string) (*models.PlayerStats, error)
+
+	// Cross-game streaks per account, kept until deleted by hand.
+	// GetAccountStreak returns an empty streak for an account with none yet.
+	SaveAccountStreak(streak *models.AccountStreak) error
+	GetAccountStreak(userID string) (*models.AccountStreak, error)
+
+	// Friendships between accounts, requested or accepted, one per pair of
+	// accounts whichever asked. GetFriendship finds it from either side.
+	SaveFriendship(friendship *models.Friendship) error
+	GetFriendship(userID, otherID string) (*models.Friendship, error)
+	ListFriendships(userID string) ([]*models.Friendship, error)
+	DeleteFriendship(userID, otherID string) error
+
+	// Recurring events, kept until deleted. ClaimRecurringEvent records
+	// lobbyID as the lobby opened for the start at startsAt, and reports
+	// false if a start that late was claimed already, so only one instance
+	// opens each start's lobby.
+	SaveRecurringEvent(event *models.RecurringEvent) error
+	ListRecurringEvents() ([]*models.RecurringEvent, error)
+	DeleteRecurringEvent(eventID string) error
+	ClaimRecurringEvent(eventID string, startsAt time.Time, lobbyID string) (bool, error)
+
+	// API keys, kept until deleted by hand; revoked keys stay on record.
+	// ListAPIKeys returns them oldest first.
+	SaveAPIKey(key *models.APIKey) error
+	GetAPIKey(keyID string) (*models.APIKey, error)
+	ListAPIKeys() ([]*models.APIKey, error)
 }
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"buildprize-game/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// Scoring configs are immutable once inserted. At most one is active: the
+// version new games are scored with.
+const createScoringTables = `
+	CREATE TABLE IF NOT EXISTS scoring_configs (
+		version VARCHAR(32) PRIMARY KEY,
+		name VARCHAR(255) NOT NULL DEFAULT '',
+		config JSONB NOT NULL,
+		active BOOLEAN NOT NULL DEFAULT FALSE,
+		created_by VARCHAR(255) NOT NULL DEFAULT '',
+		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_scoring_configs_active ON scoring_configs(active) WHERE active;
+
+	CREATE TABLE IF NOT EXISTS scoring_audit (
+		id BIGSERIAL PRIMARY KEY,
+		action VARCHAR(20) NOT NULL,
+		version VARCHAR(32) NOT NULL,
+		lobby_id VARCHAR(36) NOT NULL DEFAULT '',
+		actor VARCHAR(255) NOT NULL DEFAULT '',
+		details JSONB,
+		created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+	);
+	`
+
+// seedScoringConfig stores the built-in version and activates it when no
+// version is active yet.
+func seedScoringConfig(db *sql.DB) error {
+	config := models.DefaultScoringConfig
+	config.CreatedBy = "system"
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if _, err := db.Exec(`
+		INSERT INTO scoring_configs (version, name, config, created_by)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (version) DO NOTHING
+	`, config.Version, config.Name, data, config.CreatedBy); err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+		UPDATE scoring_configs SET active = TRUE
+		WHERE version = $1 AND NOT EXISTS (SELECT 1 FROM scoring_configs WHERE active)
+	`, config.Version)
+	return err
+}
+
+func (r *PostgresRepository) CreateScoringConfig(config *models.ScoringConfig) error {
+	config.CreatedAt = models.Now()
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`
+		INSERT INTO scoring_configs (version, name, config, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, config.Version, config.Name, data, config.CreatedBy, config.CreatedAt)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrScoringVersionExists
+	}
+	return err
+}
+
+func (r *PostgresRepository) GetScoringConfig(version string) (*models.ScoringConfig, error) {
+	var data []byte
+	err := r.db.QueryRow("SELECT config FROM scoring_configs WHERE version = $1", version).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, ErrScoringConfigNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var config models.ScoringConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	config.CreatedAt = config.CreatedAt.UTC()
+	return &config, nil
+}
+
+// ListScoringConfigs returns every stored version, oldest first.
+func (r *PostgresRepository) ListScoringConfigs() ([]*models.ScoringConfig, error) {
+	rows, err := r.db.Query("SELECT config FROM scoring_configs ORDER BY created_at, version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	configs := make([]*models.ScoringConfig, 0)
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var config models.ScoringConfig
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, err
+		}
+		config.CreatedAt = config.CreatedAt.UTC()
+		configs = append(configs, &config)
+	}
+	return configs, rows.Err()
+}
+
+func (r *PostgresRepository) GetActiveScoringVersion() (string, error) {
+	var version string
+	err := r.db.QueryRow("SELECT version FROM scoring_configs WHERE active").Scan(&version)
+	if err == sql.ErrNoRows {
+		return "", ErrScoringConfigNotFound
+	}
+	return version, err
+}
+
+func (r *PostgresRepository) SetActiveScoringVersion(version string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE scoring_configs SET active = FALSE WHERE active"); err != nil {
+		return err
+	}
+	result, err := tx.Exec("UPDATE scoring_configs SET active = TRUE WHERE version = $1", version)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrScoringConfigNotFound
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresRepository) SaveScoringAudit(entry *models.ScoringAuditEntry) error {
+	var details interface{} // NULL without details
+	if len(entry.Details) > 0 {
+		data, err := json.Marshal(entry.Details)
+		if err != nil {
+			return err
+		}
+		details = data
+	}
+	return r.db.QueryRow(`
+		INSERT INTO scoring_audit (action, version, lobby_id, actor, details, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, entry.Action, entry.Version, entry.LobbyID, entry.Actor, details, entry.CreatedAt).Scan(&entry.ID)
+}
+
+// ListScoringAudit returns the most recent audit entries, newest first.
+func (r *PostgresRepository) ListScoringAudit(limit int) ([]*models.ScoringAuditEntry, error) {
+	rows, err := r.db.Query(`
+		SELECT id, action, version, lobby_id, actor, details, created_at
+		FROM scoring_audit
+		ORDER BY id DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]*models.ScoringAuditEntry, 0)
+	for rows.Next() {
+		var entry models.ScoringAuditEntry
+		var details []byte
+		if err := rows.Scan(&entry.ID, &entry.Action, &entry.Version, &entry.LobbyID, &entry.Actor, &details, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		if len(details) > 0 {
+			json.Unmarshal(details, &entry.Details)
+		}
+		entry.CreatedAt = entry.CreatedAt.UTC()
+		entries = append(entries, &entry)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"buildprize-game/internal/models"
+
+	"github.com/lib/pq"
+)
+
+func (r *PostgresRepository) CreateUser(user *models.User) error {
+	_, err := r.db.Exec(`
+		INSERT INTO users (id, username, password_hash, created_at)
+		VALUES ($1, $2, $3, $4)
+	`, user.ID, user.Username, user.PasswordHash, user.CreatedAt)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrUsernameTaken
+	}
+	return err
+}
+
+func (r *PostgresRepository) GetUser(userID string) (*models.User, error) {
+	return r.getUser("id = $1", userID)
+}
+
+func (r *PostgresRepository) GetUserByUsername(username string) (*models.User, error) {
+	return r.getUser("LOWER(username) = LOWER($1)", username)
+}
+
+func (r *PostgresRepository) getUser(where string, arg string) (*models.User, error) {
+	var user models.User
+	err := r.db.QueryRow("SELECT id, username, password_hash, created_at FROM users WHERE "+where, arg).
+		Scan(&user.ID, &user.Username, &user.PasswordHash, &user.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *PostgresRepository) AddPlayerStats(game *models.PlayerStats) error {
+	updatedAt := time.Now()
+	if game.UpdatedAt != nil {
+		updatedAt = *game.UpdatedAt
+	}
+	_, err := r.db.Exec(`
+		INSERT INTO player_stats (user_id, games_played, wins, answered, correct, response_ms, best_streak, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id) DO UPDATE SET
+			games_played = player_stats.games_played + EXCLUDED.games_played,
+			wins = player_stats.wins + EXCLUDED.wins,
+			answered = player_stats.answered + EXCLUDED.answered,
+			correct = player_stats.correct + EXCLUDED.correct,
+			response_ms = player_stats.response_ms + EXCLUDED.response_ms,
+			best_streak = GREATEST(player_stats.best_streak, EXCLUDED.best_streak),
+			updated_at = EXCLUDED.updated_at
+	`, game.UserID, game.GamesPlayed, game.Wins, game.Answered, game.Correct, game.TotalResponseMs, game.BestStreak, updatedAt)
+	return err
+}
+
+func (r *PostgresRepository) GetPlayerStats(userID string) (*models.PlayerStats, error) {
+	stats := &models.PlayerStats{UserID: userID}
+	var updatedAt time.Time
+	err := r.db.QueryRow(`
+		SELECT games_played, wins, answered, correct, response_ms, best_streak, updated_at
+		FROM player_stats WHERE user_id = $1
+	`, userID).Scan(&stats.GamesPlayed, &stats.Wins, &stats.Answered, &stats.Correct, &stats.TotalResponseMs, &stats.BestStreak, &updatedAt)
+	if err == sql.ErrNoRows {
+		return stats, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	stats.UpdatedAt = &updatedAt
+	return stats, nil
+}
+
+func (r *PostgresRepository) SaveAccountStreak(streak *models.AccountStreak) error {
+	data, err := json.Marshal(streak)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`
+		INSERT INTO account_streaks (user_id, data, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET
+			data = EXCLUDED.data,
+			updated_at = EXCLUDED.updated_at
+	`, streak.UserID, data)
+	return err
+}
+
+func (r *PostgresRepository) GetAccountStreak(userID string) (*models.AccountStreak, error) {
+	streak := &models.AccountStreak{UserID: userID}
+	var data []byte
+	err := r.db.QueryRow("SELECT data FROM account_streaks WHERE user_id = $1", userID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return streak, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, streak); err != nil {
+		return nil, err
+	}
+	return streak, nil
+}
+
+// orderedPair puts two user IDs in a fixed order, so a friendship is stored
+// once whichever side asked.
+func orderedPair(userID, otherID string) (string, string) {
+	if otherID < userID {
+		return otherID, userID
+	}
+	return userID, otherID
+}
+
+func (r *PostgresRepository) SaveFriendship(friendship *models.Friendship) error {
+	userA, userB := orderedPair(friendship.RequesterID, friendship.AddresseeID)
+	_, err := r.db.Exec(`
+		INSERT INTO friendships (user_a, user_b, requester_id, status, created_at, accepted_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_a, user_b) DO UPDATE SET
+			requester_id = EXCLUDED.requester_id,
+			status = EXCLUDED.status,
+			created_at = EXCLUDED.created_at,
+			accepted_at = EXCLUDED.accepted_at
+	`, userA, userB, friendship.RequesterID, friendship.Status, friendship.CreatedAt, friendship.AcceptedAt)
+	return err
+}
+
+func (r *PostgresRepository) GetFriendship(userID, otherID string) (*models.Friendship, error) {
+	userA, userB := orderedPair(userID, otherID)
+	friendships, err := r.queryFriendships("user_a = $1 AND user_b = $2", userA, userB)
+	if err != nil {
+		return nil, err
+	}
+	if len(friendships) == 0 {
+		return nil, ErrFriendshipNotFound
+	}
+	return friendships[0], nil
+}
+
+func (r *PostgresRepository) ListFriendships(userID string) ([]*models.Friendship, error) {
+	return r.queryFriendships("user_a = $1 OR user_b = $1", userID)
+}
+
+func (r *PostgresRepository) queryFriendships(where string, args ...interface{}) ([]*models.Friendship, error) {
+	rows, err := r.db.Query("SELECT user_a, user_b, requester_id, status, created_at, accepted_at FROM friendships WHERE "+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	friendships := make([]*models.Friendship, 0)
+	for rows.Next() {
+		var userA, userB string
+		var f models.Friendship
+		var acceptedAt sql.NullTime
+		if err := rows.Scan(&userA, &userB, &f.RequesterID, &f.Status, &f.CreatedAt, &acceptedAt); err != nil {
+			return nil, err
+		}
+		f.AddresseeID = userA
+		if f.RequesterID == userA {
+			f.AddresseeID = userB
+		}
+		if acceptedAt.Valid {
+			f.AcceptedAt = &acceptedAt.Time
+		}
+		friendships = append(friendships, &f)
+	}
+	return friendships, rows.Err()
+}
+
+func (r *PostgresRepository) DeleteFriendship(userID, otherID string) error {
+	userA, userB := orderedPair(userID, otherID)
+	_, err := r.db.Exec("DELETE FROM friendships WHERE user_a = $1 AND user_b = $2", userA, userB)
+	return err
+}
@@ -0,0 +1,183 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Provider looks up secrets by name (the environment variable name, e.g.
+// "DATABASE_URL"). ok is false when the provider doesn't have the secret,
+// so the next provider is tried.
+type Provider interface {
+	Name() string
+	Lookup(name string) (value string, ok bool, err error)
+}
+
+// EnvProvider reads plain environment variables.
+type EnvProvider struct{}
+
+func (EnvProvider) Name() string { return "env" }
+
+func (EnvProvider) Lookup(name string) (string, bool, error) {
+	value := os.Getenv(name)
+	return value, value != "", nil
+}
+
+// FileProvider reads secrets from files, as mounted by Docker and Kubernetes
+// secrets. NAME_FILE points at the file for NAME; otherwise, when Dir is set,
+// Dir/NAME is read if it exists. Trailing newlines are trimmed.
+type FileProvider struct {
+	Dir string
+}
+
+func (FileProvider) Name() string { return "file" }
+
+func (p FileProvider) Lookup(name string) (string, bool, error) {
+	path := os.Getenv(name + "_FILE")
+	if path == "" && p.Dir != "" {
+		path = filepath.Join(p.Dir, name)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return "", false, nil
+		}
+	}
+	if path == "" {
+		return "", false, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("read %s: %w", path, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+// mapProvider serves names from a JSON object fetched as a whole, cached
+// briefly so loading the configuration makes one request rather than one
+// per secret.
+type mapProvider struct {
+	name  string
+	fetch func() (map[string]string, error)
+	ttl   time.Duration
+
+	mu        sync.Mutex
+	values    map[string]string
+	fetchedAt time.Time
+}
+
+func (p *mapProvider) Name() string { return p.name }
+
+func (p *mapProvider) Lookup(name string) (string, bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.values == nil || time.Since(p.fetchedAt) > p.ttl {
+		values, err := p.fetch()
+		if err != nil {
+			return "", false, err
+		}
+		p.values = values
+		p.fetchedAt = time.Now()
+	}
+	value, ok := p.values[name]
+	return value, ok, nil
+}
+
+// NewVaultProvider reads one HashiCorp Vault secret holding every key, e.g.
+// path "secret/data/quiz" on a KV v2 mount. Both KV v1 and v2 responses are
+// understood.
+func NewVaultProvider(addr, token, path, namespace string) Provider {
+	client := &http.Client{Timeout: 10 * time.Second}
+	url := strings.TrimRight(addr, "/") + "/v1/" + strings.TrimLeft(path, "/")
+
+	return &mapProvider{
+		name: "vault",
+		ttl:  10 * time.Second,
+		fetch: func() (map[string]string, error) {
+			req, err := http.NewRequest("GET", url, nil)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set("X-Vault-Token", token)
+			if namespace != "" {
+				req.Header.Set("X-Vault-Namespace", namespace)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				return nil, fmt.Errorf("vault request failed: %w", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != 200 {
+				return nil, fmt.Errorf("vault returned status %d for %s", resp.StatusCode, path)
+			}
+
+			var body struct {
+				Data map[string]json.RawMessage `json:"data"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				return nil, fmt.Errorf("vault response invalid: %w", err)
+			}
+			// KV v2 nests the secret under data.data, next to data.metadata
+			if _, v2 := body.Data["metadata"]; v2 {
+				nested := body.Data["data"]
+				var inner map[string]json.RawMessage
+				if json.Unmarshal(nested, &inner) == nil {
+					body.Data = inner
+				}
+			}
+			return stringValues(body.Data), nil
+		},
+	}
+}
+
+// NewCommandProvider runs a command that prints a JSON object of secrets.
+// This covers cloud secret managers through their CLIs, e.g.
+//
+//	aws secretsmanager get-secret-value --secret-id quiz --query SecretString --output text
+//	gcloud secrets versions access latest --secret=quiz
+func NewCommandProvider(command string, args []string, timeout time.Duration) Provider {
+	return &mapProvider{
+		name: "command",
+		ttl:  10 * time.Second,
+		fetch: func() (map[string]string, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			cmd := exec.CommandContext(ctx, command, args...)
+			var stderr bytes.Buffer
+			cmd.Stderr = &stderr
+			out, err := cmd.Output()
+			if err != nil {
+				return nil, fmt.Errorf("secrets command %s failed: %w (stderr: %s)", command, err, stderr.String())
+			}
+
+			var values map[string]json.RawMessage
+			if err := json.Unmarshal(out, &values); err != nil {
+				return nil, fmt.Errorf("secrets command %s printed invalid JSON: %w", command, err)
+			}
+			return stringValues(values), nil
+		},
+	}
+}
+
+// stringValues keeps string values as they are and other JSON values in
+// their encoded form.
+func stringValues(raw map[string]json.RawMessage) map[string]string {
+	values := make(map[string]string, len(raw))
+	for name, value := range raw {
+		var s string
+		if json.Unmarshal(value, &s) == nil {
+			values[name] = s
+		} else {
+			values[name] = string(value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,183 @@
+// Package secrets loads sensitive settings (database URL, API and signing
+// keys, webhook secrets) from files, Vault or a cloud secret manager as well
+// as plain environment variables, and notifies the application when a value
+// rotates.
+package secrets
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store resolves secrets through an ordered list of providers; the first
+// provider that has a secret wins. Values are cached, and Watch re-reads them
+// to detect rotations.
+type Store struct {
+	providers []Provider
+
+	mu     sync.Mutex
+	values map[string]string // last value of every secret read so far
+	hooks  map[string][]func(string)
+}
+
+func New(providers ...Provider) *Store {
+	return &Store{
+		providers: providers,
+		values:    make(map[string]string),
+		hooks:     make(map[string][]func(string)),
+	}
+}
+
+// FromEnv builds the provider chain from the environment:
+//
+//   - NAME_FILE files and SECRETS_DIR/NAME files
+//   - SECRETS_COMMAND, a command printing a JSON object (cloud secret managers)
+//   - Vault, when VAULT_ADDR, VAULT_TOKEN and VAULT_SECRET_PATH are set
+//   - plain environment variables
+func FromEnv() *Store {
+	var providers []Provider
+	if dir := os.Getenv("SECRETS_DIR"); dir != "" || hasFileVariables() {
+		providers = append(providers, FileProvider{Dir: dir})
+	}
+	if fields := strings.Fields(os.Getenv("SECRETS_COMMAND")); len(fields) > 0 {
+		providers = append(providers, NewCommandProvider(fields[0], fields[1:], 30*time.Second))
+	}
+	if addr := os.Getenv("VAULT_ADDR"); addr != "" && os.Getenv("VAULT_SECRET_PATH") != "" {
+		providers = append(providers, NewVaultProvider(addr, os.Getenv("VAULT_TOKEN"), os.Getenv("VAULT_SECRET_PATH"), os.Getenv("VAULT_NAMESPACE")))
+	}
+	providers = append(providers, EnvProvider{})
+	return New(providers...)
+}
+
+func hasFileVariables() bool {
+	for _, entry := range os.Environ() {
+		if name, _, _ := strings.Cut(entry, "="); strings.HasSuffix(name, "_FILE") {
+			return true
+		}
+	}
+	return false
+}
+
+// AddProvider puts provider ahead of the existing ones, for deployments that
+// plug in a secret manager SDK directly.
+func (s *Store) AddProvider(provider Provider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.providers = append([]Provider{provider}, s.providers...)
+}
+
+// Get returns the secret, or defaultValue if no provider has it. The name is
+// remembered so Watch picks up later rotations.
+func (s *Store) Get(name, defaultValue string) string {
+	value, ok, err := s.lookup(name)
+	if err != nil {
+		log.Printf("Failed to load secret %s: %v", name, err)
+	}
+	if !ok {
+		value = defaultValue
+	}
+	s.mu.Lock()
+	s.values[name] = value
+	s.mu.Unlock()
+	return value
+}
+
+// Current returns the latest value seen for a secret read with Get.
+func (s *Store) Current(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.values[name]
+}
+
+// OnRotate registers fn to run with the new value whenever the secret
+// changes.
+func (s *Store) OnRotate(name string, fn func(value string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks[name] = append(s.hooks[name], fn)
+}
+
+// Refresh re-reads every known secret and runs the rotation hooks of those
+// that changed. A secret keeps its value while a provider is failing.
+func (s *Store) Refresh() {
+	s.mu.Lock()
+	names := make([]string, 0, len(s.values))
+	for name := range s.values {
+		names = append(names, name)
+	}
+	s.mu.Unlock()
+
+	for _, name := range names {
+		value, ok, err := s.lookup(name)
+		if err != nil {
+			log.Printf("Failed to refresh secret %s: %v", name, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		s.mu.Lock()
+		changed := s.values[name] != value
+		s.values[name] = value
+		hooks := append([]func(string){}, s.hooks[name]...)
+		s.mu.Unlock()
+
+		if changed {
+			log.Printf("Secret %s rotated (%d hook(s))", name, len(hooks))
+			for _, hook := range hooks {
+				hook(value)
+			}
+		}
+	}
+}
+
+// Watch refreshes secrets every interval until stop is closed.
+func (s *Store) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.Refresh()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Dynamic reports whether any provider can change without a restart, i.e.
+// whether watching for rotations is worthwhile.
+func (s *Store) Dynamic() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, provider := range s.providers {
+		if _, static := provider.(EnvProvider); !static {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup stops at a failing provider rather than falling through, so an
+// outage doesn't silently swap in a lower-priority value.
+func (s *Store) lookup(name string) (string, bool, error) {
+	s.mu.Lock()
+	providers := s.providers
+	s.mu.Unlock()
+
+	for _, provider := range providers {
+		value, ok, err := provider.Lookup(name)
+		if err != nil {
+			return "", false, fmt.Errorf("%s provider: %w", provider.Name(), err)
+		}
+		if ok {
+			return value, true, nil
+		}
+	}
+	return "", false, nil
+}
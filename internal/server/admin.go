@@ -0,0 +1,507 @@
+package server
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"sort"
+	"strconv"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupAdminRoutes registers the admin API on router: the game's router, or
+// the internal admin listener's when ADMIN_ADDR is set.
+func (s *Server) setupAdminRoutes(router gin.IRouter) {
+	keys := router.Group("/api/v1/admin/api-keys")
+	keys.Use(s.requireAdmin, requireScope(models.ScopeAPIKeys))
+	{
+		keys.GET("", s.listAPIKeys)
+		keys.POST("", s.createAPIKey)
+		keys.POST("/:id/rotate", s.rotateAPIKey)
+		keys.DELETE("/:id", s.revokeAPIKey)
+	}
+
+	admin := router.Group("/api/v1/admin")
+	admin.Use(s.requireAdmin, requireAdminScope)
+	{
+		admin.POST("/sandbox/lobbies", s.createSandboxLobby)
+		admin.POST("/sandbox/lobbies/:id/players", s.addTestPlayer)
+		admin.POST("/sandbox/lobbies/:id/answers", s.injectAnswer)
+		admin.POST("/lobbies/:id/end", s.forceEndGame)
+		admin.POST("/lobbies/:id/cancel", s.cancelGame)
+		admin.POST("/lobbies/:id/recompute-scores", s.recomputeScores)
+		admin.GET("/scoring-configs", s.listScoringConfigs)
+		admin.POST("/scoring-configs", s.createScoringConfig)
+		admin.POST("/scoring-configs/:version/activate", s.activateScoringConfig)
+		admin.GET("/scoring-audit", s.getScoringAudit)
+		admin.GET("/audit", s.getAdminAudit)
+		admin.GET("/lobbies/:id/events", s.getGameEvents)
+		admin.GET("/question-cache", s.getQuestionCacheStats)
+		admin.GET("/question-sources", s.getQuestionSourceStats)
+		admin.GET("/questions/difficulty", s.getDifficultyReport)
+		admin.POST("/questions/difficulty/calibrate", s.calibrateDifficulty)
+		admin.GET("/connections", s.getConnectionStats)
+		admin.GET("/reports", s.getPlayerReports)
+		admin.GET("/prize-results", s.listPrizeResults)
+		admin.POST("/lobbies/:id/disputes/:dispute_id/resolve", s.resolveDispute)
+		admin.POST("/lobbies/:id/prizes/:player_id/retry-payout", s.retryPayout)
+		admin.GET("/questions/lint", s.lintQuestionBank)
+		admin.POST("/questions/lint", s.lintQuestions)
+		admin.GET("/events", s.listRecurringEvents)
+		admin.POST("/events", s.createRecurringEvent)
+		admin.DELETE("/events/:id", s.deleteRecurringEvent)
+	}
+	log.Printf("Admin routes registered at /api/v1/admin (enabled: %t)", s.config.AdminToken != "")
+}
+
+// requireAdmin rejects requests without the configured admin token or a
+// valid API key; the routes check the key's scopes. Without a key, the
+// admin API is disabled entirely when ADMIN_TOKEN is unset.
+func (s *Server) requireAdmin(c *gin.Context) {
+	if c.GetHeader(apiKeyHeader) != "" {
+		if _, ok := s.verifyAPIKey(c); ok {
+			c.Next()
+		}
+		return
+	}
+
+	// Read per request so a rotated token takes effect without a restart
+	adminToken := s.config.Secrets.Current("ADMIN_TOKEN")
+	if adminToken == "" {
+		c.AbortWithStatusJSON(404, gin.H{"error": "Admin API is disabled"})
+		return
+	}
+
+	token := c.GetHeader("X-Admin-Token")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+		c.AbortWithStatusJSON(401, gin.H{"error": "Invalid admin token"})
+		return
+	}
+
+	c.Next()
+}
+
+type sandboxLobbyRequest struct {
+	Name      string `json:"name"`       // default "Sandbox"
+	MaxRounds int    `json:"max_rounds"` // default 3
+}
+
+func (s *Server) createSandboxLobby(c *gin.Context) {
+	var req sandboxLobbyRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Name == "" {
+		req.Name = "Sandbox"
+	}
+	if req.MaxRounds == 0 {
+		req.MaxRounds = 3
+	}
+
+	lobby := s.gameService.CreateSandboxLobby(req.Name, req.MaxRounds)
+	s.recordAdminAction(c, models.AdminCreateSandbox, lobby.ID, "", map[string]interface{}{"name": req.Name, "max_rounds": req.MaxRounds})
+	c.JSON(201, lobby.Snapshot())
+}
+
+type testPlayerRequest struct {
+	Username string                    `json:"username" binding:"required"`
+	Script   []services.ScriptedAnswer `json:"script"`
+}
+
+func (s *Server) addTestPlayer(c *gin.Context) {
+	lobbyID := c.Param("id")
+
+	var req testPlayerRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	player, err := s.gameService.AddTestPlayer(lobbyID, req.Username, req.Script)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.recordAdminAction(c, models.AdminAddTestPlayer, lobbyID, "", map[string]interface{}{"player_id": player.ID, "username": player.Username, "scripted_answers": len(req.Script)})
+	c.JSON(201, player)
+}
+
+type injectAnswerRequest struct {
+	PlayerID     string      `json:"player_id" binding:"required"`
+	Answer       interface{} `json:"answer"`
+	ResponseTime int64       `json:"response_time"`
+}
+
+func (s *Server) injectAnswer(c *gin.Context) {
+	lobbyID := c.Param("id")
+
+	var req injectAnswerRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	answer, err := models.ParseSubmittedAnswer(req.Answer)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.gameService.InjectAnswer(lobbyID, req.PlayerID, answer, req.ResponseTime); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.recordAdminAction(c, models.AdminInjectAnswer, lobbyID, "", map[string]interface{}{"player_id": req.PlayerID, "answer": req.Answer, "response_time": req.ResponseTime})
+
+	c.JSON(200, messageResponse{"Answer injected"})
+}
+
+func (s *Server) forceEndGame(c *gin.Context) {
+	lobbyID := c.Param("id")
+
+	if err := s.gameService.ForceEndGame(lobbyID); err != nil {
+		status := 400
+		if errors.Is(err, services.ErrLobbyNotFound) {
+			status = 404
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.recordAdminAction(c, models.AdminForceEnd, lobbyID, "", nil)
+	c.JSON(200, messageResponse{"Game ended"})
+}
+
+func (s *Server) cancelGame(c *gin.Context) {
+	lobbyID := c.Param("id")
+
+	if err := s.gameService.CancelGame(lobbyID); err != nil {
+		status := 400
+		if errors.Is(err, services.ErrLobbyNotFound) {
+			status = 404
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.recordAdminAction(c, models.AdminCancelGame, lobbyID, "", nil)
+	c.JSON(200, messageResponse{"Game cancelled"})
+}
+
+type recomputeRequest struct {
+	ScoringVersion string `json:"scoring_version"` // default: the version the game was played under
+	Apply          bool   `json:"apply"`
+	ChangedBy      string `json:"changed_by"`
+}
+
+func (s *Server) recomputeScores(c *gin.Context) {
+	lobbyID := c.Param("id")
+
+	var req recomputeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := s.gameService.RecomputeScores(lobbyID, req.ScoringVersion, req.Apply, auditActor(c, req.ChangedBy))
+	if err != nil {
+		status := 500
+		switch {
+		case errors.Is(err, services.ErrUnknownScoringVersion):
+			status = 400
+		case errors.Is(err, services.ErrNoAnswerHistory):
+			status = 404
+		case errors.Is(err, services.ErrGameNotFinished):
+			status = 409
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	// A report that isn't applied changes nothing
+	if result.Applied {
+		s.recordAdminAction(c, models.AdminRecomputeScores, lobbyID, req.ChangedBy, map[string]interface{}{
+			"scoring_version": result.ScoringVersion,
+			"changed_answers": result.ChangedAnswers,
+			"winner_changed":  result.WinnerChanged,
+		})
+	}
+	c.JSON(200, result)
+}
+
+// auditActor names who made an admin change in the audit logs. Requests
+// made with an API key are recorded as the key. The admin token is shared,
+// so its callers identify themselves: with the request's changed_by, where
+// it takes one, or an X-Admin-Actor header.
+func auditActor(c *gin.Context, changedBy string) string {
+	if key := currentAPIKey(c); key != nil {
+		return "key:" + key.Name
+	}
+	if changedBy != "" {
+		return changedBy
+	}
+	if actor := c.GetHeader("X-Admin-Actor"); actor != "" {
+		return actor
+	}
+	return "admin"
+}
+
+// recordAdminAction adds a change just made through the admin API to the
+// admin audit log, with the actor auditActor names.
+func (s *Server) recordAdminAction(c *gin.Context, action, target, changedBy string, details map[string]interface{}) {
+	s.gameService.RecordAdminAction(&models.AdminAction{
+		Action:     action,
+		Actor:      auditActor(c, changedBy),
+		Target:     target,
+		Details:    details,
+		RemoteAddr: c.ClientIP(),
+	})
+}
+
+type scoringConfigsResponse struct {
+	ActiveVersion string                  `json:"active_version"`
+	Configs       []*models.ScoringConfig `json:"configs"`
+}
+
+func (s *Server) listScoringConfigs(c *gin.Context) {
+	configs, err := s.gameService.ScoringConfigs()
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, scoringConfigsResponse{s.gameService.ActiveScoringVersion(), configs})
+}
+
+type scoringConfigRequest struct {
+	models.ScoringConfig
+	ChangedBy string `json:"changed_by"`
+}
+
+func (s *Server) createScoringConfig(c *gin.Context) {
+	var req scoringConfigRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	config, err := s.gameService.CreateScoringConfig(req.ScoringConfig, auditActor(c, req.ChangedBy))
+	if err != nil {
+		status := 500
+		switch {
+		case errors.Is(err, services.ErrInvalidScoringConfig):
+			status = 400
+		case errors.Is(err, services.ErrScoringVersionExists):
+			status = 409
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.recordAdminAction(c, models.AdminCreateScoring, config.Version, req.ChangedBy, map[string]interface{}{"config": config})
+	c.JSON(201, config)
+}
+
+type activateScoringRequest struct {
+	ChangedBy string `json:"changed_by"`
+}
+
+type activeScoringResponse struct {
+	Message       string `json:"message"`
+	ActiveVersion string `json:"active_version"`
+}
+
+func (s *Server) activateScoringConfig(c *gin.Context) {
+	var req activateScoringRequest
+	// The body is optional
+	c.ShouldBindJSON(&req)
+
+	version := c.Param("version")
+	if err := s.gameService.ActivateScoringConfig(version, auditActor(c, req.ChangedBy)); err != nil {
+		status := 500
+		if errors.Is(err, services.ErrUnknownScoringVersion) {
+			status = 404
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.recordAdminAction(c, models.AdminActivateScoring, version, req.ChangedBy, nil)
+	c.JSON(200, activeScoringResponse{"Scoring version activated", version})
+}
+
+func (s *Server) getScoringAudit(c *gin.Context) {
+	entries, err := s.gameService.ScoringAudit()
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, entries)
+}
+
+type adminActionsResponse struct {
+	Actions []*models.AdminAction `json:"actions"`
+}
+
+// getAdminAudit lists admin actions, newest first, narrowed by ?action=,
+// ?actor= and ?target=. ?limit= caps how many, default 50.
+func (s *Server) getAdminAudit(c *gin.Context) {
+	limit := 50
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 500 {
+			c.JSON(400, gin.H{"error": "limit must be between 1 and 500"})
+			return
+		}
+		limit = n
+	}
+
+	actions, err := s.gameService.AdminActions(models.AdminActionFilter{
+		Action: c.Query("action"),
+		Actor:  c.Query("actor"),
+		Target: c.Query("target"),
+	}, limit)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, adminActionsResponse{actions})
+}
+
+type gameEventsResponse struct {
+	Events []*models.GameEvent `json:"events"`
+}
+
+// getGameEvents returns a lobby's stored event log in broadcast order: the
+// events after ?after_seq= (default 0), ?limit= of them (default 500, at
+// most 1000). It's kept past the lobby until the retention period ends.
+func (s *Server) getGameEvents(c *gin.Context) {
+	var afterSeq uint64
+	if raw := c.Query("after_seq"); raw != "" {
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "after_seq must be a sequence number"})
+			return
+		}
+		afterSeq = n
+	}
+	limit := 500
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 1000 {
+			c.JSON(400, gin.H{"error": "limit must be between 1 and 1000"})
+			return
+		}
+		limit = n
+	}
+
+	events, err := s.gameService.GameEvents(c.Param("id"), afterSeq, limit)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gameEventsResponse{events})
+}
+
+func (s *Server) getQuestionCacheStats(c *gin.Context) {
+	c.JSON(200, s.gameService.QuestionCacheStats())
+}
+
+func (s *Server) getQuestionSourceStats(c *gin.Context) {
+	c.JSON(200, s.gameService.QuestionSourceStats())
+}
+
+func (s *Server) getDifficultyReport(c *gin.Context) {
+	c.JSON(200, s.gameService.DifficultyReport())
+}
+
+func (s *Server) calibrateDifficulty(c *gin.Context) {
+	report, err := s.gameService.CalibrateDifficulty()
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	s.recordAdminAction(c, models.AdminCalibrateQuestions, "", "", map[string]interface{}{"calibrated": report.Calibrated, "drifted": len(report.Drifted)})
+	c.JSON(200, report)
+}
+
+// connectionStats is a connection's send queue health, with the player's
+// name so organizers can tell who is lagging.
+type connectionStats struct {
+	hub.ClientSendStats
+	Username string `json:"username,omitempty"`
+}
+
+type connectionsResponse struct {
+	Connections []connectionStats `json:"connections"`
+}
+
+// getConnectionStats lists every connection's send queue, most backed up
+// first. ?lobby_id= narrows it to one lobby and ?degraded=true to the
+// connections currently flagged as degraded.
+func (s *Server) getConnectionStats(c *gin.Context) {
+	lobbyFilter := c.Query("lobby_id")
+	degradedOnly := c.Query("degraded") == "true"
+
+	connections := make([]connectionStats, 0)
+	for id, lobbyHub := range s.hub.GetAllLobbies() {
+		if lobbyFilter != "" && id != lobbyFilter {
+			continue
+		}
+		stats := lobbyHub.SendStats()
+		lobby := lobbyHub.GetLobby()
+		lobby.Lock()
+		for _, stat := range stats {
+			if degradedOnly && !stat.Degraded {
+				continue
+			}
+			entry := connectionStats{ClientSendStats: stat}
+			if player := lobby.GetPlayer(stat.PlayerID); player != nil {
+				entry.Username = player.Username
+			}
+			connections = append(connections, entry)
+		}
+		lobby.Unlock()
+	}
+	sort.Slice(connections, func(i, j int) bool {
+		if connections[i].QueueDepth != connections[j].QueueDepth {
+			return connections[i].QueueDepth > connections[j].QueueDepth
+		}
+		return connections[i].LastWriteLatencyMs > connections[j].LastWriteLatencyMs
+	})
+	c.JSON(200, connectionsResponse{connections})
+}
+
+func (s *Server) lintQuestionBank(c *gin.Context) {
+	c.JSON(200, s.gameService.LintQuestionBank())
+}
+
+type lintRequest struct {
+	Questions []models.Question `json:"questions" binding:"required"`
+}
+
+func (s *Server) lintQuestions(c *gin.Context) {
+	var req lintRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, s.gameService.LintQuestions(req.Questions))
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"errors"
+	"time"
+
+	"buildprize-game/internal/api"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyHeader carries an API key, on admin requests and lobby creations
+// that register a webhook.
+const apiKeyHeader = "X-API-Key"
+
+// apiKeyContextKey is where verifyAPIKey leaves the request's key.
+const apiKeyContextKey = "api_key"
+
+func apiKeyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrAPIKeyNotFound):
+		return 404
+	case errors.Is(err, services.ErrAPIKeyRevoked):
+		return 409
+	case errors.Is(err, services.ErrInvalidAPIKeyRequest):
+		return 400
+	}
+	return 500
+}
+
+// verifyAPIKey attaches the key the request was sent with, or aborts it
+// when the key isn't valid.
+func (s *Server) verifyAPIKey(c *gin.Context) (*models.APIKey, bool) {
+	key, err := s.gameService.VerifyAPIKey(c.GetHeader(apiKeyHeader))
+	if err != nil {
+		status := 500
+		if errors.Is(err, services.ErrInvalidAPIKey) {
+			status = 401
+		}
+		c.AbortWithStatusJSON(status, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	c.Set(apiKeyContextKey, key)
+	return key, true
+}
+
+// currentAPIKey returns the key the request authenticated with, or nil.
+func currentAPIKey(c *gin.Context) *models.APIKey {
+	if key, ok := c.Get(apiKeyContextKey); ok {
+		return key.(*models.APIKey)
+	}
+	return nil
+}
+
+// requireScope refuses requests made with an API key that wasn't issued
+// scope. The admin token has every scope.
+func requireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key := currentAPIKey(c); key != nil && !key.HasScope(scope) {
+			c.AbortWithStatusJSON(403, gin.H{"error": "API key lacks the " + scope + " scope"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// requireAdminScope lets keys with admin:read make GET requests to the
+// admin API; everything else needs admin:write.
+func requireAdminScope(c *gin.Context) {
+	scope := models.ScopeAdminWrite
+	if c.Request.Method == "GET" || c.Request.Method == "HEAD" {
+		scope = models.ScopeAdminRead
+	}
+	requireScope(scope)(c)
+}
+
+// requireKeyWithScope checks the request carries a valid API key with
+// scope, for public routes where part of a request needs one.
+func (s *Server) requireKeyWithScope(c *gin.Context, scope, what string) bool {
+	if c.GetHeader(apiKeyHeader) == "" {
+		c.JSON(401, gin.H{"error": what + " needs an API key with the " + scope + " scope"})
+		return false
+	}
+	key, ok := s.verifyAPIKey(c)
+	if !ok {
+		return false
+	}
+	if !key.HasScope(scope) {
+		c.JSON(403, gin.H{"error": "API key lacks the " + scope + " scope"})
+		return false
+	}
+	return true
+}
+
+type apiKeysResponse struct {
+	APIKeys []*api.APIKey `json:"api_keys"`
+}
+
+func (s *Server) listAPIKeys(c *gin.Context) {
+	keys, err := s.gameService.APIKeys()
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	views := make([]*api.APIKey, len(keys))
+	for i, key := range keys {
+		views[i] = api.FromAPIKey(key)
+	}
+	c.JSON(200, apiKeysResponse{views})
+}
+
+// issuedAPIKey is a key as it's issued or rotated: the only time the key
+// itself is shown.
+type issuedAPIKey struct {
+	*api.APIKey
+	Key string `json:"key"`
+}
+
+type createAPIKeyRequest struct {
+	Name      string   `json:"name" binding:"required"`
+	Scopes    []string `json:"scopes" binding:"required"`
+	CreatedBy string   `json:"created_by"`
+}
+
+func (s *Server) createAPIKey(c *gin.Context) {
+	var req createAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	createdBy := auditActor(c, req.CreatedBy)
+	key, token, err := s.gameService.CreateAPIKey(req.Name, req.Scopes, createdBy)
+	if err != nil {
+		c.JSON(apiKeyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	s.recordAdminAction(c, models.AdminCreateAPIKey, key.ID, req.CreatedBy, map[string]interface{}{"name": key.Name, "scopes": key.Scopes})
+	c.JSON(201, issuedAPIKey{api.FromAPIKey(key), token})
+}
+
+type rotateAPIKeyRequest struct {
+	GraceMinutes int `json:"grace_minutes"`
+}
+
+// rotateAPIKey gives a key a new secret. The old one keeps working for
+// grace_minutes (default 0, at most a week) so callers can switch over.
+func (s *Server) rotateAPIKey(c *gin.Context) {
+	var req rotateAPIKeyRequest
+	// The body is optional
+	c.ShouldBindJSON(&req)
+
+	key, token, err := s.gameService.RotateAPIKey(c.Param("id"), time.Duration(req.GraceMinutes)*time.Minute)
+	if err != nil {
+		c.JSON(apiKeyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	s.recordAdminAction(c, models.AdminRotateAPIKey, key.ID, "", map[string]interface{}{"name": key.Name, "grace_minutes": req.GraceMinutes})
+	c.JSON(200, issuedAPIKey{api.FromAPIKey(key), token})
+}
+
+func (s *Server) revokeAPIKey(c *gin.Context) {
+	key, err := s.gameService.RevokeAPIKey(c.Param("id"))
+	if err != nil {
+		c.JSON(apiKeyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	s.recordAdminAction(c, models.AdminRevokeAPIKey, key.ID, "", map[string]interface{}{"name": key.Name})
+	c.JSON(200, api.FromAPIKey(key))
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"errors"
+	"log"
+
+	"buildprize-game/internal/auth"
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func audienceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrLobbyNotFound), errors.Is(err, services.ErrPlayerNotFound):
+		return 404
+	case errors.Is(err, services.ErrLobbyFull), errors.Is(err, services.ErrAlreadyAnswered), errors.Is(err, services.ErrAudienceNameTaken):
+		return 409
+	}
+	return 400
+}
+
+type joinAudienceRequest struct {
+	Username     string `json:"username" binding:"required"`
+	SessionToken string `json:"session_token"` // a member rejoining
+}
+
+// audienceJoinResponse is the member with the session token its answers
+// need.
+type audienceJoinResponse struct {
+	*models.AudienceMember
+	SessionToken string `json:"session_token"`
+}
+
+type audienceAnswerRequest struct {
+	MemberID string      `json:"member_id" binding:"required"`
+	Answer   interface{} `json:"answer"`
+}
+
+// joinAudience adds an audience member to a lobby. Members get their own ID
+// and a session token to answer with; they aren't lobby players. A member
+// rejoining passes the token instead of taking a new name.
+func (s *Server) joinAudience(c *gin.Context) {
+	var req joinAudienceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	lobbyID := c.Param("id")
+	member, err := s.audienceMember(lobbyID, req.Username, req.SessionToken)
+	if errors.Is(err, auth.ErrInvalidSession) {
+		c.JSON(401, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(audienceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, audienceJoinResponse{member, s.sessions.Issue(lobbyID, member.ID)})
+}
+
+// audienceMember joins username to the lobby's audience, or with a session
+// token returns the member it was issued for.
+func (s *Server) audienceMember(lobbyID, username, sessionToken string) (*models.AudienceMember, error) {
+	if sessionToken == "" {
+		return s.gameService.JoinAudience(lobbyID, username)
+	}
+	memberID, err := s.sessions.Verify(sessionToken, lobbyID)
+	if err != nil {
+		return nil, err
+	}
+	return s.gameService.RejoinAudience(lobbyID, memberID)
+}
+
+func (s *Server) submitAudienceAnswer(c *gin.Context) {
+	var req audienceAnswerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	answer, err := models.ParseSubmittedAnswer(req.Answer)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !s.checkSession(c, c.Param("id"), req.MemberID) {
+		return
+	}
+	result, err := s.gameService.SubmitAudienceAnswer(c.Param("id"), req.MemberID, answer)
+	if err != nil {
+		c.JSON(audienceErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, result)
+}
+
+// handleSubmitAudienceAnswer answers for the audience member this connection
+// joined as. Only the member hears back, as audience_answer_received.
+func (s *Server) handleSubmitAudienceAnswer(client *hub.Client, msg *WebSocketMessage) {
+	lobbyID := msg.LobbyID
+	if lobbyID == "" {
+		lobbyID = client.LobbyID
+	}
+	data, ok := msg.Data.(map[string]interface{})
+	if lobbyID == "" || client.PlayerID == "" || client.Hub == nil || !ok {
+		return
+	}
+
+	answer, err := models.ParseSubmittedAnswer(data["answer"])
+	if err != nil {
+		return
+	}
+	result, err := s.gameService.SubmitAudienceAnswer(lobbyID, client.PlayerID, answer)
+	if err != nil {
+		log.Printf("handleSubmitAudienceAnswer: Answer from client %s in lobby %s rejected: %v", client.ID, lobbyID, err)
+		return
+	}
+	client.Hub.SendTo(client, &models.GameEvent{
+		Type:    "audience_answer_received",
+		LobbyID: lobbyID,
+		Data:    result,
+	})
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"crypto/rand"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"buildprize-game/internal/api"
+	"buildprize-game/internal/auth"
+	"buildprize-game/internal/config"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userKey is where authenticate leaves the logged-in account in the request
+// context.
+const userKey = "user"
+
+// sessionHeader carries the session token a join returned, on REST calls
+// that act as that player.
+const sessionHeader = "X-Session-Token"
+
+// authSecret is the key login and session tokens are signed with.
+func authSecret(cfg *config.Config) []byte {
+	secret := []byte(cfg.AuthTokenSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.Fatalf("Failed to generate auth token secret: %v", err)
+		}
+		log.Printf("AUTH_TOKEN_SECRET is not set; players are logged out and lose their seats on restart")
+	}
+	return secret
+}
+
+func newTokenIssuer(cfg *config.Config, secret []byte) *auth.Issuer {
+	ttl := time.Duration(cfg.AuthTokenTTLHours) * time.Hour
+	if ttl <= 0 {
+		ttl = 7 * 24 * time.Hour
+	}
+	return auth.NewIssuer(secret, ttl)
+}
+
+func accountErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrUsernameTaken):
+		return 409
+	case errors.Is(err, services.ErrInvalidCredentials), errors.Is(err, services.ErrUserNotFound):
+		return 401
+	case errors.Is(err, services.ErrInvalidPassword), errors.Is(err, services.ErrUsernameEmpty),
+		errors.Is(err, services.ErrUsernameTooLong), errors.Is(err, services.ErrUsernameInvisible),
+		errors.Is(err, services.ErrUsernameMixedScripts):
+		return 400
+	}
+	return 500
+}
+
+// authenticate attaches the account of a request sent with an
+// "Authorization: Bearer <token>" header. Requests without one carry on as
+// guests; a bad or expired token is refused rather than ignored, so the
+// client can log in again.
+func (s *Server) authenticate(c *gin.Context) {
+	header := c.GetHeader("Authorization")
+	if header == "" {
+		c.Next()
+		return
+	}
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok {
+		c.AbortWithStatusJSON(401, gin.H{"error": "Authorization must be a Bearer token"})
+		return
+	}
+	user, err := s.verifyToken(token)
+	if err != nil {
+		c.AbortWithStatusJSON(401, gin.H{"error": err.Error()})
+		return
+	}
+	c.Set(userKey, user)
+	c.Next()
+}
+
+// verifyToken returns the account a login token was issued to, as long as
+// it still exists.
+func (s *Server) verifyToken(token string) (*models.User, error) {
+	claims, err := s.tokens.Verify(token, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.gameService.User(claims.Subject)
+	if errors.Is(err, services.ErrUserNotFound) {
+		return nil, auth.ErrInvalidToken
+	}
+	return user, err
+}
+
+// currentUser returns the account the request authenticated as, or nil for
+// guests.
+func currentUser(c *gin.Context) *models.User {
+	if user, ok := c.Get(userKey); ok {
+		return user.(*models.User)
+	}
+	return nil
+}
+
+type credentials struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// tokenResponse is a login: the account and the bearer token to send with
+// its requests.
+type tokenResponse struct {
+	User      *api.User `json:"user"`
+	Token     string    `json:"token"`
+	ExpiresAt string    `json:"expires_at"`
+}
+
+func (s *Server) register(c *gin.Context) {
+	var req credentials
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := s.gameService.Register(req.Username, req.Password)
+	if err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	s.issueToken(c, 201, user)
+}
+
+func (s *Server) login(c *gin.Context) {
+	var req credentials
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := s.gameService.Login(req.Username, req.Password)
+	if err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	s.issueToken(c, 200, user)
+}
+
+func (s *Server) issueToken(c *gin.Context, status int, user *models.User) {
+	token, expiresAt, err := s.tokens.Issue(user.ID, user.Username, time.Now())
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(status, tokenResponse{api.FromUser(user), token, models.FormatTimestamp(expiresAt)})
+}
+
+// holdsSeat reports whether a session token was issued for playerID's seat
+// in the lobby.
+func (s *Server) holdsSeat(sessionToken, lobbyID, playerID string) bool {
+	sessionPlayer, err := s.sessions.Verify(sessionToken, lobbyID)
+	return err == nil && sessionPlayer == playerID
+}
+
+// checkSession refuses a request acting as playerID unless it carries that
+// player's session token for the lobby, answering it with 401.
+func (s *Server) checkSession(c *gin.Context, lobbyID, playerID string) bool {
+	if !s.holdsSeat(c.GetHeader(sessionHeader), lobbyID, playerID) {
+		c.JSON(401, gin.H{"error": auth.ErrInvalidSession.Error()})
+		return false
+	}
+	return true
+}
+
+// getCurrentUser returns the account the request's token belongs to.
+func (s *Server) getCurrentUser(c *gin.Context) {
+	user := currentUser(c)
+	if user == nil {
+		c.JSON(401, gin.H{"error": "not logged in"})
+		return
+	}
+	c.JSON(200, api.FromUser(user))
+}
@@ -0,0 +1,190 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/models"
+)
+
+// Knowing the host's player ID isn't enough to act as them: every route
+// taking a player_id refuses another player's session token, or none.
+func TestPlayerRoutesRequireSession(t *testing.T) {
+	s := newTestServer(t, nil)
+	lobbyID := createTestLobby(t, s, map[string]interface{}{"max_rounds": 3})
+	hostID, hostToken := joinTestLobby(t, s, lobbyID, "host")
+	guestID, guestToken := joinTestLobby(t, s, lobbyID, "guest")
+	if resp := serve(s, "POST", "/api/v1/lobbies/"+lobbyID+"/start", nil, nil); resp.Code != 200 {
+		t.Fatalf("Start game: %d %s", resp.Code, resp.Body)
+	}
+
+	lobbyPath := "/api/v1/lobbies/" + lobbyID
+	routes := []struct {
+		path string
+		body map[string]interface{}
+	}{
+		{"/pause", nil},
+		{"/resume", nil},
+		{"/end", nil},
+		{"/cancel", nil},
+		{"/leave", nil},
+		{"/answer", map[string]interface{}{"answer": 0}},
+		{"/warmup-answer", map[string]interface{}{"answer": 0}},
+		{"/chat", map[string]interface{}{"message": "hello"}},
+		{"/mute", map[string]interface{}{"target_id": guestID, "scope": "lobby"}},
+		{"/unmute", map[string]interface{}{"target_id": guestID, "scope": "lobby"}},
+		{"/report", map[string]interface{}{"target_id": guestID, "reason": "spam"}},
+		{"/polls", map[string]interface{}{"question": "Next?", "options": []string{"Sports", "Music"}}},
+		{"/polls/any/vote", map[string]interface{}{"option": 0}},
+		{"/disputes", map[string]interface{}{"reason": "unfair"}},
+	}
+	for _, route := range routes {
+		body := map[string]interface{}{"player_id": hostID}
+		for key, value := range route.body {
+			body[key] = value
+		}
+		for name, header := range map[string]http.Header{
+			"no token":         nil,
+			"guest's token":    sessionHeaders(guestToken),
+			"forged token":     sessionHeaders(hostID + ".forged"),
+			"other lobby seat": sessionHeaders(s.sessions.Issue("another-lobby", hostID)),
+		} {
+			if resp := serve(s, "POST", lobbyPath+route.path, body, header); resp.Code != 401 {
+				t.Errorf("POST %s as the host with %s: got %d %s, want 401", route.path, name, resp.Code, resp.Body)
+			}
+		}
+	}
+
+	// With the host's own token the same requests go through
+	if resp := serve(s, "POST", lobbyPath+"/pause", map[string]string{"player_id": hostID}, sessionHeaders(hostToken)); resp.Code != 200 {
+		t.Fatalf("Pause as the host: %d %s", resp.Code, resp.Body)
+	}
+	if resp := serve(s, "POST", lobbyPath+"/resume", map[string]string{"player_id": hostID}, sessionHeaders(hostToken)); resp.Code != 200 {
+		t.Fatalf("Resume as the host: %d %s", resp.Code, resp.Body)
+	}
+	mute := map[string]string{"player_id": hostID, "target_id": guestID, "scope": "lobby"}
+	if resp := serve(s, "POST", lobbyPath+"/mute", mute, sessionHeaders(hostToken)); resp.Code != 200 {
+		t.Fatalf("Mute as the host: %d %s", resp.Code, resp.Body)
+	}
+	// The guest's own token doesn't make them the host
+	if resp := serve(s, "POST", lobbyPath+"/pause", map[string]string{"player_id": guestID}, sessionHeaders(guestToken)); resp.Code != 403 {
+		t.Fatalf("Pause as the guest: got %d %s, want 403", resp.Code, resp.Body)
+	}
+}
+
+// An account's profile shows its stats and streaks, over REST and GraphQL.
+func TestPlayerProfile(t *testing.T) {
+	s := newTestServer(t, nil)
+	resp := serve(s, "POST", "/api/v1/auth/register", credentials{"dana", "correct horse battery"}, nil)
+	var token tokenResponse
+	if err := json.Unmarshal(resp.Body.Bytes(), &token); err != nil || resp.Code != 201 {
+		t.Fatalf("Register: %d %s", resp.Code, resp.Body)
+	}
+	userID := token.User.ID
+	today := models.Now().UTC().Format(time.DateOnly)
+	s.gameService.GetRepository().SaveAccountStreak(&models.AccountStreak{UserID: userID, DayStreak: 4, BestDayStreak: 6,
+		WinStreak: 2, BestWinStreak: 3, LastPlayedOn: today, XP: 120, Coins: 40})
+
+	resp = serve(s, "GET", "/api/v1/players/"+userID+"/profile", nil, nil)
+	var profile struct {
+		ID       string
+		Username string
+		Stats    struct {
+			GamesPlayed int `json:"games_played"`
+		}
+		Streak struct {
+			DayStreak     int    `json:"day_streak"`
+			BestDayStreak int    `json:"best_day_streak"`
+			WinStreak     int    `json:"win_streak"`
+			LastPlayedOn  string `json:"last_played_on"`
+			XP            int64
+			Coins         int64
+		}
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &profile); err != nil || resp.Code != 200 {
+		t.Fatalf("Profile: %d %s", resp.Code, resp.Body)
+	}
+	if profile.ID != userID || profile.Username != "dana" || profile.Stats.GamesPlayed != 0 {
+		t.Fatalf("Unexpected profile: %s", resp.Body)
+	}
+	if streak := profile.Streak; streak.DayStreak != 4 || streak.BestDayStreak != 6 || streak.WinStreak != 2 ||
+		streak.LastPlayedOn != today || streak.XP != 120 || streak.Coins != 40 {
+		t.Fatalf("Unexpected streak: %s", resp.Body)
+	}
+	if resp := serve(s, "GET", "/api/v1/players/missing/profile", nil, nil); resp.Code != 404 {
+		t.Fatalf("Profile of an unknown account: got %d, want 404", resp.Code)
+	}
+
+	var data struct {
+		Account struct {
+			Streak struct {
+				DayStreak     int
+				BestWinStreak int
+				LastPlayedOn  string
+				XP            float64
+			}
+		}
+	}
+	runGraphQL(t, s, `query($id: ID!) { account(id: $id) { streak { dayStreak bestWinStreak lastPlayedOn xp } } }`,
+		map[string]interface{}{"id": userID}, &data)
+	if streak := data.Account.Streak; streak.DayStreak != 4 || streak.BestWinStreak != 3 || streak.LastPlayedOn != today || streak.XP != 120 {
+		t.Fatalf("Unexpected GraphQL streak: %+v", streak)
+	}
+}
+
+// An audience member answers and rejoins with its session token; taking its
+// username again doesn't hand over its member ID.
+func TestAudienceRequiresSession(t *testing.T) {
+	s := newTestServer(t, nil)
+	lobbyID := createTestLobby(t, s, map[string]interface{}{"max_rounds": 1, "audience": true})
+	joinTestLobby(t, s, lobbyID, "star")
+	joinTestLobby(t, s, lobbyID, "rival")
+	audiencePath := "/api/v1/lobbies/" + lobbyID + "/audience"
+
+	resp := serve(s, "POST", audiencePath, map[string]string{"username": "fan"}, nil)
+	var member struct {
+		ID           string `json:"id"`
+		SessionToken string `json:"session_token"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &member); err != nil || resp.Code != 200 || member.SessionToken == "" {
+		t.Fatalf("Join the audience: %d %s", resp.Code, resp.Body)
+	}
+	if resp := serve(s, "POST", audiencePath, map[string]string{"username": "fan"}, nil); resp.Code != 409 || strings.Contains(resp.Body.String(), member.ID) {
+		t.Fatalf("Join as a taken audience name: got %d %s, want 409", resp.Code, resp.Body)
+	}
+	rejoin := map[string]string{"username": "fan", "session_token": member.SessionToken}
+	if resp := serve(s, "POST", audiencePath, rejoin, nil); resp.Code != 200 || !strings.Contains(resp.Body.String(), member.ID) {
+		t.Fatalf("Rejoin with the session token: %d %s", resp.Code, resp.Body)
+	}
+	rejoin["session_token"] = member.ID + ".forged"
+	if resp := serve(s, "POST", audiencePath, rejoin, nil); resp.Code != 401 {
+		t.Fatalf("Rejoin with a forged token: got %d, want 401", resp.Code)
+	}
+
+	if resp := serve(s, "POST", "/api/v1/lobbies/"+lobbyID+"/start", nil, nil); resp.Code != 200 {
+		t.Fatalf("Start game: %d %s", resp.Code, resp.Body)
+	}
+	lobby := s.hub.GetLobbyHub(lobbyID).GetLobby()
+	lobby.Lock()
+	question := *lobby.CurrentQ
+	lobby.Unlock()
+	var choice interface{} = question.Correct
+	switch question.QuestionType() {
+	case models.FreeText:
+		choice = question.AcceptedAnswers[0]
+	case models.MultiSelect:
+		choice = question.CorrectAnswers
+	case models.Numeric:
+		choice = question.NumericAnswer
+	}
+	answer := map[string]interface{}{"member_id": member.ID, "answer": choice}
+	if resp := serve(s, "POST", audiencePath+"/answer", answer, nil); resp.Code != 401 {
+		t.Fatalf("Answer without the session token: got %d, want 401", resp.Code)
+	}
+	if resp := serve(s, "POST", audiencePath+"/answer", answer, sessionHeaders(member.SessionToken)); resp.Code != 200 {
+		t.Fatalf("Answer with the session token: %d %s", resp.Code, resp.Body)
+	}
+}
@@ -0,0 +1,298 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/bits"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"buildprize-game/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	challengePoW     = "pow"
+	challengeCaptcha = "captcha"
+
+	powChallengeTTL = 5 * time.Minute
+)
+
+var (
+	errChallengeRequired = errors.New("challenge required")
+	errChallengeInvalid  = errors.New("invalid or expired challenge")
+	errChallengeUsed     = errors.New("challenge already used")
+	errCaptchaFailed     = errors.New("captcha verification failed")
+)
+
+// challengeSolution is what a client sends back: a signed proof-of-work
+// challenge with its nonce, or a captcha token.
+type challengeSolution struct {
+	Challenge    string
+	Nonce        string
+	CaptchaToken string
+	RemoteIP     string
+}
+
+// abuseChallenge guards unauthenticated lobby creation and joins on public
+// instances, so scripts can't flood the lobbies table.
+type abuseChallenge struct {
+	mode       string
+	difficulty int
+	captchaURL string
+	client     *http.Client
+
+	mu             sync.Mutex
+	secret         []byte
+	previousSecret []byte // still accepted after a rotation, until previousUntil
+	previousUntil  time.Time
+	captchaSecret  string
+	used           map[string]time.Time // solved proof-of-work challenges, until they expire
+}
+
+// newAbuseChallenge returns nil when CHALLENGE_MODE is unset.
+func newAbuseChallenge(cfg *config.Config) *abuseChallenge {
+	switch cfg.ChallengeMode {
+	case "":
+		return nil
+	case challengePoW, challengeCaptcha:
+	default:
+		log.Fatalf("Unknown CHALLENGE_MODE %q (expected \"pow\" or \"captcha\")", cfg.ChallengeMode)
+	}
+	if cfg.ChallengeMode == challengeCaptcha && (cfg.CaptchaVerifyURL == "" || cfg.CaptchaSecret == "") {
+		log.Fatal("CHALLENGE_MODE=captcha requires CAPTCHA_VERIFY_URL and CAPTCHA_SECRET")
+	}
+
+	secret := []byte(cfg.ChallengeSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.Fatalf("Failed to generate challenge secret: %v", err)
+		}
+	}
+
+	return &abuseChallenge{
+		mode:          cfg.ChallengeMode,
+		difficulty:    cfg.PowDifficulty,
+		captchaURL:    cfg.CaptchaVerifyURL,
+		client:        &http.Client{Timeout: 10 * time.Second},
+		secret:        secret,
+		captchaSecret: cfg.CaptchaSecret,
+		used:          make(map[string]time.Time),
+	}
+}
+
+// rotateSecret starts signing with a new secret. Challenges signed with the
+// old one stay valid until they would have expired anyway.
+func (a *abuseChallenge) rotateSecret(secret string) {
+	if secret == "" {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.previousSecret = a.secret
+	a.previousUntil = time.Now().Add(powChallengeTTL)
+	a.secret = []byte(secret)
+}
+
+func (a *abuseChallenge) setCaptchaSecret(secret string) {
+	a.mu.Lock()
+	a.captchaSecret = secret
+	a.mu.Unlock()
+}
+
+// challengeResponse is a challenge to solve: its mode is "none" when
+// CHALLENGE_MODE is unset, and only proof-of-work challenges carry the rest.
+type challengeResponse struct {
+	Mode       string     `json:"mode"`
+	Challenge  string     `json:"challenge,omitempty"`
+	Difficulty int        `json:"difficulty,omitempty"` // leading zero bits
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// issue describes the challenge a client must solve. Proof-of-work
+// challenges are signed and expire, so the server keeps no state until one
+// is solved.
+func (a *abuseChallenge) issue() challengeResponse {
+	if a.mode == challengeCaptcha {
+		return challengeResponse{Mode: challengeCaptcha}
+	}
+
+	random := make([]byte, 16)
+	rand.Read(random)
+	expires := time.Now().Add(powChallengeTTL).Unix()
+	payload := hex.EncodeToString(random) + "." + strconv.FormatInt(expires, 10)
+
+	expiresAt := time.Unix(expires, 0).UTC()
+	return challengeResponse{
+		Mode:       challengePoW,
+		Challenge:  payload + "." + a.sign(payload),
+		Difficulty: a.difficulty,
+		ExpiresAt:  &expiresAt,
+	}
+}
+
+func (a *abuseChallenge) verify(sol challengeSolution) error {
+	if a.mode == challengeCaptcha {
+		return a.verifyCaptcha(sol)
+	}
+	return a.verifyPoW(sol)
+}
+
+// verifyPoW checks that sha256(challenge + ":" + nonce) starts with the
+// required number of zero bits, and that the challenge is ours, unexpired
+// and unused.
+func (a *abuseChallenge) verifyPoW(sol challengeSolution) error {
+	if sol.Challenge == "" || sol.Nonce == "" {
+		return errChallengeRequired
+	}
+
+	parts := strings.Split(sol.Challenge, ".")
+	if len(parts) != 3 {
+		return errChallengeInvalid
+	}
+	payload := parts[0] + "." + parts[1]
+	if !a.signedByUs(payload, parts[2]) {
+		return errChallengeInvalid
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return errChallengeInvalid
+	}
+
+	hash := sha256.Sum256([]byte(sol.Challenge + ":" + sol.Nonce))
+	if leadingZeroBits(hash[:]) < a.difficulty {
+		return errChallengeInvalid
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := time.Now()
+	for challenge, expiry := range a.used {
+		if now.After(expiry) {
+			delete(a.used, challenge)
+		}
+	}
+	if _, ok := a.used[sol.Challenge]; ok {
+		return errChallengeUsed
+	}
+	a.used[sol.Challenge] = time.Unix(expires, 0)
+	return nil
+}
+
+// verifyCaptcha checks the token with the provider's siteverify endpoint.
+func (a *abuseChallenge) verifyCaptcha(sol challengeSolution) error {
+	if sol.CaptchaToken == "" {
+		return errChallengeRequired
+	}
+
+	a.mu.Lock()
+	captchaSecret := a.captchaSecret
+	a.mu.Unlock()
+
+	form := url.Values{
+		"secret":   {captchaSecret},
+		"response": {sol.CaptchaToken},
+	}
+	if sol.RemoteIP != "" {
+		form.Set("remoteip", sol.RemoteIP)
+	}
+	resp, err := a.client.PostForm(a.captchaURL, form)
+	if err != nil {
+		return fmt.Errorf("captcha verification request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("captcha verification response invalid: %w", err)
+	}
+	if !result.Success {
+		return errCaptchaFailed
+	}
+	return nil
+}
+
+func (a *abuseChallenge) sign(payload string) string {
+	a.mu.Lock()
+	secret := a.secret
+	a.mu.Unlock()
+	return signWith(secret, payload)
+}
+
+// signedByUs checks the signature against the current secret and, shortly
+// after a rotation, the previous one.
+func (a *abuseChallenge) signedByUs(payload, signature string) bool {
+	a.mu.Lock()
+	secret, previous := a.secret, a.previousSecret
+	if time.Now().After(a.previousUntil) {
+		previous = nil
+	}
+	a.mu.Unlock()
+
+	if hmac.Equal([]byte(signature), []byte(signWith(secret, payload))) {
+		return true
+	}
+	return previous != nil && hmac.Equal([]byte(signature), []byte(signWith(previous, payload)))
+}
+
+func signWith(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func leadingZeroBits(hash []byte) int {
+	count := 0
+	for _, b := range hash {
+		if b != 0 {
+			return count + bits.LeadingZeros8(b)
+		}
+		count += 8
+	}
+	return count
+}
+
+func (s *Server) getChallenge(c *gin.Context) {
+	if s.challenge == nil {
+		c.JSON(200, challengeResponse{Mode: "none"})
+		return
+	}
+	c.JSON(200, s.challenge.issue())
+}
+
+// requireChallenge rejects lobby creation and joins that don't carry a solved
+// challenge, when CHALLENGE_MODE is set.
+func (s *Server) requireChallenge(c *gin.Context) {
+	if s.challenge == nil {
+		c.Next()
+		return
+	}
+
+	err := s.challenge.verify(challengeSolution{
+		Challenge:    c.GetHeader("X-Challenge"),
+		Nonce:        c.GetHeader("X-Challenge-Nonce"),
+		CaptchaToken: c.GetHeader("X-Captcha-Token"),
+		RemoteIP:     c.ClientIP(),
+	})
+	if err != nil {
+		log.Printf("Challenge rejected for %s %s from %s: %v", c.Request.Method, c.Request.URL.Path, c.ClientIP(), err)
+		c.AbortWithStatusJSON(403, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Next()
+}
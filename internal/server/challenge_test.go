@@ -0,0 +1,155 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/config"
+)
+
+func newPoWChallenge(difficulty int) *abuseChallenge {
+	return newAbuseChallenge(&config.Config{ChallengeMode: challengePoW, PowDifficulty: difficulty, ChallengeSecret: "challenge secret"})
+}
+
+// solve finds a nonce whose hash with the challenge has at least min and
+// fewer than max leading zero bits.
+func solve(t *testing.T, challenge string, min, max int) string {
+	t.Helper()
+	for i := 0; i < 1<<24; i++ {
+		nonce := strconv.Itoa(i)
+		hash := sha256.Sum256([]byte(challenge + ":" + nonce))
+		if zeros := leadingZeroBits(hash[:]); zeros >= min && zeros < max {
+			return nonce
+		}
+	}
+	t.Fatalf("No nonce found for %d to %d zero bits", min, max)
+	return ""
+}
+
+func TestProofOfWorkChallenge(t *testing.T) {
+	challenge := newPoWChallenge(10)
+	issued := challenge.issue()
+	if issued.Mode != challengePoW || issued.Difficulty != 10 || issued.ExpiresAt == nil {
+		t.Fatalf("Unexpected challenge: %+v", issued)
+	}
+	if err := challenge.verify(challengeSolution{}); err != errChallengeRequired {
+		t.Fatalf("Expected errChallengeRequired without a solution, got %v", err)
+	}
+
+	nonce := solve(t, issued.Challenge, 10, 257)
+	if err := challenge.verify(challengeSolution{Challenge: issued.Challenge, Nonce: nonce}); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	// A solved challenge is good for one request
+	if err := challenge.verify(challengeSolution{Challenge: issued.Challenge, Nonce: nonce}); err != errChallengeUsed {
+		t.Fatalf("Expected errChallengeUsed on replay, got %v", err)
+	}
+}
+
+// Hashes short of the required zero bits are refused, including solutions
+// to a challenge issued while the difficulty was lower.
+func TestProofOfWorkDifficulty(t *testing.T) {
+	easy := newPoWChallenge(4)
+	hard := newPoWChallenge(12)
+
+	issued := easy.issue()
+	nonce := solve(t, issued.Challenge, 4, 12)
+	if err := hard.verify(challengeSolution{Challenge: issued.Challenge, Nonce: nonce}); err != errChallengeInvalid {
+		t.Fatalf("Expected errChallengeInvalid for work done at a lower difficulty, got %v", err)
+	}
+	issued = hard.issue()
+	if err := hard.verify(challengeSolution{Challenge: issued.Challenge, Nonce: solve(t, issued.Challenge, 0, 12)}); err != errChallengeInvalid {
+		t.Fatalf("Expected errChallengeInvalid for too little work, got %v", err)
+	}
+	if err := hard.verify(challengeSolution{Challenge: issued.Challenge, Nonce: solve(t, issued.Challenge, 12, 257)}); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+}
+
+func TestProofOfWorkExpiryAndSignature(t *testing.T) {
+	challenge := newPoWChallenge(1)
+
+	// Signed by us, but past its expiry
+	payload := "00112233445566778899aabbccddeeff." + strconv.FormatInt(time.Now().Add(-time.Second).Unix(), 10)
+	expired := payload + "." + challenge.sign(payload)
+	if err := challenge.verify(challengeSolution{Challenge: expired, Nonce: solve(t, expired, 1, 257)}); err != errChallengeInvalid {
+		t.Fatalf("Expected errChallengeInvalid for an expired challenge, got %v", err)
+	}
+
+	// An expiry pushed into the future breaks the signature
+	issued := challenge.issue()
+	payload = "00112233445566778899aabbccddeeff." + strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	signature := issued.Challenge[len(issued.Challenge)-64:]
+	forged := payload + "." + signature
+	if err := challenge.verify(challengeSolution{Challenge: forged, Nonce: solve(t, forged, 1, 257)}); err != errChallengeInvalid {
+		t.Fatalf("Expected errChallengeInvalid for a forged challenge, got %v", err)
+	}
+	foreign := newAbuseChallenge(&config.Config{ChallengeMode: challengePoW, PowDifficulty: 1, ChallengeSecret: "another secret"}).issue()
+	if err := challenge.verify(challengeSolution{Challenge: foreign.Challenge, Nonce: solve(t, foreign.Challenge, 1, 257)}); err != errChallengeInvalid {
+		t.Fatalf("Expected errChallengeInvalid for another server's challenge, got %v", err)
+	}
+
+	// Challenges signed before a secret rotation stay valid
+	issued = challenge.issue()
+	challenge.rotateSecret("rotated secret")
+	if err := challenge.verify(challengeSolution{Challenge: issued.Challenge, Nonce: solve(t, issued.Challenge, 1, 257)}); err != nil {
+		t.Fatalf("verify after rotation: %v", err)
+	}
+}
+
+func TestCaptchaChallenge(t *testing.T) {
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		success := r.Form.Get("secret") == "captcha secret" && r.Form.Get("response") == "good" && r.Form.Get("remoteip") == "192.0.2.1"
+		json.NewEncoder(w).Encode(map[string]bool{"success": success})
+	}))
+	defer provider.Close()
+
+	challenge := newAbuseChallenge(&config.Config{ChallengeMode: challengeCaptcha, CaptchaVerifyURL: provider.URL, CaptchaSecret: "captcha secret"})
+	if issued := challenge.issue(); issued.Mode != challengeCaptcha || issued.Challenge != "" {
+		t.Fatalf("Unexpected captcha challenge: %+v", issued)
+	}
+	if err := challenge.verify(challengeSolution{RemoteIP: "192.0.2.1"}); err != errChallengeRequired {
+		t.Fatalf("Expected errChallengeRequired without a token, got %v", err)
+	}
+	if err := challenge.verify(challengeSolution{CaptchaToken: "bad", RemoteIP: "192.0.2.1"}); err != errCaptchaFailed {
+		t.Fatalf("Expected errCaptchaFailed, got %v", err)
+	}
+	if err := challenge.verify(challengeSolution{CaptchaToken: "good", RemoteIP: "192.0.2.1"}); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	challenge.setCaptchaSecret("rotated")
+	if err := challenge.verify(challengeSolution{CaptchaToken: "good", RemoteIP: "192.0.2.1"}); err != errCaptchaFailed {
+		t.Fatalf("Expected the rotated captcha secret to be sent, got %v", err)
+	}
+}
+
+// Lobby creation needs a solved challenge in its headers, once.
+func TestChallengeGatesLobbyCreation(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.ChallengeMode = challengePoW
+		cfg.PowDifficulty = 8
+	})
+	lobby := map[string]string{"name": "Guarded"}
+	if resp := serve(s, "POST", "/api/v1/lobbies", lobby, nil); resp.Code != 403 {
+		t.Fatalf("Create without a challenge: got %d, want 403", resp.Code)
+	}
+
+	resp := serve(s, "GET", "/api/v1/challenge", nil, nil)
+	var issued challengeResponse
+	if err := json.Unmarshal(resp.Body.Bytes(), &issued); err != nil || issued.Mode != challengePoW {
+		t.Fatalf("Challenge: %d %s", resp.Code, resp.Body)
+	}
+	solved := http.Header{"X-Challenge": {issued.Challenge}, "X-Challenge-Nonce": {solve(t, issued.Challenge, 8, 257)}}
+	if resp := serve(s, "POST", "/api/v1/lobbies", lobby, solved); resp.Code != 201 {
+		t.Fatalf("Create with a solved challenge: %d %s", resp.Code, resp.Body)
+	}
+	if resp := serve(s, "POST", "/api/v1/lobbies", lobby, solved); resp.Code != 403 {
+		t.Fatalf("Create replaying a used challenge: got %d, want 403", resp.Code)
+	}
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+)
+
+// lobbyDebugStats is one lobby's footprint in the hub.
+type lobbyDebugStats struct {
+	LobbyID     string `json:"lobby_id"`
+	Phase       string `json:"phase"`
+	Players     int    `json:"players"`
+	Connections int    `json:"connections"`
+	QueuedSends int    `json:"queued_sends"` // messages waiting in client send buffers
+	Dropped     int64  `json:"dropped_sends"`
+	Degraded    int    `json:"degraded_connections"`
+}
+
+// newDebugServer serves pprof and runtime stats on the debug address. It
+// is kept off the public router so profiling is never reachable through the
+// game's port.
+func (s *Server) newDebugServer() *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/stats", s.debugStats)
+
+	return &http.Server{
+		Addr:    s.config.DebugAddr,
+		Handler: mux,
+	}
+}
+
+func (s *Server) debugStats(w http.ResponseWriter, r *http.Request) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	lobbies := []lobbyDebugStats{}
+	for id, lobbyHub := range s.hub.GetAllLobbies() {
+		entry := lobbyDebugStats{LobbyID: id}
+		for _, stats := range lobbyHub.SendStats() {
+			entry.Connections++
+			entry.QueuedSends += stats.QueueDepth
+			entry.Dropped += stats.Dropped
+			if stats.Degraded {
+				entry.Degraded++
+			}
+		}
+		lobby := lobbyHub.GetLobby()
+		lobby.Lock()
+		entry.Phase = string(lobby.Phase)
+		entry.Players = len(lobby.Players)
+		lobby.Unlock()
+		lobbies = append(lobbies, entry)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"goroutines": runtime.NumGoroutine(),
+		"heap": map[string]interface{}{
+			"alloc_bytes":    mem.HeapAlloc,
+			"inuse_bytes":    mem.HeapInuse,
+			"sys_bytes":      mem.HeapSys,
+			"objects":        mem.HeapObjects,
+			"gc_cycles":      mem.NumGC,
+			"gc_pause_total": time.Duration(mem.PauseTotalNs).String(),
+		},
+		"hub":              s.hub.Stats(),
+		"lobbies":          lobbies,
+		"question_sources": s.gameService.QuestionSourceStats(),
+		"uptime_seconds":   int64(time.Since(s.startedAt).Seconds()),
+	})
+}
+
+// startDebugServer runs the debug server in the background.
+func (s *Server) startDebugServer() *http.Server {
+	srv := s.newDebugServer()
+	log.Printf("Debug endpoints (pprof, /debug/stats) listening on %s", srv.Addr)
+	startSideServer("Debug", srv)
+	return srv
+}
+
+// stopSideServer shuts down a listener started next to the game's, such as
+// the debug server. srv is nil when it wasn't configured.
+func stopSideServer(ctx context.Context, name string, srv *http.Server) {
+	if srv == nil {
+		return
+	}
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Failed to stop %s server: %v", name, err)
+	}
+}
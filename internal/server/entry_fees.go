@@ -0,0 +1,56 @@
+package server
+
+import (
+	"errors"
+
+	"buildprize-game/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// joinErrorStatus is 402 for joins refused for want of a valid entry
+// payment, 502 when the payment provider couldn't be asked, and 400 for any
+// other refused join.
+func joinErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrEntryFeeRequired), errors.Is(err, services.ErrPaymentNotComplete),
+		errors.Is(err, services.ErrPaymentMismatch), errors.Is(err, services.ErrPaymentUsed):
+		return 402
+	case errors.Is(err, services.ErrPaymentProvider):
+		return 502
+	}
+	return 400
+}
+
+type entryPaymentRequest struct {
+	Username string `json:"username"` // ignored when logged in
+}
+
+// startEntryPayment starts a payment of a lobby's entry fee with the payout
+// provider, for the player to complete and then join with its payment_id.
+func (s *Server) startEntryPayment(c *gin.Context) {
+	var req entryPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if user := currentUser(c); user != nil {
+		req.Username = user.Username
+	}
+
+	payment, err := s.gameService.StartEntryPayment(c.Param("id"), req.Username)
+	switch {
+	case err == nil:
+		c.JSON(201, payment)
+	case errors.Is(err, services.ErrLobbyNotFound):
+		c.JSON(404, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrEntryFeesDisabled):
+		c.JSON(503, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrLobbyFull), errors.Is(err, services.ErrGameInProgress):
+		c.JSON(409, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrPaymentProvider):
+		c.JSON(502, gin.H{"error": err.Error()})
+	default:
+		c.JSON(400, gin.H{"error": err.Error()})
+	}
+}
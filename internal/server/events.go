@@ -0,0 +1,100 @@
+package server
+
+import (
+	"errors"
+	"strconv"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type upcomingEventsResponse struct {
+	Events []*models.Occurrence `json:"events"`
+}
+
+type recurringEventsResponse struct {
+	Events []*models.RecurringEvent `json:"events"`
+}
+
+type recurringEventRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Schedule    string `json:"schedule" binding:"required"` // cron, e.g. "0 20 * * 5"
+	Timezone    string `json:"timezone"`
+	LeadMinutes int    `json:"lead_minutes"`
+	MaxRounds   int    `json:"max_rounds"`
+	MaxPlayers  int    `json:"max_players"`
+	Topic       string `json:"topic"`
+	Language    string `json:"language"`
+	RSVPQuorum  int    `json:"rsvp_quorum"`
+}
+
+// listUpcomingEvents lists recurring events' next starts, soonest first:
+// ?limit= of them (default 20, at most 100).
+func (s *Server) listUpcomingEvents(c *gin.Context) {
+	limit := 20
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 100 {
+			c.JSON(400, gin.H{"error": "limit must be between 1 and 100"})
+			return
+		}
+		limit = n
+	}
+
+	events, err := s.gameService.UpcomingEvents(limit)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, upcomingEventsResponse{events})
+}
+
+func (s *Server) listRecurringEvents(c *gin.Context) {
+	events, err := s.gameService.RecurringEvents()
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, recurringEventsResponse{events})
+}
+
+func (s *Server) createRecurringEvent(c *gin.Context) {
+	var req recurringEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, err := s.gameService.CreateRecurringEvent(services.RecurringEventOptions{
+		Name:        req.Name,
+		Schedule:    req.Schedule,
+		Timezone:    req.Timezone,
+		LeadMinutes: req.LeadMinutes,
+		MaxRounds:   req.MaxRounds,
+		MaxPlayers:  req.MaxPlayers,
+		Topic:       req.Topic,
+		Language:    req.Language,
+		RSVPQuorum:  req.RSVPQuorum,
+	})
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	s.recordAdminAction(c, models.AdminCreateEvent, event.ID, "", map[string]interface{}{"name": event.Name, "schedule": event.Schedule, "timezone": event.Timezone})
+	c.JSON(201, event)
+}
+
+func (s *Server) deleteRecurringEvent(c *gin.Context) {
+	err := s.gameService.DeleteRecurringEvent(c.Param("id"))
+	switch {
+	case errors.Is(err, services.ErrEventNotFound):
+		c.JSON(404, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(500, gin.H{"error": err.Error()})
+	default:
+		s.recordAdminAction(c, models.AdminDeleteEvent, c.Param("id"), "", nil)
+		c.JSON(200, messageResponse{"Recurring event deleted"})
+	}
+}
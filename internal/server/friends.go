@@ -0,0 +1,131 @@
+package server
+
+import (
+	"errors"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type friendsResponse struct {
+	Friends []*models.Friend `json:"friends"`
+}
+
+type addFriendRequest struct {
+	Username string `json:"username" binding:"required"`
+}
+
+type inviteFriendRequest struct {
+	LobbyID string `json:"lobby_id" binding:"required"`
+}
+
+func friendErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound), errors.Is(err, services.ErrNotFriends),
+		errors.Is(err, services.ErrLobbyNotFound):
+		return 404
+	case errors.Is(err, services.ErrAlreadyFriends), errors.Is(err, services.ErrFriendRequestSent),
+		errors.Is(err, services.ErrFriendOffline):
+		return 409
+	case errors.Is(err, services.ErrNotInLobby):
+		return 403
+	case errors.Is(err, services.ErrCannotFriendSelf):
+		return 400
+	}
+	return 500
+}
+
+// loggedIn returns the request's account, answering 401 itself for guests.
+func loggedIn(c *gin.Context) *models.User {
+	user := currentUser(c)
+	if user == nil {
+		c.JSON(401, gin.H{"error": "log in to manage friends"})
+	}
+	return user
+}
+
+func (s *Server) listFriends(c *gin.Context) {
+	user := loggedIn(c)
+	if user == nil {
+		return
+	}
+	friends, err := s.gameService.Friends(user.ID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, friendsResponse{friends})
+}
+
+// listOnlineFriends is the presence feed's starting point: friends online
+// now. Changes after that arrive over the WebSocket as friend_online and
+// friend_offline.
+func (s *Server) listOnlineFriends(c *gin.Context) {
+	user := loggedIn(c)
+	if user == nil {
+		return
+	}
+	friends, err := s.gameService.FriendsOnline(user.ID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, friendsResponse{friends})
+}
+
+// addFriend sends a friend request, or accepts the one the other account
+// sent.
+func (s *Server) addFriend(c *gin.Context) {
+	user := loggedIn(c)
+	if user == nil {
+		return
+	}
+	var req addFriendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	friend, err := s.gameService.AddFriend(user.ID, req.Username)
+	if err != nil {
+		c.JSON(friendErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	status := 201
+	if friend.Status == models.FriendAccepted {
+		status = 200
+	}
+	c.JSON(status, friend)
+}
+
+func (s *Server) removeFriend(c *gin.Context) {
+	user := loggedIn(c)
+	if user == nil {
+		return
+	}
+	if err := s.gameService.RemoveFriend(user.ID, c.Param("id")); err != nil {
+		c.JSON(friendErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, messageResponse{"Friend removed"})
+}
+
+func (s *Server) inviteFriend(c *gin.Context) {
+	user := loggedIn(c)
+	if user == nil {
+		return
+	}
+	var req inviteFriendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.gameService.InviteFriend(user.ID, c.Param("id"), req.LobbyID); err != nil {
+		c.JSON(friendErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, messageResponse{"Invitation sent"})
+}
@@ -0,0 +1,496 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"buildprize-game/internal/api"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/repository"
+	"buildprize-game/internal/services"
+
+	"github.com/gin-gonic/gin"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// graphQLMaxDepth bounds how deeply a query may nest, so one request can't
+// fan out over every lobby's players' accounts and stats.
+const graphQLMaxDepth = 8
+
+// maxReplayPage caps the replay events one lobby returns per query.
+const maxReplayPage = 200
+
+// graphQLSchema is read-only: dashboards pick the fields they need from
+// lobbies, their players and leaderboards, accounts' lifetime stats and
+// finished games' replays in one request. Lobbies are found live or
+// stored, and sandbox lobbies aren't shown, as in the public API.
+const graphQLSchema = `
+schema {
+	query: Query
+}
+
+scalar Time
+
+type Query {
+	# A lobby, live or stored; null if there's none
+	lobby(id: ID!): Lobby
+	# The lobby listing, as GET /lobbies. Finished games are state "finished"
+	lobbies(state: String = "waiting", sort: String = "newest", hasSpace: Boolean = false, limit: Int = 50, offset: Int = 0): LobbyPage!
+	# An account; null if there's none
+	account(id: ID!): Account
+}
+
+type LobbyPage {
+	lobbies: [Lobby!]!
+	total: Int!
+}
+
+type Lobby {
+	id: ID!
+	name: String!
+	# waiting, in_progress or finished
+	state: String!
+	phase: String!
+	round: Int!
+	maxRounds: Int!
+	maxPlayers: Int!
+	questionTime: Int!
+	topic: String
+	timezone: String!
+	language: String!
+	prize: String
+	createdAt: Time!
+	startedAt: Time
+	finishedAt: Time
+	startsAt: Time
+	currentQuestion: Question
+	players: [Player!]!
+	# Players by score, highest first
+	leaderboard: [Player!]!
+	# Once the game has finished
+	stats: GameStats
+	# A finished game's events in broadcast order, after afterSeq; null
+	# until the game has finished
+	replay(afterSeq: Int = 0, first: Int = 100): [GameEvent!]
+}
+
+type Player {
+	id: ID!
+	username: String!
+	score: Int!
+	streak: Int!
+	online: Boolean!
+	isBot: Boolean!
+	# The account of a logged-in player
+	account: Account
+}
+
+type Account {
+	id: ID!
+	username: String!
+	createdAt: Time!
+	stats: PlayerStats!
+	streak: AccountStreak!
+}
+
+type AccountStreak {
+	dayStreak: Int!
+	bestDayStreak: Int!
+	winStreak: Int!
+	bestWinStreak: Int!
+	lastPlayedOn: String
+	xp: Float!
+	coins: Float!
+}
+
+type PlayerStats {
+	gamesPlayed: Int!
+	wins: Int!
+	answered: Int!
+	correct: Int!
+	correctRate: Float!
+	avgResponseMs: Float!
+	bestStreak: Int!
+	updatedAt: Time
+}
+
+type Question {
+	id: ID!
+	type: String
+	text: String!
+	options: [String!]!
+	category: String!
+	difficulty: String
+}
+
+type GameStats {
+	avgResponseMs: Float!
+	chatMessages: Int!
+	hardestQuestion: QuestionAccuracy
+	rounds: [RoundParticipation!]!
+}
+
+type QuestionAccuracy {
+	round: Int!
+	questionId: ID!
+	text: String!
+	category: String!
+	answered: Int!
+	correct: Int!
+	accuracy: Float!
+}
+
+type RoundParticipation {
+	round: Int!
+	players: Int!
+	answered: Int!
+	participationRate: Float!
+}
+
+type GameEvent {
+	seq: Float!
+	type: String!
+	timestamp: Time!
+	# The event's data as JSON, as sent over WebSocket
+	data: String!
+}
+`
+
+func (s *Server) newGraphQLSchema() *graphql.Schema {
+	return graphql.MustParseSchema(graphQLSchema, &graphQLQuery{s: s}, graphql.MaxDepth(graphQLMaxDepth))
+}
+
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// graphQL answers a GraphQL query sent as JSON ({"query": ...,
+// "operationName": ..., "variables": {...}}) or, for GET, as ?query=,
+// ?operationName= and ?variables=.
+func (s *Server) graphQL(c *gin.Context) {
+	var req graphQLRequest
+	if c.Request.Method == "GET" {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if raw := c.Query("variables"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &req.Variables); err != nil {
+				c.JSON(400, gin.H{"error": "variables must be a JSON object"})
+				return
+			}
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Query == "" {
+		c.JSON(400, gin.H{"error": "query is required"})
+		return
+	}
+
+	response := s.graphQLSchema.Exec(c.Request.Context(), req.Query, req.OperationName, req.Variables)
+	c.JSON(200, response)
+}
+
+type graphQLQuery struct {
+	s *Server
+}
+
+func (q *graphQLQuery) Lobby(args struct{ ID graphql.ID }) (*lobbyResolver, error) {
+	lobby, _, err := q.s.publicLobby(string(args.ID))
+	if errors.Is(err, repository.ErrLobbyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &lobbyResolver{s: q.s, l: lobby}, nil
+}
+
+func (q *graphQLQuery) Lobbies(args struct {
+	State    string
+	Sort     string
+	HasSpace bool
+	Limit    int32
+	Offset   int32
+}) (*lobbyPageResolver, error) {
+	query, err := lobbyQuery(args.State, args.Sort, args.HasSpace, int(args.Limit), int(args.Offset))
+	if err != nil {
+		return nil, errors.New("invalid lobby query: limit must be 1-100, offset non-negative, state waiting, in_progress, finished or all, and sort newest, oldest, name or players")
+	}
+	page, err := q.s.gameService.GetRepository().ListLobbies(query)
+	if err != nil {
+		return nil, err
+	}
+	resolver := &lobbyPageResolver{total: int32(page.Total)}
+	for _, lobby := range api.FromLobbies(page.Lobbies) {
+		resolver.lobbies = append(resolver.lobbies, &lobbyResolver{s: q.s, l: lobby})
+	}
+	return resolver, nil
+}
+
+func (q *graphQLQuery) Account(args struct{ ID graphql.ID }) (*accountResolver, error) {
+	return q.s.account(string(args.ID))
+}
+
+func (s *Server) account(userID string) (*accountResolver, error) {
+	user, err := s.gameService.User(userID)
+	if errors.Is(err, services.ErrUserNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &accountResolver{s: s, u: api.FromUser(user)}, nil
+}
+
+type lobbyPageResolver struct {
+	lobbies []*lobbyResolver
+	total   int32
+}
+
+func (r *lobbyPageResolver) Lobbies() []*lobbyResolver { return r.lobbies }
+func (r *lobbyPageResolver) Total() int32              { return r.total }
+
+type lobbyResolver struct {
+	s *Server
+	l *api.Lobby
+}
+
+func (r *lobbyResolver) ID() graphql.ID      { return graphql.ID(r.l.ID) }
+func (r *lobbyResolver) Name() string        { return r.l.Name }
+func (r *lobbyResolver) State() string       { return string(r.l.State) }
+func (r *lobbyResolver) Phase() string       { return string(r.l.Phase) }
+func (r *lobbyResolver) Round() int32        { return int32(r.l.Round) }
+func (r *lobbyResolver) MaxRounds() int32    { return int32(r.l.MaxRounds) }
+func (r *lobbyResolver) MaxPlayers() int32   { return int32(r.l.MaxPlayers) }
+func (r *lobbyResolver) QuestionTime() int32 { return int32(r.l.QuestionTime) }
+func (r *lobbyResolver) Topic() *string      { return optionalString(r.l.Topic) }
+func (r *lobbyResolver) Timezone() string    { return r.l.Timezone }
+func (r *lobbyResolver) Language() string    { return r.l.Language }
+func (r *lobbyResolver) Prize() *string      { return optionalString(r.l.Prize) }
+func (r *lobbyResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.l.CreatedAt}
+}
+func (r *lobbyResolver) StartedAt() *graphql.Time  { return optionalTime(r.l.StartedAt) }
+func (r *lobbyResolver) FinishedAt() *graphql.Time { return optionalTime(r.l.FinishedAt) }
+func (r *lobbyResolver) StartsAt() *graphql.Time   { return optionalTime(r.l.StartsAt) }
+
+func (r *lobbyResolver) CurrentQuestion() *questionResolver {
+	if r.l.CurrentQ == nil {
+		return nil
+	}
+	return &questionResolver{r.l.CurrentQ}
+}
+
+func (r *lobbyResolver) Players() []*playerResolver {
+	return r.playerResolvers(r.l.Players)
+}
+
+func (r *lobbyResolver) Leaderboard() []*playerResolver {
+	players := make([]*api.Player, len(r.l.Players))
+	copy(players, r.l.Players)
+	sort.SliceStable(players, func(i, j int) bool { return players[i].Score > players[j].Score })
+	return r.playerResolvers(players)
+}
+
+func (r *lobbyResolver) playerResolvers(players []*api.Player) []*playerResolver {
+	resolvers := make([]*playerResolver, len(players))
+	for i, p := range players {
+		resolvers[i] = &playerResolver{s: r.s, p: p}
+	}
+	return resolvers
+}
+
+func (r *lobbyResolver) Stats() *gameStatsResolver {
+	if r.l.Stats == nil {
+		return nil
+	}
+	return &gameStatsResolver{r.l.Stats}
+}
+
+func (r *lobbyResolver) Replay(args struct {
+	AfterSeq int32
+	First    int32
+}) (*[]*gameEventResolver, error) {
+	if r.l.State != models.Finished {
+		return nil, nil
+	}
+	events, err := r.s.gameService.Replay(r.l.ID)
+	if errors.Is(err, services.ErrReplayNotFound) || errors.Is(err, services.ErrGameNotFinished) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	first := int(args.First)
+	if first < 1 || first > maxReplayPage {
+		first = maxReplayPage
+	}
+	resolvers := []*gameEventResolver{}
+	for _, event := range events {
+		if event.Seq <= uint64(args.AfterSeq) {
+			continue
+		}
+		if len(resolvers) == first {
+			break
+		}
+		resolvers = append(resolvers, &gameEventResolver{event})
+	}
+	return &resolvers, nil
+}
+
+type playerResolver struct {
+	s *Server
+	p *api.Player
+}
+
+func (r *playerResolver) ID() graphql.ID   { return graphql.ID(r.p.ID) }
+func (r *playerResolver) Username() string { return r.p.Username }
+func (r *playerResolver) Score() int32     { return int32(r.p.Score) }
+func (r *playerResolver) Streak() int32    { return int32(r.p.Streak) }
+func (r *playerResolver) Online() bool     { return r.p.Online }
+func (r *playerResolver) IsBot() bool      { return r.p.IsBot }
+
+func (r *playerResolver) Account() (*accountResolver, error) {
+	if r.p.UserID == "" {
+		return nil, nil
+	}
+	return r.s.account(r.p.UserID)
+}
+
+type accountResolver struct {
+	s *Server
+	u *api.User
+}
+
+func (r *accountResolver) ID() graphql.ID          { return graphql.ID(r.u.ID) }
+func (r *accountResolver) Username() string        { return r.u.Username }
+func (r *accountResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.u.CreatedAt} }
+
+func (r *accountResolver) Stats() (*playerStatsResolver, error) {
+	stats, err := r.s.gameService.PlayerStats(r.u.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &playerStatsResolver{stats}, nil
+}
+
+func (r *accountResolver) Streak() (*accountStreakResolver, error) {
+	streak, err := r.s.gameService.AccountStreak(r.u.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &accountStreakResolver{streak}, nil
+}
+
+type accountStreakResolver struct {
+	st *api.AccountStreak
+}
+
+func (r *accountStreakResolver) DayStreak() int32      { return int32(r.st.DayStreak) }
+func (r *accountStreakResolver) BestDayStreak() int32  { return int32(r.st.BestDayStreak) }
+func (r *accountStreakResolver) WinStreak() int32      { return int32(r.st.WinStreak) }
+func (r *accountStreakResolver) BestWinStreak() int32  { return int32(r.st.BestWinStreak) }
+func (r *accountStreakResolver) LastPlayedOn() *string { return optionalString(r.st.LastPlayedOn) }
+func (r *accountStreakResolver) XP() float64           { return float64(r.st.XP) }
+func (r *accountStreakResolver) Coins() float64        { return float64(r.st.Coins) }
+
+type playerStatsResolver struct {
+	st *api.PlayerStats
+}
+
+func (r *playerStatsResolver) GamesPlayed() int32       { return int32(r.st.GamesPlayed) }
+func (r *playerStatsResolver) Wins() int32              { return int32(r.st.Wins) }
+func (r *playerStatsResolver) Answered() int32          { return int32(r.st.Answered) }
+func (r *playerStatsResolver) Correct() int32           { return int32(r.st.Correct) }
+func (r *playerStatsResolver) CorrectRate() float64     { return r.st.CorrectRate }
+func (r *playerStatsResolver) AvgResponseMs() float64   { return float64(r.st.AvgResponseMs) }
+func (r *playerStatsResolver) BestStreak() int32        { return int32(r.st.BestStreak) }
+func (r *playerStatsResolver) UpdatedAt() *graphql.Time { return optionalTime(r.st.UpdatedAt) }
+
+type questionResolver struct {
+	q *api.Question
+}
+
+func (r *questionResolver) ID() graphql.ID      { return graphql.ID(r.q.ID) }
+func (r *questionResolver) Type() *string       { return optionalString(string(r.q.Type)) }
+func (r *questionResolver) Text() string        { return r.q.Text }
+func (r *questionResolver) Options() []string   { return r.q.Options }
+func (r *questionResolver) Category() string    { return r.q.Category }
+func (r *questionResolver) Difficulty() *string { return optionalString(r.q.Difficulty) }
+
+type gameStatsResolver struct {
+	st *api.GameStats
+}
+
+func (r *gameStatsResolver) AvgResponseMs() float64 { return float64(r.st.AvgResponseMs) }
+func (r *gameStatsResolver) ChatMessages() int32    { return int32(r.st.ChatMessages) }
+
+func (r *gameStatsResolver) HardestQuestion() *questionAccuracyResolver {
+	if r.st.HardestQuestion == nil {
+		return nil
+	}
+	return &questionAccuracyResolver{r.st.HardestQuestion}
+}
+
+func (r *gameStatsResolver) Rounds() []*roundParticipationResolver {
+	resolvers := make([]*roundParticipationResolver, len(r.st.Rounds))
+	for i := range r.st.Rounds {
+		resolvers[i] = &roundParticipationResolver{&r.st.Rounds[i]}
+	}
+	return resolvers
+}
+
+type questionAccuracyResolver struct {
+	a *models.QuestionAccuracy
+}
+
+func (r *questionAccuracyResolver) Round() int32           { return int32(r.a.Round) }
+func (r *questionAccuracyResolver) QuestionID() graphql.ID { return graphql.ID(r.a.QuestionID) }
+func (r *questionAccuracyResolver) Text() string           { return r.a.Text }
+func (r *questionAccuracyResolver) Category() string       { return r.a.Category }
+func (r *questionAccuracyResolver) Answered() int32        { return int32(r.a.Answered) }
+func (r *questionAccuracyResolver) Correct() int32         { return int32(r.a.Correct) }
+func (r *questionAccuracyResolver) Accuracy() float64      { return r.a.Accuracy }
+
+type roundParticipationResolver struct {
+	p *models.RoundParticipation
+}
+
+func (r *roundParticipationResolver) Round() int32               { return int32(r.p.Round) }
+func (r *roundParticipationResolver) Players() int32             { return int32(r.p.Players) }
+func (r *roundParticipationResolver) Answered() int32            { return int32(r.p.Answered) }
+func (r *roundParticipationResolver) ParticipationRate() float64 { return r.p.Rate }
+
+type gameEventResolver struct {
+	e *models.GameEvent
+}
+
+// Seq is a Float: GraphQL's Int is 32 bits, and sequence numbers are 64.
+func (r *gameEventResolver) Seq() float64            { return float64(r.e.Seq) }
+func (r *gameEventResolver) Type() string            { return r.e.Type }
+func (r *gameEventResolver) Timestamp() graphql.Time { return graphql.Time{Time: r.e.Timestamp} }
+
+func (r *gameEventResolver) Data() (string, error) {
+	data, err := json.Marshal(r.e.Data)
+	return string(data), err
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func optionalTime(t *time.Time) *graphql.Time {
+	if t == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *t}
+}
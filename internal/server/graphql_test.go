@@ -0,0 +1,195 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// runGraphQL runs query over POST /graphql and decodes its data into out.
+func runGraphQL(t *testing.T, s *Server, query string, variables map[string]interface{}, out interface{}) graphQLResponse {
+	t.Helper()
+	resp := serve(s, "POST", "/api/v1/graphql", graphQLRequest{Query: query, Variables: variables}, nil)
+	if resp.Code != 200 {
+		t.Fatalf("GraphQL: %d %s", resp.Code, resp.Body)
+	}
+	var result graphQLResponse
+	if err := json.Unmarshal(resp.Body.Bytes(), &result); err != nil {
+		t.Fatalf("GraphQL response: %v", err)
+	}
+	if out != nil {
+		if len(result.Errors) > 0 {
+			t.Fatalf("GraphQL errors: %+v", result.Errors)
+		}
+		if err := json.Unmarshal(result.Data, out); err != nil {
+			t.Fatalf("GraphQL data: %v", err)
+		}
+	}
+	return result
+}
+
+func TestGraphQLLobbies(t *testing.T) {
+	s := newTestServer(t, nil)
+	lobbyID := createTestLobby(t, s, map[string]interface{}{"name": "GraphQL Lobby", "max_rounds": 3})
+
+	// A logged-in player's account and stats hang off their seat
+	resp := serve(s, "POST", "/api/v1/auth/register", credentials{"carol", "correct horse battery"}, nil)
+	var token tokenResponse
+	if err := json.Unmarshal(resp.Body.Bytes(), &token); err != nil || resp.Code != 201 {
+		t.Fatalf("Register: %d %s", resp.Code, resp.Body)
+	}
+	resp = serve(s, "POST", "/api/v1/lobbies/"+lobbyID+"/join", map[string]string{}, http.Header{"Authorization": {"Bearer " + token.Token}})
+	if resp.Code != 200 {
+		t.Fatalf("Join as an account: %d %s", resp.Code, resp.Body)
+	}
+	joinTestLobby(t, s, lobbyID, "guest")
+	createTestLobby(t, s, map[string]interface{}{"name": "Second Lobby"})
+
+	var data struct {
+		Lobby struct {
+			Name      string
+			State     string
+			MaxRounds int
+			Players   []struct {
+				Username string
+				Account  *struct {
+					ID    string
+					Stats struct{ GamesPlayed int }
+				}
+			}
+			Leaderboard []struct{ Username string }
+			Replay      *[]interface{}
+		}
+		Missing *struct{ ID string }
+		Lobbies struct {
+			Total   int
+			Lobbies []struct{ Name string }
+		}
+	}
+	runGraphQL(t, s, `query($id: ID!) {
+		lobby(id: $id) {
+			name state maxRounds
+			players { username account { id stats { gamesPlayed } } }
+			leaderboard { username }
+			replay { seq }
+		}
+		missing: lobby(id: "missing") { id }
+		lobbies(sort: "name", limit: 1) { total lobbies { name } }
+	}`, map[string]interface{}{"id": lobbyID}, &data)
+
+	lobby := data.Lobby
+	if lobby.Name != "GraphQL Lobby" || lobby.State != "waiting" || lobby.MaxRounds != 3 || len(lobby.Players) != 2 || len(lobby.Leaderboard) != 2 {
+		t.Fatalf("Unexpected lobby: %+v", lobby)
+	}
+	if account := lobby.Players[0].Account; lobby.Players[0].Username != "carol" || account == nil || account.ID != token.User.ID {
+		t.Fatalf("Expected carol's account on her seat: %+v", lobby.Players[0])
+	}
+	if lobby.Players[1].Account != nil {
+		t.Fatalf("Guest has an account: %+v", lobby.Players[1])
+	}
+	if lobby.Replay != nil {
+		t.Fatal("A waiting lobby has a replay")
+	}
+	if data.Missing != nil {
+		t.Fatal("Expected null for an unknown lobby")
+	}
+	if data.Lobbies.Total != 2 || len(data.Lobbies.Lobbies) != 1 || data.Lobbies.Lobbies[0].Name != "GraphQL Lobby" {
+		t.Fatalf("Unexpected lobby page: %+v", data.Lobbies)
+	}
+
+	if result := runGraphQL(t, s, `{ lobbies(limit: 500) { total } }`, nil, nil); len(result.Errors) == 0 {
+		t.Fatal("Expected an error for an out of range limit")
+	}
+	if resp := serve(s, "POST", "/api/v1/graphql", graphQLRequest{}, nil); resp.Code != 400 {
+		t.Fatalf("Empty query: got %d, want 400", resp.Code)
+	}
+	resp = serve(s, "GET", "/api/v1/graphql?query="+strings.ReplaceAll("{ lobbies { total } }", " ", "+"), nil, nil)
+	if resp.Code != 200 || !strings.Contains(resp.Body.String(), `"total":2`) {
+		t.Fatalf("GET query: %d %s", resp.Code, resp.Body)
+	}
+}
+
+// The schema has no way to ask for a question's answer, and a running game
+// has no replay to read it from.
+func TestGraphQLHidesAnswers(t *testing.T) {
+	s := newTestServer(t, nil)
+	lobbyID := createTestLobby(t, s, map[string]interface{}{"max_rounds": 3})
+	joinTestLobby(t, s, lobbyID, "host")
+	joinTestLobby(t, s, lobbyID, "guest")
+	if resp := serve(s, "POST", "/api/v1/lobbies/"+lobbyID+"/start", nil, nil); resp.Code != 200 {
+		t.Fatalf("Start game: %d %s", resp.Code, resp.Body)
+	}
+
+	var introspection struct {
+		Type struct {
+			Fields []struct{ Name string }
+		} `json:"__type"`
+	}
+	runGraphQL(t, s, `{ __type(name: "Question") { fields { name } } }`, nil, &introspection)
+	if len(introspection.Type.Fields) == 0 {
+		t.Fatal("No Question fields")
+	}
+	for _, field := range introspection.Type.Fields {
+		name := strings.ToLower(field.Name)
+		if strings.Contains(name, "correct") || strings.Contains(name, "answer") || strings.Contains(name, "tolerance") {
+			t.Errorf("Question exposes %s", field.Name)
+		}
+	}
+
+	current := s.hub.GetLobbyHub(lobbyID).GetLobby()
+	current.Lock()
+	question := *current.CurrentQ
+	current.Unlock()
+
+	var data struct {
+		Lobby struct {
+			CurrentQuestion struct {
+				ID      string
+				Text    string
+				Options []string
+			}
+			Replay *[]interface{}
+		}
+	}
+	runGraphQL(t, s, `query($id: ID!) { lobby(id: $id) { currentQuestion { id text options } replay { seq data } } }`,
+		map[string]interface{}{"id": lobbyID}, &data)
+	if data.Lobby.CurrentQuestion.ID != question.ID || data.Lobby.CurrentQuestion.Text != question.Text {
+		t.Fatalf("Unexpected current question: %+v", data.Lobby.CurrentQuestion)
+	}
+	if data.Lobby.Replay != nil {
+		t.Fatal("A running game's replay is readable")
+	}
+
+	for _, field := range []string{"correct", "correctAnswers", "acceptedAnswers", "numericAnswer"} {
+		result := runGraphQL(t, s, `query($id: ID!) { lobby(id: $id) { currentQuestion { `+field+` } } }`, map[string]interface{}{"id": lobbyID}, nil)
+		if len(result.Errors) == 0 || string(result.Data) != "null" && string(result.Data) != "" {
+			t.Errorf("Query for Question.%s answered: %s", field, result.Data)
+		}
+	}
+}
+
+func TestGraphQLDepthLimit(t *testing.T) {
+	s := newTestServer(t, nil)
+
+	// Introspection nests as deep as a query cares to go; __schema and
+	// types are the first two levels and name the last
+	nested := "name"
+	for depth := 4; depth <= graphQLMaxDepth; depth++ {
+		nested = "ofType { " + nested + " }"
+	}
+	var data interface{}
+	runGraphQL(t, s, "{ __schema { types { "+nested+" } } }", nil, &data)
+
+	result := runGraphQL(t, s, "{ __schema { types { ofType { "+nested+" } } } }", nil, nil)
+	if len(result.Errors) == 0 || !strings.Contains(result.Errors[0].Message, "exceeds max depth") {
+		t.Fatalf("Expected a max depth error, got %+v", result.Errors)
+	}
+}
@@ -0,0 +1,443 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"buildprize-game/internal/api"
+	"buildprize-game/internal/api/quizv1"
+	"buildprize-game/internal/auth"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpccreds "google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// grpcAPIKeyMetadata carries the API key on gRPC calls, like apiKeyHeader.
+const grpcAPIKeyMetadata = "x-api-key"
+
+// streamPollTimeout is how long StreamEvents waits on the lobby between
+// checks that the call's still open.
+const streamPollTimeout = 25 * time.Second
+
+type apiKeyContext struct{}
+
+// grpcService is the QuizService for backend integrations, over the same
+// game service as the REST and WebSocket API.
+type grpcService struct {
+	quizv1.UnimplementedQuizServiceServer
+	s *Server
+}
+
+// newGRPCServer serves QuizService to API keys with the lobbies scope, over
+// TLS when the game's listeners use it.
+func (s *Server) newGRPCServer(tlsSetup *tlsSetup) *grpc.Server {
+	opts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			ctx, err := s.grpcAuth(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if _, err := s.grpcAuth(stream.Context()); err != nil {
+				return err
+			}
+			return handler(srv, stream)
+		}),
+	}
+	if tlsSetup != nil {
+		opts = append(opts, grpc.Creds(grpccreds.NewTLS(tlsSetup.config)))
+	}
+	srv := grpc.NewServer(opts...)
+	quizv1.RegisterQuizServiceServer(srv, &grpcService{s: s})
+	return srv
+}
+
+// startGRPCServer serves gRPC on GRPC_ADDR in the background. Like the other
+// side servers, a failure is logged and the game keeps serving without it.
+func (s *Server) startGRPCServer(tlsSetup *tlsSetup) *grpc.Server {
+	l, err := listen(s.config.GRPCAddr)
+	if err != nil {
+		log.Printf("gRPC server not started: listen on %s: %v", s.config.GRPCAddr, err)
+		return nil
+	}
+	srv := s.newGRPCServer(tlsSetup)
+	log.Printf("gRPC API listening on %s", s.config.GRPCAddr)
+	go func() {
+		if err := srv.Serve(l); err != nil {
+			log.Printf("gRPC server stopped: %v", err)
+		}
+	}()
+	return srv
+}
+
+// stopGRPCServer lets open calls finish until ctx is done, then closes
+// them, event streams included.
+func stopGRPCServer(ctx context.Context, srv *grpc.Server) {
+	if srv == nil {
+		return
+	}
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		srv.Stop()
+	}
+}
+
+// grpcAuth checks the call carries an API key with the lobbies scope.
+func (s *Server) grpcAuth(ctx context.Context) (context.Context, error) {
+	var token string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(grpcAPIKeyMetadata); len(values) > 0 {
+			token = values[0]
+		}
+	}
+	key, err := s.gameService.VerifyAPIKey(token)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAPIKey) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if !key.HasScope(models.ScopeLobbies) {
+		return nil, status.Error(codes.PermissionDenied, "API key lacks the "+models.ScopeLobbies+" scope")
+	}
+	return context.WithValue(ctx, apiKeyContext{}, key), nil
+}
+
+// grpcError turns an error into a gRPC status with the code matching the
+// HTTP status the REST API answers it with.
+func grpcError(httpStatus int, err error) error {
+	code := codes.Internal
+	switch httpStatus {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusPaymentRequired, http.StatusConflict:
+		code = codes.FailedPrecondition
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	}
+	return status.Error(code, err.Error())
+}
+
+// hostActionError maps the errors of actions only the host may take.
+func hostActionError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrLobbyNotFound):
+		return grpcError(404, err)
+	case errors.Is(err, services.ErrNotHost):
+		return grpcError(403, err)
+	}
+	return grpcError(400, err)
+}
+
+// checkSeat refuses a call acting as a player without their session token.
+func (g *grpcService) checkSeat(req *quizv1.PlayerRequest) error {
+	if !g.s.holdsSeat(req.GetSessionToken(), req.GetLobbyId(), req.GetPlayerId()) {
+		return status.Error(codes.Unauthenticated, auth.ErrInvalidSession.Error())
+	}
+	return nil
+}
+
+func (g *grpcService) CreateLobby(ctx context.Context, req *quizv1.CreateLobbyRequest) (*quizv1.CreateLobbyResponse, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	if req.GetWebhookUrl() != "" {
+		if key := ctx.Value(apiKeyContext{}).(*models.APIKey); !key.HasScope(models.ScopeWebhooks) {
+			return nil, status.Error(codes.PermissionDenied, "webhook_url needs an API key with the "+models.ScopeWebhooks+" scope")
+		}
+	}
+	roundType := models.MediaType(req.GetRoundType())
+	if roundType != "" && roundType != models.MediaImage && roundType != models.MediaAudio {
+		return nil, status.Error(codes.InvalidArgument, "round_type must be \"image\" or \"audio\"")
+	}
+
+	maxRounds := int(req.GetMaxRounds())
+	if maxRounds == 0 {
+		maxRounds = 10
+	}
+	var weights map[string]int
+	if len(req.GetCategoryWeights()) > 0 {
+		weights = make(map[string]int, len(req.GetCategoryWeights()))
+		for category, weight := range req.GetCategoryWeights() {
+			weights[category] = int(weight)
+		}
+	}
+	var startsAt time.Time
+	if req.GetStartsAt() != nil {
+		startsAt = req.GetStartsAt().AsTime()
+	}
+
+	lobby, err := g.s.gameService.CreateLobby(services.LobbyOptions{
+		Name:            req.GetName(),
+		MaxRounds:       maxRounds,
+		Topic:           req.GetTopic(),
+		RoundType:       roundType,
+		CategoryWeights: weights,
+		MaxPlayers:      int(req.GetMaxPlayers()),
+		Timezone:        req.GetTimezone(),
+		Language:        req.GetLanguage(),
+		WarmUp:          req.GetWarmUp(),
+		StartsAt:        startsAt,
+		WebhookURL:      req.GetWebhookUrl(),
+		WagerRounds:     ints(req.GetWagerRounds()),
+		FinalWager:      req.GetFinalWager(),
+	})
+	if err != nil {
+		return nil, grpcError(400, err)
+	}
+	return &quizv1.CreateLobbyResponse{
+		Lobby:         toProtoLobby(api.LobbySnapshot(lobby)),
+		WebhookSecret: g.s.gameService.LobbyWebhookSecret(lobby),
+	}, nil
+}
+
+func (g *grpcService) GetLobby(_ context.Context, req *quizv1.GetLobbyRequest) (*quizv1.Lobby, error) {
+	lobbyHub := g.s.hub.GetLobbyHub(req.GetLobbyId())
+	if lobbyHub == nil {
+		return nil, grpcError(404, services.ErrLobbyNotFound)
+	}
+	return toProtoLobby(api.LobbySnapshot(lobbyHub.GetLobby())), nil
+}
+
+func (g *grpcService) ListLobbies(_ context.Context, req *quizv1.ListLobbiesRequest) (*quizv1.ListLobbiesResponse, error) {
+	query, err := lobbyQuery(req.GetState(), req.GetSort(), req.GetHasSpace(), int(req.GetLimit()), int(req.GetOffset()))
+	if err != nil {
+		return nil, grpcError(400, err)
+	}
+
+	page, err := g.s.gameService.GetRepository().ListLobbies(query)
+	if err != nil {
+		log.Printf("Error listing lobbies: %v", err)
+		return nil, status.Error(codes.Internal, "Failed to list lobbies")
+	}
+	resp := &quizv1.ListLobbiesResponse{Total: int32(page.Total)}
+	for _, lobby := range api.FromLobbies(page.Lobbies) {
+		resp.Lobbies = append(resp.Lobbies, toProtoLobby(lobby))
+	}
+	return resp, nil
+}
+
+func (g *grpcService) JoinLobby(_ context.Context, req *quizv1.JoinLobbyRequest) (*quizv1.JoinLobbyResponse, error) {
+	if req.GetUsername() == "" {
+		return nil, status.Error(codes.InvalidArgument, "username is required")
+	}
+	lobby, player, err := g.s.gameService.JoinLobby(req.GetLobbyId(), services.JoinOptions{
+		Username:  req.GetUsername(),
+		PaymentID: req.GetPaymentId(),
+	})
+	if err != nil {
+		return nil, grpcError(joinErrorStatus(err), err)
+	}
+	return &quizv1.JoinLobbyResponse{
+		Lobby:        toProtoLobby(api.LobbySnapshot(lobby)),
+		Player:       toProtoPlayer(api.FromPlayer(player)),
+		SessionToken: g.s.sessions.Issue(lobby.ID, player.ID),
+	}, nil
+}
+
+func (g *grpcService) LeaveLobby(_ context.Context, req *quizv1.PlayerRequest) (*quizv1.LeaveLobbyResponse, error) {
+	if err := g.checkSeat(req); err != nil {
+		return nil, err
+	}
+	if err := g.s.gameService.LeaveLobby(req.GetLobbyId(), req.GetPlayerId()); err != nil {
+		return nil, grpcError(400, err)
+	}
+	return &quizv1.LeaveLobbyResponse{}, nil
+}
+
+func (g *grpcService) StartGame(_ context.Context, req *quizv1.StartGameRequest) (*quizv1.StartGameResponse, error) {
+	if err := g.s.gameService.StartGame(req.GetLobbyId()); err != nil {
+		if errors.Is(err, services.ErrLobbyNotFound) {
+			return nil, grpcError(404, err)
+		}
+		return nil, grpcError(400, err)
+	}
+	return &quizv1.StartGameResponse{}, nil
+}
+
+func (g *grpcService) EndGame(_ context.Context, req *quizv1.PlayerRequest) (*quizv1.EndGameResponse, error) {
+	if err := g.checkSeat(req); err != nil {
+		return nil, err
+	}
+	if err := g.s.gameService.HostEndGame(req.GetLobbyId(), req.GetPlayerId()); err != nil {
+		return nil, hostActionError(err)
+	}
+	return &quizv1.EndGameResponse{}, nil
+}
+
+func (g *grpcService) CancelGame(_ context.Context, req *quizv1.PlayerRequest) (*quizv1.CancelGameResponse, error) {
+	if err := g.checkSeat(req); err != nil {
+		return nil, err
+	}
+	if err := g.s.gameService.HostCancelGame(req.GetLobbyId(), req.GetPlayerId()); err != nil {
+		return nil, hostActionError(err)
+	}
+	return &quizv1.CancelGameResponse{}, nil
+}
+
+func (g *grpcService) SubmitAnswer(_ context.Context, req *quizv1.SubmitAnswerRequest) (*quizv1.SubmitAnswerResponse, error) {
+	seat := &quizv1.PlayerRequest{LobbyId: req.GetLobbyId(), PlayerId: req.GetPlayerId(), SessionToken: req.GetSessionToken()}
+	if err := g.checkSeat(seat); err != nil {
+		return nil, err
+	}
+
+	var answer models.SubmittedAnswer
+	switch a := req.GetAnswer().(type) {
+	case *quizv1.SubmitAnswerRequest_Choice:
+		answer = models.SubmittedAnswer{Choice: int(a.Choice), Number: float64(a.Choice)}
+	case *quizv1.SubmitAnswerRequest_Choices:
+		answer = models.SubmittedAnswer{Choices: ints(a.Choices.GetIndexes())}
+	case *quizv1.SubmitAnswerRequest_Text:
+		answer = models.SubmittedAnswer{Text: a.Text}
+	case *quizv1.SubmitAnswerRequest_Number:
+		answer = models.SubmittedAnswer{Number: a.Number}
+	default:
+		return nil, grpcError(400, models.ErrInvalidAnswer)
+	}
+
+	if err := g.s.gameService.SubmitAnswerSentAt(req.GetLobbyId(), req.GetPlayerId(), answer, time.Time{}); err != nil {
+		return nil, grpcError(400, err)
+	}
+	return &quizv1.SubmitAnswerResponse{}, nil
+}
+
+// StreamEvents sends the lobby's logged events as they happen, long polling
+// the event log like GET /events/poll so a slow reader never holds up the
+// lobby. The stream ends once the lobby is gone.
+func (g *grpcService) StreamEvents(req *quizv1.StreamEventsRequest, stream quizv1.QuizService_StreamEventsServer) error {
+	ctx := stream.Context()
+	afterSeq := req.GetAfterSeq()
+	for first := true; ; first = false {
+		events, seq, err := g.s.gameService.PollEvents(req.GetLobbyId(), afterSeq, streamPollTimeout, ctx.Done())
+		switch {
+		case errors.Is(err, services.ErrLobbyNotFound) && !first:
+			return nil
+		case errors.Is(err, services.ErrLobbyNotFound):
+			return grpcError(404, err)
+		case err != nil:
+			return grpcError(500, err)
+		}
+		for _, event := range events {
+			data, err := json.Marshal(event.Data)
+			if err != nil {
+				return grpcError(500, err)
+			}
+			if err := stream.Send(&quizv1.GameEvent{
+				Type:      event.Type,
+				LobbyId:   event.LobbyID,
+				Seq:       event.Seq,
+				DataJson:  string(data),
+				Timestamp: timestamppb.New(event.Timestamp),
+			}); err != nil {
+				return err
+			}
+		}
+		afterSeq = seq
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
+
+func toProtoLobby(l *api.Lobby) *quizv1.Lobby {
+	lobby := &quizv1.Lobby{
+		Id:                  l.ID,
+		Name:                l.Name,
+		State:               string(l.State),
+		Phase:               string(l.Phase),
+		CurrentQuestion:     toProtoQuestion(l.CurrentQ),
+		Round:               int32(l.Round),
+		MaxRounds:           int32(l.MaxRounds),
+		MaxPlayers:          int32(l.MaxPlayers),
+		QuestionTimeSeconds: int32(l.QuestionTime),
+		CreatedAt:           timestamppb.New(l.CreatedAt),
+		StartedAt:           protoTime(l.StartedAt),
+		FinishedAt:          protoTime(l.FinishedAt),
+		QuestionEnd:         protoTime(l.QuestionEnd),
+		Paused:              l.Paused,
+		Topic:               l.Topic,
+		Timezone:            l.Timezone,
+		Language:            l.Language,
+		StartsAt:            protoTime(l.StartsAt),
+	}
+	for _, p := range l.Players {
+		lobby.Players = append(lobby.Players, toProtoPlayer(p))
+	}
+	return lobby
+}
+
+func toProtoPlayer(p *api.Player) *quizv1.Player {
+	if p == nil {
+		return nil
+	}
+	return &quizv1.Player{
+		Id:       p.ID,
+		Username: p.Username,
+		Score:    int32(p.Score),
+		Streak:   int32(p.Streak),
+		IsReady:  p.IsReady,
+		IsBot:    p.IsBot,
+		UserId:   p.UserID,
+		Online:   p.Online,
+	}
+}
+
+func toProtoQuestion(q *api.Question) *quizv1.Question {
+	if q == nil {
+		return nil
+	}
+	return &quizv1.Question{
+		Id:         q.ID,
+		Type:       string(q.Type),
+		Text:       q.Text,
+		Options:    q.Options,
+		Category:   q.Category,
+		MediaUrl:   q.MediaURL,
+		MediaType:  string(q.MediaType),
+		Difficulty: q.Difficulty,
+	}
+}
+
+func protoTime(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func ints(values []int32) []int {
+	if values == nil {
+		return nil
+	}
+	out := make([]int, len(values))
+	for i, v := range values {
+		out[i] = int(v)
+	}
+	return out
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/api/quizv1"
+	"buildprize-game/internal/models"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newGRPCClient serves the server's QuizService over an in-memory
+// connection.
+func newGRPCClient(t *testing.T, s *Server) quizv1.QuizServiceClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	srv := s.newGRPCServer(nil)
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return quizv1.NewQuizServiceClient(conn)
+}
+
+// withAPIKey issues an API key with scopes and returns a context that sends
+// it.
+func withAPIKey(t *testing.T, s *Server, scopes ...string) context.Context {
+	t.Helper()
+	_, secret, err := s.gameService.CreateAPIKey("grpc test", scopes, "test")
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	return metadata.AppendToOutgoingContext(ctx, grpcAPIKeyMetadata, secret)
+}
+
+func wantCode(t *testing.T, err error, code codes.Code, what string) {
+	t.Helper()
+	if status.Code(err) != code {
+		t.Fatalf("%s: got %v, want %s", what, err, code)
+	}
+}
+
+func TestGRPCLobbyAPI(t *testing.T) {
+	s := newTestServer(t, nil)
+	client := newGRPCClient(t, s)
+	ctx := withAPIKey(t, s, models.ScopeLobbies)
+
+	created, err := client.CreateLobby(ctx, &quizv1.CreateLobbyRequest{Name: "gRPC Lobby", MaxRounds: 3})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	lobbyID := created.GetLobby().GetId()
+	if created.GetLobby().GetName() != "gRPC Lobby" || created.GetLobby().GetMaxRounds() != 3 {
+		t.Fatalf("Unexpected lobby: %v", created.GetLobby())
+	}
+	_, err = client.CreateLobby(ctx, &quizv1.CreateLobbyRequest{})
+	wantCode(t, err, codes.InvalidArgument, "CreateLobby without a name")
+
+	host, err := client.JoinLobby(ctx, &quizv1.JoinLobbyRequest{LobbyId: lobbyID, Username: "host"})
+	if err != nil {
+		t.Fatalf("JoinLobby: %v", err)
+	}
+	guest, err := client.JoinLobby(ctx, &quizv1.JoinLobbyRequest{LobbyId: lobbyID, Username: "guest"})
+	if err != nil {
+		t.Fatalf("JoinLobby: %v", err)
+	}
+	if host.GetSessionToken() == "" || len(guest.GetLobby().GetPlayers()) != 2 {
+		t.Fatalf("Unexpected join: %v", guest)
+	}
+
+	lobby, err := client.GetLobby(ctx, &quizv1.GetLobbyRequest{LobbyId: lobbyID})
+	if err != nil || len(lobby.GetPlayers()) != 2 {
+		t.Fatalf("GetLobby: %v, %v", lobby, err)
+	}
+	_, err = client.GetLobby(ctx, &quizv1.GetLobbyRequest{LobbyId: "missing"})
+	wantCode(t, err, codes.NotFound, "GetLobby of an unknown lobby")
+	listed, err := client.ListLobbies(ctx, &quizv1.ListLobbiesRequest{})
+	if err != nil || listed.GetTotal() != 1 || listed.GetLobbies()[0].GetId() != lobbyID {
+		t.Fatalf("ListLobbies: %v, %v", listed, err)
+	}
+
+	stream, err := client.StreamEvents(ctx, &quizv1.StreamEventsRequest{LobbyId: lobbyID})
+	if err != nil {
+		t.Fatalf("StreamEvents: %v", err)
+	}
+	if _, err := client.StartGame(ctx, &quizv1.StartGameRequest{LobbyId: lobbyID}); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	var lastSeq uint64
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		if event.GetSeq() <= lastSeq {
+			t.Fatalf("Event %s out of order: seq %d after %d", event.GetType(), event.GetSeq(), lastSeq)
+		}
+		lastSeq = event.GetSeq()
+		if event.GetType() == "game_started" {
+			break
+		}
+	}
+
+	// Acting as a player needs their session token
+	answer := &quizv1.SubmitAnswerRequest{LobbyId: lobbyID, PlayerId: host.GetPlayer().GetId(), Answer: &quizv1.SubmitAnswerRequest_Choice{Choice: 0}}
+	_, err = client.SubmitAnswer(ctx, answer)
+	wantCode(t, err, codes.Unauthenticated, "SubmitAnswer without a session token")
+	answer.SessionToken = guest.GetSessionToken()
+	_, err = client.SubmitAnswer(ctx, answer)
+	wantCode(t, err, codes.Unauthenticated, "SubmitAnswer with another player's session token")
+	asHost := &quizv1.PlayerRequest{LobbyId: lobbyID, PlayerId: host.GetPlayer().GetId()}
+	_, err = client.EndGame(ctx, asHost)
+	wantCode(t, err, codes.Unauthenticated, "EndGame without a session token")
+	_, err = client.LeaveLobby(ctx, &quizv1.PlayerRequest{LobbyId: lobbyID, PlayerId: host.GetPlayer().GetId(), SessionToken: guest.GetSessionToken()})
+	wantCode(t, err, codes.Unauthenticated, "LeaveLobby with another player's session token")
+	_, err = client.EndGame(ctx, &quizv1.PlayerRequest{LobbyId: lobbyID, PlayerId: guest.GetPlayer().GetId(), SessionToken: guest.GetSessionToken()})
+	wantCode(t, err, codes.PermissionDenied, "EndGame as the guest")
+
+	asHost.SessionToken = host.GetSessionToken()
+	if _, err := client.EndGame(ctx, asHost); err != nil {
+		t.Fatalf("EndGame as the host: %v", err)
+	}
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		if event.GetType() == "game_ended" {
+			break
+		}
+	}
+}
+
+func TestGRPCRequiresAPIKey(t *testing.T) {
+	s := newTestServer(t, nil)
+	client := newGRPCClient(t, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := client.ListLobbies(ctx, &quizv1.ListLobbiesRequest{})
+	wantCode(t, err, codes.Unauthenticated, "ListLobbies without an API key")
+	_, err = client.ListLobbies(metadata.AppendToOutgoingContext(ctx, grpcAPIKeyMetadata, "not a key"), &quizv1.ListLobbiesRequest{})
+	wantCode(t, err, codes.Unauthenticated, "ListLobbies with an unknown API key")
+
+	stream, err := client.StreamEvents(ctx, &quizv1.StreamEventsRequest{LobbyId: "any"})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	wantCode(t, err, codes.Unauthenticated, "StreamEvents without an API key")
+
+	adminOnly := withAPIKey(t, s, models.ScopeAdminRead)
+	_, err = client.ListLobbies(adminOnly, &quizv1.ListLobbiesRequest{})
+	wantCode(t, err, codes.PermissionDenied, "ListLobbies without the lobbies scope")
+
+	lobbiesOnly := withAPIKey(t, s, models.ScopeLobbies)
+	_, err = client.CreateLobby(lobbiesOnly, &quizv1.CreateLobbyRequest{Name: "Hooked", WebhookUrl: "https://hooks.example.com/quiz"})
+	wantCode(t, err, codes.PermissionDenied, "CreateLobby with a webhook without the webhooks scope")
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+)
+
+const healthPingTimeout = 2 * time.Second
+
+// checkDatabase pings the database, returning the round trip in
+// milliseconds.
+func (s *Server) checkDatabase(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, healthPingTimeout)
+	defer cancel()
+	started := time.Now()
+	err := s.gameService.GetRepository().Ping(ctx)
+	return time.Since(started).Milliseconds(), err
+}
+
+// health is the liveness check: it answers 200 as long as the process can
+// serve requests, reporting the database and hub without failing on them, so
+// a database outage doesn't get every instance restarted.
+func (s *Server) health(c *gin.Context) {
+	database := gin.H{"status": "ok"}
+	status := "ok"
+	latency, err := s.checkDatabase(c.Request.Context())
+	database["latency_ms"] = latency
+	if err != nil {
+		database["status"] = "unreachable"
+		database["error"] = err.Error()
+		status = "degraded"
+	}
+
+	c.JSON(200, gin.H{
+		"status":         status,
+		"database":       database,
+		"hub":            s.hub.Stats(),
+		"draining":       s.draining.Load(),
+		"uptime_seconds": int64(time.Since(s.startedAt).Seconds()),
+	})
+}
+
+// ready is the readiness check: 503 while the database is unreachable or the
+// server is draining for shutdown, so no new traffic is routed here.
+func (s *Server) ready(c *gin.Context) {
+	if s.draining.Load() {
+		c.JSON(503, gin.H{"status": "draining"})
+		return
+	}
+	if _, err := s.checkDatabase(c.Request.Context()); err != nil {
+		c.JSON(503, gin.H{"status": "database unreachable", "error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"status": "ready"})
+}
+
+// Start serves until SIGINT or SIGTERM, then drains: /ready fails for the
+// configured drain period before the server stops accepting requests.
+func (s *Server) Start() error {
+	srv := &http.Server{Handler: withUnixPeers(s.router)}
+	tlsSetup, err := s.newTLSSetup()
+	if err != nil {
+		return err
+	}
+	if tlsSetup != nil {
+		srv.TLSConfig = tlsSetup.config
+	}
+	addrs := s.listenAddrs()
+	serveErr, err := serveOn(srv, addrs)
+	if err != nil {
+		return err
+	}
+	if tlsSetup != nil {
+		log.Printf("Serving HTTPS and secure WebSocket on %s", strings.Join(addrs, ", "))
+	} else {
+		log.Printf("Serving HTTP and WebSocket on %s", strings.Join(addrs, ", "))
+	}
+
+	var debugSrv, publicSrv, adminSrv, redirectSrv *http.Server
+	var grpcSrv *grpc.Server
+	if s.config.GRPCAddr != "" {
+		grpcSrv = s.startGRPCServer(tlsSetup)
+	}
+	if tlsSetup != nil && s.config.HTTPRedirectAddr != "" {
+		redirectSrv = &http.Server{Addr: s.config.HTTPRedirectAddr, Handler: tlsSetup.redirect}
+		log.Printf("Redirecting HTTP on %s to HTTPS", redirectSrv.Addr)
+		startSideServer("HTTP redirect", redirectSrv)
+	}
+	if s.config.DebugAddr != "" {
+		debugSrv = s.startDebugServer()
+	}
+	if s.config.PublicAPIAddr != "" {
+		publicSrv = s.startPublicServer()
+	}
+	if s.config.AdminAddr != "" {
+		adminSrv = s.newAdminServer()
+		log.Printf("Admin API and /debug/stats listening on %s", adminSrv.Addr)
+		startSideServer("Admin", adminSrv)
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	select {
+	case err := <-serveErr:
+		return err
+	case sig := <-stop:
+		log.Printf("Received %s, draining for %ds before shutdown", sig, s.config.ShutdownDrainSeconds)
+	}
+
+	s.draining.Store(true)
+	time.Sleep(time.Duration(s.config.ShutdownDrainSeconds) * time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.config.ShutdownTimeout)*time.Second)
+	defer cancel()
+	defer stopSideServer(ctx, "debug", debugSrv)
+	defer stopSideServer(ctx, "public API", publicSrv)
+	defer stopSideServer(ctx, "admin", adminSrv)
+	defer stopSideServer(ctx, "HTTP redirect", redirectSrv)
+	defer stopGRPCServer(ctx, grpcSrv)
+	if err := srv.Shutdown(ctx); err != nil {
+		return err
+	}
+	for range addrs {
+		if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+	}
+	log.Printf("Server stopped")
+	return nil
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"buildprize-game/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+type languagesResponse struct {
+	Languages []string `json:"languages"`
+	Default   string   `json:"default"`
+}
+
+type catalogResponse struct {
+	Language string       `json:"language"`
+	Messages i18n.Catalog `json:"messages"`
+}
+
+// listLanguages returns the languages the server has message catalogs for.
+func (s *Server) listLanguages(c *gin.Context) {
+	c.JSON(200, languagesResponse{i18n.Languages(), i18n.DefaultLanguage})
+}
+
+// getMessageCatalog returns the catalog for :lang, e.g. "es" or "pt-BR",
+// which is served by "pt". Every key is always present, in English where
+// the language lacks a translation.
+func (s *Server) getMessageCatalog(c *gin.Context) {
+	lang, err := i18n.Resolve(c.Param("lang"))
+	if err != nil {
+		c.JSON(404, gin.H{"error": err.Error(), "languages": i18n.Languages()})
+		return
+	}
+	catalog, _ := i18n.Lookup(lang)
+	// Catalogs only change with a deploy
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.JSON(200, catalogResponse{lang, catalog})
+}
@@ -0,0 +1,233 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"buildprize-game/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+const geoIPCacheTTL = time.Hour
+
+// GeoIPProvider resolves the ISO 3166-1 alpha-2 country of a request.
+// It returns "" when the country is unknown (e.g. private addresses).
+type GeoIPProvider interface {
+	Country(r *http.Request, ip net.IP) (string, error)
+}
+
+// HeaderGeoIP reads the country from a header set by a CDN or load
+// balancer, such as Cloudflare's CF-IPCountry.
+type HeaderGeoIP struct {
+	Header string
+}
+
+func (h HeaderGeoIP) Country(r *http.Request, ip net.IP) (string, error) {
+	country := strings.ToUpper(strings.TrimSpace(r.Header.Get(h.Header)))
+	if country == "XX" {
+		return "", nil
+	}
+	return country, nil
+}
+
+// HTTPGeoIP looks the address up with an HTTP service that answers with a
+// bare country code, e.g. https://ipapi.co/{ip}/country/. Results are cached.
+type HTTPGeoIP struct {
+	URLTemplate string
+	client      *http.Client
+
+	mu    sync.Mutex
+	cache map[string]geoIPEntry
+}
+
+type geoIPEntry struct {
+	country string
+	expires time.Time
+}
+
+func NewHTTPGeoIP(urlTemplate string) *HTTPGeoIP {
+	return &HTTPGeoIP{
+		URLTemplate: urlTemplate,
+		client:      &http.Client{Timeout: 3 * time.Second},
+		cache:       make(map[string]geoIPEntry),
+	}
+}
+
+func (g *HTTPGeoIP) Country(r *http.Request, ip net.IP) (string, error) {
+	if ip.IsPrivate() || ip.IsLoopback() {
+		return "", nil
+	}
+	key := ip.String()
+
+	g.mu.Lock()
+	entry, ok := g.cache[key]
+	g.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.country, nil
+	}
+
+	resp, err := g.client.Get(strings.ReplaceAll(g.URLTemplate, "{ip}", url.PathEscape(key)))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("geoip lookup returned status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64))
+	if err != nil {
+		return "", err
+	}
+	country := strings.ToUpper(strings.TrimSpace(string(body)))
+
+	g.mu.Lock()
+	g.cache[key] = geoIPEntry{country: country, expires: time.Now().Add(geoIPCacheTTL)}
+	g.mu.Unlock()
+	return country, nil
+}
+
+// ipFilter applies IP allow/deny lists and country blocking to every
+// request, REST and WebSocket alike.
+type ipFilter struct {
+	allow      []*net.IPNet
+	deny       []*net.IPNet
+	countries  map[string]bool
+	geoIP      GeoIPProvider
+	failClosed bool
+}
+
+// newIPFilter returns nil when no access rules are configured.
+func newIPFilter(cfg *config.Config) *ipFilter {
+	allow, err := parseCIDRList(cfg.IPAllowList)
+	if err != nil {
+		log.Fatalf("Invalid IP_ALLOW_LIST: %v", err)
+	}
+	deny, err := parseCIDRList(cfg.IPDenyList)
+	if err != nil {
+		log.Fatalf("Invalid IP_DENY_LIST: %v", err)
+	}
+
+	countries := make(map[string]bool)
+	for _, code := range strings.Split(cfg.BlockedCountries, ",") {
+		if code = strings.ToUpper(strings.TrimSpace(code)); code != "" {
+			countries[code] = true
+		}
+	}
+
+	var geoIP GeoIPProvider
+	switch {
+	case cfg.GeoIPHeader != "":
+		geoIP = HeaderGeoIP{Header: cfg.GeoIPHeader}
+	case cfg.GeoIPLookupURL != "":
+		geoIP = NewHTTPGeoIP(cfg.GeoIPLookupURL)
+	}
+	if len(countries) > 0 && geoIP == nil {
+		log.Fatal("BLOCKED_COUNTRIES requires GEOIP_HEADER or GEOIP_LOOKUP_URL")
+	}
+
+	if len(allow) == 0 && len(deny) == 0 && len(countries) == 0 {
+		return nil
+	}
+	return &ipFilter{
+		allow:      allow,
+		deny:       deny,
+		countries:  countries,
+		geoIP:      geoIP,
+		failClosed: cfg.GeoIPFailClosed,
+	}
+}
+
+func parseCIDRList(list string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+func matchesAny(nets []*net.IPNet, ip net.IP) bool {
+	for _, ipNet := range nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// check returns why the request is rejected, or "" if it is allowed.
+func (f *ipFilter) check(r *http.Request, ip net.IP) string {
+	if ip == nil {
+		return "unknown client address"
+	}
+	if matchesAny(f.deny, ip) {
+		return "address denied"
+	}
+	if len(f.allow) > 0 && !matchesAny(f.allow, ip) {
+		return "address not allowed"
+	}
+
+	if len(f.countries) > 0 {
+		country, err := f.geoIP.Country(r, ip)
+		if err != nil {
+			log.Printf("GeoIP lookup failed for %s: %v", ip, err)
+			if f.failClosed {
+				return "country could not be determined"
+			}
+			return ""
+		}
+		if country == "" && f.failClosed {
+			return "country could not be determined"
+		}
+		if f.countries[country] {
+			return "not available in your region"
+		}
+	}
+	return ""
+}
+
+func (s *Server) filterIPs(c *gin.Context) {
+	// Keep health checks reachable for the hosting platform
+	if c.Request.URL.Path == "/health" || c.Request.URL.Path == "/ready" {
+		c.Next()
+		return
+	}
+
+	ip := net.ParseIP(c.ClientIP())
+	if reason := s.ipFilter.check(c.Request, ip); reason != "" {
+		log.Printf("Blocked %s %s from %s: %s", c.Request.Method, c.Request.URL.Path, c.ClientIP(), reason)
+		c.AbortWithStatusJSON(403, gin.H{"error": reason})
+		return
+	}
+	c.Next()
+}
+
+// SetGeoIPProvider replaces the configured GeoIP provider, for deployments
+// that plug in their own (e.g. a local MaxMind database).
+func (s *Server) SetGeoIPProvider(provider GeoIPProvider) {
+	if s.ipFilter != nil {
+		s.ipFilter.geoIP = provider
+	}
+}
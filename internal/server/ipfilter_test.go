@@ -0,0 +1,137 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"buildprize-game/internal/config"
+)
+
+func TestIPAllowAndDenyLists(t *testing.T) {
+	if filter := newIPFilter(&config.Config{}); filter != nil {
+		t.Fatal("Expected no filter without access rules")
+	}
+
+	filter := newIPFilter(&config.Config{
+		IPAllowList: "10.0.0.0/8, 192.0.2.7, 2001:db8::/32",
+		IPDenyList:  "10.1.0.0/16,2001:db8::1",
+	})
+	req := httptest.NewRequest("GET", "/", nil)
+	for ip, want := range map[string]string{
+		"10.0.0.1":     "",
+		"10.255.1.1":   "",
+		"192.0.2.7":    "",
+		"2001:db8::2":  "",
+		"10.1.2.3":     "address denied", // deny wins over allow
+		"2001:db8::1":  "address denied",
+		"192.0.2.8":    "address not allowed", // a bare address matches only itself
+		"198.51.100.1": "address not allowed",
+		"2001:db9::1":  "address not allowed",
+	} {
+		if got := filter.check(req, net.ParseIP(ip)); got != want {
+			t.Errorf("check(%s) = %q, want %q", ip, got, want)
+		}
+	}
+	if got := filter.check(req, nil); got != "unknown client address" {
+		t.Errorf("check(nil) = %q", got)
+	}
+
+	// Deny lists alone let everyone else through
+	filter = newIPFilter(&config.Config{IPDenyList: "203.0.113.0/24"})
+	if got := filter.check(req, net.ParseIP("203.0.113.50")); got != "address denied" {
+		t.Errorf("check(denied) = %q", got)
+	}
+	if got := filter.check(req, net.ParseIP("198.51.100.1")); got != "" {
+		t.Errorf("check(other) = %q", got)
+	}
+}
+
+type failingGeoIP struct{}
+
+func (failingGeoIP) Country(r *http.Request, ip net.IP) (string, error) {
+	return "", errors.New("lookup failed")
+}
+
+func TestBlockedCountries(t *testing.T) {
+	filter := newIPFilter(&config.Config{BlockedCountries: "kp, IR", GeoIPHeader: "CF-IPCountry"})
+	ip := net.ParseIP("198.51.100.1")
+	for country, want := range map[string]string{
+		"KP": "not available in your region",
+		"ir": "not available in your region",
+		"DE": "",
+		"XX": "", // unknown, let through unless failing closed
+		"":   "",
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("CF-IPCountry", country)
+		if got := filter.check(req, ip); got != want {
+			t.Errorf("check from %q = %q, want %q", country, got, want)
+		}
+	}
+
+	filter.failClosed = true
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("CF-IPCountry", "XX")
+	if got := filter.check(req, ip); got != "country could not be determined" {
+		t.Errorf("check failing closed = %q", got)
+	}
+	filter.geoIP = failingGeoIP{}
+	if got := filter.check(req, ip); got != "country could not be determined" {
+		t.Errorf("check failing closed on a lookup error = %q", got)
+	}
+	filter.failClosed = false
+	if got := filter.check(req, ip); got != "" {
+		t.Errorf("check failing open on a lookup error = %q", got)
+	}
+}
+
+// fromAddr sends a request from remoteAddr with an X-Forwarded-For header.
+func fromAddr(s *Server, remoteAddr, forwardedFor string) int {
+	req := httptest.NewRequest("GET", "/api/v1/lobbies", nil)
+	req.RemoteAddr = remoteAddr + ":40000"
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	recorder := httptest.NewRecorder()
+	s.router.ServeHTTP(recorder, req)
+	return recorder.Code
+}
+
+// X-Forwarded-For counts only from TRUSTED_PROXIES, so a denied client
+// can't claim another address and an allowed one can't be framed.
+func TestForwardedForNeedsTrustedProxy(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.IPDenyList = "203.0.113.9"
+	})
+	if code := fromAddr(s, "203.0.113.9", "198.51.100.1"); code != 403 {
+		t.Errorf("Denied client claiming another address: got %d, want 403", code)
+	}
+	if code := fromAddr(s, "198.51.100.1", "203.0.113.9"); code != 200 {
+		t.Errorf("Untrusted X-Forwarded-For naming a denied address: got %d, want 200", code)
+	}
+
+	s = newTestServer(t, func(cfg *config.Config) {
+		cfg.IPDenyList = "203.0.113.9"
+		cfg.TrustedProxies = "10.0.0.1"
+	})
+	if code := fromAddr(s, "10.0.0.1", "203.0.113.9"); code != 403 {
+		t.Errorf("Denied client behind the proxy: got %d, want 403", code)
+	}
+	if code := fromAddr(s, "10.0.0.1", "198.51.100.1"); code != 200 {
+		t.Errorf("Allowed client behind the proxy: got %d, want 200", code)
+	}
+	// The proxy appends the address it saw; an address the client put in
+	// front of it is ignored
+	if code := fromAddr(s, "10.0.0.1", "198.51.100.1, 203.0.113.9"); code != 403 {
+		t.Errorf("Denied client spoofing X-Forwarded-For through the proxy: got %d, want 403", code)
+	}
+	if code := fromAddr(s, "203.0.113.9", "198.51.100.1"); code != 403 {
+		t.Errorf("Denied client bypassing the proxy: got %d, want 403", code)
+	}
+	if code := fromAddr(s, "10.0.0.2", "203.0.113.9"); code != 200 {
+		t.Errorf("X-Forwarded-For from an untrusted address: got %d, want 200", code)
+	}
+}
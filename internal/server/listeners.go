@@ -0,0 +1,128 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unixPrefix marks a listen address as a unix socket path, e.g.
+// "unix:/run/quiz/http.sock".
+const unixPrefix = "unix:"
+
+// listenAddrs returns the addresses the game's HTTP and WebSocket traffic
+// is served on: LISTEN_ADDRS if set, otherwise every interface on PORT.
+func (s *Server) listenAddrs() []string {
+	var addrs []string
+	for _, addr := range strings.Split(s.config.ListenAddrs, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	if len(addrs) == 0 {
+		addrs = []string{":" + s.config.Port}
+	}
+	return addrs
+}
+
+// listen opens a TCP or unix socket listener for addr. A socket file left
+// behind by a previous run is removed first; anything else at the path is
+// an error rather than being overwritten.
+func listen(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, unixPrefix)
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// withUnixPeers gives requests that arrived over a unix socket, which have no
+// remote address, the loopback address instead, so IP rules and
+// TRUSTED_PROXIES treat the sidecar proxy in front of the socket as local.
+func withUnixPeers(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, _, err := net.SplitHostPort(r.RemoteAddr); err != nil {
+			r.RemoteAddr = "127.0.0.1:0"
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// serveOn serves srv on each address until it's shut down, over TLS when
+// srv has a TLSConfig, sending the first failure on the returned channel.
+// Listening on any address failing closes the listeners already opened.
+func serveOn(srv *http.Server, addrs []string) (<-chan error, error) {
+	listeners := make([]net.Listener, 0, len(addrs))
+	for _, addr := range addrs {
+		l, err := listen(addr)
+		if err != nil {
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("listen on %s: %w", addr, err)
+		}
+		listeners = append(listeners, l)
+	}
+
+	serveErr := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func(l net.Listener) {
+			if srv.TLSConfig != nil {
+				serveErr <- srv.ServeTLS(l, "", "")
+				return
+			}
+			serveErr <- srv.Serve(l)
+		}(l)
+	}
+	return serveErr, nil
+}
+
+// startSideServer runs a listener next to the game's, such as the debug
+// server, in the background. A failure is logged rather than fatal: the game
+// keeps serving without it.
+func startSideServer(name string, srv *http.Server) {
+	serveErr, err := serveOn(srv, []string{srv.Addr})
+	if err != nil {
+		log.Printf("%s server not started: %v", name, err)
+		return
+	}
+	go func() {
+		if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("%s server stopped: %v", name, err)
+		}
+	}()
+}
+
+// newAdminServer serves the admin API and /debug/stats on the internal
+// admin address, keeping them off the game's listeners entirely.
+func (s *Server) newAdminServer() *http.Server {
+	router := gin.New()
+	router.Use(gin.Logger(), gin.Recovery())
+	if err := router.SetTrustedProxies(trustedProxies(s.config)); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	if s.ipFilter != nil {
+		router.Use(s.filterIPs)
+	}
+	s.setupAdminRoutes(router)
+	router.GET("/debug/stats", gin.WrapF(s.debugStats))
+
+	return &http.Server{
+		Addr:    s.config.AdminAddr,
+		Handler: withUnixPeers(router),
+	}
+}
@@ -0,0 +1,97 @@
+package server
+
+import "time"
+
+// The data of the messages clients send over the WebSocket, in the
+// WebSocketMessage envelope. An answer is given as ParseSubmittedAnswer
+// reads it: an option's index, an array of indexes for multi-select
+// questions, or text or a number for free-text and numeric ones.
+
+type helloData struct {
+	ProtocolVersion int  `json:"protocol_version" binding:"required"`
+	Acks            bool `json:"acks"`
+}
+
+type joinLobbyData struct {
+	Username     string `json:"username"`      // ignored when logged in
+	SessionToken string `json:"session_token"` // a guest rejoining their seat
+	Audience     bool   `json:"audience"`
+	PaymentID    string `json:"payment_id"`
+	LastSeq      uint64 `json:"last_seq"` // resume after this event
+	// The solved challenge, when CHALLENGE_MODE is set
+	Challenge    string `json:"challenge"`
+	Nonce        string `json:"nonce"`
+	CaptchaToken string `json:"captcha_token"`
+}
+
+type answerData struct {
+	Answer interface{} `json:"answer" binding:"required"`
+	SentAt time.Time   `json:"sent_at"` // optional, in server time by the client's clock offset
+}
+
+type warmUpAnswerData struct {
+	Answer interface{} `json:"answer" binding:"required"`
+}
+
+type audienceAnswerData struct {
+	Answer interface{} `json:"answer" binding:"required"`
+}
+
+type chatData struct {
+	Message string `json:"message" binding:"required"`
+}
+
+type createPollData struct {
+	Question        string   `json:"question" binding:"required"`
+	Options         []string `json:"options" binding:"required"`
+	DurationSeconds int      `json:"duration_seconds"`
+	Apply           string   `json:"apply"`
+}
+
+type pollVoteData struct {
+	PollID string `json:"poll_id" binding:"required"`
+	Option int    `json:"option" binding:"required"`
+}
+
+type wagerData struct {
+	Stake int `json:"stake" binding:"required"`
+}
+
+type powerUpData struct {
+	PowerUp string `json:"power_up" binding:"required"`
+}
+
+// connectedMessage is the first frame on every connection, outside the
+// event envelope.
+type connectedMessage struct {
+	Type               string `json:"type"` // always "connected"
+	ClientID           string `json:"client_id"`
+	ProtocolVersion    int    `json:"protocol_version"`
+	MinProtocolVersion int    `json:"min_protocol_version"`
+}
+
+// clientMessage is a message type handleWebSocketMessage accepts, with the
+// type of its data, or nil for messages that carry none.
+type clientMessage struct {
+	typ     string
+	summary string
+	data    interface{}
+}
+
+var clientMessages = []clientMessage{
+	{"hello", "Settle the protocol version and whether messages are acknowledged", helloData{}},
+	{"join_lobby", "Join the lobby in lobby_id, or resume a seat", joinLobbyData{}},
+	{"leave_lobby", "Leave the lobby", nil},
+	{"start_game", "Start the game", nil},
+	{"pause_game", "Pause the game (host only)", nil},
+	{"resume_game", "Resume the game (host only)", nil},
+	{"submit_answer", "Answer the current question", answerData{}},
+	{"submit_warmup_answer", "Answer the current warm-up question", warmUpAnswerData{}},
+	{"submit_audience_answer", "Answer as the audience member this connection joined as", audienceAnswerData{}},
+	{"chat_message", "Send a chat message", chatData{}},
+	{"create_poll", "Open a poll (host only)", createPollData{}},
+	{"poll_vote", "Vote in the open poll", pollVoteData{}},
+	{"rsvp", "Answer a scheduled game's invitation", rsvpRequest{}},
+	{"place_wager", "Stake points on the wager round", wagerData{}},
+	{"use_power_up", "Use one of the player's power-ups", powerUpData{}},
+}
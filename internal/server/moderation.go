@@ -0,0 +1,123 @@
+package server
+
+import (
+	"errors"
+	"strconv"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type muteRequest struct {
+	PlayerID string `json:"player_id" binding:"required"`
+	TargetID string `json:"target_id" binding:"required"`
+	Scope    string `json:"scope"` // "self" (default) or "lobby", host only
+}
+
+type reportRequest struct {
+	PlayerID string `json:"player_id" binding:"required"`
+	TargetID string `json:"target_id" binding:"required"`
+	Reason   string `json:"reason"`
+}
+
+// reportResponse says whether the report got the reported player muted.
+type reportResponse struct {
+	ReportID int64 `json:"report_id"`
+	Muted    bool  `json:"muted"`
+}
+
+type playerReportsResponse struct {
+	Reports []*models.PlayerReport `json:"reports"`
+}
+
+func moderationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrLobbyNotFound), errors.Is(err, services.ErrPlayerNotFound):
+		return 404
+	case errors.Is(err, services.ErrNotHost):
+		return 403
+	case errors.Is(err, services.ErrAlreadyReported):
+		return 409
+	case errors.Is(err, services.ErrInvalidMuteScope), errors.Is(err, services.ErrCannotMuteSelf),
+		errors.Is(err, services.ErrReportTooLong):
+		return 400
+	}
+	return 500 // the report couldn't be saved
+}
+
+func (s *Server) mutePlayer(c *gin.Context) {
+	s.setMuted(c, true)
+}
+
+func (s *Server) unmutePlayer(c *gin.Context) {
+	s.setMuted(c, false)
+}
+
+func (s *Server) setMuted(c *gin.Context, muted bool) {
+	var req muteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if !s.checkSession(c, c.Param("id"), req.PlayerID) {
+		return
+	}
+
+	var err error
+	if muted {
+		err = s.gameService.MutePlayer(c.Param("id"), req.PlayerID, req.TargetID, req.Scope)
+	} else {
+		err = s.gameService.UnmutePlayer(c.Param("id"), req.PlayerID, req.TargetID, req.Scope)
+	}
+	if err != nil {
+		c.JSON(moderationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if muted {
+		c.JSON(200, messageResponse{"Player muted"})
+	} else {
+		c.JSON(200, messageResponse{"Player unmuted"})
+	}
+}
+
+func (s *Server) reportPlayer(c *gin.Context) {
+	var req reportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if !s.checkSession(c, c.Param("id"), req.PlayerID) {
+		return
+	}
+
+	report, autoMuted, err := s.gameService.ReportPlayer(c.Param("id"), req.PlayerID, req.TargetID, req.Reason)
+	if err != nil {
+		c.JSON(moderationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(201, reportResponse{report.ID, autoMuted})
+}
+
+// getPlayerReports lists player reports for review, newest first: all of
+// them or with ?lobby_id= one lobby's. ?limit= caps how many, default 50.
+func (s *Server) getPlayerReports(c *gin.Context) {
+	limit := 50
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 500 {
+			c.JSON(400, gin.H{"error": "limit must be between 1 and 500"})
+			return
+		}
+		limit = n
+	}
+
+	reports, err := s.gameService.PlayerReports(c.Query("lobby_id"), limit)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, playerReportsResponse{reports})
+}
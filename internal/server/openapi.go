@@ -0,0 +1,573 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"buildprize-game/internal/api"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/payouts"
+	"buildprize-game/internal/services"
+
+	"github.com/gin-gonic/gin"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// How an operation authenticates, as its security requirement in the
+// document.
+const (
+	authAccount         = "account"          // a logged-in account's bearer token
+	authOptionalAccount = "optional account" // guests too
+	authSession         = "session"          // the session token of the player_id given
+	authOptionalSession = "optional session"
+	authAdmin           = "admin" // the admin token or an API key
+)
+
+// apiOperation documents one route. Its request and response bodies are
+// described from the types the handler binds and answers with, so changing
+// those changes the document.
+type apiOperation struct {
+	method, path string // as registered, e.g. /api/v1/lobbies/:id
+	tag, summary string
+	auth         string
+	challenge    bool // needs a solved challenge when CHALLENGE_MODE is set
+	params       []apiParam
+	request      interface{} // the JSON body, or nil
+	optionalBody bool
+	status       int         // on success; 200 when unset
+	response     interface{} // the success body, or nil for none
+}
+
+// apiParam is a query parameter.
+type apiParam struct {
+	name, typ, description string
+}
+
+func param(name, typ, description string) apiParam {
+	return apiParam{name, typ, description}
+}
+
+// errorResponse is every failed request's answer.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+var apiOperations = []apiOperation{
+	{method: "POST", path: "/api/v1/auth/register", tag: "accounts", summary: "Register an account and log in", challenge: true, request: credentials{}, status: 201, response: tokenResponse{}},
+	{method: "POST", path: "/api/v1/auth/login", tag: "accounts", summary: "Log in", request: credentials{}, response: tokenResponse{}},
+	{method: "GET", path: "/api/v1/auth/me", tag: "accounts", summary: "The logged-in account", auth: authAccount, response: api.User{}},
+
+	{method: "POST", path: "/api/v1/lobbies", tag: "lobbies", summary: "Create a lobby; webhook_url needs an API key with the webhooks scope", challenge: true, request: createLobbyRequest{}, status: 201, response: createdLobbyResponse{}},
+	{method: "GET", path: "/api/v1/lobbies", tag: "lobbies", summary: "List lobbies", params: []apiParam{
+		param("limit", "integer", "1-100, default 50"),
+		param("offset", "integer", "default 0"),
+		param("state", "string", "waiting (default), in_progress, finished or all"),
+		param("sort", "string", "newest (default), oldest, name or players"),
+		param("has_space", "boolean", "only lobbies with a free seat"),
+	}, response: lobbyPageResponse{}},
+	{method: "GET", path: "/api/v1/lobbies/:id", tag: "lobbies", summary: "A lobby hosted here", response: api.Lobby{}},
+	{method: "PATCH", path: "/api/v1/lobbies/:id", tag: "lobbies", summary: "Change a waiting lobby's settings (host only); fields left out are unchanged", auth: authSession, request: updateLobbyRequest{}, response: api.Lobby{}},
+	{method: "GET", path: "/api/v1/lobbies/:id/state", tag: "lobbies", summary: "Everything a reconnecting client needs to resync", response: api.LobbyState{}},
+	{method: "POST", path: "/api/v1/lobbies/:id/join", tag: "lobbies", summary: "Join a lobby, as the logged-in account or a guest username", auth: authOptionalAccount, challenge: true, request: joinLobbyRequest{}, response: joinResponse{}},
+	{method: "POST", path: "/api/v1/lobbies/:id/leave", tag: "lobbies", summary: "Leave a lobby", auth: authSession, request: playerRequest{}, response: messageResponse{}},
+	{method: "POST", path: "/api/v1/lobbies/:id/rsvp", tag: "lobbies", summary: "Answer a scheduled game's invitation", request: rsvpRequest{}, response: rsvpResponse{}},
+	{method: "POST", path: "/api/v1/lobbies/:id/entry-payment", tag: "lobbies", summary: "Start paying a lobby's entry fee", auth: authOptionalAccount, request: entryPaymentRequest{}, status: 201, response: payouts.Payment{}},
+
+	{method: "POST", path: "/api/v1/lobbies/:id/start", tag: "game", summary: "Start the game", response: messageResponse{}},
+	{method: "POST", path: "/api/v1/lobbies/:id/pause", tag: "game", summary: "Pause the game (host only)", auth: authSession, request: playerRequest{}, response: messageResponse{}},
+	{method: "POST", path: "/api/v1/lobbies/:id/resume", tag: "game", summary: "Resume the game (host only)", auth: authSession, request: playerRequest{}, response: messageResponse{}},
+	{method: "POST", path: "/api/v1/lobbies/:id/end", tag: "game", summary: "End the game with the scores so far (host only)", auth: authSession, request: playerRequest{}, response: messageResponse{}},
+	{method: "POST", path: "/api/v1/lobbies/:id/cancel", tag: "game", summary: "Cancel the game without results (host only)", auth: authSession, request: playerRequest{}, response: messageResponse{}},
+	{method: "POST", path: "/api/v1/lobbies/:id/answer", tag: "game", summary: "Answer the current question", auth: authSession, request: answerRequest{}, response: messageResponse{}},
+	{method: "POST", path: "/api/v1/lobbies/:id/warmup-answer", tag: "game", summary: "Answer the current warm-up question", auth: authSession, request: warmUpAnswerRequest{}, response: services.WarmUpAnswerResult{}},
+	{method: "POST", path: "/api/v1/lobbies/:id/audience", tag: "game", summary: "Join a lobby's audience, or rejoin it with the member's session token", challenge: true, request: joinAudienceRequest{}, response: audienceJoinResponse{}},
+	{method: "POST", path: "/api/v1/lobbies/:id/audience/answer", tag: "game", summary: "Answer as an audience member", auth: authSession, request: audienceAnswerRequest{}, response: services.AudienceAnswerResult{}},
+	{method: "POST", path: "/api/v1/lobbies/:id/polls", tag: "game", summary: "Open a poll (host only)", auth: authSession, request: createPollRequest{}, status: 201, response: api.Poll{}},
+	{method: "POST", path: "/api/v1/lobbies/:id/polls/:poll_id/vote", tag: "game", summary: "Vote in a poll", auth: authSession, request: voteRequest{}, response: messageResponse{}},
+	{method: "GET", path: "/api/v1/lobbies/:id/events/poll", tag: "game", summary: "Long poll for the lobby's events", params: []apiParam{
+		param("after_seq", "integer", "return the events after this seq, default 0"),
+		param("timeout", "integer", "seconds to wait for an event, 0-60, default 25"),
+	}, response: pollEventsResponse{}},
+
+	{method: "POST", path: "/api/v1/lobbies/:id/chat", tag: "chat", summary: "Send a chat message; a refused one is answered with its code", auth: authSession, request: chatRequest{}, response: messageResponse{}},
+	{method: "GET", path: "/api/v1/lobbies/:id/chat", tag: "chat", summary: "The lobby's stored chat, oldest first", params: []apiParam{
+		param("since", "string", "only messages sent after this RFC3339 timestamp"),
+		param("limit", "integer", "1-200"),
+	}, response: chatHistoryResponse{}},
+	{method: "POST", path: "/api/v1/lobbies/:id/mute", tag: "chat", summary: "Mute a player for yourself, or the lobby (host only)", auth: authSession, request: muteRequest{}, response: messageResponse{}},
+	{method: "POST", path: "/api/v1/lobbies/:id/unmute", tag: "chat", summary: "Unmute a player", auth: authSession, request: muteRequest{}, response: messageResponse{}},
+	{method: "POST", path: "/api/v1/lobbies/:id/report", tag: "chat", summary: "Report a player", auth: authSession, request: reportRequest{}, status: 201, response: reportResponse{}},
+
+	{method: "GET", path: "/api/v1/lobbies/:id/results", tag: "prizes", summary: "A prize game's results and disputes", response: api.PrizeResult{}},
+	{method: "GET", path: "/api/v1/lobbies/:id/prizes", tag: "prizes", summary: "How the prize pool is shared; the player's own claim code with their session token", auth: authOptionalSession, params: []apiParam{
+		param("player_id", "string", "the player to include the claim code of"),
+	}, response: prizesResponse{}},
+	{method: "POST", path: "/api/v1/lobbies/:id/prizes/claim", tag: "prizes", summary: "Claim a share of the prize pool", request: claimPrizeRequest{}, response: models.Allocation{}},
+	{method: "POST", path: "/api/v1/lobbies/:id/disputes", tag: "prizes", summary: "Dispute a prize game's results", auth: authSession, request: disputeRequest{}, status: 201, response: models.Dispute{}},
+	{method: "POST", path: "/api/v1/payouts/webhook", tag: "prizes", summary: "Payout provider notifications, signed by the provider", status: 204},
+
+	{method: "GET", path: "/api/v1/players/:id/recommendations", tag: "players", summary: "Categories for a player to practice", response: recommendationsResponse{}},
+	{method: "GET", path: "/api/v1/players/:id/stats", tag: "players", summary: "An account's lifetime stats", response: models.PlayerStats{}},
+	{method: "GET", path: "/api/v1/players/:id/profile", tag: "players", summary: "An account with its stats and streaks", response: api.Profile{}},
+	{method: "POST", path: "/api/v1/players/:id/practice-lobby", tag: "players", summary: "Create a lobby to practice the top recommendation", challenge: true, status: 201, response: practiceLobbyResponse{}},
+
+	{method: "GET", path: "/api/v1/friends", tag: "friends", summary: "The account's friends and friend requests", auth: authAccount, response: friendsResponse{}},
+	{method: "GET", path: "/api/v1/friends/online", tag: "friends", summary: "Friends online now", auth: authAccount, response: friendsResponse{}},
+	{method: "POST", path: "/api/v1/friends", tag: "friends", summary: "Send a friend request, or accept one (200)", auth: authAccount, request: addFriendRequest{}, status: 201, response: models.Friend{}},
+	{method: "DELETE", path: "/api/v1/friends/:id", tag: "friends", summary: "Remove a friend", auth: authAccount, response: messageResponse{}},
+	{method: "POST", path: "/api/v1/friends/:id/invite", tag: "friends", summary: "Invite an online friend to a lobby", auth: authAccount, request: inviteFriendRequest{}, response: messageResponse{}},
+
+	{method: "GET", path: "/api/v1/events", tag: "events", summary: "Recurring events' upcoming starts, soonest first", params: []apiParam{
+		param("limit", "integer", "1-100, default 20"),
+	}, response: upcomingEventsResponse{}},
+	{method: "GET", path: "/api/v1/games/:id/replay", tag: "replays", summary: "A finished game's events; served as server-sent events with playback", params: []apiParam{
+		param("playback", "boolean", "stream the events as text/event-stream, spaced as they were sent"),
+		param("speed", "number", "playback speed, 0.25-16, default 1"),
+	}, response: replayResponse{}},
+	{method: "GET", path: "/api/v1/graphql", tag: "graphql", summary: "Run a read-only GraphQL query", params: []apiParam{
+		param("query", "string", "the query"),
+		param("operationName", "string", "the operation to run"),
+		param("variables", "string", "the variables, as a JSON object"),
+	}, response: graphql.Response{}},
+	{method: "POST", path: "/api/v1/graphql", tag: "graphql", summary: "Run a read-only GraphQL query", request: graphQLRequest{}, response: graphql.Response{}},
+	{method: "GET", path: "/api/v1/challenge", tag: "challenge", summary: "A challenge to solve before creating or joining a lobby", response: challengeResponse{}},
+	{method: "GET", path: "/api/v1/i18n", tag: "i18n", summary: "The languages messages are served in", response: languagesResponse{}},
+	{method: "GET", path: "/api/v1/i18n/:lang", tag: "i18n", summary: "A language's message catalog", response: catalogResponse{}},
+	{method: "GET", path: "/api/v1/openapi.json", tag: "docs", summary: "This document"},
+	{method: "GET", path: "/api/v1/docs", tag: "docs", summary: "Swagger UI for this document"},
+
+	{method: "GET", path: "/api/v1/admin/api-keys", tag: "admin", summary: "List API keys", auth: authAdmin, response: apiKeysResponse{}},
+	{method: "POST", path: "/api/v1/admin/api-keys", tag: "admin", summary: "Issue an API key", auth: authAdmin, request: createAPIKeyRequest{}, status: 201, response: issuedAPIKey{}},
+	{method: "POST", path: "/api/v1/admin/api-keys/:id/rotate", tag: "admin", summary: "Give an API key a new secret", auth: authAdmin, request: rotateAPIKeyRequest{}, optionalBody: true, response: issuedAPIKey{}},
+	{method: "DELETE", path: "/api/v1/admin/api-keys/:id", tag: "admin", summary: "Revoke an API key", auth: authAdmin, response: api.APIKey{}},
+	{method: "POST", path: "/api/v1/admin/sandbox/lobbies", tag: "admin", summary: "Create a sandbox lobby", auth: authAdmin, request: sandboxLobbyRequest{}, status: 201, response: models.Lobby{}},
+	{method: "POST", path: "/api/v1/admin/sandbox/lobbies/:id/players", tag: "admin", summary: "Add a scripted test player to a sandbox lobby", auth: authAdmin, request: testPlayerRequest{}, status: 201, response: models.Player{}},
+	{method: "POST", path: "/api/v1/admin/sandbox/lobbies/:id/answers", tag: "admin", summary: "Answer for a sandbox lobby's player", auth: authAdmin, request: injectAnswerRequest{}, response: messageResponse{}},
+	{method: "POST", path: "/api/v1/admin/lobbies/:id/end", tag: "admin", summary: "End a game with the scores so far", auth: authAdmin, response: messageResponse{}},
+	{method: "POST", path: "/api/v1/admin/lobbies/:id/cancel", tag: "admin", summary: "Cancel a game without results", auth: authAdmin, response: messageResponse{}},
+	{method: "POST", path: "/api/v1/admin/lobbies/:id/recompute-scores", tag: "admin", summary: "Re-score a finished game, applied or as a report", auth: authAdmin, request: recomputeRequest{}, response: services.ScoreRecomputation{}},
+	{method: "GET", path: "/api/v1/admin/scoring-configs", tag: "admin", summary: "List scoring versions", auth: authAdmin, response: scoringConfigsResponse{}},
+	{method: "POST", path: "/api/v1/admin/scoring-configs", tag: "admin", summary: "Add a scoring version", auth: authAdmin, request: scoringConfigRequest{}, status: 201, response: models.ScoringConfig{}},
+	{method: "POST", path: "/api/v1/admin/scoring-configs/:version/activate", tag: "admin", summary: "Score new games under a version", auth: authAdmin, request: activateScoringRequest{}, optionalBody: true, response: activeScoringResponse{}},
+	{method: "GET", path: "/api/v1/admin/scoring-audit", tag: "admin", summary: "Scoring changes, newest first", auth: authAdmin, response: []*models.ScoringAuditEntry{}},
+	{method: "GET", path: "/api/v1/admin/audit", tag: "admin", summary: "Admin actions, newest first", auth: authAdmin, params: []apiParam{
+		param("action", "string", "only this action"),
+		param("actor", "string", "only this actor's"),
+		param("target", "string", "only those on this target"),
+		param("limit", "integer", "1-500, default 50"),
+	}, response: adminActionsResponse{}},
+	{method: "GET", path: "/api/v1/admin/lobbies/:id/events", tag: "admin", summary: "A lobby's stored event log", auth: authAdmin, params: []apiParam{
+		param("after_seq", "integer", "return the events after this seq, default 0"),
+		param("limit", "integer", "1-1000, default 500"),
+	}, response: gameEventsResponse{}},
+	{method: "GET", path: "/api/v1/admin/question-cache", tag: "admin", summary: "Question cache hit rates", auth: authAdmin, response: services.QuestionCacheStats{}},
+	{method: "GET", path: "/api/v1/admin/question-sources", tag: "admin", summary: "Questions served per source", auth: authAdmin, response: services.QuestionSourceStats{}},
+	{method: "GET", path: "/api/v1/admin/questions/difficulty", tag: "admin", summary: "Questions' observed difficulty", auth: authAdmin, response: services.DifficultyReport{}},
+	{method: "POST", path: "/api/v1/admin/questions/difficulty/calibrate", tag: "admin", summary: "Recalibrate question difficulty now", auth: authAdmin, response: services.DifficultyReport{}},
+	{method: "GET", path: "/api/v1/admin/connections", tag: "admin", summary: "Send-queue health per connection, most backed up first", auth: authAdmin, params: []apiParam{
+		param("lobby_id", "string", "only this lobby's"),
+		param("degraded", "boolean", "only degraded connections"),
+	}, response: connectionsResponse{}},
+	{method: "GET", path: "/api/v1/admin/reports", tag: "admin", summary: "Player reports, newest first", auth: authAdmin, params: []apiParam{
+		param("lobby_id", "string", "only this lobby's"),
+		param("limit", "integer", "1-500, default 50"),
+	}, response: playerReportsResponse{}},
+	{method: "GET", path: "/api/v1/admin/prize-results", tag: "admin", summary: "Prize game results, most recently ended first", auth: authAdmin, params: []apiParam{
+		param("status", "string", "provisional or finalized"),
+	}, response: prizeResultsResponse{}},
+	{method: "POST", path: "/api/v1/admin/lobbies/:id/disputes/:dispute_id/resolve", tag: "admin", summary: "Uphold or reject a dispute", auth: authAdmin, request: resolveDisputeRequest{}, response: models.PrizeResult{}},
+	{method: "POST", path: "/api/v1/admin/lobbies/:id/prizes/:player_id/retry-payout", tag: "admin", summary: "Retry a failed payout", auth: authAdmin, status: 202, response: models.Allocation{}},
+	{method: "GET", path: "/api/v1/admin/questions/lint", tag: "admin", summary: "Lint the question bank", auth: authAdmin, response: services.LintReport{}},
+	{method: "POST", path: "/api/v1/admin/questions/lint", tag: "admin", summary: "Lint questions before adding them", auth: authAdmin, request: lintRequest{}, response: services.LintReport{}},
+	{method: "GET", path: "/api/v1/admin/events", tag: "admin", summary: "List recurring events", auth: authAdmin, response: recurringEventsResponse{}},
+	{method: "POST", path: "/api/v1/admin/events", tag: "admin", summary: "Schedule a recurring event", auth: authAdmin, request: recurringEventRequest{}, status: 201, response: models.RecurringEvent{}},
+	{method: "DELETE", path: "/api/v1/admin/events/:id", tag: "admin", summary: "Delete a recurring event", auth: authAdmin, response: messageResponse{}},
+}
+
+var apiSecurity = map[string][]map[string][]string{
+	authAccount:         {{"bearerAuth": {}}},
+	authOptionalAccount: {{}, {"bearerAuth": {}}},
+	authSession:         {{"sessionToken": {}}},
+	authOptionalSession: {{}, {"sessionToken": {}}},
+	authAdmin:           {{"adminToken": {}}, {"apiKey": {}}},
+}
+
+var challengeHeaders = []apiParam{
+	param("X-Challenge", "string", "a proof-of-work challenge from /challenge"),
+	param("X-Challenge-Nonce", "string", "its solution"),
+	param("X-Captcha-Token", "string", "a solved captcha, with CHALLENGE_MODE=captcha"),
+}
+
+// checkAPIDocs makes sure the document and the routes agree: every /api/v1
+// route on router is in apiOperations, and every operation is routed. Admin
+// routes aren't on the game's router when ADMIN_ADDR is set.
+func (s *Server) checkAPIDocs(router *gin.Engine) error {
+	routed := make(map[string]bool)
+	var undocumented []string
+	for _, route := range router.Routes() {
+		if !strings.HasPrefix(route.Path, "/api/v1/") || route.Method == "OPTIONS" {
+			continue
+		}
+		key := route.Method + " " + route.Path
+		routed[key] = true
+		if findOperation(route.Method, route.Path) == nil {
+			undocumented = append(undocumented, key)
+		}
+	}
+	var unrouted []string
+	for _, op := range apiOperations {
+		if s.config.AdminAddr != "" && strings.HasPrefix(op.path, "/api/v1/admin/") {
+			continue
+		}
+		if key := op.method + " " + op.path; !routed[key] {
+			unrouted = append(unrouted, key)
+		}
+	}
+	if len(undocumented) > 0 || len(unrouted) > 0 {
+		return fmt.Errorf("routes missing from apiOperations: %v; operations without a route: %v", undocumented, unrouted)
+	}
+	return nil
+}
+
+func findOperation(method, path string) *apiOperation {
+	for i := range apiOperations {
+		if apiOperations[i].method == method && apiOperations[i].path == path {
+			return &apiOperations[i]
+		}
+	}
+	return nil
+}
+
+// newOpenAPIDocument describes the REST API in apiOperations, and the
+// WebSocket's messages as schemas, in OpenAPI 3.0.
+func newOpenAPIDocument() ([]byte, error) {
+	schemas := newSchemaBuilder()
+	errorSchema := schemas.of(reflect.TypeOf(errorResponse{}))
+
+	paths := make(map[string]map[string]interface{})
+	for _, op := range apiOperations {
+		path := openAPIPath(op.path)
+		if paths[path] == nil {
+			paths[path] = make(map[string]interface{})
+		}
+		paths[path][strings.ToLower(op.method)] = op.document(schemas, errorSchema)
+	}
+	paths["/ws"] = map[string]interface{}{"get": webSocketOperation(schemas)}
+
+	return json.Marshal(map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "BuildPrize Quiz API",
+			"version":     "v1",
+			"description": "The quiz game's REST API, and the messages sent over its WebSocket at /ws. Errors are answered as {\"error\": \"...\"}.",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas.schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth":   map[string]interface{}{"type": "http", "scheme": "bearer", "description": "A token from /auth/login or /auth/register"},
+				"sessionToken": map[string]interface{}{"type": "apiKey", "in": "header", "name": sessionHeader, "description": "The session_token a join returned, for the player_id given"},
+				"apiKey":       map[string]interface{}{"type": "apiKey", "in": "header", "name": apiKeyHeader},
+				"adminToken":   map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-Admin-Token"},
+			},
+		},
+	})
+}
+
+func (op apiOperation) document(schemas *schemaBuilder, errorSchema map[string]interface{}) map[string]interface{} {
+	var params []map[string]interface{}
+	for _, name := range pathParams(op.path) {
+		params = append(params, map[string]interface{}{
+			"name": name, "in": "path", "required": true, "schema": map[string]interface{}{"type": "string"},
+		})
+	}
+	for _, p := range op.params {
+		params = append(params, p.document("query"))
+	}
+	if op.challenge {
+		for _, p := range challengeHeaders {
+			params = append(params, p.document("header"))
+		}
+	}
+
+	status := op.status
+	if status == 0 {
+		status = 200
+	}
+	success := map[string]interface{}{"description": "OK"}
+	if op.response != nil {
+		success["content"] = jsonContent(schemas.of(reflect.TypeOf(op.response)))
+	}
+	document := map[string]interface{}{
+		"tags":        []string{op.tag},
+		"summary":     op.summary,
+		"operationId": operationID(op.method, op.path),
+		"responses": map[string]interface{}{
+			fmt.Sprint(status): success,
+			"default":          map[string]interface{}{"description": "Error", "content": jsonContent(errorSchema)},
+		},
+	}
+	if len(params) > 0 {
+		document["parameters"] = params
+	}
+	if op.request != nil {
+		document["requestBody"] = map[string]interface{}{
+			"required": !op.optionalBody,
+			"content":  jsonContent(schemas.of(reflect.TypeOf(op.request))),
+		}
+	}
+	if security, ok := apiSecurity[op.auth]; ok {
+		document["security"] = security
+	}
+	return document
+}
+
+func (p apiParam) document(in string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        p.name,
+		"in":          in,
+		"description": p.description,
+		"schema":      map[string]interface{}{"type": p.typ},
+	}
+}
+
+// webSocketOperation documents /ws and puts the messages sent over it in
+// the components, as ClientMessage and ServerEvent.
+func webSocketOperation(schemas *schemaBuilder) map[string]interface{} {
+	var variants []map[string]interface{}
+	mapping := make(map[string]string)
+	for _, msg := range clientMessages {
+		envelope := schemas.of(reflect.TypeOf(WebSocketMessage{}))
+		properties := map[string]interface{}{
+			"type": map[string]interface{}{"type": "string", "enum": []string{msg.typ}},
+		}
+		if msg.data != nil {
+			properties["data"] = schemas.of(reflect.TypeOf(msg.data))
+		}
+		name := schemaName(msg.typ) + "Message"
+		schemas.schemas[name] = map[string]interface{}{
+			"description": msg.summary,
+			"allOf": []interface{}{envelope, map[string]interface{}{
+				"type":       "object",
+				"required":   []string{"type"},
+				"properties": properties,
+			}},
+		}
+		ref := "#/components/schemas/" + name
+		variants = append(variants, map[string]interface{}{"$ref": ref})
+		mapping[msg.typ] = ref
+	}
+	schemas.schemas["ClientMessage"] = map[string]interface{}{
+		"description":   "A message a client sends, as a JSON text frame or a MessagePack binary frame",
+		"oneOf":         variants,
+		"discriminator": map[string]interface{}{"propertyName": "type", "mapping": mapping},
+	}
+	schemas.schemas["ServerEvent"] = map[string]interface{}{
+		"description": "Every lobby event and direct reply the server sends, named by its type; lobby-wide events carry their seq",
+		"allOf":       []interface{}{schemas.of(reflect.TypeOf(models.GameEvent{}))},
+	}
+	schemas.of(reflect.TypeOf(connectedMessage{}))
+
+	return map[string]interface{}{
+		"tags":        []string{"websocket"},
+		"summary":     "Open the game's WebSocket",
+		"description": "The first frame is a ConnectedMessage. Clients then send ClientMessage frames and receive ServerEvent frames.",
+		"operationId": "openWebSocket",
+		"parameters": []map[string]interface{}{
+			param("token", "string", "a logged-in account's bearer token").document("query"),
+			param("capabilities", "string", "comma-separated, e.g. supports_images,supports_delta_updates,supports_msgpack").document("query"),
+		},
+		"responses": map[string]interface{}{
+			"101": map[string]interface{}{"description": "Switching to the WebSocket protocol"},
+		},
+	}
+}
+
+func jsonContent(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
+
+// openAPIPath turns gin's /lobbies/:id into /lobbies/{id}.
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func pathParams(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, ":") {
+			names = append(names, segment[1:])
+		}
+	}
+	return names
+}
+
+// operationID names an operation after its route, e.g. postLobbiesIdJoin.
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, segment := range strings.Split(strings.TrimPrefix(path, "/api/v1"), "/") {
+		id += schemaName(strings.TrimPrefix(segment, ":"))
+	}
+	return id
+}
+
+// schemaName turns join_lobby, api-keys or createLobbyRequest into
+// JoinLobby, ApiKeys or CreateLobbyRequest.
+func schemaName(name string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' || r == '.' }) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaBuilder describes types as encoding/json encodes them. Named
+// structs become component schemas, referred to by name. Types from other
+// modules, and the second of two types sharing a name, are prefixed with
+// their package's name.
+type schemaBuilder struct {
+	schemas map[string]interface{}
+	names   map[reflect.Type]string
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{schemas: make(map[string]interface{}), names: make(map[reflect.Type]string)}
+}
+
+func (b *schemaBuilder) of(t reflect.Type) map[string]interface{} {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case rawMessageType:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return b.of(t.Elem())
+	case reflect.Interface:
+		return map[string]interface{}{}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": b.of(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.object(t)
+		}
+		name, ok := b.names[t]
+		if !ok {
+			name = schemaName(t.Name())
+			pkg := t.PkgPath()
+			if _, taken := b.schemas[name]; taken || !strings.HasPrefix(pkg, "buildprize-game/") {
+				name = schemaName(pkg[strings.LastIndex(pkg, "/")+1:]) + name
+			}
+			b.names[t] = name
+			b.schemas[name] = nil // taken while its fields refer back to it
+			b.schemas[name] = b.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]interface{}{}
+}
+
+func (b *schemaBuilder) object(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+	b.fields(t, properties, &required)
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+// fields adds t's JSON fields, with those of structs it embeds, which
+// encoding/json promotes. Fields required by binding:"required" are listed.
+func (b *schemaBuilder) fields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				b.fields(embedded, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = b.of(field.Type)
+		if strings.Contains(field.Tag.Get("binding"), "required") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// getOpenAPI serves the document.
+func (s *Server) getOpenAPI(c *gin.Context) {
+	c.Data(200, "application/json; charset=utf-8", s.openAPI)
+}
+
+// swaggerUI loads Swagger UI from a CDN, pointed at the document.
+const swaggerUI = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>BuildPrize Quiz API</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+<script>
+window.ui = SwaggerUIBundle({url: "/api/v1/openapi.json", dom_id: "#swagger-ui"});
+</script>
+</body>
+</html>
+`
+
+func (s *Server) getAPIDocs(c *gin.Context) {
+	c.Data(200, "text/html; charset=utf-8", []byte(swaggerUI))
+}
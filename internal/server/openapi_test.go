@@ -0,0 +1,103 @@
+package server
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"buildprize-game/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiRoutes lists router's /api/v1 routes as "METHOD path".
+func apiRoutes(router *gin.Engine) []string {
+	var routes []string
+	for _, route := range router.Routes() {
+		if strings.HasPrefix(route.Path, "/api/v1/") && route.Method != "OPTIONS" {
+			routes = append(routes, route.Method+" "+route.Path)
+		}
+	}
+	return routes
+}
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	s := newTestServer(t, nil)
+	routes := apiRoutes(s.router)
+	if len(routes) == 0 {
+		t.Fatal("No /api/v1 routes")
+	}
+	routed := make(map[string]bool)
+	for _, route := range routes {
+		routed[route] = true
+		method, path, _ := strings.Cut(route, " ")
+		if findOperation(method, path) == nil {
+			t.Errorf("%s is missing from apiOperations", route)
+		}
+	}
+	for _, op := range apiOperations {
+		if !routed[op.method+" "+op.path] {
+			t.Errorf("%s %s is documented but not routed", op.method, op.path)
+		}
+	}
+
+	// With ADMIN_ADDR set the admin routes move to their own router
+	s = newTestServer(t, func(cfg *config.Config) {
+		cfg.AdminAddr = "127.0.0.1:0"
+	})
+	admin := gin.New()
+	s.setupAdminRoutes(admin)
+	for _, route := range apiRoutes(s.router) {
+		if strings.Contains(route, " /api/v1/admin/") {
+			t.Errorf("%s is on the game's router with ADMIN_ADDR set", route)
+		}
+	}
+	if len(apiRoutes(admin)) == 0 {
+		t.Fatal("No admin routes")
+	}
+	for _, route := range apiRoutes(admin) {
+		method, path, _ := strings.Cut(route, " ")
+		if findOperation(method, path) == nil {
+			t.Errorf("%s is missing from apiOperations", route)
+		}
+	}
+
+	s.router.GET("/api/v1/undocumented", func(c *gin.Context) {})
+	if err := s.checkAPIDocs(s.router); err == nil || !strings.Contains(err.Error(), "GET /api/v1/undocumented") {
+		t.Fatalf("checkAPIDocs with an undocumented route: %v", err)
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	s := newTestServer(t, nil)
+	resp := serve(s, "GET", "/api/v1/openapi.json", nil, nil)
+	if resp.Code != 200 {
+		t.Fatalf("GET openapi.json: %d", resp.Code)
+	}
+	var document struct {
+		OpenAPI string                                       `json:"openapi"`
+		Paths   map[string]map[string]map[string]interface{} `json:"paths"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &document); err != nil {
+		t.Fatalf("Decode the document: %v", err)
+	}
+	if document.OpenAPI != "3.0.3" {
+		t.Fatalf("openapi %q, want 3.0.3", document.OpenAPI)
+	}
+	for _, op := range apiOperations {
+		operation := document.Paths[openAPIPath(op.path)][strings.ToLower(op.method)]
+		if operation == nil {
+			t.Errorf("%s %s is missing from the document", op.method, op.path)
+			continue
+		}
+		if op.auth != "" && operation["security"] == nil {
+			t.Errorf("%s %s has no security requirement", op.method, op.path)
+		}
+	}
+	if document.Paths["/ws"]["get"] == nil {
+		t.Error("The WebSocket is missing from the document")
+	}
+	if _, ok := document.Paths["/api/v1/lobbies/{id}/join"]; !ok {
+		t.Error("Path parameters aren't written as {id}")
+	}
+}
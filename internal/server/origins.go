@@ -0,0 +1,145 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"buildprize-game/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// corsHeaders are the request headers browsers may send cross-origin.
+const corsHeaders = "Content-Type, Authorization, X-Requested-With, X-Admin-Token, X-API-Key, X-Challenge, X-Challenge-Nonce, X-Captcha-Token, X-Session-Token"
+
+// originPolicy is which web origins may call the API from a browser and open
+// WebSockets, from ALLOWED_ORIGINS: exact origins such as
+// "https://quiz.example.com", wildcards such as "https://*.example.com" for
+// any of a domain's subdomains, or "*" for any site.
+type originPolicy struct {
+	any       bool
+	exact     map[string]bool
+	wildcards []wildcardOrigin
+}
+
+// wildcardOrigin matches origins with scheme whose host ends in suffix, e.g.
+// ".example.com" on any port or ".example.com:8443" on that one.
+type wildcardOrigin struct {
+	scheme string
+	suffix string
+}
+
+func newOriginPolicy(cfg *config.Config) *originPolicy {
+	policy, err := parseOriginPolicy(cfg.AllowedOrigins)
+	if err != nil {
+		log.Fatalf("Invalid ALLOWED_ORIGINS: %v", err)
+	}
+	if policy.any {
+		log.Printf("ALLOWED_ORIGINS allows any site; list the frontend's origins in production so logged-in requests can't be made from other sites")
+	}
+	return policy
+}
+
+func parseOriginPolicy(list string) (*originPolicy, error) {
+	policy := &originPolicy{exact: make(map[string]bool)}
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(entry), "/"))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			policy.any = true
+			continue
+		}
+
+		u, err := url.Parse(entry)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+			u.Path != "" || u.RawQuery != "" || u.User != nil {
+			return nil, fmt.Errorf("%q is not an origin like https://quiz.example.com", entry)
+		}
+		if suffix, ok := strings.CutPrefix(u.Host, "*."); ok {
+			if suffix == "" || strings.Contains(suffix, "*") {
+				return nil, fmt.Errorf("%q: only a leading *. wildcard is supported", entry)
+			}
+			policy.wildcards = append(policy.wildcards, wildcardOrigin{u.Scheme, "." + suffix})
+			continue
+		}
+		if strings.Contains(u.Host, "*") {
+			return nil, fmt.Errorf("%q: only a leading *. wildcard is supported", entry)
+		}
+		policy.exact[u.Scheme+"://"+u.Host] = true
+	}
+	return policy, nil
+}
+
+// allows reports whether origin, an Origin header, is allowed.
+func (p *originPolicy) allows(origin string) bool {
+	if p.any {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if p.exact[origin] {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	for _, wildcard := range p.wildcards {
+		host := u.Hostname()
+		if strings.Contains(wildcard.suffix, ":") {
+			host = u.Host
+		}
+		if u.Scheme == wildcard.scheme && strings.HasSuffix(host, wildcard.suffix) && len(host) > len(wildcard.suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// cors answers browsers' CORS checks. Allowed origins are echoed back and
+// may send credentials; with "*" any site may call the API, but without
+// them, as browsers require. Other origins get no CORS headers, and their
+// preflight requests are refused.
+func (p *originPolicy) cors(c *gin.Context) {
+	origin := c.GetHeader("Origin")
+	allowed := origin != "" && p.allows(origin)
+	if !p.any {
+		c.Header("Vary", "Origin")
+	}
+	if allowed {
+		if p.any {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", corsHeaders)
+	}
+
+	if c.Request.Method == "OPTIONS" {
+		if origin != "" && !allowed {
+			c.AbortWithStatusJSON(403, gin.H{"error": "Origin not allowed"})
+			return
+		}
+		c.AbortWithStatus(204)
+		return
+	}
+	c.Next()
+}
+
+// checkWebSocketOrigin lets a WebSocket upgrade through from allowed
+// origins, the server's own, and clients that send no Origin, which aren't
+// browsers.
+func (p *originPolicy) checkWebSocketOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || p.allows(origin) {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"buildprize-game/internal/config"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestOriginPolicy(t *testing.T) {
+	policy, err := parseOriginPolicy("https://quiz.example.com/, http://localhost:3000, https://*.example.org, https://*.example.net:8443")
+	if err != nil {
+		t.Fatalf("parseOriginPolicy: %v", err)
+	}
+	for origin, want := range map[string]bool{
+		"https://quiz.example.com":     true,
+		"HTTPS://Quiz.Example.com":     true,
+		"http://localhost:3000":        true,
+		"https://a.example.org":        true,
+		"https://a.b.example.org:8443": true,
+		"https://a.example.net:8443":   true,
+		"https://a.example.net":        false, // the wildcard names a port
+		"http://quiz.example.com":      false, // wrong scheme
+		"https://quiz.example.com:444": false,
+		"http://localhost:3001":        false,
+		"https://example.org":          false, // the wildcard is for subdomains
+		"http://a.example.org":         false,
+		"https://evilexample.org":      false,
+		"https://example.org.evil.com": false,
+		"null":                         false,
+	} {
+		if got := policy.allows(origin); got != want {
+			t.Errorf("allows(%q) = %v, want %v", origin, got, want)
+		}
+	}
+
+	for _, list := range []string{"quiz.example.com", "ftp://quiz.example.com", "https://quiz.example.com/app", "https://a.*.example.com", "https://*."} {
+		if _, err := parseOriginPolicy(list); err == nil {
+			t.Errorf("parseOriginPolicy(%q) accepted an invalid origin", list)
+		}
+	}
+	if policy, _ := parseOriginPolicy("*"); !policy.allows("https://anywhere.test") {
+		t.Error("* should allow any origin")
+	}
+}
+
+func originRequest(s *Server, method, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/api/v1/lobbies", nil)
+	req.Header.Set("Origin", origin)
+	if method == "OPTIONS" {
+		req.Header.Set("Access-Control-Request-Method", "POST")
+	}
+	recorder := httptest.NewRecorder()
+	s.router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestCORS(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.AllowedOrigins = "https://quiz.example.com"
+	})
+
+	resp := originRequest(s, "OPTIONS", "https://quiz.example.com")
+	if resp.Code != 204 || resp.Header().Get("Access-Control-Allow-Origin") != "https://quiz.example.com" ||
+		resp.Header().Get("Access-Control-Allow-Credentials") != "true" ||
+		!strings.Contains(resp.Header().Get("Access-Control-Allow-Headers"), sessionHeader) {
+		t.Fatalf("Preflight from an allowed origin: %d %v", resp.Code, resp.Header())
+	}
+	resp = originRequest(s, "GET", "https://quiz.example.com")
+	if resp.Code != 200 || resp.Header().Get("Access-Control-Allow-Origin") != "https://quiz.example.com" {
+		t.Fatalf("Request from an allowed origin: %d %v", resp.Code, resp.Header())
+	}
+
+	resp = originRequest(s, "OPTIONS", "https://evil.example.com")
+	if resp.Code != 403 || resp.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("Preflight from a disallowed origin: %d %v", resp.Code, resp.Header())
+	}
+	// Simple requests are answered, but without CORS headers the browser
+	// keeps the response from the page
+	resp = originRequest(s, "GET", "https://evil.example.com")
+	if resp.Header().Get("Access-Control-Allow-Origin") != "" || resp.Header().Get("Vary") != "Origin" {
+		t.Fatalf("Request from a disallowed origin got CORS headers: %v", resp.Header())
+	}
+
+	s = newTestServer(t, func(cfg *config.Config) {
+		cfg.AllowedOrigins = "*"
+	})
+	resp = originRequest(s, "GET", "https://anywhere.test")
+	if resp.Header().Get("Access-Control-Allow-Origin") != "*" || resp.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Fatalf("Request with ALLOWED_ORIGINS=*: %v", resp.Header())
+	}
+}
+
+func TestWebSocketOrigin(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.AllowedOrigins = "https://quiz.example.com"
+	})
+	httpServer := httptest.NewServer(s.router)
+	defer httpServer.Close()
+	wsURL := "ws" + strings.TrimPrefix(httpServer.URL, "http") + "/ws"
+
+	for origin, allowed := range map[string]bool{
+		"https://quiz.example.com":  true,
+		"":                          true, // not a browser
+		httpServer.URL:              true, // the server's own pages
+		"https://evil.example.com":  false,
+		"http://quiz.example.com":   false,
+		"https://quiz.example.com.": false,
+	} {
+		header := http.Header{}
+		if origin != "" {
+			header.Set("Origin", origin)
+		}
+		conn, resp, err := websocket.DefaultDialer.Dial(wsURL, header)
+		if allowed {
+			if err != nil {
+				t.Errorf("Dial from %q: %v", origin, err)
+				continue
+			}
+			conn.Close()
+			continue
+		}
+		if err == nil {
+			conn.Close()
+			t.Errorf("Dial from disallowed origin %q succeeded", origin)
+			continue
+		}
+		if resp == nil || resp.StatusCode != 403 {
+			t.Errorf("Dial from disallowed origin %q: %v, want 403", origin, err)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"buildprize-game/internal/config"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/payouts"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPayoutWebhookBody caps webhook deliveries read from payout providers.
+const maxPayoutWebhookBody = 1 << 20
+
+// newPayoutProvider builds the payout provider PAYOUT_PROVIDER names from
+// its credentials.
+func newPayoutProvider(cfg *config.Config) (payouts.Provider, error) {
+	timeout := time.Duration(cfg.PayoutTimeout) * time.Second
+	switch cfg.PayoutProvider {
+	case "stripe":
+		if cfg.StripeSecretKey == "" || cfg.StripeWebhookSecret == "" {
+			return nil, errors.New("stripe payouts need STRIPE_SECRET_KEY and STRIPE_WEBHOOK_SECRET")
+		}
+		return payouts.NewStripeProvider(cfg.StripeSecretKey, cfg.StripeWebhookSecret, timeout), nil
+	case "paypal":
+		if cfg.PayPalClientID == "" || cfg.PayPalClientSecret == "" || cfg.PayPalWebhookID == "" {
+			return nil, errors.New("paypal payouts need PAYPAL_CLIENT_ID, PAYPAL_CLIENT_SECRET and PAYPAL_WEBHOOK_ID")
+		}
+		return payouts.NewPayPalProvider(cfg.PayPalClientID, cfg.PayPalClientSecret, cfg.PayPalWebhookID, cfg.PayPalSandbox, timeout), nil
+	case "wallet":
+		if cfg.PayoutWalletURL == "" || cfg.PayoutWalletSecret == "" {
+			return nil, errors.New("wallet payouts need PAYOUT_WALLET_URL and PAYOUT_WALLET_SECRET")
+		}
+		return payouts.NewWalletProvider(cfg.PayoutWalletURL, cfg.PayoutWalletSecret, timeout), nil
+	}
+	return nil, fmt.Errorf("unknown payout provider %q", cfg.PayoutProvider)
+}
+
+// payoutWebhook takes payout status updates from the configured provider.
+// Anything it sends that verifies is acknowledged, so it isn't retried.
+func (s *Server) payoutWebhook(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxPayoutWebhookBody))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = s.gameService.HandlePayoutWebhook(c.Request.Context(), c.Request.Header, body)
+	switch {
+	case err == nil:
+		c.Status(204)
+	case errors.Is(err, payouts.ErrInvalidSignature):
+		c.JSON(401, gin.H{"error": err.Error()})
+	default:
+		log.Printf("Payout webhook failed: %v", err)
+		c.JSON(prizeErrorStatus(err), gin.H{"error": err.Error()})
+	}
+}
+
+func (s *Server) retryPayout(c *gin.Context) {
+	allocation, err := s.gameService.RetryPayout(c.Param("id"), c.Param("player_id"))
+	if err != nil {
+		c.JSON(prizeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	s.recordAdminAction(c, models.AdminRetryPayout, c.Param("id"), "", map[string]interface{}{
+		"player_id": allocation.PlayerID,
+		"attempt":   allocation.PayoutAttempts,
+	})
+	c.JSON(202, allocation)
+}
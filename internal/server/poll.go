@@ -0,0 +1,54 @@
+package server
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pollEventsResponse carries the seq to poll after next.
+type pollEventsResponse struct {
+	Events []*models.GameEvent `json:"events"`
+	Seq    uint64              `json:"seq"`
+}
+
+// pollLobbyEvents is a long poll for clients that can't hold a WebSocket
+// open: it returns the lobby-wide events after ?after_seq= as soon as there
+// are any, waiting up to ?timeout= seconds (default 25, at most 60) for
+// them, as {"events": [...], "seq": N}. The next poll is after seq.
+func (s *Server) pollLobbyEvents(c *gin.Context) {
+	var afterSeq uint64
+	if raw := c.Query("after_seq"); raw != "" {
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "after_seq must be a sequence number"})
+			return
+		}
+		afterSeq = n
+	}
+	timeout := 25
+	if raw := c.Query("timeout"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 || n > 60 {
+			c.JSON(400, gin.H{"error": "timeout must be between 0 and 60 seconds"})
+			return
+		}
+		timeout = n
+	}
+
+	events, seq, err := s.gameService.PollEvents(c.Param("id"), afterSeq, time.Duration(timeout)*time.Second, c.Request.Context().Done())
+	switch {
+	case errors.Is(err, services.ErrLobbyNotFound):
+		c.JSON(404, gin.H{"error": "Lobby not found"})
+	case err != nil:
+		c.JSON(500, gin.H{"error": err.Error()})
+	default:
+		c.Header("Cache-Control", "no-store")
+		c.JSON(200, pollEventsResponse{events, seq})
+	}
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"buildprize-game/internal/api"
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type createPollRequest struct {
+	PlayerID        string   `json:"player_id" binding:"required"`
+	Question        string   `json:"question" binding:"required"`
+	Options         []string `json:"options" binding:"required"`
+	DurationSeconds int      `json:"duration_seconds"` // default 20, 5-120
+	Apply           string   `json:"apply"`            // "category" applies the winner to upcoming rounds
+}
+
+type voteRequest struct {
+	PlayerID string `json:"player_id" binding:"required"`
+	Option   *int   `json:"option" binding:"required"`
+}
+
+func (req createPollRequest) options() services.PollOptions {
+	return services.PollOptions{
+		Question: req.Question,
+		Options:  req.Options,
+		Duration: time.Duration(req.DurationSeconds) * time.Second,
+		Apply:    models.PollAction(req.Apply),
+	}
+}
+
+func pollErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrLobbyNotFound):
+		return 404
+	case errors.Is(err, services.ErrNotHost):
+		return 403
+	case errors.Is(err, services.ErrPollOpen), errors.Is(err, services.ErrAlreadyVoted):
+		return 409
+	}
+	return 400
+}
+
+func (s *Server) createPoll(c *gin.Context) {
+	var req createPollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if !s.checkSession(c, c.Param("id"), req.PlayerID) {
+		return
+	}
+
+	poll, err := s.gameService.CreatePoll(c.Param("id"), req.PlayerID, req.options())
+	if err != nil {
+		c.JSON(pollErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(201, api.FromPoll(poll))
+}
+
+func (s *Server) votePoll(c *gin.Context) {
+	var req voteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if !s.checkSession(c, c.Param("id"), req.PlayerID) {
+		return
+	}
+
+	if err := s.gameService.VotePoll(c.Param("id"), req.PlayerID, c.Param("poll_id"), *req.Option); err != nil {
+		c.JSON(pollErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, messageResponse{"Vote recorded"})
+}
+
+// handleCreatePoll opens a poll for the player this connection joined as;
+// only the host's requests succeed.
+func (s *Server) handleCreatePoll(client *hub.Client, msg *WebSocketMessage) {
+	lobbyID := msg.LobbyID
+	if lobbyID == "" {
+		lobbyID = client.LobbyID
+	}
+	data, ok := msg.Data.(map[string]interface{})
+	if lobbyID == "" || client.PlayerID == "" || !ok {
+		return
+	}
+
+	req := createPollRequest{PlayerID: client.PlayerID}
+	req.Question, _ = data["question"].(string)
+	if options, ok := data["options"].([]interface{}); ok {
+		for _, option := range options {
+			if text, ok := option.(string); ok {
+				req.Options = append(req.Options, text)
+			}
+		}
+	}
+	if seconds, ok := data["duration_seconds"].(float64); ok {
+		req.DurationSeconds = int(seconds)
+	}
+	req.Apply, _ = data["apply"].(string)
+
+	if _, err := s.gameService.CreatePoll(lobbyID, client.PlayerID, req.options()); err != nil {
+		log.Printf("handleCreatePoll: Player %s could not open a poll in lobby %s: %v", client.PlayerID, lobbyID, err)
+	}
+}
+
+func (s *Server) handlePollVote(client *hub.Client, msg *WebSocketMessage) {
+	lobbyID := msg.LobbyID
+	if lobbyID == "" {
+		lobbyID = client.LobbyID
+	}
+	data, ok := msg.Data.(map[string]interface{})
+	if lobbyID == "" || client.PlayerID == "" || !ok {
+		return
+	}
+
+	pollID, _ := data["poll_id"].(string)
+	option, ok := data["option"].(float64)
+	if !ok {
+		return
+	}
+	if err := s.gameService.VotePoll(lobbyID, client.PlayerID, pollID, int(option)); err != nil {
+		log.Printf("handlePollVote: Vote from player %s in lobby %s rejected: %v", client.PlayerID, lobbyID, err)
+	}
+}
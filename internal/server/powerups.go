@@ -0,0 +1,32 @@
+package server
+
+import (
+	"log"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+)
+
+// handleUsePowerUp spends a power-up of the player this connection joined
+// as on the open question. Refusals come back to the sender as
+// power_up_rejected.
+func (s *Server) handleUsePowerUp(client *hub.Client, msg *WebSocketMessage) {
+	lobbyID := msg.LobbyID
+	if lobbyID == "" {
+		lobbyID = client.LobbyID
+	}
+	data, ok := msg.Data.(map[string]interface{})
+	if lobbyID == "" || client.PlayerID == "" || !ok {
+		return
+	}
+
+	name, _ := data["power_up"].(string)
+	powerUp, err := models.ParsePowerUp(name)
+	if err == nil {
+		err = s.gameService.UsePowerUp(lobbyID, client.PlayerID, powerUp)
+	}
+	if err != nil {
+		log.Printf("handleUsePowerUp: %q from client %s in lobby %s rejected: %v", name, client.ID, lobbyID, err)
+		replyTo(client, lobbyID, "power_up_rejected", map[string]interface{}{"power_up": name, "error": err.Error()})
+	}
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"errors"
+	"strconv"
+
+	"buildprize-game/internal/api"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// prizesResponse is how a prize pool is shared: claim codes are left out of
+// the allocations but the requesting player's own.
+type prizesResponse struct {
+	LobbyID     string               `json:"lobby_id"`
+	Status      models.ResultsStatus `json:"status"`
+	PrizePool   *models.PrizePool    `json:"prize_pool"`
+	Allocations []*models.Allocation `json:"allocations"`
+}
+
+type claimPrizeRequest struct {
+	ClaimCode   string `json:"claim_code" binding:"required"`
+	Destination string `json:"destination"` // where to pay it, with a payout provider configured
+}
+
+type disputeRequest struct {
+	PlayerID string `json:"player_id" binding:"required"`
+	Reason   string `json:"reason" binding:"required"`
+}
+
+type prizeResultsResponse struct {
+	Results []*models.PrizeResult `json:"results"`
+}
+
+type resolveDisputeRequest struct {
+	Status         string `json:"status" binding:"required"` // upheld or rejected
+	Note           string `json:"note"`
+	Recompute      bool   `json:"recompute"`       // re-score the game, for upheld disputes
+	ScoringVersion string `json:"scoring_version"` // default: the version the game was played under
+	ChangedBy      string `json:"changed_by"`
+}
+
+func prizeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrNoPrizeResult), errors.Is(err, services.ErrDisputeNotFound),
+		errors.Is(err, services.ErrNoAnswerHistory), errors.Is(err, services.ErrNoPrizePool),
+		errors.Is(err, services.ErrNoAllocation), errors.Is(err, services.ErrPayoutsDisabled):
+		return 404
+	case errors.Is(err, services.ErrNotInResults), errors.Is(err, services.ErrInvalidClaimCode):
+		return 403
+	case errors.Is(err, services.ErrResultsFinal), errors.Is(err, services.ErrDisputeWindowClosed),
+		errors.Is(err, services.ErrAlreadyDisputed), errors.Is(err, services.ErrDisputeResolved),
+		errors.Is(err, services.ErrResultsNotFinal), errors.Is(err, services.ErrPrizeClaimed),
+		errors.Is(err, services.ErrPayoutNotRetryable), errors.Is(err, services.ErrPayoutProviderChanged):
+		return 409
+	case errors.Is(err, services.ErrInvalidDisputeReason), errors.Is(err, services.ErrInvalidDisputeStatus),
+		errors.Is(err, services.ErrUnknownScoringVersion), errors.Is(err, services.ErrInvalidPayoutDestination):
+		return 400
+	}
+	return 500
+}
+
+// getPrizeResults returns a prize game's results, provisional or final,
+// with its disputes.
+func (s *Server) getPrizeResults(c *gin.Context) {
+	result, err := s.gameService.PrizeResult(c.Param("id"))
+	if err != nil {
+		c.JSON(prizeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, api.FromPrizeResult(result))
+}
+
+// getPrizes returns how a lobby's prize pool is shared between its top
+// finishers. With ?player_id= and that player's session token, their claim
+// code is included once the results are final.
+func (s *Server) getPrizes(c *gin.Context) {
+	lobbyID := c.Param("id")
+	result, err := s.gameService.Prizes(lobbyID)
+	if err != nil {
+		c.JSON(prizeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	allocations := api.FromPrizeResult(result).Allocations
+	if playerID := c.Query("player_id"); playerID != "" && s.holdsSeat(c.GetHeader(sessionHeader), lobbyID, playerID) {
+		if own := result.FindAllocation(playerID); own != nil {
+			for i, allocation := range allocations {
+				if allocation.PlayerID == playerID {
+					allocations[i] = own
+				}
+			}
+		}
+	}
+	c.JSON(200, prizesResponse{lobbyID, result.Status, result.PrizePool, allocations})
+}
+
+func (s *Server) claimPrize(c *gin.Context) {
+	var req claimPrizeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	allocation, err := s.gameService.ClaimPrize(c.Param("id"), req.ClaimCode, req.Destination)
+	if err != nil {
+		c.JSON(prizeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	allocation.ClaimCode = ""
+	c.JSON(200, allocation)
+}
+
+func (s *Server) fileDispute(c *gin.Context) {
+	var req disputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if !s.checkSession(c, c.Param("id"), req.PlayerID) {
+		return
+	}
+
+	dispute, err := s.gameService.FileDispute(c.Param("id"), req.PlayerID, req.Reason)
+	if err != nil {
+		c.JSON(prizeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(201, dispute)
+}
+
+// listPrizeResults lists prize game results for review, most recently ended
+// first: all of them or with ?status= only provisional or finalized ones.
+func (s *Server) listPrizeResults(c *gin.Context) {
+	status := models.ResultsStatus(c.Query("status"))
+	if status != "" && status != models.ResultsProvisional && status != models.ResultsFinalized {
+		c.JSON(400, gin.H{"error": "status must be provisional or finalized"})
+		return
+	}
+
+	results, err := s.gameService.PrizeResults(status)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, prizeResultsResponse{results})
+}
+
+func (s *Server) resolveDispute(c *gin.Context) {
+	disputeID, err := strconv.Atoi(c.Param("dispute_id"))
+	if err != nil {
+		c.JSON(404, gin.H{"error": services.ErrDisputeNotFound.Error()})
+		return
+	}
+
+	var req resolveDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := s.gameService.ResolveDispute(c.Param("id"), disputeID, services.DisputeResolution{
+		Status:         models.DisputeStatus(req.Status),
+		Note:           req.Note,
+		Recompute:      req.Recompute,
+		ScoringVersion: req.ScoringVersion,
+	}, auditActor(c, req.ChangedBy))
+	if err != nil {
+		c.JSON(prizeErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	s.recordAdminAction(c, models.AdminResolveDispute, c.Param("id"), req.ChangedBy, map[string]interface{}{
+		"dispute_id": disputeID,
+		"status":     req.Status,
+		"recompute":  req.Recompute,
+	})
+	c.JSON(200, result)
+}
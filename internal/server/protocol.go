@@ -0,0 +1,52 @@
+package server
+
+import (
+	"log"
+
+	"buildprize-game/internal/hub"
+)
+
+// handleHello settles the message-format version for a connection. The
+// client sends the newest version it speaks and the server answers with a
+// hello carrying the version they'll both use, the lower of the two, and
+// whether client messages will be acknowledged. A client too old for this
+// server gets a protocol_error and carries on as a version 1 connection.
+func (s *Server) handleHello(client *hub.Client, msg *WebSocketMessage) {
+	data, _ := msg.Data.(map[string]interface{})
+	requested, ok := data["protocol_version"].(float64)
+	if !ok || int(requested) < hub.MinProtocolVersion {
+		log.Printf("handleHello: client %s asked for unsupported protocol version %v", client.ID, data["protocol_version"])
+		replyTo(client, "", "protocol_error", map[string]interface{}{
+			"error":                "unsupported protocol version",
+			"protocol_version":     hub.ProtocolVersion,
+			"min_protocol_version": hub.MinProtocolVersion,
+		})
+		return
+	}
+
+	version := int(requested)
+	if version > hub.ProtocolVersion {
+		version = hub.ProtocolVersion
+	}
+	acks, _ := data["acks"].(bool)
+	acks = acks && version >= 2
+	client.SetProtocol(version, acks)
+	log.Printf("handleHello: client %s speaks protocol version %d (acks: %v)", client.ID, version, acks)
+	replyTo(client, "", "hello", map[string]interface{}{
+		"protocol_version": version,
+		"acks":             acks,
+	})
+}
+
+// acknowledge confirms a client message carrying an id once it has been
+// handled, on connections that asked for acks. Events the message caused in
+// the client's lobby are queued first, so they arrive before the ack.
+func (s *Server) acknowledge(client *hub.Client, msg *WebSocketMessage) {
+	if msg.ID == nil || !client.Acks() {
+		return
+	}
+	if client.Hub != nil {
+		client.Hub.Sync()
+	}
+	replyTo(client, client.LobbyID, "ack", map[string]interface{}{"id": msg.ID})
+}
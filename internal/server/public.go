@@ -0,0 +1,225 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"buildprize-game/internal/api"
+	"buildprize-game/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPublicCacheEntries bounds the public API's response cache; past it new
+// responses go uncached until entries expire.
+const maxPublicCacheEntries = 1024
+
+// newPublicServer serves the read-only public API on the public address: the
+// lobby listing, lobbies and their leaderboards (final results once a game
+// has finished), and hub stats, for stats sites and dashboards. It has no
+// routes that change state and needs no auth, so it's served apart from the
+// game's router rather than as a subset of it.
+func (s *Server) newPublicServer() *http.Server {
+	router := gin.New()
+	router.Use(gin.Logger(), gin.Recovery())
+	if err := router.SetTrustedProxies(trustedProxies(s.config)); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	if s.ipFilter != nil {
+		router.Use(s.filterIPs)
+	}
+	router.Use(func(c *gin.Context) {
+		c.Header("Access-Control-Allow-Origin", "*")
+		c.Header("Access-Control-Allow-Methods", "GET, OPTIONS")
+		if c.Request.Method == "OPTIONS" {
+			c.AbortWithStatus(204)
+			return
+		}
+		c.Next()
+	})
+
+	cache := newResponseCache(time.Duration(s.config.PublicAPICacheSeconds) * time.Second)
+	public := router.Group("/public/v1", cache.serve)
+	{
+		public.GET("/lobbies", s.listLobbies)
+		public.GET("/lobbies/:id", s.getPublicLobby)
+		public.GET("/lobbies/:id/leaderboard", s.getPublicLeaderboard)
+		public.GET("/stats", s.getPublicStats)
+		public.GET("/events", s.listUpcomingEvents)
+	}
+
+	return &http.Server{
+		Addr:    s.config.PublicAPIAddr,
+		Handler: withUnixPeers(router),
+	}
+}
+
+// startPublicServer runs the public API in the background.
+func (s *Server) startPublicServer() *http.Server {
+	srv := s.newPublicServer()
+	log.Printf("Read-only public API listening on %s (cached for %ds)", srv.Addr, s.config.PublicAPICacheSeconds)
+	startSideServer("Public API", srv)
+	return srv
+}
+
+// publicLobby finds a lobby for the public API, live or stored (such as a
+// finished game's), and maps it with its leaderboard. Sandbox lobbies are
+// reported as not found.
+func (s *Server) publicLobby(lobbyID string) (*api.Lobby, []*api.Player, error) {
+	if lobbyHub := s.hub.GetLobbyHub(lobbyID); lobbyHub != nil {
+		live := lobbyHub.GetLobby()
+		live.Lock()
+		defer live.Unlock()
+		if live.Sandbox {
+			return nil, nil, repository.ErrLobbyNotFound
+		}
+		return api.FromLobby(live), api.FromPlayers(s.gameService.Leaderboard(live)), nil
+	}
+
+	stored, err := s.gameService.GetRepository().GetLobby(lobbyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if stored.Sandbox {
+		return nil, nil, repository.ErrLobbyNotFound
+	}
+	return api.FromLobby(stored), api.FromPlayers(s.gameService.Leaderboard(stored)), nil
+}
+
+func (s *Server) getPublicLobby(c *gin.Context) {
+	lobby, _, err := s.publicLobby(c.Param("id"))
+	if err != nil {
+		publicLobbyError(c, err)
+		return
+	}
+	c.JSON(200, lobby)
+}
+
+func (s *Server) getPublicLeaderboard(c *gin.Context) {
+	lobby, leaderboard, err := s.publicLobby(c.Param("id"))
+	if err != nil {
+		publicLobbyError(c, err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"lobby_id":    lobby.ID,
+		"name":        lobby.Name,
+		"state":       lobby.State,
+		"round":       lobby.Round,
+		"max_rounds":  lobby.MaxRounds,
+		"finished_at": lobby.FinishedAt,
+		"leaderboard": leaderboard,
+	})
+}
+
+func publicLobbyError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrLobbyNotFound) {
+		c.JSON(404, gin.H{"error": "Lobby not found"})
+		return
+	}
+	log.Printf("Public API: Error loading lobby %s: %v", c.Param("id"), err)
+	c.JSON(500, gin.H{"error": "Failed to load lobby"})
+}
+
+func (s *Server) getPublicStats(c *gin.Context) {
+	c.JSON(200, gin.H{
+		"hub":            s.hub.Stats(),
+		"uptime_seconds": int64(time.Since(s.startedAt).Seconds()),
+	})
+}
+
+// responseCache keeps successful public API responses by URL for ttl, and
+// tells clients and proxies in front of the API they may cache them as long.
+type responseCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*cachedResponse
+}
+
+type cachedResponse struct {
+	contentType string
+	body        []byte
+	expires     time.Time
+}
+
+func newResponseCache(ttl time.Duration) *responseCache {
+	return &responseCache{ttl: ttl, entries: make(map[string]*cachedResponse)}
+}
+
+func (rc *responseCache) serve(c *gin.Context) {
+	if rc.ttl <= 0 {
+		c.Header("Cache-Control", "no-cache")
+		c.Next()
+		return
+	}
+	c.Header("Cache-Control", "public, max-age="+strconv.Itoa(int(rc.ttl.Seconds())))
+
+	key := c.Request.URL.RequestURI()
+	if cached := rc.get(key); cached != nil {
+		c.Header("X-Cache", "HIT")
+		c.Data(200, cached.contentType, cached.body)
+		c.Abort()
+		return
+	}
+
+	recorder := &recordingWriter{ResponseWriter: c.Writer}
+	c.Writer = recorder
+	c.Header("X-Cache", "MISS")
+	c.Next()
+	if recorder.Status() == 200 {
+		rc.put(key, &cachedResponse{
+			contentType: recorder.Header().Get("Content-Type"),
+			body:        recorder.body.Bytes(),
+			expires:     time.Now().Add(rc.ttl),
+		})
+	}
+}
+
+func (rc *responseCache) get(key string) *cachedResponse {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	cached := rc.entries[key]
+	if cached == nil || time.Now().After(cached.expires) {
+		return nil
+	}
+	return cached
+}
+
+func (rc *responseCache) put(key string, response *cachedResponse) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if len(rc.entries) >= maxPublicCacheEntries {
+		now := time.Now()
+		for k, cached := range rc.entries {
+			if now.After(cached.expires) {
+				delete(rc.entries, k)
+			}
+		}
+		if len(rc.entries) >= maxPublicCacheEntries {
+			return
+		}
+	}
+	rc.entries[key] = response
+}
+
+// recordingWriter keeps a copy of the response body as it's written.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
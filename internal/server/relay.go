@@ -0,0 +1,95 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+
+	"buildprize-game/internal/auth"
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+)
+
+// handleMirrorJoin connects a WebSocket to a lobby hosted on another
+// instance. Only players who already joined (over REST, which the load
+// balancer routes to the host) can connect this way, guests with the session
+// token that join returned; the connection then receives the host's events
+// and its messages are forwarded to the host.
+func (s *Server) handleMirrorJoin(client *hub.Client, lobbyID, username, sessionToken string) {
+	stored, err := s.gameService.GetRepository().GetLobby(lobbyID)
+	if err != nil {
+		log.Printf("handleMirrorJoin: Lobby %s not found locally or in storage: %v", lobbyID, err)
+		return
+	}
+
+	var player *models.Player
+	for _, p := range stored.Players {
+		if p.Username == username && p.UserID == client.UserID {
+			player = p
+			break
+		}
+	}
+	if player == nil {
+		rejectJoin(client, lobbyID, "lobby is hosted on another server; join it over the REST API first")
+		return
+	}
+	if player.UserID == "" && !s.holdsSeat(sessionToken, lobbyID, player.ID) {
+		rejectJoin(client, lobbyID, auth.ErrInvalidSession.Error())
+		return
+	}
+
+	if client.Hub != nil {
+		if client.Hub.IsMirror() && client.LobbyID == lobbyID {
+			return
+		}
+		client.Hub.Unregister(client)
+		s.gameService.PlayerDisconnected(client.Hub, client.PlayerID, client.ID)
+	}
+	client.LobbyID = lobbyID
+	client.PlayerID = player.ID
+	client.Hub = s.hub.JoinMirror(stored, client)
+	log.Printf("handleMirrorJoin: Client %s (player: %s) connected to relayed lobby %s", client.ID, player.ID, lobbyID)
+
+	s.gameService.DeliverPendingNotifications(client.Hub, client)
+}
+
+// forwardToHost sends a mirror connection's message to the instance hosting
+// its lobby.
+func (s *Server) forwardToHost(client *hub.Client, msg *WebSocketMessage) {
+	mirror := client.Hub
+	message, err := json.Marshal(msg)
+	if err == nil {
+		err = mirror.Forward(client.PlayerID, message)
+	}
+	if err != nil {
+		log.Printf("forwardToHost: Failed to forward %s from client %s: %v", msg.Type, client.ID, err)
+	}
+
+	if msg.Type == "leave_lobby" {
+		mirror.Unregister(client)
+		client.Hub = nil
+		client.LobbyID = ""
+		client.PlayerID = ""
+	}
+}
+
+// handleForwardedMessage runs a message forwarded by another instance as if
+// it came from a connection here that joined as playerID.
+func (s *Server) handleForwardedMessage(lobbyID, playerID string, message []byte) {
+	var msg WebSocketMessage
+	if err := json.Unmarshal(message, &msg); err != nil {
+		log.Printf("handleForwardedMessage: Bad message for lobby %s: %v", lobbyID, err)
+		return
+	}
+	if msg.Type == "join_lobby" {
+		return
+	}
+	msg.LobbyID = lobbyID
+
+	relayed := &hub.Client{
+		ID:       "relay-" + playerID,
+		LobbyID:  lobbyID,
+		PlayerID: playerID,
+		Send:     make(chan []byte, 1),
+	}
+	s.handleWebSocketMessage(relayed, &msg)
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxReplayGap shortens long quiet stretches in a played-back replay, such
+// as waiting for players before the game started.
+const maxReplayGap = 30 * time.Second
+
+type replayResponse struct {
+	LobbyID string              `json:"lobby_id"`
+	Events  []*models.GameEvent `json:"events"`
+}
+
+// getReplay returns a finished game's events in broadcast order, as
+// {"lobby_id": ..., "events": [...]}. With ?playback=true (or an
+// Accept: text/event-stream header) they're streamed as server-sent events
+// instead, spaced as they were broadcast, ?speed= times faster (0.25 to 16,
+// default 1).
+func (s *Server) getReplay(c *gin.Context) {
+	playback := c.Query("playback") == "true" || strings.Contains(c.GetHeader("Accept"), "text/event-stream")
+	speed := 1.0
+	if raw := c.Query("speed"); raw != "" {
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil || n < 0.25 || n > 16 {
+			c.JSON(400, gin.H{"error": "speed must be between 0.25 and 16"})
+			return
+		}
+		speed = n
+	}
+
+	lobbyID := c.Param("id")
+	events, err := s.gameService.Replay(lobbyID)
+	switch {
+	case errors.Is(err, services.ErrReplayNotFound):
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrGameNotFinished):
+		c.JSON(409, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !playback {
+		c.JSON(200, replayResponse{lobbyID, events})
+		return
+	}
+	s.playReplay(c, events, speed)
+}
+
+// playReplay streams events as server-sent events, each named for its type
+// with its seq as the id, then a final replay_end event. It stops early if
+// the client goes away.
+func (s *Server) playReplay(c *gin.Context, events []*models.GameEvent, speed float64) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // don't let a proxy hold the stream back
+	c.Status(200)
+
+	for i, event := range events {
+		if i > 0 {
+			gap := event.Timestamp.Sub(events[i-1].Timestamp)
+			if gap > maxReplayGap {
+				gap = maxReplayGap
+			}
+			select {
+			case <-time.After(time.Duration(float64(gap) / speed)):
+			case <-c.Request.Context().Done():
+				return
+			}
+		}
+		data, err := json.Marshal(event)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Type, data)
+		c.Writer.Flush()
+	}
+	fmt.Fprintf(c.Writer, "event: replay_end\ndata: {\"events\":%d}\n\n", len(events))
+	c.Writer.Flush()
+}
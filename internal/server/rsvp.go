@@ -0,0 +1,85 @@
+package server
+
+import (
+	"errors"
+	"log"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type rsvpRequest struct {
+	Username string `json:"username" binding:"required"`
+	Status   string `json:"status" binding:"required"` // yes, no or maybe
+}
+
+type rsvpResponse struct {
+	Invite     *models.Invite     `json:"invite"`
+	Attendance *models.Attendance `json:"attendance"`
+}
+
+func rsvpErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrLobbyNotFound):
+		return 404
+	case errors.Is(err, services.ErrNotInvited):
+		return 403
+	case errors.Is(err, services.ErrGameInProgress), errors.Is(err, services.ErrRSVPListFull):
+		return 409
+	}
+	return 400
+}
+
+// rsvp records an invited player's answer to a scheduled game. Invitees
+// usually haven't joined yet, so it's keyed on username, not player ID.
+func (s *Server) rsvp(c *gin.Context) {
+	var req rsvpRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	invite, attendance, err := s.gameService.RSVP(c.Param("id"), req.Username, req.Status)
+	if err != nil {
+		c.JSON(rsvpErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, rsvpResponse{invite, attendance})
+}
+
+// handleRSVP is the WebSocket rsvp message. The sender hears back with
+// rsvp_recorded or rsvp_rejected even when they haven't joined the lobby.
+func (s *Server) handleRSVP(client *hub.Client, msg *WebSocketMessage) {
+	lobbyID := msg.LobbyID
+	if lobbyID == "" {
+		lobbyID = client.LobbyID
+	}
+	data, ok := msg.Data.(map[string]interface{})
+	if lobbyID == "" || !ok {
+		return
+	}
+
+	username, _ := data["username"].(string)
+	status, _ := data["status"].(string)
+	invite, attendance, err := s.gameService.RSVP(lobbyID, username, status)
+	if err != nil {
+		log.Printf("handleRSVP: RSVP from %q for lobby %s rejected: %v", username, lobbyID, err)
+		replyTo(client, lobbyID, "rsvp_rejected", map[string]interface{}{"error": err.Error()})
+		return
+	}
+	replyTo(client, lobbyID, "rsvp_recorded", map[string]interface{}{"invite": invite, "attendance": attendance})
+}
+
+// replyTo sends an event to one connection whether or not it's registered
+// with the lobby, dropping it if the connection is backed up.
+func replyTo(client *hub.Client, lobbyID, eventType string, data interface{}) {
+	client.Offer(&models.GameEvent{
+		Type:      eventType,
+		LobbyID:   lobbyID,
+		Data:      data,
+		Timestamp: models.Now(),
+	})
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"log"
+	"time"
+
+	"buildprize-game/internal/services"
+)
+
+// watchSecrets applies rotated secrets without a restart where the running
+// server can swap them in. The admin token is read per request, so it needs
+// no hook.
+func (s *Server) watchSecrets(generator *services.QuestionGenerator) {
+	store := s.config.Secrets
+	if s.config.SecretsRefreshInterval <= 0 || !store.Dynamic() {
+		return
+	}
+
+	if s.challenge != nil {
+		store.OnRotate("CHALLENGE_SECRET", s.challenge.rotateSecret)
+		store.OnRotate("CAPTCHA_SECRET", s.challenge.setCaptchaSecret)
+	}
+	if generator != nil {
+		store.OnRotate("QUESTION_GENERATOR_API_KEY", generator.SetAPIKey)
+	}
+	// These are only read at startup: the connection pool, stored
+	// ciphertexts, lobby webhook secrets and login and session tokens depend
+	// on them
+	for _, name := range []string{"DATABASE_URL", "ENCRYPTION_KEYS", "LOBBY_WEBHOOK_KEY", "AUTH_TOKEN_SECRET"} {
+		name := name
+		store.OnRotate(name, func(string) {
+			log.Printf("Secret %s changed; restart the server to apply it", name)
+		})
+	}
+
+	interval := time.Duration(s.config.SecretsRefreshInterval) * time.Second
+	go store.Watch(interval, nil)
+	log.Printf("Watching secrets for rotation every %s", interval)
+}
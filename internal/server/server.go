@@ -1,17 +1,25 @@
 package server
 
 import (
+	"crypto/rand"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net"
-	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"buildprize-game/internal/api"
+	"buildprize-game/internal/archive"
+	"buildprize-game/internal/auth"
 	"buildprize-game/internal/config"
+	"buildprize-game/internal/encryption"
+	"buildprize-game/internal/game"
 	"buildprize-game/internal/hub"
 	"buildprize-game/internal/models"
 	"buildprize-game/internal/repository"
@@ -19,25 +27,80 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	graphql "github.com/graph-gophers/graphql-go"
 )
 
 type Server struct {
-	config      *config.Config
-	hub         *hub.Hub
-	gameService *services.GameService
-	router      *gin.Engine
-	upgrader    websocket.Upgrader
+	config        *config.Config
+	hub           *hub.Hub
+	gameService   *services.GameService
+	router        *gin.Engine
+	upgrader      websocket.Upgrader
+	origins       *originPolicy   // CORS and WebSocket origins, from ALLOWED_ORIGINS
+	challenge     *abuseChallenge // nil when CHALLENGE_MODE is unset
+	tokens        *auth.Issuer    // account login tokens
+	sessions      *auth.Sessions  // player session tokens
+	ipFilter      *ipFilter       // nil when no IP or country rules are configured
+	graphQLSchema *graphql.Schema // the read-only GraphQL API
+	openAPI       []byte          // the REST API's OpenAPI document, as JSON
+
+	startedAt time.Time
+	draining  atomic.Bool // set on shutdown; /ready fails from then on
 }
 
+// WebSocket subprotocols a client may ask for to choose the encoding of
+// every frame in both directions. Messages have the same fields either way.
+const (
+	jsonSubprotocol    = "buildprize.json"
+	msgpackSubprotocol = "buildprize.msgpack"
+)
+
+// minCompressedFrame is the smallest message compressed on connections
+// that negotiated permessage-deflate.
+const minCompressedFrame = 256
+
 type WebSocketMessage struct {
+	// Set by clients that want the message acknowledged (protocol version 2)
+	ID       interface{} `json:"id,omitempty"`
 	Type     string      `json:"type"`
 	LobbyID  string      `json:"lobby_id,omitempty"`
 	PlayerID string      `json:"player_id,omitempty"`
 	Data     interface{} `json:"data,omitempty"`
 }
 
-func NewServer(cfg *config.Config) *Server {
-	gameHub := hub.NewHub()
+// messageResponse confirms a request that has nothing else to return.
+type messageResponse struct {
+	Message string `json:"message"`
+}
+
+// encryptedRepository is a repository that can encrypt personal data at rest.
+type encryptedRepository interface {
+	repository.Repository
+	SetEncryption(envelope *encryption.Envelope)
+	RotateEncryptedFields() (int, error)
+}
+
+// openRepository connects to the configured storage backend.
+func openRepository(cfg *config.Config) repository.Repository {
+	switch cfg.StorageBackend {
+	case "memory":
+		log.Printf("Keeping game state in memory; it is lost on restart")
+		return repository.NewInMemoryRepository()
+	case "redis":
+		if cfg.RedisStorageURL == "" {
+			log.Fatal("REDIS_STORAGE_URL or REDIS_URL is required with STORAGE=redis.")
+		}
+		log.Printf("Connecting to Redis for game state...")
+		repo, err := repository.NewRedisRepository(cfg.RedisStorageURL, time.Duration(cfg.RedisStateTTL)*time.Second)
+		if err != nil {
+			log.Fatalf("Failed to connect to Redis: %v", err)
+		}
+		log.Printf("Successfully connected to Redis (state kept for %ds)", cfg.RedisStateTTL)
+		return repo
+	case "postgres":
+	default:
+		log.Fatalf("Unknown STORAGE %q: use \"postgres\", \"redis\" or \"memory\"", cfg.StorageBackend)
+	}
 
 	if cfg.DatabaseURL == "" {
 		log.Fatal("DATABASE_URL is required. Please set the DATABASE_URL environment variable.")
@@ -49,45 +112,192 @@ func NewServer(cfg *config.Config) *Server {
 		log.Fatalf("Failed to connect to PostgreSQL: %v", err)
 	}
 	log.Printf("Successfully connected to PostgreSQL")
+	return repo
+}
+
+func NewServer(cfg *config.Config) *Server {
+	gameHub := hub.NewHub()
+	if cfg.RedisURL != "" {
+		broadcaster, err := hub.NewRedisBroadcaster(cfg.RedisURL)
+		if err != nil {
+			log.Fatalf("Failed to connect to Redis: %v", err)
+		}
+		gameHub.SetBroadcaster(broadcaster)
+		log.Printf("Relaying lobby events between instances over Redis")
+	}
+
+	repo := openRepository(cfg)
+
+	if cfg.EncryptionKeys != "" {
+		keys, err := encryption.ParseLocalKeys(cfg.EncryptionKeys)
+		if err != nil {
+			log.Fatalf("Invalid ENCRYPTION_KEYS: %v", err)
+		}
+		encrypted, ok := repo.(encryptedRepository)
+		if !ok {
+			log.Fatalf("ENCRYPTION_KEYS is set but STORAGE=%s doesn't encrypt stored data", cfg.StorageBackend)
+		}
+		encrypted.SetEncryption(encryption.NewEnvelope(keys))
+		log.Printf("At-rest encryption enabled (current key: %s)", keys.CurrentKeyID())
+		go func() {
+			count, err := encrypted.RotateEncryptedFields()
+			if err != nil {
+				log.Printf("Failed to re-encrypt stored fields: %v", err)
+				return
+			}
+			log.Printf("Re-encrypted %d stored fields under key %s", count, keys.CurrentKeyID())
+		}()
+	}
 
-	gameService := services.NewGameService(gameHub, repo)
+	gameService := services.NewGameService(gameHub, repo, cfg.MaxLobbySize)
+	gameService.SetMaxAudienceSize(cfg.MaxAudience)
+	gameService.SetAnswerGrace(time.Duration(cfg.AnswerGraceMs) * time.Millisecond)
+	gameService.SetTimerTick(time.Duration(cfg.TimerTickMs) * time.Millisecond)
+	gameService.SetWagerTime(time.Duration(cfg.WagerSeconds) * time.Second)
+	gameService.SetFinalQuestionTime(time.Duration(cfg.FinalQuestionSeconds) * time.Second)
+	gameService.SetChatRetention(time.Duration(cfg.ChatRetentionHours) * time.Hour)
+	gameService.SetStreakBonuses(models.StreakBonuses{
+		DailyXP:    int64(cfg.StreakDailyXP),
+		DailyCoins: int64(cfg.StreakDailyCoins),
+		WinXP:      int64(cfg.StreakWinXP),
+		WinCoins:   int64(cfg.StreakWinCoins),
+		Cap:        cfg.StreakBonusCap,
+	})
+	gameService.SetEventRetention(time.Duration(cfg.GameEventRetentionHours) * time.Hour)
+	if cfg.ArchiveS3Bucket != "" {
+		gameService.SetEventArchive(archive.NewS3Store(cfg.ArchiveS3Endpoint, cfg.ArchiveS3Bucket, cfg.ArchiveS3Region,
+			cfg.ArchiveS3AccessKeyID, cfg.ArchiveS3SecretKey, time.Minute))
+		log.Printf("Game event logs are archived to bucket %s after %d hours", cfg.ArchiveS3Bucket, cfg.GameEventRetentionHours)
+	}
+	gameService.SetChatModeration(services.ChatModeration{
+		BlockedWords: strings.Split(cfg.ChatBlockedWords, ","),
+		StripLinks:   cfg.ChatStripLinks,
+		MaxLength:    cfg.ChatMaxLength,
+		RateLimit:    cfg.ChatRateLimit,
+		RateWindow:   time.Duration(cfg.ChatRateWindowSeconds) * time.Second,
+	})
+	gameService.SetReportMute(cfg.ReportMuteThreshold, time.Duration(cfg.ReportMuteMinutes)*time.Minute)
+	gameService.SetDisputeWindow(time.Duration(cfg.PrizeDisputeWindowMinutes) * time.Minute)
+	gameService.SetScheduledStartGrace(time.Duration(cfg.ScheduledStartGraceMinutes) * time.Minute)
+	gameService.SetAbandonedLobbyAge(time.Duration(cfg.AbandonedLobbyMinutes) * time.Minute)
+	gameService.SetAbandonedGameGrace(time.Duration(cfg.AbandonedGameMinutes) * time.Minute)
+	var generator *services.QuestionGenerator
+	if cfg.QuestionGeneratorURL != "" {
+		generator = services.NewQuestionGenerator(
+			cfg.QuestionGeneratorURL,
+			cfg.QuestionGeneratorAPIKey,
+			cfg.QuestionGeneratorModel,
+		)
+		gameService.SetQuestionGenerator(generator)
+		log.Printf("Question generator enabled (model: %s)", cfg.QuestionGeneratorModel)
+	}
+	if cfg.DemoMode {
+		gameService.StartDemoMode(cfg.DemoLobbies)
+	}
+	gameService.StartDifficultyCalibration(time.Duration(cfg.DifficultyCalibrationMinutes) * time.Minute)
+	gameService.StartLobbyIdleTimeout(time.Duration(cfg.LobbyIdleMinutes)*time.Minute, time.Duration(cfg.LobbyIdleWarningSeconds)*time.Second)
+	gameService.StartResultFinalizer(time.Minute)
+	gameService.StartRecurringEvents(time.Minute)
+	if fields := strings.Fields(cfg.GameHookCommand); len(fields) > 0 {
+		gameService.RegisterHook(services.NewScriptHook(fields[0], fields[1:], time.Duration(cfg.GameHookTimeout)*time.Second, cfg.GameHookWorkers))
+		log.Printf("Game event script hook enabled: %s", cfg.GameHookCommand)
+	}
+	if cfg.LobbyWebhooks {
+		key := []byte(cfg.LobbyWebhookKey)
+		if len(key) == 0 {
+			key = make([]byte, 32)
+			if _, err := rand.Read(key); err != nil {
+				log.Fatalf("Failed to generate lobby webhook key: %v", err)
+			}
+			log.Printf("LOBBY_WEBHOOK_KEY is not set; lobby webhook secrets change on restart")
+		}
+		hosts := strings.Split(cfg.LobbyWebhookHosts, ",")
+		gameService.SetLobbyWebhooks(services.NewLobbyWebhooks(key, hosts, time.Duration(cfg.LobbyWebhookTimeout)*time.Second))
+		log.Printf("Lobby webhooks enabled")
+	}
+	if cfg.PayoutProvider != "" {
+		provider, err := newPayoutProvider(cfg)
+		if err != nil {
+			log.Fatalf("Invalid payout configuration: %v", err)
+		}
+		gameService.SetPayoutProvider(provider)
+		log.Printf("Prize payouts enabled through %s", provider.Name())
+	}
 
+	origins := newOriginPolicy(cfg)
 	upgrader := websocket.Upgrader{
-		CheckOrigin: func(r *http.Request) bool {
-			return true
-		},
+		CheckOrigin:     origins.checkWebSocketOrigin,
 		ReadBufferSize:  1024,
 		WriteBufferSize: 1024,
+		// Preferred first when a client offers both
+		Subprotocols:      []string{msgpackSubprotocol, jsonSubprotocol},
+		EnableCompression: cfg.WSCompression,
+	}
+	if cfg.WSCompression && (cfg.WSCompressionLevel < 1 || cfg.WSCompressionLevel > 9) {
+		log.Fatalf("Invalid WS_COMPRESSION_LEVEL %d: must be 1 to 9", cfg.WSCompressionLevel)
 	}
 
 	router := gin.Default()
+	// Only listed proxies may set the client address via X-Forwarded-For,
+	// otherwise IP rules could be bypassed with a forged header
+	if err := router.SetTrustedProxies(trustedProxies(cfg)); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 
+	secret := authSecret(cfg)
 	server := &Server{
 		config:      cfg,
 		hub:         gameHub,
 		gameService: gameService,
 		router:      router,
 		upgrader:    upgrader,
+		origins:     origins,
+		challenge:   newAbuseChallenge(cfg),
+		tokens:      newTokenIssuer(cfg, secret),
+		sessions:    auth.NewSessions(secret),
+		ipFilter:    newIPFilter(cfg),
+		startedAt:   time.Now(),
+	}
+	if server.challenge != nil {
+		log.Printf("Abuse challenge enabled for lobby creation and joins (mode: %s)", cfg.ChallengeMode)
+	}
+	if server.ipFilter != nil {
+		log.Printf("IP access rules enabled (%d allowed, %d denied, %d blocked countries)",
+			len(server.ipFilter.allow), len(server.ipFilter.deny), len(server.ipFilter.countries))
 	}
 
+	server.graphQLSchema = server.newGraphQLSchema()
+	gameHub.SetCommandHandler(server.handleForwardedMessage)
+	server.watchSecrets(generator)
 	server.setupRoutes()
+	if err := server.checkAPIDocs(router); err != nil {
+		log.Fatalf("The OpenAPI document is out of date: %v", err)
+	}
+	openAPI, err := newOpenAPIDocument()
+	if err != nil {
+		log.Fatalf("Failed to build the OpenAPI document: %v", err)
+	}
+	server.openAPI = openAPI
 	return server
 }
 
-func (s *Server) setupRoutes() {
-	s.router.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
-		c.Header("Access-Control-Allow-Credentials", "true")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
+// trustedProxies lists the TRUSTED_PROXIES addresses and ranges.
+func trustedProxies(cfg *config.Config) []string {
+	var proxies []string
+	for _, proxy := range strings.Split(cfg.TrustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
 		}
+	}
+	return proxies
+}
 
-		c.Next()
-	})
+func (s *Server) setupRoutes() {
+	if s.ipFilter != nil {
+		s.router.Use(s.filterIPs)
+	}
+
+	s.router.Use(s.origins.cors)
 
 	wd, _ := os.Getwd()
 	clientPath := filepath.Join(wd, "client")
@@ -96,9 +306,8 @@ func (s *Server) setupRoutes() {
 		c.Redirect(302, "/client/index.html")
 	})
 
-	s.router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
-	})
+	s.router.GET("/health", s.health)
+	s.router.GET("/ready", s.ready)
 
 	s.router.GET("/ws-test", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -110,27 +319,92 @@ func (s *Server) setupRoutes() {
 
 	api := s.router.Group("/api/v1")
 	{
-		api.Use(func(c *gin.Context) {
-			c.Header("Access-Control-Allow-Origin", "*")
-			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
-			c.Next()
-		})
+		api.Use(s.authenticate)
+
+		api.POST("/auth/register", s.requireChallenge, s.register)
+		api.POST("/auth/login", s.login)
+		api.GET("/auth/me", s.getCurrentUser)
 
 		api.OPTIONS("/lobbies", func(c *gin.Context) { c.Status(204) })
-		api.POST("/lobbies", s.createLobby)
+		api.POST("/lobbies", s.requireChallenge, s.createLobby)
 		api.GET("/lobbies", s.listLobbies)
 		api.GET("/lobbies/:id", s.getLobby)
+		api.OPTIONS("/lobbies/:id", func(c *gin.Context) { c.Status(204) })
+		api.PATCH("/lobbies/:id", s.updateLobby)
+		api.GET("/lobbies/:id/state", s.getLobbyState)
 		api.OPTIONS("/lobbies/:id/join", func(c *gin.Context) { c.Status(204) })
-		api.POST("/lobbies/:id/join", s.joinLobby)
+		api.POST("/lobbies/:id/join", s.requireChallenge, s.joinLobby)
 		api.OPTIONS("/lobbies/:id/leave", func(c *gin.Context) { c.Status(204) })
 		api.POST("/lobbies/:id/leave", s.leaveLobby)
 		api.OPTIONS("/lobbies/:id/start", func(c *gin.Context) { c.Status(204) })
 		api.POST("/lobbies/:id/start", s.startGame)
+		api.OPTIONS("/lobbies/:id/pause", func(c *gin.Context) { c.Status(204) })
+		api.POST("/lobbies/:id/pause", s.pauseGame)
+		api.OPTIONS("/lobbies/:id/resume", func(c *gin.Context) { c.Status(204) })
+		api.POST("/lobbies/:id/resume", s.resumeGame)
+		api.OPTIONS("/lobbies/:id/end", func(c *gin.Context) { c.Status(204) })
+		api.POST("/lobbies/:id/end", s.hostEndGame)
+		api.OPTIONS("/lobbies/:id/cancel", func(c *gin.Context) { c.Status(204) })
+		api.POST("/lobbies/:id/cancel", s.hostCancelGame)
 		api.OPTIONS("/lobbies/:id/answer", func(c *gin.Context) { c.Status(204) })
 		api.POST("/lobbies/:id/answer", s.submitAnswer)
+
+		api.POST("/lobbies/:id/warmup-answer", s.submitWarmUpAnswer)
+
+		api.POST("/lobbies/:id/audience", s.requireChallenge, s.joinAudience)
+		api.POST("/lobbies/:id/audience/answer", s.submitAudienceAnswer)
+
+		api.POST("/lobbies/:id/polls", s.createPoll)
+		api.POST("/lobbies/:id/polls/:poll_id/vote", s.votePoll)
 		api.OPTIONS("/lobbies/:id/chat", func(c *gin.Context) { c.Status(204) })
 		api.POST("/lobbies/:id/chat", s.sendChatMessage)
+		api.GET("/lobbies/:id/chat", s.getChatHistory)
+		api.GET("/lobbies/:id/events/poll", s.pollLobbyEvents)
+		api.POST("/lobbies/:id/mute", s.mutePlayer)
+		api.POST("/lobbies/:id/unmute", s.unmutePlayer)
+		api.POST("/lobbies/:id/report", s.reportPlayer)
+		api.POST("/lobbies/:id/rsvp", s.rsvp)
+		api.GET("/lobbies/:id/results", s.getPrizeResults)
+		api.GET("/lobbies/:id/prizes", s.getPrizes)
+		api.OPTIONS("/lobbies/:id/prizes/claim", func(c *gin.Context) { c.Status(204) })
+		api.POST("/lobbies/:id/prizes/claim", s.claimPrize)
+		api.OPTIONS("/lobbies/:id/entry-payment", func(c *gin.Context) { c.Status(204) })
+		api.POST("/lobbies/:id/entry-payment", s.startEntryPayment)
+		api.POST("/payouts/webhook", s.payoutWebhook)
+		api.POST("/lobbies/:id/disputes", s.fileDispute)
+
+		api.GET("/players/:id/recommendations", s.getRecommendations)
+		api.GET("/players/:id/stats", s.getPlayerStats)
+		api.GET("/players/:id/profile", s.getPlayerProfile)
+		api.OPTIONS("/players/:id/practice-lobby", func(c *gin.Context) { c.Status(204) })
+		api.POST("/players/:id/practice-lobby", s.requireChallenge, s.createPracticeLobby)
+
+		api.OPTIONS("/friends", func(c *gin.Context) { c.Status(204) })
+		api.OPTIONS("/friends/:id", func(c *gin.Context) { c.Status(204) })
+		api.OPTIONS("/friends/:id/invite", func(c *gin.Context) { c.Status(204) })
+		api.GET("/friends", s.listFriends)
+		api.GET("/friends/online", s.listOnlineFriends)
+		api.POST("/friends", s.addFriend)
+		api.DELETE("/friends/:id", s.removeFriend)
+		api.POST("/friends/:id/invite", s.inviteFriend)
+		api.GET("/events", s.listUpcomingEvents)
+		api.GET("/games/:id/replay", s.getReplay)
+		api.GET("/graphql", s.graphQL)
+		api.POST("/graphql", s.graphQL)
+
+		api.GET("/challenge", s.getChallenge)
+
+		api.GET("/i18n", s.listLanguages)
+		api.GET("/i18n/:lang", s.getMessageCatalog)
+
+		api.GET("/openapi.json", s.getOpenAPI)
+		api.GET("/docs", s.getAPIDocs)
+	}
+
+	if s.config.AdminAddr == "" {
+		s.setupAdminRoutes(s.router)
+	} else {
+		log.Printf("Admin routes are served on the internal admin listener (%s) only", s.config.AdminAddr)
 	}
 
 	s.router.GET("/ws", s.handleWebSocket)
@@ -138,11 +412,49 @@ func (s *Server) setupRoutes() {
 	log.Printf("Chat route registered at POST /api/v1/lobbies/:id/chat")
 }
 
+type createLobbyRequest struct {
+	Name      string `json:"name" binding:"required"`
+	MaxRounds int    `json:"max_rounds"`
+	Topic     string `json:"topic"`
+	RoundType string `json:"round_type"`
+	// Relative weights per category, e.g. {"Sports": 50, "Music": 30, "any": 20}
+	CategoryWeights map[string]int `json:"category_weights"`
+	MaxPlayers      int            `json:"max_players"`
+	Timezone        string         `json:"timezone"` // IANA name, e.g. "America/New_York"
+	Language        string         `json:"language"` // server message catalog, e.g. "es"
+	WarmUp          bool           `json:"warm_up"`  // serve practice questions while waiting
+	Audience        bool           `json:"audience"` // accept audience members alongside the players
+	// Top audience scorers named in results, when audience is set
+	AudienceShoutOuts int `json:"audience_shout_outs"`
+	// Lifecycle events are posted here when lobby webhooks are enabled
+	WebhookURL string `json:"webhook_url"`
+	// Scheduled start, with the usernames asked to RSVP and how many
+	// who said yes must have joined before it starts
+	StartsAt   time.Time `json:"starts_at"`
+	Invitees   []string  `json:"invitees"`
+	RSVPQuorum int       `json:"rsvp_quorum"`
+	// Awarded to the winner once the results survive the dispute window
+	Prize string `json:"prize"`
+	// Shared between the top finishers, e.g. {"amount": 10000, "currency": "USD", "split": [70, 20, 10]}
+	PrizePool *models.PrizePool `json:"prize_pool"`
+	// Paid by every player to join and added to the prize pool, in its currency
+	EntryFee int64 `json:"entry_fee"`
+	// Scoring rules for this lobby, e.g. {"base_score": 200, "wrong_penalty": 50};
+	// rules left out come from the active scoring version
+	Scoring *models.ScoringRules `json:"scoring"`
+	// Rounds that open with a double-or-nothing wager phase
+	WagerRounds []int `json:"wager_rounds"`
+	// Make the last round a final round with hidden wagers
+	FinalWager bool `json:"final_wager"`
+}
+
+type createdLobbyResponse struct {
+	*api.Lobby
+	WebhookSecret string `json:"webhook_secret,omitempty"`
+}
+
 func (s *Server) createLobby(c *gin.Context) {
-	var req struct {
-		Name      string `json:"name" binding:"required"`
-		MaxRounds int    `json:"max_rounds"`
-	}
+	var req createLobbyRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
@@ -153,26 +465,127 @@ func (s *Server) createLobby(c *gin.Context) {
 		req.MaxRounds = 10
 	}
 
-	lobby := s.gameService.CreateLobby(req.Name, req.MaxRounds)
-	c.JSON(201, lobby)
+	// Webhooks post lobby events to outside services, so only integrations
+	// given a key for it may register them
+	if req.WebhookURL != "" && !s.requireKeyWithScope(c, models.ScopeWebhooks, "webhook_url") {
+		return
+	}
+
+	roundType := models.MediaType(req.RoundType)
+	if roundType != "" && roundType != models.MediaImage && roundType != models.MediaAudio {
+		c.JSON(400, gin.H{"error": "round_type must be \"image\" or \"audio\""})
+		return
+	}
+
+	lobby, err := s.gameService.CreateLobby(services.LobbyOptions{
+		Name:            req.Name,
+		MaxRounds:       req.MaxRounds,
+		Topic:           req.Topic,
+		RoundType:       roundType,
+		CategoryWeights: req.CategoryWeights,
+		MaxPlayers:      req.MaxPlayers,
+		Timezone:        req.Timezone,
+		Language:        req.Language,
+		WarmUp:          req.WarmUp,
+
+		Audience:          req.Audience,
+		AudienceShoutOuts: req.AudienceShoutOuts,
+
+		StartsAt:   req.StartsAt,
+		Invitees:   req.Invitees,
+		RSVPQuorum: req.RSVPQuorum,
+		WebhookURL: req.WebhookURL,
+		Prize:      req.Prize,
+		PrizePool:  req.PrizePool,
+		EntryFee:   req.EntryFee,
+		Scoring:    req.Scoring,
+
+		WagerRounds: req.WagerRounds,
+		FinalWager:  req.FinalWager,
+	})
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	// Only the host sees the webhook secret, once, to verify deliveries with
+	c.JSON(201, createdLobbyResponse{api.LobbySnapshot(lobby), s.gameService.LobbyWebhookSecret(lobby)})
 }
 
+type lobbyPageResponse struct {
+	Lobbies []*api.Lobby `json:"lobbies"`
+	Total   int          `json:"total"`
+	Limit   int          `json:"limit"`
+	Offset  int          `json:"offset"`
+}
+
+// listLobbies pages through lobbies: ?limit= (default 50, at most 100),
+// ?offset=, ?state= (waiting by default, or in_progress, finished or all),
+// ?sort= (newest, oldest, name or players) and ?has_space=true.
 func (s *Server) listLobbies(c *gin.Context) {
-	lobbies, err := s.gameService.GetRepository().ListLobbies()
+	query, err := parseLobbyQuery(c)
+	if err == nil {
+		query, err = query.Normalize()
+	}
+	if err != nil {
+		c.JSON(400, gin.H{"error": "invalid lobby query: limit must be 1-100, offset non-negative, state waiting, in_progress, finished or all, and sort newest, oldest, name or players"})
+		return
+	}
+
+	page, err := s.gameService.GetRepository().ListLobbies(query)
 	if err != nil {
 		log.Printf("Error listing lobbies: %v", err)
 		c.JSON(500, gin.H{"error": "Failed to list lobbies"})
 		return
 	}
-	// Ensure we always return an array, not null
-	if lobbies == nil {
-		lobbies = []*models.Lobby{}
-	}
-	log.Printf("ListLobbies: Returning %d waiting lobbies", len(lobbies))
-	for _, lobby := range lobbies {
+	log.Printf("ListLobbies: Returning %d of %d lobbies", len(page.Lobbies), page.Total)
+	for _, lobby := range page.Lobbies {
 		log.Printf("  - Lobby: %s (ID: %s, State: %s, Players: %d)", lobby.Name, lobby.ID, lobby.State, len(lobby.Players))
 	}
-	c.JSON(200, lobbies)
+	c.JSON(200, lobbyPageResponse{api.FromLobbies(page.Lobbies), page.Total, query.Limit, query.Offset})
+}
+
+func parseLobbyQuery(c *gin.Context) (repository.LobbyQuery, error) {
+	query := repository.WaitingLobbies
+	switch state := c.Query("state"); state {
+	case "":
+	case "all":
+		query.State = ""
+	default:
+		query.State = models.GameState(state)
+	}
+	query.Sort = repository.LobbySort(c.Query("sort"))
+	query.HasSpace = c.Query("has_space") == "true"
+
+	var err error
+	if limit := c.Query("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil || query.Limit == 0 {
+			return query, repository.ErrInvalidLobbyQuery
+		}
+	}
+	if offset := c.Query("offset"); offset != "" {
+		if query.Offset, err = strconv.Atoi(offset); err != nil {
+			return query, repository.ErrInvalidLobbyQuery
+		}
+	}
+	return query, nil
+}
+
+// lobbyQuery builds a lobby listing query from the gRPC and GraphQL APIs'
+// arguments, which mean the same as parseLobbyQuery's.
+func lobbyQuery(state, sort string, hasSpace bool, limit, offset int) (repository.LobbyQuery, error) {
+	query := repository.WaitingLobbies
+	switch state {
+	case "":
+	case "all":
+		query.State = ""
+	default:
+		query.State = models.GameState(state)
+	}
+	query.Sort = repository.LobbySort(sort)
+	query.HasSpace = hasSpace
+	query.Limit = limit
+	query.Offset = offset
+	return query.Normalize()
 }
 
 func (s *Server) getLobby(c *gin.Context) {
@@ -184,45 +597,79 @@ func (s *Server) getLobby(c *gin.Context) {
 		return
 	}
 
-	lobby := lobbyHub.GetLobby()
-	c.JSON(200, lobby)
+	c.JSON(200, api.LobbySnapshot(lobbyHub.GetLobby()))
+}
+
+// getLobbyState returns everything a reconnecting client needs to resync
+// without waiting for the next broadcast.
+func (s *Server) getLobbyState(c *gin.Context) {
+	state, err := s.gameService.LobbyState(c.Param("id"))
+	if err != nil {
+		c.JSON(404, gin.H{"error": "Lobby not found"})
+		return
+	}
+	c.JSON(200, state)
+}
+
+type joinLobbyRequest struct {
+	Username  string `json:"username"`   // ignored when logged in: accounts play under their own
+	PaymentID string `json:"payment_id"` // completed entry payment, for lobbies with an entry fee
+}
+
+// joinResponse carries the session token the player acts with over REST.
+type joinResponse struct {
+	Lobby        *api.Lobby  `json:"lobby"`
+	Player       *api.Player `json:"player"`
+	SessionToken string      `json:"session_token"`
 }
 
 func (s *Server) joinLobby(c *gin.Context) {
 	lobbyID := c.Param("id")
 
-	var req struct {
-		Username string `json:"username" binding:"required"`
-	}
+	var req joinLobbyRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
 
-	lobby, player, err := s.gameService.JoinLobby(lobbyID, req.Username)
+	var lobby *models.Lobby
+	var player *models.Player
+	var err error
+	if user := currentUser(c); user == nil && req.Username == "" {
+		err = errors.New("username is required unless logged in")
+	} else {
+		lobby, player, err = s.gameService.JoinLobby(lobbyID, services.JoinOptions{
+			Username:  req.Username,
+			User:      user,
+			PaymentID: req.PaymentID,
+		})
+	}
 	if err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		c.JSON(joinErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(200, gin.H{
-		"lobby":  lobby,
-		"player": player,
-	})
+	c.JSON(200, joinResponse{api.LobbySnapshot(lobby), api.FromPlayer(player), s.sessions.Issue(lobby.ID, player.ID)})
+}
+
+// playerRequest names the player a lobby action is taken as.
+type playerRequest struct {
+	PlayerID string `json:"player_id" binding:"required"`
 }
 
 func (s *Server) leaveLobby(c *gin.Context) {
 	lobbyID := c.Param("id")
 
-	var req struct {
-		PlayerID string `json:"player_id" binding:"required"`
-	}
+	var req playerRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
+	if !s.checkSession(c, lobbyID, req.PlayerID) {
+		return
+	}
 
 	err := s.gameService.LeaveLobby(lobbyID, req.PlayerID)
 	if err != nil {
@@ -230,7 +677,7 @@ func (s *Server) leaveLobby(c *gin.Context) {
 		return
 	}
 
-	c.JSON(200, gin.H{"message": "Left lobby successfully"})
+	c.JSON(200, messageResponse{"Left lobby successfully"})
 }
 
 func (s *Server) startGame(c *gin.Context) {
@@ -242,44 +689,176 @@ func (s *Server) startGame(c *gin.Context) {
 		return
 	}
 
-	c.JSON(200, gin.H{"message": "Game started"})
+	c.JSON(200, messageResponse{"Game started"})
+}
+
+func (s *Server) pauseGame(c *gin.Context) {
+	s.setPaused(c, true)
+}
+
+func (s *Server) resumeGame(c *gin.Context) {
+	s.setPaused(c, false)
+}
+
+func (s *Server) setPaused(c *gin.Context, paused bool) {
+	lobbyID := c.Param("id")
+
+	var req playerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if !s.checkSession(c, lobbyID, req.PlayerID) {
+		return
+	}
+
+	var err error
+	if paused {
+		err = s.gameService.PauseGame(lobbyID, req.PlayerID)
+	} else {
+		err = s.gameService.ResumeGame(lobbyID, req.PlayerID)
+	}
+	if err != nil {
+		status := 400
+		switch {
+		case errors.Is(err, services.ErrLobbyNotFound):
+			status = 404
+		case errors.Is(err, services.ErrNotHost):
+			status = 403
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	if paused {
+		c.JSON(200, messageResponse{"Game paused"})
+	} else {
+		c.JSON(200, messageResponse{"Game resumed"})
+	}
+}
+
+func (s *Server) hostEndGame(c *gin.Context) {
+	s.hostGameAction(c, s.gameService.HostEndGame, "Game ended")
+}
+
+func (s *Server) hostCancelGame(c *gin.Context) {
+	s.hostGameAction(c, s.gameService.HostCancelGame, "Game cancelled")
+}
+
+// hostGameAction runs a host-only change to a running game for the player
+// whose session the request carries.
+func (s *Server) hostGameAction(c *gin.Context, action func(lobbyID, playerID string) error, message string) {
+	lobbyID := c.Param("id")
+
+	var req playerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if !s.checkSession(c, lobbyID, req.PlayerID) {
+		return
+	}
+
+	if err := action(lobbyID, req.PlayerID); err != nil {
+		status := 400
+		switch {
+		case errors.Is(err, services.ErrLobbyNotFound):
+			status = 404
+		case errors.Is(err, services.ErrNotHost):
+			status = 403
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, messageResponse{message})
+}
+
+type answerRequest struct {
+	PlayerID string      `json:"player_id" binding:"required"`
+	Answer   interface{} `json:"answer"`  // index, or array of indexes for multi-select
+	SentAt   time.Time   `json:"sent_at"` // optional, in server time by the client's clock offset
 }
 
 func (s *Server) submitAnswer(c *gin.Context) {
 	lobbyID := c.Param("id")
 
-	var req struct {
-		PlayerID     string `json:"player_id" binding:"required"`
-		Answer       int    `json:"answer" binding:"required"`
-		ResponseTime int64  `json:"response_time"`
+	var req answerRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if !s.checkSession(c, lobbyID, req.PlayerID) {
+		return
+	}
+
+	answer, err := models.ParseSubmittedAnswer(req.Answer)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
 	}
 
+	err = s.gameService.SubmitAnswerSentAt(lobbyID, req.PlayerID, answer, req.SentAt)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, messageResponse{"Answer submitted"})
+}
+
+type warmUpAnswerRequest struct {
+	PlayerID string      `json:"player_id" binding:"required"`
+	Answer   interface{} `json:"answer"`
+}
+
+// submitWarmUpAnswer answers the current warm-up question. The response
+// carries the would-be score; nothing counts towards the game.
+func (s *Server) submitWarmUpAnswer(c *gin.Context) {
+	var req warmUpAnswerRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
+	if !s.checkSession(c, c.Param("id"), req.PlayerID) {
+		return
+	}
 
-	err := s.gameService.SubmitAnswer(lobbyID, req.PlayerID, req.Answer, req.ResponseTime)
+	answer, err := models.ParseSubmittedAnswer(req.Answer)
 	if err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(200, gin.H{"message": "Answer submitted"})
+	result, err := s.gameService.SubmitWarmUpAnswer(c.Param("id"), req.PlayerID, answer)
+	if err != nil {
+		status := 400
+		if errors.Is(err, services.ErrLobbyNotFound) {
+			status = 404
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, result)
+}
+
+type chatRequest struct {
+	PlayerID string `json:"player_id" binding:"required"`
+	Message  string `json:"message" binding:"required"`
 }
 
 func (s *Server) sendChatMessage(c *gin.Context) {
 	lobbyID := c.Param("id")
 
-	var req struct {
-		PlayerID string `json:"player_id" binding:"required"`
-		Message  string `json:"message" binding:"required"`
-	}
+	var req chatRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
+	if !s.checkSession(c, lobbyID, req.PlayerID) {
+		return
+	}
 
 	// Get lobby hub
 	lobbyHub := s.hub.GetLobbyHub(lobbyID)
@@ -290,7 +869,9 @@ func (s *Server) sendChatMessage(c *gin.Context) {
 
 	// Get player username
 	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
 	player := lobby.GetPlayer(req.PlayerID)
+	lobby.Unlock()
 	if player == nil {
 		c.JSON(404, gin.H{"error": "Player not found in lobby"})
 		return
@@ -306,16 +887,141 @@ func (s *Server) sendChatMessage(c *gin.Context) {
 		log.Printf("  Client %s (player: %s) will receive message", clientID, client.PlayerID)
 	}
 
-	s.gameService.BroadcastLobbyUpdate(lobbyHub, "chat_message", map[string]interface{}{
-		"player_id": req.PlayerID,
-		"username":  player.Username,
-		"message":   req.Message,
-		"timestamp": time.Now().UnixMilli(),
-	})
+	if err := s.gameService.PostChatMessage(lobbyHub, player, req.Message); err != nil {
+		var rejection *services.ChatRejection
+		if !errors.As(err, &rejection) {
+			c.JSON(500, gin.H{"error": "Failed to send chat message"})
+			return
+		}
+		log.Printf("REST API: Chat message from player %s in lobby %s rejected: %v", req.PlayerID, lobbyID, err)
+		status := 400
+		if rejection.Code == services.ChatRateLimited {
+			status = 429
+			c.Header("Retry-After", strconv.FormatInt((rejection.RetryAfterMs+999)/1000, 10))
+		}
+		c.JSON(status, rejection)
+		return
+	}
 
 	log.Printf("REST API: Chat message broadcast completed for lobby %s", lobbyID)
 
-	c.JSON(200, gin.H{"message": "Chat message sent"})
+	c.JSON(200, messageResponse{"Chat message sent"})
+}
+
+type chatHistoryResponse struct {
+	Messages []api.ChatMessage `json:"messages"`
+}
+
+// getChatHistory returns the lobby's stored chat, oldest first: the most
+// recent messages, or with ?since= (an RFC3339 timestamp, e.g. the last
+// message's) only those sent after it. ?limit= caps how many, up to 200.
+func (s *Server) getChatHistory(c *gin.Context) {
+	var since time.Time
+	if raw := c.Query("since"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "since must be an RFC3339 timestamp"})
+			return
+		}
+		since = parsed
+	}
+	limit := repository.DefaultChatHistoryLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > repository.MaxChatHistoryLimit {
+			c.JSON(400, gin.H{"error": "limit must be between 1 and 200"})
+			return
+		}
+		limit = n
+	}
+
+	messages, err := s.gameService.ChatHistory(c.Param("id"), since, limit)
+	if err != nil {
+		if errors.Is(err, services.ErrLobbyNotFound) {
+			c.JSON(404, gin.H{"error": "Lobby not found"})
+			return
+		}
+		log.Printf("Error loading chat history for lobby %s: %v", c.Param("id"), err)
+		c.JSON(500, gin.H{"error": "Failed to load chat history"})
+		return
+	}
+	c.JSON(200, chatHistoryResponse{messages})
+}
+
+type recommendationsResponse struct {
+	PlayerID        string                           `json:"player_id"`
+	Recommendations []*models.PracticeRecommendation `json:"recommendations"`
+}
+
+func (s *Server) getRecommendations(c *gin.Context) {
+	playerID := c.Param("id")
+
+	recommendations, err := s.gameService.GetPracticeRecommendations(playerID)
+	if err != nil {
+		log.Printf("Error building recommendations for player %s: %v", playerID, err)
+		c.JSON(500, gin.H{"error": "Failed to build recommendations"})
+		return
+	}
+
+	c.JSON(200, recommendationsResponse{playerID, recommendations})
+}
+
+// getPlayerStats returns an account's lifetime stats; :id is the account's
+// user ID, as carried by its players.
+func (s *Server) getPlayerStats(c *gin.Context) {
+	stats, err := s.gameService.PlayerStats(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, stats)
+}
+
+// getPlayerProfile returns an account with its lifetime stats and streaks.
+func (s *Server) getPlayerProfile(c *gin.Context) {
+	user, err := s.gameService.User(c.Param("id"))
+	if err == nil {
+		var profile api.Profile
+		profile.User = api.FromUser(user)
+		if profile.Stats, err = s.gameService.PlayerStats(user.ID); err == nil {
+			profile.Streak, err = s.gameService.AccountStreak(user.ID)
+		}
+		if err == nil {
+			c.JSON(200, profile)
+			return
+		}
+	}
+	if errors.Is(err, services.ErrUserNotFound) {
+		c.JSON(404, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(500, gin.H{"error": err.Error()})
+}
+
+type practiceLobbyResponse struct {
+	Lobby          *api.Lobby                     `json:"lobby"`
+	Recommendation *models.PracticeRecommendation `json:"recommendation"`
+}
+
+func (s *Server) createPracticeLobby(c *gin.Context) {
+	playerID := c.Param("id")
+
+	lobby, rec, err := s.gameService.CreatePracticeLobby(playerID)
+	if err != nil {
+		if errors.Is(err, services.ErrNoRecommendations) {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Error creating practice lobby for player %s: %v", playerID, err)
+		c.JSON(500, gin.H{"error": "Failed to create practice lobby"})
+		return
+	}
+
+	c.JSON(201, practiceLobbyResponse{api.LobbySnapshot(lobby), rec})
 }
 
 func (s *Server) handleWebSocket(c *gin.Context) {
@@ -323,16 +1029,47 @@ func (s *Server) handleWebSocket(c *gin.Context) {
 	log.Printf("WebSocket request headers: %v", c.Request.Header.Get("Upgrade"))
 	log.Printf("WebSocket request method: %s", c.Request.Method)
 
+	// Logged-in players pass their token here, as browsers can't set headers
+	// on a WebSocket
+	var user *models.User
+	if token := c.Query("token"); token != "" {
+		var err error
+		if user, err = s.verifyToken(token); err != nil {
+			c.JSON(401, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	conn, err := s.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Printf("WebSocket upgrade FAILED from %s: %v", c.Request.RemoteAddr, err)
 		return
 	}
 	log.Printf("WebSocket upgrade successful from %s", c.Request.RemoteAddr)
+	if s.config.WSCompression {
+		// Only applies if the client offered permessage-deflate
+		conn.SetCompressionLevel(s.config.WSCompressionLevel)
+	}
 	client := &hub.Client{
 		ID:   generateClientID(),
 		Send: make(chan []byte, 256),
 	}
+	if user != nil {
+		client.UserID = user.ID
+	}
+	// Clients declare optional features at connect, e.g. ?capabilities=supports_images,supports_msgpack
+	if capabilities, ok := c.GetQuery("capabilities"); ok {
+		client.Capabilities = models.ParseCapabilities(capabilities)
+		log.Printf("WebSocket client %s capabilities: %+v", client.ID, *client.Capabilities)
+	}
+	// A negotiated subprotocol settles the encoding, whatever was declared
+	if protocol := conn.Subprotocol(); protocol != "" {
+		if client.Capabilities == nil {
+			client.Capabilities = &models.ClientCapabilities{Images: true}
+		}
+		client.Capabilities.MsgPack = protocol == msgpackSubprotocol
+		log.Printf("WebSocket client %s negotiated %s", client.ID, protocol)
+	}
 
 	log.Printf("WebSocket client connected: %s (from %s)", client.ID, c.Request.RemoteAddr)
 	log.Printf("New WebSocket connection created - client ID: %s", client.ID)
@@ -375,16 +1112,23 @@ func (s *Server) handleWebSocket(c *gin.Context) {
 		}
 	}()
 
-	conn.SetWriteDeadline(time.Now().Add(writeWait))
-	if err := conn.WriteJSON(map[string]interface{}{
-		"type":      "connected",
-		"client_id": client.ID,
-	}); err != nil {
+	connected, _ := json.Marshal(connectedMessage{
+		Type:               "connected",
+		ClientID:           client.ID,
+		ProtocolVersion:    hub.ProtocolVersion,
+		MinProtocolVersion: hub.MinProtocolVersion,
+	})
+	if connected, err = client.Encode(connected); err == nil {
+		conn.SetWriteDeadline(time.Now().Add(writeWait))
+		err = conn.WriteMessage(frameType(client), connected)
+	}
+	if err != nil {
 		log.Printf("FAILED to send initial connection message to client %s: %v", client.ID, err)
 		conn.Close()
 		return
 	}
 	log.Printf("Sent initial connection message to client %s", client.ID)
+	s.gameService.UserConnected(client)
 
 	go s.handleClientMessages(conn, client, pongWait)
 	go s.handleClientWrites(conn, client, writeWait, pingPeriod)
@@ -395,7 +1139,9 @@ func (s *Server) handleClientMessages(conn *websocket.Conn, client *hub.Client,
 		log.Printf("WebSocket client %s read goroutine exiting - connection will be closed", client.ID)
 		if client.Hub != nil {
 			client.Hub.Unregister(client)
+			s.gameService.PlayerDisconnected(client.Hub, client.PlayerID, client.ID)
 		}
+		s.gameService.UserDisconnected(client)
 		conn.Close()
 		totalConnections := s.countTotalConnections()
 		log.Printf("Total active WebSocket connections after disconnect: %d", totalConnections)
@@ -405,7 +1151,13 @@ func (s *Server) handleClientMessages(conn *websocket.Conn, client *hub.Client,
 
 	for {
 		var msg WebSocketMessage
-		err := conn.ReadJSON(&msg)
+		messageType, frame, err := conn.ReadMessage()
+		if err == nil && messageType == websocket.BinaryMessage {
+			frame, err = hub.MsgPackToJSON(frame)
+		}
+		if err == nil {
+			err = json.Unmarshal(frame, &msg)
+		}
 		if err != nil {
 			errStr := err.Error()
 
@@ -440,6 +1192,7 @@ func (s *Server) handleClientMessages(conn *websocket.Conn, client *hub.Client,
 		conn.SetReadDeadline(time.Now().Add(pongWait))
 
 		s.handleWebSocketMessage(client, &msg)
+		s.acknowledge(client, &msg)
 	}
 }
 
@@ -467,7 +1220,12 @@ func (s *Server) handleClientWrites(conn *websocket.Conn, client *hub.Client, wr
 				}
 			}
 
-			if err := conn.WriteMessage(websocket.TextMessage, message); err != nil {
+			// Deflating a few bytes costs more than it saves
+			conn.EnableWriteCompression(len(message) >= minCompressedFrame)
+			writeStart := time.Now()
+			err := conn.WriteMessage(frameType(client), message)
+			client.RecordWrite(time.Since(writeStart))
+			if err != nil {
 				if !websocket.IsCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) &&
 					!errors.Is(err, net.ErrClosed) &&
 					!strings.Contains(err.Error(), "use of closed network connection") &&
@@ -494,8 +1252,25 @@ func (s *Server) handleClientWrites(conn *websocket.Conn, client *hub.Client, wr
 	}
 }
 
+// frameType is the WebSocket frame the connection's messages are sent in.
+func frameType(client *hub.Client) int {
+	if client.Binary() {
+		return websocket.BinaryMessage
+	}
+	return websocket.TextMessage
+}
+
 func (s *Server) handleWebSocketMessage(client *hub.Client, msg *WebSocketMessage) {
 	log.Printf("handleWebSocketMessage: Received message type=%s from client=%s", msg.Type, client.ID)
+	if msg.Type == "hello" {
+		// Between the client and this instance, even in a mirrored lobby
+		s.handleHello(client, msg)
+		return
+	}
+	if client.Hub != nil && client.Hub.IsMirror() && msg.Type != "join_lobby" {
+		s.forwardToHost(client, msg)
+		return
+	}
 	switch msg.Type {
 	case "join_lobby":
 		s.handleJoinLobby(client, msg)
@@ -503,10 +1278,28 @@ func (s *Server) handleWebSocketMessage(client *hub.Client, msg *WebSocketMessag
 		s.handleLeaveLobby(client, msg)
 	case "start_game":
 		s.handleStartGame(client, msg)
+	case "pause_game":
+		s.handlePauseGame(client, msg, true)
+	case "resume_game":
+		s.handlePauseGame(client, msg, false)
 	case "submit_answer":
 		s.handleSubmitAnswer(client, msg)
+	case "submit_warmup_answer":
+		s.handleSubmitWarmUpAnswer(client, msg)
+	case "submit_audience_answer":
+		s.handleSubmitAudienceAnswer(client, msg)
 	case "chat_message":
 		s.handleChatMessage(client, msg)
+	case "create_poll":
+		s.handleCreatePoll(client, msg)
+	case "poll_vote":
+		s.handlePollVote(client, msg)
+	case "rsvp":
+		s.handleRSVP(client, msg)
+	case "place_wager":
+		s.handlePlaceWager(client, msg)
+	case "use_power_up":
+		s.handleUsePowerUp(client, msg)
 	default:
 		log.Printf("handleWebSocketMessage: Unknown message type: %s", msg.Type)
 	}
@@ -519,24 +1312,92 @@ func (s *Server) handleJoinLobby(client *hub.Client, msg *WebSocketMessage) {
 	}
 
 	username, ok := msg.Data.(map[string]interface{})["username"].(string)
+	// Logged-in connections play under their account's username
+	var user *models.User
+	if client.UserID != "" {
+		var err error
+		if user, err = s.gameService.User(client.UserID); err != nil {
+			rejectJoin(client, lobbyID, err.Error())
+			return
+		}
+		username, ok = user.Username, true
+	}
 	if !ok {
 		return
 	}
+	// Rejoining players are matched on the normalized name they joined
+	// with, or on their account
+	username, err := models.NormalizeUsername(username)
+	if err != nil {
+		rejectJoin(client, lobbyID, err.Error())
+		return
+	}
+
+	// Guests take their seat back with the session token their join
+	// returned; accounts are matched on the account they're logged in as
+	sessionToken, _ := msg.Data.(map[string]interface{})["session_token"].(string)
 
 	lobbyHub := s.hub.GetLobbyHub(lobbyID)
 	if lobbyHub == nil {
+		if s.hub.Relayed() {
+			s.handleMirrorJoin(client, lobbyID, username, sessionToken)
+		}
 		return
 	}
 
+	// Audience members answer along without taking a player seat
+	asAudience, _ := msg.Data.(map[string]interface{})["audience"].(bool)
+	// New players pay for their seat in lobbies with an entry fee
+	paymentID, _ := msg.Data.(map[string]interface{})["payment_id"].(string)
+
+	// Joining again, elsewhere or as someone else, ends the connection's
+	// presence as the player it joined as before
+	previousHub, previousPlayerID := client.Hub, client.PlayerID
+
 	lobby := lobbyHub.GetLobby()
-	playerExists := false
+	playerExists, seatClaimed := false, false
+	lobby.Lock()
 	for _, p := range lobby.Players {
-		if p.Username == username {
+		if !asAudience && p.UserID == client.UserID && (user != nil || p.Username == username) {
+			if user == nil && !s.holdsSeat(sessionToken, lobbyID, p.ID) {
+				seatClaimed = true
+				break
+			}
 			playerExists = true
 			client.PlayerID = p.ID
 			break
 		}
 	}
+	lobby.Unlock()
+	if seatClaimed {
+		log.Printf("handleJoinLobby: Client %s tried to take %s's seat in lobby %s without its session", client.ID, username, lobbyID)
+		rejectJoin(client, lobbyID, auth.ErrInvalidSession.Error())
+		return
+	}
+
+	// Joining as a new player needs a solved challenge, same as the REST join
+	if !playerExists && s.challenge != nil {
+		data := msg.Data.(map[string]interface{})
+		challenge, _ := data["challenge"].(string)
+		nonce, _ := data["nonce"].(string)
+		captchaToken, _ := data["captcha_token"].(string)
+		err := s.challenge.verify(challengeSolution{Challenge: challenge, Nonce: nonce, CaptchaToken: captchaToken})
+		if err != nil {
+			log.Printf("handleJoinLobby: Challenge rejected for client %s joining lobby %s: %v", client.ID, lobbyID, err)
+			rejectJoin(client, lobbyID, err.Error())
+			return
+		}
+	}
+
+	if asAudience {
+		member, err := s.audienceMember(lobbyID, username, sessionToken)
+		if err != nil {
+			log.Printf("handleJoinLobby: Failed to join the audience of lobby %s for %s: %v", lobbyID, username, err)
+			rejectJoin(client, lobbyID, err.Error())
+			return
+		}
+		client.PlayerID = member.ID
+	}
 
 	if client.Hub != nil && client.Hub != lobbyHub {
 		client.Hub.Unregister(client)
@@ -553,62 +1414,142 @@ func (s *Server) handleJoinLobby(client *hub.Client, msg *WebSocketMessage) {
 
 	client.LobbyID = lobbyID
 	client.Hub = lobbyHub
-	lobbyHub.Register(client)
+	// Reconnecting clients send the last seq they saw to be caught up on
+	// the events they missed
+	lastSeq, resuming := msg.Data.(map[string]interface{})["last_seq"].(float64)
+	var resumed hub.ResumeResult
+	if resuming && lastSeq >= 0 {
+		resumed = lobbyHub.RegisterFrom(client, uint64(lastSeq))
+		log.Printf("handleJoinLobby: Replayed %d event(s) after seq %d to client %s (complete: %v)", resumed.Replayed, uint64(lastSeq), client.ID, resumed.Complete)
+	} else {
+		lobbyHub.Register(client)
+	}
 
-	if !playerExists {
+	seated := playerExists
+	switch {
+	case asAudience:
+		// Audience joins aren't announced; hundreds of lobby snapshots would
+		// swamp every connection
+	case !playerExists:
 		// Join the player and broadcast to all clients (including the one just registered)
-		_, newPlayer, err := s.gameService.JoinLobby(lobbyID, username)
+		_, newPlayer, err := s.gameService.JoinLobby(lobbyID, services.JoinOptions{
+			Username:  username,
+			User:      user,
+			Client:    client,
+			PaymentID: paymentID,
+		})
 		if err == nil && newPlayer != nil {
 			// Set the client's PlayerID from the newly created player
 			client.PlayerID = newPlayer.ID
+			seated = true
 			log.Printf("handleJoinLobby: Set client.PlayerID to %s for newly joined player %s", newPlayer.ID, username)
 		} else if err != nil {
 			log.Printf("handleJoinLobby: Failed to join lobby %s for player %s: %v", lobbyID, username, err)
+			// Entry payment problems are for the joiner to sort out
+			if joinErrorStatus(err) != 400 {
+				rejectJoin(client, lobbyID, err.Error())
+			}
 		}
-	} else {
-		currentLobby := lobbyHub.GetLobby()
-		s.gameService.BroadcastLobbyUpdate(lobbyHub, "player_joined", map[string]interface{}{
-			"lobby": currentLobby,
+	default:
+		// A returning player is announced as reconnected; otherwise refresh
+		// everyone's view of the lobby
+		if !s.gameService.PlayerConnected(lobbyHub, client.PlayerID, client.ID) {
+			lobby.Lock()
+			s.gameService.BroadcastLobbyUpdate(lobbyHub, "player_joined", map[string]interface{}{
+				"lobby": api.FromLobby(lobby),
+			})
+			lobby.Unlock()
+		}
+	}
+	if previousHub != nil && (previousHub != lobbyHub || previousPlayerID != client.PlayerID) {
+		s.gameService.PlayerDisconnected(previousHub, previousPlayerID, client.ID)
+	}
+	if seated || asAudience {
+		lobbyHub.SendTo(client, &models.GameEvent{
+			Type:    "session",
+			LobbyID: lobbyID,
+			Data: map[string]interface{}{
+				"player_id":     client.PlayerID,
+				"session_token": s.sessions.Issue(lobbyID, client.PlayerID),
+			},
 		})
 	}
 
-	
+	s.gameService.DeliverPendingNotifications(lobbyHub, client)
+
+	if resuming {
+		lobbyHub.SendTo(client, &models.GameEvent{
+			Type:    "resumed",
+			LobbyID: lobbyID,
+			Data: map[string]interface{}{
+				"replayed": resumed.Replayed,
+				"complete": resumed.Complete,
+			},
+		})
+		if resumed.Complete {
+			// The replay already brought the client up to date
+			return
+		}
+	}
+
 	currentLobby := lobbyHub.GetLobby()
-	if currentLobby.State == models.InProgress && currentLobby.IsQuestionActive() && currentLobby.CurrentQ != nil {
+	currentLobby.Lock()
+	defer currentLobby.Unlock()
+	questionOpen := currentLobby.IsQuestionActive() || (currentLobby.Paused && currentLobby.QuestionEnd != nil)
+	if currentLobby.Phase == game.Question && questionOpen && currentLobby.CurrentQ != nil {
 		
-		questionEndTimestamp := currentLobby.QuestionEnd.UnixMilli()
-		currentServerTime := time.Now().UnixMilli()
-		remainingSeconds := int(time.Until(*currentLobby.QuestionEnd).Seconds())
-		if remainingSeconds < 0 {
-			remainingSeconds = 0
-		}
+		questionEndTimestamp := models.FormatTimestamp(*currentLobby.QuestionEnd)
+		currentServerTime := models.FormatTimestamp(models.Now())
+		remainingSeconds := int(currentLobby.QuestionTimeLeft().Seconds())
 
-		event := models.GameEvent{
+		lobbyHub.SendTo(client, &models.GameEvent{
 			Type:    "new_question",
 			LobbyID: currentLobby.ID,
 			Data: map[string]interface{}{
-				"question":          currentLobby.CurrentQ,
+				"question":          api.FromQuestion(currentLobby.CurrentQ),
 				"round":             currentLobby.Round,
 				"time_left":         remainingSeconds,
 				"question_end_time": questionEndTimestamp,
 				"server_time":       currentServerTime,
 			},
-			Timestamp: time.Now(),
-		}
-
-		jsonData, err := json.Marshal(event)
-		if err != nil {
-			log.Printf("Error marshaling current question for client %s: %v", client.ID, err)
-		} else {
-		
-			select {
-			case client.Send <- jsonData:
-				log.Printf("Sent current question to newly connected client %s (player: %s) in lobby %s", client.ID, client.PlayerID, lobbyID)
-			default:
-				log.Printf("Warning: Could not send current question to client %s (channel full)", client.ID)
-			}
-		}
+		})
+		log.Printf("Sent current question to newly connected client %s (player: %s) in lobby %s", client.ID, client.PlayerID, lobbyID)
 	}
+	if question, warmUpRound, endsAt := s.gameService.CurrentWarmUpQuestion(lobbyID); question != nil {
+		lobbyHub.SendTo(client, &models.GameEvent{
+			Type:    "warmup_question",
+			LobbyID: currentLobby.ID,
+			Data: map[string]interface{}{
+				"question":          api.FromQuestion(question),
+				"warmup_round":      warmUpRound,
+				"time_left":         int(time.Until(endsAt).Seconds()),
+				"question_end_time": models.FormatTimestamp(endsAt),
+				"server_time":       models.FormatTimestamp(models.Now()),
+			},
+		})
+	}
+	if currentLobby.Paused {
+		lobbyHub.SendTo(client, &models.GameEvent{
+			Type:    "game_paused",
+			LobbyID: currentLobby.ID,
+			Data: map[string]interface{}{
+				"round":        currentLobby.Round,
+				"remaining_ms": currentLobby.RemainingMs,
+				"paused_at":    models.FormatTimestamp(*currentLobby.PausedAt),
+			},
+		})
+	}
+}
+
+// rejectJoin tells a connection its join was refused. The connection isn't
+// registered with the lobby yet, so it gets the event directly.
+func rejectJoin(client *hub.Client, lobbyID, reason string) {
+	client.Offer(&models.GameEvent{
+		Type:      "join_rejected",
+		LobbyID:   lobbyID,
+		Data:      map[string]interface{}{"error": reason},
+		Timestamp: models.Now(),
+	})
 }
 
 func (s *Server) handleLeaveLobby(client *hub.Client, msg *WebSocketMessage) {
@@ -623,15 +1564,6 @@ func (s *Server) handleLeaveLobby(client *hub.Client, msg *WebSocketMessage) {
 	}
 
 	playerID := client.PlayerID
-	if playerID == "" {
-		
-		if data, ok := msg.Data.(map[string]interface{}); ok {
-			if pid, ok := data["player_id"].(string); ok {
-				playerID = pid
-			}
-		}
-	}
-
 	if playerID == "" {
 		log.Printf("handleLeaveLobby: No player ID found for client %s in lobby %s", client.ID, lobbyID)
 		if client.Hub != nil {
@@ -648,6 +1580,7 @@ func (s *Server) handleLeaveLobby(client *hub.Client, msg *WebSocketMessage) {
 
 	if client.Hub != nil {
 		client.Hub.Unregister(client)
+		s.gameService.PlayerDisconnected(client.Hub, playerID, client.ID)
 		client.Hub = nil
 		client.LobbyID = ""
 		client.PlayerID = ""
@@ -663,6 +1596,28 @@ func (s *Server) handleStartGame(client *hub.Client, msg *WebSocketMessage) {
 	s.gameService.StartGame(lobbyID)
 }
 
+// handlePauseGame pauses or resumes for the player this connection joined
+// as; only the host's requests succeed.
+func (s *Server) handlePauseGame(client *hub.Client, msg *WebSocketMessage, paused bool) {
+	lobbyID := msg.LobbyID
+	if lobbyID == "" {
+		lobbyID = client.LobbyID
+	}
+	if lobbyID == "" || client.PlayerID == "" {
+		return
+	}
+
+	var err error
+	if paused {
+		err = s.gameService.PauseGame(lobbyID, client.PlayerID)
+	} else {
+		err = s.gameService.ResumeGame(lobbyID, client.PlayerID)
+	}
+	if err != nil {
+		log.Printf("handlePauseGame: Player %s could not %s lobby %s: %v", client.PlayerID, msg.Type, lobbyID, err)
+	}
+}
+
 func (s *Server) handleSubmitAnswer(client *hub.Client, msg *WebSocketMessage) {
 	lobbyID := msg.LobbyID
 	if lobbyID == "" {
@@ -670,15 +1625,46 @@ func (s *Server) handleSubmitAnswer(client *hub.Client, msg *WebSocketMessage) {
 	}
 
 	data, ok := msg.Data.(map[string]interface{})
-	if !ok {
+	if !ok || client.PlayerID == "" {
 		return
 	}
 
-	playerID, _ := data["player_id"].(string)
-	answer, _ := data["answer"].(float64)
-	responseTime, _ := data["response_time"].(float64)
+	answer, err := models.ParseSubmittedAnswer(data["answer"])
+	if err != nil {
+		log.Printf("handleSubmitAnswer: Invalid answer from client %s: %v", client.ID, err)
+		return
+	}
+
+	// Clients may report when they sent the answer, in server time
+	var sentAt time.Time
+	if raw, ok := data["sent_at"].(string); ok {
+		if parsed, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+			sentAt = parsed
+		}
+	}
 
-	s.gameService.SubmitAnswer(lobbyID, playerID, int(answer), int64(responseTime))
+	s.gameService.SubmitAnswerSentAt(lobbyID, client.PlayerID, answer, sentAt)
+}
+
+// handleSubmitWarmUpAnswer answers the warm-up question for the player this
+// connection joined as; the result goes out as warmup_answer_received.
+func (s *Server) handleSubmitWarmUpAnswer(client *hub.Client, msg *WebSocketMessage) {
+	lobbyID := msg.LobbyID
+	if lobbyID == "" {
+		lobbyID = client.LobbyID
+	}
+	data, ok := msg.Data.(map[string]interface{})
+	if lobbyID == "" || client.PlayerID == "" || !ok {
+		return
+	}
+
+	answer, err := models.ParseSubmittedAnswer(data["answer"])
+	if err == nil {
+		_, err = s.gameService.SubmitWarmUpAnswer(lobbyID, client.PlayerID, answer)
+	}
+	if err != nil {
+		log.Printf("handleSubmitWarmUpAnswer: Answer from client %s in lobby %s rejected: %v", client.ID, lobbyID, err)
+	}
 }
 
 func (s *Server) handleChatMessage(client *hub.Client, msg *WebSocketMessage) {
@@ -709,33 +1695,14 @@ func (s *Server) handleChatMessage(client *hub.Client, msg *WebSocketMessage) {
 		return
 	}
 
-	// Get player info - try multiple sources
+	// Chat is sent as the player this connection joined as, never as a
+	// player ID the message names
 	playerID := client.PlayerID
 	if playerID == "" {
-		// Try from top-level message first (frontend sends it there)
-		if msg.PlayerID != "" {
-			playerID = msg.PlayerID
-		}
-	}
-	if playerID == "" {
-		// Try to get from message data as fallback
-		if pid, ok := data["player_id"].(string); ok {
-			playerID = pid
-		}
-	}
-
-	if playerID == "" {
-		log.Printf("handleChatMessage: No player ID found for client %s (client.PlayerID: %s, msg.PlayerID: %s, data: %v)",
-			client.ID, client.PlayerID, msg.PlayerID, data)
+		log.Printf("handleChatMessage: Client %s sent chat before joining lobby %s as a player", client.ID, lobbyID)
 		return
 	}
 
-	// Update client.PlayerID if we found it from the message (for future messages)
-	if client.PlayerID == "" && playerID != "" {
-		client.PlayerID = playerID
-		log.Printf("handleChatMessage: Set client.PlayerID to %s for client %s", playerID, client.ID)
-	}
-
 	// Get lobby hub
 	lobbyHub := s.hub.GetLobbyHub(lobbyID)
 	if lobbyHub == nil {
@@ -745,7 +1712,9 @@ func (s *Server) handleChatMessage(client *hub.Client, msg *WebSocketMessage) {
 
 	// Get player username
 	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
 	player := lobby.GetPlayer(playerID)
+	lobby.Unlock()
 	if player == nil {
 		log.Printf("handleChatMessage: Player %s not found in lobby %s", playerID, lobbyID)
 		return
@@ -761,30 +1730,33 @@ func (s *Server) handleChatMessage(client *hub.Client, msg *WebSocketMessage) {
 		log.Printf("  Client %s (player: %s) will receive message", clientID, client.PlayerID)
 	}
 
-	s.gameService.BroadcastLobbyUpdate(lobbyHub, "chat_message", map[string]interface{}{
-		"player_id": playerID,
-		"username":  player.Username,
-		"message":   messageText,
-		"timestamp": time.Now().UnixMilli(),
-	})
+	if err := s.gameService.PostChatMessage(lobbyHub, player, messageText); err != nil {
+		log.Printf("handleChatMessage: Message from player %s in lobby %s rejected: %v", playerID, lobbyID, err)
+		var rejection *services.ChatRejection
+		if !errors.As(err, &rejection) {
+			rejection = &services.ChatRejection{Message: err.Error()}
+		}
+		lobbyHub.SendTo(client, &models.GameEvent{
+			Type:    "chat_rejected",
+			LobbyID: lobbyID,
+			Data:    rejection,
+		})
+		return
+	}
 
 	log.Printf("WebSocket: Chat message broadcast completed for lobby %s", lobbyID)
 }
 
-func (s *Server) Start() error {
-	return s.router.Run(":" + s.config.Port)
-}
-
 func (s *Server) countTotalConnections() int {
 	total := 0
 
-	allLobbies, err := s.gameService.GetRepository().ListLobbies()
+	waiting, err := s.gameService.GetRepository().ListLobbies(repository.WaitingLobbies)
 	if err != nil {
 		log.Printf("Error listing lobbies for connection count: %v", err)
 		return 0
 	}
 
-	for _, lobby := range allLobbies {
+	for _, lobby := range waiting.Lobbies {
 		lobbyHub := s.hub.GetLobbyHub(lobby.ID)
 		if lobbyHub != nil {
 			clients := lobbyHub.GetClients()
@@ -799,6 +1771,9 @@ func (s *Server) countTotalConnections() int {
 	return total
 }
 
+// clientSeq tells apart connections opened in the same second.
+var clientSeq atomic.Uint64
+
 func generateClientID() string {
-	return "client_" + time.Now().Format("20060102150405") + "_" + time.Now().Format("000000000")
+	return fmt.Sprintf("client_%s_%09d", time.Now().Format("20060102150405"), clientSeq.Add(1))
 }
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"buildprize-game/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestServer runs a server on the in-memory repository with the default
+// configuration, changed by configure when given.
+func newTestServer(t *testing.T, configure func(cfg *config.Config)) *Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	t.Setenv("STORAGE", "memory")
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if configure != nil {
+		configure(cfg)
+	}
+	return NewServer(cfg)
+}
+
+// serve sends a request to the server's router, encoding body as JSON when
+// it isn't nil.
+func serve(s *Server, method, path string, body interface{}, header http.Header) *httptest.ResponseRecorder {
+	var reader bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&reader).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &reader)
+	req.Header.Set("Content-Type", "application/json")
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	recorder := httptest.NewRecorder()
+	s.router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+// createTestLobby creates a lobby over REST and returns its ID.
+func createTestLobby(t *testing.T, s *Server, body map[string]interface{}) string {
+	t.Helper()
+	if body == nil {
+		body = map[string]interface{}{}
+	}
+	if body["name"] == nil {
+		body["name"] = "Test Lobby"
+	}
+	resp := serve(s, "POST", "/api/v1/lobbies", body, nil)
+	if resp.Code != 201 {
+		t.Fatalf("Create lobby: %d %s", resp.Code, resp.Body)
+	}
+	var lobby createdLobbyResponse
+	if err := json.Unmarshal(resp.Body.Bytes(), &lobby); err != nil {
+		t.Fatalf("Create lobby response: %v", err)
+	}
+	return lobby.ID
+}
+
+// joinTestLobby joins a lobby over REST and returns the player's ID and
+// session token.
+func joinTestLobby(t *testing.T, s *Server, lobbyID, username string) (string, string) {
+	t.Helper()
+	resp := serve(s, "POST", "/api/v1/lobbies/"+lobbyID+"/join", map[string]string{"username": username}, nil)
+	if resp.Code != 200 {
+		t.Fatalf("Join lobby: %d %s", resp.Code, resp.Body)
+	}
+	var joined joinResponse
+	if err := json.Unmarshal(resp.Body.Bytes(), &joined); err != nil {
+		t.Fatalf("Join lobby response: %v", err)
+	}
+	return joined.Player.ID, joined.SessionToken
+}
+
+// sessionHeaders sends a player's session token.
+func sessionHeaders(token string) http.Header {
+	return http.Header{sessionHeader: {token}}
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"errors"
+
+	"buildprize-game/internal/api"
+	"buildprize-game/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// updateLobbyRequest changes only the fields it includes; an empty
+// category_weights object serves the whole question bank again.
+type updateLobbyRequest struct {
+	PlayerID        string          `json:"player_id" binding:"required"`
+	Name            *string         `json:"name"`
+	MaxRounds       *int            `json:"max_rounds"`
+	QuestionTime    *int            `json:"question_time"` // seconds
+	CategoryWeights *map[string]int `json:"category_weights"`
+	MaxPlayers      *int            `json:"max_players"`
+	WrongPenalty    *int            `json:"wrong_penalty"`
+}
+
+func settingsErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrLobbyNotFound):
+		return 404
+	case errors.Is(err, services.ErrNotHost):
+		return 403
+	case errors.Is(err, services.ErrGameInProgress), errors.Is(err, services.ErrCapacityTooSmall):
+		return 409
+	}
+	return 400
+}
+
+// updateLobby changes a waiting lobby's settings. Only the host can, with
+// their session token.
+func (s *Server) updateLobby(c *gin.Context) {
+	lobbyID := c.Param("id")
+	var req updateLobbyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if !s.checkSession(c, lobbyID, req.PlayerID) {
+		return
+	}
+
+	lobby, err := s.gameService.UpdateLobbySettings(lobbyID, req.PlayerID, services.LobbySettings{
+		Name:            req.Name,
+		MaxRounds:       req.MaxRounds,
+		QuestionTime:    req.QuestionTime,
+		CategoryWeights: req.CategoryWeights,
+		MaxPlayers:      req.MaxPlayers,
+		WrongPenalty:    req.WrongPenalty,
+	})
+	if err != nil {
+		c.JSON(settingsErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, api.LobbySnapshot(lobby))
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// certCheckInterval is how often TLS_CERT_FILE is checked for a renewed
+// certificate.
+const certCheckInterval = time.Minute
+
+// tlsSetup is how the game's listeners terminate TLS: their tls.Config, and
+// the handler that answers plain HTTP on HTTP_REDIRECT_ADDR.
+type tlsSetup struct {
+	config   *tls.Config
+	redirect http.Handler
+}
+
+// newTLSSetup returns nil when TLS isn't configured.
+func (s *Server) newTLSSetup() (*tlsSetup, error) {
+	redirect := redirectToHTTPS(s.httpsPort())
+	switch {
+	case s.config.TLSCertFile != "":
+		certs, err := loadCertFiles(s.config.TLSCertFile, s.config.TLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return &tlsSetup{
+			config:   &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: certs.get},
+			redirect: redirect,
+		}, nil
+
+	case s.config.AutocertDomains != "":
+		var domains []string
+		for _, domain := range strings.Split(s.config.AutocertDomains, ",") {
+			if domain = strings.TrimSpace(domain); domain != "" {
+				domains = append(domains, domain)
+			}
+		}
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(domains...),
+			Cache:      autocert.DirCache(s.config.AutocertCacheDir),
+			Email:      s.config.AutocertEmail,
+		}
+		config := manager.TLSConfig()
+		config.MinVersion = tls.VersionTLS12
+		// The HTTP challenge needs HTTP_REDIRECT_ADDR on port 80; without
+		// it, certificates are issued over TLS-ALPN on port 443
+		return &tlsSetup{config: config, redirect: manager.HTTPHandler(redirect)}, nil
+	}
+	return nil, nil
+}
+
+// httpsPort is the port HTTP requests are redirected to: that of the first
+// TCP address the game listens on, or none for 443.
+func (s *Server) httpsPort() string {
+	for _, addr := range s.listenAddrs() {
+		if strings.HasPrefix(addr, unixPrefix) {
+			continue
+		}
+		if _, port, err := net.SplitHostPort(addr); err == nil && port != "443" {
+			return port
+		}
+		break
+	}
+	return ""
+}
+
+// redirectToHTTPS sends plain HTTP requests to the same URL over HTTPS.
+func redirectToHTTPS(port string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
+
+// certFiles serves a certificate from PEM files, picking up a renewed
+// certificate, such as one written by certbot, without a restart.
+type certFiles struct {
+	certFile, keyFile string
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func loadCertFiles(certFile, keyFile string) (*certFiles, error) {
+	c := &certFiles{certFile: certFile, keyFile: keyFile}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *certFiles) load() error {
+	info, err := os.Stat(c.certFile)
+	if err != nil {
+		return fmt.Errorf("TLS_CERT_FILE: %w", err)
+	}
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE: %w", err)
+	}
+	c.cert, c.modTime = &cert, info.ModTime()
+	return nil
+}
+
+// get is the listeners' tls.Config.GetCertificate. A renewed certificate
+// that can't be loaded, e.g. because its key isn't written yet, is retried
+// on the next check while the current one keeps being served.
+func (c *certFiles) get(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Since(c.checkedAt) >= certCheckInterval {
+		c.checkedAt = time.Now()
+		if info, err := os.Stat(c.certFile); err == nil && !info.ModTime().Equal(c.modTime) {
+			if err := c.load(); err != nil {
+				log.Printf("Keeping the current TLS certificate: %v", err)
+			} else {
+				log.Printf("Loaded renewed TLS certificate from %s", c.certFile)
+			}
+		}
+	}
+	return c.cert, nil
+}
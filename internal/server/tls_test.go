@@ -0,0 +1,221 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/config"
+)
+
+// writeSelfSigned writes a self-signed certificate for localhost, named
+// commonName, and its key as PEM files, returning the certificate.
+func writeSelfSigned(t *testing.T, certFile, keyFile, commonName string) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey: %v", err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return cert
+}
+
+func servedCommonName(t *testing.T, certs *certFiles) string {
+	t.Helper()
+	cert, err := certs.get(nil)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("ParseCertificate: %v", err)
+	}
+	return leaf.Subject.CommonName
+}
+
+// A renewed certificate is picked up on the next check, and one that can't
+// be loaded yet leaves the current one in place.
+func TestCertFilesReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if _, err := loadCertFiles(certFile, keyFile); err == nil {
+		t.Fatal("Expected an error for missing certificate files")
+	}
+	writeSelfSigned(t, certFile, keyFile, "first")
+	certs, err := loadCertFiles(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("loadCertFiles: %v", err)
+	}
+	certs.checkedAt = time.Now()
+	if name := servedCommonName(t, certs); name != "first" {
+		t.Fatalf("Serving %q, want first", name)
+	}
+
+	writeSelfSigned(t, certFile, keyFile, "second")
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+	if name := servedCommonName(t, certs); name != "first" {
+		t.Fatalf("Serving %q before the next check, want first", name)
+	}
+	certs.checkedAt = time.Time{}
+	if name := servedCommonName(t, certs); name != "second" {
+		t.Fatalf("Serving %q after the check, want second", name)
+	}
+
+	// A certificate written before its key doesn't match the old key
+	otherDir := t.TempDir()
+	writeSelfSigned(t, filepath.Join(otherDir, "cert.pem"), keyFile+".new", "third")
+	renewed, _ := os.ReadFile(filepath.Join(otherDir, "cert.pem"))
+	os.WriteFile(certFile, renewed, 0o600)
+	evenLater := later.Add(time.Minute)
+	os.Chtimes(certFile, evenLater, evenLater)
+	certs.checkedAt = time.Time{}
+	if name := servedCommonName(t, certs); name != "second" {
+		t.Fatalf("Serving %q with a mismatched key, want second", name)
+	}
+	os.Rename(keyFile+".new", keyFile)
+	certs.checkedAt = time.Time{}
+	if name := servedCommonName(t, certs); name != "third" {
+		t.Fatalf("Serving %q once the key is written, want third", name)
+	}
+	if _, err := loadCertFiles(filepath.Join(otherDir, "cert.pem"), filepath.Join(otherDir, "missing.pem")); err == nil {
+		t.Fatal("Expected an error for a missing key file")
+	}
+}
+
+// With TLS_CERT_FILE set the game is served over HTTPS with that
+// certificate.
+func TestServeTLSWithCertFiles(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	cert := writeSelfSigned(t, certFile, keyFile, "game")
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.TLSCertFile = certFile
+		cfg.TLSKeyFile = keyFile
+	})
+	setup, err := s.newTLSSetup()
+	if err != nil || setup == nil {
+		t.Fatalf("newTLSSetup: %v, %v", setup, err)
+	}
+	if setup.config.MinVersion != tls.VersionTLS12 {
+		t.Fatalf("MinVersion %x, want TLS 1.2", setup.config.MinVersion)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: s.router, TLSConfig: setup.config}
+	go srv.ServeTLS(listener, "", "")
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	resp, err := client.Get("https://" + listener.Addr().String() + "/health")
+	if err != nil {
+		t.Fatalf("GET over HTTPS: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 || resp.TLS == nil || resp.TLS.PeerCertificates[0].Subject.CommonName != "game" {
+		t.Fatalf("Unexpected HTTPS response: %d %+v", resp.StatusCode, resp.TLS)
+	}
+
+	broken := newTestServer(t, func(cfg *config.Config) {
+		cfg.TLSCertFile = certFile
+		cfg.TLSKeyFile = filepath.Join(dir, "missing.pem")
+	})
+	if _, err := broken.newTLSSetup(); err == nil {
+		t.Fatal("Expected an error for an unreadable key")
+	}
+}
+
+// Without TLS settings the game is served over plain HTTP.
+func TestPlainHTTPWithoutTLS(t *testing.T) {
+	s := newTestServer(t, nil)
+	if s.config.TLSEnabled() {
+		t.Fatal("TLS enabled by default")
+	}
+	setup, err := s.newTLSSetup()
+	if err != nil || setup != nil {
+		t.Fatalf("newTLSSetup without TLS settings: %v, %v", setup, err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: s.router}
+	go srv.Serve(listener)
+	defer srv.Close()
+	resp, err := http.Get("http://" + listener.Addr().String() + "/health")
+	if err != nil {
+		t.Fatalf("GET over HTTP: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 200 || resp.TLS != nil {
+		t.Fatalf("Unexpected HTTP response: %d", resp.StatusCode)
+	}
+}
+
+func TestRedirectToHTTPS(t *testing.T) {
+	for port, want := range map[string]string{
+		"":     "https://quiz.example.com/lobbies?state=all",
+		"8443": "https://quiz.example.com:8443/lobbies?state=all",
+	} {
+		req := httptest.NewRequest("GET", "http://quiz.example.com:8080/lobbies?state=all", nil)
+		recorder := httptest.NewRecorder()
+		redirectToHTTPS(port).ServeHTTP(recorder, req)
+		if recorder.Code != 301 || recorder.Header().Get("Location") != want {
+			t.Errorf("Redirect with port %q: %d %s, want %s", port, recorder.Code, recorder.Header().Get("Location"), want)
+		}
+	}
+
+	for addrs, want := range map[string]string{
+		"":                              "8080", // PORT
+		":443":                          "",
+		"unix:/run/quiz.sock,:8443":     "8443",
+		"127.0.0.1:9443,127.0.0.1:9444": "9443",
+	} {
+		s := &Server{config: &config.Config{Port: "8080", ListenAddrs: addrs}}
+		if got := s.httpsPort(); got != want {
+			t.Errorf("httpsPort with LISTEN_ADDRS %q = %q, want %q", addrs, got, want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"log"
+
+	"buildprize-game/internal/hub"
+)
+
+// handlePlaceWager stakes points for the player this connection joined as
+// on the wager round about to be asked. Stakes that aren't accepted come
+// back to the sender as wager_rejected.
+func (s *Server) handlePlaceWager(client *hub.Client, msg *WebSocketMessage) {
+	lobbyID := msg.LobbyID
+	if lobbyID == "" {
+		lobbyID = client.LobbyID
+	}
+	data, ok := msg.Data.(map[string]interface{})
+	if lobbyID == "" || client.PlayerID == "" || !ok {
+		return
+	}
+
+	stake, ok := data["stake"].(float64)
+	if !ok || stake != float64(int(stake)) {
+		replyTo(client, lobbyID, "wager_rejected", map[string]interface{}{"error": "stake must be a whole number of points"})
+		return
+	}
+	if _, err := s.gameService.PlaceWager(lobbyID, client.PlayerID, int(stake)); err != nil {
+		log.Printf("handlePlaceWager: Wager from client %s in lobby %s rejected: %v", client.ID, lobbyID, err)
+		replyTo(client, lobbyID, "wager_rejected", map[string]interface{}{"error": err.Error()})
+	}
+}
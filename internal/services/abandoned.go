@@ -0,0 +1,170 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"buildprize-game/internal/game"
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/repository"
+)
+
+const (
+	// defaultAbandonedLobbyAge is how long a waiting lobby nobody is
+	// connected to is kept unless changed with SetAbandonedLobbyAge.
+	defaultAbandonedLobbyAge = 30 * time.Minute
+	// defaultAbandonedGameGrace is how long a game runs on after its last
+	// player dropped off unless changed with SetAbandonedGameGrace.
+	defaultAbandonedGameGrace = 5 * time.Minute
+)
+
+// SetAbandonedLobbyAge sets how long a waiting lobby with no connections is
+// kept before it's deleted. Zero or less keeps the current setting.
+func (gs *GameService) SetAbandonedLobbyAge(age time.Duration) {
+	if age <= 0 {
+		return
+	}
+	gs.mu.Lock()
+	gs.abandonedLobbyAge = age
+	gs.mu.Unlock()
+}
+
+// SetAbandonedGameGrace sets how long a game whose players have all
+// disconnected keeps running before it's deleted. Zero or less keeps the
+// current setting.
+func (gs *GameService) SetAbandonedGameGrace(grace time.Duration) {
+	if grace <= 0 {
+		return
+	}
+	gs.mu.Lock()
+	gs.abandonedGameGrace = grace
+	gs.mu.Unlock()
+}
+
+// DeleteAbandonedLobbies deletes the lobbies nobody is coming back to, and
+// returns how many: waiting lobbies nobody has been connected to for the
+// abandoned-lobby age, and running games whose players all dropped off more
+// than the abandoned-game grace ago. Their connections, if any, are sent
+// game_cancelled with reason "abandoned" first. Scheduled, demo and sandbox
+// lobbies are left alone. The cleanup task runs it every few minutes.
+//
+// Presence is only known for connections to this instance, so with the
+// relay on, lobbies are left alone. Without it, stored lobbies no longer
+// hosted here, left over from an earlier run, are deleted at the
+// abandoned-lobby age too.
+func (gs *GameService) DeleteAbandonedLobbies() int {
+	if gs.hub.Relayed() {
+		return 0
+	}
+	gs.mu.Lock()
+	age, grace := gs.abandonedLobbyAge, gs.abandonedGameGrace
+	gs.mu.Unlock()
+
+	now := models.Now()
+	deleted := 0
+	for lobbyID, lobbyHub := range gs.hub.GetAllLobbies() {
+		if gs.closeIfAbandoned(lobbyHub, now, age, grace) {
+			log.Printf("Deleted abandoned lobby %s", lobbyID)
+			deleted++
+		}
+	}
+	return deleted + gs.deleteUnhostedLobbies(now, age)
+}
+
+// closeIfAbandoned cancels and discards the lobby if nobody is coming back
+// to it.
+func (gs *GameService) closeIfAbandoned(lobbyHub *hub.LobbyHub, now time.Time, age, grace time.Duration) bool {
+	if len(lobbyHub.GetClients()) > 0 {
+		return false
+	}
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	idle, humans := lobbyIdleSince(lobby)
+	abandoned := false
+	switch {
+	case lobby.Demo || lobby.Sandbox:
+	case lobby.Phase == game.Waiting:
+		abandoned = lobby.StartsAt == nil && now.Sub(idle) >= age
+	case lobby.Phase.Running():
+		abandoned = humans && now.Sub(idle) >= grace
+	}
+	if !abandoned {
+		lobby.Unlock()
+		return false
+	}
+	gs.BroadcastLobbyUpdate(lobbyHub, "game_cancelled", map[string]interface{}{
+		"reason": "abandoned",
+	})
+	lobby.Unlock()
+	gs.discardLobby(lobby.ID, "abandoned")
+	return true
+}
+
+// lobbyIdleSince is when anyone last had a hand in the lobby: its creation
+// or start, or a human player last connecting or dropping off, and whether
+// it has human players, none of them online. Call with the lobby locked.
+func lobbyIdleSince(lobby *models.Lobby) (time.Time, bool) {
+	idle := lobby.CreatedAt
+	if lobby.StartedAt != nil && lobby.StartedAt.After(idle) {
+		idle = *lobby.StartedAt
+	}
+	humans := false
+	for _, p := range lobby.Players {
+		if !p.IsHuman() {
+			continue
+		}
+		if p.Online {
+			return models.Now(), false
+		}
+		humans = true
+		if p.LastSeen != nil && p.LastSeen.After(idle) {
+			idle = *p.LastSeen
+		}
+	}
+	return idle, humans
+}
+
+// deleteUnhostedLobbies deletes stored waiting and running lobbies that no
+// lobby hub here is serving and that were created over age ago.
+func (gs *GameService) deleteUnhostedLobbies(now time.Time, age time.Duration) int {
+	var ghosts []*models.Lobby
+	for _, state := range []models.GameState{models.Waiting, models.InProgress} {
+		query := repository.LobbyQuery{State: state, Sort: repository.SortOldest, Limit: repository.MaxLobbyPageSize}
+	pages:
+		for {
+			page, err := gs.repo.ListLobbies(query)
+			if err != nil {
+				log.Printf("Error listing lobbies to clean up: %v", err)
+				return 0
+			}
+			for _, lobby := range page.Lobbies {
+				if now.Sub(lobby.CreatedAt) < age {
+					// Oldest first, so the rest are newer still
+					break pages
+				}
+				if gs.hub.GetLobbyHub(lobby.ID) == nil && !lobby.Demo && (lobby.StartsAt == nil || now.After(*lobby.StartsAt)) {
+					ghosts = append(ghosts, lobby)
+				}
+			}
+			query.Offset += len(page.Lobbies)
+			if len(page.Lobbies) < query.Limit || query.Offset >= page.Total {
+				break
+			}
+		}
+	}
+
+	deleted := 0
+	for _, lobby := range ghosts {
+		if !gs.recordEntryRefunds(lobby, "abandoned") {
+			continue
+		}
+		if err := gs.repo.DeleteLobby(lobby.ID); err != nil {
+			log.Printf("Error deleting unhosted lobby %s: %v", lobby.ID, err)
+			continue
+		}
+		log.Printf("Deleted lobby %s, which isn't hosted anywhere", lobby.ID)
+		deleted++
+	}
+	return deleted
+}
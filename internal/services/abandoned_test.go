@@ -0,0 +1,64 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// Waiting lobbies nobody has been connected to for the abandoned-lobby age,
+// games whose players all left longer than the grace ago, and stored lobbies
+// hosted nowhere are deleted; lobbies someone is still in are kept.
+func TestAbandonedLobbiesDeleted(t *testing.T) {
+	gs, gameHub, repo := newService(t)
+	gs.SetAbandonedLobbyAge(time.Hour)
+	gs.SetAbandonedGameGrace(time.Minute)
+	longAgo := models.Now().Add(-2 * time.Hour)
+
+	create := func(name string) *models.Lobby {
+		lobby, err := gs.CreateLobby(services.LobbyOptions{Name: name, MaxRounds: 3, MaxPlayers: 4})
+		if err != nil {
+			t.Fatalf("CreateLobby: %v", err)
+		}
+		gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"})
+		gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "bob"})
+		return lobby
+	}
+	empty := create("Empty")
+	fresh := create("Fresh")
+	watched := create("Watched")
+	running := create("Running")
+	for _, lobby := range []*models.Lobby{empty, watched, running} {
+		lobby.Lock()
+		lobby.CreatedAt = longAgo
+		lobby.Unlock()
+	}
+	gameHub.GetLobbyHub(watched.ID).Register(&hub.Client{ID: "watcher", LobbyID: watched.ID, Send: make(chan []byte, 64)})
+	if err := gs.StartGame(running.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	running.Lock()
+	running.StartedAt = &longAgo
+	running.Unlock()
+
+	ghost := models.NewLobby("Ghost", 3)
+	ghost.CreatedAt = longAgo
+	repo.SaveLobby(ghost)
+
+	if deleted := gs.DeleteAbandonedLobbies(); deleted != 3 {
+		t.Fatalf("Expected the empty, running and ghost lobbies deleted, got %d", deleted)
+	}
+	for _, lobby := range []*models.Lobby{empty, running, ghost} {
+		if _, err := repo.GetLobby(lobby.ID); err == nil || gameHub.GetLobbyHub(lobby.ID) != nil {
+			t.Fatalf("Expected lobby %s deleted", lobby.Name)
+		}
+	}
+	for _, lobby := range []*models.Lobby{fresh, watched} {
+		if gameHub.GetLobbyHub(lobby.ID) == nil {
+			t.Fatalf("Expected lobby %s kept", lobby.Name)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"log"
+
+	"buildprize-game/internal/models"
+)
+
+// SetStreakBonuses sets the XP and coins accounts earn for their day and
+// win streaks (see models.StreakBonuses). Negative amounts are ignored.
+func (gs *GameService) SetStreakBonuses(bonuses models.StreakBonuses) {
+	if bonuses.DailyXP < 0 || bonuses.DailyCoins < 0 || bonuses.WinXP < 0 || bonuses.WinCoins < 0 || bonuses.Cap < 0 {
+		return
+	}
+	gs.mu.Lock()
+	gs.streakBonuses = bonuses
+	gs.mu.Unlock()
+}
+
+// recordAccountStreaks adds a finished game to the day and win streaks of
+// the accounts that played it out, crediting their bonuses, and returns the
+// account_streak_milestone events to send for the milestones they reached.
+// Updates are serialized, so an account finishing two games at once has
+// both counted. The caller holds the lobby lock.
+func (gs *GameService) recordAccountStreaks(lobby *models.Lobby, standings []models.Standing) []map[string]interface{} {
+	gs.mu.Lock()
+	bonuses := gs.streakBonuses
+	gs.mu.Unlock()
+	finishedAt := models.Now()
+	if lobby.FinishedAt != nil {
+		finishedAt = *lobby.FinishedAt
+	}
+
+	gs.streaksMu.Lock()
+	defer gs.streaksMu.Unlock()
+	var milestones []map[string]interface{}
+	for _, standing := range standings {
+		if standing.UserID == "" {
+			continue
+		}
+		streak, err := gs.repo.GetAccountStreak(standing.UserID)
+		if err != nil {
+			log.Printf("ALERT: failed to load the streaks of account %s for lobby %s: %v", standing.UserID, lobby.ID, err)
+			continue
+		}
+		award := streak.Record(finishedAt, standing.Rank == 1, bonuses)
+		if err := gs.repo.SaveAccountStreak(streak); err != nil {
+			log.Printf("ALERT: failed to add lobby %s to the streaks of account %s: %v", lobby.ID, standing.UserID, err)
+			continue
+		}
+		for _, reached := range []struct {
+			kind   string
+			streak int
+		}{{"days", award.DayMilestone}, {"wins", award.WinMilestone}} {
+			if reached.streak == 0 {
+				continue
+			}
+			milestones = append(milestones, map[string]interface{}{
+				"player_id": standing.PlayerID,
+				"username":  standing.Username,
+				"user_id":   standing.UserID,
+				"kind":      reached.kind,
+				"streak":    reached.streak,
+				"xp":        award.XP,
+				"coins":     award.Coins,
+			})
+		}
+	}
+	return milestones
+}
+
+// AccountStreak returns an account's day and win streaks as of now, and the
+// bonuses they've earned it.
+func (gs *GameService) AccountStreak(userID string) (*models.AccountStreak, error) {
+	if _, err := gs.User(userID); err != nil {
+		return nil, err
+	}
+	streak, err := gs.repo.GetAccountStreak(userID)
+	if err != nil {
+		return nil, err
+	}
+	current := streak.Current(models.Now())
+	return &current, nil
+}
@@ -0,0 +1,135 @@
+package services_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// Accounts keep day and win streaks across games, earn bonuses for them and
+// have their milestones announced to the lobby.
+func TestAccountStreaks(t *testing.T) {
+	gs, gameHub, repo := newService(t)
+	gs.SetStreakBonuses(models.StreakBonuses{DailyXP: 10, DailyCoins: 5, WinXP: 4, WinCoins: 1, Cap: 3})
+	alice, _ := gs.Register("alice", "correct horse")
+	bob, _ := gs.Register("bob", "battery staple")
+
+	// alice last played yesterday, two days in a row
+	yesterday := models.Now().AddDate(0, 0, -1).UTC().Format(time.DateOnly)
+	repo.SaveAccountStreak(&models.AccountStreak{UserID: alice.ID, DayStreak: 2, BestDayStreak: 2, LastPlayedOn: yesterday})
+
+	// play has winner answer a one-round game right and loser wrong, and
+	// returns the account_streak_milestone events sent
+	play := func(winner, loser *models.User) []map[string]interface{} {
+		t.Helper()
+		lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Streaks", MaxRounds: 3})
+		if err != nil {
+			t.Fatalf("CreateLobby: %v", err)
+		}
+		watcher := &hub.Client{ID: "watcher", LobbyID: lobby.ID, Send: make(chan []byte, 256)}
+		gameHub.GetLobbyHub(lobby.ID).Register(watcher)
+		_, w, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{User: winner})
+		_, l, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{User: loser})
+		if err := gs.StartGame(lobby.ID); err != nil {
+			t.Fatalf("StartGame: %v", err)
+		}
+		lobby.Lock()
+		question := lobby.CurrentQ
+		lobby.Unlock()
+		gs.SubmitAnswer(lobby.ID, w.ID, correctAnswer(question))
+		gs.SubmitAnswer(lobby.ID, l.ID, wrongAnswer(question))
+		if err := gs.ForceEndGame(lobby.ID); err != nil {
+			t.Fatalf("ForceEndGame: %v", err)
+		}
+
+		// Milestones follow game_ended; read until the lobby goes quiet
+		var milestones []map[string]interface{}
+		ended := false
+		for {
+			wait := 2 * time.Second
+			if ended {
+				wait = 100 * time.Millisecond
+			}
+			select {
+			case payload := <-watcher.Send:
+				var event struct {
+					Type string                 `json:"type"`
+					Data map[string]interface{} `json:"data"`
+				}
+				json.Unmarshal(payload, &event)
+				switch event.Type {
+				case "game_ended":
+					ended = true
+				case "account_streak_milestone":
+					if !ended {
+						t.Fatal("account_streak_milestone was sent before game_ended")
+					}
+					milestones = append(milestones, event.Data)
+				}
+			case <-time.After(wait):
+				if !ended {
+					t.Fatal("game_ended never arrived")
+				}
+				return milestones
+			}
+		}
+	}
+
+	// Today's first game makes it three days for alice: the daily bonus
+	// times three, and the day milestone. It's bob's first day
+	milestones := play(alice, bob)
+	streak, err := gs.AccountStreak(alice.ID)
+	if err != nil {
+		t.Fatalf("AccountStreak: %v", err)
+	}
+	if streak.DayStreak != 3 || streak.BestDayStreak != 3 || streak.WinStreak != 1 || streak.XP != 30 || streak.Coins != 15 {
+		t.Fatalf("Expected alice on a three day streak with 30 XP, got %+v", streak)
+	}
+	if len(milestones) != 1 || milestones[0]["user_id"] != alice.ID || milestones[0]["kind"] != "days" || milestones[0]["streak"] != 3.0 || milestones[0]["xp"] != 30.0 {
+		t.Fatalf("Expected alice's three day milestone, got %v", milestones)
+	}
+	if streak, _ := gs.AccountStreak(bob.ID); streak.DayStreak != 1 || streak.WinStreak != 0 || streak.XP != 10 || streak.Coins != 5 {
+		t.Fatalf("Expected bob's first day, got %+v", streak)
+	}
+
+	// Later games today earn no daily bonus, only the win bonus for each
+	// win after the first in a row, capped at three
+	play(alice, bob)
+	milestones = play(alice, bob)
+	if len(milestones) != 1 || milestones[0]["kind"] != "wins" || milestones[0]["streak"] != 3.0 || milestones[0]["username"] != "alice" {
+		t.Fatalf("Expected alice's three win milestone, got %v", milestones)
+	}
+	play(alice, bob)
+	play(alice, bob)
+	streak, _ = gs.AccountStreak(alice.ID)
+	if streak.DayStreak != 3 || streak.WinStreak != 5 || streak.BestWinStreak != 5 || streak.XP != 30+4+8+12+12 || streak.Coins != 15+1+2+3+3 {
+		t.Fatalf("Expected five wins with capped bonuses, got %+v", streak)
+	}
+
+	// A loss ends the win streak but keeps the best one
+	play(bob, alice)
+	streak, _ = gs.AccountStreak(alice.ID)
+	if streak.WinStreak != 0 || streak.BestWinStreak != 5 {
+		t.Fatalf("Expected alice's win streak ended, got %+v", streak)
+	}
+
+	// A day missed breaks the day streak
+	repo.SaveAccountStreak(&models.AccountStreak{UserID: bob.ID, DayStreak: 4, BestDayStreak: 4,
+		LastPlayedOn: models.Now().AddDate(0, 0, -2).UTC().Format(time.DateOnly)})
+	if streak, _ := gs.AccountStreak(bob.ID); streak.DayStreak != 0 || streak.BestDayStreak != 4 {
+		t.Fatalf("Expected bob's day streak broken, got %+v", streak)
+	}
+	play(bob, alice)
+	if streak, _ := gs.AccountStreak(bob.ID); streak.DayStreak != 1 || streak.BestDayStreak != 4 {
+		t.Fatalf("Expected bob's day streak started over, got %+v", streak)
+	}
+
+	if _, err := gs.AccountStreak("no-such-user"); !errors.Is(err, services.ErrUserNotFound) {
+		t.Fatalf("Expected ErrUserNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"errors"
+	"log"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/repository"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Passwords are hashed with bcrypt, which ignores anything past 72 bytes.
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 72
+)
+
+// dummyPasswordHash is compared against when logging in to an unknown
+// username, so the response takes as long as for a wrong password.
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("not a real password"), bcrypt.DefaultCost)
+
+// Register creates an account under the normalized form of username.
+func (gs *GameService) Register(username, password string) (*models.User, error) {
+	username, err := models.NormalizeUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if len(password) < MinPasswordLength || len(password) > MaxPasswordLength {
+		return nil, ErrInvalidPassword
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	user := models.NewUser(username, string(hash))
+	if err := gs.repo.CreateUser(user); err != nil {
+		if errors.Is(err, repository.ErrUsernameTaken) {
+			return nil, ErrUsernameTaken
+		}
+		return nil, err
+	}
+	log.Printf("Registered account %s (%s)", user.Username, user.ID)
+	return user, nil
+}
+
+// Login returns the account for a username and password, or
+// ErrInvalidCredentials without saying which one was wrong.
+func (gs *GameService) Login(username, password string) (*models.User, error) {
+	username, err := models.NormalizeUsername(username)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	user, err := gs.repo.GetUserByUsername(username)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// User returns an account by ID.
+func (gs *GameService) User(userID string) (*models.User, error) {
+	user, err := gs.repo.GetUser(userID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+// checkGuestUsername refuses guests a username registered to an account, so
+// nobody can play as someone else without logging in.
+func (gs *GameService) checkGuestUsername(username string) error {
+	_, err := gs.repo.GetUserByUsername(username)
+	switch {
+	case err == nil:
+		return ErrUsernameRegistered
+	case errors.Is(err, repository.ErrUserNotFound):
+		return nil
+	}
+	return err
+}
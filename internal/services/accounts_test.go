@@ -0,0 +1,110 @@
+package services_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/auth"
+	"buildprize-game/internal/services"
+)
+
+// Accounts register under unique usernames, log in with their password and
+// join lobbies as themselves; guests can't take a registered name.
+func TestAccounts(t *testing.T) {
+	gs, _, _ := newService(t)
+	user, err := gs.Register("Alice", "correct horse")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if _, err := gs.Register("alice", "another password"); !errors.Is(err, services.ErrUsernameTaken) {
+		t.Fatalf("Expected ErrUsernameTaken, got %v", err)
+	}
+	if _, err := gs.Register("bob", "short"); !errors.Is(err, services.ErrInvalidPassword) {
+		t.Fatalf("Expected ErrInvalidPassword, got %v", err)
+	}
+	if _, err := gs.Login("Alice", "wrong horse"); !errors.Is(err, services.ErrInvalidCredentials) {
+		t.Fatalf("Expected ErrInvalidCredentials for a wrong password, got %v", err)
+	}
+	if _, err := gs.Login("nobody", "correct horse"); !errors.Is(err, services.ErrInvalidCredentials) {
+		t.Fatalf("Expected ErrInvalidCredentials for an unknown user, got %v", err)
+	}
+	if loggedIn, err := gs.Login("Alice", "correct horse"); err != nil || loggedIn.ID != user.ID {
+		t.Fatalf("Expected to log in as %s, got %+v (%v)", user.ID, loggedIn, err)
+	}
+
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Accounts", MaxRounds: 3, MaxPlayers: 4})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	if _, _, err := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "ALICE"}); !errors.Is(err, services.ErrUsernameRegistered) {
+		t.Fatalf("Expected a guest refused a registered name, got %v", err)
+	}
+	_, player, err := gs.JoinLobby(lobby.ID, services.JoinOptions{User: user})
+	if err != nil {
+		t.Fatalf("JoinLobby: %v", err)
+	}
+	if player.UserID != user.ID || player.Username != "Alice" {
+		t.Fatalf("Expected the player tied to the account, got %+v", player)
+	}
+	if _, again, err := gs.JoinLobby(lobby.ID, services.JoinOptions{User: user}); err != nil || again.ID != player.ID {
+		t.Fatalf("Expected the account's player back on rejoining, got %+v (%v)", again, err)
+	}
+}
+
+// Login tokens carry the account and are refused once tampered with, signed
+// with another secret or expired.
+func TestLoginTokens(t *testing.T) {
+	issuer := auth.NewIssuer([]byte("secret"), time.Hour)
+	now := time.Now()
+	token, expiresAt, err := issuer.Issue("user-1", "alice", now)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if !expiresAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("Expected expiry an hour out, got %s", expiresAt)
+	}
+	claims, err := issuer.Verify(token, now)
+	if err != nil || claims.Subject != "user-1" || claims.Username != "alice" {
+		t.Fatalf("Expected claims for user-1, got %+v (%v)", claims, err)
+	}
+
+	parts := strings.Split(token, ".")
+	tampered := parts[0] + "." + parts[1] + "x." + parts[2]
+	for name, check := range map[string]func() error{
+		"tampered":     func() error { _, err := issuer.Verify(tampered, now); return err },
+		"other secret": func() error { _, err := auth.NewIssuer([]byte("other"), time.Hour).Verify(token, now); return err },
+		"expired":      func() error { _, err := issuer.Verify(token, now.Add(2*time.Hour)); return err },
+		"not a token":  func() error { _, err := issuer.Verify("abc", now); return err },
+		"alg none":     func() error { _, err := issuer.Verify("eyJhbGciOiJub25lIn0."+parts[1]+".", now); return err },
+	} {
+		if err := check(); !errors.Is(err, auth.ErrInvalidToken) {
+			t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+		}
+	}
+}
+
+// Session tokens hold one player's seat in one lobby: they can't be moved to
+// another player or lobby, and login tokens don't pass for them.
+func TestSessionTokens(t *testing.T) {
+	sessions := auth.NewSessions([]byte("secret"))
+	token := sessions.Issue("lobby-1", "player-1")
+	if playerID, err := sessions.Verify(token, "lobby-1"); err != nil || playerID != "player-1" {
+		t.Fatalf("Expected the session of player-1, got %q (%v)", playerID, err)
+	}
+
+	_, signature, _ := strings.Cut(token, ".")
+	login, _, _ := auth.NewIssuer([]byte("secret"), time.Hour).Issue("player-1", "alice", time.Now())
+	for name, check := range map[string]func() error{
+		"other lobby":  func() error { _, err := sessions.Verify(token, "lobby-2"); return err },
+		"other player": func() error { _, err := sessions.Verify("player-2."+signature, "lobby-1"); return err },
+		"other secret": func() error { _, err := auth.NewSessions([]byte("other")).Verify(token, "lobby-1"); return err },
+		"login token":  func() error { _, err := sessions.Verify(login, "lobby-1"); return err },
+		"empty":        func() error { _, err := sessions.Verify("", "lobby-1"); return err },
+	} {
+		if err := check(); !errors.Is(err, auth.ErrInvalidSession) {
+			t.Errorf("%s: expected ErrInvalidSession, got %v", name, err)
+		}
+	}
+}
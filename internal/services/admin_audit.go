@@ -0,0 +1,30 @@
+package services
+
+import (
+	"log"
+
+	"buildprize-game/internal/models"
+)
+
+const maxAdminActions = 500
+
+// RecordAdminAction adds a change made through the admin API to the audit
+// log. The change has already been made, so failing to store the entry is
+// logged rather than returned.
+func (gs *GameService) RecordAdminAction(action *models.AdminAction) {
+	action.CreatedAt = models.Now()
+	if err := gs.repo.SaveAdminAction(action); err != nil {
+		log.Printf("ERROR: Failed to record admin action %s on %q by %s: %v", action.Action, action.Target, action.Actor, err)
+		return
+	}
+	log.Printf("Admin action %s on %q by %s", action.Action, action.Target, action.Actor)
+}
+
+// AdminActions returns the most recent audit log entries the filter
+// selects, newest first, up to limit (at most 500).
+func (gs *GameService) AdminActions(filter models.AdminActionFilter, limit int) ([]*models.AdminAction, error) {
+	if limit < 1 || limit > maxAdminActions {
+		limit = maxAdminActions
+	}
+	return gs.repo.ListAdminActions(filter, limit)
+}
@@ -0,0 +1,45 @@
+package services_test
+
+import (
+	"testing"
+
+	"buildprize-game/internal/models"
+)
+
+// Admin actions are listed newest first, narrowed by action, actor and
+// target.
+func TestAdminAuditLog(t *testing.T) {
+	gs, _, _ := newService(t)
+	gs.RecordAdminAction(&models.AdminAction{Action: models.AdminForceEnd, Actor: "alice", Target: "lobby-1"})
+	gs.RecordAdminAction(&models.AdminAction{Action: models.AdminInjectAnswer, Actor: "bob", Target: "lobby-1", Details: map[string]interface{}{"player_id": "p1"}})
+	gs.RecordAdminAction(&models.AdminAction{Action: models.AdminForceEnd, Actor: "bob", Target: "lobby-2"})
+
+	all, err := gs.AdminActions(models.AdminActionFilter{}, 50)
+	if err != nil {
+		t.Fatalf("AdminActions: %v", err)
+	}
+	if len(all) != 3 || all[0].Target != "lobby-2" || all[2].Actor != "alice" {
+		t.Fatalf("Expected the three actions newest first, got %+v", all)
+	}
+	if all[0].ID == 0 || all[0].CreatedAt.IsZero() {
+		t.Fatalf("Expected stored actions with IDs and times, got %+v", all[0])
+	}
+
+	for _, c := range []struct {
+		filter models.AdminActionFilter
+		want   int
+	}{
+		{models.AdminActionFilter{Action: models.AdminForceEnd}, 2},
+		{models.AdminActionFilter{Actor: "bob"}, 2},
+		{models.AdminActionFilter{Target: "lobby-1"}, 2},
+		{models.AdminActionFilter{Action: models.AdminForceEnd, Actor: "bob"}, 1},
+		{models.AdminActionFilter{Actor: "carol"}, 0},
+	} {
+		if got, _ := gs.AdminActions(c.filter, 50); len(got) != c.want {
+			t.Errorf("%+v: expected %d actions, got %d", c.filter, c.want, len(got))
+		}
+	}
+	if limited, _ := gs.AdminActions(models.AdminActionFilter{}, 1); len(limited) != 1 || limited[0].Target != "lobby-2" {
+		t.Fatalf("Expected only the newest action, got %+v", limited)
+	}
+}
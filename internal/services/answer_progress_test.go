@@ -0,0 +1,61 @@
+package services_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/services"
+)
+
+// Every answer is followed by an answer_progress count that doesn't say
+// what was answered.
+func TestAnswerProgress(t *testing.T) {
+	gs, gameHub, _ := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Progress", MaxRounds: 3, MaxPlayers: 4})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	_, alice, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"})
+	gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "bob"})
+	gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "carol"})
+
+	client := &hub.Client{ID: "watcher", LobbyID: lobby.ID, Send: make(chan []byte, 64)}
+	gameHub.GetLobbyHub(lobby.ID).Register(client)
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	defer gs.ForceEndGame(lobby.ID)
+
+	lobby.Lock()
+	question := lobby.CurrentQ
+	lobby.Unlock()
+	if err := gs.SubmitAnswer(lobby.ID, alice.ID, correctAnswer(question)); err != nil {
+		t.Fatalf("SubmitAnswer: %v", err)
+	}
+
+	deadline := time.After(2 * time.Second)
+	for {
+		select {
+		case payload := <-client.Send:
+			var event struct {
+				Type string                 `json:"type"`
+				Data map[string]interface{} `json:"data"`
+			}
+			json.Unmarshal(payload, &event)
+			if event.Type != "answer_progress" {
+				continue
+			}
+			if event.Data["answered"] != 1.0 || event.Data["total"] != 3.0 || event.Data["round"] != 1.0 {
+				t.Fatalf("Expected 1 of 3 answered in round 1, got %v", event.Data)
+			}
+			if len(event.Data) != 3 {
+				t.Fatalf("Expected only counts in answer_progress, got %s", payload)
+			}
+			return
+		case <-deadline:
+			t.Fatal("answer_progress never arrived")
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"buildprize-game/internal/game"
+	"buildprize-game/internal/models"
+)
+
+// defaultAnswerGrace is how long after a question's end time answers are
+// still accepted, covering the network and a timer that fires a little late.
+const defaultAnswerGrace = 500 * time.Millisecond
+
+// answerArrival is how an accepted answer was timed under the acceptance
+// policy. The server clock decides: an answer is accepted
+// if it arrives by the question's end time plus the grace window, and the
+// round timer fires only once the grace window has passed, so which answers
+// count never depends on what runs first. A client may report when it sent
+// the answer, as server time by the offset it measured from server_time;
+// that time is trusted only between the grace window before arrival and the
+// arrival itself, so a skewed or dishonest clock gains at most the grace
+// window and can't turn a late arrival into an accepted one.
+type answerArrival struct {
+	receivedAt time.Time
+	effective  time.Time // what the response time is measured at
+	inGrace    bool      // received after the end time, within the grace window
+}
+
+// SetAnswerGrace sets how long past a question's end time answers are
+// accepted. Zero disables the grace window.
+func (gs *GameService) SetAnswerGrace(grace time.Duration) {
+	if grace >= 0 {
+		gs.answerGrace = grace
+	}
+}
+
+// judgeArrival times an accepted answer received at receivedAt and logs it
+// if it came in the grace window or its reported send time was used. The
+// caller holds the lobby lock.
+func (gs *GameService) judgeArrival(lobby *models.Lobby, playerID string, receivedAt, sentAt time.Time) answerArrival {
+	end := *lobby.QuestionEnd
+	arrival := answerArrival{receivedAt: receivedAt, effective: receivedAt, inGrace: receivedAt.After(end)}
+	if !sentAt.IsZero() {
+		earliest := receivedAt.Add(-gs.answerGrace)
+		switch {
+		case sentAt.Before(earliest):
+			arrival.effective = earliest
+		case sentAt.Before(receivedAt):
+			arrival.effective = sentAt
+		}
+	}
+
+	if arrival.inGrace || !arrival.effective.Equal(receivedAt) {
+		decision := "on time"
+		if arrival.inGrace {
+			decision = "accepted in grace window"
+		}
+		log.Printf("Answer from %s in lobby %s round %d %s: received %+dms from the end time (grace %s), client skew %s, timed at %s",
+			playerID, lobby.ID, lobby.Round, decision, receivedAt.Sub(end).Milliseconds(), gs.answerGrace,
+			reportedSkew(receivedAt, sentAt), models.FormatTimestamp(arrival.effective))
+	}
+	return arrival
+}
+
+// logRejectedAnswer records, for audits, an answer turned away because it
+// came after the grace window or after its round had closed. The caller
+// holds the lobby lock.
+func (gs *GameService) logRejectedAnswer(lobby *models.Lobby, playerID string, receivedAt, sentAt time.Time) {
+	switch {
+	case lobby.Phase == game.Question && lobby.QuestionEnd != nil && !lobby.Paused:
+		log.Printf("Answer from %s in lobby %s round %d rejected as late: received %+dms from the end time (grace %s), client skew %s",
+			playerID, lobby.ID, lobby.Round, receivedAt.Sub(*lobby.QuestionEnd).Milliseconds(), gs.answerGrace,
+			reportedSkew(receivedAt, sentAt))
+	case lobby.Phase == game.Results && lobby.GetPlayer(playerID) != nil:
+		log.Printf("Answer from %s in lobby %s rejected as late: round %d had already closed", playerID, lobby.ID, lobby.Round-1)
+	}
+}
+
+func reportedSkew(receivedAt, sentAt time.Time) string {
+	if sentAt.IsZero() {
+		return "not reported"
+	}
+	return receivedAt.Sub(sentAt).String()
+}
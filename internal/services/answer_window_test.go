@@ -0,0 +1,71 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// Answers arriving within the grace window after the question's end time are
+// accepted with the response time capped at the window; later ones aren't,
+// and a reported send time is trusted no further than the grace window.
+func TestAnswerGraceWindow(t *testing.T) {
+	gs, gameHub, repo := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Grace", MaxRounds: 3, MaxPlayers: 4})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	lobbyHub := gameHub.GetLobbyHub(lobby.ID)
+
+	_, onTime, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "ontime"})
+	_, skewed, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "skewed"})
+	_, late, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "late"})
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	defer gs.ForceEndGame(lobby.ID)
+
+	current := lobbyHub.GetLobby()
+	endAt := func(end time.Time) models.SubmittedAnswer {
+		current.Lock()
+		defer current.Unlock()
+		start := end.Add(-15 * time.Second)
+		current.QuestionStart, current.QuestionEnd = &start, &end
+		return correctAnswer(current.CurrentQ)
+	}
+
+	answer := endAt(models.Now().Add(-200 * time.Millisecond))
+	if err := gs.SubmitAnswer(lobby.ID, onTime.ID, answer); err != nil {
+		t.Fatalf("Answer within the grace window was rejected: %v", err)
+	}
+	// Claims to have been sent long before it arrived
+	if err := gs.SubmitAnswerSentAt(lobby.ID, skewed.ID, answer, models.Now().Add(-10*time.Second)); err != nil {
+		t.Fatalf("Answer with a reported send time was rejected: %v", err)
+	}
+
+	answers, _ := repo.GetLobbyAnswers(lobby.ID)
+	if len(answers) != 2 {
+		t.Fatalf("Expected 2 recorded answers, got %d", len(answers))
+	}
+	for _, record := range answers {
+		switch record.PlayerID {
+		case onTime.ID:
+			if record.ResponseTime != 15000 {
+				t.Fatalf("Expected a grace answer timed at the window's end, got %dms", record.ResponseTime)
+			}
+		case skewed.ID:
+			// Timed at most the grace window before arrival, about 14700ms
+			if record.ResponseTime < 14500 || record.ResponseTime >= 15000 {
+				t.Fatalf("Expected the reported send time capped at the grace window, got %dms", record.ResponseTime)
+			}
+		}
+	}
+
+	endAt(models.Now().Add(-time.Second))
+	if err := gs.SubmitAnswer(lobby.ID, late.ID, answer); !errors.Is(err, services.ErrQuestionNotActive) {
+		t.Fatalf("Expected ErrQuestionNotActive past the grace window, got %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/repository"
+)
+
+const (
+	// apiKeyPrefix starts every key, followed by its ID and secret, e.g.
+	// "bpk_<id>_<secret>", so leaked keys are easy to spot and scan for.
+	apiKeyPrefix  = "bpk_"
+	maxAPIKeyName = 100
+
+	// maxRotationGrace caps how long a rotated key's old secret keeps working.
+	maxRotationGrace = 7 * 24 * time.Hour
+)
+
+// CreateAPIKey issues a key with the given scopes. It's returned with the
+// key itself, which isn't stored and can't be shown again.
+func (gs *GameService) CreateAPIKey(name string, scopes []string, createdBy string) (*models.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxAPIKeyName {
+		return nil, "", fmt.Errorf("%w: the name must be 1 to %d characters", ErrInvalidAPIKeyRequest, maxAPIKeyName)
+	}
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := models.NewAPIKey(name, scopes, createdBy)
+	token, hash, err := newAPIKeySecret(key.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	key.Hash = hash
+	if err := gs.repo.SaveAPIKey(key); err != nil {
+		return nil, "", err
+	}
+	log.Printf("Issued API key %s (%s) scoped %s", key.ID, key.Name, strings.Join(key.Scopes, ","))
+	return key, token, nil
+}
+
+// RotateAPIKey gives a key a new secret, returned as with CreateAPIKey. The
+// old one keeps working for grace, up to a week, or stops at once for none.
+func (gs *GameService) RotateAPIKey(keyID string, grace time.Duration) (*models.APIKey, string, error) {
+	if grace < 0 || grace > maxRotationGrace {
+		return nil, "", fmt.Errorf("%w: the grace period must be at most a week", ErrInvalidAPIKeyRequest)
+	}
+	key, err := gs.getAPIKey(keyID)
+	if err != nil {
+		return nil, "", err
+	}
+	if key.RevokedAt != nil {
+		return nil, "", ErrAPIKeyRevoked
+	}
+
+	token, hash, err := newAPIKeySecret(key.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	now := models.Now()
+	key.PreviousHash, key.PreviousExpiresAt = "", nil
+	if grace > 0 {
+		expires := now.Add(grace)
+		key.PreviousHash, key.PreviousExpiresAt = key.Hash, &expires
+	}
+	key.Hash, key.RotatedAt = hash, &now
+	if err := gs.repo.SaveAPIKey(key); err != nil {
+		return nil, "", err
+	}
+	log.Printf("Rotated API key %s (%s), old secret valid for %s", key.ID, key.Name, grace)
+	return key, token, nil
+}
+
+// RevokeAPIKey stops a key working for good. It stays listed, revoked.
+func (gs *GameService) RevokeAPIKey(keyID string) (*models.APIKey, error) {
+	key, err := gs.getAPIKey(keyID)
+	if err != nil {
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		return nil, ErrAPIKeyRevoked
+	}
+
+	now := models.Now()
+	key.RevokedAt = &now
+	key.PreviousHash, key.PreviousExpiresAt = "", nil
+	if err := gs.repo.SaveAPIKey(key); err != nil {
+		return nil, err
+	}
+	log.Printf("Revoked API key %s (%s)", key.ID, key.Name)
+	return key, nil
+}
+
+// APIKeys lists every key issued, revoked ones included, oldest first.
+func (gs *GameService) APIKeys() ([]*models.APIKey, error) {
+	return gs.repo.ListAPIKeys()
+}
+
+// VerifyAPIKey returns the key token belongs to, or ErrInvalidAPIKey for one
+// that's malformed, unknown, revoked or rotated out.
+func (gs *GameService) VerifyAPIKey(token string) (*models.APIKey, error) {
+	rest, ok := strings.CutPrefix(token, apiKeyPrefix)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+	keyID, secret, ok := strings.Cut(rest, "_")
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+	key, err := gs.repo.GetAPIKey(keyID)
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	hash := hashAPIKeySecret(secret)
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(key.Hash)) == 1 {
+		return key, nil
+	}
+	if key.PreviousExpiresAt != nil && models.Now().Before(*key.PreviousExpiresAt) &&
+		subtle.ConstantTimeCompare([]byte(hash), []byte(key.PreviousHash)) == 1 {
+		return key, nil
+	}
+	return nil, ErrInvalidAPIKey
+}
+
+func (gs *GameService) getAPIKey(keyID string) (*models.APIKey, error) {
+	key, err := gs.repo.GetAPIKey(keyID)
+	if errors.Is(err, repository.ErrAPIKeyNotFound) {
+		return nil, ErrAPIKeyNotFound
+	}
+	return key, err
+}
+
+// normalizeScopes checks scopes are all known and returns them without
+// repeats, in APIKeyScopes order.
+func normalizeScopes(scopes []string) ([]string, error) {
+	requested := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		if !models.ValidScope(scope) {
+			return nil, fmt.Errorf("%w: unknown scope %q (use %s)", ErrInvalidAPIKeyRequest, scope, strings.Join(models.APIKeyScopes, ", "))
+		}
+		requested[scope] = true
+	}
+	normalized := make([]string, 0, len(requested))
+	for _, scope := range models.APIKeyScopes {
+		if requested[scope] {
+			normalized = append(normalized, scope)
+		}
+	}
+	if len(normalized) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is needed", ErrInvalidAPIKeyRequest)
+	}
+	return normalized, nil
+}
+
+// newAPIKeySecret returns a new key for keyID and the hash stored for it.
+func newAPIKeySecret(keyID string) (token, hash string, err error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	encoded := hex.EncodeToString(secret)
+	return apiKeyPrefix + keyID + "_" + encoded, hashAPIKeySecret(encoded), nil
+}
+
+func hashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
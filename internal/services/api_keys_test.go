@@ -0,0 +1,78 @@
+package services_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// API keys are issued with known scopes only, verify by their secret, keep
+// their old secret through a rotation's grace period only, and stop working
+// once revoked.
+func TestAPIKeyLifecycle(t *testing.T) {
+	gs, _, repo := newService(t)
+	if _, _, err := gs.CreateAPIKey("ci", []string{"root"}, "ops"); !errors.Is(err, services.ErrInvalidAPIKeyRequest) {
+		t.Fatalf("Expected an unknown scope refused, got %v", err)
+	}
+	if _, _, err := gs.CreateAPIKey("ci", nil, "ops"); !errors.Is(err, services.ErrInvalidAPIKeyRequest) {
+		t.Fatalf("Expected a key without scopes refused, got %v", err)
+	}
+
+	key, token, err := gs.CreateAPIKey(" ci ", []string{models.ScopeWebhooks, models.ScopeAdminWrite, models.ScopeWebhooks}, "ops")
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	if key.Name != "ci" || len(key.Scopes) != 2 || !key.HasScope(models.ScopeAdminRead) || key.HasScope(models.ScopeAPIKeys) {
+		t.Fatalf("Expected admin:write and webhooks, including admin:read, got %+v", key)
+	}
+	stored, _ := repo.GetAPIKey(key.ID)
+	if stored.Hash == "" || strings.Contains(token, stored.Hash) {
+		t.Fatal("Expected only a hash of the secret stored")
+	}
+	if verified, err := gs.VerifyAPIKey(token); err != nil || verified.ID != key.ID {
+		t.Fatalf("Expected the issued key to verify, got %v", err)
+	}
+	wrong := token[:len(token)-1] + "0"
+	if wrong == token {
+		wrong = token[:len(token)-1] + "1"
+	}
+	if _, err := gs.VerifyAPIKey(wrong); !errors.Is(err, services.ErrInvalidAPIKey) {
+		t.Fatalf("Expected a wrong secret refused, got %v", err)
+	}
+
+	_, rotated, err := gs.RotateAPIKey(key.ID, time.Hour)
+	if err != nil {
+		t.Fatalf("RotateAPIKey: %v", err)
+	}
+	for _, candidate := range []string{token, rotated} {
+		if _, err := gs.VerifyAPIKey(candidate); err != nil {
+			t.Fatalf("Expected both secrets accepted during the grace period, got %v", err)
+		}
+	}
+	_, latest, err := gs.RotateAPIKey(key.ID, 0)
+	if err != nil {
+		t.Fatalf("RotateAPIKey: %v", err)
+	}
+	for _, old := range []string{token, rotated} {
+		if _, err := gs.VerifyAPIKey(old); !errors.Is(err, services.ErrInvalidAPIKey) {
+			t.Fatalf("Expected old secrets refused after a rotation without grace, got %v", err)
+		}
+	}
+
+	if _, err := gs.RevokeAPIKey(key.ID); err != nil {
+		t.Fatalf("RevokeAPIKey: %v", err)
+	}
+	if _, err := gs.VerifyAPIKey(latest); !errors.Is(err, services.ErrInvalidAPIKey) {
+		t.Fatalf("Expected a revoked key refused, got %v", err)
+	}
+	if _, _, err := gs.RotateAPIKey(key.ID, 0); !errors.Is(err, services.ErrAPIKeyRevoked) {
+		t.Fatalf("Expected a revoked key kept revoked, got %v", err)
+	}
+	if keys, _ := gs.APIKeys(); len(keys) != 1 || keys[0].RevokedAt == nil {
+		t.Fatalf("Expected the revoked key still listed, got %+v", keys)
+	}
+}
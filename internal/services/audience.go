@@ -0,0 +1,292 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"buildprize-game/internal/game"
+	"buildprize-game/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const defaultMaxAudienceSize = 1000
+
+// audience holds an audience lobby's members and the current round's
+// aggregate. It has its own lock so hundreds of answers don't each hold the
+// lobby lock for longer than a phase check; lock order is lobby, then
+// audience.
+type audience struct {
+	mu        sync.Mutex
+	members   map[string]*models.AudienceMember
+	byName    map[string]string // lowercased username -> member ID
+	answered  map[string]int    // member ID -> last round answered
+	shoutOuts int
+
+	round   int
+	options int
+	answers int
+	correct int
+	choices []int // answers selecting each option
+}
+
+// AudienceAnswerResult is returned to the member who answered; nothing is
+// broadcast per audience answer.
+type AudienceAnswerResult struct {
+	Correct bool `json:"correct"`
+	Score   int  `json:"score"`
+	Total   int  `json:"total"`
+}
+
+// SetMaxAudienceSize caps how many audience members one lobby accepts.
+func (gs *GameService) SetMaxAudienceSize(size int) {
+	if size > 0 {
+		gs.maxAudience = size
+	}
+}
+
+func (gs *GameService) startAudience(lobbyID string, shoutOuts int) {
+	gs.mu.Lock()
+	gs.audiences[lobbyID] = &audience{
+		members:   make(map[string]*models.AudienceMember),
+		byName:    make(map[string]string),
+		answered:  make(map[string]int),
+		shoutOuts: shoutOuts,
+	}
+	gs.mu.Unlock()
+}
+
+func (gs *GameService) lobbyAudience(lobbyID string) *audience {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	return gs.audiences[lobbyID]
+}
+
+func (gs *GameService) dropAudience(lobbyID string) {
+	gs.mu.Lock()
+	delete(gs.audiences, lobbyID)
+	gs.mu.Unlock()
+}
+
+// JoinAudience adds a member to an audience lobby, at any point before the
+// game ends. A username already in the audience is refused; its member
+// comes back with RejoinAudience.
+func (gs *GameService) JoinAudience(lobbyID, username string) (*models.AudienceMember, error) {
+	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
+	if lobbyHub == nil {
+		return nil, ErrLobbyNotFound
+	}
+	username, err := models.NormalizeUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	finished := lobby.Phase == game.Finished
+	impersonates := lobby.FindConfusablePlayer(username) != nil
+	lobby.Unlock()
+	a := gs.lobbyAudience(lobbyID)
+	if a == nil {
+		return nil, ErrNotAudienceLobby
+	}
+	if finished {
+		return nil, ErrGameNotRunning
+	}
+	// Top scorers are named in results, so members can't pass as a player
+	if impersonates {
+		return nil, ErrUsernameConfusable
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	key := strings.ToLower(username)
+	if _, ok := a.byName[key]; ok {
+		return nil, ErrAudienceNameTaken
+	}
+	if len(a.members) >= gs.maxAudience {
+		return nil, ErrLobbyFull
+	}
+	member := &models.AudienceMember{ID: "aud-" + uuid.New().String(), Username: username}
+	a.members[member.ID] = member
+	a.byName[key] = member.ID
+	return member, nil
+}
+
+// RejoinAudience returns an audience member of the lobby by ID, for a
+// member coming back before the game ends. Callers check the member's
+// session first: the ID is all SubmitAudienceAnswer asks for.
+func (gs *GameService) RejoinAudience(lobbyID, memberID string) (*models.AudienceMember, error) {
+	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
+	if lobbyHub == nil {
+		return nil, ErrLobbyNotFound
+	}
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	finished := lobby.Phase == game.Finished
+	lobby.Unlock()
+	a := gs.lobbyAudience(lobbyID)
+	if a == nil {
+		return nil, ErrNotAudienceLobby
+	}
+	if finished {
+		return nil, ErrGameNotRunning
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	member := a.members[memberID]
+	if member == nil {
+		return nil, ErrPlayerNotFound
+	}
+	return member, nil
+}
+
+// SubmitAudienceAnswer scores an audience answer against the open question.
+// The lobby lock is held only to check the question; the answer is folded
+// into the round's aggregate without saving the lobby, broadcasting or
+// recording answer history.
+func (gs *GameService) SubmitAudienceAnswer(lobbyID, memberID string, answer models.SubmittedAnswer) (*AudienceAnswerResult, error) {
+	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
+	if lobbyHub == nil {
+		return nil, ErrLobbyNotFound
+	}
+	a := gs.lobbyAudience(lobbyID)
+	if a == nil {
+		return nil, ErrNotAudienceLobby
+	}
+
+	lobby := lobbyHub.GetLobby()
+	receivedAt := models.Now()
+	lobby.Lock()
+	if !lobby.AcceptsAnswersAt(receivedAt, gs.answerGrace) {
+		lobby.Unlock()
+		return nil, ErrQuestionNotActive
+	}
+	question := lobby.CurrentQ
+	round := lobby.Round
+	responseTime := lobby.ResponseTimeAt(receivedAt)
+	scoring := gs.lobbyScoring(lobby)
+	lobby.Unlock()
+
+	if err := question.CheckAnswer(answer); err != nil {
+		return nil, ErrInvalidAnswer
+	}
+	score := scoring.Score(question, answer, responseTime, 0)
+	correct := question.IsCorrect(answer)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	member := a.members[memberID]
+	if member == nil {
+		return nil, ErrPlayerNotFound
+	}
+	if a.answered[memberID] == round {
+		return nil, ErrAlreadyAnswered
+	}
+	a.answered[memberID] = round
+	member.Score = models.AddScore(member.Score, score)
+	member.Answered++
+
+	// The round may have closed since the check; its summary is already out
+	if a.round == round {
+		a.answers++
+		if correct {
+			a.correct++
+		}
+		switch question.QuestionType() {
+		case models.MultiSelect:
+			for _, idx := range answer.Choices {
+				a.choices[idx]++
+			}
+		case models.FreeText, models.Numeric:
+		default:
+			a.choices[answer.Choice]++
+		}
+	}
+	return &AudienceAnswerResult{Correct: correct, Score: score, Total: member.Score}, nil
+}
+
+// openAudienceRound resets the aggregate for a new question. The caller holds
+// the lobby lock.
+func (gs *GameService) openAudienceRound(lobby *models.Lobby) {
+	a := gs.lobbyAudience(lobby.ID)
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	a.round = lobby.Round
+	a.options = len(lobby.CurrentQ.Options)
+	a.answers = 0
+	a.correct = 0
+	a.choices = make([]int, a.options)
+	a.mu.Unlock()
+}
+
+// closeAudienceRound summarises the round's audience answers and stops
+// counting new ones. It returns nil for lobbies without an audience. The
+// caller holds the lobby lock.
+func (gs *GameService) closeAudienceRound(lobby *models.Lobby) *models.AudienceSummary {
+	a := gs.lobbyAudience(lobby.ID)
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	summary := &models.AudienceSummary{Round: a.round, Members: len(a.members), Answered: a.answers}
+	if a.answers > 0 {
+		summary.CorrectPercent = percent(a.correct, a.answers)
+		if a.options > 0 {
+			summary.OptionPercents = make([]float64, a.options)
+			for i, count := range a.choices {
+				summary.OptionPercents[i] = percent(count, a.answers)
+			}
+		}
+	}
+	summary.TopScorers = a.topScorers()
+	a.round = 0
+	return summary
+}
+
+// audienceLeaderboard returns the top audience scorers when shout-outs are
+// enabled.
+func (gs *GameService) audienceLeaderboard(lobbyID string) []models.AudienceScore {
+	a := gs.lobbyAudience(lobbyID)
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.topScorers()
+}
+
+// topScorers lists the best shoutOuts members who scored at all. The caller
+// holds a.mu.
+func (a *audience) topScorers() []models.AudienceScore {
+	if a.shoutOuts <= 0 {
+		return nil
+	}
+	var scores []models.AudienceScore
+	for _, member := range a.members {
+		if member.Score > 0 {
+			scores = append(scores, models.AudienceScore{Username: member.Username, Score: member.Score})
+		}
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].Username < scores[j].Username
+	})
+	if len(scores) > a.shoutOuts {
+		scores = scores[:a.shoutOuts]
+	}
+	return scores
+}
+
+// percent returns part/total as a percentage rounded to one decimal.
+func percent(part, total int) float64 {
+	return math.Round(float64(part)*1000/float64(total)) / 10
+}
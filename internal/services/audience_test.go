@@ -0,0 +1,125 @@
+package services_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// Audience answers are aggregated into the round results without seating the
+// members or recording their answers.
+func TestAudienceAnswersAggregated(t *testing.T) {
+	gs, gameHub, repo := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{
+		Name: "Finals", MaxRounds: 1, MaxPlayers: 2, Audience: true, AudienceShoutOuts: 3,
+	})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	lobbyHub := gameHub.GetLobbyHub(lobby.ID)
+	var featured []*models.Player
+	for _, name := range []string{"star", "rival"} {
+		_, player, err := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: name})
+		if err != nil {
+			t.Fatalf("JoinLobby: %v", err)
+		}
+		featured = append(featured, player)
+	}
+
+	const audienceSize = 200
+	members := make([]*models.AudienceMember, audienceSize)
+	for i := range members {
+		if members[i], err = gs.JoinAudience(lobby.ID, fmt.Sprintf("fan%d", i)); err != nil {
+			t.Fatalf("JoinAudience: %v", err)
+		}
+	}
+	// A name in the audience can't be joined again to take over its member
+	if _, err := gs.JoinAudience(lobby.ID, "FAN0"); !errors.Is(err, services.ErrAudienceNameTaken) {
+		t.Fatalf("Expected ErrAudienceNameTaken, got %v", err)
+	}
+	if again, err := gs.RejoinAudience(lobby.ID, members[0].ID); err != nil || again.ID != members[0].ID {
+		t.Fatalf("Rejoining the audience should return the same member, got %+v, %v", again, err)
+	}
+
+	watcher := &hub.Client{ID: "watcher", LobbyID: lobby.ID, Send: make(chan []byte, 64)}
+	lobbyHub.Register(watcher)
+
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	current := lobbyHub.GetLobby()
+	current.Lock()
+	question := current.CurrentQ
+	players := len(current.Players)
+	current.Unlock()
+	if players != len(featured) {
+		t.Fatalf("Audience members took player seats: %d players", players)
+	}
+
+	var wg sync.WaitGroup
+	for _, member := range members {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			if _, err := gs.SubmitAudienceAnswer(lobby.ID, id, correctAnswer(question)); err != nil {
+				t.Errorf("SubmitAudienceAnswer: %v", err)
+			}
+		}(member.ID)
+	}
+	wg.Wait()
+	if _, err := gs.SubmitAudienceAnswer(lobby.ID, members[0].ID, correctAnswer(question)); !errors.Is(err, services.ErrAlreadyAnswered) {
+		t.Fatalf("Expected ErrAlreadyAnswered, got %v", err)
+	}
+
+	for _, player := range featured {
+		if err := gs.SubmitAnswer(lobby.ID, player.ID, correctAnswer(question)); err != nil {
+			t.Fatalf("SubmitAnswer: %v", err)
+		}
+	}
+
+	var summary *models.AudienceSummary
+	deadline := time.After(5 * time.Second)
+	for summary == nil {
+		select {
+		case payload := <-watcher.Send:
+			var event struct {
+				Type string `json:"type"`
+				Data struct {
+					Audience *models.AudienceSummary `json:"audience"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(payload, &event); err != nil {
+				t.Fatalf("Decode event: %v", err)
+			}
+			if event.Type == "answer_received" {
+				continue
+			}
+			if event.Type == "question_results" {
+				summary = event.Data.Audience
+				if summary == nil {
+					t.Fatal("question_results carried no audience summary")
+				}
+			}
+		case <-deadline:
+			t.Fatal("No question_results event arrived")
+		}
+	}
+
+	if summary.Members != audienceSize || summary.Answered != audienceSize || summary.CorrectPercent != 100 {
+		t.Fatalf("Unexpected audience summary %+v", summary)
+	}
+	if len(summary.TopScorers) != 3 {
+		t.Fatalf("Expected 3 shout-outs, got %d", len(summary.TopScorers))
+	}
+	if answers, _ := repo.GetLobbyAnswers(lobby.ID); len(answers) != len(featured) {
+		t.Fatalf("Only the featured players' answers should be recorded, got %d", len(answers))
+	}
+	gs.ForceEndGame(lobby.ID)
+}
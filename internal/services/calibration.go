@@ -0,0 +1,163 @@
+package services
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"buildprize-game/internal/models"
+)
+
+const (
+	// Questions with fewer answers than this keep their label.
+	minAnswersForCalibration = 10
+	// A question whose median answer takes longer than this, over half its
+	// 15 seconds, is a step harder than its accuracy alone suggests.
+	slowMedianResponse = 8 * time.Second
+)
+
+// DifficultyDrift is a bank question whose difficulty label was changed to
+// match how players actually did on it.
+type DifficultyDrift struct {
+	QuestionID       string  `json:"question_id"`
+	Text             string  `json:"text"`
+	Category         string  `json:"category"`
+	From             string  `json:"from"` // the label before calibration, empty if it had none
+	To               string  `json:"to"`
+	Answered         int     `json:"answered"`
+	Accuracy         float64 `json:"accuracy"`
+	MedianResponseMs int64   `json:"median_response_ms"`
+	DriftedAt        string  `json:"drifted_at"`
+}
+
+// DifficultyReport lists the questions whose labels calibration has moved
+// away from the bank's since the server started.
+type DifficultyReport struct {
+	LastRunAt  string             `json:"last_run_at,omitempty"` // empty before the first run
+	Calibrated int                `json:"calibrated"`            // questions with enough answers in the last run
+	Drifted    []*DifficultyDrift `json:"drifted"`               // in question ID order
+}
+
+// difficultyCalibrator keeps the drift found so far; the zero value is ready
+// to use.
+type difficultyCalibrator struct {
+	mu         sync.Mutex
+	lastRunAt  time.Time
+	calibrated int
+	drift      map[string]*DifficultyDrift
+}
+
+// StartDifficultyCalibration recalibrates question difficulty now and then
+// every interval.
+func (gs *GameService) StartDifficultyCalibration(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	log.Printf("Difficulty calibration enabled: every %s", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if _, err := gs.CalibrateDifficulty(); err != nil {
+				log.Printf("Error calibrating question difficulty: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// CalibrateDifficulty relabels every bank question with enough answers as
+// easy, medium or hard from its accuracy and median response time across
+// games, and returns the drift report.
+func (gs *GameService) CalibrateDifficulty() (*DifficultyReport, error) {
+	performance, err := gs.repo.GetQuestionPerformance()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &gs.calibrator
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.drift == nil {
+		c.drift = make(map[string]*DifficultyDrift)
+	}
+
+	now := models.Now()
+	labels := make(map[string]string)
+	calibrated := 0
+	for _, p := range performance {
+		question := gs.questionDB.Question(p.QuestionID)
+		if question == nil || p.Answered < minAnswersForCalibration {
+			continue
+		}
+		calibrated++
+		label := calibratedDifficulty(p)
+		if label == question.Difficulty {
+			continue
+		}
+		labels[p.QuestionID] = label
+
+		drift := c.drift[p.QuestionID]
+		if drift == nil {
+			drift = &DifficultyDrift{QuestionID: p.QuestionID, Text: question.Text, Category: question.Category, From: question.Difficulty}
+			c.drift[p.QuestionID] = drift
+		}
+		drift.To = label
+		drift.Answered, drift.Accuracy, drift.MedianResponseMs = p.Answered, p.Accuracy, p.MedianResponseMs
+		drift.DriftedAt = models.FormatTimestamp(now)
+		if drift.To == drift.From {
+			delete(c.drift, p.QuestionID)
+		}
+		log.Printf("Question %s relabelled %q -> %q (%d answers, %.0f%% correct, median %dms)",
+			p.QuestionID, question.Difficulty, label, p.Answered, p.Accuracy*100, p.MedianResponseMs)
+	}
+	if len(labels) > 0 {
+		gs.questionDB.SetDifficulties(labels)
+	}
+	c.lastRunAt, c.calibrated = now, calibrated
+	return c.report(), nil
+}
+
+// DifficultyReport returns the drift found by calibration so far.
+func (gs *GameService) DifficultyReport() *DifficultyReport {
+	gs.calibrator.mu.Lock()
+	defer gs.calibrator.mu.Unlock()
+	return gs.calibrator.report()
+}
+
+// report builds the DifficultyReport. The caller holds c.mu.
+func (c *difficultyCalibrator) report() *DifficultyReport {
+	report := &DifficultyReport{Calibrated: c.calibrated, Drifted: make([]*DifficultyDrift, 0, len(c.drift))}
+	if !c.lastRunAt.IsZero() {
+		report.LastRunAt = models.FormatTimestamp(c.lastRunAt)
+	}
+	for _, drift := range c.drift {
+		copied := *drift
+		report.Drifted = append(report.Drifted, &copied)
+	}
+	sort.Slice(report.Drifted, func(i, j int) bool { return report.Drifted[i].QuestionID < report.Drifted[j].QuestionID })
+	return report
+}
+
+// calibratedDifficulty labels a question by how often it's answered
+// correctly, a step harder when players are slow to answer it.
+func calibratedDifficulty(p *models.QuestionPerformance) string {
+	label := models.DifficultyHard
+	switch {
+	case p.Accuracy >= 0.75:
+		label = models.DifficultyEasy
+	case p.Accuracy >= 0.4:
+		label = models.DifficultyMedium
+	}
+	if p.MedianResponseMs > slowMedianResponse.Milliseconds() {
+		switch label {
+		case models.DifficultyEasy:
+			label = models.DifficultyMedium
+		case models.DifficultyMedium:
+			label = models.DifficultyHard
+		}
+	}
+	return label
+}
@@ -0,0 +1,50 @@
+package services_test
+
+import (
+	"testing"
+
+	"buildprize-game/internal/models"
+)
+
+// Calibration labels questions from answer history and reports the ones
+// whose labels moved; questions with too few answers keep theirs.
+func TestDifficultyCalibration(t *testing.T) {
+	gs, _, repo := newService(t)
+	answer := func(questionID string, correct bool, responseMs int64) {
+		if err := repo.SaveAnswer(&models.AnswerRecord{PlayerID: "p", Username: "p", LobbyID: "l", QuestionID: questionID, Correct: correct, ResponseTime: responseMs}); err != nil {
+			t.Fatalf("SaveAnswer: %v", err)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		answer("1", true, 2000)   // easy
+		answer("2", i < 6, 12000) // medium by accuracy, but slow
+		answer("3", i < 2, 3000)  // hard
+	}
+	answer("4", false, 1000)
+
+	report, err := gs.CalibrateDifficulty()
+	if err != nil {
+		t.Fatalf("CalibrateDifficulty: %v", err)
+	}
+	if report.Calibrated != 3 || len(report.Drifted) != 3 || report.LastRunAt == "" {
+		t.Fatalf("Expected 3 questions calibrated and drifted, got %+v", report)
+	}
+	want := map[string]string{"1": models.DifficultyEasy, "2": models.DifficultyHard, "3": models.DifficultyHard}
+	for _, drift := range report.Drifted {
+		if drift.From != "" || drift.To != want[drift.QuestionID] {
+			t.Fatalf("Expected question %s labelled %q, got %+v", drift.QuestionID, want[drift.QuestionID], drift)
+		}
+	}
+	if report.Drifted[1].MedianResponseMs != 12000 || report.Drifted[1].Accuracy != 0.6 {
+		t.Fatalf("Expected question 2's performance in the report, got %+v", report.Drifted[1])
+	}
+
+	// Once relabelled, the drift stays reported without changing again
+	again, err := gs.CalibrateDifficulty()
+	if err != nil {
+		t.Fatalf("CalibrateDifficulty: %v", err)
+	}
+	if len(again.Drifted) != 3 || again.Drifted[0].DriftedAt != report.Drifted[0].DriftedAt {
+		t.Fatalf("Expected the same drift on a second run, got %+v", again)
+	}
+}
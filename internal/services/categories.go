@@ -0,0 +1,75 @@
+package services
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// AnyCategory in a weights map stands for "any category from the bank".
+const AnyCategory = "any"
+
+// validateCategoryWeights checks that every weighted category exists in the
+// question bank (or is AnyCategory) and that the weights are usable.
+func (gs *GameService) validateCategoryWeights(weights map[string]int) error {
+	total := 0
+	for category, weight := range weights {
+		if weight < 0 {
+			return ErrInvalidCategoryWeights
+		}
+		if !strings.EqualFold(category, AnyCategory) && !gs.questionDB.HasCategory(category) {
+			return ErrUnknownCategory
+		}
+		total += weight
+	}
+	if len(weights) > 0 && total == 0 {
+		return ErrInvalidCategoryWeights
+	}
+	return nil
+}
+
+// buildCategoryPlan turns weights into one category per round using the
+// largest remainder method, so a 10-round game with 50/30/20 weights gets
+// exactly 5/3/2 questions. The plan is shuffled so categories interleave.
+func buildCategoryPlan(weights map[string]int, rounds int) []string {
+	total := 0
+	categories := make([]string, 0, len(weights))
+	for category, weight := range weights {
+		if weight > 0 {
+			categories = append(categories, category)
+			total += weight
+		}
+	}
+	if total == 0 || rounds <= 0 {
+		return nil
+	}
+	sort.Strings(categories) // deterministic tie-breaking
+
+	type share struct {
+		category  string
+		count     int
+		remainder int
+	}
+	shares := make([]share, len(categories))
+	assigned := 0
+	for i, category := range categories {
+		exact := weights[category] * rounds
+		shares[i] = share{category: category, count: exact / total, remainder: exact % total}
+		assigned += shares[i].count
+	}
+
+	sort.SliceStable(shares, func(i, j int) bool { return shares[i].remainder > shares[j].remainder })
+	for i := 0; assigned < rounds; i = (i + 1) % len(shares) {
+		shares[i].count++
+		assigned++
+	}
+
+	plan := make([]string, 0, rounds)
+	for _, s := range shares {
+		for n := 0; n < s.count; n++ {
+			plan = append(plan, s.category)
+		}
+	}
+	rand.Shuffle(len(plan), func(i, j int) { plan[i], plan[j] = plan[j], plan[i] })
+	return plan
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"buildprize-game/internal/api"
+	"buildprize-game/internal/game"
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/repository"
+)
+
+// defaultChatRetention is how long chat history is kept unless changed
+// with SetChatRetention.
+const defaultChatRetention = 24 * time.Hour
+
+// SetChatRetention sets how long chat messages are kept for the chat history
+// endpoint. Zero or less keeps the current setting.
+func (gs *GameService) SetChatRetention(retention time.Duration) {
+	if retention <= 0 {
+		return
+	}
+	gs.mu.Lock()
+	gs.chatRetention = retention
+	gs.mu.Unlock()
+}
+
+// PostChatMessage passes a chat message from player through the chat filter,
+// then broadcasts it, stores it in the chat history and keeps it in the
+// lobby's recent chat. A message the filter refuses is returned as a
+// *ChatRejection for the sender. It's recorded and broadcast under the
+// lobby lock, so the history is in the order clients saw it. A message that
+// can't be stored is still delivered.
+func (gs *GameService) PostChatMessage(lobbyHub *hub.LobbyHub, player *models.Player, message string) error {
+	lobby := lobbyHub.GetLobby()
+	// Millisecond precision, as timestamps are sent, so a client can pass the
+	// last one it saw back as ChatHistory's since
+	now := models.Now().Truncate(time.Millisecond)
+
+	lobby.Lock()
+	defer lobby.Unlock()
+	message, err := gs.moderateChat(lobby, player, message, now)
+	if err != nil {
+		return err
+	}
+	msg := models.ChatMessage{
+		LobbyID:  lobby.ID,
+		PlayerID: player.ID,
+		Username: player.Username,
+		Message:  message,
+		SentAt:   now,
+	}
+	if err := gs.repo.SaveChatMessage(&msg); err != nil {
+		log.Printf("ERROR: Failed to store chat message from player %s in lobby %s: %v", player.ID, lobby.ID, err)
+	}
+	lobby.AddChatMessage(msg)
+	gs.touchLobby(lobbyHub, lobby)
+	// Not delivered to players who muted the sender for themselves
+	lobbyHub.Publish(&models.GameEvent{
+		Type:        "chat_message",
+		LobbyID:     lobby.ID,
+		Data:        api.FromChatMessage(msg),
+		SkipPlayers: lobby.MutedBy(player.ID),
+	})
+	return nil
+}
+
+// ChatHistory returns up to limit of the lobby's most recent stored chat
+// messages sent after since, oldest first. A zero since returns the most
+// recent messages still kept; a limit out of range gets the default.
+func (gs *GameService) ChatHistory(lobbyID string, since time.Time, limit int) ([]api.ChatMessage, error) {
+	if gs.hub.GetLobbyHub(lobbyID) == nil {
+		return nil, ErrLobbyNotFound
+	}
+	if limit <= 0 || limit > repository.MaxChatHistoryLimit {
+		limit = repository.DefaultChatHistoryLimit
+	}
+	messages, err := gs.repo.ListChatMessages(lobbyID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	history := make([]api.ChatMessage, len(messages))
+	for i, msg := range messages {
+		history[i] = api.FromChatMessage(*msg)
+	}
+	return history, nil
+}
+
+func (gs *GameService) deleteExpiredChat() {
+	gs.mu.Lock()
+	retention := gs.chatRetention
+	gs.mu.Unlock()
+	deleted, err := gs.repo.DeleteChatMessagesOlderThan(retention)
+	if err != nil {
+		log.Printf("Error cleaning up chat history: %v", err)
+	} else if deleted > 0 {
+		log.Printf("Cleaned up %d chat message(s) older than %s", deleted, retention)
+	}
+}
+
+// LobbyState returns a snapshot of the lobby for a client resyncing over
+// REST: the open question (without its answers) and time left, the round,
+// the leaderboard and recent chat.
+func (gs *GameService) LobbyState(lobbyID string) (*api.LobbyState, error) {
+	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
+	if lobbyHub == nil {
+		return nil, ErrLobbyNotFound
+	}
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+
+	state := &api.LobbyState{
+		Lobby:       api.FromLobby(lobby),
+		Round:       lobby.Round,
+		Leaderboard: api.FromPlayers(gs.Leaderboard(lobby)),
+		RecentChat:  api.FromChatMessages(lobby.RecentChat),
+		ServerTime:  models.FormatTimestamp(models.Now()),
+	}
+	questionOpen := lobby.IsQuestionActive() || (lobby.Paused && lobby.QuestionEnd != nil)
+	if lobby.Phase == game.Question && questionOpen && lobby.CurrentQ != nil {
+		state.Question = api.FromQuestion(lobby.CurrentQ)
+		state.TimeLeft = int(lobby.QuestionTimeLeft().Seconds())
+		state.QuestionEndTime = models.FormatTimestamp(*lobby.QuestionEnd)
+	}
+	return state, nil
+}
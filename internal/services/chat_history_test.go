@@ -0,0 +1,69 @@
+package services_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/services"
+)
+
+// Chat is stored as it's posted, can be loaded again from a given message
+// on, and goes once it's past the retention period or its lobby is deleted.
+func TestChatHistory(t *testing.T) {
+	gs, gameHub, repo := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Chatty", MaxRounds: 3, MaxPlayers: 4})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	_, alice, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"})
+	lobbyHub := gameHub.GetLobbyHub(lobby.ID)
+	for i := 0; i < 5; i++ {
+		gs.PostChatMessage(lobbyHub, alice, fmt.Sprintf("message %d", i))
+		time.Sleep(2 * time.Millisecond) // distinct millisecond timestamps
+	}
+
+	history, err := gs.ChatHistory(lobby.ID, time.Time{}, 3)
+	if err != nil {
+		t.Fatalf("ChatHistory: %v", err)
+	}
+	if len(history) != 3 || history[0].Message != "message 2" || history[2].Message != "message 4" {
+		t.Fatalf("Expected the last 3 messages oldest first, got %+v", history)
+	}
+	if history[0].ID == 0 || history[0].Username != "alice" {
+		t.Fatalf("Expected stored messages with IDs and usernames, got %+v", history[0])
+	}
+
+	since, err := time.Parse(time.RFC3339Nano, history[1].Timestamp)
+	if err != nil {
+		t.Fatalf("Parse timestamp %q: %v", history[1].Timestamp, err)
+	}
+	newer, err := gs.ChatHistory(lobby.ID, since, 50)
+	if err != nil {
+		t.Fatalf("ChatHistory: %v", err)
+	}
+	if len(newer) != 1 || newer[0].Message != "message 4" {
+		t.Fatalf("Expected only the message after %s, got %+v", since, newer)
+	}
+
+	if _, err := gs.ChatHistory("missing", time.Time{}, 50); !errors.Is(err, services.ErrLobbyNotFound) {
+		t.Fatalf("Expected ErrLobbyNotFound, got %v", err)
+	}
+
+	if deleted, _ := repo.DeleteChatMessagesOlderThan(time.Hour); deleted != 0 {
+		t.Fatalf("Expected recent chat to be kept, deleted %d", deleted)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if deleted, _ := repo.DeleteChatMessagesOlderThan(time.Millisecond); deleted != 5 {
+		t.Fatalf("Expected every message past retention deleted, deleted %d", deleted)
+	}
+
+	gs.PostChatMessage(lobbyHub, alice, "after cleanup")
+	if err := repo.DeleteLobby(lobby.ID); err != nil {
+		t.Fatalf("DeleteLobby: %v", err)
+	}
+	if stored, _ := repo.ListChatMessages(lobby.ID, time.Time{}, 50); len(stored) != 0 {
+		t.Fatalf("Expected chat deleted with its lobby, got %d messages", len(stored))
+	}
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"buildprize-game/internal/models"
+)
+
+// Codes a ChatRejection carries, so clients can tell the sender why without
+// parsing the message.
+const (
+	ChatEmpty       = "empty"
+	ChatTooLong     = "too_long"
+	ChatRateLimited = "rate_limited"
+	ChatMuted       = "muted"
+)
+
+// ChatModeration configures the filter every chat message passes before it's
+// delivered. The zero value masks no words, keeps links, allows messages up
+// to models.MaxChatMessageLength and sets no rate limit.
+type ChatModeration struct {
+	BlockedWords []string      // masked with asterisks, matched as whole words ignoring case
+	StripLinks   bool          // remove URLs and bare domain names
+	MaxLength    int           // in characters; at most models.MaxChatMessageLength
+	RateLimit    int           // messages per player per RateWindow; zero for no limit
+	RateWindow   time.Duration // defaults to 10s when RateLimit is set
+}
+
+// ChatRejection is the error for a chat message that isn't delivered. It's
+// sent back to the sender as is: as the REST response body, or as the data
+// of a chat_rejected event over WebSocket.
+type ChatRejection struct {
+	Code         string `json:"code"`
+	Message      string `json:"error"`
+	RetryAfterMs int64  `json:"retry_after_ms,omitempty"` // set for rate_limited and temporary mutes
+}
+
+func (r *ChatRejection) Error() string {
+	return r.Message
+}
+
+// chatFilter is a ChatModeration ready to apply.
+type chatFilter struct {
+	ChatModeration
+	blocked *regexp.Regexp // nil with no blocked words
+}
+
+// linkPattern matches URLs with a scheme, www. addresses and bare domains
+// under common TLDs, optionally followed by a path.
+var linkPattern = regexp.MustCompile(`(?i)\b(?:[a-z][a-z0-9+.-]*://\S+|www\.\S+|[a-z0-9-]+(?:\.[a-z0-9-]+)*\.(?:com|net|org|io|gg|co|me|tv|xyz|ru|info|biz|link|app|dev)\b(?:/\S*)?)`)
+
+// SetChatModeration replaces the chat filter. Blank blocked words are
+// ignored and MaxLength is capped at models.MaxChatMessageLength.
+func (gs *GameService) SetChatModeration(moderation ChatModeration) {
+	filter := &chatFilter{ChatModeration: moderation}
+	if filter.MaxLength <= 0 || filter.MaxLength > models.MaxChatMessageLength {
+		filter.MaxLength = models.MaxChatMessageLength
+	}
+	if filter.RateLimit > 0 && filter.RateWindow <= 0 {
+		filter.RateWindow = 10 * time.Second
+	}
+
+	var words []string
+	for _, word := range moderation.BlockedWords {
+		if word = strings.TrimSpace(word); word != "" {
+			words = append(words, regexp.QuoteMeta(word))
+		}
+	}
+	if len(words) > 0 {
+		filter.blocked = regexp.MustCompile(`(?i)\b(?:` + strings.Join(words, "|") + `)\b`)
+	}
+
+	gs.mu.Lock()
+	gs.chatFilter = filter
+	gs.mu.Unlock()
+}
+
+// moderateChat normalizes a chat message and applies the chat filter to it,
+// returning the text to deliver or a *ChatRejection. A message within the
+// rate limit is counted against it. The caller holds the lobby lock.
+func (gs *GameService) moderateChat(lobby *models.Lobby, player *models.Player, text string, now time.Time) (string, error) {
+	gs.mu.Lock()
+	filter := gs.chatFilter
+	gs.mu.Unlock()
+	if filter == nil {
+		filter = &chatFilter{ChatModeration: ChatModeration{MaxLength: models.MaxChatMessageLength}}
+	}
+
+	if until, muted := lobby.ChatMutes[player.ID]; muted {
+		if until.IsZero() || now.Before(until) {
+			rejection := &ChatRejection{Code: ChatMuted, Message: "you are muted in this lobby"}
+			if !until.IsZero() {
+				rejection.RetryAfterMs = until.Sub(now).Milliseconds() + 1
+			}
+			return "", rejection
+		}
+		delete(lobby.ChatMutes, player.ID)
+	}
+
+	text, err := models.NormalizeChatMessage(text)
+	switch {
+	case errors.Is(err, models.ErrChatMessageEmpty):
+		return "", &ChatRejection{Code: ChatEmpty, Message: err.Error()}
+	case errors.Is(err, models.ErrChatMessageTooLong):
+		return "", &ChatRejection{Code: ChatTooLong, Message: err.Error()}
+	case err != nil:
+		return "", err
+	}
+
+	if filter.StripLinks {
+		text = strings.Join(strings.Fields(linkPattern.ReplaceAllString(text, "")), " ")
+		if text == "" {
+			return "", &ChatRejection{Code: ChatEmpty, Message: "chat message is empty once links are removed"}
+		}
+	}
+	if models.Graphemes(text) > filter.MaxLength {
+		return "", &ChatRejection{Code: ChatTooLong, Message: fmt.Sprintf("chat message is longer than %d characters", filter.MaxLength)}
+	}
+	if filter.blocked != nil {
+		text = filter.blocked.ReplaceAllStringFunc(text, func(word string) string {
+			return strings.Repeat("*", models.Graphemes(word))
+		})
+	}
+
+	if filter.RateLimit > 0 {
+		if lobby.ChatSentAt == nil {
+			lobby.ChatSentAt = make(map[string][]time.Time)
+		}
+		windowStart := now.Add(-filter.RateWindow)
+		recent := lobby.ChatSentAt[player.ID]
+		for len(recent) > 0 && !recent[0].After(windowStart) {
+			recent = recent[1:]
+		}
+		if len(recent) >= filter.RateLimit {
+			lobby.ChatSentAt[player.ID] = recent
+			retryAfter := recent[0].Add(filter.RateWindow).Sub(now)
+			return "", &ChatRejection{
+				Code:         ChatRateLimited,
+				Message:      fmt.Sprintf("too many chat messages, at most %d every %s", filter.RateLimit, filter.RateWindow),
+				RetryAfterMs: retryAfter.Milliseconds() + 1,
+			}
+		}
+		lobby.ChatSentAt[player.ID] = append(recent, now)
+	}
+	return text, nil
+}
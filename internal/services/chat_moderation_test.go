@@ -0,0 +1,76 @@
+package services_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/services"
+)
+
+// The chat filter masks blocked words, strips links and refuses messages
+// that are too long or over a player's rate limit, telling the sender why.
+func TestChatModeration(t *testing.T) {
+	gs, gameHub, _ := newService(t)
+	gs.SetChatModeration(services.ChatModeration{
+		BlockedWords: []string{"darn", " ", "heck"},
+		StripLinks:   true,
+		MaxLength:    40,
+		RateLimit:    3,
+		RateWindow:   time.Minute,
+	})
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Moderated", MaxRounds: 3, MaxPlayers: 4})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	_, alice, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"})
+	_, bob, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "bob"})
+	lobbyHub := gameHub.GetLobbyHub(lobby.ID)
+
+	rejection := func(err error) *services.ChatRejection {
+		t.Helper()
+		var r *services.ChatRejection
+		if !errors.As(err, &r) {
+			t.Fatalf("Expected a ChatRejection, got %v", err)
+		}
+		return r
+	}
+
+	if err := gs.PostChatMessage(lobbyHub, alice, "Darn it, DARN! darning the socks"); err != nil {
+		t.Fatalf("PostChatMessage: %v", err)
+	}
+	if err := gs.PostChatMessage(lobbyHub, alice, "join https://spam.example/x or visit cheap-prizes.com now"); err != nil {
+		t.Fatalf("PostChatMessage: %v", err)
+	}
+	if r := rejection(gs.PostChatMessage(lobbyHub, alice, "www.spam.io")); r.Code != services.ChatEmpty {
+		t.Fatalf("Expected a links-only message refused as empty, got %+v", r)
+	}
+	if r := rejection(gs.PostChatMessage(lobbyHub, alice, strings.Repeat("a", 41))); r.Code != services.ChatTooLong {
+		t.Fatalf("Expected too_long, got %+v", r)
+	}
+
+	if err := gs.PostChatMessage(lobbyHub, alice, "third"); err != nil {
+		t.Fatalf("PostChatMessage: %v", err)
+	}
+	r := rejection(gs.PostChatMessage(lobbyHub, alice, "fourth"))
+	if r.Code != services.ChatRateLimited || r.RetryAfterMs <= 0 || r.RetryAfterMs > time.Minute.Milliseconds()+1 {
+		t.Fatalf("Expected rate_limited with a retry time, got %+v", r)
+	}
+	if err := gs.PostChatMessage(lobbyHub, bob, "bob is limited separately"); err != nil {
+		t.Fatalf("Expected bob's message through, got %v", err)
+	}
+
+	history, err := gs.ChatHistory(lobby.ID, time.Time{}, 50)
+	if err != nil {
+		t.Fatalf("ChatHistory: %v", err)
+	}
+	got := make([]string, len(history))
+	for i, msg := range history {
+		got[i] = msg.Message
+	}
+	want := []string{"**** it, ****! darning the socks", "join or visit now", "third", "bob is limited separately"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("Unexpected delivered chat:\n got %q\nwant %q", got, want)
+	}
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"time"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+)
+
+const (
+	demoSeedInterval   = 30 * time.Second
+	demoStartDelay     = 10 * time.Second
+	demoBotsPerLobby   = 1
+	demoBotAccuracy    = 0.6
+	demoBotMinDelayMs  = 2000
+	demoBotMaxDelayMs  = 10000
+	demoLobbyMaxRounds = 5
+)
+
+var demoLobbyNames = []string{"Quick Play", "Drop-in Trivia", "Open Table", "Warm-up Round"}
+
+var demoBotNames = []string{"QuizBot Ada", "QuizBot Turing", "QuizBot Hopper", "QuizBot Curie", "QuizBot Tesla"}
+
+// StartDemoMode keeps count public demo lobbies open, each seated with a bot,
+// so first-time visitors always have a game to join. A demo game starts
+// shortly after a real player joins; a fresh lobby replaces it afterwards.
+func (gs *GameService) StartDemoMode(count int) {
+	if count <= 0 {
+		return
+	}
+	log.Printf("Demo mode enabled: keeping %d demo lobbies open", count)
+
+	go func() {
+		gs.seedDemoLobbies(count)
+		ticker := time.NewTicker(demoSeedInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			gs.seedDemoLobbies(count)
+		}
+	}()
+}
+
+func (gs *GameService) seedDemoLobbies(count int) {
+	open := 0
+	for _, lobbyHub := range gs.hub.GetAllLobbies() {
+		lobby := lobbyHub.GetLobby()
+		lobby.Lock()
+		if lobby.Demo && lobby.State == models.Waiting {
+			open++
+		}
+		lobby.Unlock()
+	}
+
+	for ; open < count; open++ {
+		lobby, err := gs.CreateLobby(LobbyOptions{
+			Name:      demoLobbyNames[rand.Intn(len(demoLobbyNames))],
+			MaxRounds: demoLobbyMaxRounds,
+		})
+		if err != nil {
+			log.Printf("Demo mode: failed to create lobby: %v", err)
+			return
+		}
+		lobby.Lock()
+		lobby.Demo = true
+		for i := 0; i < demoBotsPerLobby; i++ {
+			lobby.AddBot(demoBotNames[rand.Intn(len(demoBotNames))])
+		}
+		gs.repo.SaveLobby(lobby)
+		lobby.Unlock()
+		log.Printf("Demo mode: seeded lobby %s (%s)", lobby.Name, lobby.ID)
+	}
+}
+
+// scheduleDemoStart starts a demo game shortly after a real player joins,
+// giving others a few seconds to hop in too.
+func (gs *GameService) scheduleDemoStart(lobbyID string) {
+	go func() {
+		time.Sleep(demoStartDelay)
+		if err := gs.StartGame(lobbyID); err != nil && err != ErrCannotStartGame {
+			log.Printf("Demo mode: failed to start lobby %s: %v", lobbyID, err)
+		}
+	}()
+}
+
+// playBotAnswers makes every bot in the lobby answer the current question
+// after a random delay, correctly demoBotAccuracy of the time. The caller
+// holds the lobby lock.
+func (gs *GameService) playBotAnswers(ctx context.Context, lobbyHub *hub.LobbyHub) {
+	lobby := lobbyHub.GetLobby()
+	question := lobby.CurrentQ
+	if question == nil {
+		return
+	}
+
+	for _, player := range lobby.Players {
+		if !player.IsBot {
+			continue
+		}
+		delay := int64(demoBotMinDelayMs + rand.Intn(demoBotMaxDelayMs-demoBotMinDelayMs))
+		answer := botAnswer(question, rand.Float64() < demoBotAccuracy)
+
+		go func(playerID string, delay int64) {
+			if !sleepContext(ctx, time.Duration(delay)*time.Millisecond) {
+				return
+			}
+			if err := gs.SubmitAnswer(lobby.ID, playerID, answer); err != nil && err != ErrQuestionNotActive {
+				log.Printf("Demo mode: bot %s answer failed in lobby %s: %v", playerID, lobby.ID, err)
+			}
+		}(player.ID, delay)
+	}
+}
+
+func botAnswer(q *models.Question, correct bool) models.SubmittedAnswer {
+	switch q.QuestionType() {
+	case models.FreeText:
+		if correct && len(q.AcceptedAnswers) > 0 {
+			return models.SubmittedAnswer{Text: q.AcceptedAnswers[0]}
+		}
+		return models.SubmittedAnswer{Text: "no idea"}
+	case models.Numeric:
+		if correct {
+			return models.SubmittedAnswer{Number: q.NumericAnswer}
+		}
+		return models.SubmittedAnswer{Number: q.NumericAnswer + (rand.Float64()*2-1)*q.NumericTolerance()}
+	case models.MultiSelect:
+		if correct {
+			return models.SubmittedAnswer{Choices: append([]int(nil), q.CorrectAnswers...)}
+		}
+		return models.SubmittedAnswer{Choices: []int{rand.Intn(len(q.Options))}}
+	default:
+		if correct {
+			return models.SubmittedAnswer{Choice: q.Correct}
+		}
+		return models.SubmittedAnswer{Choice: rand.Intn(len(q.Options))}
+	}
+}
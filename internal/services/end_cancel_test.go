@@ -0,0 +1,65 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+
+	"buildprize-game/internal/game"
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/services"
+)
+
+// The host can end a running game early, leaving a finished lobby with the
+// scores as they stand, or call it off so it is cancelled and removed.
+// Nobody else can do either.
+func TestHostEndsAndCancelsGame(t *testing.T) {
+	gs, gameHub, _ := newService(t)
+	start := func(name string) (lobbyID, hostID, guestID string) {
+		t.Helper()
+		lobby, err := gs.CreateLobby(services.LobbyOptions{Name: name, MaxRounds: 5, MaxPlayers: 4})
+		if err != nil {
+			t.Fatalf("CreateLobby: %v", err)
+		}
+		_, host, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "host"})
+		_, guest, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "guest"})
+		if err := gs.HostCancelGame(lobby.ID, host.ID); !errors.Is(err, services.ErrGameNotRunning) {
+			t.Fatalf("Expected a game not yet started refused, got %v", err)
+		}
+		if err := gs.StartGame(lobby.ID); err != nil {
+			t.Fatalf("StartGame: %v", err)
+		}
+		return lobby.ID, host.ID, guest.ID
+	}
+
+	lobbyID, hostID, guestID := start("Ended")
+	if err := gs.HostEndGame(lobbyID, guestID); !errors.Is(err, services.ErrNotHost) {
+		t.Fatalf("Expected a guest refused, got %v", err)
+	}
+	if err := gs.HostEndGame(lobbyID, hostID); err != nil {
+		t.Fatalf("HostEndGame: %v", err)
+	}
+	lobby := gameHub.GetLobbyHub(lobbyID).GetLobby()
+	lobby.Lock()
+	phase := lobby.Phase
+	lobby.Unlock()
+	if phase != game.Finished {
+		t.Fatalf("Expected the game finished, got %s", phase)
+	}
+
+	lobbyID, hostID, guestID = start("Cancelled")
+	watcher := &hub.Client{ID: "watcher", LobbyID: lobbyID, Send: make(chan []byte, 64)}
+	gameHub.GetLobbyHub(lobbyID).Register(watcher)
+	if err := gs.HostCancelGame(lobbyID, guestID); !errors.Is(err, services.ErrNotHost) {
+		t.Fatalf("Expected a guest refused, got %v", err)
+	}
+	if err := gs.HostCancelGame(lobbyID, hostID); err != nil {
+		t.Fatalf("HostCancelGame: %v", err)
+	}
+	waitForEvent(t, watcher, "game_cancelled")
+	if gameHub.GetLobbyHub(lobbyID) != nil {
+		t.Fatal("Expected the cancelled lobby removed")
+	}
+	if err := gs.CancelGame(lobbyID); !errors.Is(err, services.ErrLobbyNotFound) {
+		t.Fatalf("Expected the removed lobby not found, got %v", err)
+	}
+}
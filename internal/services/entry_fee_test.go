@@ -0,0 +1,311 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/payouts"
+	"buildprize-game/internal/repository"
+	"buildprize-game/internal/services"
+)
+
+// fakeCollector completes payments when the test says the player paid,
+// and refunds them unless refundErr is set.
+type fakeCollector struct {
+	mu        sync.Mutex
+	payments  map[string]*payouts.Payment
+	refundErr error
+	refunds   []string // idempotency keys, in the order refunds were asked for
+}
+
+func (f *fakeCollector) Name() string                  { return "fake" }
+func (f *fakeCollector) CheckDestination(string) error { return nil }
+func (f *fakeCollector) Send(context.Context, payouts.Request) (*payouts.Result, error) {
+	return &payouts.Result{Reference: "payout", Status: payouts.StatusPaid}, nil
+}
+func (f *fakeCollector) ParseWebhook(context.Context, http.Header, []byte) (*payouts.Update, error) {
+	return nil, nil
+}
+
+func (f *fakeCollector) CreatePayment(ctx context.Context, req payouts.PaymentRequest) (*payouts.Payment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	payment := &payouts.Payment{Reference: "pay-" + req.Username, Status: payouts.StatusPending,
+		LobbyID: req.LobbyID, Amount: req.Amount, Currency: req.Currency}
+	f.payments[payment.Reference] = payment
+	copied := *payment
+	return &copied, nil
+}
+
+func (f *fakeCollector) ConfirmPayment(ctx context.Context, reference string) (*payouts.Payment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	payment := f.payments[reference]
+	if payment == nil {
+		return nil, errors.New("no such payment")
+	}
+	copied := *payment
+	return &copied, nil
+}
+
+func (f *fakeCollector) RefundPayment(ctx context.Context, reference, idempotencyKey string) (*payouts.Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.refunds = append(f.refunds, idempotencyKey)
+	if f.refundErr != nil {
+		return nil, f.refundErr
+	}
+	if payment := f.payments[reference]; payment == nil || payment.Status != payouts.StatusPaid {
+		return nil, fmt.Errorf("%w: %s isn't paid", payouts.ErrRejected, reference)
+	}
+	return &payouts.Result{Reference: "re-" + reference, Status: payouts.StatusPaid}, nil
+}
+
+func (f *fakeCollector) refundKeys() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.refunds...)
+}
+
+func (f *fakeCollector) pay(reference string) {
+	f.mu.Lock()
+	f.payments[reference].Status = payouts.StatusPaid
+	f.mu.Unlock()
+}
+
+// Players only get a seat in an entry-fee lobby with a completed payment,
+// each payment seats one player, and the fees grow the prize pool shared
+// out when the game ends.
+func TestEntryFeeLobby(t *testing.T) {
+	gs, _, _ := newService(t)
+	if _, err := gs.CreateLobby(services.LobbyOptions{Name: "No provider", MaxRounds: 3, EntryFee: 500,
+		PrizePool: &models.PrizePool{Currency: "USD"}}); !errors.Is(err, services.ErrEntryFeesDisabled) {
+		t.Fatalf("Expected ErrEntryFeesDisabled without a collecting provider, got %v", err)
+	}
+	collector := &fakeCollector{payments: make(map[string]*payouts.Payment)}
+	gs.SetPayoutProvider(collector)
+	if _, err := gs.CreateLobby(services.LobbyOptions{Name: "Empty pool", MaxRounds: 3,
+		PrizePool: &models.PrizePool{Currency: "USD"}}); !errors.Is(err, services.ErrInvalidPrizePool) {
+		t.Fatalf("Expected ErrInvalidPrizePool for an empty pool without entry fees, got %v", err)
+	}
+
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Buy-in", MaxRounds: 3, MaxPlayers: 4, EntryFee: 500,
+		PrizePool: &models.PrizePool{Amount: 1000, Currency: "USD", Split: []int{100}}})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	if _, _, err := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"}); !errors.Is(err, services.ErrEntryFeeRequired) {
+		t.Fatalf("Expected ErrEntryFeeRequired joining without paying, got %v", err)
+	}
+
+	payment, err := gs.StartEntryPayment(lobby.ID, "alice")
+	if err != nil {
+		t.Fatalf("StartEntryPayment: %v", err)
+	}
+	if payment.Amount != 500 || payment.Currency != "USD" {
+		t.Fatalf("Expected a payment of 500 USD, got %+v", payment)
+	}
+	if _, _, err := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice", PaymentID: payment.Reference}); !errors.Is(err, services.ErrPaymentNotComplete) {
+		t.Fatalf("Expected ErrPaymentNotComplete before paying, got %v", err)
+	}
+	collector.pay(payment.Reference)
+	_, alice, err := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice", PaymentID: payment.Reference})
+	if err != nil {
+		t.Fatalf("JoinLobby: %v", err)
+	}
+	if _, _, err := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "mallory", PaymentID: payment.Reference}); !errors.Is(err, services.ErrPaymentUsed) {
+		t.Fatalf("Expected ErrPaymentUsed reusing alice's payment, got %v", err)
+	}
+
+	// bob pays his way in too; alice may then leave and come back on her
+	// payment without paying again
+	bobPayment, err := gs.StartEntryPayment(lobby.ID, "bob")
+	if err != nil {
+		t.Fatalf("StartEntryPayment: %v", err)
+	}
+	collector.pay(bobPayment.Reference)
+	if _, _, err := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "bob", PaymentID: bobPayment.Reference}); err != nil {
+		t.Fatalf("JoinLobby: %v", err)
+	}
+	if err := gs.LeaveLobby(lobby.ID, alice.ID); err != nil {
+		t.Fatalf("LeaveLobby: %v", err)
+	}
+	if _, alice, err = gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice", PaymentID: payment.Reference}); err != nil {
+		t.Fatalf("Expected alice back on her payment, got %v", err)
+	}
+	lobby.Lock()
+	pool := lobby.PrizePool.Amount
+	lobby.Unlock()
+	if pool != 2000 {
+		t.Fatalf("Expected the pool at 1000 plus two entry fees, got %d", pool)
+	}
+
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	lobby.Lock()
+	question := lobby.CurrentQ
+	lobby.Unlock()
+	if err := gs.SubmitAnswer(lobby.ID, alice.ID, correctAnswer(question)); err != nil {
+		t.Fatalf("SubmitAnswer: %v", err)
+	}
+	if err := gs.ForceEndGame(lobby.ID); err != nil {
+		t.Fatalf("ForceEndGame: %v", err)
+	}
+	result, err := gs.Prizes(lobby.ID)
+	if err != nil {
+		t.Fatalf("Prizes: %v", err)
+	}
+	if result.PrizePool.Amount != 2000 || len(result.Allocations) != 1 || result.Allocations[0].Amount != 2000 {
+		t.Fatalf("Expected alice allocated the whole 2000 pool, got %+v", result.Allocations)
+	}
+}
+
+// paidLobby opens a lobby with a 500 USD entry fee and seats each of names
+// on a completed payment.
+func paidLobby(t *testing.T, gs *services.GameService, collector *fakeCollector, names ...string) *models.Lobby {
+	t.Helper()
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Buy-in", MaxRounds: 3, EntryFee: 500,
+		PrizePool: &models.PrizePool{Currency: "USD", Split: []int{100}}})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	for _, name := range names {
+		payment, err := gs.StartEntryPayment(lobby.ID, name)
+		if err != nil {
+			t.Fatalf("StartEntryPayment: %v", err)
+		}
+		collector.pay(payment.Reference)
+		if _, _, err := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: name, PaymentID: payment.Reference}); err != nil {
+			t.Fatalf("JoinLobby: %v", err)
+		}
+	}
+	return lobby
+}
+
+// entryRefunds returns the lobby's stored refunds by payment ID.
+func entryRefunds(t *testing.T, repo repository.Repository, lobbyID string) map[string]*models.EntryRefund {
+	t.Helper()
+	refunds, err := repo.ListEntryRefunds("")
+	if err != nil {
+		t.Fatalf("ListEntryRefunds: %v", err)
+	}
+	byPayment := make(map[string]*models.EntryRefund)
+	for _, refund := range refunds {
+		if refund.LobbyID == lobbyID {
+			byPayment[refund.PaymentID] = refund
+		}
+	}
+	return byPayment
+}
+
+// Entry fees are refunded when a lobby closes without its game played out,
+// and kept in the prize pool once it has been.
+func TestEntryFeeRefunds(t *testing.T) {
+	gs, _, repo := newService(t)
+	collector := &fakeCollector{payments: make(map[string]*payouts.Payment)}
+	gs.SetPayoutProvider(collector)
+
+	cancelled := paidLobby(t, gs, collector, "alice", "bob")
+	if err := gs.StartGame(cancelled.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	if err := gs.CancelGame(cancelled.ID); err != nil {
+		t.Fatalf("CancelGame: %v", err)
+	}
+	waitFor(t, func() bool {
+		refunds := entryRefunds(t, repo, cancelled.ID)
+		return len(refunds) == 2 && refunds["pay-alice"].Status == models.RefundRefunded && refunds["pay-bob"].Status == models.RefundRefunded
+	})
+	refund := entryRefunds(t, repo, cancelled.ID)["pay-alice"]
+	if refund.Amount != 500 || refund.Currency != "USD" || refund.Username != "alice" || refund.Reason != "cancelled" ||
+		refund.Provider != "fake" || refund.Reference != "re-pay-alice" || refund.Attempts != 1 {
+		t.Fatalf("Unexpected refund: %+v", refund)
+	}
+
+	// A provider that doesn't answer leaves the refund pending, to be sent
+	// again under the same key; the last player leaving closes the lobby
+	collector.mu.Lock()
+	collector.refundErr = errors.New("timeout")
+	collector.mu.Unlock()
+	emptied := paidLobby(t, gs, collector, "carol")
+	emptied.Lock()
+	carol := emptied.Players[0].ID
+	emptied.Unlock()
+	if err := gs.LeaveLobby(emptied.ID, carol); err != nil {
+		t.Fatalf("LeaveLobby: %v", err)
+	}
+	waitFor(t, func() bool { return entryRefunds(t, repo, emptied.ID)["pay-carol"].Attempts == 1 })
+	if refund := entryRefunds(t, repo, emptied.ID)["pay-carol"]; refund.Status != models.RefundPending || refund.Error == "" || refund.Reason != "empty" {
+		t.Fatalf("Expected the refund pending after a timeout, got %+v", refund)
+	}
+	collector.mu.Lock()
+	collector.refundErr = nil
+	collector.mu.Unlock()
+	if refunded := gs.RefundEntryFees(); refunded != 1 {
+		t.Fatalf("RefundEntryFees: got %d, want 1", refunded)
+	}
+	if refund := entryRefunds(t, repo, emptied.ID)["pay-carol"]; refund.Status != models.RefundRefunded || refund.Attempts != 2 || refund.Error != "" {
+		t.Fatalf("Expected the retried refund refunded, got %+v", refund)
+	}
+	var keys []string
+	for _, key := range collector.refundKeys() {
+		if strings.Contains(key, emptied.ID) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) != 2 || keys[0] != keys[1] {
+		t.Fatalf("Expected the retry under the same idempotency key, got %v", keys)
+	}
+	if refunded := gs.RefundEntryFees(); refunded != 0 {
+		t.Fatalf("RefundEntryFees with nothing pending: got %d", refunded)
+	}
+
+	// A refund the provider refuses is failed, not retried
+	collector.mu.Lock()
+	collector.refundErr = fmt.Errorf("%w: charge disputed", payouts.ErrRejected)
+	collector.mu.Unlock()
+	refused := paidLobby(t, gs, collector, "dave", "erin")
+	if err := gs.StartGame(refused.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	if err := gs.CancelGame(refused.ID); err != nil {
+		t.Fatalf("CancelGame: %v", err)
+	}
+	waitFor(t, func() bool { return entryRefunds(t, repo, refused.ID)["pay-dave"].Status == models.RefundFailed })
+	if refunded := gs.RefundEntryFees(); refunded != 0 {
+		t.Fatalf("RefundEntryFees retried a refused refund: got %d", refunded)
+	}
+
+	// Once the game is played out the fees belong to the winners
+	collector.mu.Lock()
+	collector.refundErr = nil
+	collector.mu.Unlock()
+	played := paidLobby(t, gs, collector, "frank", "grace")
+	if err := gs.StartGame(played.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	if err := gs.ForceEndGame(played.ID); err != nil {
+		t.Fatalf("ForceEndGame: %v", err)
+	}
+	played.Lock()
+	var seated []string
+	for _, player := range played.Players {
+		seated = append(seated, player.ID)
+	}
+	played.Unlock()
+	for _, playerID := range seated {
+		if err := gs.LeaveLobby(played.ID, playerID); err != nil {
+			t.Fatalf("LeaveLobby: %v", err)
+		}
+	}
+	if refunds := entryRefunds(t, repo, played.ID); len(refunds) != 0 {
+		t.Fatalf("Refunded entry fees of a finished game: %+v", refunds)
+	}
+}
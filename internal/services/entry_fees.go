@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"buildprize-game/internal/game"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/payouts"
+)
+
+// validateEntryFee checks the entry fee a lobby is created with against its
+// prize pool: a pool needs a guaranteed amount unless entry fees fund it.
+func (gs *GameService) validateEntryFee(fee int64, pool *models.PrizePool) error {
+	if fee < 0 {
+		return ErrInvalidEntryFee
+	}
+	if fee == 0 {
+		if pool != nil && pool.Amount == 0 {
+			return fmt.Errorf("%w: amount must be positive without an entry fee", ErrInvalidPrizePool)
+		}
+		return nil
+	}
+	if pool == nil {
+		return ErrEntryFeeNeedsPool
+	}
+	if gs.entryCollector() == nil {
+		return ErrEntryFeesDisabled
+	}
+	return nil
+}
+
+// entryCollector returns the payout provider if it can also collect
+// payments, or nil.
+func (gs *GameService) entryCollector() payouts.Collector {
+	collector, _ := gs.currentPayoutProvider().(payouts.Collector)
+	return collector
+}
+
+// StartEntryPayment starts a payment of a lobby's entry fee for username to
+// complete with the provider, then join the lobby with. It's refused once
+// the lobby is full or its game has started.
+func (gs *GameService) StartEntryPayment(lobbyID, username string) (*payouts.Payment, error) {
+	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
+	if lobbyHub == nil {
+		return nil, ErrLobbyNotFound
+	}
+	username, err := models.NormalizeUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	collector := gs.entryCollector()
+	if collector == nil {
+		return nil, ErrEntryFeesDisabled
+	}
+
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	if lobby.EntryFee == 0 {
+		lobby.Unlock()
+		return nil, ErrNoEntryFee
+	}
+	if lobby.IsFull() {
+		lobby.Unlock()
+		return nil, ErrLobbyFull
+	}
+	if lobby.Phase != game.Waiting {
+		lobby.Unlock()
+		return nil, ErrGameInProgress
+	}
+	req := payouts.PaymentRequest{
+		LobbyID:     lobby.ID,
+		Username:    username,
+		Amount:      lobby.EntryFee,
+		Currency:    lobby.PrizePool.Currency,
+		Description: fmt.Sprintf("Entry to %s", lobby.Name),
+	}
+	lobby.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), payoutTimeout)
+	defer cancel()
+	payment, err := collector.CreatePayment(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPaymentProvider, err)
+	}
+	log.Printf("Started entry payment %s for %s in lobby %s", payment.Reference, username, lobbyID)
+	return payment, nil
+}
+
+// confirmEntryPayment checks with the provider that a payment of the
+// lobby's entry fee, in its prize pool's currency, is complete. It's called
+// without the lobby lock.
+func (gs *GameService) confirmEntryPayment(lobbyID string, fee int64, currency, paymentID string) (*payouts.Payment, error) {
+	collector := gs.entryCollector()
+	if collector == nil {
+		return nil, ErrEntryFeesDisabled
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), payoutTimeout)
+	defer cancel()
+	payment, err := collector.ConfirmPayment(ctx, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPaymentProvider, err)
+	}
+	if payment.LobbyID != lobbyID || payment.Currency != currency || payment.Amount < fee {
+		return nil, ErrPaymentMismatch
+	}
+	if payment.Status != payouts.StatusPaid {
+		return nil, ErrPaymentNotComplete
+	}
+	return payment, nil
+}
+
+// takeEntryPayment returns the entry payment a new player joining the lobby
+// is seated with: a confirmed one nobody else seated holds. The payment is
+// kept with the lobby so it can't let in two players. The caller holds the
+// lobby lock.
+func takeEntryPayment(lobby *models.Lobby, confirmed *payouts.Payment) (*models.EntryPayment, error) {
+	if confirmed == nil {
+		return nil, ErrEntryFeeRequired
+	}
+	entry := lobby.RecordEntryPayment(confirmed.Reference, confirmed.Amount)
+	if entry.PlayerID != "" && lobby.GetPlayer(entry.PlayerID) != nil {
+		return nil, ErrPaymentUsed
+	}
+	return entry, nil
+}
+
+// recordEntryRefunds stores a refund for each entry payment a closing lobby
+// holds, unless its game was played out and the fees went into the prize
+// pool, and starts sending them. It reports whether the lobby can be
+// deleted: false if a refund couldn't be stored, so the payments aren't
+// lost with it. Call with the lobby locked.
+func (gs *GameService) recordEntryRefunds(lobby *models.Lobby, reason string) bool {
+	if len(lobby.EntryPayments) == 0 || lobby.Phase == game.Finished {
+		return true
+	}
+	existing, err := gs.repo.ListEntryRefunds("")
+	if err != nil {
+		log.Printf("Error listing entry refunds for lobby %s: %v", lobby.ID, err)
+		return false
+	}
+	recorded := make(map[string]bool)
+	for _, refund := range existing {
+		if refund.LobbyID == lobby.ID {
+			recorded[refund.PaymentID] = true
+		}
+	}
+	provider := ""
+	if current := gs.currentPayoutProvider(); current != nil {
+		provider = current.Name()
+	}
+	for _, refund := range lobby.EntryRefunds(reason) {
+		if recorded[refund.PaymentID] {
+			continue
+		}
+		refund.Provider = provider
+		if err := gs.repo.SaveEntryRefund(refund); err != nil {
+			log.Printf("Error recording the refund of entry payment %s in lobby %s: %v", refund.PaymentID, lobby.ID, err)
+			return false
+		}
+		log.Printf("Refunding entry payment %s in lobby %s (%s)", refund.PaymentID, lobby.ID, reason)
+	}
+	go gs.RefundEntryFees()
+	return true
+}
+
+// RefundEntryFees sends every pending entry refund to the payment provider,
+// returning how many it refunded. Refunds the provider refuses are marked
+// failed, to be settled by hand; any other error leaves them pending, to be
+// sent again under the same idempotency key.
+func (gs *GameService) RefundEntryFees() int {
+	gs.refundsMu.Lock()
+	defer gs.refundsMu.Unlock()
+	pending, err := gs.repo.ListEntryRefunds(models.RefundPending)
+	if err != nil {
+		log.Printf("Error listing pending entry refunds: %v", err)
+		return 0
+	}
+	refunded := 0
+	for _, refund := range pending {
+		if gs.sendEntryRefund(refund) {
+			refunded++
+		}
+	}
+	return refunded
+}
+
+func (gs *GameService) sendEntryRefund(refund *models.EntryRefund) bool {
+	provider := gs.currentPayoutProvider()
+	collector, _ := provider.(payouts.Collector)
+	if collector == nil || provider.Name() != refund.Provider {
+		refund.Error = fmt.Sprintf("payment provider %s is no longer configured", refund.Provider)
+		gs.saveEntryRefund(refund)
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), payoutTimeout)
+	defer cancel()
+	refund.Attempts++
+	result, err := collector.RefundPayment(ctx, refund.PaymentID, fmt.Sprintf("refund-%s-%s", refund.LobbyID, refund.PaymentID))
+	switch {
+	case errors.Is(err, payouts.ErrRejected):
+		refund.Status, refund.Error = models.RefundFailed, err.Error()
+		log.Printf("ALERT: refund of entry payment %s in lobby %s was refused: %v", refund.PaymentID, refund.LobbyID, err)
+	case err != nil:
+		refund.Error = err.Error()
+		log.Printf("Error refunding entry payment %s in lobby %s: %v", refund.PaymentID, refund.LobbyID, err)
+	default:
+		refund.Status, refund.Reference, refund.Error = models.RefundRefunded, result.Reference, ""
+		log.Printf("Refunded entry payment %s in lobby %s as %s", refund.PaymentID, refund.LobbyID, result.Reference)
+	}
+	gs.saveEntryRefund(refund)
+	return refund.Status == models.RefundRefunded
+}
+
+func (gs *GameService) saveEntryRefund(refund *models.EntryRefund) {
+	refund.UpdatedAt = models.Now()
+	if err := gs.repo.SaveEntryRefund(refund); err != nil {
+		log.Printf("Error saving the refund of entry payment %s in lobby %s: %v", refund.PaymentID, refund.LobbyID, err)
+	}
+}
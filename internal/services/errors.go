@@ -1,6 +1,12 @@
 package services
 
-import "errors"
+import (
+	"errors"
+
+	"buildprize-game/internal/i18n"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/payouts"
+)
 
 var (
 	ErrLobbyNotFound     = errors.New("lobby not found")
@@ -9,4 +15,134 @@ var (
 	ErrPlayerNotFound    = errors.New("player not found")
 	ErrCannotStartGame   = errors.New("cannot start game")
 	ErrQuestionNotActive = errors.New("no active question")
+	ErrInvalidAnswer     = models.ErrInvalidAnswer
+	ErrAlreadyAnswered   = errors.New("player already answered this question")
+
+	ErrNotHost        = errors.New("only the host can do that")
+	ErrCannotPause    = errors.New("game is not running or already paused")
+	ErrNotPaused      = errors.New("game is not paused")
+	ErrGameNotRunning = errors.New("game is not in progress")
+
+	ErrNotSandboxLobby = errors.New("lobby is not a sandbox lobby")
+
+	ErrNotAudienceLobby  = errors.New("lobby does not take an audience")
+	ErrAudienceNameTaken = errors.New("username is already in the audience; rejoin with its session_token")
+
+	ErrInvalidMaxPlayers = errors.New("max_players must be between 2 and the server's lobby size limit")
+
+	ErrInvalidLobbyName    = errors.New("name must be 1 to 100 characters")
+	ErrInvalidMaxRounds    = errors.New("max_rounds must be between 1 and 50")
+	ErrInvalidQuestionTime = errors.New("question_time must be between 5 and 120 seconds")
+	ErrCapacityTooSmall    = errors.New("max_players can't be below the number of players already seated")
+
+	ErrInvalidWagerRounds = errors.New("wager_rounds must be distinct rounds of the game")
+	ErrWagersClosed       = errors.New("no wager is open")
+	ErrAlreadyWagered     = errors.New("player already wagered this round")
+	ErrInvalidStake       = errors.New("stake must be between 0 and the player's score")
+
+	ErrUnknownPowerUp       = models.ErrUnknownPowerUp
+	ErrNoPowerUp            = errors.New("player has no power-up of that kind")
+	ErrPowerUpAlreadyUsed   = errors.New("player already used a power-up on this question")
+	ErrPowerUpNotApplicable = errors.New("power-up can't be used on this question")
+
+	ErrNoRecommendations = errors.New("no practice recommendations available")
+
+	ErrUnknownCategory        = errors.New("unknown question category")
+	ErrInvalidCategoryWeights = errors.New("category weights must be non-negative and not all zero")
+
+	ErrInvalidTopic         = errors.New("invalid question topic")
+	ErrNoGeneratedQuestions = errors.New("question generator returned no usable questions")
+
+	ErrInvalidTimezone     = models.ErrInvalidTimezone
+	ErrUnsupportedLanguage = i18n.ErrUnsupportedLanguage
+
+	ErrUsernameEmpty        = models.ErrUsernameEmpty
+	ErrUsernameTooLong      = models.ErrUsernameTooLong
+	ErrUsernameInvisible    = models.ErrUsernameInvisible
+	ErrUsernameMixedScripts = models.ErrUsernameMixedScripts
+	ErrUsernameConfusable   = models.ErrUsernameConfusable
+	ErrChatMessageEmpty     = models.ErrChatMessageEmpty
+	ErrChatMessageTooLong   = models.ErrChatMessageTooLong
+
+	ErrWebhooksDisabled      = errors.New("lobby webhooks are not enabled on this server")
+	ErrInvalidWebhookURL     = errors.New("webhook_url must be an http or https URL")
+	ErrWebhookHostNotAllowed = errors.New("webhook_url host is not allowed")
+
+	ErrInvalidSchedule = errors.New("starts_at must be in the future, with at most 100 invitees and an rsvp_quorum no larger than the invitation list")
+	ErrNotScheduled    = errors.New("lobby is not a scheduled game")
+	ErrNotInvited      = errors.New("player was not invited to this game")
+	ErrRSVPListFull    = errors.New("rsvp list is full")
+	ErrInvalidRSVP     = models.ErrInvalidRSVP
+
+	ErrInvalidPassword    = errors.New("password must be 8 to 72 characters")
+	ErrUsernameTaken      = errors.New("username is already registered")
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrUsernameRegistered = errors.New("username belongs to a registered account; log in to play as it")
+
+	ErrCannotFriendSelf  = errors.New("you can't add yourself as a friend")
+	ErrAlreadyFriends    = errors.New("already friends")
+	ErrFriendRequestSent = errors.New("friend request already sent")
+	ErrNotFriends        = errors.New("not friends")
+	ErrFriendOffline     = errors.New("friend is not online")
+	ErrNotInLobby        = errors.New("join the lobby before inviting friends to it")
+
+	ErrReplayNotFound = errors.New("no replay for this game")
+
+	ErrNoPrizeResult        = errors.New("no prize results for this lobby")
+	ErrResultsFinal         = errors.New("results are final")
+	ErrDisputeWindowClosed  = errors.New("the dispute window has closed")
+	ErrNotInResults         = errors.New("player is not in these results")
+	ErrInvalidDisputeReason = errors.New("dispute reason must be 1 to 1000 characters")
+	ErrAlreadyDisputed      = errors.New("player already has an open dispute")
+	ErrDisputeNotFound      = errors.New("dispute not found")
+	ErrDisputeResolved      = errors.New("dispute already resolved")
+	ErrInvalidDisputeStatus = errors.New("dispute status must be upheld or rejected")
+
+	ErrInvalidPrizePool = models.ErrInvalidPrizePool
+	ErrNoPrizePool      = errors.New("this lobby has no prize pool")
+	ErrResultsNotFinal  = errors.New("prizes can be claimed once the results are final")
+	ErrInvalidClaimCode = errors.New("claim code not recognised")
+	ErrPrizeClaimed     = errors.New("prize already claimed")
+
+	ErrInvalidPayoutDestination = payouts.ErrInvalidDestination
+	ErrPayoutsDisabled          = errors.New("automatic payouts are not configured")
+	ErrNoAllocation             = errors.New("player has no share of the prize pool")
+	ErrPayoutNotRetryable       = errors.New("only failed, unsent or unanswered payouts can be retried")
+	ErrPayoutProviderChanged    = errors.New("payout may have been sent through another provider; reconcile it there")
+
+	ErrInvalidEntryFee    = errors.New("entry fee can't be negative")
+	ErrEntryFeeNeedsPool  = errors.New("lobbies with an entry fee need a prize pool")
+	ErrEntryFeesDisabled  = errors.New("entry fees need a payout provider that collects payments")
+	ErrEntryFeeRequired   = errors.New("this lobby has an entry fee; join with a completed payment")
+	ErrNoEntryFee         = errors.New("this lobby has no entry fee")
+	ErrPaymentProvider    = errors.New("payment provider request failed")
+	ErrPaymentNotComplete = errors.New("entry payment is not complete")
+	ErrPaymentMismatch    = errors.New("payment is not for this lobby's entry fee")
+	ErrPaymentUsed        = errors.New("entry payment already used by a seated player")
+
+	ErrInvalidMuteScope = errors.New("mute scope must be self or lobby")
+	ErrCannotMuteSelf   = errors.New("players can't mute or report themselves")
+	ErrReportTooLong    = errors.New("report reason is longer than 500 characters")
+	ErrAlreadyReported  = errors.New("player already reported this player")
+
+	ErrInvalidPoll  = models.ErrInvalidPoll
+	ErrPollOpen     = errors.New("a poll is already open")
+	ErrNoOpenPoll   = errors.New("no open poll")
+	ErrAlreadyVoted = errors.New("player already voted in this poll")
+	ErrInvalidVote  = errors.New("invalid poll option")
+
+	ErrUnknownScoringVersion = errors.New("unknown scoring version")
+	ErrScoringVersionExists  = errors.New("scoring version already exists")
+	ErrInvalidScoringConfig  = models.ErrInvalidScoringConfig
+	ErrNoAnswerHistory       = errors.New("no recorded answers for this lobby")
+	ErrGameNotFinished       = errors.New("game has not finished")
+
+	ErrInvalidEvent  = errors.New("invalid recurring event")
+	ErrEventNotFound = errors.New("recurring event not found")
+
+	ErrInvalidAPIKey        = errors.New("invalid API key")
+	ErrAPIKeyNotFound       = errors.New("API key not found")
+	ErrAPIKeyRevoked        = errors.New("API key has been revoked")
+	ErrInvalidAPIKeyRequest = errors.New("invalid API key request")
 )
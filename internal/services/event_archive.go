@@ -0,0 +1,171 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"buildprize-game/internal/archive"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/repository"
+)
+
+const (
+	// archiveBatch is how many lobbies' logs are looked up at a time when
+	// moving expired ones to cold storage.
+	archiveBatch = 100
+
+	// archiveTimeout bounds a single request to the archive.
+	archiveTimeout = time.Minute
+)
+
+// archivedLog is how an event log is stored in the archive, gzipped: the
+// same shape as a replay.
+type archivedLog struct {
+	LobbyID string              `json:"lobby_id"`
+	Events  []*models.GameEvent `json:"events"`
+}
+
+// SetEventArchive has game event logs moved to store once they're past the
+// retention period, rather than deleted, and read back from it when asked
+// for. nil, the default, deletes them.
+func (gs *GameService) SetEventArchive(store archive.Store) {
+	gs.mu.Lock()
+	gs.eventArchive = store
+	gs.mu.Unlock()
+}
+
+func (gs *GameService) currentEventArchive() archive.Store {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	return gs.eventArchive
+}
+
+// ArchiveExpiredEvents moves the logs of lobbies with no event for the
+// retention period to the archive, whole, and returns how many it moved;
+// without an archive it moves none. It stops at the first log it fails to
+// move, which stays in the database.
+func (gs *GameService) ArchiveExpiredEvents() (int, error) {
+	gs.mu.Lock()
+	store, before := gs.eventArchive, time.Now().Add(-gs.eventRetention)
+	gs.mu.Unlock()
+	if store == nil {
+		return 0, nil
+	}
+	archived := 0
+	for {
+		lobbyIDs, err := gs.repo.ListGameEventLobbies(before, archiveBatch)
+		if err != nil {
+			return archived, err
+		}
+		for _, lobbyID := range lobbyIDs {
+			if err := gs.archiveEvents(store, lobbyID); err != nil {
+				return archived, fmt.Errorf("lobby %s: %w", lobbyID, err)
+			}
+			archived++
+		}
+		if len(lobbyIDs) < archiveBatch {
+			return archived, nil
+		}
+	}
+}
+
+// archiveEvents uploads the lobby's log, records where it went and then
+// deletes it from the database. Were the lobby to log an event in between,
+// the event would be lost, but by then it has been idle for the whole
+// retention period.
+func (gs *GameService) archiveEvents(store archive.Store, lobbyID string) error {
+	var events []*models.GameEvent
+	for afterSeq := uint64(0); ; {
+		page, err := gs.repo.ListGameEvents(lobbyID, afterSeq, maxGameEvents)
+		if err != nil {
+			return err
+		}
+		events = append(events, page...)
+		if len(page) < maxGameEvents {
+			break
+		}
+		afterSeq = page[len(page)-1].Seq
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	zw := gzip.NewWriter(&body)
+	if err := json.NewEncoder(zw).Encode(archivedLog{LobbyID: lobbyID, Events: events}); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	key := "game-events/" + lobbyID + ".json.gz"
+	ctx, cancel := context.WithTimeout(context.Background(), archiveTimeout)
+	defer cancel()
+	if err := store.Put(ctx, key, body.Bytes()); err != nil {
+		return err
+	}
+
+	last := events[len(events)-1]
+	if err := gs.repo.SaveGameArchive(&models.GameArchive{
+		LobbyID:     lobbyID,
+		Key:         key,
+		Events:      len(events),
+		LastSeq:     last.Seq,
+		LastEventAt: last.Timestamp,
+		ArchivedAt:  models.Now(),
+	}); err != nil {
+		return err
+	}
+	return gs.repo.DeleteGameEvents(lobbyID)
+}
+
+// storedEvents returns the lobby's events after afterSeq, up to limit,
+// from the database or, once its log has been moved there, the archive.
+// Lobbies still hosted here keep their log in the database.
+func (gs *GameService) storedEvents(lobbyID string, afterSeq uint64, limit int) ([]*models.GameEvent, error) {
+	events, err := gs.repo.ListGameEvents(lobbyID, afterSeq, limit)
+	if err != nil || len(events) > 0 {
+		return events, err
+	}
+	store := gs.currentEventArchive()
+	if store == nil || gs.hub.GetLobbyHub(lobbyID) != nil {
+		return events, nil
+	}
+	pointer, err := gs.repo.GetGameArchive(lobbyID)
+	if errors.Is(err, repository.ErrGameArchiveNotFound) {
+		return events, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), archiveTimeout)
+	defer cancel()
+	body, err := store.Get(ctx, pointer.Key)
+	if err != nil {
+		return nil, fmt.Errorf("read archived events of lobby %s: %w", lobbyID, err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	var archived archivedLog
+	if err := json.NewDecoder(io.LimitReader(zr, 256<<20)).Decode(&archived); err != nil {
+		return nil, err
+	}
+	for _, event := range archived.Events {
+		if len(events) == limit {
+			break
+		}
+		if event.Seq > afterSeq {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
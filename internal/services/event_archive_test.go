@@ -0,0 +1,105 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/archive"
+	"buildprize-game/internal/services"
+)
+
+// fakeArchive keeps archived objects in memory, failing every Put while
+// putErr is set.
+type fakeArchive struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	putErr  error
+}
+
+func (a *fakeArchive) Put(ctx context.Context, key string, body []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.putErr != nil {
+		return a.putErr
+	}
+	a.objects[key] = body
+	return nil
+}
+
+func (a *fakeArchive) Get(ctx context.Context, key string) ([]byte, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	body, ok := a.objects[key]
+	if !ok {
+		return nil, archive.ErrNotFound
+	}
+	return body, nil
+}
+
+// Event logs idle for the retention period move to the archive whole, and
+// replays read them back from it.
+func TestEventArchive(t *testing.T) {
+	gs, gameHub, repo := newService(t)
+	lobby, _ := gs.CreateLobby(services.LobbyOptions{Name: "Archived", MaxRounds: 1, MaxPlayers: 4})
+	gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"})
+	gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "bob"})
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	gs.ForceEndGame(lobby.ID)
+	events, err := gs.Replay(lobby.ID)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	gameHub.RemoveLobbyHub(lobby.ID)
+	repo.DeleteLobby(lobby.ID)
+
+	gs.SetEventRetention(time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if archived, err := gs.ArchiveExpiredEvents(); archived != 0 || err != nil {
+		t.Fatalf("Expected nothing archived without an archive, got %d, %v", archived, err)
+	}
+
+	// A failed upload keeps the log in the database
+	store := &fakeArchive{objects: make(map[string][]byte), putErr: errors.New("bucket unavailable")}
+	gs.SetEventArchive(store)
+	if _, err := gs.ArchiveExpiredEvents(); err == nil {
+		t.Fatal("Expected the failed upload reported")
+	}
+	if kept, _ := repo.ListGameEvents(lobby.ID, 0, 1000); len(kept) != len(events) {
+		t.Fatalf("Expected the log kept after a failed upload, got %d of %d events", len(kept), len(events))
+	}
+
+	store.putErr = nil
+	if archived, err := gs.ArchiveExpiredEvents(); archived != 1 || err != nil {
+		t.Fatalf("Expected the log archived, got %d, %v", archived, err)
+	}
+	if left, _ := repo.ListGameEvents(lobby.ID, 0, 1000); len(left) != 0 {
+		t.Fatalf("Expected the archived log deleted from the database, %d events left", len(left))
+	}
+	pointer, err := repo.GetGameArchive(lobby.ID)
+	if err != nil || pointer.Events != len(events) || pointer.LastSeq != events[len(events)-1].Seq || store.objects[pointer.Key] == nil {
+		t.Fatalf("Unexpected archive pointer: %+v, %v", pointer, err)
+	}
+
+	replayed, err := gs.Replay(lobby.ID)
+	if err != nil || len(replayed) != len(events) {
+		t.Fatalf("Expected the replay read from the archive, got %d of %d events, %v", len(replayed), len(events), err)
+	}
+	for i, event := range replayed {
+		if event.Seq != events[i].Seq || event.Type != events[i].Type || !event.Timestamp.Equal(events[i].Timestamp) {
+			t.Fatalf("Event %d: got %s %d, want %s %d", i, event.Type, event.Seq, events[i].Type, events[i].Seq)
+		}
+	}
+	if after, _ := gs.GameEvents(lobby.ID, 2, 2); len(after) != 2 || after[0].Seq != 3 || after[1].Seq != 4 {
+		t.Fatalf("Expected seqs 3 and 4 after seq 2 from the archive, got %+v", after)
+	}
+
+	delete(store.objects, pointer.Key)
+	if _, err := gs.GameEvents(lobby.ID, 0, 1000); !errors.Is(err, archive.ErrNotFound) {
+		t.Fatalf("Expected a missing archived log reported, got %v", err)
+	}
+}
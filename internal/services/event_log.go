@@ -0,0 +1,143 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/repository"
+)
+
+const (
+	// defaultEventRetention is how long game events are kept unless changed
+	// with SetEventRetention.
+	defaultEventRetention = 7 * 24 * time.Hour
+
+	// Events waiting to be stored. A full queue drops events rather than
+	// stalling the lobby that broadcast them.
+	eventLogQueue = 4096
+	eventLogBatch = 256
+	eventLogFlush = 250 * time.Millisecond
+
+	maxGameEvents = 1000
+)
+
+// gameEventLog stores every lobby-wide event the hub broadcasts, in batches
+// off the lobbies' run loops, as the game's authoritative record. Events
+// reach the queue in each lobby's sequence order and are stored in it.
+type gameEventLog struct {
+	repo   repository.Repository
+	events chan *models.GameEvent
+	synced chan chan struct{}
+}
+
+func newGameEventLog(repo repository.Repository) *gameEventLog {
+	l := &gameEventLog{
+		repo:   repo,
+		events: make(chan *models.GameEvent, eventLogQueue),
+		synced: make(chan chan struct{}),
+	}
+	go l.run()
+	return l
+}
+
+// Record queues an event to be stored; it's called from the lobby's run
+// loop, after the event is stamped.
+func (l *gameEventLog) Record(event *models.GameEvent) {
+	recorded := *event
+	recorded.SkipPlayers = nil
+	select {
+	case l.events <- &recorded:
+	default:
+		log.Printf("ALERT: Game event log full, %s event %d of lobby %s not stored", event.Type, event.Seq, event.LobbyID)
+	}
+}
+
+// sync returns once every event recorded before it was called is stored or
+// has failed to be.
+func (l *gameEventLog) sync() {
+	done := make(chan struct{})
+	l.synced <- done
+	<-done
+}
+
+func (l *gameEventLog) run() {
+	ticker := time.NewTicker(eventLogFlush)
+	defer ticker.Stop()
+
+	batch := make([]*models.GameEvent, 0, eventLogBatch)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := l.repo.SaveGameEvents(batch); err != nil {
+			log.Printf("ERROR: Failed to store %d game event(s): %v", len(batch), err)
+		}
+		batch = make([]*models.GameEvent, 0, eventLogBatch)
+	}
+	for {
+		select {
+		case event := <-l.events:
+			if batch = append(batch, event); len(batch) == eventLogBatch {
+				flush()
+			}
+		case done := <-l.synced:
+			for pending := len(l.events); pending > 0; pending-- {
+				batch = append(batch, <-l.events)
+			}
+			flush()
+			close(done)
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// SetEventRetention sets how long stored game events are kept in the
+// database. Zero or less keeps the current setting.
+func (gs *GameService) SetEventRetention(retention time.Duration) {
+	if retention <= 0 {
+		return
+	}
+	gs.mu.Lock()
+	gs.eventRetention = retention
+	gs.mu.Unlock()
+}
+
+// GameEvents returns the lobby's stored events after afterSeq, up to limit
+// (at most 1000), in the order they were broadcast. Events the lobby has
+// broadcast here are stored first, so the log is current for lobbies
+// hosted on this instance. It outlives the lobby until the retention
+// period ends, or is read back from the archive it was moved to then.
+func (gs *GameService) GameEvents(lobbyID string, afterSeq uint64, limit int) ([]*models.GameEvent, error) {
+	if limit < 1 || limit > maxGameEvents {
+		limit = maxGameEvents
+	}
+	if lobbyHub := gs.hub.GetLobbyHub(lobbyID); lobbyHub != nil {
+		lobbyHub.Sync()
+	}
+	gs.eventLog.sync()
+	return gs.storedEvents(lobbyID, afterSeq, limit)
+}
+
+func (gs *GameService) deleteExpiredEvents() {
+	gs.mu.Lock()
+	retention := gs.eventRetention
+	gs.mu.Unlock()
+	// Logs are archived whole; what's older than the retention period in
+	// lobbies still logging events is deleted
+	archived, err := gs.ArchiveExpiredEvents()
+	if err != nil {
+		log.Printf("Error archiving game events, %d log(s) archived and none deleted: %v", archived, err)
+		return
+	}
+	if archived > 0 {
+		log.Printf("Archived %d game event log(s) idle for %s", archived, retention)
+	}
+	deleted, err := gs.repo.DeleteGameEventsOlderThan(retention)
+	if err != nil {
+		log.Printf("Error cleaning up game events: %v", err)
+	} else if deleted > 0 {
+		log.Printf("Cleaned up %d game event(s) older than %s", deleted, retention)
+	}
+}
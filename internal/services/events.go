@@ -0,0 +1,252 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"buildprize-game/internal/cron"
+	"buildprize-game/internal/i18n"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/repository"
+)
+
+const (
+	// defaultEventLead is how long before each start a recurring event's
+	// lobby opens, unless the event sets its own.
+	defaultEventLead = 60
+	maxEventLead     = 7 * 24 * 60
+	maxEventName     = 100
+
+	// maxOccurrences caps the upcoming starts listed per request.
+	maxOccurrences = 100
+)
+
+// RecurringEventOptions define a recurring event and the lobbies it opens.
+type RecurringEventOptions struct {
+	Name string
+	// Five-field cron expression, e.g. "0 20 * * 5" for Fridays at 8pm,
+	// read in Timezone (UTC by default)
+	Schedule string
+	Timezone string
+	// Minutes before each start its lobby opens; 0 means an hour.
+	LeadMinutes int
+
+	MaxRounds  int
+	MaxPlayers int
+	Topic      string
+	Language   string
+	RSVPQuorum int
+}
+
+// CreateRecurringEvent stores a recurring event. Its lobbies are opened by
+// OpenDueEvents as each start comes within the event's lead.
+func (gs *GameService) CreateRecurringEvent(opts RecurringEventOptions) (*models.RecurringEvent, error) {
+	name := strings.TrimSpace(opts.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxEventName {
+		return nil, fmt.Errorf("%w: the name must be 1 to %d characters", ErrInvalidEvent, maxEventName)
+	}
+	if opts.LeadMinutes < 0 || opts.LeadMinutes > maxEventLead {
+		return nil, fmt.Errorf("%w: lead_minutes must be at most a week", ErrInvalidEvent)
+	}
+	if opts.MaxPlayers != 0 && (opts.MaxPlayers < 2 || opts.MaxPlayers > gs.maxPlayers) {
+		return nil, ErrInvalidMaxPlayers
+	}
+	if opts.RSVPQuorum < 0 || opts.RSVPQuorum > maxInvitees {
+		return nil, ErrInvalidSchedule
+	}
+	event := models.NewRecurringEvent(name, strings.Join(strings.Fields(opts.Schedule), " "))
+	if opts.Timezone != "" {
+		if err := models.ValidateTimezone(opts.Timezone); err != nil {
+			return nil, ErrInvalidTimezone
+		}
+		event.Timezone = opts.Timezone
+	}
+	if opts.Language != "" {
+		language, err := i18n.Resolve(opts.Language)
+		if err != nil {
+			return nil, ErrUnsupportedLanguage
+		}
+		event.Language = language
+	}
+	schedule, err := cron.Parse(event.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+	loc, _ := time.LoadLocation(event.Timezone)
+	if schedule.Next(models.Now(), loc).IsZero() {
+		return nil, fmt.Errorf("%w: the schedule never comes round", ErrInvalidEvent)
+	}
+
+	event.LeadMinutes = opts.LeadMinutes
+	if event.LeadMinutes == 0 {
+		event.LeadMinutes = defaultEventLead
+	}
+	event.MaxRounds = opts.MaxRounds
+	if event.MaxRounds == 0 {
+		event.MaxRounds = 10
+	}
+	event.MaxPlayers = opts.MaxPlayers
+	event.Topic = opts.Topic
+	event.RSVPQuorum = opts.RSVPQuorum
+	if err := gs.repo.SaveRecurringEvent(event); err != nil {
+		return nil, err
+	}
+	log.Printf("Created recurring event %s (%s) on %q in %s", event.Name, event.ID, event.Schedule, event.Timezone)
+	return event, nil
+}
+
+// RecurringEvents lists the stored recurring events, oldest first.
+func (gs *GameService) RecurringEvents() ([]*models.RecurringEvent, error) {
+	return gs.repo.ListRecurringEvents()
+}
+
+// DeleteRecurringEvent stops an event opening lobbies. A lobby it already
+// opened is left to run.
+func (gs *GameService) DeleteRecurringEvent(eventID string) error {
+	err := gs.repo.DeleteRecurringEvent(eventID)
+	if errors.Is(err, repository.ErrEventNotFound) {
+		return ErrEventNotFound
+	}
+	if err == nil {
+		log.Printf("Deleted recurring event %s", eventID)
+	}
+	return err
+}
+
+// UpcomingEvents lists the next limit starts across every recurring event,
+// soonest first, with the lobby of each start that already has one.
+func (gs *GameService) UpcomingEvents(limit int) ([]*models.Occurrence, error) {
+	if limit < 1 || limit > maxOccurrences {
+		limit = maxOccurrences
+	}
+	events, err := gs.repo.ListRecurringEvents()
+	if err != nil {
+		return nil, err
+	}
+
+	now := models.Now()
+	occurrences := make([]*models.Occurrence, 0)
+	for _, event := range events {
+		schedule, loc, err := eventSchedule(event)
+		if err != nil {
+			log.Printf("Skipping recurring event %s: %v", event.ID, err)
+			continue
+		}
+		for next, n := now, 0; n < limit; n++ {
+			if next = schedule.Next(next, loc); next.IsZero() {
+				break
+			}
+			occurrences = append(occurrences, occurrence(event, next))
+		}
+	}
+	sort.SliceStable(occurrences, func(i, j int) bool { return occurrences[i].StartsAt.Before(occurrences[j].StartsAt) })
+	if len(occurrences) > limit {
+		occurrences = occurrences[:limit]
+	}
+	return occurrences, nil
+}
+
+// StartRecurringEvents opens recurring events' lobbies as they come due,
+// checking every interval.
+func (gs *GameService) StartRecurringEvents(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if _, err := gs.OpenDueEvents(); err != nil {
+				log.Printf("Error opening recurring event lobbies: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// OpenDueEvents opens a scheduled lobby for each recurring event whose next
+// start is within its lead and has no lobby yet, and returns them. The
+// lobbies start on their own like any scheduled game. A start is claimed
+// in the repository once its lobby exists, so instances checking at the
+// same time open it once; the loser's lobby is discarded.
+func (gs *GameService) OpenDueEvents() ([]*models.Lobby, error) {
+	events, err := gs.repo.ListRecurringEvents()
+	if err != nil {
+		return nil, err
+	}
+
+	now := models.Now()
+	var opened []*models.Lobby
+	for _, event := range events {
+		schedule, loc, err := eventSchedule(event)
+		if err != nil {
+			log.Printf("Skipping recurring event %s: %v", event.ID, err)
+			continue
+		}
+		startsAt := schedule.Next(now, loc).UTC()
+		if startsAt.IsZero() || startsAt.Sub(now) > time.Duration(event.LeadMinutes)*time.Minute {
+			continue
+		}
+		if event.LastStartsAt != nil && !startsAt.After(*event.LastStartsAt) {
+			continue
+		}
+
+		lobby, err := gs.CreateLobby(LobbyOptions{
+			Name:       event.Name,
+			MaxRounds:  event.MaxRounds,
+			MaxPlayers: event.MaxPlayers,
+			Topic:      event.Topic,
+			Timezone:   event.Timezone,
+			Language:   event.Language,
+			StartsAt:   startsAt,
+			RSVPQuorum: event.RSVPQuorum,
+		})
+		if err != nil {
+			log.Printf("Error opening lobby for recurring event %s: %v", event.ID, err)
+			continue
+		}
+		claimed, err := gs.repo.ClaimRecurringEvent(event.ID, startsAt, lobby.ID)
+		if err != nil || !claimed {
+			if err != nil {
+				log.Printf("Error claiming recurring event %s: %v", event.ID, err)
+			}
+			gs.discardLobby(lobby.ID, "unclaimed")
+			continue
+		}
+		log.Printf("Opened lobby %s for recurring event %s starting at %s", lobby.ID, event.ID, startsAt.Format(time.RFC3339))
+		opened = append(opened, lobby)
+	}
+	return opened, nil
+}
+
+func eventSchedule(event *models.RecurringEvent) (*cron.Schedule, *time.Location, error) {
+	schedule, err := cron.Parse(event.Schedule)
+	if err != nil {
+		return nil, nil, err
+	}
+	loc, err := time.LoadLocation(event.Timezone)
+	if err != nil {
+		return nil, nil, err
+	}
+	return schedule, loc, nil
+}
+
+func occurrence(event *models.RecurringEvent, startsAt time.Time) *models.Occurrence {
+	o := &models.Occurrence{
+		EventID:  event.ID,
+		Name:     event.Name,
+		StartsAt: startsAt.UTC(),
+		Timezone: event.Timezone,
+		Topic:    event.Topic,
+	}
+	if event.LastStartsAt != nil && event.LastStartsAt.Equal(startsAt) {
+		o.LobbyID = event.LastLobbyID
+	}
+	return o
+}
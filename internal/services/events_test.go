@@ -0,0 +1,108 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/cron"
+	"buildprize-game/internal/services"
+)
+
+// Schedules run at the wall-clock times they name in their timezone, with
+// the day fields matching either one when both are set.
+func TestCronSchedules(t *testing.T) {
+	newYork, _ := time.LoadLocation("America/New_York")
+	monday := time.Date(2026, 3, 2, 12, 0, 0, 0, newYork)
+	for _, c := range []struct {
+		expr string
+		want time.Time
+	}{
+		{"0 20 * * 5", time.Date(2026, 3, 6, 20, 0, 0, 0, newYork)},
+		{"*/15 9-17 * * 1-5", time.Date(2026, 3, 2, 12, 15, 0, 0, newYork)},
+		{"30 19 1 * 0", time.Date(2026, 3, 8, 19, 30, 0, 0, newYork)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, newYork)},
+		// 2:30am doesn't happen when the clocks go forward on March 8th
+		{"30 2 * 3 0", time.Date(2026, 3, 15, 2, 30, 0, 0, newYork)},
+	} {
+		schedule, err := cron.Parse(c.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", c.expr, err)
+		}
+		if got := schedule.Next(monday, newYork); !got.Equal(c.want) {
+			t.Errorf("%q: expected %s, got %s", c.expr, c.want, got)
+		}
+	}
+
+	for _, expr := range []string{"", "0 20 * *", "60 * * * *", "0 20 * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := cron.Parse(expr); !errors.Is(err, cron.ErrInvalidSchedule) {
+			t.Errorf("%q: expected ErrInvalidSchedule, got %v", expr, err)
+		}
+	}
+	never, _ := cron.Parse("0 0 30 2 *")
+	if next := never.Next(monday, newYork); !next.IsZero() {
+		t.Errorf("Expected February 30th never to come, got %s", next)
+	}
+}
+
+// A recurring event opens one scheduled lobby per start once the start is
+// within its lead, and lists its upcoming starts with that lobby.
+func TestRecurringEventsOpenLobbies(t *testing.T) {
+	gs, gameHub, _ := newService(t)
+	if _, err := gs.CreateRecurringEvent(services.RecurringEventOptions{Name: "Broken", Schedule: "0 25 * * *"}); !errors.Is(err, services.ErrInvalidEvent) {
+		t.Fatalf("Expected ErrInvalidEvent for a bad schedule, got %v", err)
+	}
+	if _, err := gs.CreateRecurringEvent(services.RecurringEventOptions{Name: "Never", Schedule: "0 0 30 2 *"}); !errors.Is(err, services.ErrInvalidEvent) {
+		t.Fatalf("Expected ErrInvalidEvent for a schedule that never comes, got %v", err)
+	}
+
+	weekly, err := gs.CreateRecurringEvent(services.RecurringEventOptions{Name: "Friday Night Trivia", Schedule: "0 20 * * 5", Timezone: "Europe/London"})
+	if err != nil {
+		t.Fatalf("CreateRecurringEvent: %v", err)
+	}
+	minutely, err := gs.CreateRecurringEvent(services.RecurringEventOptions{Name: "Every minute", Schedule: "* * * * *", MaxRounds: 3, RSVPQuorum: 2})
+	if err != nil {
+		t.Fatalf("CreateRecurringEvent: %v", err)
+	}
+
+	opened, err := gs.OpenDueEvents()
+	if err != nil {
+		t.Fatalf("OpenDueEvents: %v", err)
+	}
+	if len(opened) != 1 || opened[0].Name != "Every minute" || opened[0].StartsAt == nil || opened[0].RSVPQuorum != 2 {
+		t.Fatalf("Expected one scheduled lobby for the minutely event, got %+v", opened)
+	}
+	lobby := opened[0]
+	if gameHub.GetLobbyHub(lobby.ID) == nil {
+		t.Fatal("Expected the opened lobby to be live")
+	}
+	if again, _ := gs.OpenDueEvents(); len(again) != 0 && again[0].StartsAt.Equal(*lobby.StartsAt) {
+		t.Fatalf("Expected the start opened once, got another lobby %s", again[0].ID)
+	}
+
+	upcoming, err := gs.UpcomingEvents(5)
+	if err != nil {
+		t.Fatalf("UpcomingEvents: %v", err)
+	}
+	if len(upcoming) != 5 || upcoming[0].EventID != minutely.ID || upcoming[0].LobbyID != lobby.ID {
+		t.Fatalf("Expected the opened start first with its lobby, got %+v", upcoming[0])
+	}
+	for i := 1; i < len(upcoming); i++ {
+		if upcoming[i].StartsAt.Before(upcoming[i-1].StartsAt) {
+			t.Fatalf("Expected starts soonest first, got %s after %s", upcoming[i].StartsAt, upcoming[i-1].StartsAt)
+		}
+	}
+
+	if err := gs.DeleteRecurringEvent(minutely.ID); err != nil {
+		t.Fatalf("DeleteRecurringEvent: %v", err)
+	}
+	if err := gs.DeleteRecurringEvent(minutely.ID); !errors.Is(err, services.ErrEventNotFound) {
+		t.Fatalf("Expected ErrEventNotFound deleting twice, got %v", err)
+	}
+	upcoming, _ = gs.UpcomingEvents(3)
+	for _, o := range upcoming {
+		if o.EventID != weekly.ID {
+			t.Fatalf("Expected only the weekly event left, got %+v", o)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"time"
+
+	"buildprize-game/internal/models"
+)
+
+// defaultFinalQuestionTime is how long the final round's question stays open
+// unless changed with SetFinalQuestionTime.
+const defaultFinalQuestionTime = 45 * time.Second
+
+// SetFinalQuestionTime sets how long the final round's question stays open.
+// Lobbies whose own question time is longer keep theirs. Zero or less is
+// ignored.
+func (gs *GameService) SetFinalQuestionTime(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	gs.mu.Lock()
+	gs.finalQuestionTime = d
+	gs.mu.Unlock()
+}
+
+// questionDuration is how long the lobby's current round's question stays
+// open: the extended final question time in the final round, the lobby's
+// question time otherwise. The caller holds the lobby lock.
+func (gs *GameService) questionDuration(lobby *models.Lobby) time.Duration {
+	duration := lobby.QuestionDuration()
+	if !lobby.IsFinalRound(lobby.Round) {
+		return duration
+	}
+	gs.mu.Lock()
+	final := gs.finalQuestionTime
+	gs.mu.Unlock()
+	if final > duration {
+		return final
+	}
+	return duration
+}
+
+// recordFinalAnswer holds back a final round answer for the round's results.
+// The caller holds the lobby lock.
+func recordFinalAnswer(lobby *models.Lobby, player *models.Player, answer models.SubmittedAnswer, correct bool, score int) {
+	if lobby.FinalAnswers == nil {
+		lobby.FinalAnswers = make(map[string]*models.FinalAnswer)
+	}
+	lobby.FinalAnswers[player.ID] = &models.FinalAnswer{
+		PlayerID: player.ID,
+		Username: player.Username,
+		Answer:   answer,
+		Correct:  correct,
+		Score:    score,
+	}
+}
+
+// revealFinalAnswers returns the final round's answers in seating order and
+// clears them. The caller holds the lobby lock.
+func revealFinalAnswers(lobby *models.Lobby) []*models.FinalAnswer {
+	answers := make([]*models.FinalAnswer, 0, len(lobby.FinalAnswers))
+	for _, player := range lobby.Players {
+		if answer := lobby.FinalAnswers[player.ID]; answer != nil {
+			answers = append(answers, answer)
+		}
+	}
+	lobby.FinalAnswers = nil
+	return answers
+}
@@ -0,0 +1,83 @@
+package services_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"buildprize-game/internal/game"
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// The final round's stakes and answers stay hidden from the lobby until its
+// results reveal them, and its question runs on the extended timer.
+func TestFinalRound(t *testing.T) {
+	gs, gameHub, _ := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Final", MaxRounds: 1, MaxPlayers: 4, FinalWager: true})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	_, alice, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"})
+	_, bob, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "bob"})
+	watcher := &hub.Client{ID: "watcher", LobbyID: lobby.ID, Send: make(chan []byte, 256)}
+	gameHub.GetLobbyHub(lobby.ID).Register(watcher)
+
+	current := gameHub.GetLobbyHub(lobby.ID).GetLobby()
+	current.Lock()
+	current.GetPlayer(alice.ID).Score = 100
+	current.GetPlayer(bob.ID).Score = 100
+	current.Unlock()
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	waitFor(t, phaseIs(current, game.Wager))
+	if _, err := gs.PlaceWager(lobby.ID, alice.ID, 40); err != nil {
+		t.Fatalf("PlaceWager: %v", err)
+	}
+	if _, err := gs.PlaceWager(lobby.ID, bob.ID, 100); err != nil {
+		t.Fatalf("PlaceWager: %v", err)
+	}
+
+	waitFor(t, phaseIs(current, game.Question))
+	current.Lock()
+	question := current.CurrentQ
+	right, wrong := correctAnswer(question), wrongAnswer(question)
+	current.Unlock()
+	gs.SubmitAnswer(lobby.ID, alice.ID, right)
+	gs.SubmitAnswer(lobby.ID, bob.ID, wrong)
+	waitFor(t, phaseIs(current, game.Results))
+
+	var results map[string]interface{}
+	for results == nil {
+		var event models.GameEvent
+		json.Unmarshal(<-watcher.Send, &event)
+		data, _ := event.Data.(map[string]interface{})
+		switch event.Type {
+		case "wager_placed", "wager_closed", "answer_received":
+			if data["stake"] != nil || data["wagers"] != nil || data["score"] != nil {
+				t.Fatalf("Expected %s to give nothing away, got %v", event.Type, data)
+			}
+		case "new_question":
+			if data["final"] != true || data["time_left"].(float64) != 45 {
+				t.Fatalf("Expected the final question on the extended timer, got %v", data)
+			}
+		case "question_results":
+			results = data
+		}
+	}
+
+	answers, _ := results["answers"].([]interface{})
+	wagers, _ := results["wagers"].([]interface{})
+	if results["final"] != true || len(answers) != 2 || len(wagers) != 2 {
+		t.Fatalf("Expected the results to reveal both answers and wagers, got %v", results)
+	}
+	first := answers[0].(map[string]interface{})
+	current.Lock()
+	score := current.GetPlayer(alice.ID).Score
+	current.Unlock()
+	if want := 100 + int(first["score"].(float64)) + closestBonus(question) + 40; first["player_id"] != alice.ID || first["correct"] != true || score != want {
+		t.Fatalf("Expected alice's revealed answer and won stake to total %d, got %d and %v", want, score, first)
+	}
+	gs.ForceEndGame(lobby.ID)
+}
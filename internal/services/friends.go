@@ -0,0 +1,241 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"sort"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/repository"
+)
+
+// AddFriend asks the account named username to be userID's friend, or
+// accepts their request if they already asked. They're told with
+// friend_request or friend_accepted if they're online. It returns the
+// friendship as userID sees it.
+func (gs *GameService) AddFriend(userID, username string) (*models.Friend, error) {
+	user, err := gs.User(userID)
+	if err != nil {
+		return nil, err
+	}
+	other, err := gs.repo.GetUserByUsername(username)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if other.ID == user.ID {
+		return nil, ErrCannotFriendSelf
+	}
+
+	gs.friendsMu.Lock()
+	defer gs.friendsMu.Unlock()
+	friendship, err := gs.repo.GetFriendship(user.ID, other.ID)
+	switch {
+	case errors.Is(err, repository.ErrFriendshipNotFound):
+		friendship = &models.Friendship{
+			RequesterID: user.ID,
+			AddresseeID: other.ID,
+			Status:      models.FriendRequested,
+			CreatedAt:   models.Now(),
+		}
+	case err != nil:
+		return nil, err
+	case friendship.Status == models.FriendAccepted:
+		return nil, ErrAlreadyFriends
+	case friendship.RequesterID == user.ID:
+		return nil, ErrFriendRequestSent
+	default:
+		now := models.Now()
+		friendship.Status = models.FriendAccepted
+		friendship.AcceptedAt = &now
+	}
+	if err := gs.repo.SaveFriendship(friendship); err != nil {
+		return nil, err
+	}
+
+	eventType := "friend_request"
+	if friendship.Status == models.FriendAccepted {
+		eventType = "friend_accepted"
+		log.Printf("Accounts %s and %s are now friends", other.ID, user.ID)
+	}
+	gs.sendToUser(other.ID, eventType, map[string]interface{}{"friend": gs.friendView(friendship, other.ID, user)})
+	return gs.friendView(friendship, user.ID, other), nil
+}
+
+// RemoveFriend ends a friendship, or withdraws or declines a request.
+func (gs *GameService) RemoveFriend(userID, otherID string) error {
+	gs.friendsMu.Lock()
+	defer gs.friendsMu.Unlock()
+	_, err := gs.repo.GetFriendship(userID, otherID)
+	if errors.Is(err, repository.ErrFriendshipNotFound) {
+		return ErrNotFriends
+	}
+	if err != nil {
+		return err
+	}
+	return gs.repo.DeleteFriendship(userID, otherID)
+}
+
+// Friends lists an account's friends and requests: friends online first,
+// then the rest of its friends, then requests waiting on it, then those it
+// sent, each by username.
+func (gs *GameService) Friends(userID string) ([]*models.Friend, error) {
+	friendships, err := gs.repo.ListFriendships(userID)
+	if err != nil {
+		return nil, err
+	}
+	friends := make([]*models.Friend, 0, len(friendships))
+	for _, friendship := range friendships {
+		other, err := gs.User(friendship.Other(userID))
+		if errors.Is(err, ErrUserNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		friends = append(friends, gs.friendView(friendship, userID, other))
+	}
+	order := func(f *models.Friend) int {
+		switch {
+		case f.Online:
+			return 0
+		case f.Status == models.FriendAccepted:
+			return 1
+		case f.Incoming:
+			return 2
+		}
+		return 3
+	}
+	sort.Slice(friends, func(i, j int) bool {
+		if order(friends[i]) != order(friends[j]) {
+			return order(friends[i]) < order(friends[j])
+		}
+		return friends[i].Username < friends[j].Username
+	})
+	return friends, nil
+}
+
+// FriendsOnline lists an account's friends with a connection open.
+func (gs *GameService) FriendsOnline(userID string) ([]*models.Friend, error) {
+	friends, err := gs.Friends(userID)
+	if err != nil {
+		return nil, err
+	}
+	online := make([]*models.Friend, 0)
+	for _, friend := range friends {
+		if friend.Online {
+			online = append(online, friend)
+		}
+	}
+	return online, nil
+}
+
+// InviteFriend sends a friend who's online a lobby_invite to a lobby the
+// account is playing in, with a link to join it.
+func (gs *GameService) InviteFriend(userID, friendID, lobbyID string) error {
+	user, err := gs.User(userID)
+	if err != nil {
+		return err
+	}
+	friendship, err := gs.repo.GetFriendship(userID, friendID)
+	if errors.Is(err, repository.ErrFriendshipNotFound) || (err == nil && friendship.Status != models.FriendAccepted) {
+		return ErrNotFriends
+	}
+	if err != nil {
+		return err
+	}
+
+	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
+	if lobbyHub == nil {
+		return ErrLobbyNotFound
+	}
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	seated := false
+	for _, player := range lobby.Players {
+		seated = seated || player.UserID == userID
+	}
+	finished, name := lobby.State == models.Finished, lobby.Name
+	lobby.Unlock()
+	switch {
+	case finished:
+		return ErrLobbyNotFound
+	case !seated:
+		return ErrNotInLobby
+	}
+
+	delivered := gs.sendToUser(friendID, "lobby_invite", map[string]interface{}{
+		"from":       map[string]string{"user_id": user.ID, "username": user.Username},
+		"lobby_id":   lobbyID,
+		"lobby_name": name,
+		"link":       "/?join=" + lobbyID,
+	})
+	if !delivered {
+		return ErrFriendOffline
+	}
+	log.Printf("Account %s invited friend %s to lobby %s", userID, friendID, lobbyID)
+	return nil
+}
+
+// UserConnected counts a logged-in connection towards its account being
+// online, telling its friends with friend_online when it's the first.
+func (gs *GameService) UserConnected(client *hub.Client) {
+	if gs.hub.ConnectUser(client) {
+		gs.announcePresence(client.UserID, "friend_online")
+	}
+}
+
+// UserDisconnected drops a logged-in connection, telling the account's
+// friends with friend_offline when it was the last.
+func (gs *GameService) UserDisconnected(client *hub.Client) {
+	if gs.hub.DisconnectUser(client) {
+		gs.announcePresence(client.UserID, "friend_offline")
+	}
+}
+
+func (gs *GameService) announcePresence(userID, eventType string) {
+	user, err := gs.User(userID)
+	if err != nil {
+		return
+	}
+	friendships, err := gs.repo.ListFriendships(userID)
+	if err != nil {
+		log.Printf("Error listing friends of %s to announce %s: %v", userID, eventType, err)
+		return
+	}
+	for _, friendship := range friendships {
+		if friendship.Status == models.FriendAccepted {
+			gs.sendToUser(friendship.Other(userID), eventType, map[string]interface{}{
+				"user_id":  user.ID,
+				"username": user.Username,
+			})
+		}
+	}
+}
+
+// friendView shows a friendship from userID's side, with other on the far
+// end of it.
+func (gs *GameService) friendView(friendship *models.Friendship, userID string, other *models.User) *models.Friend {
+	friend := &models.Friend{
+		UserID:   other.ID,
+		Username: other.Username,
+		Status:   friendship.Status,
+		Since:    friendship.CreatedAt,
+	}
+	if friendship.Status == models.FriendAccepted {
+		friend.Online = gs.hub.UserOnline(other.ID)
+		if friendship.AcceptedAt != nil {
+			friend.Since = *friendship.AcceptedAt
+		}
+	} else {
+		friend.Incoming = friendship.AddresseeID == userID
+	}
+	return friend
+}
+
+func (gs *GameService) sendToUser(userID, eventType string, data interface{}) bool {
+	return gs.hub.SendToUser(userID, &models.GameEvent{Type: eventType, Data: data})
+}
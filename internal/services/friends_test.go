@@ -0,0 +1,111 @@
+package services_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// Friend requests are accepted by asking back; friends see each other come
+// online and go offline, and can be invited to a lobby while online.
+func TestFriends(t *testing.T) {
+	gs, _, _ := newService(t)
+	alice, _ := gs.Register("alice", "correct horse")
+	bob, _ := gs.Register("bob", "battery staple")
+	carol, _ := gs.Register("carol", "correct horse")
+
+	if _, err := gs.AddFriend(alice.ID, "ALICE"); !errors.Is(err, services.ErrCannotFriendSelf) {
+		t.Fatalf("Expected ErrCannotFriendSelf, got %v", err)
+	}
+	if _, err := gs.AddFriend(alice.ID, "nobody"); !errors.Is(err, services.ErrUserNotFound) {
+		t.Fatalf("Expected ErrUserNotFound, got %v", err)
+	}
+
+	bobConn := &hub.Client{ID: "bob-conn", UserID: bob.ID, Send: make(chan []byte, 16)}
+	gs.UserConnected(bobConn)
+	requested, err := gs.AddFriend(alice.ID, "bob")
+	if err != nil || requested.Status != models.FriendRequested || requested.Incoming {
+		t.Fatalf("Expected an outgoing request, got %+v (%v)", requested, err)
+	}
+	waitForEvent(t, bobConn, "friend_request")
+	if _, err := gs.AddFriend(alice.ID, "bob"); !errors.Is(err, services.ErrFriendRequestSent) {
+		t.Fatalf("Expected ErrFriendRequestSent, got %v", err)
+	}
+	if friends, _ := gs.Friends(bob.ID); len(friends) != 1 || !friends[0].Incoming {
+		t.Fatalf("Expected bob to see an incoming request, got %+v", friends)
+	}
+
+	accepted, err := gs.AddFriend(bob.ID, "alice")
+	if err != nil || accepted.Status != models.FriendAccepted {
+		t.Fatalf("Expected asking back to accept, got %+v (%v)", accepted, err)
+	}
+	if _, err := gs.AddFriend(alice.ID, "bob"); !errors.Is(err, services.ErrAlreadyFriends) {
+		t.Fatalf("Expected ErrAlreadyFriends, got %v", err)
+	}
+	online, _ := gs.FriendsOnline(alice.ID)
+	if len(online) != 1 || online[0].UserID != bob.ID {
+		t.Fatalf("Expected bob online, got %+v", online)
+	}
+
+	aliceConn := &hub.Client{ID: "alice-conn", UserID: alice.ID, Send: make(chan []byte, 16)}
+	gs.UserConnected(aliceConn)
+	waitForEvent(t, bobConn, "friend_online")
+
+	lobby, _ := gs.CreateLobby(services.LobbyOptions{Name: "Friends", MaxRounds: 3, MaxPlayers: 4})
+	if err := gs.InviteFriend(alice.ID, bob.ID, lobby.ID); !errors.Is(err, services.ErrNotInLobby) {
+		t.Fatalf("Expected ErrNotInLobby before joining, got %v", err)
+	}
+	gs.JoinLobby(lobby.ID, services.JoinOptions{User: alice})
+	if err := gs.InviteFriend(alice.ID, carol.ID, lobby.ID); !errors.Is(err, services.ErrNotFriends) {
+		t.Fatalf("Expected ErrNotFriends inviting a stranger, got %v", err)
+	}
+	if err := gs.InviteFriend(alice.ID, bob.ID, lobby.ID); err != nil {
+		t.Fatalf("InviteFriend: %v", err)
+	}
+	invite := nextEvent(t, bobConn, "lobby_invite")
+	if invite["lobby_id"] != lobby.ID || invite["link"] != "/?join="+lobby.ID {
+		t.Fatalf("Expected an invite to %s with a link, got %v", lobby.ID, invite)
+	}
+
+	gs.UserDisconnected(bobConn)
+	waitForEvent(t, aliceConn, "friend_offline")
+	if err := gs.InviteFriend(alice.ID, bob.ID, lobby.ID); !errors.Is(err, services.ErrFriendOffline) {
+		t.Fatalf("Expected ErrFriendOffline, got %v", err)
+	}
+
+	if err := gs.RemoveFriend(bob.ID, alice.ID); err != nil {
+		t.Fatalf("RemoveFriend: %v", err)
+	}
+	if friends, _ := gs.Friends(alice.ID); len(friends) != 0 {
+		t.Fatalf("Expected no friends left, got %+v", friends)
+	}
+	if err := gs.RemoveFriend(bob.ID, alice.ID); !errors.Is(err, services.ErrNotFriends) {
+		t.Fatalf("Expected ErrNotFriends, got %v", err)
+	}
+}
+
+// nextEvent waits for an event of the given type and returns its data.
+func nextEvent(t *testing.T, client *hub.Client, eventType string) map[string]interface{} {
+	t.Helper()
+	deadline := time.After(2 * time.Second)
+	for {
+		select {
+		case payload := <-client.Send:
+			var event struct {
+				Type string                 `json:"type"`
+				Data map[string]interface{} `json:"data"`
+			}
+			json.Unmarshal(payload, &event)
+			if event.Type == eventType {
+				return event.Data
+			}
+		case <-deadline:
+			t.Fatalf("%s never arrived", eventType)
+		}
+	}
+}
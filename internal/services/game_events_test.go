@@ -0,0 +1,127 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/services"
+)
+
+// Every lobby-wide event is stored in broadcast order, gapless from seq 1,
+// and outlives the lobby until the retention period ends.
+func TestGameEventLog(t *testing.T) {
+	gs, _, repo := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Recorded", MaxRounds: 1, MaxPlayers: 4})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"})
+	gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "bob"})
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	if err := gs.ForceEndGame(lobby.ID); err != nil {
+		t.Fatalf("ForceEndGame: %v", err)
+	}
+
+	events, err := gs.GameEvents(lobby.ID, 0, 1000)
+	if err != nil {
+		t.Fatalf("GameEvents: %v", err)
+	}
+	seen := make(map[string]bool)
+	for i, event := range events {
+		if event.Seq != uint64(i+1) || event.LobbyID != lobby.ID {
+			t.Fatalf("Expected event %d to be seq %d of %s, got seq %d of %s", i, i+1, lobby.ID, event.Seq, event.LobbyID)
+		}
+		seen[event.Type] = true
+	}
+	for _, want := range []string{"player_joined", "game_started", "game_ended"} {
+		if !seen[want] {
+			t.Errorf("Expected a stored %s event, got %d events", want, len(events))
+		}
+	}
+
+	after, _ := gs.GameEvents(lobby.ID, 2, 2)
+	if len(after) != 2 || after[0].Seq != 3 || after[1].Seq != 4 {
+		t.Fatalf("Expected seqs 3 and 4 after seq 2, got %+v", after)
+	}
+
+	if err := repo.DeleteLobby(lobby.ID); err != nil {
+		t.Fatalf("DeleteLobby: %v", err)
+	}
+	if kept, _ := gs.GameEvents(lobby.ID, 0, 1000); len(kept) != len(events) {
+		t.Fatalf("Expected the log kept past its lobby, got %d of %d events", len(kept), len(events))
+	}
+	time.Sleep(5 * time.Millisecond)
+	if deleted, _ := repo.DeleteGameEventsOlderThan(time.Millisecond); deleted != len(events) {
+		t.Fatalf("Expected every event past retention deleted, deleted %d of %d", deleted, len(events))
+	}
+}
+
+// A game's replay is its event log, served once the game is over.
+func TestGameReplay(t *testing.T) {
+	gs, _, _ := newService(t)
+	if _, err := gs.Replay("missing"); !errors.Is(err, services.ErrReplayNotFound) {
+		t.Fatalf("Expected ErrReplayNotFound for an unknown game, got %v", err)
+	}
+
+	lobby, _ := gs.CreateLobby(services.LobbyOptions{Name: "Replayed", MaxRounds: 1, MaxPlayers: 4})
+	gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"})
+	gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "bob"})
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	if _, err := gs.Replay(lobby.ID); !errors.Is(err, services.ErrGameNotFinished) {
+		t.Fatalf("Expected ErrGameNotFinished while the game runs, got %v", err)
+	}
+
+	gs.ForceEndGame(lobby.ID)
+	events, err := gs.Replay(lobby.ID)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if events[0].Seq != 1 || events[len(events)-1].Type != "game_ended" {
+		t.Fatalf("Expected the replay from seq 1 to game_ended, got %s at seq %d", events[len(events)-1].Type, events[0].Seq)
+	}
+}
+
+// A long poll returns waiting events straight away, otherwise the next one
+// broadcast, or nothing once it times out or the client goes.
+func TestPollEvents(t *testing.T) {
+	gs, _, _ := newService(t)
+	if _, _, err := gs.PollEvents("missing", 0, time.Second, nil); !errors.Is(err, services.ErrLobbyNotFound) {
+		t.Fatalf("Expected ErrLobbyNotFound, got %v", err)
+	}
+	lobby, _ := gs.CreateLobby(services.LobbyOptions{Name: "Polled", MaxRounds: 1, MaxPlayers: 4})
+	gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"})
+
+	events, seq, err := gs.PollEvents(lobby.ID, 0, time.Second, nil)
+	if err != nil || len(events) != 1 || seq != 1 {
+		t.Fatalf("Expected alice's join at once, got %d events, seq %d, %v", len(events), seq, err)
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "bob"})
+	}()
+	started := time.Now()
+	events, seq, _ = gs.PollEvents(lobby.ID, 1, 5*time.Second, nil)
+	if len(events) != 1 || events[0].Type != "player_joined" || seq != 2 {
+		t.Fatalf("Expected bob's join as seq 2, got %d events, seq %d", len(events), seq)
+	}
+	if waited := time.Since(started); waited > 2*time.Second {
+		t.Fatalf("Expected the poll to return as bob joined, waited %s", waited)
+	}
+
+	if events, seq, _ := gs.PollEvents(lobby.ID, 2, 20*time.Millisecond, nil); len(events) != 0 || seq != 2 {
+		t.Fatalf("Expected an empty poll at seq 2 on timeout, got %d events, seq %d", len(events), seq)
+	}
+	done := make(chan struct{})
+	close(done)
+	started = time.Now()
+	gs.PollEvents(lobby.ID, 2, 5*time.Second, done)
+	if waited := time.Since(started); waited > time.Second {
+		t.Fatalf("Expected the poll to give up once done, waited %s", waited)
+	}
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// gameLoop is a running game: its pending step (the end of the open question
+// or the next question) and a context that stops everything the game spawned
+// once the lobby is removed or the game is force-ended.
+type gameLoop struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	timer  *time.Timer // nil while paused
+	fireAt time.Time
+}
+
+// stoppedContext is handed out for lobbies without a running game.
+var stoppedContext = func() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}()
+
+// startGameLoop begins a lobby's game loop, stopping any previous one.
+func (gs *GameService) startGameLoop(lobbyID string) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	if loop := gs.loops[lobbyID]; loop != nil {
+		loop.stop()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	gs.loops[lobbyID] = &gameLoop{ctx: ctx, cancel: cancel}
+}
+
+// stopGameLoop cancels the lobby's pending step and every goroutine
+// started under its context. It is safe to call for lobbies without a game.
+func (gs *GameService) stopGameLoop(lobbyID string) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	if loop := gs.loops[lobbyID]; loop != nil {
+		loop.stop()
+		delete(gs.loops, lobbyID)
+		log.Printf("Stopped game loop for lobby %s", lobbyID)
+	}
+}
+
+func (l *gameLoop) stop() {
+	l.cancel()
+	if l.timer != nil {
+		l.timer.Stop()
+	}
+}
+
+// gameContext returns the context of the lobby's running game, or an
+// already-canceled one if there is none.
+func (gs *GameService) gameContext(lobbyID string) context.Context {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	if loop := gs.loops[lobbyID]; loop != nil {
+		return loop.ctx
+	}
+	return stoppedContext
+}
+
+func (gs *GameService) loopRunning(lobbyID string) bool {
+	return gs.gameContext(lobbyID).Err() == nil
+}
+
+// scheduleRound runs fn after delay as the lobby's next game loop step,
+// replacing any step already pending. Nothing is scheduled once the game
+// loop has stopped.
+func (gs *GameService) scheduleRound(lobbyID string, delay time.Duration, fn func()) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	loop := gs.loops[lobbyID]
+	if loop == nil {
+		return
+	}
+	if loop.timer != nil {
+		loop.timer.Stop()
+	}
+	ctx := loop.ctx
+	loop.timer = time.AfterFunc(delay, func() {
+		if ctx.Err() == nil {
+			fn()
+		}
+	})
+	loop.fireAt = time.Now().Add(delay)
+}
+
+// stopRound cancels the lobby's pending step, keeping the game loop, and
+// returns how long the step had left to run.
+func (gs *GameService) stopRound(lobbyID string) time.Duration {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	loop := gs.loops[lobbyID]
+	if loop == nil || loop.timer == nil {
+		return 0
+	}
+	loop.timer.Stop()
+	loop.timer = nil
+	if remaining := time.Until(loop.fireAt); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// ForceEndGame ends a running game immediately, with the scores as they
+// stand.
+func (gs *GameService) ForceEndGame(lobbyID string) error {
+	return gs.forceEndGame(lobbyID, "")
+}
+
+// HostEndGame is ForceEndGame for the lobby's host.
+func (gs *GameService) HostEndGame(lobbyID, playerID string) error {
+	return gs.forceEndGame(lobbyID, playerID)
+}
+
+// forceEndGame ends a running game, for its host hostID, or for an admin
+// when hostID is empty.
+func (gs *GameService) forceEndGame(lobbyID, hostID string) error {
+	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
+	if lobbyHub == nil {
+		return ErrLobbyNotFound
+	}
+
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+	if hostID != "" && !lobby.IsHost(hostID) {
+		return ErrNotHost
+	}
+	if !lobby.Phase.Running() {
+		return ErrGameNotRunning
+	}
+
+	log.Printf("Force-ending game in lobby %s at round %d", lobbyID, lobby.Round)
+	gs.stopGameLoop(lobbyID)
+	lobby.CurrentQ = nil
+	lobby.QuestionEnd = nil
+	lobby.Paused = false
+	lobby.PausedAt = nil
+	lobby.RemainingMs = 0
+	gs.endGame(lobbyHub)
+	return nil
+}
+
+// CancelGame calls off a running game without a winner: its rounds stop,
+// nothing is recorded for it (no stats, prize results or payouts), and the
+// lobby is sent game_cancelled and then removed.
+func (gs *GameService) CancelGame(lobbyID string) error {
+	return gs.cancelGame(lobbyID, "")
+}
+
+// HostCancelGame is CancelGame for the lobby's host.
+func (gs *GameService) HostCancelGame(lobbyID, playerID string) error {
+	return gs.cancelGame(lobbyID, playerID)
+}
+
+func (gs *GameService) cancelGame(lobbyID, hostID string) error {
+	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
+	if lobbyHub == nil {
+		return ErrLobbyNotFound
+	}
+
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	if hostID != "" && !lobby.IsHost(hostID) {
+		lobby.Unlock()
+		return ErrNotHost
+	}
+	if !lobby.Phase.Running() {
+		lobby.Unlock()
+		return ErrGameNotRunning
+	}
+
+	cancelledBy := "admin"
+	if hostID != "" {
+		cancelledBy = "host"
+	}
+	log.Printf("Cancelling game in lobby %s at round %d (by %s)", lobbyID, lobby.Round, cancelledBy)
+	gs.stopGameLoop(lobbyID)
+	gs.BroadcastLobbyUpdate(lobbyHub, "game_cancelled", map[string]interface{}{
+		"reason":       "cancelled",
+		"cancelled_by": cancelledBy,
+		"round":        lobby.Round,
+	})
+	lobby.Unlock()
+	gs.discardLobby(lobbyID, "cancelled")
+	return nil
+}
+
+// sleepContext waits for d, returning false if ctx is canceled first.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
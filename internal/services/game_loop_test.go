@@ -0,0 +1,89 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// Once the last player leaves, nothing the game scheduled may touch the
+// lobby again: a stray save would resurrect the deleted row.
+func TestGameLoopStopsWhenLobbyEmpties(t *testing.T) {
+	gs, gameHub, repo := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Loop Stop", MaxRounds: 3, MaxPlayers: 4})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	lobbyHub := gameHub.GetLobbyHub(lobby.ID)
+
+	var playerIDs []string
+	for _, name := range []string{"first", "second"} {
+		_, player, err := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: name})
+		if err != nil {
+			t.Fatalf("JoinLobby: %v", err)
+		}
+		playerIDs = append(playerIDs, player.ID)
+	}
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+
+	current := lobbyHub.GetLobby()
+	current.Lock()
+	answer := models.SubmittedAnswer{Choice: 0}
+	switch current.CurrentQ.QuestionType() {
+	case models.FreeText:
+		answer = models.SubmittedAnswer{Text: "an answer"}
+	case models.MultiSelect:
+		answer = models.SubmittedAnswer{Choices: []int{0}}
+	}
+	current.Unlock()
+
+	// Everyone answering ends the round early and schedules the next one
+	for _, playerID := range playerIDs {
+		if err := gs.SubmitAnswer(lobby.ID, playerID, answer); err != nil {
+			t.Fatalf("SubmitAnswer: %v", err)
+		}
+	}
+	for _, playerID := range playerIDs {
+		if err := gs.LeaveLobby(lobby.ID, playerID); err != nil {
+			t.Fatalf("LeaveLobby: %v", err)
+		}
+	}
+
+	time.Sleep(4 * time.Second)
+	if _, err := repo.GetLobby(lobby.ID); err == nil {
+		t.Fatal("Deleted lobby was saved again after its game loop should have stopped")
+	}
+}
+
+func TestForceEndGame(t *testing.T) {
+	gs, gameHub, _ := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Force End", MaxRounds: 5, MaxPlayers: 4})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	for _, name := range []string{"first", "second"} {
+		if _, _, err := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: name}); err != nil {
+			t.Fatalf("JoinLobby: %v", err)
+		}
+	}
+	if err := gs.ForceEndGame(lobby.ID); err != services.ErrGameNotRunning {
+		t.Fatalf("Expected ErrGameNotRunning before the game starts, got %v", err)
+	}
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	if err := gs.ForceEndGame(lobby.ID); err != nil {
+		t.Fatalf("ForceEndGame: %v", err)
+	}
+
+	current := gameHub.GetLobbyHub(lobby.ID).GetLobby()
+	current.Lock()
+	defer current.Unlock()
+	if current.State != models.Finished || current.CurrentQ != nil {
+		t.Fatalf("Expected a finished lobby without a question, got state %s", current.State)
+	}
+}
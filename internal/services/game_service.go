@@ -1,28 +1,127 @@
 package services
 
 import (
-	"encoding/json"
 	"log"
-	"math"
+	"strings"
+	"sync"
 	"time"
 
+	"buildprize-game/internal/api"
+	"buildprize-game/internal/archive"
+	"buildprize-game/internal/game"
 	"buildprize-game/internal/hub"
+	"buildprize-game/internal/i18n"
 	"buildprize-game/internal/models"
+	"buildprize-game/internal/payouts"
 	"buildprize-game/internal/repository"
 )
 
 type GameService struct {
-	hub        *hub.Hub
-	repo       repository.Repository
-	questionDB *QuestionDatabase
+	hub         *hub.Hub
+	repo        repository.Repository
+	questionDB  *QuestionDatabase
+	questionGen *QuestionGenerator
+	prefetcher  *questionPrefetcher
+	maxPlayers  int // global lobby capacity (MAX_LOBBY_SIZE)
+
+	mu      sync.Mutex
+	scripts map[string]map[string][]ScriptedAnswer // lobbyID -> playerID -> remaining answers
+	hooks   []GameHook
+
+	loops   map[string]*gameLoop // lobbyID -> running game
+	warmUps map[string]*warmUp   // lobbyID -> pre-game warm-up
+
+	audiences   map[string]*audience // lobbyID -> audience members and round tally
+	maxAudience int
+
+	answerGrace time.Duration // answers accepted past a question's end time
+
+	chatRetention time.Duration // guarded by mu; how long chat history is kept
+	chatFilter    *chatFilter   // guarded by mu; nil until SetChatModeration
+
+	reportMuteThreshold int           // guarded by mu; reporters that mute a player, 0 never
+	reportMuteDuration  time.Duration // guarded by mu
+
+	webhooks *LobbyWebhooks // guarded by mu; nil refuses lobby webhooks
+
+	disputeWindow time.Duration // guarded by mu; prize results are open to disputes this long
+	resultsMu     sync.Mutex    // serializes changes to stored prize results
+
+	payoutProvider payouts.Provider // guarded by mu; nil leaves claimed prizes to be paid by hand
+	refundsMu      sync.Mutex       // serializes sending entry refunds, so none goes out twice at once
+
+	startGrace time.Duration // guarded by mu; how long a held scheduled start waits before it's cancelled
+
+	friendsMu sync.Mutex // serializes friend requests, so two crossing requests make one friendship
+
+	streakBonuses models.StreakBonuses // guarded by mu
+	streaksMu     sync.Mutex           // serializes account streak updates
+
+	eventLog       *gameEventLog // broadcast events, stored as they happen
+	eventRetention time.Duration // guarded by mu
+	eventArchive   archive.Store // guarded by mu; nil deletes logs past the retention period
+
+	// Guarded by mu; see DeleteAbandonedLobbies
+	abandonedLobbyAge  time.Duration
+	abandonedGameGrace time.Duration
+
+	timerTick time.Duration // guarded by mu; 0 sends no timer_tick events
+	wagerTime time.Duration // guarded by mu
+
+	finalQuestionTime time.Duration // guarded by mu
+
+	sourceMonitor questionSourceMonitor // round-start question failures
+	calibrator    difficultyCalibrator  // difficulty labels changed from live data
+
+	scoringVersion string                           // version new games are scored with
+	scoringConfigs map[string]*models.ScoringConfig // versions looked up so far
 }
 
-func NewGameService(hub *hub.Hub, repo repository.Repository) *GameService {
+func NewGameService(hub *hub.Hub, repo repository.Repository, maxLobbySize int) *GameService {
+	if maxLobbySize < 2 {
+		maxLobbySize = 2
+	}
+
 	gs := &GameService{
 		hub:        hub,
 		repo:       repo,
 		questionDB: NewQuestionDatabase(),
+		maxPlayers: maxLobbySize,
+		scripts:    make(map[string]map[string][]ScriptedAnswer),
+
+		loops:   make(map[string]*gameLoop),
+		warmUps: make(map[string]*warmUp),
+
+		audiences:   make(map[string]*audience),
+		maxAudience: defaultMaxAudienceSize,
+		answerGrace: defaultAnswerGrace,
+
+		chatRetention: defaultChatRetention,
+
+		reportMuteThreshold: defaultReportMuteThreshold,
+		reportMuteDuration:  defaultReportMuteDuration,
+
+		disputeWindow: defaultDisputeWindow,
+
+		startGrace: defaultStartGrace,
+
+		streakBonuses: models.DefaultStreakBonuses,
+
+		eventLog:       newGameEventLog(repo),
+		eventRetention: defaultEventRetention,
+
+		abandonedLobbyAge:  defaultAbandonedLobbyAge,
+		abandonedGameGrace: defaultAbandonedGameGrace,
+
+		timerTick: defaultTimerTick,
+		wagerTime: defaultWagerTime,
+
+		finalQuestionTime: defaultFinalQuestionTime,
+
+		scoringConfigs: make(map[string]*models.ScoringConfig),
 	}
+	gs.loadScoring()
+	hub.SetEventLog(gs.eventLog)
 
 	go gs.startCleanupTask()
 
@@ -41,6 +140,12 @@ func (gs *GameService) startCleanupTask() {
 		} else if deleted > 0 {
 			log.Printf("Cleaned up %d finished game(s) older than 10 minutes", deleted)
 		}
+		if deleted := gs.DeleteAbandonedLobbies(); deleted > 0 {
+			log.Printf("Cleaned up %d abandoned lobbies", deleted)
+		}
+		gs.deleteExpiredChat()
+		gs.deleteExpiredEvents()
+		gs.RefundEntryFees()
 	}
 }
 
@@ -48,44 +153,300 @@ func (gs *GameService) GetRepository() repository.Repository {
 	return gs.repo
 }
 
-func (gs *GameService) CreateLobby(name string, maxRounds int) *models.Lobby {
-	lobby := models.NewLobby(name, maxRounds)
-	gs.hub.CreateLobbyHub(lobby)
+// MaxLobbySize is the largest capacity a lobby may be created with.
+func (gs *GameService) MaxLobbySize() int {
+	return gs.maxPlayers
+}
+
+// SetQuestionGenerator enables generated questions for lobbies created
+// with a topic. Passing nil disables generation.
+func (gs *GameService) SetQuestionGenerator(gen *QuestionGenerator) {
+	gs.questionGen = gen
+	gs.prefetcher = nil
+	if gen != nil {
+		gs.prefetcher = newQuestionPrefetcher(gen)
+	}
+}
+
+// LobbyOptions are the host-supplied settings for a new lobby.
+type LobbyOptions struct {
+	Name      string
+	MaxRounds int
+	Topic     string           // generate questions on this topic when a generator is configured
+	RoundType models.MediaType // serve only picture or audio questions
+
+	// Relative weight per category; AnyCategory matches the whole bank.
+	CategoryWeights map[string]int
+
+	// Player cap for this lobby; 0 means the global MAX_LOBBY_SIZE.
+	MaxPlayers int
+
+	// IANA timezone for scheduled-start displays and daily challenges; defaults to UTC.
+	Timezone string
+
+	// Language clients show server messages in, e.g. "es"; defaults to English.
+	Language string
+
+	// Start the game on its own at StartsAt, e.g. an office game at noon;
+	// zero leaves starting to the host. Invitees are the usernames asked to
+	// RSVP (anyone may when empty), and the start waits until RSVPQuorum of
+	// the players who said yes have joined.
+	StartsAt   time.Time
+	Invitees   []string
+	RSVPQuorum int
+
+	// URL the lobby's lifecycle events are posted to (see LobbyWebhooks)
+	WebhookURL string
+
+	// Awarded to the winner; the results are open to disputes for a while
+	// before the payout is recorded (see FileDispute). Ignored for sandboxes.
+	Prize string
+	// Money shared between the top finishers by its split, claimed with
+	// codes issued once the results are final (see ClaimPrize).
+	PrizePool *models.PrizePool
+	// Charged to every player joining, in the prize pool's currency, and
+	// added to the pool (see StartEntryPayment). Needs a prize pool.
+	EntryFee int64
+
+	// Serve no-stakes warm-up questions while players gather.
+	WarmUp bool
+
+	// Accept audience members whose answers are only aggregated, alongside
+	// the players (the featured players) who compete normally.
+	Audience bool
+	// Top audience scorers named in results; 0 keeps the audience anonymous.
+	AudienceShoutOuts int
+
+	// The lobby's own scoring rules, on top of the active scoring version;
+	// nil scores the game with the active version.
+	Scoring *models.ScoringRules
+
+	// Rounds that open with a wager phase, in which players stake points
+	// on the round's question (see PlaceWager).
+	WagerRounds []int
+	// Make the last round a final round: every player wagers in secret and
+	// the stakes and answers are only revealed with its results.
+	FinalWager bool
+}
+
+func (gs *GameService) CreateLobby(opts LobbyOptions) (*models.Lobby, error) {
+	if err := gs.validateCategoryWeights(opts.CategoryWeights); err != nil {
+		return nil, err
+	}
+	if opts.MaxPlayers != 0 && (opts.MaxPlayers < 2 || opts.MaxPlayers > gs.maxPlayers) {
+		return nil, ErrInvalidMaxPlayers
+	}
+	if err := models.ValidateTimezone(opts.Timezone); err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	invites, err := validateSchedule(opts)
+	if err != nil {
+		return nil, err
+	}
+	webhookURL, err := gs.checkWebhookURL(opts.WebhookURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateWagerRounds(opts.WagerRounds, opts.MaxRounds); err != nil {
+		return nil, err
+	}
+	if opts.PrizePool != nil {
+		if err := opts.PrizePool.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if err := gs.validateEntryFee(opts.EntryFee, opts.PrizePool); err != nil {
+		return nil, err
+	}
+	var scoring *models.ScoringConfig
+	if opts.Scoring != nil {
+		base, err := gs.ScoringConfig(gs.ActiveScoringVersion())
+		if err != nil {
+			return nil, err
+		}
+		if scoring, err = opts.Scoring.Apply(*base); err != nil {
+			return nil, err
+		}
+	}
+	language := i18n.DefaultLanguage
+	if opts.Language != "" {
+		resolved, err := i18n.Resolve(opts.Language)
+		if err != nil {
+			return nil, ErrUnsupportedLanguage
+		}
+		language = resolved
+	}
+
+	lobby := models.NewLobby(opts.Name, opts.MaxRounds)
+	lobby.Topic = opts.Topic
+	lobby.Timezone = models.DefaultTimezone
+	if opts.Timezone != "" {
+		lobby.Timezone = opts.Timezone
+	}
+	lobby.Language = language
+	lobby.RoundType = opts.RoundType
+	lobby.MaxPlayers = gs.maxPlayers
+	if opts.MaxPlayers != 0 {
+		lobby.MaxPlayers = opts.MaxPlayers
+	}
+	if len(opts.CategoryWeights) > 0 {
+		lobby.CategoryWeights = opts.CategoryWeights
+	}
+	lobby.WarmUp = opts.WarmUp
+	lobby.Audience = opts.Audience
+	lobby.WebhookURL = webhookURL
+	lobby.Prize = strings.TrimSpace(opts.Prize)
+	lobby.PrizePool = opts.PrizePool
+	lobby.EntryFee = opts.EntryFee
+	lobby.Scoring = scoring
+	lobby.WagerRounds = opts.WagerRounds
+	lobby.FinalWager = opts.FinalWager
+	if !opts.StartsAt.IsZero() {
+		startsAt := opts.StartsAt.UTC()
+		lobby.StartsAt = &startsAt
+		lobby.Invites = invites
+		lobby.OpenRSVP = len(invites) == 0
+		lobby.RSVPQuorum = opts.RSVPQuorum
+	}
+	lobby.Lock()
+	defer lobby.Unlock()
+	lobbyHub := gs.hub.CreateLobbyHub(lobby)
+	// Topic questions start generating while players gather
+	gs.prefetchQuestions(lobbyHub)
+	if lobby.WarmUp {
+		gs.startWarmUp(lobbyHub)
+	}
+	if lobby.Audience {
+		gs.startAudience(lobby.ID, opts.AudienceShoutOuts)
+	}
+	if lobby.StartsAt != nil {
+		gs.scheduleStart(lobby.ID, time.Until(*lobby.StartsAt))
+	}
 
 	// Save lobby to database
 	if err := gs.repo.SaveLobby(lobby); err != nil {
 		log.Printf("ERROR: Failed to save lobby %s: %v", lobby.ID, err)
 	} else {
-		log.Printf("Created lobby %s with ID %s, State: %s, Players: %d - Saved to database", name, lobby.ID, lobby.State, len(lobby.Players))
+		log.Printf("Created lobby %s with ID %s, State: %s, Players: %d - Saved to database", opts.Name, lobby.ID, lobby.State, len(lobby.Players))
 	}
 
-	return lobby
+	return lobby, nil
 }
 
-func (gs *GameService) JoinLobby(lobbyID, username string) (*models.Lobby, *models.Player, error) {
+// JoinOptions describe a join: who joins, over which connection and with
+// which entry payment.
+type JoinOptions struct {
+	Username string
+	User     *models.User // logged-in account, joining under its username
+	Client   *hub.Client  // WebSocket connection joining; nil over REST
+	// Completed payment of the lobby's entry fee (see StartEntryPayment)
+	PaymentID string
+}
+
+// JoinLobby seats a new player as opts describe, under the normalized form
+// of their username. Names that look like a seated player's (see
+// models.UsernameSkeleton) are refused so nobody can pass as someone else,
+// as are guests taking usernames registered to an account. A logged-in
+// account already seated in the lobby gets its player back. A joining
+// WebSocket connection counts towards the player's presence before the join
+// is announced. Lobbies with an entry fee only seat new players with a
+// completed payment of it.
+func (gs *GameService) JoinLobby(lobbyID string, opts JoinOptions) (*models.Lobby, *models.Player, error) {
+	username, connID := opts.Username, ""
+	if opts.User != nil {
+		username = opts.User.Username
+	}
+	if opts.Client != nil {
+		connID = opts.Client.ID
+	}
+	return gs.joinLobby(lobbyID, username, opts.User, connID, opts.PaymentID)
+}
+
+func (gs *GameService) joinLobby(lobbyID, username string, user *models.User, connID, paymentID string) (*models.Lobby, *models.Player, error) {
 	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
 	if lobbyHub == nil {
 		return nil, nil, ErrLobbyNotFound
 	}
+	username, err := models.NormalizeUsername(username)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil {
+		if err := gs.checkGuestUsername(username); err != nil {
+			return nil, nil, err
+		}
+	}
 
 	lobby := lobbyHub.GetLobby()
-	if len(lobby.Players) >= 8 {
+	lobby.Lock()
+	defer lobby.Unlock()
+	var confirmed *payouts.Payment
+	if lobby.EntryFee > 0 && paymentID != "" {
+		fee, currency := lobby.EntryFee, lobby.PrizePool.Currency
+		// Asks the provider, so without the lock
+		lobby.Unlock()
+		confirmed, err = gs.confirmEntryPayment(lobby.ID, fee, currency, paymentID)
+		lobby.Lock()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if user != nil {
+		if player := lobby.FindUserPlayer(user.ID); player != nil {
+			if connID != "" {
+				lobby.Connect(player.ID, connID, models.Now())
+			}
+			gs.touchLobby(lobbyHub, lobby)
+			return lobby, player, nil
+		}
+	}
+
+	if lobby.IsFull() {
 		return nil, nil, ErrLobbyFull
 	}
 
-	if lobby.State != models.Waiting {
+	if lobby.Phase != game.Waiting {
 		return nil, nil, ErrGameInProgress
 	}
 
+	if lobby.FindConfusablePlayer(username) != nil {
+		return nil, nil, ErrUsernameConfusable
+	}
+	var entry *models.EntryPayment
+	if lobby.EntryFee > 0 {
+		if entry, err = takeEntryPayment(lobby, confirmed); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	player := lobby.AddPlayer(username)
+	if user != nil {
+		player.UserID = user.ID
+	}
+	if entry != nil {
+		lobby.SeatEntryPayment(entry, player)
+	}
+	gs.touchLobby(lobbyHub, lobby)
+	if connID != "" {
+		lobby.Connect(player.ID, connID, models.Now())
+	}
 	gs.repo.SaveLobby(lobby)
 
 	log.Printf("Player %s joined lobby %s, State: %s, Total players: %d", username, lobbyID, lobby.State, len(lobby.Players))
 
+	if lobby.Demo {
+		gs.scheduleDemoStart(lobbyID)
+	}
+	if lobby.StartHeld && lobby.QuorumReached() && lobby.CanStart() {
+		log.Printf("Lobby %s has the players its held scheduled start was waiting for", lobbyID)
+		go gs.startScheduled(lobbyID)
+	}
+
 	// Broadcast player joined
 	gs.BroadcastLobbyUpdate(lobbyHub, "player_joined", map[string]interface{}{
-		"player": player,
-		"lobby":  lobby,
+		"player": api.FromPlayer(player),
+		"lobby":  api.FromLobby(lobby),
 	})
 
 	return lobby, player, nil
@@ -98,8 +459,10 @@ func (gs *GameService) LeaveLobby(lobbyID, playerID string) error {
 	}
 
 	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
 	removed := lobby.RemovePlayer(playerID)
 	if !removed {
+		lobby.Unlock()
 		return ErrPlayerNotFound
 	}
 
@@ -107,17 +470,41 @@ func (gs *GameService) LeaveLobby(lobbyID, playerID string) error {
 
 	gs.BroadcastLobbyUpdate(lobbyHub, "player_left", map[string]interface{}{
 		"player_id": playerID,
-		"lobby":     lobby,
+		"lobby":     api.FromLobby(lobby),
 	})
+	empty := len(lobby.Players) == 0
+	lobby.Unlock()
 
-	if len(lobby.Players) == 0 {
-		gs.hub.RemoveLobbyHub(lobbyID)
-		gs.repo.DeleteLobby(lobbyID)
+	if empty {
+		gs.discardLobby(lobbyID, "empty")
 	}
 
 	return nil
 }
 
+// discardLobby stops everything running for a lobby and deletes it,
+// refunding any entry fees paid for a game that wasn't played out; reason
+// is why it's closing. A lobby whose refunds can't be recorded is left
+// stored, so they're recorded when it's cleaned up instead.
+func (gs *GameService) discardLobby(lobbyID, reason string) {
+	refunds := true
+	if lobbyHub := gs.hub.GetLobbyHub(lobbyID); lobbyHub != nil {
+		lobby := lobbyHub.GetLobby()
+		lobby.Lock()
+		refunds = gs.recordEntryRefunds(lobby, reason)
+		lobby.Unlock()
+	}
+	gs.stopGameLoop(lobbyID)
+	gs.stopWarmUp(lobbyID)
+	gs.dropAudience(lobbyID)
+	gs.cancelPrefetch(lobbyID)
+	gs.clearScripts(lobbyID)
+	gs.hub.RemoveLobbyHub(lobbyID)
+	if refunds {
+		gs.repo.DeleteLobby(lobbyID)
+	}
+}
+
 func (gs *GameService) StartGame(lobbyID string) error {
 	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
 	if lobbyHub == nil {
@@ -125,30 +512,69 @@ func (gs *GameService) StartGame(lobbyID string) error {
 	}
 
 	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
 	if !lobby.CanStart() {
 		return ErrCannotStartGame
 	}
 
-	lobby.StartGame()
+	if len(lobby.CategoryWeights) > 0 {
+		lobby.CategoryPlan = buildCategoryPlan(lobby.CategoryWeights, lobby.MaxRounds)
+	}
+
+	if err := lobby.StartGame(); err != nil {
+		return ErrCannotStartGame
+	}
+	lobby.StartHeld = false
+	gs.stopWarmUp(lobbyID)
+	lobby.ScoringVersion = gs.ActiveScoringVersion()
+	if lobby.Scoring != nil {
+		lobby.ScoringVersion = models.LobbyScoringVersion
+	}
 	gs.repo.SaveLobby(lobby)
+	gs.startGameLoop(lobbyID)
 
 	gs.BroadcastLobbyUpdate(lobbyHub, "game_started", map[string]interface{}{
-		"lobby": lobby,
+		"lobby": api.FromLobby(lobby),
 	})
+	gs.runHooks("OnGameStart", func(h GameHook) { h.OnGameStart(lobby) })
 
 	gs.startNextQuestion(lobbyHub)
 
 	return nil
 }
 
-func (gs *GameService) SubmitAnswer(lobbyID, playerID string, answer int, responseTime int64) error {
+// SubmitAnswer scores a player's answer to the current question. The
+// response time is measured server-side from when the question was shown.
+func (gs *GameService) SubmitAnswer(lobbyID, playerID string, answer models.SubmittedAnswer) error {
+	return gs.SubmitAnswerSentAt(lobbyID, playerID, answer, time.Time{})
+}
+
+// SubmitAnswerSentAt is SubmitAnswer for a client that reported when it sent
+// the answer, in server time; see answerArrival for how far that's trusted.
+func (gs *GameService) SubmitAnswerSentAt(lobbyID, playerID string, answer models.SubmittedAnswer, sentAt time.Time) error {
 	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
 	if lobbyHub == nil {
 		return ErrLobbyNotFound
 	}
 
+	receivedAt := models.Now()
 	lobby := lobbyHub.GetLobby()
-	if !lobby.IsQuestionActive() {
+	lobby.Lock()
+	defer lobby.Unlock()
+	if !lobby.AcceptsAnswersAt(receivedAt, gs.answerGrace) {
+		gs.logRejectedAnswer(lobby, playerID, receivedAt, sentAt)
+		return ErrQuestionNotActive
+	}
+	arrival := gs.judgeArrival(lobby, playerID, receivedAt, sentAt)
+	return gs.submitAnswer(lobbyHub, playerID, answer, lobby.ResponseTimeAt(arrival.effective))
+}
+
+// submitAnswer scores an answer; the caller holds the lobby lock.
+func (gs *GameService) submitAnswer(lobbyHub *hub.LobbyHub, playerID string, answer models.SubmittedAnswer, responseTime int64) error {
+	lobby := lobbyHub.GetLobby()
+	lobbyID := lobby.ID
+	if !lobby.AcceptsAnswersAt(models.Now(), gs.answerGrace) {
 		return ErrQuestionNotActive
 	}
 
@@ -157,10 +583,29 @@ func (gs *GameService) SubmitAnswer(lobbyID, playerID string, answer int, respon
 		return ErrPlayerNotFound
 	}
 
-	score := gs.calculateScore(lobby.CurrentQ, answer, responseTime)
-	player.Score += score
+	if err := lobby.CurrentQ.CheckAnswer(answer); err != nil {
+		return ErrInvalidAnswer
+	}
 
-	if answer == lobby.CurrentQ.Correct {
+	if lobby.HasAnswered(playerID) {
+		return ErrAlreadyAnswered
+	}
+	lobby.MarkAnswered(playerID)
+
+	responseTime, powerUp := applyPowerUp(lobby, playerID, responseTime)
+	correct := lobby.CurrentQ.IsCorrect(answer)
+	position := 0
+	if correct {
+		position = lobby.RecordCorrect(playerID)
+	}
+	scoring := gs.lobbyScoring(lobby)
+	score := powerUp.ApplyScore(scoring.Score(lobby.CurrentQ, answer, responseTime, player.Streak) + scoring.PositionBonus(position))
+	player.Score = models.AddScore(player.Score, score)
+	lobby.TallyAnswer(correct, responseTime)
+	recordWager(lobby, playerID, correct)
+	recordGuess(lobby, playerID, answer)
+
+	if correct {
 		player.Streak++
 	} else {
 		player.Streak = 0
@@ -168,27 +613,75 @@ func (gs *GameService) SubmitAnswer(lobbyID, playerID string, answer int, respon
 
 	gs.repo.SaveLobby(lobby)
 
-	gs.BroadcastLobbyUpdate(lobbyHub, "answer_received", map[string]interface{}{
+	// Sandbox lobbies, test players and bots are kept out of analytics
+	if !lobby.Sandbox && player.IsHuman() {
+		record := &models.AnswerRecord{
+			PlayerID:       player.ID,
+			Username:       player.Username,
+			UserID:         player.UserID,
+			LobbyID:        lobby.ID,
+			Round:          lobby.Round,
+			QuestionID:     lobby.CurrentQ.ID,
+			Category:       lobby.CurrentQ.Category,
+			Question:       lobby.CurrentQ,
+			Answer:         answer,
+			Correct:        correct,
+			Score:          score,
+			ScoringVersion: scoring.Version,
+			ResponseTime:   responseTime,
+			PowerUp:        powerUp,
+			Position:       position,
+			AnsweredAt:     models.Now(),
+		}
+		if err := gs.repo.SaveAnswer(record); err != nil {
+			log.Printf("ERROR: Failed to save answer for player %s in lobby %s: %v", playerID, lobbyID, err)
+		} else if wager := lobby.Wagers[playerID]; wager != nil {
+			wager.AnswerID = record.ID
+		}
+	}
+
+	received := map[string]interface{}{
 		"player_id": playerID,
 		"score":     score,
 		"streak":    player.Streak,
+	}
+	if powerUp != "" {
+		received["power_up"] = powerUp
+	}
+	if bonus := scoring.PositionBonus(position); bonus > 0 {
+		received["position"] = position
+		received["position_bonus"] = bonus
+	}
+	// The final round gives nothing away until its results
+	final := lobby.IsFinalRound(lobby.Round)
+	if final {
+		recordFinalAnswer(lobby, player, answer, correct, score)
+		received = map[string]interface{}{"player_id": playerID}
+	}
+	gs.BroadcastLobbyUpdate(lobbyHub, "answer_received", received)
+	if correct && !final {
+		gs.announceStreak(lobbyHub, player, scoring)
+	}
+	gs.runHooks("OnAnswer", func(h GameHook) { h.OnAnswer(lobby, player, answer, score) })
+
+	// Counts only, so nobody learns what was answered
+	answered, expected := gs.answerProgress(lobbyHub)
+	gs.BroadcastLobbyUpdate(lobbyHub, "answer_progress", map[string]interface{}{
+		"round":    lobby.Round,
+		"answered": answered,
+		"total":    expected,
 	})
 
-	return nil
-}
-
-func (gs *GameService) calculateScore(question *models.Question, answer int, responseTime int64) int {
-	if answer != question.Correct {
-		return 0
+	if expected > 0 && answered == expected {
+		log.Printf("All players answered round %d in lobby %s, ending question early", lobby.Round, lobbyID)
+		go gs.endQuestion(lobbyHub, lobby.Round)
 	}
 
-	baseScore := 100
-	timeBonus := int(math.Max(0, float64(50-(responseTime/1000))))
-	accuracyBonus := 25
-
-	return baseScore + timeBonus + accuracyBonus
+	return nil
 }
 
+// startNextQuestion serves the next round, or ends the game after the last
+// one. The caller holds the lobby lock.
 func (gs *GameService) startNextQuestion(lobbyHub *hub.LobbyHub) {
 	lobby := lobbyHub.GetLobby()
 
@@ -196,80 +689,225 @@ func (gs *GameService) startNextQuestion(lobbyHub *hub.LobbyHub) {
 		gs.endGame(lobbyHub)
 		return
 	}
+	if lobby.IsWagerRound(lobby.Round) && lobby.Phase != game.Wager {
+		gs.openWager(lobbyHub)
+		return
+	}
 
-	question := gs.questionDB.GetRandomQuestion()
-	lobby.SetQuestion(question, 15*time.Second)
+	question := gs.pickQuestion(lobbyHub)
+	if question == nil {
+		// Ending the game beats leaving players waiting on a round that never comes
+		log.Printf("ALERT: no valid question for lobby %s round %d, ending the game", lobby.ID, lobby.Round)
+		gs.sourceMonitor.mu.Lock()
+		gs.sourceMonitor.stats.GamesEnded++
+		gs.sourceMonitor.mu.Unlock()
+		gs.endGame(lobbyHub)
+		return
+	}
+	lobby.RecordCategory(question.Category)
+	duration := gs.questionDuration(lobby)
+	lobby.SetQuestion(question, duration)
+	lobby.TallyRound()
+	gs.advance(lobby, game.Question)
+	gs.openAudienceRound(lobby)
+	gs.prefetchQuestions(lobbyHub)
 
 	gs.repo.SaveLobby(lobby)
 
 	
-	questionEndTimestamp := lobby.QuestionEnd.UnixMilli() 
-	currentServerTime := time.Now().UnixMilli()           
+	questionEndTimestamp := models.FormatTimestamp(*lobby.QuestionEnd)
+	currentServerTime := models.FormatTimestamp(models.Now())
 
-	gs.BroadcastLobbyUpdate(lobbyHub, "new_question", map[string]interface{}{
-		"question":          question,
+	newQuestion := map[string]interface{}{
+		"question":          api.FromQuestion(question),
 		"round":             lobby.Round,
-		"time_left":         15,
+		"time_left":         int(duration.Seconds()),
 		"question_end_time": questionEndTimestamp,
-		"server_time":       currentServerTime,   
-	})
+		"server_time":       currentServerTime,
+	}
+	if lobby.IsFinalRound(lobby.Round) {
+		newQuestion["final"] = true
+	}
+	gs.BroadcastLobbyUpdate(lobbyHub, "new_question", newQuestion)
+
+	ctx := gs.gameContext(lobby.ID)
+	gs.playScriptedAnswers(ctx, lobbyHub)
+	gs.playBotAnswers(ctx, lobbyHub)
 
-	go func() {
-		time.Sleep(15 * time.Second)
-		gs.endQuestion(lobbyHub)
-	}()
+	round := lobby.Round
+	gs.startTimerTicks(ctx, lobbyHub, round)
+	gs.scheduleRound(lobby.ID, duration+gs.answerGrace, func() { gs.endQuestion(lobbyHub, round) })
+}
+
+// answerProgress counts the players expected to answer the current question
+// and how many of them have. Expected players are those with an open
+// connection plus bots and test players, which answer without one. If nobody
+// is connected at all (REST-only clients) every player in the lobby is
+// expected. Audience connections don't count. The caller holds the lobby
+// lock.
+func (gs *GameService) answerProgress(lobbyHub *hub.LobbyHub) (answered, expected int) {
+	lobby := lobbyHub.GetLobby()
+	for _, player := range gs.expectedPlayers(lobbyHub) {
+		expected++
+		if lobby.HasAnswered(player.ID) {
+			answered++
+		}
+	}
+	return answered, expected
 }
 
-func (gs *GameService) endQuestion(lobbyHub *hub.LobbyHub) {
+// expectedPlayers returns the players expected to act on a round, as
+// described for answerProgress. The caller holds the lobby lock.
+func (gs *GameService) expectedPlayers(lobbyHub *hub.LobbyHub) []*models.Player {
 	lobby := lobbyHub.GetLobby()
 
-	leaderboard := gs.calculateLeaderboard(lobby)
+	connected := make(map[string]bool)
+	for _, client := range lobbyHub.GetClients() {
+		if client.PlayerID != "" && lobby.GetPlayer(client.PlayerID) != nil {
+			connected[client.PlayerID] = true
+		}
+	}
 
-	gs.BroadcastLobbyUpdate(lobbyHub, "question_results", map[string]interface{}{
+	var expected []*models.Player
+	for _, player := range lobby.Players {
+		if len(connected) > 0 && !connected[player.ID] && player.IsHuman() {
+			continue
+		}
+		expected = append(expected, player)
+	}
+	return expected
+}
+
+// endQuestion closes a round and shows its results. Leaving the question
+// phase is what stops further answers, so only the first of the round timer
+// and the last answer to arrive gets past the phase check.
+func (gs *GameService) endQuestion(lobbyHub *hub.LobbyHub, round int) {
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+	// A paused round is rescheduled on resume; a stopped game is over
+	if lobby.Phase != game.Question || lobby.Round != round || lobby.Paused || !gs.loopRunning(lobby.ID) {
+		return
+	}
+
+	closest := awardClosestBonuses(lobby)
+	wagers := gs.settleWagers(lobby)
+	leaderboard := gs.Leaderboard(lobby)
+
+	results := map[string]interface{}{
 		"correct_answer": lobby.CurrentQ.Correct,
-		"leaderboard":    leaderboard,
+		"leaderboard":    api.FromPlayers(leaderboard),
 		"round":          lobby.Round,
-	})
+	}
+	switch lobby.CurrentQ.QuestionType() {
+	case models.MultiSelect:
+		results["correct_answers"] = lobby.CurrentQ.CorrectAnswers
+	case models.FreeText:
+		results["accepted_answers"] = lobby.CurrentQ.AcceptedAnswers
+	case models.Numeric:
+		results["numeric_answer"] = lobby.CurrentQ.NumericAnswer
+		results["closest"] = closest
+	}
+	if summary := gs.closeAudienceRound(lobby); summary != nil {
+		results["audience"] = summary
+	}
+	if wagers != nil {
+		results["wagers"] = wagers
+	}
+	if uses := powerUpUses(lobby); uses != nil {
+		results["power_ups"] = uses
+	}
+	if lobby.IsFinalRound(lobby.Round) {
+		results["final"] = true
+		results["answers"] = revealFinalAnswers(lobby)
+	}
 
 	lobby.CurrentQ = nil
 	lobby.QuestionEnd = nil
 	lobby.NextRound()
+	gs.advance(lobby, game.Results)
+	gs.BroadcastLobbyUpdate(lobbyHub, "question_results", results)
 
 	gs.repo.SaveLobby(lobby)
 
-	time.Sleep(3 * time.Second)
+	next := lobby.Round
+	gs.scheduleRound(lobby.ID, 3*time.Second, func() { gs.nextQuestion(lobbyHub, next) })
+}
+
+// nextQuestion runs after the results have been shown.
+func (gs *GameService) nextQuestion(lobbyHub *hub.LobbyHub, round int) {
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+	if lobby.Phase != game.Results || lobby.Round != round || lobby.Paused || !gs.loopRunning(lobby.ID) {
+		return
+	}
+	if lobby.Round > lobby.MaxRounds {
+		gs.endGame(lobbyHub)
+		return
+	}
+	gs.advance(lobby, game.Intermission)
 	gs.startNextQuestion(lobbyHub)
 }
 
+// advance moves the lobby to the next phase. The callers only ask for moves
+// the lifecycle allows, so a failure is a bug worth logging loudly.
+func (gs *GameService) advance(lobby *models.Lobby, to game.Phase) {
+	if err := lobby.Advance(to); err != nil {
+		log.Printf("ERROR: Lobby %s: %v", lobby.ID, err)
+	}
+}
+
+// endGame finishes the game; the caller holds the lobby lock.
 func (gs *GameService) endGame(lobbyHub *hub.LobbyHub) {
 	lobby := lobbyHub.GetLobby()
-	lobby.State = models.Finished
+	gs.advance(lobby, game.Finished)
 
 	// Set finished timestamp for cleanup tracking
-	now := time.Now()
+	now := models.Now()
 	lobby.FinishedAt = &now
 
-	leaderboard := gs.calculateLeaderboard(lobby)
+	leaderboard := gs.Leaderboard(lobby)
+	lobby.Stats = lobby.GameStats()
 
 	eventData := map[string]interface{}{
-		"final_leaderboard": leaderboard,
+		"final_leaderboard": api.FromPlayers(leaderboard),
+		"category_mix":      lobby.CategoryMix,
+		"stats":             lobby.Stats,
+	}
+	if lobby.Audience {
+		eventData["audience_top_scorers"] = gs.audienceLeaderboard(lobby.ID)
+	}
+	if result := gs.recordPrizeResult(lobby, leaderboard); result != nil {
+		eventData["results"] = result.Status
+		eventData["dispute_until"] = models.FormatTimestamp(result.DisputeUntil)
 	}
+	streakMilestones := gs.recordPlayerStats(lobby, leaderboard)
 
 	// Only set winner if there's at least one player
 	if len(leaderboard) > 0 {
-		eventData["winner"] = leaderboard[0]
+		eventData["winner"] = api.FromPlayer(leaderboard[0])
 	} else {
 		log.Printf("WARNING: Game ended with no players in lobby %s", lobby.ID)
 		eventData["winner"] = nil
 	}
 
 	gs.BroadcastLobbyUpdate(lobbyHub, "game_ended", eventData)
+	for _, milestone := range streakMilestones {
+		gs.BroadcastLobbyUpdate(lobbyHub, "account_streak_milestone", milestone)
+	}
+	gs.runHooks("OnGameEnd", func(h GameHook) { h.OnGameEnd(lobby, leaderboard) })
+	gs.clearScripts(lobby.ID)
+	gs.stopGameLoop(lobby.ID)
+	gs.cancelPrefetch(lobby.ID)
 
 	gs.repo.SaveLobby(lobby)
 	log.Printf("Game finished for lobby %s, will be deleted in 10 minutes", lobby.ID)
 }
 
-func (gs *GameService) calculateLeaderboard(lobby *models.Lobby) []*models.Player {
+// Leaderboard ranks the lobby's players by score, highest first. The caller
+// holds the lobby lock.
+func (gs *GameService) Leaderboard(lobby *models.Lobby) []*models.Player {
 	players := make([]*models.Player, len(lobby.Players))
 	copy(players, lobby.Players)
 
@@ -285,19 +923,13 @@ func (gs *GameService) calculateLeaderboard(lobby *models.Lobby) []*models.Playe
 }
 
 func (gs *GameService) BroadcastLobbyUpdate(lobbyHub *hub.LobbyHub, eventType string, data interface{}) {
-	event := models.GameEvent{
-		Type:      eventType,
-		LobbyID:   lobbyHub.GetLobby().ID,
-		Data:      data,
-		Timestamp: time.Now(),
-	}
-
-	jsonData, err := json.Marshal(event)
-	if err != nil {
-		log.Printf("Error marshaling event: %v", err)
-		return
+	event := &models.GameEvent{
+		Type:    eventType,
+		LobbyID: lobbyHub.GetLobby().ID,
+		Data:    data,
 	}
 
 	log.Printf("Broadcasting %s event to lobby %s with %d clients", eventType, lobbyHub.GetLobby().ID, len(lobbyHub.GetClients()))
-	lobbyHub.Broadcast(jsonData)
+	lobbyHub.Publish(event)
+	gs.notifyWebhook(lobbyHub.GetLobby(), eventType, data)
 }
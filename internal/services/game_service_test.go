@@ -0,0 +1,30 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/repository"
+	"buildprize-game/internal/services"
+)
+
+// newService runs a game service against the in-memory repository.
+func newService(t *testing.T) (*services.GameService, *hub.Hub, *repository.InMemoryRepository) {
+	t.Helper()
+	gameHub := hub.NewHub()
+	repo := repository.NewInMemoryRepository()
+	return services.NewGameService(gameHub, repo, 50), gameHub, repo
+}
+
+// waitFor polls done until it holds, failing the test after two seconds.
+func waitFor(t *testing.T, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatal("Condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,79 @@
+package services_test
+
+import (
+	"testing"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// A finished game carries its stats, kept with the stored lobby: average
+// answer time, the hardest question, participation per round and chat.
+func TestGameStats(t *testing.T) {
+	gs, gameHub, repo := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Stats", MaxRounds: 3, MaxPlayers: 4})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	_, alice, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"})
+	_, bob, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "bob"})
+	gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "carol"})
+	lobbyHub := gameHub.GetLobbyHub(lobby.ID)
+	gs.PostChatMessage(lobbyHub, alice, "good luck")
+
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	lobby.Lock()
+	question := lobby.CurrentQ
+	lobby.Unlock()
+	if err := gs.SubmitAnswer(lobby.ID, alice.ID, correctAnswer(question)); err != nil {
+		t.Fatalf("SubmitAnswer: %v", err)
+	}
+	if err := gs.SubmitAnswer(lobby.ID, bob.ID, wrongAnswer(question)); err != nil {
+		t.Fatalf("SubmitAnswer: %v", err)
+	}
+	gs.PostChatMessage(lobbyHub, bob, "oops")
+	if err := gs.ForceEndGame(lobby.ID); err != nil {
+		t.Fatalf("ForceEndGame: %v", err)
+	}
+
+	stored, err := repo.GetLobby(lobby.ID)
+	if err != nil {
+		t.Fatalf("GetLobby: %v", err)
+	}
+	stats := stored.Stats
+	if stats == nil {
+		t.Fatal("Expected stats stored with the finished lobby")
+	}
+	if stats.ChatMessages != 2 {
+		t.Fatalf("Expected 2 chat messages, got %d", stats.ChatMessages)
+	}
+	if len(stats.Rounds) != 1 || stats.Rounds[0].Players != 3 || stats.Rounds[0].Answered != 2 {
+		t.Fatalf("Expected 2 of 3 players to answer round 1, got %+v", stats.Rounds)
+	}
+	hardest := stats.HardestQuestion
+	if hardest == nil || hardest.QuestionID != question.ID || hardest.Correct != 1 || hardest.Accuracy != 0.5 {
+		t.Fatalf("Expected round 1's question at 50%% accuracy, got %+v", hardest)
+	}
+	if stats.AvgResponseMs < 0 {
+		t.Fatalf("Expected a non-negative average response time, got %d", stats.AvgResponseMs)
+	}
+}
+
+func wrongAnswer(q *models.Question) models.SubmittedAnswer {
+	switch q.QuestionType() {
+	case models.FreeText:
+		return models.SubmittedAnswer{Text: "definitely not it"}
+	case models.Numeric:
+		return models.SubmittedAnswer{Number: q.NumericAnswer + 2*q.NumericTolerance()}
+	case models.MultiSelect:
+		for i := range q.Options {
+			// A correct option alone would still earn partial credit
+			if hits, _ := q.MultiSelectHits(models.SubmittedAnswer{Choices: []int{i}}); hits == 0 {
+				return models.SubmittedAnswer{Choices: []int{i}}
+			}
+		}
+	}
+	return models.SubmittedAnswer{Choice: (q.Correct + 1) % len(q.Options)}
+}
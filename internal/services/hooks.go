@@ -0,0 +1,149 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"os/exec"
+	"time"
+
+	"buildprize-game/internal/models"
+)
+
+// GameHook lets deployments extend game behaviour without forking
+// GameService. Hooks are called synchronously from the game loop with the
+// lobby locked, so implementations must return quickly, hand slow work off
+// themselves and not call back into GameService.
+type GameHook interface {
+	OnGameStart(lobby *models.Lobby)
+	OnAnswer(lobby *models.Lobby, player *models.Player, answer models.SubmittedAnswer, score int)
+	OnGameEnd(lobby *models.Lobby, leaderboard []*models.Player)
+}
+
+// RegisterHook adds a hook that is notified of game events.
+func (gs *GameService) RegisterHook(hook GameHook) {
+	gs.mu.Lock()
+	gs.hooks = append(gs.hooks, hook)
+	gs.mu.Unlock()
+}
+
+func (gs *GameService) runHooks(name string, fn func(GameHook)) {
+	gs.mu.Lock()
+	hooks := make([]GameHook, len(gs.hooks))
+	copy(hooks, gs.hooks)
+	gs.mu.Unlock()
+
+	for _, hook := range hooks {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("Game hook %T panicked in %s: %v", hook, name, r)
+				}
+			}()
+			fn(hook)
+		}()
+	}
+}
+
+// ScriptHook runs an external command for every game event, writing the
+// event as a single JSON object to the command's stdin:
+//
+//	{"event":"game_start","lobby":{...}}
+//	{"event":"answer","lobby":{...},"player":{...},"answer":{"choice":2},"score":150}
+//	{"event":"game_end","lobby":{...},"leaderboard":[...]}
+//
+// Each event runs in its own process, in the background on one of a fixed
+// number of workers, and is killed after the configured timeout. Events
+// waiting for a worker are queued; a full queue drops them rather than
+// starting more processes.
+type ScriptHook struct {
+	command string
+	args    []string
+	timeout time.Duration
+	events  chan scriptEvent
+}
+
+// scriptHookBacklog is how many events per worker may wait in the queue.
+const scriptHookBacklog = 16
+
+type scriptEvent struct {
+	name interface{}
+	data []byte
+}
+
+func NewScriptHook(command string, args []string, timeout time.Duration, workers int) *ScriptHook {
+	if workers < 1 {
+		workers = 1
+	}
+	sh := &ScriptHook{
+		command: command,
+		args:    args,
+		timeout: timeout,
+		events:  make(chan scriptEvent, workers*scriptHookBacklog),
+	}
+	for i := 0; i < workers; i++ {
+		go sh.work()
+	}
+	return sh
+}
+
+func (sh *ScriptHook) OnGameStart(lobby *models.Lobby) {
+	sh.send(map[string]interface{}{
+		"event": "game_start",
+		"lobby": lobby,
+	})
+}
+
+func (sh *ScriptHook) OnAnswer(lobby *models.Lobby, player *models.Player, answer models.SubmittedAnswer, score int) {
+	sh.send(map[string]interface{}{
+		"event":  "answer",
+		"lobby":  lobby,
+		"player": player,
+		"answer": answer,
+		"score":  score,
+	})
+}
+
+func (sh *ScriptHook) OnGameEnd(lobby *models.Lobby, leaderboard []*models.Player) {
+	sh.send(map[string]interface{}{
+		"event":       "game_end",
+		"lobby":       lobby,
+		"leaderboard": leaderboard,
+	})
+}
+
+func (sh *ScriptHook) send(payload map[string]interface{}) {
+	// Marshal before leaving the game loop so the script sees a consistent snapshot.
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Script hook: failed to marshal %v event: %v", payload["event"], err)
+		return
+	}
+
+	select {
+	case sh.events <- scriptEvent{name: payload["event"], data: data}:
+	default:
+		log.Printf("ALERT: Script hook %s queue full, %v event not run", sh.command, payload["event"])
+	}
+}
+
+func (sh *ScriptHook) work() {
+	for event := range sh.events {
+		sh.run(event)
+	}
+}
+
+func (sh *ScriptHook) run(event scriptEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), sh.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, sh.command, sh.args...)
+	cmd.Stdin = bytes.NewReader(event.data)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		log.Printf("Script hook %s failed for %v event: %v (stderr: %s)", sh.command, event.name, err, stderr.String())
+	}
+}
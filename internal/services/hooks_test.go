@@ -0,0 +1,51 @@
+package services_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// A script hook runs no more processes at once than it has workers, queues
+// a bounded backlog behind them and drops events past it.
+func TestScriptHookBounded(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	started := filepath.Join(dir, "started")
+	// Each run notes that it started, then holds its worker until released
+	script := `echo . >> "$0/started"; while [ ! -e "$0/release" ]; do sleep 0.01; done`
+	hook := services.NewScriptHook("sh", []string{"-c", script, dir}, 5*time.Second, 2)
+
+	runs := func() int {
+		data, _ := os.ReadFile(started)
+		return strings.Count(string(data), "\n")
+	}
+	lobby := &models.Lobby{ID: "hooked"}
+	hook.OnGameStart(lobby)
+	hook.OnGameStart(lobby)
+	waitFor(t, func() bool { return runs() == 2 })
+	for i := 0; i < 2*16+8; i++ {
+		hook.OnGameStart(lobby)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := runs(); n != 2 {
+		t.Fatalf("Expected 2 hook processes at once, got %d", n)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "release"), nil, 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	waitFor(t, func() bool { return runs() == 2+2*16 })
+	time.Sleep(50 * time.Millisecond)
+	if n := runs(); n != 2+2*16 {
+		t.Fatalf("Expected the events past the queue to be dropped, %d ran", n)
+	}
+}
@@ -0,0 +1,50 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+
+	"buildprize-game/internal/i18n"
+	"buildprize-game/internal/services"
+)
+
+// Every catalog translates every key; regional tags fall back to their base
+// language, and lobbies keep the language they were created with.
+func TestMessageCatalogs(t *testing.T) {
+	english, err := i18n.Lookup(i18n.DefaultLanguage)
+	if err != nil {
+		t.Fatalf("Lookup(en): %v", err)
+	}
+	for _, lang := range i18n.Languages() {
+		catalog, err := i18n.Lookup(lang)
+		if err != nil {
+			t.Fatalf("Lookup(%s): %v", lang, err)
+		}
+		if len(catalog) != len(english) {
+			t.Fatalf("Expected %d messages in %s, got %d", len(english), lang, len(catalog))
+		}
+		for key, text := range catalog {
+			if lang != i18n.DefaultLanguage && text == english[key] {
+				t.Fatalf("Expected %s translated into %s, got the English %q", key, lang, text)
+			}
+		}
+	}
+	if lang, err := i18n.Resolve("PT-br"); err != nil || lang != "pt" {
+		t.Fatalf("Expected pt-BR served by pt, got %q %v", lang, err)
+	}
+	if _, err := i18n.Resolve("xx"); !errors.Is(err, i18n.ErrUnsupportedLanguage) {
+		t.Fatalf("Expected ErrUnsupportedLanguage, got %v", err)
+	}
+
+	gs, _, _ := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Hola", MaxRounds: 3, Language: "es-MX"})
+	if err != nil || lobby.Language != "es" {
+		t.Fatalf("Expected a Spanish lobby, got %v", err)
+	}
+	if lobby, _ := gs.CreateLobby(services.LobbyOptions{Name: "Default", MaxRounds: 3}); lobby.Language != i18n.DefaultLanguage {
+		t.Fatalf("Expected the default language, got %q", lobby.Language)
+	}
+	if _, err := gs.CreateLobby(services.LobbyOptions{Name: "Klingon", MaxRounds: 3, Language: "tlh"}); !errors.Is(err, services.ErrUnsupportedLanguage) {
+		t.Fatalf("Expected ErrUnsupportedLanguage, got %v", err)
+	}
+}
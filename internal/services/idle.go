@@ -0,0 +1,91 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"buildprize-game/internal/game"
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+)
+
+// maxIdleCheckInterval bounds how late an idle lobby is warned or closed.
+const maxIdleCheckInterval = 5 * time.Second
+
+// StartLobbyIdleTimeout closes waiting lobbies that see no joins or chat
+// for timeout. warning before the end, the lobby is sent lobby_expiring
+// with its expires_at time; activity in the meantime keeps it open and is
+// announced with lobby_expiry_cancelled. A lobby that runs out is sent
+// game_cancelled with reason "idle" and removed, seats and stored lobby
+// included. Scheduled, demo and sandbox lobbies never expire. Zero or less
+// disables the timeout.
+func (gs *GameService) StartLobbyIdleTimeout(timeout, warning time.Duration) {
+	if timeout <= 0 {
+		return
+	}
+	if warning < 0 || warning >= timeout {
+		warning = timeout / 2
+	}
+	interval := timeout / 4
+	if warning > 0 && warning/4 < interval {
+		interval = warning / 4
+	}
+	if interval > maxIdleCheckInterval {
+		interval = maxIdleCheckInterval
+	}
+	log.Printf("Lobby idle timeout enabled: %s, warned %s ahead", timeout, warning)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			for _, lobbyHub := range gs.hub.GetAllLobbies() {
+				gs.expireIdleLobby(lobbyHub, timeout, warning)
+			}
+		}
+	}()
+}
+
+// expireIdleLobby warns or closes a waiting lobby by how long it's been idle.
+func (gs *GameService) expireIdleLobby(lobbyHub *hub.LobbyHub, timeout, warning time.Duration) {
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	if lobby.Phase != game.Waiting || lobby.StartsAt != nil || lobby.Demo || lobby.Sandbox {
+		lobby.Unlock()
+		return
+	}
+	lastActivity := lobby.LastActivity
+	if lastActivity.IsZero() {
+		lastActivity = lobby.CreatedAt
+	}
+	expiresAt := lastActivity.Add(timeout)
+	now := models.Now()
+	switch {
+	case !now.Before(expiresAt):
+		log.Printf("Closing lobby %s: no activity since %s", lobby.ID, models.FormatTimestamp(lastActivity))
+		gs.BroadcastLobbyUpdate(lobbyHub, "game_cancelled", map[string]interface{}{
+			"reason": "idle",
+		})
+		lobby.Unlock()
+		gs.discardLobby(lobby.ID, "idle")
+		return
+	case warning > 0 && !lobby.IdleWarned && expiresAt.Sub(now) <= warning:
+		lobby.IdleWarned = true
+		gs.BroadcastLobbyUpdate(lobbyHub, "lobby_expiring", map[string]interface{}{
+			"expires_at":         models.FormatTimestamp(expiresAt),
+			"expires_in_seconds": int(expiresAt.Sub(now).Seconds()),
+		})
+	}
+	lobby.Unlock()
+}
+
+// touchLobby records a join or chat message as activity that keeps the
+// lobby from expiring, withdrawing a warning already sent. Call with the
+// lobby locked.
+func (gs *GameService) touchLobby(lobbyHub *hub.LobbyHub, lobby *models.Lobby) {
+	lobby.LastActivity = models.Now()
+	if lobby.IdleWarned {
+		lobby.IdleWarned = false
+		gs.BroadcastLobbyUpdate(lobbyHub, "lobby_expiry_cancelled", map[string]interface{}{})
+	}
+}
@@ -0,0 +1,41 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/services"
+)
+
+// A waiting lobby with no joins or chat is warned before it expires, kept
+// open by chat after the warning, and closed once it goes quiet for the
+// whole timeout.
+func TestIdleLobbyExpires(t *testing.T) {
+	gs, gameHub, repo := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Quiet", MaxRounds: 3, MaxPlayers: 4})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	_, alice, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"})
+	lobbyHub := gameHub.GetLobbyHub(lobby.ID)
+	watcher := &hub.Client{ID: "watcher", LobbyID: lobby.ID, Send: make(chan []byte, 64)}
+	lobbyHub.Register(watcher)
+	gs.StartLobbyIdleTimeout(800*time.Millisecond, 400*time.Millisecond)
+
+	waitForEvent(t, watcher, "lobby_expiring")
+	if err := gs.PostChatMessage(lobbyHub, alice, "still here"); err != nil {
+		t.Fatalf("PostChatMessage: %v", err)
+	}
+	waitForEvent(t, watcher, "lobby_expiry_cancelled")
+	if gameHub.GetLobbyHub(lobby.ID) == nil {
+		t.Fatal("Expected the lobby kept open by chat")
+	}
+
+	waitForEvent(t, watcher, "lobby_expiring")
+	waitForEvent(t, watcher, "game_cancelled")
+	waitFor(t, func() bool { return gameHub.GetLobbyHub(lobby.ID) == nil })
+	if _, err := repo.GetLobby(lobby.ID); err == nil {
+		t.Fatal("Expected the idle lobby deleted")
+	}
+}
@@ -0,0 +1,81 @@
+package services_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/repository"
+	"buildprize-game/internal/services"
+)
+
+// Lobby listings page, sort and filter with a total of everything matching.
+func TestLobbyListingPages(t *testing.T) {
+	gs, _, repo := newService(t)
+	var ids []string
+	seated := []int{0, 1, 2, 0, 2}
+	for i, name := range []string{"delta", "Alpha", "charlie", "bravo", "echo"} {
+		lobby, err := gs.CreateLobby(services.LobbyOptions{Name: name, MaxRounds: 3, MaxPlayers: 2})
+		if err != nil {
+			t.Fatalf("CreateLobby: %v", err)
+		}
+		ids = append(ids, lobby.ID)
+		for p := 0; p < seated[i]; p++ {
+			gs.JoinLobby(lobby.ID, services.JoinOptions{Username: fmt.Sprintf("%s-%d", name, p)})
+		}
+	}
+	// charlie is full; echo starts its game
+	if err := gs.StartGame(ids[4]); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	defer gs.ForceEndGame(ids[4])
+
+	names := func(page *repository.LobbyPage) []string {
+		var out []string
+		for _, lobby := range page.Lobbies {
+			out = append(out, lobby.Name)
+		}
+		return out
+	}
+	list := func(q repository.LobbyQuery) *repository.LobbyPage {
+		t.Helper()
+		page, err := repo.ListLobbies(q)
+		if err != nil {
+			t.Fatalf("ListLobbies(%+v): %v", q, err)
+		}
+		return page
+	}
+
+	page := list(repository.LobbyQuery{State: models.Waiting, Sort: repository.SortName, Limit: 2})
+	if page.Total != 4 || fmt.Sprint(names(page)) != "[Alpha bravo]" {
+		t.Fatalf("Expected the first 2 of 4 by name, got %v of %d", names(page), page.Total)
+	}
+	page = list(repository.LobbyQuery{State: models.Waiting, Sort: repository.SortName, Limit: 2, Offset: 2})
+	if fmt.Sprint(names(page)) != "[charlie delta]" {
+		t.Fatalf("Expected the second page by name, got %v", names(page))
+	}
+	page = list(repository.LobbyQuery{State: models.Waiting, Offset: 10})
+	if page.Total != 4 || len(page.Lobbies) != 0 {
+		t.Fatalf("Expected an empty page past the end with the total, got %v of %d", names(page), page.Total)
+	}
+
+	page = list(repository.LobbyQuery{State: models.Waiting, HasSpace: true, Sort: repository.SortOldest})
+	if fmt.Sprint(names(page)) != "[delta Alpha bravo]" {
+		t.Fatalf("Expected full lobbies left out, got %v", names(page))
+	}
+	page = list(repository.LobbyQuery{Sort: repository.SortPlayers, Limit: 2})
+	if page.Total != 5 || fmt.Sprint(names(page)) != "[echo charlie]" {
+		t.Fatalf("Expected every state with the fullest first, got %v of %d", names(page), page.Total)
+	}
+	page = list(repository.LobbyQuery{State: models.InProgress})
+	if fmt.Sprint(names(page)) != "[echo]" {
+		t.Fatalf("Expected the game in progress, got %v", names(page))
+	}
+
+	for _, q := range []repository.LobbyQuery{{Limit: 101}, {Offset: -1}, {Sort: "loudest"}, {State: "paused"}} {
+		if _, err := repo.ListLobbies(q); !errors.Is(err, repository.ErrInvalidLobbyQuery) {
+			t.Fatalf("Expected ErrInvalidLobbyQuery for %+v, got %v", q, err)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// A lobby created with its own scoring rules scores its game with them, on
+// top of the active version, and recomputes under them.
+func TestLobbyScoringRules(t *testing.T) {
+	gs, gameHub, repo := newService(t)
+
+	tooHarsh := 500
+	if _, err := gs.CreateLobby(services.LobbyOptions{Name: "Harsh", Scoring: &models.ScoringRules{WrongPenalty: &tooHarsh}}); !errors.Is(err, services.ErrInvalidScoringConfig) {
+		t.Fatalf("Expected ErrInvalidScoringConfig for a penalty above the base score, got %v", err)
+	}
+
+	base, curve, penalty, partial := 200, models.TimeBonusNone, 50, false
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "House rules", MaxRounds: 3, MaxPlayers: 4, Scoring: &models.ScoringRules{
+		BaseScore:      &base,
+		TimeBonusCurve: &curve,
+		WrongPenalty:   &penalty,
+		PartialCredit:  &partial,
+	}})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	if lobby.Scoring == nil || lobby.Scoring.AccuracyBonus != models.DefaultScoringConfig.AccuracyBonus {
+		t.Fatalf("Expected unset rules to come from the active version, got %+v", lobby.Scoring)
+	}
+
+	var playerIDs []string
+	for _, name := range []string{"right", "wrong"} {
+		_, player, err := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: name})
+		if err != nil {
+			t.Fatalf("JoinLobby: %v", err)
+		}
+		playerIDs = append(playerIDs, player.ID)
+	}
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+
+	current := gameHub.GetLobbyHub(lobby.ID).GetLobby()
+	current.Lock()
+	right, wrong := correctAnswer(current.CurrentQ), wrongAnswer(current.CurrentQ)
+	version := current.ScoringVersion
+	current.Unlock()
+	if version != models.LobbyScoringVersion {
+		t.Fatalf("Expected the game recorded under %q, got %q", models.LobbyScoringVersion, version)
+	}
+	if err := gs.SubmitAnswer(lobby.ID, playerIDs[0], right); err != nil {
+		t.Fatalf("SubmitAnswer: %v", err)
+	}
+	if err := gs.SubmitAnswer(lobby.ID, playerIDs[1], wrong); err != nil {
+		t.Fatalf("SubmitAnswer: %v", err)
+	}
+
+	current.Lock()
+	scores := []int{current.GetPlayer(playerIDs[0]).Score, current.GetPlayer(playerIDs[1]).Score}
+	current.Unlock()
+	if want := base + models.DefaultScoringConfig.AccuracyBonus; scores[0] != want {
+		t.Fatalf("Expected %d with no time bonus, got %d", want, scores[0])
+	}
+	if scores[1] != 0 {
+		t.Fatalf("Expected the penalty to stop at zero, got %d", scores[1])
+	}
+
+	if err := gs.ForceEndGame(lobby.ID); err != nil {
+		t.Fatalf("ForceEndGame: %v", err)
+	}
+	answers, _ := repo.GetLobbyAnswers(lobby.ID)
+	if len(answers) != 2 || answers[1].Score != -penalty {
+		t.Fatalf("Expected the wrong answer recorded at %d, got %+v", -penalty, answers)
+	}
+	result, err := gs.RecomputeScores(lobby.ID, "", false, "test")
+	if err != nil {
+		t.Fatalf("RecomputeScores: %v", err)
+	}
+	if result.ScoringVersion != models.LobbyScoringVersion || result.ChangedAnswers != 0 {
+		t.Fatalf("Expected recomputation under the lobby's rules to match, got %+v", result)
+	}
+
+	// Streaks multiply correct answers
+	rules := *lobby.Scoring
+	rules.StreakMultiplier = 0.5
+	if got, want := rules.ScoreResult(true, 0, 2), 2*(base+rules.AccuracyBonus); got != want {
+		t.Fatalf("Expected a third correct answer in a row to score %d, got %d", want, got)
+	}
+}
@@ -0,0 +1,81 @@
+package services_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// The lobby state snapshot carries the open question without its answers,
+// the time left, the round, the leaderboard and the most recent chat.
+func TestLobbyStateSnapshot(t *testing.T) {
+	gs, gameHub, _ := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "State", MaxRounds: 3, MaxPlayers: 4})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	_, alice, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"})
+	gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "bob"})
+
+	lobbyHub := gameHub.GetLobbyHub(lobby.ID)
+	for i := 0; i < models.MaxRecentChat+5; i++ {
+		gs.PostChatMessage(lobbyHub, alice, fmt.Sprintf("message %d", i))
+	}
+
+	state, err := gs.LobbyState(lobby.ID)
+	if err != nil {
+		t.Fatalf("LobbyState: %v", err)
+	}
+	if state.Question != nil || state.TimeLeft != 0 {
+		t.Fatalf("Expected no question before the game starts, got %+v", state.Question)
+	}
+	if len(state.RecentChat) != models.MaxRecentChat || state.RecentChat[0].Message != "message 5" {
+		t.Fatalf("Expected the last %d messages oldest first, got %d starting %q",
+			models.MaxRecentChat, len(state.RecentChat), state.RecentChat[0].Message)
+	}
+
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	defer gs.ForceEndGame(lobby.ID)
+	waitFor(t, func() bool {
+		lobby.Lock()
+		defer lobby.Unlock()
+		return lobby.IsQuestionActive()
+	})
+	lobby.Lock()
+	alice.Score = 300
+	lobby.Unlock()
+
+	state, err = gs.LobbyState(lobby.ID)
+	if err != nil {
+		t.Fatalf("LobbyState: %v", err)
+	}
+	if state.Question == nil || state.Round != 1 || state.TimeLeft <= 0 || state.QuestionEndTime == "" {
+		t.Fatalf("Expected round 1's open question with time left, got round %d, %d s, %+v", state.Round, state.TimeLeft, state.Question)
+	}
+	if len(state.Leaderboard) != 2 || state.Leaderboard[0].Username != "alice" {
+		t.Fatalf("Expected alice to lead, got %+v", state.Leaderboard)
+	}
+
+	payload, _ := json.Marshal(state)
+	var decoded struct {
+		Question map[string]interface{} `json:"question"`
+	}
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		t.Fatalf("Decode state: %v", err)
+	}
+	for _, key := range []string{"correct", "correct_answers", "accepted_answers"} {
+		if _, ok := decoded.Question[key]; ok {
+			t.Fatalf("State question leaked %q", key)
+		}
+	}
+
+	if _, err := gs.LobbyState("missing"); !errors.Is(err, services.ErrLobbyNotFound) {
+		t.Fatalf("Expected ErrLobbyNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package services_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/services"
+)
+
+// A lobby's webhook receives its lifecycle events in order, signed with the
+// secret its host was given, ending with game_ended naming the winner.
+func TestLobbyWebhook(t *testing.T) {
+	type delivery struct {
+		event string
+		body  []byte
+		sig   string
+	}
+	deliveries := make(chan delivery, 16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		deliveries <- delivery{r.Header.Get("X-Webhook-Event"), body, r.Header.Get("X-Webhook-Signature")}
+	}))
+	defer server.Close()
+
+	gs, _, _ := newService(t)
+	opts := services.LobbyOptions{Name: "Office", MaxRounds: 3, MaxPlayers: 4, WebhookURL: server.URL + "/hook"}
+	if _, err := gs.CreateLobby(opts); !errors.Is(err, services.ErrWebhooksDisabled) {
+		t.Fatalf("Expected webhooks refused until enabled, got %v", err)
+	}
+	gs.SetLobbyWebhooks(services.NewLobbyWebhooks([]byte("test key"), []string{"chat.example.com"}, time.Second))
+	if _, err := gs.CreateLobby(opts); !errors.Is(err, services.ErrWebhookHostNotAllowed) {
+		t.Fatalf("Expected a host off the allow list refused, got %v", err)
+	}
+	if _, err := gs.CreateLobby(services.LobbyOptions{Name: "Office", MaxRounds: 3, WebhookURL: "ftp://chat.example.com/x"}); !errors.Is(err, services.ErrInvalidWebhookURL) {
+		t.Fatalf("Expected a non-HTTP URL refused, got %v", err)
+	}
+
+	gs.SetLobbyWebhooks(services.NewLobbyWebhooks([]byte("test key"), nil, time.Second))
+	lobby, err := gs.CreateLobby(opts)
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	secret := gs.LobbyWebhookSecret(lobby)
+	if secret == "" {
+		t.Fatal("Expected a webhook secret for the host")
+	}
+	gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"})
+	gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "bob"})
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	if err := gs.ForceEndGame(lobby.ID); err != nil {
+		t.Fatalf("ForceEndGame: %v", err)
+	}
+
+	deadline := time.After(3 * time.Second)
+	seen := make(map[string]bool)
+	for !seen["game_ended"] {
+		select {
+		case d := <-deliveries:
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write(d.body)
+			if d.sig != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+				t.Fatalf("Bad signature on %s delivery", d.event)
+			}
+			var payload struct {
+				Event   string `json:"event"`
+				LobbyID string `json:"lobby_id"`
+				Data    struct {
+					Winner *struct {
+						Username string `json:"username"`
+					} `json:"winner"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(d.body, &payload); err != nil || payload.Event != d.event || payload.LobbyID != lobby.ID {
+				t.Fatalf("Unexpected %s delivery: %s", d.event, d.body)
+			}
+			if d.event == "game_ended" && payload.Data.Winner == nil {
+				t.Fatalf("Expected game_ended to name the winner: %s", d.body)
+			}
+			if d.event == "game_ended" && (!seen["player_joined"] || !seen["game_started"]) {
+				t.Fatalf("Expected the joins and the start delivered before game_ended, got %v", seen)
+			}
+			seen[d.event] = true
+		case <-deadline:
+			t.Fatalf("Expected game_ended delivered, got %v", seen)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package services_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/services"
+)
+
+// A personal mute stops the muted player's chat reaching only the player who
+// muted them; the host's mute refuses their chat for everyone.
+func TestMutePlayer(t *testing.T) {
+	gs, gameHub, _ := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Mutes", MaxRounds: 3, MaxPlayers: 4})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	_, host, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "host"})
+	_, alice, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"})
+	_, bob, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "bob"})
+	lobbyHub := gameHub.GetLobbyHub(lobby.ID)
+
+	aliceClient := &hub.Client{ID: "alice-conn", LobbyID: lobby.ID, PlayerID: alice.ID, Send: make(chan []byte, 64)}
+	hostClient := &hub.Client{ID: "host-conn", LobbyID: lobby.ID, PlayerID: host.ID, Send: make(chan []byte, 64)}
+	lobbyHub.Register(aliceClient)
+	lobbyHub.Register(hostClient)
+
+	if err := gs.MutePlayer(lobby.ID, alice.ID, bob.ID, services.MuteForSelf); err != nil {
+		t.Fatalf("MutePlayer: %v", err)
+	}
+	if err := gs.PostChatMessage(lobbyHub, bob, "spam"); err != nil {
+		t.Fatalf("PostChatMessage: %v", err)
+	}
+	if err := gs.PostChatMessage(lobbyHub, host, "hello"); err != nil {
+		t.Fatalf("PostChatMessage: %v", err)
+	}
+	if got := chatReceived(t, hostClient, 2); got[0] != "spam" || got[1] != "hello" {
+		t.Fatalf("Expected the host to get both messages, got %q", got)
+	}
+	if got := chatReceived(t, aliceClient, 1); got[0] != "hello" {
+		t.Fatalf("Expected alice not to get bob's message, got %q", got)
+	}
+
+	if err := gs.MutePlayer(lobby.ID, alice.ID, bob.ID, services.MuteForLobby); !errors.Is(err, services.ErrNotHost) {
+		t.Fatalf("Expected ErrNotHost, got %v", err)
+	}
+	if err := gs.MutePlayer(lobby.ID, host.ID, bob.ID, services.MuteForLobby); err != nil {
+		t.Fatalf("MutePlayer: %v", err)
+	}
+	var r *services.ChatRejection
+	if err := gs.PostChatMessage(lobbyHub, bob, "let me talk"); !errors.As(err, &r) || r.Code != services.ChatMuted || r.RetryAfterMs != 0 {
+		t.Fatalf("Expected bob muted until unmuted, got %v", err)
+	}
+	if err := gs.UnmutePlayer(lobby.ID, host.ID, bob.ID, services.MuteForLobby); err != nil {
+		t.Fatalf("UnmutePlayer: %v", err)
+	}
+	if err := gs.PostChatMessage(lobbyHub, bob, "thanks"); err != nil {
+		t.Fatalf("Expected bob unmuted, got %v", err)
+	}
+}
+
+// Reports are stored for review, and enough of them mute the reported
+// player for a while.
+func TestReportsMutePlayer(t *testing.T) {
+	gs, gameHub, repo := newService(t)
+	gs.SetReportMute(2, time.Minute)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Reports", MaxRounds: 3, MaxPlayers: 4})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	_, alice, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"})
+	_, bob, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "bob"})
+	_, carol, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "carol"})
+	lobbyHub := gameHub.GetLobbyHub(lobby.ID)
+
+	if _, muted, err := gs.ReportPlayer(lobby.ID, alice.ID, carol.ID, "  spamming  "); err != nil || muted {
+		t.Fatalf("Expected a report without a mute, got muted=%t err=%v", muted, err)
+	}
+	if _, _, err := gs.ReportPlayer(lobby.ID, alice.ID, carol.ID, "again"); !errors.Is(err, services.ErrAlreadyReported) {
+		t.Fatalf("Expected ErrAlreadyReported, got %v", err)
+	}
+	if _, muted, err := gs.ReportPlayer(lobby.ID, bob.ID, carol.ID, ""); err != nil || !muted {
+		t.Fatalf("Expected the second reporter to mute carol, got muted=%t err=%v", muted, err)
+	}
+
+	var r *services.ChatRejection
+	err = gs.PostChatMessage(lobbyHub, carol, "hi")
+	if !errors.As(err, &r) || r.Code != services.ChatMuted || r.RetryAfterMs <= 0 || r.RetryAfterMs > time.Minute.Milliseconds()+1 {
+		t.Fatalf("Expected carol muted for a minute, got %v", err)
+	}
+
+	reports, err := repo.ListPlayerReports(lobby.ID, 10)
+	if err != nil {
+		t.Fatalf("ListPlayerReports: %v", err)
+	}
+	if len(reports) != 2 || reports[0].ReporterID != bob.ID || reports[1].Reason != "spamming" || reports[1].Username != "carol" {
+		t.Fatalf("Expected both reports stored newest first, got %+v", reports)
+	}
+}
+
+// chatReceived waits for n chat messages on client and returns their text.
+func chatReceived(t *testing.T, client *hub.Client, n int) []string {
+	t.Helper()
+	var got []string
+	deadline := time.After(2 * time.Second)
+	for len(got) < n {
+		select {
+		case payload := <-client.Send:
+			var event struct {
+				Type string `json:"type"`
+				Data struct {
+					Message string `json:"message"`
+				} `json:"data"`
+			}
+			json.Unmarshal(payload, &event)
+			if event.Type == "chat_message" {
+				got = append(got, event.Data.Message)
+			}
+		case <-deadline:
+			t.Fatalf("Got %d of %d chat messages", len(got), n)
+		}
+	}
+	return got
+}
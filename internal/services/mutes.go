@@ -0,0 +1,198 @@
+package services
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+)
+
+// Mute scopes: a player can mute another for themselves, and the host can
+// mute a player for the whole lobby.
+const (
+	MuteForSelf  = "self"
+	MuteForLobby = "lobby"
+)
+
+// Players are muted lobby-wide for defaultReportMuteDuration once
+// defaultReportMuteThreshold others have reported them, unless changed with
+// SetReportMute.
+const (
+	defaultReportMuteThreshold = 3
+	defaultReportMuteDuration  = 10 * time.Minute
+)
+
+// SetReportMute sets how many players must report a player before their chat
+// is muted lobby-wide, and for how long. A threshold of zero turns automatic
+// mutes off; a duration of zero or less keeps the current one.
+func (gs *GameService) SetReportMute(threshold int, duration time.Duration) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	if threshold >= 0 {
+		gs.reportMuteThreshold = threshold
+	}
+	if duration > 0 {
+		gs.reportMuteDuration = duration
+	}
+}
+
+// MutePlayer mutes targetID's chat: for playerID only with MuteForSelf, so
+// their messages are no longer delivered to that player, or for everyone
+// with MuteForLobby, which only the host can do and which refuses the muted
+// player's messages until unmuted.
+func (gs *GameService) MutePlayer(lobbyID, playerID, targetID, scope string) error {
+	return gs.setMute(lobbyID, playerID, targetID, scope, true)
+}
+
+// UnmutePlayer lifts a mute set with MutePlayer. The host's unmuting also
+// lifts a mute that followed reports.
+func (gs *GameService) UnmutePlayer(lobbyID, playerID, targetID, scope string) error {
+	return gs.setMute(lobbyID, playerID, targetID, scope, false)
+}
+
+func (gs *GameService) setMute(lobbyID, playerID, targetID, scope string, muted bool) error {
+	if scope == "" {
+		scope = MuteForSelf
+	}
+	if scope != MuteForSelf && scope != MuteForLobby {
+		return ErrInvalidMuteScope
+	}
+	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
+	if lobbyHub == nil {
+		return ErrLobbyNotFound
+	}
+
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+	player, target := lobby.GetPlayer(playerID), lobby.GetPlayer(targetID)
+	if player == nil || target == nil {
+		return ErrPlayerNotFound
+	}
+	if playerID == targetID {
+		return ErrCannotMuteSelf
+	}
+
+	if scope == MuteForSelf {
+		if lobby.PersonalMutes == nil {
+			lobby.PersonalMutes = make(map[string]map[string]bool)
+		}
+		if muted {
+			if lobby.PersonalMutes[playerID] == nil {
+				lobby.PersonalMutes[playerID] = make(map[string]bool)
+			}
+			lobby.PersonalMutes[playerID][targetID] = true
+		} else {
+			delete(lobby.PersonalMutes[playerID], targetID)
+		}
+		return nil
+	}
+
+	if !lobby.IsHost(playerID) {
+		return ErrNotHost
+	}
+	if muted {
+		gs.muteInLobby(lobbyHub, target, time.Time{}, "host")
+		return nil
+	}
+	if _, ok := lobby.ChatMutes[targetID]; ok {
+		delete(lobby.ChatMutes, targetID)
+		log.Printf("Player %s unmuted in lobby %s by the host", targetID, lobbyID)
+		gs.BroadcastLobbyUpdate(lobbyHub, "player_unmuted", map[string]interface{}{
+			"player_id": targetID,
+			"username":  target.Username,
+		})
+	}
+	return nil
+}
+
+// muteInLobby mutes a player's chat for everyone until until, or until
+// unmuted for a zero until, and tells the lobby. The caller holds the lobby
+// lock.
+func (gs *GameService) muteInLobby(lobbyHub *hub.LobbyHub, player *models.Player, until time.Time, by string) {
+	lobby := lobbyHub.GetLobby()
+	if lobby.ChatMutes == nil {
+		lobby.ChatMutes = make(map[string]time.Time)
+	}
+	lobby.ChatMutes[player.ID] = until
+	log.Printf("Player %s muted in lobby %s by %s", player.ID, lobby.ID, by)
+
+	data := map[string]interface{}{
+		"player_id": player.ID,
+		"username":  player.Username,
+		"muted_by":  by,
+	}
+	if !until.IsZero() {
+		data["muted_until"] = models.FormatTimestamp(until)
+	}
+	gs.BroadcastLobbyUpdate(lobbyHub, "player_muted", data)
+}
+
+// ReportPlayer records reporterID's report of targetID for admin review.
+// Once enough different players have reported them, the reported player is
+// muted lobby-wide for a while; autoMuted says whether this report did it.
+// Each player can report another once per lobby.
+func (gs *GameService) ReportPlayer(lobbyID, reporterID, targetID, reason string) (report *models.PlayerReport, autoMuted bool, err error) {
+	reason = strings.TrimSpace(reason)
+	if models.Graphemes(reason) > models.MaxReportReasonLength {
+		return nil, false, ErrReportTooLong
+	}
+	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
+	if lobbyHub == nil {
+		return nil, false, ErrLobbyNotFound
+	}
+
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+	reporter, target := lobby.GetPlayer(reporterID), lobby.GetPlayer(targetID)
+	if reporter == nil || target == nil {
+		return nil, false, ErrPlayerNotFound
+	}
+	if reporterID == targetID {
+		return nil, false, ErrCannotMuteSelf
+	}
+	if lobby.Reporters[targetID][reporterID] {
+		return nil, false, ErrAlreadyReported
+	}
+
+	report = &models.PlayerReport{
+		LobbyID:          lobbyID,
+		ReporterID:       reporterID,
+		ReporterUsername: reporter.Username,
+		PlayerID:         targetID,
+		Username:         target.Username,
+		Reason:           reason,
+		CreatedAt:        models.Now(),
+	}
+	if err := gs.repo.SavePlayerReport(report); err != nil {
+		return nil, false, err
+	}
+	if lobby.Reporters == nil {
+		lobby.Reporters = make(map[string]map[string]bool)
+	}
+	if lobby.Reporters[targetID] == nil {
+		lobby.Reporters[targetID] = make(map[string]bool)
+	}
+	lobby.Reporters[targetID][reporterID] = true
+	log.Printf("Player %s reported player %s in lobby %s (%d report(s))", reporterID, targetID, lobbyID, len(lobby.Reporters[targetID]))
+
+	gs.mu.Lock()
+	threshold, duration := gs.reportMuteThreshold, gs.reportMuteDuration
+	gs.mu.Unlock()
+	// A mute by the host already lasts until they lift it
+	until, muted := lobby.ChatMutes[targetID]
+	if threshold > 0 && len(lobby.Reporters[targetID]) == threshold && (!muted || !until.IsZero()) {
+		gs.muteInLobby(lobbyHub, target, report.CreatedAt.Add(duration), "reports")
+		autoMuted = true
+	}
+	return report, autoMuted, nil
+}
+
+// PlayerReports returns the most recent reports, newest first, for one
+// lobby or, with an empty lobbyID, all of them.
+func (gs *GameService) PlayerReports(lobbyID string, limit int) ([]*models.PlayerReport, error) {
+	return gs.repo.ListPlayerReports(lobbyID, limit)
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+)
+
+// SendPersonalEvent delivers an event to one player, such as an invite or a
+// power-up result. If the player has no connection that can take it, the
+// event is stored and delivered when they next connect. The caller holds the
+// lobby lock.
+func (gs *GameService) SendPersonalEvent(lobbyHub *hub.LobbyHub, playerID, eventType string, data interface{}) {
+	lobby := lobbyHub.GetLobby()
+	event := &models.GameEvent{
+		Type:    eventType,
+		LobbyID: lobby.ID,
+		Data:    data,
+	}
+	if lobbyHub.SendToPlayer(playerID, event) {
+		return
+	}
+
+	// Test players and bots never connect, so there is nobody to deliver to later
+	if player := lobby.GetPlayer(playerID); player == nil || !player.IsHuman() {
+		return
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Error marshaling %s event for player %s: %v", eventType, playerID, err)
+		return
+	}
+	notification := &models.PendingNotification{
+		PlayerID:  playerID,
+		LobbyID:   lobby.ID,
+		Type:      eventType,
+		Data:      payload,
+		CreatedAt: models.Now(),
+	}
+	if err := gs.repo.SavePendingNotification(notification); err != nil {
+		log.Printf("ERROR: Failed to store undelivered %s event for player %s in lobby %s: %v", eventType, playerID, lobby.ID, err)
+		return
+	}
+	log.Printf("Player %s has no open connection in lobby %s, stored %s event for next connect", playerID, lobby.ID, eventType)
+}
+
+// DeliverPendingNotifications sends a newly connected client the personal
+// events stored while its player was offline.
+func (gs *GameService) DeliverPendingNotifications(lobbyHub *hub.LobbyHub, client *hub.Client) {
+	if client.PlayerID == "" {
+		return
+	}
+	lobbyID := lobbyHub.GetLobby().ID
+
+	notifications, err := gs.repo.TakePendingNotifications(lobbyID, client.PlayerID)
+	if err != nil {
+		log.Printf("ERROR: Failed to load pending notifications for player %s in lobby %s: %v", client.PlayerID, lobbyID, err)
+		return
+	}
+
+	for i, n := range notifications {
+		event := &models.GameEvent{
+			Type:    n.Type,
+			LobbyID: n.LobbyID,
+			Data:    n.Data,
+		}
+		if !lobbyHub.SendTo(client, event) {
+			// Connection dropped again; keep the rest for next time
+			for _, remaining := range notifications[i:] {
+				if err := gs.repo.SavePendingNotification(remaining); err != nil {
+					log.Printf("ERROR: Failed to re-store %s event for player %s: %v", remaining.Type, client.PlayerID, err)
+				}
+			}
+			return
+		}
+	}
+	if len(notifications) > 0 {
+		log.Printf("Delivered %d pending notification(s) to player %s in lobby %s", len(notifications), client.PlayerID, lobbyID)
+	}
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"sort"
+
+	"buildprize-game/internal/models"
+)
+
+// recordGuess keeps a numeric question's guess for ranking when the question
+// closes. The caller holds the lobby lock.
+func recordGuess(lobby *models.Lobby, playerID string, answer models.SubmittedAnswer) {
+	if lobby.CurrentQ.QuestionType() != models.Numeric {
+		return
+	}
+	if lobby.Guesses == nil {
+		lobby.Guesses = make(map[string]float64)
+	}
+	lobby.Guesses[playerID] = answer.Number
+}
+
+// awardClosestBonuses ranks the guesses at the current numeric question,
+// closest first, and adds models.ClosestBonuses to the players' scores by place.
+// Guesses equally close share a place; those too far off to score earn no
+// bonus. It returns the ranking, or nil for other question types. The
+// caller holds the lobby lock.
+func awardClosestBonuses(lobby *models.Lobby) []*models.ClosestGuess {
+	question := lobby.CurrentQ
+	if question.QuestionType() != models.Numeric {
+		return nil
+	}
+
+	ranking := make([]*models.ClosestGuess, 0, len(lobby.Guesses))
+	for _, player := range lobby.Players {
+		if guess, ok := lobby.Guesses[player.ID]; ok {
+			ranking = append(ranking, &models.ClosestGuess{PlayerID: player.ID, Username: player.Username, Guess: guess})
+		}
+	}
+	distance := func(i int) float64 {
+		return question.Distance(models.SubmittedAnswer{Number: ranking[i].Guess})
+	}
+	sort.SliceStable(ranking, func(i, j int) bool { return distance(i) < distance(j) })
+
+	for i, guess := range ranking {
+		guess.Rank = i + 1
+		if i > 0 && distance(i) == distance(i-1) {
+			guess.Rank = ranking[i-1].Rank
+		}
+		if guess.Rank > len(models.ClosestBonuses) || question.Proximity(models.SubmittedAnswer{Number: guess.Guess}) == 0 {
+			continue
+		}
+		guess.Bonus = models.ClosestBonuses[guess.Rank-1]
+		if player := lobby.GetPlayer(guess.PlayerID); player != nil {
+			player.Score = models.AddScore(player.Score, guess.Bonus)
+		}
+	}
+	return ranking
+}
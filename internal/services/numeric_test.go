@@ -0,0 +1,70 @@
+package services_test
+
+import (
+	"testing"
+
+	"buildprize-game/internal/game"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// Numeric guesses score by how close they are, and once the question closes
+// the closest guesses earn bonuses by place.
+func TestNumericQuestion(t *testing.T) {
+	gs, gameHub, repo := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Closest wins", MaxRounds: 2, MaxPlayers: 4})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	var playerIDs []string
+	for _, name := range []string{"alice", "bob", "carol"} {
+		_, player, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: name})
+		playerIDs = append(playerIDs, player.ID)
+	}
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+
+	question := &models.Question{ID: "numeric", Type: models.Numeric, Text: "How many?", NumericAnswer: 100, Tolerance: 50}
+	if err := question.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	current := gameHub.GetLobbyHub(lobby.ID).GetLobby()
+	current.Lock()
+	current.CurrentQ = question
+	current.Unlock()
+
+	if err := gs.SubmitAnswer(lobby.ID, playerIDs[0], models.SubmittedAnswer{Text: "a hundred"}); err != services.ErrInvalidAnswer {
+		t.Fatalf("Expected ErrInvalidAnswer for a typed guess, got %v", err)
+	}
+	for i, guess := range []float64{100, 80, 200} {
+		if err := gs.SubmitAnswer(lobby.ID, playerIDs[i], models.SubmittedAnswer{Number: guess}); err != nil {
+			t.Fatalf("SubmitAnswer: %v", err)
+		}
+	}
+	waitFor(t, phaseIs(current, game.Results))
+
+	answers, _ := repo.GetLobbyAnswers(lobby.ID)
+	if len(answers) != 3 || !answers[0].Correct || answers[1].Correct || answers[1].Score != 60 || answers[2].Score != 0 {
+		t.Fatalf("Expected the exact guess correct and 80 to score 60, got %+v", answers)
+	}
+	current.Lock()
+	scores := []int{current.GetPlayer(playerIDs[0]).Score, current.GetPlayer(playerIDs[1]).Score, current.GetPlayer(playerIDs[2]).Score}
+	current.Unlock()
+	want := []int{answers[0].Score + models.ClosestBonuses[0], 60 + models.ClosestBonuses[1], 0}
+	for i := range want {
+		if scores[i] != want[i] {
+			t.Fatalf("Expected scores %v with the closest bonuses, got %v", want, scores)
+		}
+	}
+	gs.ForceEndGame(lobby.ID)
+}
+
+// closestBonus is what a lone correct answer to q earns on top of its score
+// when the question closes.
+func closestBonus(q *models.Question) int {
+	if q.QuestionType() == models.Numeric {
+		return models.ClosestBonuses[0]
+	}
+	return 0
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"buildprize-game/internal/game"
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+)
+
+// PauseGame freezes the round timer on the host's request. Answers are
+// rejected until the game resumes.
+func (gs *GameService) PauseGame(lobbyID, playerID string) error {
+	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
+	if lobbyHub == nil {
+		return ErrLobbyNotFound
+	}
+
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+	if !lobby.IsHost(playerID) {
+		return ErrNotHost
+	}
+	if !lobby.CanPause() {
+		return ErrCannotPause
+	}
+
+	remaining := gs.stopRound(lobbyID)
+	if lobby.Phase == game.Question {
+		// The round timer runs the grace window past the question's end
+		remaining -= gs.answerGrace
+		if remaining < 0 {
+			remaining = 0
+		}
+	}
+	lobby.Pause(remaining)
+	gs.repo.SaveLobby(lobby)
+
+	log.Printf("Lobby %s paused by %s in round %d with %s left", lobbyID, playerID, lobby.Round, remaining)
+	gs.BroadcastLobbyUpdate(lobbyHub, "game_paused", map[string]interface{}{
+		"paused_by":    playerID,
+		"round":        lobby.Round,
+		"remaining_ms": lobby.RemainingMs,
+		"paused_at":    models.FormatTimestamp(*lobby.PausedAt),
+	})
+	return nil
+}
+
+// ResumeGame restarts the round timer with the time that was left when the
+// game was paused.
+func (gs *GameService) ResumeGame(lobbyID, playerID string) error {
+	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
+	if lobbyHub == nil {
+		return ErrLobbyNotFound
+	}
+
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+	if !lobby.IsHost(playerID) {
+		return ErrNotHost
+	}
+	if !lobby.Paused {
+		return ErrNotPaused
+	}
+
+	remaining := lobby.Resume()
+	gs.scheduleResumed(lobbyHub, remaining)
+	gs.repo.SaveLobby(lobby)
+
+	log.Printf("Lobby %s resumed by %s in round %d with %s left", lobbyID, playerID, lobby.Round, remaining)
+	data := map[string]interface{}{
+		"resumed_by":   playerID,
+		"round":        lobby.Round,
+		"remaining_ms": remaining.Milliseconds(),
+		"server_time":  models.FormatTimestamp(models.Now()),
+	}
+	if lobby.CurrentQ != nil && lobby.QuestionEnd != nil {
+		data["question_end_time"] = models.FormatTimestamp(*lobby.QuestionEnd)
+	}
+	gs.BroadcastLobbyUpdate(lobbyHub, "game_resumed", data)
+	return nil
+}
+
+// scheduleResumed reschedules the step that was pending when the game was
+// paused: the end of the open question or wager phase, or the next question
+// if the pause came during the results. The caller holds the lobby lock.
+func (gs *GameService) scheduleResumed(lobbyHub *hub.LobbyHub, remaining time.Duration) {
+	lobby := lobbyHub.GetLobby()
+	round := lobby.Round
+	switch lobby.Phase {
+	case game.Question:
+		gs.scheduleRound(lobby.ID, remaining+gs.answerGrace, func() { gs.endQuestion(lobbyHub, round) })
+		return
+	case game.Wager:
+		gs.scheduleRound(lobby.ID, remaining, func() { gs.closeWager(lobbyHub, round) })
+		return
+	}
+	gs.scheduleRound(lobby.ID, remaining, func() { gs.nextQuestion(lobbyHub, round) })
+}
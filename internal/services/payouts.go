@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/payouts"
+)
+
+// payoutTimeout bounds a single payout request to the provider.
+const payoutTimeout = 30 * time.Second
+
+// SetPayoutProvider has claimed prize pool shares paid automatically through
+// provider; nil, the default, leaves them to be paid by hand.
+func (gs *GameService) SetPayoutProvider(provider payouts.Provider) {
+	gs.mu.Lock()
+	gs.payoutProvider = provider
+	gs.mu.Unlock()
+}
+
+func (gs *GameService) currentPayoutProvider() payouts.Provider {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	return gs.payoutProvider
+}
+
+// sendPayout sends a claimed share that is waiting to be sent to the
+// payout provider and records how it answered. The request goes out without
+// holding any lock; its idempotency key only changes when a payout the
+// provider refused is retried, so sending it again after a crash or a lost
+// answer can't pay twice. Errors other than a refusal leave the payout
+// unknown until it's resent or the provider's webhook settles it.
+func (gs *GameService) sendPayout(lobbyID, playerID string) {
+	gs.resultsMu.Lock()
+	result, err := gs.repo.GetPrizeResult(lobbyID)
+	var allocation *models.Allocation
+	if err == nil {
+		allocation = result.FindAllocation(playerID)
+	}
+	gs.resultsMu.Unlock()
+	if allocation == nil || allocation.PayoutStatus != models.PayoutSending {
+		return
+	}
+
+	provider := gs.currentPayoutProvider()
+	if provider == nil || provider.Name() != allocation.PayoutProvider {
+		// It may have been sent before a restart, so it isn't safe to call failed
+		gs.applyPayoutUpdate(&payouts.Update{LobbyID: lobbyID, PlayerID: playerID, Status: payouts.StatusUnknown,
+			Error: fmt.Sprintf("payout provider %s is no longer configured", allocation.PayoutProvider)})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), payoutTimeout)
+	defer cancel()
+	sent, err := provider.Send(ctx, payouts.Request{
+		LobbyID:        lobbyID,
+		PlayerID:       playerID,
+		Amount:         allocation.Amount,
+		Currency:       allocation.Currency,
+		Destination:    allocation.Destination,
+		IdempotencyKey: fmt.Sprintf("prize-%s-%s-%d", lobbyID, playerID, allocation.PayoutAttempts),
+	})
+	update := &payouts.Update{LobbyID: lobbyID, PlayerID: playerID}
+	if err != nil {
+		update.Status = payouts.StatusUnknown
+		if errors.Is(err, payouts.ErrRejected) {
+			update.Status = payouts.StatusFailed
+		}
+		update.Error = err.Error()
+	} else {
+		update.Reference = sent.Reference
+		update.Status = sent.Status
+	}
+	gs.applyPayoutUpdate(update)
+}
+
+// HandlePayoutWebhook applies the payout status change a provider's webhook
+// delivery reports. Deliveries about other events, or payouts this server
+// doesn't know, are ignored.
+func (gs *GameService) HandlePayoutWebhook(ctx context.Context, header http.Header, body []byte) error {
+	provider := gs.currentPayoutProvider()
+	if provider == nil {
+		return ErrPayoutsDisabled
+	}
+	update, err := provider.ParseWebhook(ctx, header, body)
+	if err != nil || update == nil {
+		return err
+	}
+	gs.applyPayoutUpdate(update)
+	return nil
+}
+
+// applyPayoutUpdate records a payout's new status and announces it to the
+// lobby, if still around, as prize_payout. Updates for another payout than
+// the allocation's latest are ignored, as is anything after it's paid: a
+// paid payout is never reopened for a retry. A lost answer doesn't undo
+// one the webhook has already reported on.
+func (gs *GameService) applyPayoutUpdate(update *payouts.Update) {
+	gs.resultsMu.Lock()
+	defer gs.resultsMu.Unlock()
+	result, err := gs.repo.GetPrizeResult(update.LobbyID)
+	if err != nil {
+		log.Printf("Ignoring payout update for lobby %s: %v", update.LobbyID, err)
+		return
+	}
+	allocation := result.FindAllocation(update.PlayerID)
+	if allocation == nil || allocation.PayoutStatus == "" {
+		log.Printf("Ignoring payout update for unknown payout to %s in lobby %s", update.PlayerID, update.LobbyID)
+		return
+	}
+	if update.Reference != "" && allocation.PayoutReference != "" && update.Reference != allocation.PayoutReference {
+		log.Printf("Ignoring payout update for superseded payout %s in lobby %s", update.Reference, update.LobbyID)
+		return
+	}
+	status := models.PayoutStatus(update.Status)
+	if status == allocation.PayoutStatus {
+		return
+	}
+	if allocation.PayoutStatus == models.PayoutPaid {
+		if status != models.PayoutPending {
+			log.Printf("ALERT: ignoring %s update for paid payout to %s in lobby %s (%s %s); reconcile it with the provider",
+				status, update.PlayerID, update.LobbyID, allocation.PayoutProvider, allocation.PayoutReference)
+		}
+		return
+	}
+	if status == models.PayoutUnknown && allocation.PayoutStatus != models.PayoutSending {
+		return
+	}
+
+	allocation.PayoutStatus = status
+	allocation.PayoutError = update.Error
+	if update.Reference != "" {
+		allocation.PayoutReference = update.Reference
+	}
+	if status == models.PayoutPaid {
+		now := models.Now()
+		allocation.PaidAt = &now
+	}
+	if err := gs.repo.SavePrizeResult(result); err != nil {
+		log.Printf("ALERT: failed to save payout status %s for %s in lobby %s (reference %q): %v",
+			status, update.PlayerID, update.LobbyID, allocation.PayoutReference, err)
+		return
+	}
+
+	switch status {
+	case models.PayoutFailed:
+		log.Printf("ALERT: payout to %s in lobby %s failed: %s", update.PlayerID, update.LobbyID, update.Error)
+	case models.PayoutUnknown:
+		log.Printf("ALERT: payout to %s in lobby %s got no clear answer, so it may have been paid; a retry resends it under the same idempotency key: %s",
+			update.PlayerID, update.LobbyID, update.Error)
+	default:
+		log.Printf("Payout to %s in lobby %s is %s (%s %s)", update.PlayerID, update.LobbyID, status, allocation.PayoutProvider, allocation.PayoutReference)
+	}
+	event := map[string]interface{}{
+		"player_id": allocation.PlayerID,
+		"username":  allocation.Username,
+		"rank":      allocation.Rank,
+		"amount":    allocation.Amount,
+		"currency":  allocation.Currency,
+		"provider":  allocation.PayoutProvider,
+		"status":    status,
+	}
+	if allocation.PayoutError != "" {
+		event["error"] = allocation.PayoutError
+	}
+	gs.announceResults(update.LobbyID, "prize_payout", event)
+}
+
+// RetryPayout sends a payout the provider refused again, as a new payout,
+// or resends one that never got an answer from the provider under its old
+// idempotency key, so the provider pays it at most once. The latter has to
+// go to the provider it was first sent to.
+func (gs *GameService) RetryPayout(lobbyID, playerID string) (*models.Allocation, error) {
+	provider := gs.currentPayoutProvider()
+	if provider == nil {
+		return nil, ErrPayoutsDisabled
+	}
+	gs.resultsMu.Lock()
+	defer gs.resultsMu.Unlock()
+	result, err := gs.Prizes(lobbyID)
+	if err != nil {
+		return nil, err
+	}
+	allocation := result.FindAllocation(playerID)
+	if allocation == nil {
+		return nil, ErrNoAllocation
+	}
+	switch allocation.PayoutStatus {
+	case models.PayoutFailed:
+		allocation.PayoutAttempts++
+		allocation.PayoutReference = ""
+		allocation.PayoutError = ""
+	case models.PayoutSending, models.PayoutUnknown:
+		if allocation.PayoutProvider != provider.Name() {
+			return nil, ErrPayoutProviderChanged
+		}
+	default:
+		return nil, ErrPayoutNotRetryable
+	}
+	if err := provider.CheckDestination(allocation.Destination); err != nil {
+		return nil, err
+	}
+	allocation.PayoutStatus = models.PayoutSending
+	allocation.PayoutProvider = provider.Name()
+	if err := gs.repo.SavePrizeResult(result); err != nil {
+		return nil, err
+	}
+
+	go gs.sendPayout(lobbyID, playerID)
+	copied := *allocation
+	return &copied, nil
+}
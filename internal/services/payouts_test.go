@@ -0,0 +1,212 @@
+package services_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/payouts"
+	"buildprize-game/internal/services"
+)
+
+func signPayout(secret string, body []byte) http.Header {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return http.Header{"X-Payout-Signature": {"sha256=" + hex.EncodeToString(mac.Sum(nil))}}
+}
+
+// walletStub is a wallet service that answers each payout request with the
+// next of its responses, or a pending payout once they run out.
+type walletStub struct {
+	mu        sync.Mutex
+	requests  []map[string]interface{}
+	responses []int         // HTTP statuses; 0 is a pending payout
+	delay     time.Duration // before answering the first request
+}
+
+const walletSecret = "wallet-secret"
+
+func (stub *walletStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if r.Header.Get("X-Payout-Signature") != signPayout(walletSecret, body).Get("X-Payout-Signature") {
+		w.WriteHeader(401)
+		return
+	}
+	var request map[string]interface{}
+	json.Unmarshal(body, &request)
+	stub.mu.Lock()
+	stub.requests = append(stub.requests, request)
+	delay := stub.delay
+	stub.delay = 0
+	status := 0
+	if len(stub.responses) > 0 {
+		status, stub.responses = stub.responses[0], stub.responses[1:]
+	}
+	stub.mu.Unlock()
+	time.Sleep(delay)
+	if status != 0 {
+		w.WriteHeader(status)
+		return
+	}
+	w.Write([]byte(`{"reference":"tx-1","status":"pending"}`))
+}
+
+func (stub *walletStub) keys() []interface{} {
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+	var keys []interface{}
+	for _, request := range stub.requests {
+		keys = append(keys, request["idempotency_key"])
+	}
+	return keys
+}
+
+// claimedPayout plays a prize game alice wins and has her claim her share
+// to a wallet address, returning a func that reads her allocation.
+func claimedPayout(t *testing.T, stub *walletStub) (*services.GameService, string, string, func() *models.Allocation) {
+	t.Helper()
+	wallet := httptest.NewServer(stub)
+	t.Cleanup(wallet.Close)
+
+	gs, _, _ := newService(t)
+	gs.SetDisputeWindow(0)
+	gs.SetPayoutProvider(payouts.NewWalletProvider(wallet.URL, walletSecret, 200*time.Millisecond))
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Payouts", MaxRounds: 3, MaxPlayers: 4,
+		PrizePool: &models.PrizePool{Amount: 500, Currency: "USD", Split: []int{100}}})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	_, alice, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"})
+	gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "bob"})
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	lobby.Lock()
+	question := lobby.CurrentQ
+	lobby.Unlock()
+	if err := gs.SubmitAnswer(lobby.ID, alice.ID, correctAnswer(question)); err != nil {
+		t.Fatalf("SubmitAnswer: %v", err)
+	}
+	if err := gs.ForceEndGame(lobby.ID); err != nil {
+		t.Fatalf("ForceEndGame: %v", err)
+	}
+	if _, err := gs.FinalizeDueResults(); err != nil {
+		t.Fatalf("FinalizeDueResults: %v", err)
+	}
+	result, _ := gs.Prizes(lobby.ID)
+	code := result.FindAllocation(alice.ID).ClaimCode
+
+	if _, err := gs.ClaimPrize(lobby.ID, code, "not an address!"); !errors.Is(err, services.ErrInvalidPayoutDestination) {
+		t.Fatalf("Expected ErrInvalidPayoutDestination, got %v", err)
+	}
+	if _, err := gs.ClaimPrize(lobby.ID, code, "0xAbC0000000000000000000000000000000000001"); err != nil {
+		t.Fatalf("ClaimPrize: %v", err)
+	}
+	return gs, lobby.ID, alice.ID, func() *models.Allocation {
+		result, _ := gs.Prizes(lobby.ID)
+		return result.FindAllocation(alice.ID)
+	}
+}
+
+func payoutWebhook(t *testing.T, gs *services.GameService, lobbyID, playerID, status string) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{
+		"reference": "tx-1", "lobby_id": lobbyID, "player_id": playerID, "status": status,
+	})
+	if err := gs.HandlePayoutWebhook(context.Background(), signPayout(walletSecret, body), body); err != nil {
+		t.Fatalf("HandlePayoutWebhook: %v", err)
+	}
+}
+
+// A claimed prize pool share is sent to the payout provider, stays pending
+// until the provider's webhook confirms it, and a payout the provider
+// refused can be retried as a new one.
+func TestPrizePayouts(t *testing.T) {
+	stub := &walletStub{responses: []int{422}}
+	gs, lobbyID, playerID, payout := claimedPayout(t, stub)
+
+	waitFor(t, func() bool { return payout().PayoutStatus == models.PayoutFailed })
+	if _, err := gs.RetryPayout(lobbyID, playerID); err != nil {
+		t.Fatalf("RetryPayout: %v", err)
+	}
+	waitFor(t, func() bool { return payout().PayoutStatus == models.PayoutPending })
+	if _, err := gs.RetryPayout(lobbyID, playerID); !errors.Is(err, services.ErrPayoutNotRetryable) {
+		t.Fatalf("Expected ErrPayoutNotRetryable for a pending payout, got %v", err)
+	}
+
+	stub.mu.Lock()
+	if len(stub.requests) != 2 || stub.requests[0]["idempotency_key"] == stub.requests[1]["idempotency_key"] || stub.requests[1]["amount"] != float64(500) {
+		t.Fatalf("Expected the retry sent as a new payout of 500, got %v", stub.requests)
+	}
+	stub.mu.Unlock()
+
+	confirmation, _ := json.Marshal(map[string]string{
+		"reference": "tx-1", "lobby_id": lobbyID, "player_id": playerID, "status": "paid",
+	})
+	if err := gs.HandlePayoutWebhook(context.Background(), signPayout("wrong", confirmation), confirmation); !errors.Is(err, payouts.ErrInvalidSignature) {
+		t.Fatalf("Expected ErrInvalidSignature, got %v", err)
+	}
+	payoutWebhook(t, gs, lobbyID, playerID, "paid")
+	if paid := payout(); paid.PayoutStatus != models.PayoutPaid || paid.PayoutReference != "tx-1" || paid.PaidAt == nil {
+		t.Fatalf("Expected the payout confirmed paid, got %+v", paid)
+	}
+}
+
+// A payout that timed out or hit a server error may have been paid, so it's
+// left unknown and resent under the same idempotency key, never as a new
+// payout.
+func TestUnansweredPayoutKeepsItsIdempotencyKey(t *testing.T) {
+	stub := &walletStub{responses: []int{0, 502}, delay: time.Second}
+	gs, lobbyID, playerID, payout := claimedPayout(t, stub)
+
+	// The provider took the first request, but answered after the timeout
+	waitFor(t, func() bool { return payout().PayoutStatus == models.PayoutUnknown })
+	if allocation, err := gs.RetryPayout(lobbyID, playerID); err != nil || allocation.PayoutAttempts != 1 {
+		t.Fatalf("RetryPayout: %+v, %v", allocation, err)
+	}
+	waitFor(t, func() bool { return len(stub.keys()) == 2 && payout().PayoutStatus == models.PayoutUnknown })
+	if unknown := payout(); unknown.PayoutError == "" {
+		t.Fatalf("Expected the server error recorded, got %+v", unknown)
+	}
+	if _, err := gs.RetryPayout(lobbyID, playerID); err != nil {
+		t.Fatalf("RetryPayout: %v", err)
+	}
+	waitFor(t, func() bool { return payout().PayoutStatus == models.PayoutPending })
+
+	keys := stub.keys()
+	if len(keys) != 3 || keys[0] != keys[1] || keys[1] != keys[2] {
+		t.Fatalf("Expected every resend under the first idempotency key, got %v", keys)
+	}
+}
+
+// Once paid, a payout stays paid: a late or replayed webhook can't reopen it
+// for a retry.
+func TestPaidPayoutIsFinal(t *testing.T) {
+	stub := &walletStub{}
+	gs, lobbyID, playerID, payout := claimedPayout(t, stub)
+
+	waitFor(t, func() bool { return payout().PayoutStatus == models.PayoutPending })
+	payoutWebhook(t, gs, lobbyID, playerID, "paid")
+	for _, late := range []string{"failed", "pending"} {
+		payoutWebhook(t, gs, lobbyID, playerID, late)
+		if status := payout().PayoutStatus; status != models.PayoutPaid {
+			t.Fatalf("A %s webhook moved a paid payout to %s", late, status)
+		}
+	}
+	if _, err := gs.RetryPayout(lobbyID, playerID); !errors.Is(err, services.ErrPayoutNotRetryable) {
+		t.Fatalf("Expected ErrPayoutNotRetryable for a paid payout, got %v", err)
+	}
+	if keys := stub.keys(); len(keys) != 1 {
+		t.Fatalf("Expected one payout request, got %v", keys)
+	}
+}
@@ -0,0 +1,98 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/game"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// Run with -tags debug to also check the lobby invariants on every transition.
+func TestLobbyPhaseTransitions(t *testing.T) {
+	lobby := models.NewLobby("Phases", 2)
+	if err := lobby.Advance(game.Question); !errors.Is(err, game.ErrInvalidTransition) {
+		t.Fatalf("Expected waiting → question to be rejected, got %v", err)
+	}
+
+	lobby.AddPlayer("first")
+	lobby.AddPlayer("second")
+	if err := lobby.StartGame(); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	if lobby.Phase != game.Countdown || lobby.State != models.InProgress {
+		t.Fatalf("Expected countdown/in_progress, got %s/%s", lobby.Phase, lobby.State)
+	}
+	if err := lobby.StartGame(); !errors.Is(err, game.ErrInvalidTransition) {
+		t.Fatalf("Expected a second start to be rejected, got %v", err)
+	}
+	if lobby.Round != 1 {
+		t.Fatalf("Rejected start changed the round to %d", lobby.Round)
+	}
+
+	if err := lobby.Advance(game.Finished); err != nil {
+		t.Fatalf("Advance to finished: %v", err)
+	}
+	if lobby.State != models.Finished || lobby.Phase.Running() {
+		t.Fatalf("Expected a finished lobby, got %s/%s", lobby.Phase, lobby.State)
+	}
+	if err := lobby.Advance(game.Waiting); !errors.Is(err, game.ErrInvalidTransition) {
+		t.Fatalf("Expected finished to be final, got %v", err)
+	}
+}
+
+// Once a round's results are out no answer may be scored, even though the
+// next question hasn't been shown yet.
+func TestAnswersRejectedAfterResults(t *testing.T) {
+	gs, gameHub, _ := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Late Answers", MaxRounds: 2, MaxPlayers: 4})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	lobbyHub := gameHub.GetLobbyHub(lobby.ID)
+
+	var playerIDs []string
+	for _, name := range []string{"first", "second"} {
+		_, player, err := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: name})
+		if err != nil {
+			t.Fatalf("JoinLobby: %v", err)
+		}
+		playerIDs = append(playerIDs, player.ID)
+	}
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+
+	current := lobbyHub.GetLobby()
+	current.Lock()
+	answer := correctAnswer(current.CurrentQ)
+	current.Unlock()
+	for _, playerID := range playerIDs {
+		if err := gs.SubmitAnswer(lobby.ID, playerID, answer); err != nil {
+			t.Fatalf("SubmitAnswer: %v", err)
+		}
+	}
+
+	// Everyone answered, so the round ends early
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		current.Lock()
+		phase := current.Phase
+		current.Unlock()
+		if phase == game.Results {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Round did not end, phase %s", phase)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	err = gs.SubmitAnswer(lobby.ID, playerIDs[0], models.SubmittedAnswer{Choice: 1})
+	if !errors.Is(err, services.ErrQuestionNotActive) {
+		t.Fatalf("Expected ErrQuestionNotActive after results, got %v", err)
+	}
+	gs.ForceEndGame(lobby.ID)
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"log"
+
+	"buildprize-game/internal/models"
+)
+
+// recordPlayerStats adds a finished game to the lifetime stats and streaks
+// of every account seated at the end, from the answers recorded during it,
+// and returns the streak milestone events to send. A win is finishing first
+// among the game's human players, ties included. Sandboxes don't count. The
+// caller holds the lobby lock.
+func (gs *GameService) recordPlayerStats(lobby *models.Lobby, leaderboard []*models.Player) []map[string]interface{} {
+	if lobby.Sandbox {
+		return nil
+	}
+	var standings []models.Standing
+	for _, player := range leaderboard {
+		if player.IsHuman() {
+			standings = append(standings, models.Standing{PlayerID: player.ID, Username: player.Username, UserID: player.UserID, Score: player.Score})
+		}
+	}
+	models.RankStandings(standings)
+
+	games := make(map[string]*models.PlayerStats)
+	for _, standing := range standings {
+		if standing.UserID == "" {
+			continue
+		}
+		game := &models.PlayerStats{UserID: standing.UserID, GamesPlayed: 1, UpdatedAt: lobby.FinishedAt}
+		if standing.Rank == 1 {
+			game.Wins = 1
+		}
+		games[standing.PlayerID] = game
+	}
+	if len(games) == 0 {
+		return nil
+	}
+
+	answers, err := gs.repo.GetLobbyAnswers(lobby.ID)
+	if err != nil {
+		log.Printf("ALERT: failed to load answers for player stats of lobby %s: %v", lobby.ID, err)
+		return nil
+	}
+	// Streaks run as in the game: a wrong answer ends one, a skipped round
+	// doesn't
+	streaks := make(map[string]int)
+	for _, answer := range answers {
+		game := games[answer.PlayerID]
+		if game == nil {
+			continue
+		}
+		game.Answered++
+		game.TotalResponseMs += answer.ResponseTime
+		if answer.Correct {
+			game.Correct++
+			streaks[answer.PlayerID]++
+			game.BestStreak = max(game.BestStreak, streaks[answer.PlayerID])
+		} else {
+			streaks[answer.PlayerID] = 0
+		}
+	}
+
+	for _, game := range games {
+		if err := gs.repo.AddPlayerStats(game); err != nil {
+			log.Printf("ALERT: failed to add lobby %s to the stats of account %s: %v", lobby.ID, game.UserID, err)
+		}
+	}
+	return gs.recordAccountStreaks(lobby, standings)
+}
+
+// PlayerStats returns an account's lifetime stats.
+func (gs *GameService) PlayerStats(userID string) (*models.PlayerStats, error) {
+	user, err := gs.User(userID)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := gs.repo.GetPlayerStats(userID)
+	if err != nil {
+		return nil, err
+	}
+	stats.Username = user.Username
+	stats.Summarize()
+	return stats, nil
+}
@@ -0,0 +1,72 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// Every finished game adds to the lifetime stats of the accounts that played
+// it; guests and sandboxes don't count.
+func TestPlayerStats(t *testing.T) {
+	gs, _, _ := newService(t)
+	alice, _ := gs.Register("alice", "correct horse")
+	bob, _ := gs.Register("bob", "battery staple")
+
+	// playRound plays a one-round game, answering correctly for the winner
+	playRound := func(winner, loser *models.User, sandbox bool) {
+		t.Helper()
+		var lobby *models.Lobby
+		if sandbox {
+			lobby = gs.CreateSandboxLobby("Stats sandbox", 3)
+		} else {
+			var err error
+			if lobby, err = gs.CreateLobby(services.LobbyOptions{Name: "Stats", MaxRounds: 3, MaxPlayers: 4}); err != nil {
+				t.Fatalf("CreateLobby: %v", err)
+			}
+		}
+		_, w, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{User: winner})
+		_, l, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{User: loser})
+		_, guest, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "carol"})
+		if err := gs.StartGame(lobby.ID); err != nil {
+			t.Fatalf("StartGame: %v", err)
+		}
+		lobby.Lock()
+		question := lobby.CurrentQ
+		lobby.Unlock()
+		gs.SubmitAnswer(lobby.ID, w.ID, correctAnswer(question))
+		gs.SubmitAnswer(lobby.ID, l.ID, wrongAnswer(question))
+		gs.SubmitAnswer(lobby.ID, guest.ID, wrongAnswer(question))
+		if err := gs.ForceEndGame(lobby.ID); err != nil {
+			t.Fatalf("ForceEndGame: %v", err)
+		}
+	}
+
+	playRound(alice, bob, false)
+	stats, err := gs.PlayerStats(alice.ID)
+	if err != nil {
+		t.Fatalf("PlayerStats: %v", err)
+	}
+	if stats.Username != "alice" || stats.GamesPlayed != 1 || stats.Wins != 1 || stats.Answered != 1 || stats.CorrectRate != 1 || stats.BestStreak != 1 {
+		t.Fatalf("Expected alice with one game won, got %+v", stats)
+	}
+
+	playRound(bob, alice, false)
+	playRound(bob, alice, true)
+	stats, _ = gs.PlayerStats(alice.ID)
+	if stats.GamesPlayed != 2 || stats.Wins != 1 || stats.Correct != 1 || stats.CorrectRate != 0.5 || stats.BestStreak != 1 {
+		t.Fatalf("Expected alice's two games added up, sandbox left out, got %+v", stats)
+	}
+	if stats.AvgResponseMs != stats.TotalResponseMs/2 {
+		t.Fatalf("Expected the average over both answers, got %+v", stats)
+	}
+	if stats, _ := gs.PlayerStats(bob.ID); stats.GamesPlayed != 2 || stats.Wins != 1 {
+		t.Fatalf("Expected bob with two games and one win, got %+v", stats)
+	}
+
+	if _, err := gs.PlayerStats("no-such-user"); !errors.Is(err, services.ErrUserNotFound) {
+		t.Fatalf("Expected ErrUserNotFound, got %v", err)
+	}
+}
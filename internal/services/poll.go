@@ -0,0 +1,52 @@
+package services
+
+import (
+	"time"
+
+	"buildprize-game/internal/models"
+)
+
+// maxPolledEvents caps the events one long poll returns; a client further
+// behind polls again from the last one.
+const maxPolledEvents = 200
+
+// PollEvents returns the lobby's lobby-wide events after afterSeq, waiting
+// up to timeout for the next one when there are none yet, along with the
+// lobby's latest sequence number as far as it's known. It gives up early
+// when done is closed. Events come from the event log, so an event dropped
+// from it is skipped, and the sequence number lets the client poll on past
+// it. Only lobbies hosted on this instance can be polled.
+func (gs *GameService) PollEvents(lobbyID string, afterSeq uint64, timeout time.Duration, done <-chan struct{}) ([]*models.GameEvent, uint64, error) {
+	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
+	if lobbyHub == nil {
+		return nil, 0, ErrLobbyNotFound
+	}
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	woken := lobbyHub.Await(afterSeq, done)
+	seq := afterSeq
+	for {
+		events, err := gs.GameEvents(lobbyID, afterSeq, maxPolledEvents)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(events) > 0 {
+			if last := events[len(events)-1].Seq; last > seq {
+				seq = last
+			}
+			return events, seq, nil
+		}
+		if seq > afterSeq {
+			// Woken, but the events didn't make it into the log
+			return events, seq, nil
+		}
+		select {
+		case seq = <-woken:
+		case <-deadline.C:
+			return events, seq, nil
+		case <-done:
+			return events, seq, nil
+		}
+	}
+}
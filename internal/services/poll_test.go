@@ -0,0 +1,74 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// A category poll closes once everyone has voted and its winner is served
+// when the game starts.
+func TestCategoryPoll(t *testing.T) {
+	gs, gameHub, _ := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Polls", MaxRounds: 3, MaxPlayers: 4})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	lobbyHub := gameHub.GetLobbyHub(lobby.ID)
+
+	_, host, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "host"})
+	_, guest, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "guest"})
+
+	opts := services.PollOptions{
+		Question: "Next category?",
+		Options:  []string{"Science", "History"},
+		Apply:    models.PollCategory,
+	}
+	if _, err := gs.CreatePoll(lobby.ID, guest.ID, opts); !errors.Is(err, services.ErrNotHost) {
+		t.Fatalf("Expected ErrNotHost for a guest, got %v", err)
+	}
+	if _, err := gs.CreatePoll(lobby.ID, host.ID, services.PollOptions{
+		Question: "Next category?", Options: []string{"Science", "Cooking"}, Apply: models.PollCategory,
+	}); !errors.Is(err, services.ErrUnknownCategory) {
+		t.Fatalf("Expected ErrUnknownCategory, got %v", err)
+	}
+
+	poll, err := gs.CreatePoll(lobby.ID, host.ID, opts)
+	if err != nil {
+		t.Fatalf("CreatePoll: %v", err)
+	}
+	if _, err := gs.CreatePoll(lobby.ID, host.ID, opts); !errors.Is(err, services.ErrPollOpen) {
+		t.Fatalf("Expected ErrPollOpen for a second poll, got %v", err)
+	}
+
+	if err := gs.VotePoll(lobby.ID, host.ID, poll.ID, 1); err != nil {
+		t.Fatalf("VotePoll: %v", err)
+	}
+	if err := gs.VotePoll(lobby.ID, host.ID, poll.ID, 0); !errors.Is(err, services.ErrAlreadyVoted) {
+		t.Fatalf("Expected ErrAlreadyVoted, got %v", err)
+	}
+	if err := gs.VotePoll(lobby.ID, guest.ID, poll.ID, 1); err != nil {
+		t.Fatalf("VotePoll: %v", err)
+	}
+
+	current := lobbyHub.GetLobby()
+	current.Lock()
+	closed, winner := current.Poll.Closed, current.Poll.WinningOption()
+	current.Unlock()
+	if !closed || winner != "History" {
+		t.Fatalf("Expected the poll closed with History winning, got closed=%v winner=%q", closed, winner)
+	}
+
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	current.Lock()
+	category := current.CurrentQ.Category
+	current.Unlock()
+	if category != "History" {
+		t.Fatalf("Expected a History question after the poll, got %s", category)
+	}
+	gs.ForceEndGame(lobby.ID)
+}
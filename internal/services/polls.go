@@ -0,0 +1,179 @@
+package services
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"buildprize-game/internal/api"
+	"buildprize-game/internal/game"
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+)
+
+const (
+	defaultPollDuration = 20 * time.Second
+	minPollDuration     = 5 * time.Second
+	maxPollDuration     = 2 * time.Minute
+)
+
+// PollOptions are the host's settings for a new poll.
+type PollOptions struct {
+	Question string
+	Options  []string
+	Duration time.Duration // 0 means defaultPollDuration
+	Apply    models.PollAction
+}
+
+// CreatePoll opens a poll in the lobby on the host's request. Only one poll
+// is open at a time. Category polls need every option to be a bank category
+// (or AnyCategory) so the winner can be served.
+func (gs *GameService) CreatePoll(lobbyID, playerID string, opts PollOptions) (*models.Poll, error) {
+	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
+	if lobbyHub == nil {
+		return nil, ErrLobbyNotFound
+	}
+
+	if opts.Duration == 0 {
+		opts.Duration = defaultPollDuration
+	}
+	if opts.Duration < minPollDuration || opts.Duration > maxPollDuration {
+		return nil, ErrInvalidPoll
+	}
+	if opts.Apply == models.PollCategory {
+		for _, category := range opts.Options {
+			category = strings.TrimSpace(category)
+			if !strings.EqualFold(category, AnyCategory) && !gs.questionDB.HasCategory(category) {
+				return nil, ErrUnknownCategory
+			}
+		}
+	}
+
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+	if !lobby.IsHost(playerID) {
+		return nil, ErrNotHost
+	}
+	if lobby.Phase == game.Finished {
+		return nil, ErrGameNotRunning
+	}
+	if lobby.Poll != nil && !lobby.Poll.Closed {
+		return nil, ErrPollOpen
+	}
+
+	poll, err := models.NewPoll(opts.Question, opts.Options, opts.Apply, playerID, opts.Duration)
+	if err != nil {
+		return nil, err
+	}
+	lobby.Poll = poll
+	time.AfterFunc(opts.Duration, func() { gs.closePoll(lobbyHub, poll.ID) })
+
+	log.Printf("Lobby %s: host %s opened poll %s (%d options, closes in %s)", lobbyID, playerID, poll.ID, len(poll.Options), opts.Duration)
+	gs.BroadcastLobbyUpdate(lobbyHub, "poll_started", map[string]interface{}{
+		"poll":        api.FromPoll(poll),
+		"server_time": models.FormatTimestamp(models.Now()),
+	})
+	return poll, nil
+}
+
+// VotePoll records a player's vote in the open poll. The poll closes early
+// once every real player has voted.
+func (gs *GameService) VotePoll(lobbyID, playerID, pollID string, option int) error {
+	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
+	if lobbyHub == nil {
+		return ErrLobbyNotFound
+	}
+
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+	poll := lobby.Poll
+	if poll == nil || poll.Closed || poll.ID != pollID {
+		return ErrNoOpenPoll
+	}
+	if lobby.GetPlayer(playerID) == nil {
+		return ErrPlayerNotFound
+	}
+	if option < 0 || option >= len(poll.Options) {
+		return ErrInvalidVote
+	}
+	if poll.HasVoted(playerID) {
+		return ErrAlreadyVoted
+	}
+	poll.Vote(playerID, option)
+
+	gs.BroadcastLobbyUpdate(lobbyHub, "poll_updated", map[string]interface{}{
+		"poll_id": poll.ID,
+		"tally":   poll.Tally,
+		"votes":   len(poll.Votes),
+	})
+
+	if gs.everyoneVoted(lobby, poll) {
+		gs.finishPoll(lobbyHub)
+	}
+	return nil
+}
+
+func (gs *GameService) everyoneVoted(lobby *models.Lobby, poll *models.Poll) bool {
+	voters := 0
+	for _, player := range lobby.Players {
+		if !player.IsHuman() {
+			continue
+		}
+		if !poll.HasVoted(player.ID) {
+			return false
+		}
+		voters++
+	}
+	return voters > 0
+}
+
+// closePoll runs when the voting window ends, unless the poll already
+// closed early.
+func (gs *GameService) closePoll(lobbyHub *hub.LobbyHub, pollID string) {
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+	if lobby.Poll == nil || lobby.Poll.ID != pollID || lobby.Poll.Closed {
+		return
+	}
+	gs.finishPoll(lobbyHub)
+}
+
+// finishPoll tallies the open poll and applies its result. The caller holds
+// the lobby lock.
+func (gs *GameService) finishPoll(lobbyHub *hub.LobbyHub) {
+	lobby := lobbyHub.GetLobby()
+	poll := lobby.Poll
+	poll.Close()
+
+	applied := false
+	if poll.Apply == models.PollCategory && poll.Winner != nil && lobby.Phase != game.Finished {
+		gs.applyPollCategory(lobby, poll.WinningOption())
+		applied = true
+		gs.repo.SaveLobby(lobby)
+	}
+
+	log.Printf("Lobby %s: poll %s closed, winner %q (applied: %v)", lobby.ID, poll.ID, poll.WinningOption(), applied)
+	gs.BroadcastLobbyUpdate(lobbyHub, "poll_closed", map[string]interface{}{
+		"poll":    api.FromPoll(poll),
+		"winner":  poll.WinningOption(),
+		"applied": applied,
+	})
+}
+
+// applyPollCategory serves category from the next unserved round. Before the
+// game starts it replaces the category weights, which the plan is built
+// from at start.
+func (gs *GameService) applyPollCategory(lobby *models.Lobby, category string) {
+	if lobby.Phase == game.Waiting {
+		lobby.CategoryWeights = map[string]int{category: 1}
+		return
+	}
+	next := lobby.Round
+	if lobby.Phase == game.Question {
+		next++
+	}
+	lobby.PlanCategory(next, category)
+}
@@ -0,0 +1,62 @@
+package services_test
+
+import (
+	"testing"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// Correct answers earn position bonuses in the order they arrive, and
+// recomputation gives each the bonus for the place it was recorded in.
+func TestPositionBonuses(t *testing.T) {
+	gs, gameHub, repo := newService(t)
+	growing := []int{10, 20}
+	if _, err := gs.CreateLobby(services.LobbyOptions{Name: "Bad bonuses", MaxRounds: 1, Scoring: &models.ScoringRules{PositionBonuses: &growing}}); err == nil {
+		t.Fatal("Expected growing position bonuses refused")
+	}
+	bonuses := []int{30, 20}
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "First past the post", MaxRounds: 2, MaxPlayers: 4, Scoring: &models.ScoringRules{PositionBonuses: &bonuses}})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	var playerIDs []string
+	for _, name := range []string{"alice", "bob", "carol"} {
+		_, player, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: name})
+		playerIDs = append(playerIDs, player.ID)
+	}
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+
+	current := gameHub.GetLobbyHub(lobby.ID).GetLobby()
+	current.Lock()
+	question := current.CurrentQ
+	scoring := current.Scoring
+	current.Unlock()
+	for _, playerID := range playerIDs {
+		if err := gs.SubmitAnswer(lobby.ID, playerID, correctAnswer(question)); err != nil {
+			t.Fatalf("SubmitAnswer: %v", err)
+		}
+	}
+
+	answers, _ := repo.GetLobbyAnswers(lobby.ID)
+	if len(answers) != 3 {
+		t.Fatalf("Expected three answers, got %d", len(answers))
+	}
+	for i, answer := range answers {
+		want := scoring.Score(question, correctAnswer(question), answer.ResponseTime, 0) + scoring.PositionBonus(i+1)
+		if answer.Position != i+1 || answer.Score != want {
+			t.Fatalf("Expected answer %d in place %d scoring %d, got %+v", i, i+1, want, answer)
+		}
+	}
+
+	gs.ForceEndGame(lobby.ID)
+	result, err := gs.RecomputeScores(lobby.ID, "", false, "test")
+	if err != nil {
+		t.Fatalf("RecomputeScores: %v", err)
+	}
+	if result.ChangedAnswers != 0 {
+		t.Fatalf("Expected the recorded positions rescored the same, got %+v", result)
+	}
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"math/rand"
+
+	"buildprize-game/internal/models"
+)
+
+// fiftyFiftyMinOptions is the fewest options a question needs for FiftyFifty
+// to leave more than the right answer.
+const fiftyFiftyMinOptions = 4
+
+// UsePowerUp spends one of the player's power-ups on the open question,
+// before they answer it; one power-up per question. FiftyFifty's removed
+// options go to the player alone, as fifty_fifty. The lobby hears that the
+// power-up was used, but not what it revealed.
+func (gs *GameService) UsePowerUp(lobbyID, playerID string, powerUp models.PowerUp) error {
+	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
+	if lobbyHub == nil {
+		return ErrLobbyNotFound
+	}
+
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+	if !lobby.IsQuestionActive() {
+		return ErrQuestionNotActive
+	}
+	player := lobby.GetPlayer(playerID)
+	if player == nil {
+		return ErrPlayerNotFound
+	}
+	if lobby.HasAnswered(playerID) {
+		return ErrAlreadyAnswered
+	}
+	if lobby.PowerUpsUsed[playerID] != nil {
+		return ErrPowerUpAlreadyUsed
+	}
+	question := lobby.CurrentQ
+	if powerUp == models.FiftyFifty && (question.QuestionType() != models.SingleChoice || len(question.Options) < fiftyFiftyMinOptions) {
+		return ErrPowerUpNotApplicable
+	}
+	if !player.TakePowerUp(powerUp) {
+		return ErrNoPowerUp
+	}
+
+	use := &models.PowerUpUse{PlayerID: playerID, Username: player.Username, PowerUp: powerUp}
+	switch powerUp {
+	case models.FiftyFifty:
+		use.Removed = wrongOptions(question, 2)
+	case models.Freeze:
+		use.FrozenAt = lobby.ResponseTimeAt(models.Now())
+	}
+	if lobby.PowerUpsUsed == nil {
+		lobby.PowerUpsUsed = make(map[string]*models.PowerUpUse)
+	}
+	lobby.PowerUpsUsed[playerID] = use
+	gs.repo.SaveLobby(lobby)
+
+	gs.BroadcastLobbyUpdate(lobbyHub, "power_up_used", map[string]interface{}{
+		"player_id": playerID,
+		"power_up":  powerUp,
+		"round":     lobby.Round,
+	})
+	if use.Removed != nil {
+		gs.SendPersonalEvent(lobbyHub, playerID, "fifty_fifty", map[string]interface{}{
+			"round":           lobby.Round,
+			"removed_options": use.Removed,
+		})
+	}
+	return nil
+}
+
+// wrongOptions picks n of a single-choice question's wrong options at random.
+func wrongOptions(question *models.Question, n int) []int {
+	var wrong []int
+	for i := range question.Options {
+		if i != question.Correct {
+			wrong = append(wrong, i)
+		}
+	}
+	rand.Shuffle(len(wrong), func(i, j int) { wrong[i], wrong[j] = wrong[j], wrong[i] })
+	return wrong[:n]
+}
+
+// applyPowerUp returns the response time an answer is scored with and the
+// power-up used on it, if any. The caller holds the lobby lock.
+func applyPowerUp(lobby *models.Lobby, playerID string, responseTime int64) (int64, models.PowerUp) {
+	use := lobby.PowerUpsUsed[playerID]
+	if use == nil {
+		return responseTime, ""
+	}
+	if use.PowerUp == models.Freeze && use.FrozenAt < responseTime {
+		responseTime = use.FrozenAt
+	}
+	return responseTime, use.PowerUp
+}
+
+// awardPowerUp gives player a power-up for reaching a streak milestone,
+// returning it, or "" if the streak earns none. The caller holds the lobby
+// lock.
+func awardPowerUp(player *models.Player) models.PowerUp {
+	powerUp := streakMilestones[player.Streak]
+	if powerUp != "" {
+		player.AwardPowerUp(powerUp)
+	}
+	return powerUp
+}
+
+// powerUpUses lists the power-ups used on the current question in seating
+// order. The caller holds the lobby lock.
+func powerUpUses(lobby *models.Lobby) []*models.PowerUpUse {
+	var uses []*models.PowerUpUse
+	for _, player := range lobby.Players {
+		if use := lobby.PowerUpsUsed[player.ID]; use != nil {
+			uses = append(uses, use)
+		}
+	}
+	return uses
+}
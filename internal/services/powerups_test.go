@@ -0,0 +1,61 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// A power-up is spent from the player's inventory, once per question, and
+// double points doubles the answer's score.
+func TestPowerUps(t *testing.T) {
+	gs, gameHub, repo := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Power-ups", MaxRounds: 2, MaxPlayers: 4})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	_, player, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"})
+	_, other, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "bob"})
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+
+	if err := gs.UsePowerUp(lobby.ID, other.ID, models.DoublePoints); !errors.Is(err, services.ErrNoPowerUp) {
+		t.Fatalf("Expected ErrNoPowerUp without one to spend, got %v", err)
+	}
+
+	current := gameHub.GetLobbyHub(lobby.ID).GetLobby()
+	current.Lock()
+	current.GetPlayer(player.ID).AwardPowerUp(models.DoublePoints)
+	current.GetPlayer(player.ID).AwardPowerUp(models.Freeze)
+	question := current.CurrentQ
+	current.Unlock()
+
+	if err := gs.UsePowerUp(lobby.ID, player.ID, models.DoublePoints); err != nil {
+		t.Fatalf("UsePowerUp: %v", err)
+	}
+	if err := gs.UsePowerUp(lobby.ID, player.ID, models.Freeze); !errors.Is(err, services.ErrPowerUpAlreadyUsed) {
+		t.Fatalf("Expected ErrPowerUpAlreadyUsed for a second power-up, got %v", err)
+	}
+
+	answer := correctAnswer(question)
+	if err := gs.SubmitAnswer(lobby.ID, player.ID, answer); err != nil {
+		t.Fatalf("SubmitAnswer: %v", err)
+	}
+	answers, _ := repo.GetLobbyAnswers(lobby.ID)
+	if len(answers) != 1 || answers[0].PowerUp != models.DoublePoints {
+		t.Fatalf("Expected one answer recorded with double points, got %+v", answers)
+	}
+	if want := 2 * models.DefaultScoringConfig.Score(question, answer, answers[0].ResponseTime, 0); answers[0].Score != want {
+		t.Fatalf("Expected double points to score %d, got %d", want, answers[0].Score)
+	}
+
+	current.Lock()
+	left := current.GetPlayer(player.ID).PowerUps
+	current.Unlock()
+	if left[models.DoublePoints] != 0 || left[models.Freeze] != 1 {
+		t.Fatalf("Expected only double points spent, got %v", left)
+	}
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+)
+
+// prefetchAhead is how many upcoming rounds of generated questions a topic
+// lobby keeps queued, so a round never waits on the generator.
+const prefetchAhead = 3
+
+// QuestionCacheStats describes how well prefetching keeps up.
+type QuestionCacheStats struct {
+	Hits        int64 `json:"hits"`                // topic rounds served a prefetched question
+	Misses      int64 `json:"misses"`              // topic rounds that fell back to the question bank
+	Fetches     int64 `json:"fetches"`             // generator requests made
+	FetchErrors int64 `json:"fetch_errors"`        // generator requests that failed
+	Fetched     int64 `json:"questions_fetched"`   // validated questions received
+	Discarded   int64 `json:"questions_discarded"` // duplicates, or arrived after the game ended
+	AvgFetchMs  int64 `json:"avg_fetch_ms"`
+	InFlight    int   `json:"in_flight"`
+}
+
+// questionPrefetcher generates topic questions in the background, at most one
+// request per lobby at a time.
+type questionPrefetcher struct {
+	gen *QuestionGenerator
+
+	mu           sync.Mutex
+	inFlight     map[string]*prefetchRequest // lobbyID -> running request
+	stats        QuestionCacheStats
+	totalFetchMs int64
+}
+
+type prefetchRequest struct {
+	cancel context.CancelFunc
+}
+
+func newQuestionPrefetcher(gen *QuestionGenerator) *questionPrefetcher {
+	return &questionPrefetcher{
+		gen:      gen,
+		inFlight: make(map[string]*prefetchRequest),
+	}
+}
+
+// questionsToServe counts the rounds that still need a question.
+func questionsToServe(lobby *models.Lobby) int {
+	switch lobby.State {
+	case models.Waiting:
+		return lobby.MaxRounds
+	case models.InProgress:
+		remaining := lobby.MaxRounds - lobby.Round
+		if lobby.CurrentQ == nil {
+			remaining++
+		}
+		return remaining
+	}
+	return 0
+}
+
+// prefetchQuestions tops up the lobby's question queue in the background.
+// The caller holds the lobby lock.
+func (gs *GameService) prefetchQuestions(lobbyHub *hub.LobbyHub) {
+	lobby := lobbyHub.GetLobby()
+	p := gs.prefetcher
+	if p == nil || lobby.Topic == "" {
+		return
+	}
+
+	want := questionsToServe(lobby)
+	if want > prefetchAhead {
+		want = prefetchAhead
+	}
+	want -= len(lobby.QuestionQueue)
+	if want <= 0 {
+		return
+	}
+
+	p.mu.Lock()
+	if p.inFlight[lobby.ID] != nil {
+		p.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	req := &prefetchRequest{cancel: cancel}
+	p.inFlight[lobby.ID] = req
+	p.stats.Fetches++
+	p.mu.Unlock()
+
+	go gs.runPrefetch(ctx, lobbyHub, req, lobby.ID, lobby.Topic, want)
+}
+
+func (gs *GameService) runPrefetch(ctx context.Context, lobbyHub *hub.LobbyHub, req *prefetchRequest, lobbyID, topic string, count int) {
+	p := gs.prefetcher
+	started := time.Now()
+	questions, err := p.gen.GenerateContext(ctx, topic, count)
+	elapsed := time.Since(started).Milliseconds()
+	canceled := ctx.Err() != nil
+
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.inFlight[lobbyID] == req {
+		delete(p.inFlight, lobbyID)
+	}
+	req.cancel()
+	p.totalFetchMs += elapsed
+
+	if err != nil {
+		p.stats.FetchErrors++
+		if !canceled {
+			log.Printf("Prefetching %d question(s) for lobby %s (topic %q) failed, rounds will use the question bank: %v", count, lobbyID, topic, err)
+		}
+		return
+	}
+	p.stats.Fetched += int64(len(questions))
+
+	// The game may have ended, or the lobby gone, while the request ran
+	if canceled || gs.hub.GetLobbyHub(lobbyID) != lobbyHub || questionsToServe(lobby) == 0 {
+		p.stats.Discarded += int64(len(questions))
+		return
+	}
+
+	// Drop near-duplicates of bank questions and of ones already queued
+	linter := NewQuestionLinter(gs.questionDB.questions)
+	for _, q := range lobby.QuestionQueue {
+		linter.remember(q)
+	}
+	added := 0
+	for _, q := range questions {
+		if hasLintErrors(linter.Check(q)) {
+			p.stats.Discarded++
+			continue
+		}
+		lobby.QuestionQueue = append(lobby.QuestionQueue, q)
+		added++
+	}
+	log.Printf("Prefetched %d question(s) for lobby %s on topic %q in %dms (%d queued)", added, lobbyID, topic, elapsed, len(lobby.QuestionQueue))
+}
+
+// nextPrefetchedQuestion pops the next prepared question and records whether
+// a topic round found one ready. The caller holds the lobby lock.
+func (gs *GameService) nextPrefetchedQuestion(lobbyHub *hub.LobbyHub) *models.Question {
+	lobby := lobbyHub.GetLobby()
+	question := lobby.NextQueuedQuestion()
+
+	if p := gs.prefetcher; p != nil && lobby.Topic != "" {
+		p.mu.Lock()
+		if question != nil {
+			p.stats.Hits++
+		} else {
+			p.stats.Misses++
+			log.Printf("No prefetched question ready for lobby %s round %d, using question bank", lobby.ID, lobby.Round)
+		}
+		p.mu.Unlock()
+	}
+	return question
+}
+
+// cancelPrefetch aborts the lobby's running generator request, if any.
+func (gs *GameService) cancelPrefetch(lobbyID string) {
+	p := gs.prefetcher
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if req := p.inFlight[lobbyID]; req != nil {
+		req.cancel()
+		delete(p.inFlight, lobbyID)
+	}
+}
+
+// QuestionCacheStats reports prefetch metrics; all zero without a generator.
+func (gs *GameService) QuestionCacheStats() QuestionCacheStats {
+	p := gs.prefetcher
+	if p == nil {
+		return QuestionCacheStats{}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := p.stats
+	stats.InFlight = len(p.inFlight)
+	if completed := stats.Fetches - int64(stats.InFlight); completed > 0 {
+		stats.AvgFetchMs = p.totalFetchMs / completed
+	}
+	return stats
+}
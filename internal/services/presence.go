@@ -0,0 +1,67 @@
+package services
+
+import (
+	"log"
+
+	"buildprize-game/internal/api"
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+)
+
+// PlayerConnected counts the WebSocket connection connID, registered with
+// the lobby as playerID, towards the player's presence. A player coming back after their
+// last connection dropped is announced with player_reconnected; it reports
+// whether that happened, so the caller can announce a first connection
+// itself. Connections to a lobby hosted on another instance aren't counted.
+func (gs *GameService) PlayerConnected(lobbyHub *hub.LobbyHub, playerID, connID string) bool {
+	if lobbyHub.IsMirror() || playerID == "" {
+		return false
+	}
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+
+	seen := false
+	if player := lobby.GetPlayer(playerID); player != nil {
+		seen = player.LastSeen != nil
+	}
+	player, online := lobby.Connect(playerID, connID, models.Now())
+	if online {
+		gs.touchLobby(lobbyHub, lobby)
+	}
+	if !online || !seen {
+		return false
+	}
+	log.Printf("Player %s reconnected to lobby %s", player.Username, lobby.ID)
+	gs.BroadcastLobbyUpdate(lobbyHub, "player_reconnected", map[string]interface{}{
+		"player_id": player.ID,
+		"username":  player.Username,
+		"lobby":     api.FromLobby(lobby),
+	})
+	return true
+}
+
+// PlayerDisconnected drops connection connID from playerID's presence, and
+// announces player_disconnected with when they were last_seen if it was
+// their last one. The player stays in the lobby, greyed out, until they
+// reconnect or leave.
+func (gs *GameService) PlayerDisconnected(lobbyHub *hub.LobbyHub, playerID, connID string) {
+	if lobbyHub.IsMirror() || playerID == "" {
+		return
+	}
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+
+	player, offline := lobby.Disconnect(playerID, connID, models.Now())
+	if !offline {
+		return
+	}
+	log.Printf("Player %s dropped off lobby %s", player.Username, lobby.ID)
+	gs.BroadcastLobbyUpdate(lobbyHub, "player_disconnected", map[string]interface{}{
+		"player_id": player.ID,
+		"username":  player.Username,
+		"last_seen": player.LastSeen,
+		"lobby":     api.FromLobby(lobby),
+	})
+}
@@ -0,0 +1,103 @@
+package services_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/api"
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/services"
+)
+
+// Players are shown online while they have a connection open. Dropping the
+// last one is announced with player_disconnected and coming back with
+// player_reconnected; a second tab closing changes nothing.
+func TestPlayerPresence(t *testing.T) {
+	gs, gameHub, _ := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Presence", MaxRounds: 3, MaxPlayers: 4})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	lobbyHub := gameHub.GetLobbyHub(lobby.ID)
+	watcher := &hub.Client{ID: "watcher", LobbyID: lobby.ID, Send: make(chan []byte, 64)}
+	lobbyHub.Register(watcher)
+
+	aliceConn := &hub.Client{ID: "alice-1", LobbyID: lobby.ID, Send: make(chan []byte, 64)}
+	lobbyHub.Register(aliceConn)
+	_, alice, err := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice", Client: aliceConn})
+	if err != nil {
+		t.Fatalf("JoinLobby: %v", err)
+	}
+	_, bob, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "bob"})
+
+	online := func(playerID string) (bool, *time.Time) {
+		t.Helper()
+		for _, p := range api.LobbySnapshot(lobby).Players {
+			if p.ID == playerID {
+				return p.Online, p.LastSeen
+			}
+		}
+		t.Fatalf("Player %s not in the lobby", playerID)
+		return false, nil
+	}
+	if up, _ := online(alice.ID); !up {
+		t.Fatal("Expected alice online, joined over WebSocket")
+	}
+	if up, _ := online(bob.ID); up {
+		t.Fatal("Expected bob offline until he connects")
+	}
+
+	if gs.PlayerConnected(lobbyHub, bob.ID, "bob-1") {
+		t.Fatal("Expected a first connection not announced as a reconnect")
+	}
+	gs.PlayerConnected(lobbyHub, bob.ID, "bob-2")
+	gs.PlayerDisconnected(lobbyHub, bob.ID, "bob-1")
+	if up, _ := online(bob.ID); !up {
+		t.Fatal("Expected bob online with his second tab open")
+	}
+
+	gs.PlayerDisconnected(lobbyHub, alice.ID, aliceConn.ID)
+	event := presenceEvent(t, watcher, "player_disconnected")
+	if event.PlayerID != alice.ID || event.LastSeen == nil {
+		t.Fatalf("Expected alice's disconnect with last_seen, got %+v", event)
+	}
+	if up, lastSeen := online(alice.ID); up || lastSeen == nil {
+		t.Fatalf("Expected alice offline with a last_seen, got online=%v last_seen=%v", up, lastSeen)
+	}
+
+	if !gs.PlayerConnected(lobbyHub, alice.ID, "alice-2") {
+		t.Fatal("Expected alice's return announced as a reconnect")
+	}
+	if event := presenceEvent(t, watcher, "player_reconnected"); event.PlayerID != alice.ID {
+		t.Fatalf("Expected alice's reconnect, got %+v", event)
+	}
+	if up, _ := online(alice.ID); !up {
+		t.Fatal("Expected alice online again")
+	}
+}
+
+type presence struct {
+	PlayerID string     `json:"player_id"`
+	LastSeen *time.Time `json:"last_seen"`
+}
+
+func presenceEvent(t *testing.T, client *hub.Client, eventType string) presence {
+	t.Helper()
+	deadline := time.After(2 * time.Second)
+	for {
+		select {
+		case payload := <-client.Send:
+			var event struct {
+				Type string   `json:"type"`
+				Data presence `json:"data"`
+			}
+			json.Unmarshal(payload, &event)
+			if event.Type == eventType {
+				return event.Data
+			}
+		case <-deadline:
+			t.Fatalf("%s never arrived", eventType)
+		}
+	}
+}
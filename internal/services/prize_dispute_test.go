@@ -0,0 +1,90 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// A prize game's results stay provisional through the dispute window and
+// until every dispute is resolved; only then is the winner's payout recorded.
+func TestPrizeResultDisputes(t *testing.T) {
+	gs, _, _ := newService(t)
+	gs.SetDisputeWindow(300 * time.Millisecond)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Prize", MaxRounds: 3, MaxPlayers: 4, Prize: "$50 gift card"})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	_, alice, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"})
+	_, bob, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "bob"})
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	lobby.Lock()
+	question := lobby.CurrentQ
+	lobby.Unlock()
+	if err := gs.SubmitAnswer(lobby.ID, alice.ID, correctAnswer(question)); err != nil {
+		t.Fatalf("SubmitAnswer: %v", err)
+	}
+	if err := gs.SubmitAnswer(lobby.ID, bob.ID, wrongAnswer(question)); err != nil {
+		t.Fatalf("SubmitAnswer: %v", err)
+	}
+	if err := gs.ForceEndGame(lobby.ID); err != nil {
+		t.Fatalf("ForceEndGame: %v", err)
+	}
+
+	result, err := gs.PrizeResult(lobby.ID)
+	if err != nil {
+		t.Fatalf("PrizeResult: %v", err)
+	}
+	if result.Status != models.ResultsProvisional || len(result.Standings) != 2 || result.Standings[0].PlayerID != alice.ID {
+		t.Fatalf("Expected provisional results led by alice, got %+v", result)
+	}
+
+	dispute, err := gs.FileDispute(lobby.ID, bob.ID, "my answer was right")
+	if err != nil {
+		t.Fatalf("FileDispute: %v", err)
+	}
+	if _, err := gs.FileDispute(lobby.ID, bob.ID, "again"); !errors.Is(err, services.ErrAlreadyDisputed) {
+		t.Fatalf("Expected ErrAlreadyDisputed, got %v", err)
+	}
+	if _, err := gs.FileDispute(lobby.ID, "stranger", "let me in"); !errors.Is(err, services.ErrNotInResults) {
+		t.Fatalf("Expected ErrNotInResults, got %v", err)
+	}
+
+	time.Sleep(350 * time.Millisecond)
+	if _, err := gs.FileDispute(lobby.ID, alice.ID, "too late"); !errors.Is(err, services.ErrDisputeWindowClosed) {
+		t.Fatalf("Expected ErrDisputeWindowClosed, got %v", err)
+	}
+	if finalized, err := gs.FinalizeDueResults(); err != nil || len(finalized) != 0 {
+		t.Fatalf("Expected results held for the open dispute, got %d finalized (%v)", len(finalized), err)
+	}
+
+	resolved, err := gs.ResolveDispute(lobby.ID, dispute.ID, services.DisputeResolution{
+		Status: models.DisputeUpheld, Note: "checked", Recompute: true,
+	}, "referee")
+	if err != nil {
+		t.Fatalf("ResolveDispute: %v", err)
+	}
+	if d := resolved.FindDispute(dispute.ID); d.Status != models.DisputeUpheld || d.ResolvedBy != "referee" || d.RescoredWith == "" {
+		t.Fatalf("Expected the dispute upheld and rescored, got %+v", d)
+	}
+	if _, err := gs.ResolveDispute(lobby.ID, dispute.ID, services.DisputeResolution{Status: models.DisputeRejected}, "referee"); !errors.Is(err, services.ErrDisputeResolved) {
+		t.Fatalf("Expected ErrDisputeResolved, got %v", err)
+	}
+
+	finalized, err := gs.FinalizeDueResults()
+	if err != nil || len(finalized) != 1 {
+		t.Fatalf("Expected the results finalized, got %d (%v)", len(finalized), err)
+	}
+	result, _ = gs.PrizeResult(lobby.ID)
+	if result.Status != models.ResultsFinalized || len(result.Payouts) != 1 || result.Payouts[0].PlayerID != alice.ID {
+		t.Fatalf("Expected alice's payout recorded, got %+v", result)
+	}
+	if _, err := gs.FileDispute(lobby.ID, bob.ID, "one more"); !errors.Is(err, services.ErrResultsFinal) {
+		t.Fatalf("Expected ErrResultsFinal, got %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"log"
+
+	"buildprize-game/internal/models"
+)
+
+// claimCodeAlphabet leaves out characters easily misread for each other.
+const claimCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+const claimCodeLength = 12
+
+// allocatePrizePool shares a result's prize pool between its standings as
+// they are ranked now.
+func allocatePrizePool(result *models.PrizeResult) {
+	if result.PrizePool != nil {
+		result.Allocations = result.PrizePool.Allocate(result.Standings)
+	}
+}
+
+// issueClaimCodes gives every share of a newly final result's prize pool a
+// claim code to collect it with.
+func issueClaimCodes(result *models.PrizeResult) error {
+	for _, allocation := range result.Allocations {
+		code, err := newClaimCode()
+		if err != nil {
+			return err
+		}
+		allocation.ClaimCode = code
+		allocation.Status = models.AllocationUnclaimed
+	}
+	return nil
+}
+
+func newClaimCode() (string, error) {
+	random := make([]byte, claimCodeLength)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	code := make([]byte, claimCodeLength)
+	for i, b := range random {
+		code[i] = claimCodeAlphabet[int(b)%len(claimCodeAlphabet)]
+	}
+	return string(code), nil
+}
+
+// sendClaimCodes tells each finisher owed a share of the prize pool their
+// claim code as prize_claim_code, if the lobby is still around. Players
+// who aren't connected get it when they next connect, and can always fetch
+// it from the lobby's prizes with their session.
+func (gs *GameService) sendClaimCodes(result *models.PrizeResult) {
+	lobbyHub := gs.hub.GetLobbyHub(result.LobbyID)
+	if lobbyHub == nil || len(result.Allocations) == 0 {
+		return
+	}
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+	for _, allocation := range result.Allocations {
+		gs.SendPersonalEvent(lobbyHub, allocation.PlayerID, "prize_claim_code", map[string]interface{}{
+			"rank":       allocation.Rank,
+			"amount":     allocation.Amount,
+			"currency":   allocation.Currency,
+			"claim_code": allocation.ClaimCode,
+		})
+	}
+}
+
+// Prizes returns the results of a lobby with a prize pool, with each top
+// finisher's share.
+func (gs *GameService) Prizes(lobbyID string) (*models.PrizeResult, error) {
+	result, err := gs.PrizeResult(lobbyID)
+	if err != nil {
+		return nil, err
+	}
+	if result.PrizePool == nil {
+		return nil, ErrNoPrizePool
+	}
+	return result, nil
+}
+
+// ClaimPrize collects the prize pool share a claim code was issued for,
+// once the results are final. Each share is claimed once. With a payout
+// provider configured, the share is paid to destination in the background;
+// otherwise destination is ignored and the prize is paid by hand.
+func (gs *GameService) ClaimPrize(lobbyID, claimCode, destination string) (*models.Allocation, error) {
+	gs.resultsMu.Lock()
+	defer gs.resultsMu.Unlock()
+	result, err := gs.Prizes(lobbyID)
+	if err != nil {
+		return nil, err
+	}
+	if result.Status != models.ResultsFinalized {
+		return nil, ErrResultsNotFinal
+	}
+	var allocation *models.Allocation
+	for _, candidate := range result.Allocations {
+		if candidate.ClaimCode != "" && subtle.ConstantTimeCompare([]byte(candidate.ClaimCode), []byte(claimCode)) == 1 {
+			allocation = candidate
+		}
+	}
+	if allocation == nil {
+		return nil, ErrInvalidClaimCode
+	}
+	if allocation.Status != models.AllocationUnclaimed {
+		return nil, ErrPrizeClaimed
+	}
+	provider := gs.currentPayoutProvider()
+	if provider != nil {
+		if err := provider.CheckDestination(destination); err != nil {
+			return nil, err
+		}
+		allocation.Destination = destination
+		allocation.PayoutProvider = provider.Name()
+		allocation.PayoutStatus = models.PayoutSending
+		allocation.PayoutAttempts = 1
+	}
+
+	now := models.Now()
+	allocation.Status = models.AllocationClaimed
+	allocation.ClaimedAt = &now
+	if err := gs.repo.SavePrizeResult(result); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Player %s claimed their prize pool share in lobby %s (rank %d)", allocation.PlayerID, lobbyID, allocation.Rank)
+	gs.announceResults(lobbyID, "prize_claimed", map[string]interface{}{
+		"player_id": allocation.PlayerID,
+		"username":  allocation.Username,
+		"rank":      allocation.Rank,
+	})
+	if provider != nil {
+		go gs.sendPayout(lobbyID, allocation.PlayerID)
+	}
+	copied := *allocation
+	return &copied, nil
+}
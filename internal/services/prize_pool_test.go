@@ -0,0 +1,87 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// A prize pool is shared between the top finishers when the game ends, and
+// once the results are final each share can be claimed once with the claim
+// code issued for it.
+func TestPrizePool(t *testing.T) {
+	gs, _, _ := newService(t)
+	gs.SetDisputeWindow(0)
+	if _, err := gs.CreateLobby(services.LobbyOptions{Name: "Bad pool", MaxRounds: 3, MaxPlayers: 4,
+		PrizePool: &models.PrizePool{Amount: 1000, Currency: "USD", Split: []int{60, 30}}}); !errors.Is(err, services.ErrInvalidPrizePool) {
+		t.Fatalf("Expected ErrInvalidPrizePool for a split short of 100, got %v", err)
+	}
+
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Pool", MaxRounds: 3, MaxPlayers: 4,
+		PrizePool: &models.PrizePool{Amount: 1000, Currency: "USD", Split: []int{80, 20}}})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	_, alice, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"})
+	_, bob, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "bob"})
+	_, carol, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "carol"})
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	lobby.Lock()
+	question := lobby.CurrentQ
+	lobby.Unlock()
+	if err := gs.SubmitAnswer(lobby.ID, alice.ID, correctAnswer(question)); err != nil {
+		t.Fatalf("SubmitAnswer: %v", err)
+	}
+	if err := gs.SubmitAnswer(lobby.ID, bob.ID, wrongAnswer(question)); err != nil {
+		t.Fatalf("SubmitAnswer: %v", err)
+	}
+	if err := gs.SubmitAnswer(lobby.ID, carol.ID, wrongAnswer(question)); err != nil {
+		t.Fatalf("SubmitAnswer: %v", err)
+	}
+	if err := gs.ForceEndGame(lobby.ID); err != nil {
+		t.Fatalf("ForceEndGame: %v", err)
+	}
+
+	result, err := gs.Prizes(lobby.ID)
+	if err != nil {
+		t.Fatalf("Prizes: %v", err)
+	}
+	// bob and carol tie for second and split its share
+	if len(result.Allocations) != 3 || result.Allocations[0].PlayerID != alice.ID || result.Allocations[0].Amount != 800 ||
+		result.Allocations[1].Amount != 100 || result.Allocations[2].Amount != 100 {
+		t.Fatalf("Expected 800 for alice and 100 each for bob and carol, got %+v", result.Allocations)
+	}
+	if _, err := gs.ClaimPrize(lobby.ID, "anything", ""); !errors.Is(err, services.ErrResultsNotFinal) {
+		t.Fatalf("Expected ErrResultsNotFinal before finalizing, got %v", err)
+	}
+
+	if finalized, err := gs.FinalizeDueResults(); err != nil || len(finalized) != 1 {
+		t.Fatalf("Expected the results finalized, got %d (%v)", len(finalized), err)
+	}
+	result, _ = gs.Prizes(lobby.ID)
+	code := result.FindAllocation(alice.ID).ClaimCode
+	if code == "" || code == result.FindAllocation(bob.ID).ClaimCode {
+		t.Fatalf("Expected distinct claim codes, got %+v", result.Allocations)
+	}
+
+	if _, err := gs.ClaimPrize(lobby.ID, "NOTACODE", ""); !errors.Is(err, services.ErrInvalidClaimCode) {
+		t.Fatalf("Expected ErrInvalidClaimCode, got %v", err)
+	}
+	claimed, err := gs.ClaimPrize(lobby.ID, code, "")
+	if err != nil {
+		t.Fatalf("ClaimPrize: %v", err)
+	}
+	if claimed.PlayerID != alice.ID || claimed.Status != models.AllocationClaimed || claimed.ClaimedAt == nil {
+		t.Fatalf("Expected alice's share claimed, got %+v", claimed)
+	}
+	if _, err := gs.ClaimPrize(lobby.ID, code, ""); !errors.Is(err, services.ErrPrizeClaimed) {
+		t.Fatalf("Expected ErrPrizeClaimed on a second claim, got %v", err)
+	}
+	if result, _ := gs.Prizes(lobby.ID); result.FindAllocation(bob.ID).Status != models.AllocationUnclaimed {
+		t.Fatalf("Expected bob's share still unclaimed, got %+v", result.FindAllocation(bob.ID))
+	}
+}
@@ -0,0 +1,291 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"buildprize-game/internal/api"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/repository"
+)
+
+// defaultDisputeWindow is how long a prize game's results stay provisional,
+// unless changed with SetDisputeWindow.
+const defaultDisputeWindow = 24 * time.Hour
+
+// DisputeResolution is an admin's decision on a dispute.
+type DisputeResolution struct {
+	Status models.DisputeStatus // upheld or rejected
+	Note   string               // shown to players with the dispute
+
+	// Re-score the game from its answer history and re-rank the standings,
+	// under ScoringVersion or the version the game was played under when
+	// empty. Only upheld disputes are recomputed.
+	Recompute      bool
+	ScoringVersion string
+}
+
+// SetDisputeWindow sets how long a prize game's results are open to
+// disputes after it ends. Negative durations are ignored; with zero,
+// results are finalized at the next check.
+func (gs *GameService) SetDisputeWindow(window time.Duration) {
+	if window < 0 {
+		return
+	}
+	gs.mu.Lock()
+	gs.disputeWindow = window
+	gs.mu.Unlock()
+}
+
+// recordPrizeResult stores the provisional results of a prize game that has
+// just ended, ranking its human players and allocating any prize pool, and
+// returns them. It returns nil for games without a prize or prize pool and
+// for sandboxes. The caller holds the lobby lock.
+func (gs *GameService) recordPrizeResult(lobby *models.Lobby, leaderboard []*models.Player) *models.PrizeResult {
+	if (lobby.Prize == "" && lobby.PrizePool == nil) || lobby.Sandbox {
+		return nil
+	}
+	gs.mu.Lock()
+	window := gs.disputeWindow
+	gs.mu.Unlock()
+
+	result := &models.PrizeResult{
+		LobbyID:      lobby.ID,
+		LobbyName:    lobby.Name,
+		Prize:        lobby.Prize,
+		Status:       models.ResultsProvisional,
+		EndedAt:      *lobby.FinishedAt,
+		DisputeUntil: lobby.FinishedAt.Add(window),
+		Standings:    []models.Standing{},
+		PrizePool:    lobby.PrizePool,
+	}
+	for _, player := range leaderboard {
+		if player.IsHuman() {
+			result.Standings = append(result.Standings, models.Standing{PlayerID: player.ID, Username: player.Username, UserID: player.UserID, Score: player.Score})
+		}
+	}
+	models.RankStandings(result.Standings)
+	allocatePrizePool(result)
+
+	if err := gs.repo.SavePrizeResult(result); err != nil {
+		log.Printf("ALERT: failed to save prize results for lobby %s: %v", lobby.ID, err)
+	} else {
+		log.Printf("Prize results for lobby %s are provisional until %s", lobby.ID, models.FormatTimestamp(result.DisputeUntil))
+	}
+	return result
+}
+
+// PrizeResult returns a prize game's results.
+func (gs *GameService) PrizeResult(lobbyID string) (*models.PrizeResult, error) {
+	result, err := gs.repo.GetPrizeResult(lobbyID)
+	if errors.Is(err, repository.ErrPrizeResultNotFound) {
+		return nil, ErrNoPrizeResult
+	}
+	return result, err
+}
+
+// PrizeResults lists prize game results with the given status, or all of
+// them for an empty status, most recently ended first.
+func (gs *GameService) PrizeResults(status models.ResultsStatus) ([]*models.PrizeResult, error) {
+	return gs.repo.ListPrizeResults(status)
+}
+
+// FileDispute records a player's dispute of a prize game's provisional
+// results. Only players in the results may dispute them, one open dispute
+// each, until the dispute window closes.
+func (gs *GameService) FileDispute(lobbyID, playerID, reason string) (*models.Dispute, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" || models.Graphemes(reason) > models.MaxDisputeReasonLength {
+		return nil, ErrInvalidDisputeReason
+	}
+
+	gs.resultsMu.Lock()
+	defer gs.resultsMu.Unlock()
+	result, err := gs.PrizeResult(lobbyID)
+	if err != nil {
+		return nil, err
+	}
+	now := models.Now()
+	switch {
+	case result.Status == models.ResultsFinalized:
+		return nil, ErrResultsFinal
+	case !now.Before(result.DisputeUntil):
+		return nil, ErrDisputeWindowClosed
+	}
+	standing := result.Standing(playerID)
+	if standing == nil {
+		return nil, ErrNotInResults
+	}
+	for _, dispute := range result.Disputes {
+		if dispute.PlayerID == playerID && dispute.Status == models.DisputeOpen {
+			return nil, ErrAlreadyDisputed
+		}
+	}
+
+	dispute := &models.Dispute{
+		ID:        len(result.Disputes) + 1,
+		PlayerID:  playerID,
+		Username:  standing.Username,
+		Reason:    reason,
+		Status:    models.DisputeOpen,
+		CreatedAt: now,
+	}
+	result.Disputes = append(result.Disputes, dispute)
+	if err := gs.repo.SavePrizeResult(result); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Player %s disputed the results of lobby %s (dispute %d)", playerID, lobbyID, dispute.ID)
+	gs.announceResults(lobbyID, "dispute_filed", map[string]interface{}{
+		"dispute":       dispute,
+		"open_disputes": result.OpenDisputes(),
+	})
+	return dispute, nil
+}
+
+// ResolveDispute records an admin's decision on an open dispute. Upheld
+// disputes can have the game recomputed, which applies the new scores and
+// re-ranks the standings. Disputes can be resolved after the window closes,
+// as long as the results aren't final.
+func (gs *GameService) ResolveDispute(lobbyID string, disputeID int, resolution DisputeResolution, actor string) (*models.PrizeResult, error) {
+	if resolution.Status != models.DisputeUpheld && resolution.Status != models.DisputeRejected {
+		return nil, ErrInvalidDisputeStatus
+	}
+
+	gs.resultsMu.Lock()
+	defer gs.resultsMu.Unlock()
+	result, err := gs.PrizeResult(lobbyID)
+	if err != nil {
+		return nil, err
+	}
+	if result.Status == models.ResultsFinalized {
+		return nil, ErrResultsFinal
+	}
+	dispute := result.FindDispute(disputeID)
+	if dispute == nil {
+		return nil, ErrDisputeNotFound
+	}
+	if dispute.Status != models.DisputeOpen {
+		return nil, ErrDisputeResolved
+	}
+
+	if resolution.Status == models.DisputeUpheld && resolution.Recompute {
+		recomputed, err := gs.RecomputeScores(lobbyID, resolution.ScoringVersion, true, actor)
+		if err != nil {
+			return nil, err
+		}
+		scores := make(map[string]int, len(recomputed.Players))
+		for _, p := range recomputed.Players {
+			scores[p.PlayerID] = p.RecomputedScore
+		}
+		for i := range result.Standings {
+			if score, ok := scores[result.Standings[i].PlayerID]; ok {
+				result.Standings[i].Score = score
+			}
+		}
+		models.RankStandings(result.Standings)
+		allocatePrizePool(result)
+		dispute.RescoredWith = recomputed.ScoringVersion
+	}
+
+	now := models.Now()
+	dispute.Status = resolution.Status
+	dispute.Resolution = strings.TrimSpace(resolution.Note)
+	dispute.ResolvedBy = actor
+	dispute.ResolvedAt = &now
+	if err := gs.repo.SavePrizeResult(result); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Dispute %d on lobby %s %s by %s", disputeID, lobbyID, resolution.Status, actor)
+	gs.announceResults(lobbyID, "dispute_resolved", map[string]interface{}{
+		"dispute":       dispute,
+		"standings":     result.Standings,
+		"open_disputes": result.OpenDisputes(),
+	})
+	return result, nil
+}
+
+// StartResultFinalizer finalizes prize results whose dispute window has
+// passed every interval.
+func (gs *GameService) StartResultFinalizer(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := gs.FinalizeDueResults(); err != nil {
+				log.Printf("Error finalizing prize results: %v", err)
+			}
+		}
+	}()
+}
+
+// FinalizeDueResults finalizes provisional prize results whose dispute
+// window has passed and which have no open disputes, recording a payout for
+// each winner, tied winners each getting one, and issuing claim codes for
+// prize pool shares. It returns the results finalized.
+func (gs *GameService) FinalizeDueResults() ([]*models.PrizeResult, error) {
+	gs.resultsMu.Lock()
+	defer gs.resultsMu.Unlock()
+	provisional, err := gs.repo.ListPrizeResults(models.ResultsProvisional)
+	if err != nil {
+		return nil, err
+	}
+
+	now := models.Now()
+	var finalized []*models.PrizeResult
+	for _, result := range provisional {
+		if now.Before(result.DisputeUntil) || result.OpenDisputes() > 0 {
+			continue
+		}
+		result.Status = models.ResultsFinalized
+		result.FinalizedAt = &now
+		for _, standing := range result.Standings {
+			if standing.Rank == 1 && result.Prize != "" {
+				result.Payouts = append(result.Payouts, &models.Payout{
+					PlayerID:   standing.PlayerID,
+					Username:   standing.Username,
+					UserID:     standing.UserID,
+					Rank:       standing.Rank,
+					Prize:      result.Prize,
+					RecordedAt: now,
+				})
+			}
+		}
+		if err := issueClaimCodes(result); err != nil {
+			return finalized, err
+		}
+		if err := gs.repo.SavePrizeResult(result); err != nil {
+			return finalized, err
+		}
+		finalized = append(finalized, result)
+
+		log.Printf("Prize results for lobby %s finalized with %d payout(s) of %q and %d prize pool share(s)", result.LobbyID, len(result.Payouts), result.Prize, len(result.Allocations))
+		gs.announceResults(result.LobbyID, "results_finalized", map[string]interface{}{
+			"standings":   result.Standings,
+			"payouts":     result.Payouts,
+			"allocations": api.FromPrizeResult(result).Allocations,
+		})
+		gs.sendClaimCodes(result)
+	}
+	return finalized, nil
+}
+
+// announceResults tells a prize game's lobby about a change to its results,
+// if the lobby is still around.
+func (gs *GameService) announceResults(lobbyID, eventType string, data interface{}) {
+	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
+	if lobbyHub == nil {
+		return
+	}
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+	gs.BroadcastLobbyUpdate(lobbyHub, eventType, data)
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"buildprize-game/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// QuestionGenerator produces quiz questions on a topic by calling an
+// OpenAI-compatible chat completions endpoint.
+type QuestionGenerator struct {
+	endpoint string
+	model    string
+	client   *http.Client
+
+	mu     sync.RWMutex
+	apiKey string
+}
+
+type generatedQuestion struct {
+	Text     string   `json:"text"`
+	Options  []string `json:"options"`
+	Correct  int      `json:"correct"`
+	Category string   `json:"category"`
+}
+
+// Words that should never reach players, regardless of the topic asked for.
+var blockedQuestionTerms = []string{
+	"fuck", "shit", "nigger", "faggot", "rape", "porn", "suicide",
+}
+
+func NewQuestionGenerator(endpoint, apiKey, model string) *QuestionGenerator {
+	return &QuestionGenerator{
+		endpoint: endpoint,
+		apiKey:   apiKey,
+		model:    model,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// SetAPIKey swaps the API key, e.g. after the secret rotates.
+func (qg *QuestionGenerator) SetAPIKey(apiKey string) {
+	qg.mu.Lock()
+	qg.apiKey = apiKey
+	qg.mu.Unlock()
+}
+
+// Generate asks the model for count questions about topic. Only questions
+// that pass schema validation and the moderation check are returned.
+func (qg *QuestionGenerator) Generate(topic string, count int) ([]*models.Question, error) {
+	return qg.GenerateContext(context.Background(), topic, count)
+}
+
+// GenerateContext is Generate with a context that aborts the request.
+func (qg *QuestionGenerator) GenerateContext(ctx context.Context, topic string, count int) ([]*models.Question, error) {
+	topic = strings.TrimSpace(topic)
+	if topic == "" || count <= 0 {
+		return nil, ErrInvalidTopic
+	}
+	if !passesModeration(topic) {
+		return nil, ErrInvalidTopic
+	}
+
+	prompt := fmt.Sprintf(
+		"Write %d multiple-choice trivia questions about %q. "+
+			"Respond with JSON only, in the form "+
+			`{"questions":[{"text":"...","options":["a","b","c","d"],"correct":0,"category":"..."}]}. `+
+			"Each question must have between 2 and 6 distinct options (4 is preferred) and \"correct\" is the zero-based index of the right one.",
+		count, topic)
+
+	reqBody := map[string]interface{}{
+		"model": qg.model,
+		"messages": []map[string]string{
+			{"role": "system", "content": "You are a family-friendly trivia writer. Only produce factual, non-offensive questions."},
+			{"role": "user", "content": prompt},
+		},
+		"response_format": map[string]string{"type": "json_object"},
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", qg.endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	qg.mu.RLock()
+	apiKey := qg.apiKey
+	qg.mu.RUnlock()
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := qg.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("question generator request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("question generator returned HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	var completion struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(body, &completion); err != nil {
+		return nil, fmt.Errorf("failed to decode question generator response: %w", err)
+	}
+	if len(completion.Choices) == 0 {
+		return nil, ErrNoGeneratedQuestions
+	}
+
+	var payload struct {
+		Questions []generatedQuestion `json:"questions"`
+	}
+	if err := json.Unmarshal([]byte(completion.Choices[0].Message.Content), &payload); err != nil {
+		return nil, fmt.Errorf("question generator returned invalid JSON: %w", err)
+	}
+
+	questions := make([]*models.Question, 0, len(payload.Questions))
+	for _, gq := range payload.Questions {
+		if err := validateGeneratedQuestion(gq); err != nil {
+			continue
+		}
+		category := gq.Category
+		if category == "" {
+			category = topic
+		}
+		questions = append(questions, &models.Question{
+			ID:          "gen-" + uuid.New().String(),
+			Text:        strings.TrimSpace(gq.Text),
+			Options:     gq.Options,
+			OptionCount: len(gq.Options),
+			Correct:     gq.Correct,
+			Category:    category,
+		})
+		if len(questions) == count {
+			break
+		}
+	}
+
+	if len(questions) == 0 {
+		return nil, ErrNoGeneratedQuestions
+	}
+
+	return questions, nil
+}
+
+func validateGeneratedQuestion(q generatedQuestion) error {
+	text := strings.TrimSpace(q.Text)
+	mq := models.Question{Text: text, Options: q.Options, Correct: q.Correct}
+	for _, issue := range NewQuestionLinter(nil).Check(&mq) {
+		if issue.Severity == LintError {
+			return fmt.Errorf("%w: %s", models.ErrInvalidQuestion, issue.Message)
+		}
+	}
+
+	if !passesModeration(text) {
+		return fmt.Errorf("question failed moderation")
+	}
+	for _, opt := range q.Options {
+		if !passesModeration(opt) {
+			return fmt.Errorf("option failed moderation")
+		}
+	}
+
+	return nil
+}
+
+func passesModeration(text string) bool {
+	lower := strings.ToLower(text)
+	for _, term := range blockedQuestionTerms {
+		if strings.Contains(lower, term) {
+			return false
+		}
+	}
+	return true
+}
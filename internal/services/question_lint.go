@@ -0,0 +1,193 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"buildprize-game/internal/models"
+)
+
+const (
+	maxQuestionTextLength = 300
+	maxOptionLength       = 100
+)
+
+type LintSeverity string
+
+const (
+	// LintError issues make a question unusable; import and generation drop it.
+	LintError LintSeverity = "error"
+	// LintWarning issues are reported but the question is still accepted.
+	LintWarning LintSeverity = "warning"
+)
+
+// LintIssue is one problem found with a question.
+type LintIssue struct {
+	QuestionID string       `json:"question_id"`
+	Index      int          `json:"index"` // position in the linted batch
+	Severity   LintSeverity `json:"severity"`
+	Code       string       `json:"code"`
+	Message    string       `json:"message"`
+}
+
+// LintReport summarises a lint pass over a batch of questions.
+type LintReport struct {
+	Checked  int         `json:"checked"`
+	Passed   int         `json:"passed"` // questions with no errors
+	Errors   int         `json:"errors"`
+	Warnings int         `json:"warnings"`
+	Issues   []LintIssue `json:"issues"`
+}
+
+// QuestionLinter checks questions before they reach the bank or a lobby:
+// option uniqueness, correct-index bounds, text and option length, fuzzy
+// duplicates of questions it has already seen, and correct answers given away
+// by the question text. A linter remembers every question it accepts, so a
+// batch is also checked against itself.
+type QuestionLinter struct {
+	seen map[string]string // fingerprint -> question ID
+}
+
+func NewQuestionLinter(existing []models.Question) *QuestionLinter {
+	l := &QuestionLinter{seen: make(map[string]string)}
+	for _, q := range existing {
+		l.remember(&q)
+	}
+	return l
+}
+
+func (l *QuestionLinter) remember(q *models.Question) {
+	fp := models.QuestionFingerprint(q.Text)
+	if _, ok := l.seen[fp]; !ok {
+		l.seen[fp] = q.ID
+	}
+}
+
+// Check returns the issues found with q. Questions without errors are
+// remembered for later duplicate checks.
+func (l *QuestionLinter) Check(q *models.Question) []LintIssue {
+	var issues []LintIssue
+	add := func(severity LintSeverity, code, format string, args ...interface{}) {
+		issues = append(issues, LintIssue{
+			QuestionID: q.ID,
+			Severity:   severity,
+			Code:       code,
+			Message:    fmt.Sprintf(format, args...),
+		})
+	}
+
+	text := strings.TrimSpace(q.Text)
+	if len(text) > maxQuestionTextLength {
+		add(LintError, "text_too_long", "question text is %d characters, limit %d", len(text), maxQuestionTextLength)
+	}
+	for i, opt := range q.Options {
+		if len(opt) > maxOptionLength {
+			add(LintError, "option_too_long", "option %d is %d characters, limit %d", i, len(opt), maxOptionLength)
+		}
+		for j := 0; j < i; j++ {
+			if models.SameOption(opt, q.Options[j]) {
+				add(LintError, "duplicate_option", "options %d and %d are both %q", j, i, opt)
+				break
+			}
+		}
+	}
+	switch q.QuestionType() {
+	case models.SingleChoice, models.TrueFalse:
+		if !q.HasOption(q.Correct) {
+			add(LintError, "correct_out_of_range", "correct index %d, question has %d options", q.Correct, len(q.Options))
+		}
+	case models.MultiSelect:
+		for _, idx := range q.CorrectAnswers {
+			if !q.HasOption(idx) {
+				add(LintError, "correct_out_of_range", "correct answer index %d, question has %d options", idx, len(q.Options))
+			}
+		}
+	}
+
+	// Validate covers the remaining structural rules (option count, type,
+	// accepted answers); skip it when a more specific error already fired
+	if len(issues) == 0 {
+		if err := q.Validate(); err != nil {
+			add(LintError, "invalid", "%v", err)
+		}
+	}
+
+	if id, ok := l.seen[models.QuestionFingerprint(text)]; ok && text != "" {
+		add(LintError, "duplicate_question", "duplicates question %s", id)
+	}
+
+	for _, answer := range correctAnswerTexts(q) {
+		if models.MentionsAnswer(text, answer) {
+			add(LintWarning, "answer_in_text", "correct answer %q appears in the question text", answer)
+			break
+		}
+	}
+
+	if !hasLintErrors(issues) {
+		l.remember(q)
+	}
+	return issues
+}
+
+// Lint checks every question in the batch and returns the report.
+func (l *QuestionLinter) Lint(questions []models.Question) LintReport {
+	report := LintReport{Checked: len(questions), Issues: []LintIssue{}}
+	for i := range questions {
+		issues := l.Check(&questions[i])
+		if !hasLintErrors(issues) {
+			report.Passed++
+		}
+		for _, issue := range issues {
+			issue.Index = i
+			if issue.Severity == LintError {
+				report.Errors++
+			} else {
+				report.Warnings++
+			}
+			report.Issues = append(report.Issues, issue)
+		}
+	}
+	return report
+}
+
+func hasLintErrors(issues []LintIssue) bool {
+	for _, issue := range issues {
+		if issue.Severity == LintError {
+			return true
+		}
+	}
+	return false
+}
+
+// correctAnswerTexts lists the text of q's correct answers. True/false
+// questions are skipped since "true" and "false" often appear in the prompt.
+func correctAnswerTexts(q *models.Question) []string {
+	switch q.QuestionType() {
+	case models.FreeText:
+		return q.AcceptedAnswers
+	case models.MultiSelect:
+		var answers []string
+		for _, idx := range q.CorrectAnswers {
+			if q.HasOption(idx) {
+				answers = append(answers, q.Options[idx])
+			}
+		}
+		return answers
+	case models.SingleChoice:
+		if q.HasOption(q.Correct) {
+			return []string{q.Options[q.Correct]}
+		}
+	}
+	return nil
+}
+
+// LintQuestionBank reports issues with the questions in the bank.
+func (gs *GameService) LintQuestionBank() LintReport {
+	return gs.questionDB.LintBank()
+}
+
+// LintQuestions checks a batch of questions against the bank without adding
+// them, e.g. to vet an import before running it.
+func (gs *GameService) LintQuestions(questions []models.Question) LintReport {
+	return gs.questionDB.Lint(questions)
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+)
+
+// bankAttempts is how many bank picks a round tries before giving up on
+// finding a valid question at all.
+const bankAttempts = 3
+
+// QuestionSourceStats counts rounds whose question source let them down.
+// Every count is zero in a healthy deployment, so any of them rising is
+// worth alerting on.
+type QuestionSourceStats struct {
+	Failures       int64  `json:"failures"`          // sources that panicked or came back empty
+	Invalid        int64  `json:"invalid_questions"` // questions that failed validation at round start
+	BankFallbacks  int64  `json:"bank_fallbacks"`    // rounds served from the bank after a failure
+	GamesEnded     int64  `json:"games_ended"`       // games ended with no valid question to ask
+	LastIncident   string `json:"last_incident,omitempty"`
+	LastIncidentAt string `json:"last_incident_at,omitempty"`
+}
+
+// questionSourceMonitor keeps QuestionSourceStats; the zero value is ready
+// to use.
+type questionSourceMonitor struct {
+	mu    sync.Mutex
+	stats QuestionSourceStats
+}
+
+// questionSource is one place a round's question can come from. A source
+// with nothing ready returns nil and the next one is asked.
+type questionSource struct {
+	name string
+	pick func() *models.Question
+}
+
+// pickQuestion picks and shuffles the question for the lobby's next round:
+// a prefetched one, else one for the round type or planned category, else
+// any question from the bank. A source that panics or hands over a question
+// that doesn't validate is logged and counted, and the round is served from
+// the bank instead. It returns nil only if the bank can't produce a valid
+// question either. The caller holds the lobby lock.
+func (gs *GameService) pickQuestion(lobbyHub *hub.LobbyHub) *models.Question {
+	lobby := lobbyHub.GetLobby()
+
+	sources := []questionSource{{"prefetched", func() *models.Question { return gs.nextPrefetchedQuestion(lobbyHub) }}}
+	if lobby.RoundType != "" {
+		sources = append(sources, questionSource{"round type " + string(lobby.RoundType), func() *models.Question {
+			return gs.questionDB.GetQuestionByMediaType(lobby.RoundType)
+		}})
+	}
+	if category := lobby.PlannedCategory(); category != "" && !strings.EqualFold(category, AnyCategory) {
+		sources = append(sources, questionSource{"category " + category, func() *models.Question {
+			return gs.questionDB.GetQuestionByCategory(category)
+		}})
+	}
+
+	failed := false
+	for _, source := range sources {
+		question, err := tryQuestionSource(source.pick)
+		if err != nil {
+			gs.recordQuestionIncident(lobby, source.name, err)
+			failed = true
+			break
+		}
+		if question != nil {
+			return question
+		}
+	}
+
+	for attempt := 0; attempt < bankAttempts; attempt++ {
+		question, err := tryQuestionSource(gs.questionDB.GetRandomQuestion)
+		if err == nil && question == nil {
+			err = errors.New("no question returned")
+		}
+		if err != nil {
+			gs.recordQuestionIncident(lobby, "question bank", err)
+			failed = true
+			continue
+		}
+		if failed {
+			gs.sourceMonitor.mu.Lock()
+			gs.sourceMonitor.stats.BankFallbacks++
+			gs.sourceMonitor.mu.Unlock()
+			log.Printf("Lobby %s round %d served from the question bank after a question source failure", lobby.ID, lobby.Round)
+		}
+		return question
+	}
+	return nil
+}
+
+// tryQuestionSource runs pick, turning a panic into an error, and returns a
+// shuffled copy of its question if it validates.
+func tryQuestionSource(pick func() *models.Question) (question *models.Question, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			question, err = nil, fmt.Errorf("panicked: %v", r)
+		}
+	}()
+	question = pick()
+	if question == nil {
+		return nil, nil
+	}
+	if err := question.Validate(); err != nil {
+		return nil, err
+	}
+	return question.Shuffled(), nil
+}
+
+// recordQuestionIncident logs and counts a question source failure.
+func (gs *GameService) recordQuestionIncident(lobby *models.Lobby, source string, err error) {
+	incident := fmt.Sprintf("lobby %s round %d: %s: %v", lobby.ID, lobby.Round, source, err)
+	log.Printf("ALERT: question source failed for %s", incident)
+
+	m := &gs.sourceMonitor
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if errors.Is(err, models.ErrInvalidQuestion) {
+		m.stats.Invalid++
+	} else {
+		m.stats.Failures++
+	}
+	m.stats.LastIncident = incident
+	m.stats.LastIncidentAt = models.FormatTimestamp(models.Now())
+}
+
+// QuestionSourceStats reports round-start question failures.
+func (gs *GameService) QuestionSourceStats() QuestionSourceStats {
+	gs.sourceMonitor.mu.Lock()
+	defer gs.sourceMonitor.mu.Unlock()
+	return gs.sourceMonitor.stats
+}
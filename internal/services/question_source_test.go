@@ -0,0 +1,61 @@
+package services_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// A queued question that doesn't validate is never asked: the round is served
+// from the question bank instead and the incident is counted.
+func TestInvalidQueuedQuestionFallsBackToBank(t *testing.T) {
+	gs, gameHub, _ := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Fallback", MaxRounds: 3, MaxPlayers: 4})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"})
+	gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "bob"})
+
+	broken := &models.Question{ID: "broken", Text: "Which one?", Options: []string{"A", "B", "C", "D"}, Correct: 7}
+	lobby.Lock()
+	lobby.QuestionQueue = []*models.Question{broken}
+	lobby.Unlock()
+
+	client := &hub.Client{ID: "watcher", LobbyID: lobby.ID, Send: make(chan []byte, 64)}
+	gameHub.GetLobbyHub(lobby.ID).Register(client)
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	defer gs.ForceEndGame(lobby.ID)
+
+	deadline := time.After(2 * time.Second)
+	for asked := false; !asked; {
+		select {
+		case payload := <-client.Send:
+			var event struct {
+				Type string `json:"type"`
+			}
+			json.Unmarshal(payload, &event)
+			asked = event.Type == "new_question"
+		case <-deadline:
+			t.Fatal("new_question never arrived")
+		}
+	}
+
+	lobby.Lock()
+	question := lobby.CurrentQ
+	lobby.Unlock()
+	if question.ID == broken.ID || question.Validate() != nil {
+		t.Fatalf("Expected a valid bank question, got %+v", question)
+	}
+
+	stats := gs.QuestionSourceStats()
+	if stats.Invalid != 1 || stats.BankFallbacks != 1 || stats.GamesEnded != 0 || stats.LastIncident == "" {
+		t.Fatalf("Expected one invalid question served from the bank, got %+v", stats)
+	}
+}
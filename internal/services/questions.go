@@ -1,102 +1,287 @@
 package services
 
 import (
+	"log"
 	"math/rand"
+	"strings"
+	"sync"
 	"time"
 	"buildprize-game/internal/models"
 )
 
 type QuestionDatabase struct {
+	mu sync.RWMutex
+	// Replaced rather than changed in place once served, so questions
+	// already handed out never change under their readers
 	questions []models.Question
 }
 
 func NewQuestionDatabase() *QuestionDatabase {
-	return &QuestionDatabase{
-		questions: []models.Question{
-			{
-				ID:       "1",
-				Text:     "What is the capital of France?",
-				Options:  []string{"London", "Berlin", "Paris", "Madrid"},
-				Correct:  2,
-				Category: "Geography",
-			},
-			{
-				ID:       "2",
-				Text:     "Which planet is known as the Red Planet?",
-				Options:  []string{"Venus", "Mars", "Jupiter", "Saturn"},
-				Correct:  1,
-				Category: "Science",
-			},
-			{
-				ID:       "3",
-				Text:     "What is 2 + 2?",
-				Options:  []string{"3", "4", "5", "6"},
-				Correct:  1,
-				Category: "Math",
-			},
-			{
-				ID:       "4",
-				Text:     "Who painted the Mona Lisa?",
-				Options:  []string{"Van Gogh", "Picasso", "Da Vinci", "Monet"},
-				Correct:  2,
-				Category: "Art",
-			},
-			{
-				ID:       "5",
-				Text:     "What is the largest ocean on Earth?",
-				Options:  []string{"Atlantic", "Indian", "Pacific", "Arctic"},
-				Correct:  2,
-				Category: "Geography",
-			},
-			{
-				ID:       "6",
-				Text:     "Which programming language was created by Google?",
-				Options:  []string{"Java", "Python", "Go", "C++"},
-				Correct:  2,
-				Category: "Technology",
-			},
-			{
-				ID:       "7",
-				Text:     "What is the chemical symbol for gold?",
-				Options:  []string{"Go", "Gd", "Au", "Ag"},
-				Correct:  2,
-				Category: "Science",
-			},
-			{
-				ID:       "8",
-				Text:     "In which year did World War II end?",
-				Options:  []string{"1944", "1945", "1946", "1947"},
-				Correct:  1,
-				Category: "History",
-			},
-			{
-				ID:       "9",
-				Text:     "What is the fastest land animal?",
-				Options:  []string{"Lion", "Cheetah", "Leopard", "Tiger"},
-				Correct:  1,
-				Category: "Nature",
-			},
-			{
-				ID:       "10",
-				Text:     "Which country has the most natural lakes?",
-				Options:  []string{"Russia", "Canada", "USA", "Finland"},
-				Correct:  1,
-				Category: "Geography",
-			},
+	qd := &QuestionDatabase{}
+	qd.Import([]models.Question{
+		{
+			ID:       "1",
+			Text:     "What is the capital of France?",
+			Options:  []string{"London", "Berlin", "Paris", "Madrid"},
+			Correct:  2,
+			Category: "Geography",
 		},
+		{
+			ID:       "2",
+			Text:     "Which planet is known as the Red Planet?",
+			Options:  []string{"Venus", "Mars", "Jupiter", "Saturn"},
+			Correct:  1,
+			Category: "Science",
+		},
+		{
+			ID:       "3",
+			Text:     "What is 2 + 2?",
+			Options:  []string{"3", "4", "5", "6"},
+			Correct:  1,
+			Category: "Math",
+		},
+		{
+			ID:       "4",
+			Text:     "Who painted the Mona Lisa?",
+			Options:  []string{"Van Gogh", "Picasso", "Da Vinci", "Monet"},
+			Correct:  2,
+			Category: "Art",
+		},
+		{
+			ID:       "5",
+			Text:     "What is the largest ocean on Earth?",
+			Options:  []string{"Atlantic", "Indian", "Pacific", "Arctic"},
+			Correct:  2,
+			Category: "Geography",
+		},
+		{
+			ID:       "6",
+			Text:     "Which programming language was created by Google?",
+			Options:  []string{"Java", "Python", "Go", "C++"},
+			Correct:  2,
+			Category: "Technology",
+		},
+		{
+			ID:       "7",
+			Text:     "What is the chemical symbol for gold?",
+			Options:  []string{"Go", "Gd", "Au", "Ag"},
+			Correct:  2,
+			Category: "Science",
+		},
+		{
+			ID:       "8",
+			Text:     "In which year did World War II end?",
+			Options:  []string{"1944", "1945", "1946", "1947"},
+			Correct:  1,
+			Category: "History",
+		},
+		{
+			ID:       "9",
+			Text:     "What is the fastest land animal?",
+			Options:  []string{"Lion", "Cheetah", "Leopard", "Tiger"},
+			Correct:  1,
+			Category: "Nature",
+		},
+		{
+			ID:       "10",
+			Text:     "Which country has the most natural lakes?",
+			Options:  []string{"Russia", "Canada", "USA", "Finland"},
+			Correct:  1,
+			Category: "Geography",
+		},
+		{
+			ID:        "11",
+			Text:      "Which landmark is shown in this picture?",
+			Options:   []string{"Big Ben", "Eiffel Tower", "Leaning Tower of Pisa", "CN Tower"},
+			Correct:   1,
+			Category:  "Geography",
+			MediaURL:  "https://upload.wikimedia.org/wikipedia/commons/a/a8/Tour_Eiffel_Wikimedia_Commons.jpg",
+			MediaType: models.MediaImage,
+		},
+		{
+			ID:        "12",
+			Text:      "Which famous painting is this?",
+			Options:   []string{"The Starry Night", "The Scream", "Mona Lisa", "Girl with a Pearl Earring"},
+			Correct:   2,
+			Category:  "Art",
+			MediaURL:  "https://upload.wikimedia.org/wikipedia/commons/e/ec/Mona_Lisa%2C_by_Leonardo_da_Vinci%2C_from_C2RMF_retouched.jpg",
+			MediaType: models.MediaImage,
+		},
+		{
+			ID:        "13",
+			Text:      "Which animal makes this sound?",
+			Options:   []string{"Wolf", "Owl", "Lion", "Elephant"},
+			Correct:   2,
+			Category:  "Nature",
+			MediaURL:  "https://upload.wikimedia.org/wikipedia/commons/7/7d/Lion_raring-sound1TamilNadu178.ogg",
+			MediaType: models.MediaAudio,
+		},
+		{
+			ID:       "14",
+			Text:     "Which is the largest planet in our solar system?",
+			Options:  []string{"Earth", "Jupiter", "Neptune"},
+			Correct:  1,
+			Category: "Science",
+		},
+		{
+			ID:       "15",
+			Text:     "Which of these is a Scandinavian country?",
+			Options:  []string{"Austria", "Belgium", "Norway", "Portugal", "Greece", "Hungary"},
+			Correct:  2,
+			Category: "Geography",
+		},
+		{
+			ID:       "16",
+			Text:     "Who wrote \"Romeo and Juliet\"?",
+			Options:  []string{"Charles Dickens", "William Shakespeare"},
+			Correct:  1,
+			Category: "Literature",
+		},
+		{
+			ID:       "17",
+			Type:     models.TrueFalse,
+			Text:     "The Great Wall of China is visible from the Moon with the naked eye.",
+			Options:  []string{"True", "False"},
+			Correct:  1,
+			Category: "History",
+		},
+		{
+			ID:       "18",
+			Type:     models.TrueFalse,
+			Text:     "Water boils at a lower temperature at high altitude.",
+			Options:  []string{"True", "False"},
+			Correct:  0,
+			Category: "Science",
+		},
+		{
+			ID:             "19",
+			Type:           models.MultiSelect,
+			Text:           "Which of these are primary colors of light?",
+			Options:        []string{"Red", "Yellow", "Green", "Blue", "Purple"},
+			CorrectAnswers: []int{0, 2, 3},
+			Category:       "Science",
+		},
+		{
+			ID:             "20",
+			Type:           models.MultiSelect,
+			Text:           "Which of these languages were created at Google or Apple?",
+			Options:        []string{"Go", "Rust", "Swift", "Kotlin"},
+			CorrectAnswers: []int{0, 2},
+			Category:       "Technology",
+		},
+		{
+			ID:              "21",
+			Type:            models.FreeText,
+			Text:            "What is the capital of Australia?",
+			AcceptedAnswers: []string{"Canberra"},
+			Category:        "Geography",
+		},
+		{
+			ID:              "22",
+			Type:            models.FreeText,
+			Text:            "Who developed the theory of general relativity?",
+			AcceptedAnswers: []string{"Albert Einstein", "Einstein"},
+			Category:        "Science",
+		},
+		{
+			ID:            "23",
+			Type:          models.Numeric,
+			Text:          "How many bones are in the adult human body?",
+			NumericAnswer: 206,
+			Tolerance:     100,
+			Category:      "Science",
+		},
+		{
+			ID:            "24",
+			Type:          models.Numeric,
+			Text:          "In what year did the first person walk on the Moon?",
+			NumericAnswer: 1969,
+			Tolerance:     50,
+			Category:      "History",
+		},
+	})
+	return qd
+}
+
+// Import lints questions against the bank and adds the ones without errors.
+// Rejected questions are logged and skipped; the number imported is returned.
+func (qd *QuestionDatabase) Import(questions []models.Question) int {
+	qd.mu.Lock()
+	defer qd.mu.Unlock()
+	bank := append([]models.Question(nil), qd.questions...)
+	linter := NewQuestionLinter(bank)
+	imported := 0
+	for _, q := range questions {
+		issues := linter.Check(&q)
+		for _, issue := range issues {
+			log.Printf("Question %s: %s %s: %s", q.ID, issue.Severity, issue.Code, issue.Message)
+		}
+		if hasLintErrors(issues) {
+			log.Printf("Skipping question %s", q.ID)
+			continue
+		}
+		q.OptionCount = len(q.Options)
+		bank = append(bank, q)
+		imported++
 	}
+	qd.questions = bank
+	return imported
+}
+
+// Lint checks questions against the bank without importing them.
+func (qd *QuestionDatabase) Lint(questions []models.Question) LintReport {
+	return NewQuestionLinter(qd.bank()).Lint(questions)
+}
+
+// LintBank re-checks every question already in the bank, each against the
+// ones before it.
+func (qd *QuestionDatabase) LintBank() LintReport {
+	return NewQuestionLinter(nil).Lint(append([]models.Question(nil), qd.bank()...))
+}
+
+// bank returns the questions as they stand; callers must not modify them.
+func (qd *QuestionDatabase) bank() []models.Question {
+	qd.mu.RLock()
+	defer qd.mu.RUnlock()
+	return qd.questions
+}
+
+// Question returns the bank's question with the given ID, or nil.
+func (qd *QuestionDatabase) Question(id string) *models.Question {
+	questions := qd.bank()
+	for i := range questions {
+		if questions[i].ID == id {
+			return &questions[i]
+		}
+	}
+	return nil
+}
+
+// SetDifficulties relabels the questions with the IDs in labels; IDs not in
+// the bank are ignored.
+func (qd *QuestionDatabase) SetDifficulties(labels map[string]string) {
+	qd.mu.Lock()
+	defer qd.mu.Unlock()
+	questions := append([]models.Question(nil), qd.questions...)
+	for i := range questions {
+		if label, ok := labels[questions[i].ID]; ok {
+			questions[i].Difficulty = label
+		}
+	}
+	qd.questions = questions
 }
 
 func (qd *QuestionDatabase) GetRandomQuestion() *models.Question {
+	questions := qd.bank()
 	rand.Seed(time.Now().UnixNano())
-	index := rand.Intn(len(qd.questions))
-	return &qd.questions[index]
+	index := rand.Intn(len(questions))
+	return &questions[index]
 }
 
 func (qd *QuestionDatabase) GetQuestionByCategory(category string) *models.Question {
 	var categoryQuestions []models.Question
-	for _, q := range qd.questions {
-		if q.Category == category {
+	for _, q := range qd.bank() {
+		if strings.EqualFold(q.Category, category) {
 			categoryQuestions = append(categoryQuestions, q)
 		}
 	}
@@ -109,3 +294,45 @@ func (qd *QuestionDatabase) GetQuestionByCategory(category string) *models.Quest
 	index := rand.Intn(len(categoryQuestions))
 	return &categoryQuestions[index]
 }
+
+// Categories returns the distinct categories in the bank, in first-seen order.
+func (qd *QuestionDatabase) Categories() []string {
+	seen := make(map[string]bool)
+	var categories []string
+	for _, q := range qd.bank() {
+		if !seen[q.Category] {
+			seen[q.Category] = true
+			categories = append(categories, q.Category)
+		}
+	}
+	return categories
+}
+
+// HasCategory reports whether the bank holds at least one question in category.
+func (qd *QuestionDatabase) HasCategory(category string) bool {
+	for _, q := range qd.bank() {
+		if strings.EqualFold(q.Category, category) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetQuestionByMediaType returns a random question carrying the given media
+// type, for picture and audio rounds. Falls back to any question if the bank
+// has none of that type.
+func (qd *QuestionDatabase) GetQuestionByMediaType(mediaType models.MediaType) *models.Question {
+	questions := qd.bank()
+	var mediaQuestions []*models.Question
+	for i := range questions {
+		if questions[i].MediaType == mediaType {
+			mediaQuestions = append(mediaQuestions, &questions[i])
+		}
+	}
+
+	if len(mediaQuestions) == 0 {
+		return qd.GetRandomQuestion()
+	}
+
+	return mediaQuestions[rand.Intn(len(mediaQuestions))]
+}
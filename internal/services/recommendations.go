@@ -0,0 +1,123 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"buildprize-game/internal/models"
+)
+
+const (
+	// Categories with fewer answers than this are treated as unexplored.
+	minAnswersForMastery = 3
+	practiceRounds       = 5
+)
+
+// GetPracticeRecommendations suggests practice lobby configurations that
+// target the player's weakest categories. Categories the player has barely
+// played are suggested as a separate "explore" configuration.
+func (gs *GameService) GetPracticeRecommendations(playerID string) ([]*models.PracticeRecommendation, error) {
+	mastery, err := gs.repo.GetCategoryMastery(playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	played := make(map[string]bool)
+	var weak []*models.CategoryMastery
+	for _, m := range mastery {
+		if m.Answered < minAnswersForMastery || !gs.questionDB.HasCategory(m.Category) {
+			continue
+		}
+		played[strings.ToLower(m.Category)] = true
+		if m.Accuracy < 0.8 {
+			weak = append(weak, m)
+		}
+	}
+	sort.SliceStable(weak, func(i, j int) bool { return weak[i].Accuracy < weak[j].Accuracy })
+
+	recommendations := make([]*models.PracticeRecommendation, 0, 2)
+
+	if len(weak) > 0 {
+		if len(weak) > 3 {
+			weak = weak[:3]
+		}
+		weights := make(map[string]int)
+		categories := make([]string, 0, len(weak))
+		totalAccuracy := 0.0
+		for _, m := range weak {
+			// Weaker categories get proportionally more rounds
+			weights[m.Category] = int((1-m.Accuracy)*100) + 1
+			categories = append(categories, m.Category)
+			totalAccuracy += m.Accuracy
+		}
+		averageAccuracy := totalAccuracy / float64(len(weak))
+
+		recommendations = append(recommendations, &models.PracticeRecommendation{
+			Categories:      categories,
+			CategoryWeights: weights,
+			Difficulty:      difficultyForAccuracy(averageAccuracy),
+			MaxRounds:       practiceRounds,
+			Reason:          fmt.Sprintf("Lowest accuracy: %s (%.0f%%)", weak[0].Category, weak[0].Accuracy*100),
+		})
+	}
+
+	var unexplored []string
+	for _, category := range gs.questionDB.Categories() {
+		if !played[strings.ToLower(category)] {
+			unexplored = append(unexplored, category)
+		}
+	}
+	if len(unexplored) > 0 {
+		if len(unexplored) > 3 {
+			unexplored = unexplored[:3]
+		}
+		weights := make(map[string]int)
+		for _, category := range unexplored {
+			weights[category] = 1
+		}
+		recommendations = append(recommendations, &models.PracticeRecommendation{
+			Categories:      unexplored,
+			CategoryWeights: weights,
+			Difficulty:      "easy",
+			MaxRounds:       practiceRounds,
+			Reason:          "Categories you haven't played much yet",
+		})
+	}
+
+	return recommendations, nil
+}
+
+// CreatePracticeLobby creates a lobby using the player's top recommendation.
+func (gs *GameService) CreatePracticeLobby(playerID string) (*models.Lobby, *models.PracticeRecommendation, error) {
+	recommendations, err := gs.GetPracticeRecommendations(playerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(recommendations) == 0 {
+		return nil, nil, ErrNoRecommendations
+	}
+
+	rec := recommendations[0]
+	lobby, err := gs.CreateLobby(LobbyOptions{
+		Name:            "Practice: " + strings.Join(rec.Categories, ", "),
+		MaxRounds:       rec.MaxRounds,
+		CategoryWeights: rec.CategoryWeights,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return lobby, rec, nil
+}
+
+func difficultyForAccuracy(accuracy float64) string {
+	switch {
+	case accuracy < 0.4:
+		return "easy"
+	case accuracy < 0.7:
+		return "medium"
+	default:
+		return "hard"
+	}
+}
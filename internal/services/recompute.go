@@ -0,0 +1,219 @@
+package services
+
+import (
+	"log"
+	"sort"
+
+	"buildprize-game/internal/models"
+)
+
+// RecomputedScore compares one player's recorded score with the score the
+// same answers earn under the chosen scoring version.
+type RecomputedScore struct {
+	PlayerID        string `json:"player_id"`
+	Username        string `json:"username"`
+	Answers         int    `json:"answers"`
+	RecordedScore   int    `json:"recorded_score"`
+	RecomputedScore int    `json:"recomputed_score"`
+	Delta           int    `json:"delta"`
+}
+
+// ScoreRecomputation is the outcome of re-scoring a finished game from its
+// answer history. Only persisted answers count, so bots and test players
+// (never recorded) are left out.
+type ScoreRecomputation struct {
+	LobbyID          string            `json:"lobby_id"`
+	ScoringVersion   string            `json:"scoring_version"`          // rules the scores were recomputed with
+	RecordedVersion  string            `json:"recorded_scoring_version"` // rules the game was played under
+	Players          []RecomputedScore `json:"players"`                  // ranked by recomputed score
+	RecordedWinner   string            `json:"recorded_winner,omitempty"`
+	RecomputedWinner string            `json:"recomputed_winner,omitempty"`
+	WinnerChanged    bool              `json:"winner_changed"`
+	ChangedAnswers   int               `json:"changed_answers"`
+	Applied          bool              `json:"applied"`
+}
+
+// RecomputeScores re-scores a finished game's recorded answers under the
+// given scoring version, or the version the game was played under when
+// version is empty. Settled wagers carry over unchanged. While the lobby is
+// still around, recorded scores are its players' final scores, which also
+// hold what no answer recorded, such as a stake lost without answering and
+// closest-guess bonuses; the recomputed score moves that by the re-scored
+// answers. With apply set, the new scores are written back to the
+// answer history and to the lobby's players, if the lobby still exists, and
+// the change is recorded in the scoring audit log under actor.
+func (gs *GameService) RecomputeScores(lobbyID, version string, apply bool, actor string) (*ScoreRecomputation, error) {
+	recorded := ""
+	var lobbyRules *models.ScoringConfig
+	var final map[string]int
+	if lobby := gs.findLobby(lobbyID); lobby != nil {
+		lobby.Lock()
+		finished := lobby.State == models.Finished
+		recorded = lobby.ScoringVersion
+		lobbyRules = lobby.Scoring
+		final = make(map[string]int, len(lobby.Players))
+		for _, player := range lobby.Players {
+			final[player.ID] = player.Score
+		}
+		lobby.Unlock()
+		if !finished {
+			return nil, ErrGameNotFinished
+		}
+	}
+
+	answers, err := gs.repo.GetLobbyAnswers(lobbyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(answers) == 0 {
+		return nil, ErrNoAnswerHistory
+	}
+	// Answers outlive their lobby, so they carry the version too
+	if answers[0].ScoringVersion != "" {
+		recorded = answers[0].ScoringVersion
+	}
+	if recorded == "" {
+		recorded = models.LegacyScoringConfig.Version
+	}
+	if version == "" {
+		version = recorded
+	}
+
+	// A lobby's own rules go with the lobby
+	config := lobbyRules
+	if version != models.LobbyScoringVersion {
+		if config, err = gs.ScoringConfig(version); err != nil {
+			return nil, err
+		}
+	} else if config == nil {
+		return nil, ErrUnknownScoringVersion
+	}
+
+	result := &ScoreRecomputation{LobbyID: lobbyID, ScoringVersion: config.Version, RecordedVersion: recorded}
+	byPlayer := make(map[string]*RecomputedScore)
+	var order []string
+	rescored := make(map[int64]int)
+	streaks := make(map[string]int)
+	for _, record := range answers {
+		// Answers come oldest first, so streaks build up as they were played
+		score := recomputeAnswer(config, record, streaks[record.PlayerID])
+		if score != record.Score {
+			result.ChangedAnswers++
+			rescored[record.ID] = score
+		}
+		if record.Correct {
+			streaks[record.PlayerID]++
+		} else {
+			streaks[record.PlayerID] = 0
+		}
+
+		entry, ok := byPlayer[record.PlayerID]
+		if !ok {
+			entry = &RecomputedScore{PlayerID: record.PlayerID, Username: record.Username}
+			byPlayer[record.PlayerID] = entry
+			order = append(order, record.PlayerID)
+		}
+		entry.Answers++
+		entry.RecordedScore = models.AddScore(entry.RecordedScore, record.Score+record.WagerDelta)
+		entry.RecomputedScore = models.AddScore(entry.RecomputedScore, score+record.WagerDelta)
+	}
+
+	for _, playerID := range order {
+		entry := byPlayer[playerID]
+		entry.Delta = entry.RecomputedScore - entry.RecordedScore
+		if score, ok := final[playerID]; ok {
+			entry.RecordedScore = score
+			entry.RecomputedScore = models.AddScore(score, entry.Delta)
+		}
+		result.Players = append(result.Players, *entry)
+	}
+	result.RecordedWinner = topScorer(result.Players, func(p RecomputedScore) int { return p.RecordedScore })
+	result.RecomputedWinner = topScorer(result.Players, func(p RecomputedScore) int { return p.RecomputedScore })
+	result.WinnerChanged = result.RecordedWinner != result.RecomputedWinner
+	sort.SliceStable(result.Players, func(i, j int) bool {
+		return result.Players[i].RecomputedScore > result.Players[j].RecomputedScore
+	})
+
+	if apply && result.ChangedAnswers > 0 {
+		if err := gs.applyRecomputedScores(lobbyID, rescored, result.Players); err != nil {
+			return nil, err
+		}
+		result.Applied = true
+		gs.audit(&models.ScoringAuditEntry{
+			Action:  models.ScoringRecomputed,
+			Version: config.Version,
+			LobbyID: lobbyID,
+			Actor:   actor,
+			Details: map[string]interface{}{
+				"recorded_scoring_version": recorded,
+				"changed_answers":          result.ChangedAnswers,
+				"recorded_winner":          result.RecordedWinner,
+				"recomputed_winner":        result.RecomputedWinner,
+			},
+		})
+		log.Printf("Recomputed scores for lobby %s with scoring %s: %d answer(s) changed, winner %s -> %s",
+			lobbyID, config.Version, result.ChangedAnswers, result.RecordedWinner, result.RecomputedWinner)
+	}
+
+	return result, nil
+}
+
+// recomputeAnswer scores a recorded answer, given the player's streak going
+// into it, with the position bonus for the place it arrived in. Answers
+// recorded before the question was stored alongside are scored from their
+// right/wrong flag.
+func recomputeAnswer(config *models.ScoringConfig, record *models.AnswerRecord, streak int) int {
+	bonus := config.PositionBonus(record.Position)
+	if record.Question != nil {
+		return record.PowerUp.ApplyScore(config.Score(record.Question, record.Answer, record.ResponseTime, streak) + bonus)
+	}
+	return record.PowerUp.ApplyScore(config.ScoreResult(record.Correct, record.ResponseTime, streak) + bonus)
+}
+
+// topScorer returns the player ID with the highest score; ties go to whoever
+// answered first.
+func topScorer(players []RecomputedScore, score func(RecomputedScore) int) string {
+	best := -1
+	winner := ""
+	for _, p := range players {
+		if s := score(p); s > best {
+			best = s
+			winner = p.PlayerID
+		}
+	}
+	return winner
+}
+
+func (gs *GameService) applyRecomputedScores(lobbyID string, rescored map[int64]int, players []RecomputedScore) error {
+	for answerID, score := range rescored {
+		if err := gs.repo.UpdateAnswerScore(answerID, score); err != nil {
+			return err
+		}
+	}
+
+	lobby := gs.findLobby(lobbyID)
+	if lobby == nil {
+		return nil
+	}
+	lobby.Lock()
+	defer lobby.Unlock()
+	for _, p := range players {
+		if player := lobby.GetPlayer(p.PlayerID); player != nil {
+			player.Score += p.Delta
+		}
+	}
+	return gs.repo.SaveLobby(lobby)
+}
+
+// findLobby returns the live lobby, or the stored one once it has left the
+// hub, or nil if neither exists.
+func (gs *GameService) findLobby(lobbyID string) *models.Lobby {
+	if lobbyHub := gs.hub.GetLobbyHub(lobbyID); lobbyHub != nil {
+		return lobbyHub.GetLobby()
+	}
+	lobby, err := gs.repo.GetLobby(lobbyID)
+	if err != nil {
+		return nil
+	}
+	return lobby
+}
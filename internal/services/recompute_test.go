@@ -0,0 +1,255 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+
+	"buildprize-game/internal/game"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// A game scored with a bug is corrected from its answer history, and the
+// fix reaches both the stored answers and the players' totals.
+func TestRecomputeScores(t *testing.T) {
+	gs, gameHub, repo := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Recompute", MaxRounds: 3, MaxPlayers: 4})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+
+	var playerIDs []string
+	for _, name := range []string{"first", "second"} {
+		_, player, err := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: name})
+		if err != nil {
+			t.Fatalf("JoinLobby: %v", err)
+		}
+		playerIDs = append(playerIDs, player.ID)
+	}
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+
+	current := gameHub.GetLobbyHub(lobby.ID).GetLobby()
+	current.Lock()
+	answer := correctAnswer(current.CurrentQ)
+	current.Unlock()
+	for _, playerID := range playerIDs {
+		if err := gs.SubmitAnswer(lobby.ID, playerID, answer); err != nil {
+			t.Fatalf("SubmitAnswer: %v", err)
+		}
+	}
+
+	if _, err := gs.RecomputeScores(lobby.ID, "", false, "test"); !errors.Is(err, services.ErrGameNotFinished) {
+		t.Fatalf("Expected ErrGameNotFinished for a running game, got %v", err)
+	}
+	if err := gs.ForceEndGame(lobby.ID); err != nil {
+		t.Fatalf("ForceEndGame: %v", err)
+	}
+
+	result, err := gs.RecomputeScores(lobby.ID, "", false, "test")
+	if err != nil {
+		t.Fatalf("RecomputeScores: %v", err)
+	}
+	if result.ChangedAnswers != 0 {
+		t.Fatalf("Expected recorded scores to match v1, %d changed", result.ChangedAnswers)
+	}
+
+	// Simulate a scoring bug that shorted the first player
+	answers, _ := repo.GetLobbyAnswers(lobby.ID)
+	want := answers[0].Score
+	buggy := answers[0].PlayerID
+	repo.UpdateAnswerScore(answers[0].ID, 0)
+	current.Lock()
+	current.GetPlayer(buggy).Score = 0
+	current.Unlock()
+
+	if _, err := gs.RecomputeScores(lobby.ID, "v0", false, "test"); !errors.Is(err, services.ErrUnknownScoringVersion) {
+		t.Fatalf("Expected ErrUnknownScoringVersion, got %v", err)
+	}
+
+	result, err = gs.RecomputeScores(lobby.ID, "", true, "test")
+	if err != nil {
+		t.Fatalf("RecomputeScores: %v", err)
+	}
+	if result.ChangedAnswers != 1 || !result.Applied {
+		t.Fatalf("Expected one corrected answer to be applied, got %+v", result)
+	}
+	current.Lock()
+	score := current.GetPlayer(buggy).Score
+	current.Unlock()
+	if score != want {
+		t.Fatalf("Expected the player's score to be restored to %d, got %d", want, score)
+	}
+	answers, _ = repo.GetLobbyAnswers(lobby.ID)
+	stored := answers[0].Score
+	if stored != want {
+		t.Fatalf("Expected the stored answer to be rescored to %d, got %d", want, stored)
+	}
+}
+
+// Settled wagers count towards recomputed totals, so a game won on a stake
+// keeps its winner.
+func TestRecomputeScoresWithWagers(t *testing.T) {
+	gs, gameHub, repo := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Recompute wagers", MaxRounds: 2, MaxPlayers: 4, WagerRounds: []int{1}})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	_, alice, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"})
+	_, bob, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "bob"})
+
+	current := gameHub.GetLobbyHub(lobby.ID).GetLobby()
+	current.Lock()
+	current.GetPlayer(alice.ID).Score = 100
+	current.GetPlayer(bob.ID).Score = 100
+	current.Unlock()
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	if _, err := gs.PlaceWager(lobby.ID, alice.ID, 0); err != nil {
+		t.Fatalf("PlaceWager: %v", err)
+	}
+	if _, err := gs.PlaceWager(lobby.ID, bob.ID, 100); err != nil {
+		t.Fatalf("PlaceWager: %v", err)
+	}
+
+	// Both answer correctly, alice first, so only bob's stake separates them
+	waitFor(t, phaseIs(current, game.Question))
+	current.Lock()
+	answer := correctAnswer(current.CurrentQ)
+	current.Unlock()
+	for _, playerID := range []string{alice.ID, bob.ID} {
+		if err := gs.SubmitAnswer(lobby.ID, playerID, answer); err != nil {
+			t.Fatalf("SubmitAnswer: %v", err)
+		}
+	}
+	waitFor(t, phaseIs(current, game.Results))
+	if err := gs.ForceEndGame(lobby.ID); err != nil {
+		t.Fatalf("ForceEndGame: %v", err)
+	}
+
+	answers, _ := repo.GetLobbyAnswers(lobby.ID)
+	for _, record := range answers {
+		want := 0
+		if record.PlayerID == bob.ID {
+			want = 100
+		}
+		if record.WagerDelta != want {
+			t.Fatalf("Expected %s's answer to carry a wager of %d, got %d", record.Username, want, record.WagerDelta)
+		}
+	}
+
+	result, err := gs.RecomputeScores(lobby.ID, "", false, "test")
+	if err != nil {
+		t.Fatalf("RecomputeScores: %v", err)
+	}
+	if result.RecordedWinner != bob.ID || result.RecomputedWinner != bob.ID || result.WinnerChanged {
+		t.Fatalf("Expected bob to win both before and after recomputing, got %+v", result)
+	}
+	current.Lock()
+	final := map[string]int{alice.ID: current.GetPlayer(alice.ID).Score, bob.ID: current.GetPlayer(bob.ID).Score}
+	current.Unlock()
+	for _, p := range result.Players {
+		if p.RecordedScore != final[p.PlayerID] || p.RecomputedScore != final[p.PlayerID] {
+			t.Fatalf("Expected %s's scores to match the final %d, got %+v", p.Username, final[p.PlayerID], p)
+		}
+	}
+}
+
+func correctAnswer(q *models.Question) models.SubmittedAnswer {
+	switch q.QuestionType() {
+	case models.FreeText:
+		return models.SubmittedAnswer{Text: q.AcceptedAnswers[0]}
+	case models.MultiSelect:
+		return models.SubmittedAnswer{Choices: q.CorrectAnswers}
+	case models.Numeric:
+		return models.SubmittedAnswer{Number: q.NumericAnswer}
+	}
+	return models.SubmittedAnswer{Choice: q.Correct}
+}
+
+// Games are scored, and by default recomputed, under the version active when
+// they started, and every scoring change lands in the audit log.
+func TestScoringVersions(t *testing.T) {
+	gs, gameHub, repo := newService(t)
+
+	v2 := models.DefaultScoringConfig
+	v2.Version, v2.Name, v2.BaseScore = "v2", "Double base", 200
+	if _, err := gs.CreateScoringConfig(v2, "tester"); err != nil {
+		t.Fatalf("CreateScoringConfig: %v", err)
+	}
+	if _, err := gs.CreateScoringConfig(v2, "tester"); !errors.Is(err, services.ErrScoringVersionExists) {
+		t.Fatalf("Expected ErrScoringVersionExists, got %v", err)
+	}
+	invalid := v2
+	invalid.Version, invalid.BaseScore = "v3", 0
+	if _, err := gs.CreateScoringConfig(invalid, "tester"); !errors.Is(err, services.ErrInvalidScoringConfig) {
+		t.Fatalf("Expected ErrInvalidScoringConfig, got %v", err)
+	}
+	if err := gs.ActivateScoringConfig("v2", "tester"); err != nil {
+		t.Fatalf("ActivateScoringConfig: %v", err)
+	}
+
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Versions", MaxRounds: 3, MaxPlayers: 4})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	var playerIDs []string
+	for _, name := range []string{"first", "second"} {
+		_, player, err := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: name})
+		if err != nil {
+			t.Fatalf("JoinLobby: %v", err)
+		}
+		playerIDs = append(playerIDs, player.ID)
+	}
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+
+	current := gameHub.GetLobbyHub(lobby.ID).GetLobby()
+	current.Lock()
+	version := current.ScoringVersion
+	answer := correctAnswer(current.CurrentQ)
+	current.Unlock()
+	if version != "v2" {
+		t.Fatalf("Expected the game to use v2, got %q", version)
+	}
+	for _, playerID := range playerIDs {
+		if err := gs.SubmitAnswer(lobby.ID, playerID, answer); err != nil {
+			t.Fatalf("SubmitAnswer: %v", err)
+		}
+	}
+	if err := gs.ForceEndGame(lobby.ID); err != nil {
+		t.Fatalf("ForceEndGame: %v", err)
+	}
+
+	answers, _ := repo.GetLobbyAnswers(lobby.ID)
+	first := answers[0]
+	if first.ScoringVersion != "v2" || first.Score < 200 {
+		t.Fatalf("Expected a v2 score of at least 200, got %d under %q", first.Score, first.ScoringVersion)
+	}
+
+	result, err := gs.RecomputeScores(lobby.ID, "", false, "tester")
+	if err != nil {
+		t.Fatalf("RecomputeScores: %v", err)
+	}
+	if result.ScoringVersion != "v2" || result.ChangedAnswers != 0 {
+		t.Fatalf("Expected recomputation under v2 to match, got %+v", result)
+	}
+	result, err = gs.RecomputeScores(lobby.ID, "v1", false, "tester")
+	if err != nil {
+		t.Fatalf("RecomputeScores: %v", err)
+	}
+	if result.RecordedVersion != "v2" || result.ChangedAnswers != len(playerIDs) {
+		t.Fatalf("Expected every answer to change under v1, got %+v", result)
+	}
+
+	entries, err := gs.ScoringAudit()
+	if err != nil {
+		t.Fatalf("ScoringAudit: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Action != models.ScoringActivated || entries[1].Action != models.ScoringCreated {
+		t.Fatalf("Expected created then activated audit entries, got %+v", entries)
+	}
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"buildprize-game/internal/game"
+	"buildprize-game/internal/models"
+)
+
+// maxReplayEvents caps the events a replay loads, well beyond a full game.
+const maxReplayEvents = 20000
+
+// Replay returns a game's event log, in broadcast order, once the game is
+// over: it has ended or been cancelled, or its lobby is gone. Games still
+// being played, or waiting to be, have no replay yet.
+func (gs *GameService) Replay(lobbyID string) ([]*models.GameEvent, error) {
+	events, err := gs.GameEvents(lobbyID, 0, maxGameEvents)
+	if err != nil {
+		return nil, err
+	}
+	for page := events; len(page) == maxGameEvents && len(events) < maxReplayEvents; {
+		if page, err = gs.storedEvents(lobbyID, page[len(page)-1].Seq, maxGameEvents); err != nil {
+			return nil, err
+		}
+		events = append(events, page...)
+	}
+
+	over := false
+	for _, event := range events {
+		over = over || event.Type == "game_ended" || event.Type == "game_cancelled"
+	}
+	if !over {
+		if lobby, err := gs.repo.GetLobby(lobbyID); err == nil && lobby.Phase != game.Finished {
+			return nil, ErrGameNotFinished
+		}
+	}
+	if len(events) == 0 {
+		return nil, ErrReplayNotFound
+	}
+	return events, nil
+}
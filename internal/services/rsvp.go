@@ -0,0 +1,219 @@
+package services
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"buildprize-game/internal/api"
+	"buildprize-game/internal/game"
+	"buildprize-game/internal/models"
+)
+
+// maxInvitees caps a scheduled game's RSVP list, invited or open.
+const maxInvitees = 100
+
+// defaultStartGrace is how long a held scheduled start waits for its
+// players before the game is cancelled, unless changed with
+// SetScheduledStartGrace.
+const defaultStartGrace = 10 * time.Minute
+
+// startReminders are how long before a scheduled start the lobby is sent a
+// start_reminder. Those already past when the lobby is created are skipped.
+var startReminders = []time.Duration{15 * time.Minute, 5 * time.Minute, time.Minute, 10 * time.Second}
+
+// SetScheduledStartGrace sets how long a scheduled start held for missing
+// players waits before the game is cancelled. Negative durations are
+// ignored; with zero, an under-filled game is cancelled at its start time.
+func (gs *GameService) SetScheduledStartGrace(grace time.Duration) {
+	if grace < 0 {
+		return
+	}
+	gs.mu.Lock()
+	gs.startGrace = grace
+	gs.mu.Unlock()
+}
+
+// validateSchedule checks a scheduled game's options and returns its
+// invitation list, one pending invite per distinct normalized username.
+func validateSchedule(opts LobbyOptions) ([]*models.Invite, error) {
+	if opts.StartsAt.IsZero() {
+		if len(opts.Invitees) > 0 || opts.RSVPQuorum != 0 {
+			return nil, ErrInvalidSchedule
+		}
+		return nil, nil
+	}
+	if !opts.StartsAt.After(models.Now()) || len(opts.Invitees) > maxInvitees || opts.RSVPQuorum < 0 {
+		return nil, ErrInvalidSchedule
+	}
+
+	var invites []*models.Invite
+	seen := make(map[string]bool)
+	for _, username := range opts.Invitees {
+		username, err := models.NormalizeUsername(username)
+		if err != nil {
+			return nil, err
+		}
+		if key := strings.ToLower(username); !seen[key] {
+			seen[key] = true
+			invites = append(invites, &models.Invite{Username: username, Status: models.RSVPPending})
+		}
+	}
+	limit := len(invites)
+	if limit == 0 {
+		limit = maxInvitees
+	}
+	if opts.RSVPQuorum > limit {
+		return nil, ErrInvalidSchedule
+	}
+	return invites, nil
+}
+
+// scheduleStart starts the lobby's game after delay, reminding the lobby
+// beforehand, or holds the start until the quorum has joined.
+func (gs *GameService) scheduleStart(lobbyID string, delay time.Duration) {
+	log.Printf("Lobby %s scheduled to start in %s", lobbyID, delay.Round(time.Second))
+	for _, before := range startReminders {
+		if before < delay {
+			time.AfterFunc(delay-before, func() { gs.remindStart(lobbyID) })
+		}
+	}
+	time.AfterFunc(delay, func() { gs.scheduledStart(lobbyID) })
+}
+
+// remindStart tells a lobby still waiting how soon its game starts and who
+// is expected.
+func (gs *GameService) remindStart(lobbyID string) {
+	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
+	if lobbyHub == nil {
+		return
+	}
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+	if lobby.Phase != game.Waiting || lobby.StartsAt == nil {
+		return
+	}
+	startsIn := time.Until(*lobby.StartsAt).Round(time.Second)
+	gs.BroadcastLobbyUpdate(lobbyHub, "start_reminder", map[string]interface{}{
+		"starts_at":         *lobby.StartsAt,
+		"starts_in_seconds": int(startsIn.Seconds()),
+		"attendance":        lobby.Attendance(),
+		"players":           len(lobby.Players),
+	})
+}
+
+func (gs *GameService) scheduledStart(lobbyID string) {
+	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
+	if lobbyHub == nil {
+		return
+	}
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	if lobby.Phase != game.Waiting {
+		lobby.Unlock()
+		return
+	}
+	if !lobby.QuorumReached() || !lobby.CanStart() {
+		lobby.StartHeld = true
+		gs.repo.SaveLobby(lobby)
+		attendance := lobby.Attendance()
+		log.Printf("Lobby %s scheduled start held: %d of %d joined, %d players seated", lobbyID, attendance.Joined, attendance.Quorum, len(lobby.Players))
+		gs.mu.Lock()
+		grace := gs.startGrace
+		gs.mu.Unlock()
+		cancelAt := models.Now().Add(grace)
+		gs.BroadcastLobbyUpdate(lobbyHub, "start_held", map[string]interface{}{
+			"attendance": attendance,
+			"cancel_at":  cancelAt,
+			"lobby":      api.FromLobby(lobby),
+		})
+		lobby.Unlock()
+		time.AfterFunc(grace, func() { gs.cancelUnderFilled(lobbyID) })
+		return
+	}
+	lobby.Unlock()
+	gs.startScheduled(lobbyID)
+}
+
+// cancelUnderFilled cancels a scheduled game whose held start is still
+// missing players once the grace window has passed: the lobby is sent
+// game_cancelled and then removed.
+func (gs *GameService) cancelUnderFilled(lobbyID string) {
+	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
+	if lobbyHub == nil {
+		return
+	}
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	if lobby.Phase != game.Waiting || !lobby.StartHeld {
+		lobby.Unlock()
+		return
+	}
+	attendance := lobby.Attendance()
+	log.Printf("Cancelling scheduled lobby %s: %d of %d joined, %d players seated", lobbyID, attendance.Joined, attendance.Quorum, len(lobby.Players))
+	gs.BroadcastLobbyUpdate(lobbyHub, "game_cancelled", map[string]interface{}{
+		"reason":     "under_filled",
+		"attendance": attendance,
+		"players":    len(lobby.Players),
+	})
+	lobby.Unlock()
+	gs.discardLobby(lobbyID, "under_filled")
+}
+
+func (gs *GameService) startScheduled(lobbyID string) {
+	if err := gs.StartGame(lobbyID); err != nil && err != ErrCannotStartGame {
+		log.Printf("Failed to start scheduled lobby %s: %v", lobbyID, err)
+	}
+}
+
+// RSVP records username's answer to a scheduled game's invitation, status
+// being yes, no or maybe, and tells the lobby. A player can change their
+// answer until the game starts.
+func (gs *GameService) RSVP(lobbyID, username, status string) (*models.Invite, *models.Attendance, error) {
+	rsvp, err := models.ParseRSVP(status)
+	if err != nil {
+		return nil, nil, ErrInvalidRSVP
+	}
+	username, err = models.NormalizeUsername(username)
+	if err != nil {
+		return nil, nil, err
+	}
+	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
+	if lobbyHub == nil {
+		return nil, nil, ErrLobbyNotFound
+	}
+
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+	if lobby.StartsAt == nil {
+		return nil, nil, ErrNotScheduled
+	}
+	if lobby.Phase != game.Waiting {
+		return nil, nil, ErrGameInProgress
+	}
+
+	invite := lobby.FindInvite(username)
+	if invite == nil {
+		if !lobby.OpenRSVP {
+			return nil, nil, ErrNotInvited
+		}
+		if len(lobby.Invites) >= maxInvitees {
+			return nil, nil, ErrRSVPListFull
+		}
+		invite = &models.Invite{Username: username}
+		lobby.Invites = append(lobby.Invites, invite)
+	}
+	now := models.Now()
+	invite.Status, invite.RespondedAt = rsvp, &now
+	gs.repo.SaveLobby(lobby)
+
+	recorded, attendance := *invite, lobby.Attendance()
+	log.Printf("Player %s answered %s for scheduled lobby %s (%d expected)", username, rsvp, lobbyID, attendance.Expected)
+	gs.BroadcastLobbyUpdate(lobbyHub, "rsvp_updated", map[string]interface{}{
+		"invite":     recorded,
+		"attendance": attendance,
+	})
+	return &recorded, attendance, nil
+}
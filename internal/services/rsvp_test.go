@@ -0,0 +1,127 @@
+package services_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/game"
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// A scheduled game takes RSVPs from its invitees only, counts the players
+// who said yes against those who joined, and holds its start until the
+// quorum of them is seated.
+func TestScheduledGameWaitsForRSVPQuorum(t *testing.T) {
+	gs, gameHub, _ := newService(t)
+	if _, err := gs.CreateLobby(services.LobbyOptions{Name: "Late", MaxRounds: 3, MaxPlayers: 4, StartsAt: models.Now().Add(-time.Minute)}); !errors.Is(err, services.ErrInvalidSchedule) {
+		t.Fatalf("Expected a start in the past refused, got %v", err)
+	}
+
+	lobby, err := gs.CreateLobby(services.LobbyOptions{
+		Name:       "Friday quiz",
+		MaxRounds:  3,
+		MaxPlayers: 4,
+		StartsAt:   models.Now().Add(300 * time.Millisecond),
+		Invitees:   []string{"alice", "bob", "carol", "Alice"},
+		RSVPQuorum: 2,
+	})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	lobbyHub := gameHub.GetLobbyHub(lobby.ID)
+	watcher := &hub.Client{ID: "watcher", LobbyID: lobby.ID, Send: make(chan []byte, 64)}
+	lobbyHub.Register(watcher)
+
+	if _, _, err := gs.RSVP(lobby.ID, "mallory", "yes"); !errors.Is(err, services.ErrNotInvited) {
+		t.Fatalf("Expected an RSVP from someone not invited refused, got %v", err)
+	}
+	if _, _, err := gs.RSVP(lobby.ID, "alice", "sure"); !errors.Is(err, services.ErrInvalidRSVP) {
+		t.Fatalf("Expected an unknown RSVP refused, got %v", err)
+	}
+	gs.RSVP(lobby.ID, "ALICE", "maybe")
+	gs.RSVP(lobby.ID, "alice", "yes")
+	gs.RSVP(lobby.ID, "bob", "yes")
+	_, attendance, err := gs.RSVP(lobby.ID, "carol", "no")
+	if err != nil {
+		t.Fatalf("RSVP: %v", err)
+	}
+	if attendance.Invited != 3 || attendance.Expected != 2 || attendance.No != 1 || attendance.Joined != 0 {
+		t.Fatalf("Expected 2 of 3 invitees expected and none joined, got %+v", attendance)
+	}
+
+	gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"})
+	gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "dave"})
+	waitForEvent(t, watcher, "start_held")
+	lobby.Lock()
+	phase, held, joined := lobby.Phase, lobby.StartHeld, lobby.Attendance().Joined
+	lobby.Unlock()
+	if phase != game.Waiting || !held || joined != 1 {
+		t.Fatalf("Expected the start held with 1 of 2 joined, got phase %s, held %v, joined %d", phase, held, joined)
+	}
+
+	gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "bob"})
+	waitForEvent(t, watcher, "game_started")
+	defer gs.ForceEndGame(lobby.ID)
+	lobby.Lock()
+	held = lobby.StartHeld
+	lobby.Unlock()
+	if held {
+		t.Fatal("Expected the hold cleared once the game started")
+	}
+	if _, _, err := gs.RSVP(lobby.ID, "carol", "yes"); !errors.Is(err, services.ErrGameInProgress) {
+		t.Fatalf("Expected RSVPs closed once the game started, got %v", err)
+	}
+}
+
+// A scheduled game reminds its lobby before the start and, if its held
+// start is still missing players once the grace window passes, is cancelled
+// with notice and removed.
+func TestScheduledGameRemindsAndCancelsUnderFilled(t *testing.T) {
+	gs, gameHub, _ := newService(t)
+	soon, err := gs.CreateLobby(services.LobbyOptions{Name: "Soon", MaxRounds: 3, MaxPlayers: 4, StartsAt: models.Now().Add(10*time.Second + 300*time.Millisecond)})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	_, host, _ := gs.JoinLobby(soon.ID, services.JoinOptions{Username: "host"})
+	defer gs.LeaveLobby(soon.ID, host.ID)
+	reminded := &hub.Client{ID: "reminded", LobbyID: soon.ID, Send: make(chan []byte, 64)}
+	gameHub.GetLobbyHub(soon.ID).Register(reminded)
+	waitForEvent(t, reminded, "start_reminder")
+
+	gs.SetScheduledStartGrace(200 * time.Millisecond)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Quiet night", MaxRounds: 3, MaxPlayers: 4, StartsAt: models.Now().Add(200 * time.Millisecond)})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	watcher := &hub.Client{ID: "watcher", LobbyID: lobby.ID, Send: make(chan []byte, 64)}
+	gameHub.GetLobbyHub(lobby.ID).Register(watcher)
+	gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"})
+	waitForEvent(t, watcher, "start_held")
+	waitForEvent(t, watcher, "game_cancelled")
+	if gameHub.GetLobbyHub(lobby.ID) != nil {
+		t.Fatal("Expected the cancelled lobby removed")
+	}
+}
+
+func waitForEvent(t *testing.T, client *hub.Client, eventType string) {
+	t.Helper()
+	deadline := time.After(2 * time.Second)
+	for {
+		select {
+		case payload := <-client.Send:
+			var event struct {
+				Type string `json:"type"`
+			}
+			json.Unmarshal(payload, &event)
+			if event.Type == eventType {
+				return
+			}
+		case <-deadline:
+			t.Fatalf("%s never arrived", eventType)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"buildprize-game/internal/api"
+	"buildprize-game/internal/game"
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+)
+
+// ScriptedAnswer is an answer a test player submits automatically when a
+// round starts, ResponseTime milliseconds after the question is shown.
+type ScriptedAnswer struct {
+	Answer       interface{} `json:"answer"` // a choice index, or an array of indexes for multi-select
+	ResponseTime int64       `json:"response_time"`
+}
+
+// CreateSandboxLobby creates a lobby for admin test drives. Sandbox lobbies
+// are hidden from the public listing and their players are excluded from stats.
+func (gs *GameService) CreateSandboxLobby(name string, maxRounds int) *models.Lobby {
+	lobby := models.NewLobby(name, maxRounds)
+	lobby.Sandbox = true
+	lobby.MaxPlayers = gs.maxPlayers
+	lobby.Lock()
+	defer lobby.Unlock()
+	gs.hub.CreateLobbyHub(lobby)
+
+	if err := gs.repo.SaveLobby(lobby); err != nil {
+		log.Printf("ERROR: Failed to save sandbox lobby %s: %v", lobby.ID, err)
+	} else {
+		log.Printf("Created sandbox lobby %s with ID %s", name, lobby.ID)
+	}
+
+	return lobby
+}
+
+// AddTestPlayer spawns an ephemeral test player in a sandbox lobby. Any
+// scripted answers are replayed one per round once the game starts.
+func (gs *GameService) AddTestPlayer(lobbyID, username string, script []ScriptedAnswer) (*models.Player, error) {
+	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
+	if lobbyHub == nil {
+		return nil, ErrLobbyNotFound
+	}
+	username, err := models.NormalizeUsername(username)
+	if err != nil {
+		return nil, err
+	}
+
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+
+	if !lobby.Sandbox {
+		return nil, ErrNotSandboxLobby
+	}
+	if lobby.IsFull() {
+		return nil, ErrLobbyFull
+	}
+	if lobby.Phase != game.Waiting {
+		return nil, ErrGameInProgress
+	}
+
+	player := lobby.AddTestPlayer(username)
+	gs.repo.SaveLobby(lobby)
+
+	if len(script) > 0 {
+		gs.mu.Lock()
+		if gs.scripts[lobbyID] == nil {
+			gs.scripts[lobbyID] = make(map[string][]ScriptedAnswer)
+		}
+		gs.scripts[lobbyID][player.ID] = script
+		gs.mu.Unlock()
+	}
+
+	log.Printf("Admin spawned test player %s in sandbox lobby %s (%d scripted answer(s))", username, lobbyID, len(script))
+
+	gs.BroadcastLobbyUpdate(lobbyHub, "player_joined", map[string]interface{}{
+		"player": api.FromPlayer(player),
+		"lobby":  api.FromLobby(lobby),
+	})
+
+	return player, nil
+}
+
+// InjectAnswer submits an answer on behalf of a test player in a sandbox lobby.
+// Unlike SubmitAnswer, the caller chooses the response time.
+func (gs *GameService) InjectAnswer(lobbyID, playerID string, answer models.SubmittedAnswer, responseTime int64) error {
+	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
+	if lobbyHub == nil {
+		return ErrLobbyNotFound
+	}
+
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+
+	if !lobby.Sandbox {
+		return ErrNotSandboxLobby
+	}
+	player := lobby.GetPlayer(playerID)
+	if player == nil || !player.IsTest {
+		return ErrPlayerNotFound
+	}
+
+	return gs.submitAnswer(lobbyHub, playerID, answer, responseTime)
+}
+
+// playScriptedAnswers schedules the next scripted answer of every test
+// player in a sandbox lobby for the round that just started. The caller
+// holds the lobby lock.
+func (gs *GameService) playScriptedAnswers(ctx context.Context, lobbyHub *hub.LobbyHub) {
+	lobby := lobbyHub.GetLobby()
+	if !lobby.Sandbox {
+		return
+	}
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	for playerID, script := range gs.scripts[lobby.ID] {
+		if len(script) == 0 {
+			continue
+		}
+		next := script[0]
+		gs.scripts[lobby.ID][playerID] = script[1:]
+
+		go func(playerID string, sa ScriptedAnswer) {
+			if !sleepContext(ctx, time.Duration(sa.ResponseTime)*time.Millisecond) {
+				return
+			}
+			answer, err := models.ParseSubmittedAnswer(sa.Answer)
+			if err == nil {
+				err = gs.SubmitAnswer(lobby.ID, playerID, answer)
+			}
+			if err != nil {
+				log.Printf("Scripted answer for test player %s in lobby %s failed: %v", playerID, lobby.ID, err)
+			}
+		}(playerID, next)
+	}
+}
+
+func (gs *GameService) clearScripts(lobbyID string) {
+	gs.mu.Lock()
+	delete(gs.scripts, lobbyID)
+	gs.mu.Unlock()
+}
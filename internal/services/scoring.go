@@ -0,0 +1,151 @@
+package services
+
+import (
+	"errors"
+	"log"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/repository"
+)
+
+const scoringAuditLimit = 100
+
+// loadScoring picks up the active scoring version, falling back to the
+// built-in one when none is stored.
+func (gs *GameService) loadScoring() {
+	version, err := gs.repo.GetActiveScoringVersion()
+	if err != nil {
+		if !errors.Is(err, repository.ErrScoringConfigNotFound) {
+			log.Printf("Failed to load the active scoring version, using %s: %v", models.DefaultScoringConfig.Version, err)
+		}
+		version = models.DefaultScoringConfig.Version
+	}
+	gs.mu.Lock()
+	gs.scoringVersion = version
+	gs.mu.Unlock()
+	log.Printf("Scoring games with version %s", version)
+}
+
+// ActiveScoringVersion is the version new games are scored with.
+func (gs *GameService) ActiveScoringVersion() string {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	return gs.scoringVersion
+}
+
+// ScoringConfig looks up a scoring version. Versions never change once
+// stored, so they're cached after the first lookup; an empty version means
+// the one games were scored with before versions were recorded.
+func (gs *GameService) ScoringConfig(version string) (*models.ScoringConfig, error) {
+	if version == "" {
+		version = models.LegacyScoringConfig.Version
+	}
+	gs.mu.Lock()
+	config, ok := gs.scoringConfigs[version]
+	gs.mu.Unlock()
+	if ok {
+		return config, nil
+	}
+
+	config, err := gs.repo.GetScoringConfig(version)
+	if errors.Is(err, repository.ErrScoringConfigNotFound) {
+		builtIn, ok := models.BuiltInScoringConfig(version)
+		if !ok {
+			return nil, ErrUnknownScoringVersion
+		}
+		config, err = builtIn, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	gs.mu.Lock()
+	gs.scoringConfigs[version] = config
+	gs.mu.Unlock()
+	return config, nil
+}
+
+// lobbyScoring returns the rules a lobby's game is scored with: its own, or
+// its scoring version. The caller holds the lobby lock.
+func (gs *GameService) lobbyScoring(lobby *models.Lobby) *models.ScoringConfig {
+	if lobby.Scoring != nil {
+		return lobby.Scoring
+	}
+	config, err := gs.ScoringConfig(lobby.ScoringVersion)
+	if err != nil {
+		log.Printf("ERROR: Lobby %s: scoring version %q unavailable, using %s: %v",
+			lobby.ID, lobby.ScoringVersion, models.DefaultScoringConfig.Version, err)
+		builtIn := models.DefaultScoringConfig
+		return &builtIn
+	}
+	return config
+}
+
+// ScoringConfigs lists the stored scoring versions.
+func (gs *GameService) ScoringConfigs() ([]*models.ScoringConfig, error) {
+	return gs.repo.ListScoringConfigs()
+}
+
+// CreateScoringConfig stores a new scoring version. It doesn't take effect
+// until activated.
+func (gs *GameService) CreateScoringConfig(config models.ScoringConfig, actor string) (*models.ScoringConfig, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	config.CreatedBy = actor
+	if err := gs.repo.CreateScoringConfig(&config); err != nil {
+		if errors.Is(err, repository.ErrScoringVersionExists) {
+			return nil, ErrScoringVersionExists
+		}
+		return nil, err
+	}
+
+	gs.audit(&models.ScoringAuditEntry{
+		Action:  models.ScoringCreated,
+		Version: config.Version,
+		Actor:   actor,
+		Details: map[string]interface{}{"config": config},
+	})
+	log.Printf("Scoring version %s created by %s", config.Version, actor)
+	return &config, nil
+}
+
+// ActivateScoringConfig makes version the one games starting from now on
+// are scored with. Games already under way keep their version.
+func (gs *GameService) ActivateScoringConfig(version, actor string) error {
+	if _, err := gs.ScoringConfig(version); err != nil {
+		return err
+	}
+	if err := gs.repo.SetActiveScoringVersion(version); err != nil {
+		if errors.Is(err, repository.ErrScoringConfigNotFound) {
+			return ErrUnknownScoringVersion
+		}
+		return err
+	}
+
+	gs.mu.Lock()
+	previous := gs.scoringVersion
+	gs.scoringVersion = version
+	gs.mu.Unlock()
+
+	gs.audit(&models.ScoringAuditEntry{
+		Action:  models.ScoringActivated,
+		Version: version,
+		Actor:   actor,
+		Details: map[string]interface{}{"previous_version": previous},
+	})
+	log.Printf("Scoring version %s activated by %s (was %s)", version, actor, previous)
+	return nil
+}
+
+// ScoringAudit returns recent scoring changes, newest first.
+func (gs *GameService) ScoringAudit() ([]*models.ScoringAuditEntry, error) {
+	return gs.repo.ListScoringAudit(scoringAuditLimit)
+}
+
+func (gs *GameService) audit(entry *models.ScoringAuditEntry) {
+	entry.CreatedAt = models.Now()
+	if err := gs.repo.SaveScoringAudit(entry); err != nil {
+		log.Printf("ERROR: Failed to record scoring audit entry %s for version %s: %v", entry.Action, entry.Version, err)
+	}
+}
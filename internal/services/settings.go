@@ -0,0 +1,139 @@
+package services
+
+import (
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"buildprize-game/internal/api"
+	"buildprize-game/internal/game"
+	"buildprize-game/internal/models"
+)
+
+// Bounds on the settings a host can give a lobby.
+const (
+	maxLobbyNameLength = 100
+	maxLobbyRounds     = 50
+	minQuestionTime    = 5   // seconds
+	maxQuestionTime    = 120 // seconds
+)
+
+// LobbySettings are the settings a host can change before the game starts.
+// Nil fields are left as they are; empty CategoryWeights goes back to the
+// whole question bank.
+type LobbySettings struct {
+	Name            *string
+	MaxRounds       *int
+	QuestionTime    *int // seconds
+	CategoryWeights *map[string]int
+	MaxPlayers      *int
+	// Points taken off for a wrong answer, from 0 to the lobby's base score;
+	// totals never drop below zero
+	WrongPenalty *int
+}
+
+// UpdateLobbySettings changes a waiting lobby's settings on its host's
+// request and announces lobby_settings_updated with the fields that
+// changed. Either every setting is valid and applied, or none is.
+func (gs *GameService) UpdateLobbySettings(lobbyID, playerID string, settings LobbySettings) (*models.Lobby, error) {
+	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
+	if lobbyHub == nil {
+		return nil, ErrLobbyNotFound
+	}
+
+	var name string
+	if settings.Name != nil {
+		name = strings.TrimSpace(*settings.Name)
+		if name == "" || utf8.RuneCountInString(name) > maxLobbyNameLength {
+			return nil, ErrInvalidLobbyName
+		}
+	}
+	if settings.MaxRounds != nil && (*settings.MaxRounds < 1 || *settings.MaxRounds > maxLobbyRounds) {
+		return nil, ErrInvalidMaxRounds
+	}
+	if settings.QuestionTime != nil && (*settings.QuestionTime < minQuestionTime || *settings.QuestionTime > maxQuestionTime) {
+		return nil, ErrInvalidQuestionTime
+	}
+	if settings.CategoryWeights != nil {
+		if err := gs.validateCategoryWeights(*settings.CategoryWeights); err != nil {
+			return nil, err
+		}
+	}
+	if settings.MaxPlayers != nil && (*settings.MaxPlayers < 2 || *settings.MaxPlayers > gs.maxPlayers) {
+		return nil, ErrInvalidMaxPlayers
+	}
+
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+	if !lobby.IsHost(playerID) {
+		return nil, ErrNotHost
+	}
+	if lobby.Phase != game.Waiting {
+		return nil, ErrGameInProgress
+	}
+	if settings.MaxPlayers != nil && *settings.MaxPlayers < len(lobby.Players) {
+		return nil, ErrCapacityTooSmall
+	}
+	var scoring *models.ScoringConfig
+	if settings.WrongPenalty != nil {
+		// The penalty becomes one of the lobby's own scoring rules
+		base := lobby.Scoring
+		var err error
+		if base == nil {
+			if base, err = gs.ScoringConfig(gs.ActiveScoringVersion()); err != nil {
+				return nil, err
+			}
+		}
+		rules := &models.ScoringRules{WrongPenalty: settings.WrongPenalty}
+		if scoring, err = rules.Apply(*base); err != nil {
+			return nil, err
+		}
+		if base.WrongPenalty == scoring.WrongPenalty {
+			scoring = nil
+		}
+	}
+
+	var changed []string
+	if settings.Name != nil && name != lobby.Name {
+		lobby.Name = name
+		changed = append(changed, "name")
+	}
+	if settings.MaxRounds != nil && *settings.MaxRounds != lobby.MaxRounds {
+		lobby.MaxRounds = *settings.MaxRounds
+		changed = append(changed, "max_rounds")
+	}
+	if settings.QuestionTime != nil && *settings.QuestionTime != int(lobby.QuestionDuration().Seconds()) {
+		lobby.QuestionTime = *settings.QuestionTime
+		changed = append(changed, "question_time")
+	}
+	if settings.CategoryWeights != nil {
+		weights := *settings.CategoryWeights
+		if len(weights) == 0 {
+			weights = nil
+		}
+		lobby.CategoryWeights = weights
+		changed = append(changed, "category_weights")
+	}
+	if settings.MaxPlayers != nil && *settings.MaxPlayers != lobby.MaxPlayers {
+		lobby.MaxPlayers = *settings.MaxPlayers
+		changed = append(changed, "max_players")
+	}
+	if scoring != nil {
+		lobby.Scoring = scoring
+		changed = append(changed, "wrong_penalty")
+	}
+	gs.touchLobby(lobbyHub, lobby)
+	if len(changed) == 0 {
+		return lobby, nil
+	}
+
+	gs.repo.SaveLobby(lobby)
+	log.Printf("Lobby %s settings changed by %s: %s", lobbyID, playerID, strings.Join(changed, ", "))
+	gs.BroadcastLobbyUpdate(lobbyHub, "lobby_settings_updated", map[string]interface{}{
+		"updated_by": playerID,
+		"changed":    changed,
+		"lobby":      api.FromLobby(lobby),
+	})
+	return lobby, nil
+}
@@ -0,0 +1,59 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/services"
+)
+
+// Only the host can change a waiting lobby's settings, invalid settings
+// change nothing, and the change is announced with the fields changed.
+func TestUpdateLobbySettings(t *testing.T) {
+	gs, gameHub, _ := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Before", MaxRounds: 3, MaxPlayers: 4})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	_, host, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "host"})
+	_, guest, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "guest"})
+	gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "third"})
+	watcher := &hub.Client{ID: "watcher", LobbyID: lobby.ID, Send: make(chan []byte, 64)}
+	gameHub.GetLobbyHub(lobby.ID).Register(watcher)
+
+	name, rounds, questionTime, capacity := "After", 5, 20, 2
+	if _, err := gs.UpdateLobbySettings(lobby.ID, guest.ID, services.LobbySettings{Name: &name}); !errors.Is(err, services.ErrNotHost) {
+		t.Fatalf("Expected a guest refused, got %v", err)
+	}
+	if _, err := gs.UpdateLobbySettings(lobby.ID, host.ID, services.LobbySettings{Name: &name, MaxPlayers: &capacity}); !errors.Is(err, services.ErrCapacityTooSmall) {
+		t.Fatalf("Expected a capacity below the seated players refused, got %v", err)
+	}
+	weights := map[string]int{"Nowhere": 1}
+	if _, err := gs.UpdateLobbySettings(lobby.ID, host.ID, services.LobbySettings{Name: &name, CategoryWeights: &weights}); !errors.Is(err, services.ErrUnknownCategory) {
+		t.Fatalf("Expected an unknown category refused, got %v", err)
+	}
+	lobby.Lock()
+	unchanged := lobby.Name == "Before"
+	lobby.Unlock()
+	if !unchanged {
+		t.Fatal("Expected a refused update to change nothing")
+	}
+
+	weights = map[string]int{"any": 1}
+	updated, err := gs.UpdateLobbySettings(lobby.ID, host.ID, services.LobbySettings{Name: &name, MaxRounds: &rounds, QuestionTime: &questionTime, CategoryWeights: &weights})
+	if err != nil {
+		t.Fatalf("UpdateLobbySettings: %v", err)
+	}
+	updated.Lock()
+	if updated.Name != "After" || updated.MaxRounds != 5 || updated.QuestionDuration().Seconds() != 20 || updated.CategoryWeights["any"] != 1 || updated.MaxPlayers != 4 {
+		t.Fatalf("Unexpected settings after the update: %+v", updated)
+	}
+	updated.Unlock()
+	waitForEvent(t, watcher, "lobby_settings_updated")
+
+	gs.StartGame(lobby.ID)
+	if _, err := gs.UpdateLobbySettings(lobby.ID, host.ID, services.LobbySettings{Name: &name}); !errors.Is(err, services.ErrGameInProgress) {
+		t.Fatalf("Expected changes refused once the game started, got %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+)
+
+// streakMilestones are the streak lengths announced with streak_milestone,
+// and the power-up each one earns.
+var streakMilestones = map[int]models.PowerUp{
+	3:  models.FiftyFifty,
+	5:  models.DoublePoints,
+	10: models.Freeze,
+}
+
+// announceStreak broadcasts streak_milestone when player's correct answer
+// has just brought their streak to a milestone, with the multiplier their
+// next correct answer scores at and the power-up it earned them. The caller
+// holds the lobby lock.
+func (gs *GameService) announceStreak(lobbyHub *hub.LobbyHub, player *models.Player, scoring *models.ScoringConfig) {
+	powerUp := awardPowerUp(player)
+	if powerUp == "" {
+		return
+	}
+	gs.BroadcastLobbyUpdate(lobbyHub, "streak_milestone", map[string]interface{}{
+		"player_id":  player.ID,
+		"username":   player.Username,
+		"streak":     player.Streak,
+		"multiplier": scoring.Multiplier(player.Streak),
+		"power_up":   powerUp,
+	})
+}
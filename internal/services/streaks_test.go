@@ -0,0 +1,70 @@
+package services_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// Correct answers in a row score more, up to the cap, and a streak reaching
+// a milestone is announced.
+func TestStreakMultiplier(t *testing.T) {
+	gs, gameHub, repo := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Streaks", MaxRounds: 3, MaxPlayers: 4})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	_, player, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"})
+	gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "bob"})
+	watcher := &hub.Client{ID: "watcher", LobbyID: lobby.ID, Send: make(chan []byte, 256)}
+	gameHub.GetLobbyHub(lobby.ID).Register(watcher)
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+
+	// Two correct answers already behind her
+	current := gameHub.GetLobbyHub(lobby.ID).GetLobby()
+	current.Lock()
+	current.GetPlayer(player.ID).Streak = 2
+	question := current.CurrentQ
+	current.Unlock()
+	answer := correctAnswer(question)
+	if err := gs.SubmitAnswer(lobby.ID, player.ID, answer); err != nil {
+		t.Fatalf("SubmitAnswer: %v", err)
+	}
+
+	answers, _ := repo.GetLobbyAnswers(lobby.ID)
+	if len(answers) != 1 || answers[0].ScoringVersion != models.DefaultScoringConfig.Version {
+		t.Fatalf("Expected one answer under %s, got %+v", models.DefaultScoringConfig.Version, answers)
+	}
+	unstreaked := models.LegacyScoringConfig.Score(question, answer, answers[0].ResponseTime, 0)
+	if want := int(float64(unstreaked)*1.2 + 0.5); answers[0].Score != want {
+		t.Fatalf("Expected a third correct answer in a row to score x1.2 (%d), got %d", want, answers[0].Score)
+	}
+
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case payload := <-watcher.Send:
+			var event models.GameEvent
+			json.Unmarshal(payload, &event)
+			if event.Type != "streak_milestone" {
+				continue
+			}
+			data := event.Data.(map[string]interface{})
+			if data["player_id"] != player.ID || data["streak"].(float64) != 3 || data["multiplier"].(float64) < 1.29 {
+				t.Fatalf("Unexpected milestone: %v", data)
+			}
+			if got := models.DefaultScoringConfig.Multiplier(50); got != models.DefaultScoringConfig.StreakCap {
+				t.Fatalf("Expected a long streak capped at x%g, got x%g", models.DefaultScoringConfig.StreakCap, got)
+			}
+			return
+		case <-deadline:
+			t.Fatal("streak_milestone never arrived")
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"buildprize-game/internal/game"
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+)
+
+// defaultTimerTick is how often the open question's remaining time is
+// broadcast unless changed with SetTimerTick.
+const defaultTimerTick = 5 * time.Second
+
+// SetTimerTick sets how often timer_tick events are sent while a question
+// is open. Zero or less turns them off.
+func (gs *GameService) SetTimerTick(interval time.Duration) {
+	if interval < 0 {
+		interval = 0
+	}
+	gs.mu.Lock()
+	gs.timerTick = interval
+	gs.mu.Unlock()
+}
+
+// startTimerTicks sends the lobby timer_tick events with the question's
+// remaining_ms every tick interval until round's question closes, so
+// clients whose clocks drift stay in step with the server's. Ticks pause
+// with the game. The caller holds the lobby lock.
+func (gs *GameService) startTimerTicks(ctx context.Context, lobbyHub *hub.LobbyHub, round int) {
+	gs.mu.Lock()
+	interval := gs.timerTick
+	gs.mu.Unlock()
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if !gs.sendTimerTick(lobbyHub, round) {
+				return
+			}
+		}
+	}()
+}
+
+// sendTimerTick broadcasts one tick, reporting false once round's question
+// has closed.
+func (gs *GameService) sendTimerTick(lobbyHub *hub.LobbyHub, round int) bool {
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+	if lobby.Round != round || lobby.Phase != game.Question || lobby.QuestionEnd == nil {
+		return false
+	}
+	if lobby.Paused {
+		return true
+	}
+	remaining := lobby.QuestionTimeLeft()
+	if remaining <= 0 {
+		return false
+	}
+	lobbyHub.Publish(&models.GameEvent{
+		Type:    "timer_tick",
+		LobbyID: lobby.ID,
+		Data: map[string]interface{}{
+			"round":             round,
+			"remaining_ms":      remaining.Milliseconds(),
+			"question_end_time": models.FormatTimestamp(*lobby.QuestionEnd),
+			"server_time":       models.FormatTimestamp(models.Now()),
+		},
+		Ephemeral: true,
+	})
+	return true
+}
@@ -0,0 +1,61 @@
+package services_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// An open question's remaining time is broadcast as timer_tick events,
+// which carry the lobby's current seq without taking one of their own and
+// stay out of the event log.
+func TestTimerTicks(t *testing.T) {
+	gs, gameHub, _ := newService(t)
+	gs.SetTimerTick(50 * time.Millisecond)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Ticks", MaxRounds: 1, MaxPlayers: 4})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"})
+	gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "bob"})
+	watcher := &hub.Client{ID: "watcher", LobbyID: lobby.ID, Send: make(chan []byte, 256)}
+	gameHub.GetLobbyHub(lobby.ID).Register(watcher)
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+
+	var lastSeq uint64
+	deadline := time.After(5 * time.Second)
+	for ticks := 0; ticks < 2; {
+		select {
+		case payload := <-watcher.Send:
+			var event models.GameEvent
+			json.Unmarshal(payload, &event)
+			if event.Type != "timer_tick" {
+				lastSeq = event.Seq
+				continue
+			}
+			data := event.Data.(map[string]interface{})
+			if event.Seq != lastSeq || data["remaining_ms"].(float64) > 15000 || data["round"].(float64) != 1 {
+				t.Fatalf("Unexpected tick after seq %d: seq %d, %v", lastSeq, event.Seq, data)
+			}
+			ticks++
+		case <-deadline:
+			t.Fatal("timer_tick never arrived")
+		}
+	}
+
+	events, err := gs.GameEvents(lobby.ID, 0, 0)
+	if err != nil {
+		t.Fatalf("GameEvents: %v", err)
+	}
+	for _, event := range events {
+		if event.Type == "timer_tick" {
+			t.Fatal("Expected ticks kept out of the event log")
+		}
+	}
+}
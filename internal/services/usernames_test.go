@@ -0,0 +1,67 @@
+package services_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// Usernames are normalized on join and lookalikes of a seated player, such
+// as one spelled with a Cyrillic А, are refused.
+func TestUsernameNormalizationAndConfusables(t *testing.T) {
+	gs, _, _ := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Names", MaxRounds: 3, MaxPlayers: 8})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+
+	// "José" with a combining accent is stored precomposed
+	_, jose, err := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "  Jose\u0301   Luis "})
+	if err != nil {
+		t.Fatalf("JoinLobby: %v", err)
+	}
+	if jose.Username != "Jos\u00e9 Luis" {
+		t.Fatalf("Expected the NFC form with collapsed spaces, got %q", jose.Username)
+	}
+	if _, _, err := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"}); err != nil {
+		t.Fatalf("JoinLobby: %v", err)
+	}
+
+	for name, want := range map[string]error{
+		"alice":                          services.ErrUsernameConfusable,
+		"ALICE":                          services.ErrUsernameConfusable,
+		"a1ice":                          services.ErrUsernameConfusable,
+		"\u0430\u04cf\u0456\u0441\u0435": services.ErrUsernameConfusable, // all Cyrillic
+		"\u0410lice":                     services.ErrUsernameMixedScripts,
+		"ali\u200bce":                    services.ErrUsernameInvisible,
+		"   ":                            services.ErrUsernameEmpty,
+		strings.Repeat("x", 21):          services.ErrUsernameTooLong,
+	} {
+		if _, _, err := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: name}); !errors.Is(err, want) {
+			t.Errorf("JoinLobby(%q): expected %v, got %v", name, want, err)
+		}
+	}
+
+	// Length is counted in characters as displayed, not bytes or code points
+	flags := strings.Repeat("\U0001F1EC\U0001F1E7", 20)
+	if _, _, err := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: flags}); err != nil {
+		t.Fatalf("Expected 20 flags to fit the username limit, got %v", err)
+	}
+	if _, _, err := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "\u5c71\u7530\u305f\u308d\u3046"}); err != nil {
+		t.Fatalf("Expected a Japanese name mixing kanji and kana to be accepted, got %v", err)
+	}
+
+	message, err := models.NormalizeChatMessage("hi\u202e there\n")
+	if err != nil || message != "hi there" {
+		t.Fatalf("Expected bidi overrides stripped, got %q, %v", message, err)
+	}
+	if _, err := models.NormalizeChatMessage(strings.Repeat("e\u0301", 300)); err != nil {
+		t.Fatalf("Expected 300 accented characters to fit the chat limit, got %v", err)
+	}
+	if _, err := models.NormalizeChatMessage(strings.Repeat("e", 301)); !errors.Is(err, models.ErrChatMessageTooLong) {
+		t.Fatalf("Expected ErrChatMessageTooLong, got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package services_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/services"
+)
+
+// Lobby events carry the wire views: the open question goes out without its
+// answers and internal lobby and player fields stay server-side.
+func TestEventsOmitInternalFields(t *testing.T) {
+	gs, gameHub, _ := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Views", MaxRounds: 3, MaxPlayers: 4})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	lobbyHub := gameHub.GetLobbyHub(lobby.ID)
+	client := &hub.Client{ID: "watcher", LobbyID: lobby.ID, Send: make(chan []byte, 64)}
+	lobbyHub.Register(client)
+
+	gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"})
+	gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "bob"})
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	defer gs.ForceEndGame(lobby.ID)
+
+	seen := make(map[string]map[string]interface{})
+	deadline := time.After(2 * time.Second)
+	for seen["new_question"] == nil {
+		select {
+		case payload := <-client.Send:
+			var event struct {
+				Type string                 `json:"type"`
+				Data map[string]interface{} `json:"data"`
+			}
+			if err := json.Unmarshal(payload, &event); err != nil {
+				t.Fatalf("Decode event: %v", err)
+			}
+			seen[event.Type] = event.Data
+		case <-deadline:
+			t.Fatalf("new_question never arrived, saw %d event types", len(seen))
+		}
+	}
+
+	question, _ := seen["new_question"]["question"].(map[string]interface{})
+	if question == nil || question["text"] == nil {
+		t.Fatalf("new_question carried no question: %v", seen["new_question"])
+	}
+	for _, key := range []string{"correct", "correct_answers", "accepted_answers"} {
+		if _, ok := question[key]; ok {
+			t.Fatalf("new_question leaked %q", key)
+		}
+	}
+
+	started, _ := seen["game_started"]["lobby"].(map[string]interface{})
+	if started == nil {
+		t.Fatal("game_started carried no lobby")
+	}
+	for _, key := range []string{"scoring_version", "question_start", "sandbox"} {
+		if _, ok := started[key]; ok {
+			t.Fatalf("game_started lobby leaked %q", key)
+		}
+	}
+	players, _ := started["players"].([]interface{})
+	if len(players) != 2 || players[0].(map[string]interface{})["username"] != "alice" {
+		t.Fatalf("Unexpected players in game_started: %v", started["players"])
+	}
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"buildprize-game/internal/game"
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+)
+
+// defaultWagerTime is how long players have to stake points before a wager
+// round's question unless changed with SetWagerTime.
+const defaultWagerTime = 10 * time.Second
+
+// SetWagerTime sets how long the wager phase of a wager round lasts. Zero
+// or less is ignored.
+func (gs *GameService) SetWagerTime(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	gs.mu.Lock()
+	gs.wagerTime = d
+	gs.mu.Unlock()
+}
+
+// validateWagerRounds checks that wager rounds fall within the game's rounds
+// and are listed once each.
+func validateWagerRounds(rounds []int, maxRounds int) error {
+	seen := make(map[int]bool)
+	for _, round := range rounds {
+		if round < 1 || round > maxRounds || seen[round] {
+			return ErrInvalidWagerRounds
+		}
+		seen[round] = true
+	}
+	return nil
+}
+
+// openWager starts a wager round with its wager phase, serving the question
+// once every player has staked or the wager timer runs out. The caller
+// holds the lobby lock.
+func (gs *GameService) openWager(lobbyHub *hub.LobbyHub) {
+	lobby := lobbyHub.GetLobby()
+	gs.mu.Lock()
+	wagerTime := gs.wagerTime
+	gs.mu.Unlock()
+
+	lobby.Wagers = make(map[string]*models.Wager)
+	gs.advance(lobby, game.Wager)
+	gs.repo.SaveLobby(lobby)
+
+	endsAt := models.Now().Add(wagerTime)
+	open := map[string]interface{}{
+		"round":          lobby.Round,
+		"time_left":      int(wagerTime.Seconds()),
+		"wager_end_time": models.FormatTimestamp(endsAt),
+		"server_time":    models.FormatTimestamp(models.Now()),
+	}
+	if lobby.IsFinalRound(lobby.Round) {
+		open["final"] = true
+	}
+	gs.BroadcastLobbyUpdate(lobbyHub, "wager_open", open)
+
+	round := lobby.Round
+	gs.scheduleRound(lobby.ID, wagerTime, func() { gs.closeWager(lobbyHub, round) })
+}
+
+// PlaceWager stakes up to the player's whole score on the wager round about
+// to be asked. Each player wagers once; the stake is announced to the lobby,
+// except in the final round, where the lobby only hears that the player
+// wagered and the stake goes back to them alone as wager_confirmed.
+func (gs *GameService) PlaceWager(lobbyID, playerID string, stake int) (*models.Wager, error) {
+	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
+	if lobbyHub == nil {
+		return nil, ErrLobbyNotFound
+	}
+
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+	if lobby.Phase != game.Wager || lobby.Paused {
+		return nil, ErrWagersClosed
+	}
+	player := lobby.GetPlayer(playerID)
+	if player == nil {
+		return nil, ErrPlayerNotFound
+	}
+	if lobby.Wagers[playerID] != nil {
+		return nil, ErrAlreadyWagered
+	}
+	if stake < 0 || stake > player.Score {
+		return nil, ErrInvalidStake
+	}
+
+	wager := &models.Wager{PlayerID: playerID, Username: player.Username, Stake: stake}
+	lobby.Wagers[playerID] = wager
+	if lobby.IsFinalRound(lobby.Round) {
+		gs.BroadcastLobbyUpdate(lobbyHub, "wager_placed", map[string]interface{}{
+			"player_id": playerID,
+			"round":     lobby.Round,
+		})
+		gs.SendPersonalEvent(lobbyHub, playerID, "wager_confirmed", map[string]interface{}{
+			"round": lobby.Round,
+			"stake": stake,
+		})
+	} else {
+		gs.BroadcastLobbyUpdate(lobbyHub, "wager_placed", map[string]interface{}{
+			"player_id": playerID,
+			"round":     lobby.Round,
+			"stake":     stake,
+		})
+	}
+
+	if gs.everyoneWagered(lobbyHub) {
+		log.Printf("All players wagered on round %d in lobby %s, serving the question early", lobby.Round, lobbyID)
+		round := lobby.Round
+		go gs.closeWager(lobbyHub, round)
+	}
+	copied := *wager
+	return &copied, nil
+}
+
+// everyoneWagered reports whether every connected human player has staked.
+// Bots and test players never wager, so a wager phase with nobody else runs
+// its full time. The caller holds the lobby lock.
+func (gs *GameService) everyoneWagered(lobbyHub *hub.LobbyHub) bool {
+	lobby := lobbyHub.GetLobby()
+	expected := 0
+	for _, player := range gs.expectedPlayers(lobbyHub) {
+		if !player.IsHuman() {
+			continue
+		}
+		expected++
+		if lobby.Wagers[player.ID] == nil {
+			return false
+		}
+	}
+	return expected > 0
+}
+
+// closeWager ends the wager phase and serves the round's question.
+func (gs *GameService) closeWager(lobbyHub *hub.LobbyHub, round int) {
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+	// A paused wager phase is rescheduled on resume; a stopped game is over
+	if lobby.Phase != game.Wager || lobby.Round != round || lobby.Paused || !gs.loopRunning(lobby.ID) {
+		return
+	}
+
+	closed := map[string]interface{}{"round": round}
+	if lobby.IsFinalRound(round) {
+		// Hidden stakes are revealed with the results
+		closed["wagered"] = len(lobby.Wagers)
+	} else {
+		closed["wagers"] = gs.wagerList(lobby)
+	}
+	gs.BroadcastLobbyUpdate(lobbyHub, "wager_closed", closed)
+	gs.startNextQuestion(lobbyHub)
+}
+
+// recordWager marks the player's wager, if they placed one, as won or lost
+// by their answer. The caller holds the lobby lock.
+func recordWager(lobby *models.Lobby, playerID string, correct bool) {
+	if wager := lobby.Wagers[playerID]; wager != nil {
+		wager.Won = correct
+	}
+}
+
+// settleWagers pays out the round's wagers: won stakes are added to the
+// players' scores and lost ones, including those of players who never
+// answered, are taken off. Each delta is saved with the player's recorded
+// answer so recomputed scores include it. It returns the settled wagers in
+// seating order. The caller holds the lobby lock.
+func (gs *GameService) settleWagers(lobby *models.Lobby) []*models.Wager {
+	if lobby.Wagers == nil {
+		return nil
+	}
+	wagers := gs.wagerList(lobby)
+	for _, wager := range wagers {
+		player := lobby.GetPlayer(wager.PlayerID)
+		if player == nil {
+			continue
+		}
+		delta := -wager.Stake
+		if wager.Won {
+			delta = wager.Stake
+		}
+		before := player.Score
+		player.Score = models.AddScore(player.Score, delta)
+		wager.Delta = player.Score - before
+		if wager.AnswerID != 0 && wager.Delta != 0 {
+			if err := gs.repo.UpdateAnswerWager(wager.AnswerID, wager.Delta); err != nil {
+				log.Printf("ERROR: Failed to save wager for player %s in lobby %s: %v", wager.PlayerID, lobby.ID, err)
+			}
+		}
+	}
+	lobby.Wagers = nil
+	return wagers
+}
+
+// wagerList returns the wagers placed, in seating order. The caller holds
+// the lobby lock.
+func (gs *GameService) wagerList(lobby *models.Lobby) []*models.Wager {
+	wagers := make([]*models.Wager, 0, len(lobby.Wagers))
+	for _, player := range lobby.Players {
+		if wager := lobby.Wagers[player.ID]; wager != nil {
+			wagers = append(wagers, wager)
+		}
+	}
+	return wagers
+}
@@ -0,0 +1,89 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+
+	"buildprize-game/internal/game"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// A wager round opens with a wager phase; once everyone has staked the
+// question is served, and the results double won stakes and take lost ones.
+func TestWagerRound(t *testing.T) {
+	gs, gameHub, repo := newService(t)
+	if _, err := gs.CreateLobby(services.LobbyOptions{Name: "Bad wagers", MaxRounds: 2, WagerRounds: []int{3}}); !errors.Is(err, services.ErrInvalidWagerRounds) {
+		t.Fatalf("Expected ErrInvalidWagerRounds for a round past the end, got %v", err)
+	}
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Double or nothing", MaxRounds: 2, MaxPlayers: 4, WagerRounds: []int{1}})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	_, alice, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "alice"})
+	_, bob, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "bob"})
+
+	current := gameHub.GetLobbyHub(lobby.ID).GetLobby()
+	current.Lock()
+	current.GetPlayer(alice.ID).Score = 100
+	current.GetPlayer(bob.ID).Score = 100
+	current.Unlock()
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	current.Lock()
+	phase, question := current.Phase, current.CurrentQ
+	current.Unlock()
+	if phase != game.Wager || question != nil {
+		t.Fatalf("Expected a wager phase without a question, got %s", phase)
+	}
+
+	if _, err := gs.PlaceWager(lobby.ID, alice.ID, 101); !errors.Is(err, services.ErrInvalidStake) {
+		t.Fatalf("Expected ErrInvalidStake above the player's score, got %v", err)
+	}
+	if _, err := gs.PlaceWager(lobby.ID, alice.ID, 60); err != nil {
+		t.Fatalf("PlaceWager: %v", err)
+	}
+	if _, err := gs.PlaceWager(lobby.ID, alice.ID, 10); !errors.Is(err, services.ErrAlreadyWagered) {
+		t.Fatalf("Expected ErrAlreadyWagered, got %v", err)
+	}
+	if _, err := gs.PlaceWager(lobby.ID, bob.ID, 100); err != nil {
+		t.Fatalf("PlaceWager: %v", err)
+	}
+
+	waitFor(t, phaseIs(current, game.Question))
+	current.Lock()
+	question = current.CurrentQ
+	right, wrong := correctAnswer(question), wrongAnswer(question)
+	current.Unlock()
+	if err := gs.SubmitAnswer(lobby.ID, alice.ID, right); err != nil {
+		t.Fatalf("SubmitAnswer: %v", err)
+	}
+	if err := gs.SubmitAnswer(lobby.ID, bob.ID, wrong); err != nil {
+		t.Fatalf("SubmitAnswer: %v", err)
+	}
+	waitFor(t, phaseIs(current, game.Results))
+
+	answers, _ := repo.GetLobbyAnswers(lobby.ID)
+	current.Lock()
+	scores := []int{current.GetPlayer(alice.ID).Score, current.GetPlayer(bob.ID).Score}
+	current.Unlock()
+	if want := 100 + answers[0].Score + closestBonus(question) + 60; scores[0] != want {
+		t.Fatalf("Expected alice's won stake added (%d), got %d", want, scores[0])
+	}
+	if scores[1] != 0 {
+		t.Fatalf("Expected bob to lose his whole stake, got %d", scores[1])
+	}
+	if _, err := gs.PlaceWager(lobby.ID, alice.ID, 10); !errors.Is(err, services.ErrWagersClosed) {
+		t.Fatalf("Expected ErrWagersClosed after the question, got %v", err)
+	}
+	gs.ForceEndGame(lobby.ID)
+}
+
+func phaseIs(lobby *models.Lobby, want game.Phase) func() bool {
+	return func() bool {
+		lobby.Lock()
+		defer lobby.Unlock()
+		return lobby.Phase == want
+	}
+}
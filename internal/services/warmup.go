@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"buildprize-game/internal/api"
+	"buildprize-game/internal/game"
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+)
+
+const (
+	warmUpQuestionTime = 10 * time.Second
+	warmUpResultsTime  = 3 * time.Second
+	warmUpIdleDelay    = 2 * time.Second // re-check for players this often while nobody is waiting
+)
+
+// warmUp is a lobby's pre-game warm-up: no-stakes questions served on a loop
+// while players gather. Scores are shown to the player and then discarded.
+// Fields other than cancel are guarded by the lobby lock.
+type warmUp struct {
+	cancel context.CancelFunc
+	ctx    context.Context
+
+	round    int
+	question *models.Question // nil between questions
+	shownAt  time.Time
+	answered map[string]bool
+	correct  []string // players who got the current question right
+}
+
+// WarmUpAnswerResult is what a warm-up answer would have earned.
+type WarmUpAnswerResult struct {
+	Correct bool `json:"correct"`
+	Score   int  `json:"score"` // not added to the player's score
+}
+
+// startWarmUp begins serving warm-up questions in a waiting lobby. The caller
+// holds the lobby lock.
+func (gs *GameService) startWarmUp(lobbyHub *hub.LobbyHub) {
+	lobbyID := lobbyHub.GetLobby().ID
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &warmUp{ctx: ctx, cancel: cancel}
+
+	gs.mu.Lock()
+	if previous := gs.warmUps[lobbyID]; previous != nil {
+		previous.cancel()
+	}
+	gs.warmUps[lobbyID] = w
+	gs.mu.Unlock()
+
+	go gs.runWarmUp(lobbyHub, w)
+	log.Printf("Warm-up questions enabled for lobby %s", lobbyID)
+}
+
+// stopWarmUp ends the lobby's warm-up. Called with the lobby lock held, so no
+// warm-up event can follow the game starting. Safe for lobbies without one.
+func (gs *GameService) stopWarmUp(lobbyID string) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	if w := gs.warmUps[lobbyID]; w != nil {
+		w.cancel()
+		delete(gs.warmUps, lobbyID)
+	}
+}
+
+func (gs *GameService) runWarmUp(lobbyHub *hub.LobbyHub, w *warmUp) {
+	for {
+		if !gs.serveWarmUpQuestion(lobbyHub, w) {
+			if !sleepContext(w.ctx, warmUpIdleDelay) {
+				return
+			}
+			continue
+		}
+		if !sleepContext(w.ctx, warmUpQuestionTime) {
+			return
+		}
+		gs.endWarmUpQuestion(lobbyHub, w)
+		if !sleepContext(w.ctx, warmUpResultsTime) {
+			return
+		}
+	}
+}
+
+// serveWarmUpQuestion shows the next warm-up question, or returns false if
+// no real player is waiting yet.
+func (gs *GameService) serveWarmUpQuestion(lobbyHub *hub.LobbyHub, w *warmUp) bool {
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+	if w.ctx.Err() != nil || lobby.Phase != game.Waiting || !hasHumanPlayer(lobby) {
+		return false
+	}
+
+	w.round++
+	w.question = gs.questionDB.GetRandomQuestion().Shuffled()
+	w.shownAt = models.Now()
+	w.answered = make(map[string]bool)
+	w.correct = []string{}
+
+	gs.BroadcastLobbyUpdate(lobbyHub, "warmup_question", map[string]interface{}{
+		"question":          api.FromQuestion(w.question),
+		"warmup_round":      w.round,
+		"time_left":         int(warmUpQuestionTime.Seconds()),
+		"question_end_time": models.FormatTimestamp(w.shownAt.Add(warmUpQuestionTime)),
+		"server_time":       models.FormatTimestamp(w.shownAt),
+	})
+	return true
+}
+
+func (gs *GameService) endWarmUpQuestion(lobbyHub *hub.LobbyHub, w *warmUp) {
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+	if w.ctx.Err() != nil || w.question == nil {
+		return
+	}
+
+	results := map[string]interface{}{
+		"warmup_round":    w.round,
+		"correct_answer":  w.question.Correct,
+		"correct_players": w.correct,
+	}
+	switch w.question.QuestionType() {
+	case models.MultiSelect:
+		results["correct_answers"] = w.question.CorrectAnswers
+	case models.FreeText:
+		results["accepted_answers"] = w.question.AcceptedAnswers
+	case models.Numeric:
+		results["numeric_answer"] = w.question.NumericAnswer
+	}
+	w.question = nil
+	gs.BroadcastLobbyUpdate(lobbyHub, "warmup_results", results)
+}
+
+// SubmitWarmUpAnswer scores an answer to the current warm-up question with
+// the same rules as the game. Nothing is recorded: the player's score, streak
+// and answer history are untouched.
+func (gs *GameService) SubmitWarmUpAnswer(lobbyID, playerID string, answer models.SubmittedAnswer) (*WarmUpAnswerResult, error) {
+	lobbyHub := gs.hub.GetLobbyHub(lobbyID)
+	if lobbyHub == nil {
+		return nil, ErrLobbyNotFound
+	}
+
+	lobby := lobbyHub.GetLobby()
+	lobby.Lock()
+	defer lobby.Unlock()
+
+	gs.mu.Lock()
+	w := gs.warmUps[lobbyID]
+	gs.mu.Unlock()
+	if w == nil || w.question == nil {
+		return nil, ErrQuestionNotActive
+	}
+	if lobby.GetPlayer(playerID) == nil {
+		return nil, ErrPlayerNotFound
+	}
+	if err := w.question.CheckAnswer(answer); err != nil {
+		return nil, ErrInvalidAnswer
+	}
+	if w.answered[playerID] {
+		return nil, ErrAlreadyAnswered
+	}
+	w.answered[playerID] = true
+
+	responseTime := models.Now().Sub(w.shownAt).Milliseconds()
+	result := &WarmUpAnswerResult{
+		Correct: w.question.IsCorrect(answer),
+		Score:   gs.lobbyScoring(lobby).Score(w.question, answer, responseTime, 0),
+	}
+	if result.Correct {
+		w.correct = append(w.correct, playerID)
+	}
+
+	gs.BroadcastLobbyUpdate(lobbyHub, "warmup_answer_received", map[string]interface{}{
+		"player_id": playerID,
+		"correct":   result.Correct,
+		"score":     result.Score,
+	})
+	return result, nil
+}
+
+// CurrentWarmUpQuestion returns the open warm-up question and when it closes,
+// for catching up clients that connect mid-question. The caller holds the
+// lobby lock.
+func (gs *GameService) CurrentWarmUpQuestion(lobbyID string) (*models.Question, int, time.Time) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	w := gs.warmUps[lobbyID]
+	if w == nil || w.question == nil {
+		return nil, 0, time.Time{}
+	}
+	return w.question, w.round, w.shownAt.Add(warmUpQuestionTime)
+}
+
+func hasHumanPlayer(lobby *models.Lobby) bool {
+	for _, player := range lobby.Players {
+		if player.IsHuman() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// Warm-up answers are scored for the player's benefit only and stop being
+// accepted once the game starts.
+func TestWarmUpScoresDiscarded(t *testing.T) {
+	gs, gameHub, repo := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Warm-up", MaxRounds: 1, MaxPlayers: 4, WarmUp: true})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	lobbyHub := gameHub.GetLobbyHub(lobby.ID)
+
+	_, player, err := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "early"})
+	if err != nil {
+		t.Fatalf("JoinLobby: %v", err)
+	}
+	if _, _, err := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "late"}); err != nil {
+		t.Fatalf("JoinLobby: %v", err)
+	}
+
+	var question *models.Question
+	deadline := time.Now().Add(5 * time.Second)
+	for question == nil {
+		current := lobbyHub.GetLobby()
+		current.Lock()
+		question, _, _ = gs.CurrentWarmUpQuestion(lobby.ID)
+		current.Unlock()
+		if question == nil {
+			if time.Now().After(deadline) {
+				t.Fatal("No warm-up question was served")
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	result, err := gs.SubmitWarmUpAnswer(lobby.ID, player.ID, correctAnswer(question))
+	if err != nil {
+		t.Fatalf("SubmitWarmUpAnswer: %v", err)
+	}
+	if !result.Correct || result.Score <= 0 {
+		t.Fatalf("Expected a scored correct answer, got %+v", result)
+	}
+	if _, err := gs.SubmitWarmUpAnswer(lobby.ID, player.ID, correctAnswer(question)); !errors.Is(err, services.ErrAlreadyAnswered) {
+		t.Fatalf("Expected ErrAlreadyAnswered, got %v", err)
+	}
+
+	current := lobbyHub.GetLobby()
+	current.Lock()
+	score := current.GetPlayer(player.ID).Score
+	current.Unlock()
+	if score != 0 {
+		t.Fatalf("Warm-up answer counted towards the game: score %d", score)
+	}
+	if answers, _ := repo.GetLobbyAnswers(lobby.ID); len(answers) != 0 {
+		t.Fatalf("Warm-up answer was recorded: %d answers", len(answers))
+	}
+
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	if _, err := gs.SubmitWarmUpAnswer(lobby.ID, player.ID, correctAnswer(question)); !errors.Is(err, services.ErrQuestionNotActive) {
+		t.Fatalf("Expected ErrQuestionNotActive once the game started, got %v", err)
+	}
+	gs.ForceEndGame(lobby.ID)
+}
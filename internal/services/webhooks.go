@@ -0,0 +1,246 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"buildprize-game/internal/models"
+)
+
+const (
+	maxWebhookURLLength = 2048
+	webhookAttempts     = 3
+	webhookRetryDelay   = 2 * time.Second
+	maxWebhookPending   = 256
+)
+
+// lobbyWebhookEvents are the lobby events sent to its webhook, with the same
+// data players get.
+var lobbyWebhookEvents = map[string]bool{
+	"player_joined":  true,
+	"player_left":    true,
+	"game_started":   true,
+	"game_paused":    true,
+	"game_resumed":   true,
+	"game_ended":     true,
+	"game_cancelled": true,
+	"prize_payout":   true,
+}
+
+// LobbyWebhooks posts a lobby's lifecycle events to the webhook URL its host
+// registered at creation, e.g. to announce an office game's winner in a team
+// chat. Each event is a JSON POST:
+//
+//	{"event":"game_ended","lobby_id":"...","timestamp":"...","data":{...}}
+//
+// signed in the X-Webhook-Signature header as "sha256=" and the hex
+// HMAC-SHA256 of the body, keyed with the lobby's secret. A lobby's events
+// are delivered one at a time, in order, and failed deliveries are retried
+// twice. Events beyond maxWebhookPending waiting deliveries are dropped.
+type LobbyWebhooks struct {
+	key    []byte   // lobby secrets are derived from it
+	hosts  []string // hosts webhooks may point at, subdomains included; any when empty
+	client *http.Client
+
+	mu      sync.Mutex
+	queues  map[string][]webhookDelivery // lobbyID -> waiting, oldest first; kept while its sender runs
+	pending int                          // waiting deliveries across lobbies
+}
+
+type webhookDelivery struct {
+	target, secret, event string
+	body                  []byte
+}
+
+func NewLobbyWebhooks(key []byte, hosts []string, timeout time.Duration) *LobbyWebhooks {
+	var allowed []string
+	for _, host := range hosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			allowed = append(allowed, host)
+		}
+	}
+	return &LobbyWebhooks{
+		key:    key,
+		hosts:  allowed,
+		client: &http.Client{Timeout: timeout},
+		queues: make(map[string][]webhookDelivery),
+	}
+}
+
+// SetLobbyWebhooks lets hosts register a webhook for their lobby; nil, the
+// default, refuses them.
+func (gs *GameService) SetLobbyWebhooks(webhooks *LobbyWebhooks) {
+	gs.mu.Lock()
+	gs.webhooks = webhooks
+	gs.mu.Unlock()
+}
+
+// LobbyWebhookSecret returns the key a lobby's webhook deliveries are signed
+// with, given to its host when the lobby is created. It's derived from the
+// server's signing key, so it isn't stored with the lobby.
+func (gs *GameService) LobbyWebhookSecret(lobby *models.Lobby) string {
+	gs.mu.Lock()
+	webhooks := gs.webhooks
+	gs.mu.Unlock()
+	if webhooks == nil || lobby.WebhookURL == "" {
+		return ""
+	}
+	return webhooks.secret(lobby.ID)
+}
+
+func (w *LobbyWebhooks) secret(lobbyID string) string {
+	mac := hmac.New(sha256.New, w.key)
+	mac.Write([]byte("lobby-webhook:" + lobbyID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// checkURL validates a webhook URL a host registered.
+func (w *LobbyWebhooks) checkURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || len(raw) > maxWebhookURLLength || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return "", ErrInvalidWebhookURL
+	}
+	if len(w.hosts) == 0 {
+		return raw, nil
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range w.hosts {
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return raw, nil
+		}
+	}
+	return "", ErrWebhookHostNotAllowed
+}
+
+// checkWebhookURL validates the webhook URL a lobby is created with, empty
+// for none.
+func (gs *GameService) checkWebhookURL(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+	gs.mu.Lock()
+	webhooks := gs.webhooks
+	gs.mu.Unlock()
+	if webhooks == nil {
+		return "", ErrWebhooksDisabled
+	}
+	return webhooks.checkURL(raw)
+}
+
+// notifyWebhook sends a lifecycle event to the lobby's webhook, if it has
+// one. The caller holds the lobby lock; the event is encoded before
+// returning and delivered in the background.
+func (gs *GameService) notifyWebhook(lobby *models.Lobby, eventType string, data interface{}) {
+	if lobby.WebhookURL == "" || !lobbyWebhookEvents[eventType] {
+		return
+	}
+	gs.mu.Lock()
+	webhooks := gs.webhooks
+	gs.mu.Unlock()
+	if webhooks == nil {
+		return
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"event":     eventType,
+		"lobby_id":  lobby.ID,
+		"timestamp": models.Now(),
+		"data":      data,
+	})
+	if err != nil {
+		log.Printf("Lobby webhook: failed to marshal %s event for lobby %s: %v", eventType, lobby.ID, err)
+		return
+	}
+
+	delivery := webhookDelivery{target: lobby.WebhookURL, secret: webhooks.secret(lobby.ID), event: eventType, body: body}
+	if !webhooks.enqueue(lobby.ID, delivery) {
+		log.Printf("Lobby webhook: dropped %s event for lobby %s, too many deliveries pending", eventType, lobby.ID)
+	}
+}
+
+// enqueue queues a lobby's delivery, starting its sender if it isn't
+// running. It reports false if too many deliveries are waiting.
+func (w *LobbyWebhooks) enqueue(lobbyID string, delivery webhookDelivery) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.pending >= maxWebhookPending {
+		return false
+	}
+	w.pending++
+	queue, running := w.queues[lobbyID]
+	w.queues[lobbyID] = append(queue, delivery)
+	if !running {
+		go w.send(lobbyID)
+	}
+	return true
+}
+
+// send delivers a lobby's queued events until none are left.
+func (w *LobbyWebhooks) send(lobbyID string) {
+	for {
+		w.mu.Lock()
+		queue := w.queues[lobbyID]
+		if len(queue) == 0 {
+			delete(w.queues, lobbyID)
+			w.mu.Unlock()
+			return
+		}
+		delivery := queue[0]
+		w.queues[lobbyID] = queue[1:]
+		w.pending--
+		w.mu.Unlock()
+
+		w.deliver(delivery)
+	}
+}
+
+func (w *LobbyWebhooks) deliver(d webhookDelivery) {
+	mac := hmac.New(sha256.New, []byte(d.secret))
+	mac.Write(d.body)
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	for attempt := 1; ; attempt++ {
+		retry, err := w.post(d.target, signature, d.event, d.body)
+		if err == nil {
+			return
+		}
+		if !retry || attempt == webhookAttempts {
+			log.Printf("Lobby webhook: %s event to %s failed after %d attempt(s): %v", d.event, d.target, attempt, err)
+			return
+		}
+		time.Sleep(webhookRetryDelay * time.Duration(attempt))
+	}
+}
+
+// post makes one delivery, reporting whether a failure is worth retrying.
+func (w *LobbyWebhooks) post(target, signature, eventType string, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", eventType)
+	req.Header.Set("X-Webhook-Signature", signature)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("status %d", resp.StatusCode)
+}
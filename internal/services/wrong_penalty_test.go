@@ -0,0 +1,62 @@
+package services_test
+
+import (
+	"testing"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/services"
+)
+
+// The host can set a wrong-answer penalty before the game, which takes
+// points off wrong answers but never takes a total below zero.
+func TestWrongAnswerPenalty(t *testing.T) {
+	gs, gameHub, repo := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Penalised", MaxRounds: 2, MaxPlayers: 4})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	_, host, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "host"})
+	_, guest, _ := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "guest"})
+
+	tooMuch, penalty := models.DefaultScoringConfig.BaseScore+1, 40
+	if _, err := gs.UpdateLobbySettings(lobby.ID, host.ID, services.LobbySettings{WrongPenalty: &tooMuch}); err == nil {
+		t.Fatal("Expected a penalty above the base score refused")
+	}
+	updated, err := gs.UpdateLobbySettings(lobby.ID, host.ID, services.LobbySettings{WrongPenalty: &penalty})
+	if err != nil {
+		t.Fatalf("UpdateLobbySettings: %v", err)
+	}
+	updated.Lock()
+	scoring := updated.Scoring
+	updated.GetPlayer(host.ID).Score = 100
+	updated.GetPlayer(guest.ID).Score = 30
+	updated.Unlock()
+	if scoring == nil || scoring.WrongPenalty != penalty || scoring.BaseScore != models.DefaultScoringConfig.BaseScore {
+		t.Fatalf("Expected the penalty on top of the active rules, got %+v", scoring)
+	}
+
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	current := gameHub.GetLobbyHub(lobby.ID).GetLobby()
+	current.Lock()
+	wrong := wrongAnswer(current.CurrentQ)
+	current.Unlock()
+	for _, playerID := range []string{host.ID, guest.ID} {
+		if err := gs.SubmitAnswer(lobby.ID, playerID, wrong); err != nil {
+			t.Fatalf("SubmitAnswer: %v", err)
+		}
+	}
+
+	current.Lock()
+	scores := []int{current.GetPlayer(host.ID).Score, current.GetPlayer(guest.ID).Score}
+	current.Unlock()
+	if scores[0] != 100-penalty || scores[1] != 0 {
+		t.Fatalf("Expected scores of %d and 0, got %v", 100-penalty, scores)
+	}
+	answers, _ := repo.GetLobbyAnswers(lobby.ID)
+	if len(answers) != 2 || answers[0].Score != -penalty || answers[1].Score != -penalty {
+		t.Fatalf("Expected both wrong answers recorded at %d, got %+v", -penalty, answers)
+	}
+	gs.ForceEndGame(lobby.ID)
+}
@@ -0,0 +1,348 @@
+// Package simulate plays synthetic games under a scoring config, so a
+// scoring change can be judged on how games play out before it ships:
+// whether trailing players can still come back, and how early the winner is
+// decided.
+package simulate
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"buildprize-game/internal/models"
+)
+
+// questionTime is how long each question stays open, as the game loop
+// serves them.
+const questionTime int64 = 15000
+
+// Mixed asks each round in a question type picked at random.
+const Mixed models.QuestionType = "mixed"
+
+var ErrInvalidOptions = errors.New("invalid simulation options")
+
+// Skill is a distribution of player skill, from 0 (always guessing) to 1
+// (always right, and quick about it).
+type Skill interface {
+	Draw(rng *rand.Rand, seat int) float64
+}
+
+// Uniform draws skills evenly between Min and Max.
+type Uniform struct{ Min, Max float64 }
+
+func (u Uniform) Draw(rng *rand.Rand, _ int) float64 {
+	return u.Min + rng.Float64()*(u.Max-u.Min)
+}
+
+// Normal draws skills around Mean, clamped to 0..1.
+type Normal struct{ Mean, StdDev float64 }
+
+func (n Normal) Draw(rng *rand.Rand, _ int) float64 {
+	return clamp(n.Mean + rng.NormFloat64()*n.StdDev)
+}
+
+// Fixed seats players with the given skills, in order, cycling through them
+// when there are more players than skills.
+type Fixed []float64
+
+func (f Fixed) Draw(_ *rand.Rand, seat int) float64 {
+	return f[seat%len(f)]
+}
+
+// ParseSkill reads a skill distribution: "uniform:MIN,MAX",
+// "normal:MEAN,STDDEV" or "fixed:S1,S2,...".
+func ParseSkill(spec string) (Skill, error) {
+	name, rawParams, _ := strings.Cut(spec, ":")
+	var params []float64
+	for _, raw := range strings.Split(rawParams, ",") {
+		value, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil || value < 0 || value > 1 {
+			return nil, fmt.Errorf("%w: skill %q needs numbers between 0 and 1", ErrInvalidOptions, spec)
+		}
+		params = append(params, value)
+	}
+	switch {
+	case name == "uniform" && len(params) == 2 && params[0] <= params[1]:
+		return Uniform{Min: params[0], Max: params[1]}, nil
+	case name == "normal" && len(params) == 2:
+		return Normal{Mean: params[0], StdDev: params[1]}, nil
+	case name == "fixed" && len(params) > 0:
+		return Fixed(params), nil
+	}
+	return nil, fmt.Errorf("%w: unknown skill distribution %q", ErrInvalidOptions, spec)
+}
+
+// Options describe the games to simulate.
+type Options struct {
+	Scoring models.ScoringConfig
+	Mode    models.QuestionType // every round's question type, or Mixed
+	Skill   Skill
+	Games   int
+	Players int
+	Rounds  int
+	Seed    int64
+}
+
+func (o *Options) validate() error {
+	if err := o.Scoring.Validate(); err != nil {
+		return err
+	}
+	switch o.Mode {
+	case models.SingleChoice, models.TrueFalse, models.MultiSelect, models.FreeText, Mixed:
+	default:
+		return fmt.Errorf("%w: unknown mode %q", ErrInvalidOptions, o.Mode)
+	}
+	switch {
+	case o.Skill == nil:
+		return fmt.Errorf("%w: no skill distribution", ErrInvalidOptions)
+	case o.Games < 1:
+		return fmt.Errorf("%w: games must be positive", ErrInvalidOptions)
+	case o.Players < 2:
+		return fmt.Errorf("%w: at least two players are needed", ErrInvalidOptions)
+	case o.Rounds < 2:
+		return fmt.Errorf("%w: at least two rounds are needed", ErrInvalidOptions)
+	}
+	return nil
+}
+
+// Report summarises how the simulated games played out. Comebacks and
+// lock-in are measured over games with a single winner; ties are counted
+// apart.
+type Report struct {
+	Scoring string              `json:"scoring_version"`
+	Mode    models.QuestionType `json:"mode"`
+	Games   int                 `json:"games"`
+	Players int                 `json:"players"`
+	Rounds  int                 `json:"rounds"`
+
+	// Games won by a player who wasn't leading, alone or tied, after the
+	// halfway round
+	ComebackProbability float64 `json:"comeback_probability"`
+	// The round from which the winner led alone to the end, on average and
+	// as a share of the game's rounds; lower means decided earlier
+	AvgLockInRound  float64 `json:"avg_lock_in_round"`
+	LockInShare     float64 `json:"lock_in_share"`
+	LockInHistogram []int   `json:"lock_in_histogram"` // games locked in at each round, from round 1
+	// Games won by their most skilled player, and games ending in a tie for
+	// first
+	TopSkillWinRate float64 `json:"top_skill_win_rate"`
+	TieRate         float64 `json:"tie_rate"`
+	// The winner's margin over second place, as a share of the winner's score
+	AvgWinningMargin float64 `json:"avg_winning_margin"`
+}
+
+// Run plays the games and reports on them. The same options and seed give
+// the same report.
+func Run(opts Options) (*Report, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	rng := rand.New(rand.NewSource(opts.Seed))
+	report := &Report{
+		Scoring:         opts.Scoring.Version,
+		Mode:            opts.Mode,
+		Games:           opts.Games,
+		Players:         opts.Players,
+		Rounds:          opts.Rounds,
+		LockInHistogram: make([]int, opts.Rounds),
+	}
+
+	var decided, comebacks, topSkillWins, ties, lockInRounds int
+	var margins float64
+	for g := 0; g < opts.Games; g++ {
+		result := playGame(rng, &opts)
+		if result.winner < 0 {
+			ties++
+			continue
+		}
+		decided++
+		if !result.ledAtHalfway {
+			comebacks++
+		}
+		if result.winner == result.mostSkilled {
+			topSkillWins++
+		}
+		lockInRounds += result.lockInRound
+		report.LockInHistogram[result.lockInRound-1]++
+		margins += result.margin
+	}
+
+	report.TieRate = float64(ties) / float64(opts.Games)
+	if decided > 0 {
+		report.ComebackProbability = float64(comebacks) / float64(decided)
+		report.TopSkillWinRate = float64(topSkillWins) / float64(decided)
+		report.AvgLockInRound = float64(lockInRounds) / float64(decided)
+		report.LockInShare = report.AvgLockInRound / float64(opts.Rounds)
+		report.AvgWinningMargin = margins / float64(decided)
+	}
+	return report, nil
+}
+
+type gameResult struct {
+	winner       int // -1 for a tie for first
+	mostSkilled  int
+	ledAtHalfway bool
+	lockInRound  int
+	margin       float64
+}
+
+func playGame(rng *rand.Rand, opts *Options) gameResult {
+	skills := make([]float64, opts.Players)
+	mostSkilled := 0
+	for i := range skills {
+		skills[i] = opts.Skill.Draw(rng, i)
+		if skills[i] > skills[mostSkilled] {
+			mostSkilled = i
+		}
+	}
+
+	scores := make([]int, opts.Players)
+	streaks := make([]int, opts.Players)
+	halfway := opts.Rounds / 2
+	var halfwayLeaders []bool
+	soleLeaders := make([]int, opts.Rounds) // after each round, -1 when tied
+	for round := 1; round <= opts.Rounds; round++ {
+		question := newQuestion(rng, opts.Mode)
+		answers := make([]models.SubmittedAnswer, opts.Players)
+		times := make([]int64, opts.Players)
+		for i, skill := range skills {
+			answers[i], times[i] = answerFor(rng, question, skill), responseTime(rng, skill)
+		}
+		for i := range skills {
+			score := opts.Scoring.Score(question, answers[i], times[i], streaks[i])
+			if question.IsCorrect(answers[i]) {
+				score += opts.Scoring.PositionBonus(position(question, answers, times, i))
+				streaks[i]++
+			} else {
+				streaks[i] = 0
+			}
+			scores[i] = models.AddScore(scores[i], score)
+		}
+		leader, leaders := standings(scores)
+		soleLeaders[round-1] = leader
+		if round == halfway {
+			halfwayLeaders = leaders
+		}
+	}
+
+	winner, _ := standings(scores)
+	result := gameResult{winner: winner, mostSkilled: mostSkilled}
+	if winner < 0 {
+		return result
+	}
+	result.ledAtHalfway = halfwayLeaders[winner]
+	result.lockInRound = opts.Rounds
+	for round := opts.Rounds - 1; round >= 1 && soleLeaders[round-1] == winner; round-- {
+		result.lockInRound = round
+	}
+	second := 0
+	for i, score := range scores {
+		if i != winner && score > second {
+			second = score
+		}
+	}
+	result.margin = float64(scores[winner]-second) / float64(scores[winner])
+	return result
+}
+
+// standings returns the sole leader, or -1 when first place is tied, and
+// which players share the lead.
+func standings(scores []int) (int, []bool) {
+	top := math.MinInt
+	for _, score := range scores {
+		top = max(top, score)
+	}
+	leaders := make([]bool, len(scores))
+	leader, tied := -1, false
+	for i, score := range scores {
+		if score == top {
+			leaders[i] = true
+			tied = leader >= 0
+			leader = i
+		}
+	}
+	if tied {
+		return -1, leaders
+	}
+	return leader, leaders
+}
+
+// newQuestion builds a question of the mode's type. Only its shape matters:
+// which options are right, and how many there are to guess from.
+func newQuestion(rng *rand.Rand, mode models.QuestionType) *models.Question {
+	if mode == Mixed {
+		types := []models.QuestionType{models.SingleChoice, models.TrueFalse, models.MultiSelect, models.FreeText}
+		mode = types[rng.Intn(len(types))]
+	}
+	switch mode {
+	case models.TrueFalse:
+		return &models.Question{Type: mode, Options: []string{"True", "False"}, OptionCount: 2, Correct: rng.Intn(2)}
+	case models.MultiSelect:
+		correct := rng.Perm(4)[:2]
+		return &models.Question{Type: mode, Options: []string{"A", "B", "C", "D"}, OptionCount: 4, CorrectAnswers: correct}
+	case models.FreeText:
+		return &models.Question{Type: mode, AcceptedAnswers: []string{"answer"}}
+	}
+	return &models.Question{Type: models.SingleChoice, Options: []string{"A", "B", "C", "D"}, OptionCount: 4, Correct: rng.Intn(4)}
+}
+
+// answerFor answers as a player who knows the answer with probability skill
+// and guesses otherwise. Multi-select options are each known or guessed on
+// their own; free text can't be guessed.
+func answerFor(rng *rand.Rand, q *models.Question, skill float64) models.SubmittedAnswer {
+	knows := func() bool { return rng.Float64() < skill }
+	switch q.QuestionType() {
+	case models.MultiSelect:
+		correct := make(map[int]bool)
+		for _, idx := range q.CorrectAnswers {
+			correct[idx] = true
+		}
+		var choices []int
+		for idx := range q.Options {
+			if knows() {
+				if correct[idx] {
+					choices = append(choices, idx)
+				}
+			} else if rng.Intn(2) == 0 {
+				choices = append(choices, idx)
+			}
+		}
+		return models.SubmittedAnswer{Choices: choices}
+	case models.FreeText:
+		if knows() {
+			return models.SubmittedAnswer{Text: q.AcceptedAnswers[0]}
+		}
+		return models.SubmittedAnswer{Text: "wrong"}
+	}
+	if knows() {
+		return models.SubmittedAnswer{Choice: q.Correct}
+	}
+	return models.SubmittedAnswer{Choice: rng.Intn(len(q.Options))}
+}
+
+// responseTime is how long a player takes, in milliseconds: skilled players
+// answer sooner, give or take 30%, within the question's time.
+func responseTime(rng *rand.Rand, skill float64) int64 {
+	mean := float64(questionTime) * (0.75 - 0.5*skill)
+	ms := mean * (0.7 + 0.6*rng.Float64())
+	return int64(math.Max(300, math.Min(ms, float64(questionTime))))
+}
+
+// position is player i's place among the correct answers, from 1, by
+// response time; ties go to the player seated first.
+func position(q *models.Question, answers []models.SubmittedAnswer, times []int64, i int) int {
+	place := 1
+	for j := range answers {
+		if j != i && q.IsCorrect(answers[j]) && (times[j] < times[i] || (times[j] == times[i] && j < i)) {
+			place++
+		}
+	}
+	return place
+}
+
+func clamp(skill float64) float64 {
+	return math.Max(0, math.Min(1, skill))
+}
@@ -0,0 +1,52 @@
+package simulate_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/simulate"
+)
+
+// Simulations repeat under the same seed, and their metrics follow the
+// skill gap: a far better player rarely needs a comeback and wins early.
+func TestScoringSimulation(t *testing.T) {
+	opts := simulate.Options{
+		Scoring: models.DefaultScoringConfig,
+		Mode:    simulate.Mixed,
+		Skill:   simulate.Uniform{Min: 0.4, Max: 0.6},
+		Games:   2000,
+		Players: 4,
+		Rounds:  10,
+		Seed:    7,
+	}
+	even, err := simulate.Run(opts)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	again, _ := simulate.Run(opts)
+	if !reflect.DeepEqual(even, again) {
+		t.Fatalf("Expected the same report for the same seed, got %+v and %+v", even, again)
+	}
+
+	opts.Skill = simulate.Fixed{0.95, 0.2, 0.2, 0.2}
+	lopsided, err := simulate.Run(opts)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if lopsided.TopSkillWinRate < 0.95 || lopsided.TopSkillWinRate <= even.TopSkillWinRate {
+		t.Fatalf("Expected the strongest player to win nearly every lopsided game, got %.2f (even: %.2f)", lopsided.TopSkillWinRate, even.TopSkillWinRate)
+	}
+	if lopsided.ComebackProbability >= even.ComebackProbability || lopsided.AvgLockInRound >= even.AvgLockInRound {
+		t.Fatalf("Expected fewer comebacks and an earlier lock-in with a skill gap, got %+v vs %+v", lopsided, even)
+	}
+
+	if _, err := simulate.ParseSkill("normal:0.5"); !errors.Is(err, simulate.ErrInvalidOptions) {
+		t.Fatalf("Expected ErrInvalidOptions for a bad skill spec, got %v", err)
+	}
+	opts.Mode = "relay"
+	if _, err := simulate.Run(opts); !errors.Is(err, simulate.ErrInvalidOptions) {
+		t.Fatalf("Expected ErrInvalidOptions for an unknown mode, got %v", err)
+	}
+}
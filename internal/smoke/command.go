@@ -0,0 +1,43 @@
+package smoke
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// Main runs the smoke subcommand with its arguments and returns the exit
+// code: 0 when the game went as expected, 1 on a deviation and 2 for bad
+// arguments.
+func Main(args []string) int {
+	flags := flag.NewFlagSet("smoke", flag.ContinueOnError)
+	target := flags.String("url", "http://localhost:8080", "base URL of the deployment to check")
+	adminURL := flags.String("admin-url", "", "base URL of the admin API, when served on ADMIN_ADDR (default: -url)")
+	adminToken := flags.String("admin-token", os.Getenv("ADMIN_TOKEN"), "admin token, to play in a sandbox lobby (default: $ADMIN_TOKEN)")
+	rounds := flags.Int("rounds", 2, "rounds to play")
+	timeout := flags.Duration("timeout", 2*time.Minute, "how long the whole run may take")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	started := time.Now()
+	err := Run(ctx, Options{
+		URL:        *target,
+		AdminURL:   *adminURL,
+		AdminToken: *adminToken,
+		Rounds:     *rounds,
+		Timeout:    *timeout,
+		Logf:       log.Printf,
+	})
+	if err != nil {
+		log.Printf("Smoke test against %s FAILED after %s: %v", *target, time.Since(started).Round(time.Millisecond), err)
+		return 1
+	}
+	log.Printf("Smoke test against %s passed in %s", *target, time.Since(started).Round(time.Millisecond))
+	return 0
+}
@@ -0,0 +1,576 @@
+// Package smoke plays a scripted game against a running deployment to check
+// it end to end after a deploy: two bots create and join a lobby over REST,
+// connect over WebSocket, play every round and check the events and final
+// results they get back. Any deviation fails the run.
+package smoke
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"buildprize-game/internal/api"
+	"buildprize-game/internal/models"
+
+	"github.com/gorilla/websocket"
+)
+
+var ErrDeviation = errors.New("smoke test deviation")
+
+// Options describe the deployment to check and the game to play there.
+type Options struct {
+	URL string // the deployment's base URL, e.g. https://quiz.example.com
+	// With an admin token the game is played in a sandbox lobby, which keeps
+	// it out of analytics and player stats. AdminURL defaults to URL, for
+	// deployments serving the admin API on ADMIN_ADDR instead.
+	AdminURL   string
+	AdminToken string
+	Rounds     int
+	Timeout    time.Duration // for the whole run
+	Logf       func(format string, args ...interface{})
+}
+
+// Run plays the game and returns the first deviation from how it should go,
+// or nil when everything checked out.
+func Run(ctx context.Context, opts Options) error {
+	if opts.URL == "" || opts.Rounds < 1 {
+		return fmt.Errorf("%w: a URL and at least one round are needed", ErrDeviation)
+	}
+	if opts.AdminURL == "" {
+		opts.AdminURL = opts.URL
+	}
+	if opts.Logf == nil {
+		opts.Logf = func(string, ...interface{}) {}
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	r := &run{
+		opts:   opts,
+		base:   strings.TrimRight(opts.URL, "/"),
+		client: &http.Client{Timeout: 15 * time.Second},
+		ctx:    ctx,
+	}
+	defer r.cleanUp()
+	return r.play()
+}
+
+type run struct {
+	opts   Options
+	base   string
+	client *http.Client
+	ctx    context.Context
+
+	lobbyID string
+	bots    []*bot
+}
+
+func (r *run) play() error {
+	if err := r.call("GET", r.base+"/health", nil, nil, nil); err != nil {
+		return err
+	}
+
+	lobby, err := r.createLobby()
+	if err != nil {
+		return err
+	}
+	r.lobbyID = lobby.ID
+	r.opts.Logf("Created lobby %s for %d round(s)", lobby.ID, r.opts.Rounds)
+
+	suffix := randomSuffix()
+	for _, name := range []string{"smoke-a-" + suffix, "smoke-b-" + suffix} {
+		b, err := r.join(name)
+		if b != nil {
+			r.bots = append(r.bots, b)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	r.opts.Logf("Bots %s and %s joined over REST and WebSocket", r.bots[0].name, r.bots[1].name)
+
+	if err := r.call("POST", r.api("/start"), nil, map[string]string{}, nil); err != nil {
+		return err
+	}
+	for _, b := range r.bots {
+		if _, err := b.expect(r.ctx, "game_started"); err != nil {
+			return err
+		}
+	}
+
+	totals := make(map[string]int)
+	for round := 1; round <= r.opts.Rounds; round++ {
+		if err := r.playRound(round, totals); err != nil {
+			return err
+		}
+		r.opts.Logf("Round %d checked out: %s %d, %s %d", round,
+			r.bots[0].name, totals[r.bots[0].playerID], r.bots[1].name, totals[r.bots[1].playerID])
+	}
+
+	for _, b := range r.bots {
+		ev, err := b.expect(r.ctx, "game_ended")
+		if err != nil {
+			return err
+		}
+		var ended struct {
+			FinalLeaderboard []api.Player `json:"final_leaderboard"`
+			Winner           *api.Player  `json:"winner"`
+		}
+		if err := ev.decode(&ended); err != nil {
+			return b.deviate("game_ended: %v", err)
+		}
+		if err := r.checkLeaderboard(ended.FinalLeaderboard, totals); err != nil {
+			return b.deviate("game_ended: %v", err)
+		}
+		if ended.Winner == nil || ended.Winner.Score != ended.FinalLeaderboard[0].Score {
+			return b.deviate("game_ended: winner %+v isn't the leaderboard's top score", ended.Winner)
+		}
+	}
+
+	var final api.Lobby
+	if err := r.call("GET", r.api(""), nil, nil, &final); err != nil {
+		return err
+	}
+	if final.State != models.Finished {
+		return fmt.Errorf("%w: lobby is %s after the game, expected finished", ErrDeviation, final.State)
+	}
+	r.opts.Logf("Game finished with the expected results")
+	return nil
+}
+
+// createLobby creates a sandbox lobby through the admin API when there's an
+// admin token, and a public one otherwise.
+func (r *run) createLobby() (*api.Lobby, error) {
+	body := map[string]interface{}{
+		"name":       "Smoke test " + models.FormatTimestamp(time.Now()),
+		"max_rounds": r.opts.Rounds,
+	}
+	var lobby api.Lobby
+	if r.opts.AdminToken != "" {
+		headers := map[string]string{"X-Admin-Token": r.opts.AdminToken}
+		err := r.call("POST", strings.TrimRight(r.opts.AdminURL, "/")+"/api/v1/admin/sandbox/lobbies", headers, body, &lobby)
+		return &lobby, err
+	}
+
+	headers, err := r.solveChallenge()
+	if err != nil {
+		return nil, err
+	}
+	body["max_players"] = 2
+	err = r.call("POST", r.base+"/api/v1/lobbies", headers, body, &lobby)
+	return &lobby, err
+}
+
+// join seats a bot over REST and takes its seat over WebSocket with the
+// session token the join returned.
+func (r *run) join(name string) (*bot, error) {
+	headers, err := r.solveChallenge()
+	if err != nil {
+		return nil, err
+	}
+	var joined struct {
+		Player       api.Player `json:"player"`
+		SessionToken string     `json:"session_token"`
+	}
+	if err := r.call("POST", r.api("/join"), headers, map[string]string{"username": name}, &joined); err != nil {
+		return nil, err
+	}
+	if joined.Player.ID == "" || joined.SessionToken == "" {
+		return nil, fmt.Errorf("%w: joining as %s returned no player or session token", ErrDeviation, name)
+	}
+
+	b := &bot{name: name, playerID: joined.Player.ID, token: joined.SessionToken, lobbyID: r.lobbyID}
+	if err := b.connect(r.ctx, r.wsURL()); err != nil {
+		return b, err
+	}
+	ev, err := b.expect(r.ctx, "session")
+	if err != nil {
+		return b, err
+	}
+	var session struct {
+		PlayerID string `json:"player_id"`
+	}
+	if err := ev.decode(&session); err != nil || session.PlayerID != b.playerID {
+		return b, b.deviate("WebSocket join seated player %q, expected %s", session.PlayerID, b.playerID)
+	}
+	return b, nil
+}
+
+// playRound answers the round's question with the first bot over WebSocket
+// and the second over REST, then checks both bots saw both answers scored
+// and results that add up.
+func (r *run) playRound(round int, totals map[string]int) error {
+	var question *api.Question
+	for _, b := range r.bots {
+		ev, err := b.expect(r.ctx, "new_question")
+		if err != nil {
+			return err
+		}
+		var asked struct {
+			Question *api.Question `json:"question"`
+			Round    int           `json:"round"`
+		}
+		if err := ev.decode(&asked); err != nil || asked.Question == nil {
+			return b.deviate("new_question without a question (%v)", err)
+		}
+		if asked.Round != round {
+			return b.deviate("new_question for round %d, expected round %d", asked.Round, round)
+		}
+		if question != nil && asked.Question.ID != question.ID {
+			return b.deviate("got question %s, the other bot got %s", asked.Question.ID, question.ID)
+		}
+		question = asked.Question
+	}
+
+	first, second := r.bots[0], r.bots[1]
+	err := first.send(map[string]interface{}{
+		"type":     "submit_answer",
+		"lobby_id": r.lobbyID,
+		"data":     map[string]interface{}{"answer": answerFor(question, 0)},
+	})
+	if err != nil {
+		return first.deviate("sending an answer: %v", err)
+	}
+	headers := map[string]string{"X-Session-Token": second.token}
+	body := map[string]interface{}{"player_id": second.playerID, "answer": answerFor(question, 1)}
+	if err := r.call("POST", r.api("/answer"), headers, body, nil); err != nil {
+		return err
+	}
+
+	var scored map[string]int
+	for _, b := range r.bots {
+		scores := make(map[string]int)
+		for len(scores) < len(r.bots) {
+			ev, err := b.expect(r.ctx, "answer_received")
+			if err != nil {
+				return err
+			}
+			var received struct {
+				PlayerID string `json:"player_id"`
+				Score    int    `json:"score"`
+			}
+			if err := ev.decode(&received); err != nil {
+				return b.deviate("answer_received: %v", err)
+			}
+			if _, seen := scores[received.PlayerID]; seen || !r.isBot(received.PlayerID) {
+				return b.deviate("unexpected answer_received for player %s", received.PlayerID)
+			}
+			scores[received.PlayerID] = received.Score
+		}
+		if scored == nil {
+			scored = scores
+			for playerID, score := range scores {
+				totals[playerID] += score
+			}
+		} else {
+			for playerID, score := range scores {
+				if scored[playerID] != score {
+					return b.deviate("scored %d for player %s, the other bot was told %d", score, playerID, scored[playerID])
+				}
+			}
+		}
+
+		ev, err := b.expect(r.ctx, "question_results")
+		if err != nil {
+			return err
+		}
+		var results struct {
+			Round       int          `json:"round"`
+			Leaderboard []api.Player `json:"leaderboard"`
+		}
+		if err := ev.decode(&results); err != nil {
+			return b.deviate("question_results: %v", err)
+		}
+		if results.Round != round {
+			return b.deviate("question_results for round %d, expected round %d", results.Round, round)
+		}
+		if err := r.checkLeaderboard(results.Leaderboard, totals); err != nil {
+			return b.deviate("question_results: %v", err)
+		}
+	}
+	return nil
+}
+
+// checkLeaderboard checks a leaderboard holds exactly the bots, highest
+// score first, with the scores their answers added up to.
+func (r *run) checkLeaderboard(leaderboard []api.Player, totals map[string]int) error {
+	if len(leaderboard) != len(r.bots) {
+		return fmt.Errorf("leaderboard has %d players, expected %d", len(leaderboard), len(r.bots))
+	}
+	for i, player := range leaderboard {
+		want, ok := totals[player.ID]
+		if !ok {
+			return fmt.Errorf("unexpected player %s on the leaderboard", player.ID)
+		}
+		if player.Score != want {
+			return fmt.Errorf("%s has %d points, its answers were scored %d", player.Username, player.Score, want)
+		}
+		if i > 0 && player.Score > leaderboard[i-1].Score {
+			return fmt.Errorf("leaderboard isn't ranked by score")
+		}
+	}
+	return nil
+}
+
+func (r *run) isBot(playerID string) bool {
+	for _, b := range r.bots {
+		if b.playerID == playerID {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanUp closes the bots' connections and has them leave, so the lobby is
+// removed straight away rather than left for the cleanup sweep.
+func (r *run) cleanUp() {
+	for _, b := range r.bots {
+		b.close()
+		headers := map[string]string{"X-Session-Token": b.token}
+		if err := r.call("POST", r.api("/leave"), headers, map[string]string{"player_id": b.playerID}, nil); err != nil {
+			r.opts.Logf("Bot %s couldn't leave: %v", b.name, err)
+		}
+	}
+}
+
+// solveChallenge returns the headers that pass the deployment's abuse
+// challenge, solving its proof of work. Captchas need a person, so runs
+// against a deployment using them fail here.
+func (r *run) solveChallenge() (map[string]string, error) {
+	var challenge struct {
+		Mode       string `json:"mode"`
+		Challenge  string `json:"challenge"`
+		Difficulty int    `json:"difficulty"`
+	}
+	if err := r.call("GET", r.base+"/api/v1/challenge", nil, nil, &challenge); err != nil {
+		return nil, err
+	}
+	switch challenge.Mode {
+	case "none":
+		return map[string]string{}, nil
+	case "pow":
+	default:
+		return nil, fmt.Errorf("%w: the deployment asks for a %q challenge, which bots can't solve", ErrDeviation, challenge.Mode)
+	}
+
+	for nonce := 0; ; nonce++ {
+		if nonce%(1<<16) == 0 && r.ctx.Err() != nil {
+			return nil, r.ctx.Err()
+		}
+		candidate := strconv.Itoa(nonce)
+		hash := sha256.Sum256([]byte(challenge.Challenge + ":" + candidate))
+		if leadingZeroBits(hash[:]) >= challenge.Difficulty {
+			return map[string]string{"X-Challenge": challenge.Challenge, "X-Challenge-Nonce": candidate}, nil
+		}
+	}
+}
+
+// call makes a request with a JSON body, when there is one, and decodes the
+// JSON response into target, when given. Non-2xx responses are deviations.
+func (r *run) call(method, url string, headers map[string]string, body, target interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(r.ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %s %s: %v", ErrDeviation, method, url, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%w: %s %s: %v", ErrDeviation, method, url, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%w: %s %s returned %d: %s", ErrDeviation, method, url, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if target != nil {
+		if err := json.Unmarshal(data, target); err != nil {
+			return fmt.Errorf("%w: %s %s returned unexpected JSON: %v", ErrDeviation, method, url, err)
+		}
+	}
+	return nil
+}
+
+func (r *run) api(path string) string {
+	return r.base + "/api/v1/lobbies/" + r.lobbyID + path
+}
+
+func (r *run) wsURL() string {
+	u, err := url.Parse(r.base)
+	if err != nil {
+		return r.base + "/ws"
+	}
+	if u.Scheme == "https" {
+		u.Scheme = "wss"
+	} else {
+		u.Scheme = "ws"
+	}
+	u.Path = strings.TrimRight(u.Path, "/") + "/ws"
+	return u.String()
+}
+
+// answerFor picks a valid answer of the question's type. Bots don't know
+// the right answer; they answer differently so the scores can differ.
+func answerFor(q *api.Question, seat int) interface{} {
+	options := max(q.OptionCount, len(q.Options))
+	switch q.Type {
+	case models.FreeText:
+		return "smoke test"
+	case models.MultiSelect:
+		return []int{seat % max(options, 1)}
+	}
+	return seat % max(options, 1)
+}
+
+func leadingZeroBits(hash []byte) int {
+	count := 0
+	for _, b := range hash {
+		if b != 0 {
+			return count + bits.LeadingZeros8(b)
+		}
+		count += 8
+	}
+	return count
+}
+
+func randomSuffix() string {
+	random := make([]byte, 3)
+	rand.Read(random)
+	return hex.EncodeToString(random)
+}
+
+// bot is one player's WebSocket connection, with the events it has been
+// sent queued up in order.
+type bot struct {
+	name     string
+	playerID string
+	token    string
+	lobbyID  string
+
+	conn    *websocket.Conn
+	events  chan event
+	readErr error // set before events is closed
+	lastSeq uint64
+}
+
+type event struct {
+	Type    string          `json:"type"`
+	LobbyID string          `json:"lobby_id"`
+	Seq     uint64          `json:"seq"`
+	Data    json.RawMessage `json:"data"`
+}
+
+func (e event) decode(target interface{}) error {
+	return json.Unmarshal(e.Data, target)
+}
+
+func (b *bot) connect(ctx context.Context, wsURL string) error {
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, nil)
+	if err != nil {
+		return b.deviate("connecting to %s: %v", wsURL, err)
+	}
+	b.conn = conn
+	b.events = make(chan event, 256)
+	go b.read()
+
+	return b.send(map[string]interface{}{
+		"type":     "join_lobby",
+		"lobby_id": b.lobbyID,
+		"data":     map[string]interface{}{"username": b.name, "session_token": b.token},
+	})
+}
+
+func (b *bot) read() {
+	defer close(b.events)
+	for {
+		_, message, err := b.conn.ReadMessage()
+		if err != nil {
+			b.readErr = err
+			return
+		}
+		var ev event
+		if err := json.Unmarshal(message, &ev); err != nil {
+			b.readErr = fmt.Errorf("undecodable message %q: %v", message, err)
+			return
+		}
+		b.events <- ev
+	}
+}
+
+func (b *bot) send(message interface{}) error {
+	return b.conn.WriteJSON(message)
+}
+
+// expect waits for the next event of the given type, passing over others.
+// Events for another lobby or out of sequence, a rejected join and a game
+// ending early are deviations.
+func (b *bot) expect(ctx context.Context, eventType string) (event, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return event{}, b.deviate("timed out waiting for %s", eventType)
+		case ev, ok := <-b.events:
+			if !ok {
+				return event{}, b.deviate("connection lost waiting for %s: %v", eventType, b.readErr)
+			}
+			if ev.LobbyID != "" && ev.LobbyID != b.lobbyID {
+				return ev, b.deviate("%s event for lobby %s", ev.Type, ev.LobbyID)
+			}
+			// Replies to one connection repeat the current seq, so it only
+			// has to never go backwards
+			if ev.Seq != 0 {
+				if ev.Seq < b.lastSeq {
+					return ev, b.deviate("%s event has seq %d after %d", ev.Type, ev.Seq, b.lastSeq)
+				}
+				b.lastSeq = ev.Seq
+			}
+			switch {
+			case ev.Type == eventType:
+				return ev, nil
+			case ev.Type == "join_rejected", ev.Type == "game_ended":
+				return ev, b.deviate("got %s (%s) waiting for %s", ev.Type, ev.Data, eventType)
+			}
+		}
+	}
+}
+
+func (b *bot) deviate(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s: %s", ErrDeviation, b.name, fmt.Sprintf(format, args...))
+}
+
+func (b *bot) close() {
+	if b.conn != nil {
+		b.conn.Close()
+	}
+}
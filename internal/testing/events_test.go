@@ -0,0 +1,106 @@
+package testing
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// TestEventOrdering checks that lobby events carry strictly increasing
+// sequence numbers and non-decreasing timestamps, and that every connection
+// sees them in the same order, while chat arrives concurrently over REST and
+// WebSocket.
+func TestEventOrdering(t *testing.T) {
+	fmt.Println("\nTesting event ordering under concurrent REST and WebSocket activity...")
+
+	var lobby LobbyResponse
+	if err := testClient.PostJSON("/lobbies", CreateLobbyRequest{Name: "Ordering Test", MaxRounds: 3}, &lobby); err != nil {
+		t.Fatalf("Failed to create lobby: %v", err)
+	}
+
+	var joined JoinLobbyResponse
+	if err := testClient.PostJSON(fmt.Sprintf("/lobbies/%s/join", lobby.ID), JoinLobbyRequest{Username: "orderer"}, &joined); err != nil {
+		t.Fatalf("Failed to join lobby: %v", err)
+	}
+
+	const connections = 3
+	const messagesPerSource = 20
+
+	conns := make([]*websocket.Conn, 0, connections)
+	for i := 0; i < connections; i++ {
+		conn, err := DialLobby(WS_URL, lobby.ID, "orderer", joined.SessionToken)
+		if err != nil {
+			t.Fatalf("Failed to open WebSocket: %v", err)
+		}
+		defer conn.Close()
+		conns = append(conns, conn)
+	}
+
+	// Give every connection time to register before producing events
+	time.Sleep(500 * time.Millisecond)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < messagesPerSource; i++ {
+			req := ChatMessageRequest{PlayerID: joined.Player.ID, Message: fmt.Sprintf("rest %d", i)}
+			if err := testClient.PostJSONAs(fmt.Sprintf("/lobbies/%s/chat", lobby.ID), joined.SessionToken, req, nil); err != nil {
+				t.Errorf("REST chat failed: %v", err)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < messagesPerSource; i++ {
+			err := conns[0].WriteJSON(map[string]interface{}{
+				"type":     "chat_message",
+				"lobby_id": lobby.ID,
+				"data":     map[string]interface{}{"message": fmt.Sprintf("ws %d", i)},
+			})
+			if err != nil {
+				t.Errorf("WebSocket chat failed: %v", err)
+			}
+		}
+	}()
+	wg.Wait()
+
+	orders := make([][]string, connections)
+	for i, conn := range conns {
+		conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+
+		var lastSeq uint64
+		var lastTimestamp time.Time
+		for len(orders[i]) < 2*messagesPerSource {
+			var event EventResponse
+			if err := conn.ReadJSON(&event); err != nil {
+				t.Fatalf("Connection %d: stopped after %d chat messages: %v", i, len(orders[i]), err)
+			}
+			if event.Timestamp.Before(lastTimestamp) {
+				t.Fatalf("Connection %d: %s event timestamp went backwards", i, event.Type)
+			}
+			lastTimestamp = event.Timestamp
+			if event.Type != "chat_message" {
+				continue
+			}
+			if lastSeq != 0 && event.Seq <= lastSeq {
+				t.Fatalf("Connection %d: seq %d arrived after seq %d", i, event.Seq, lastSeq)
+			}
+			lastSeq = event.Seq
+			orders[i] = append(orders[i], fmt.Sprintf("%d:%v", event.Seq, event.Data["message"]))
+		}
+	}
+
+	for i := 1; i < connections; i++ {
+		for j := range orders[0] {
+			if orders[i][j] != orders[0][j] {
+				t.Fatalf("Connection %d saw %s at position %d, connection 0 saw %s", i, orders[i][j], j, orders[0][j])
+			}
+		}
+	}
+
+	fmt.Printf("%d connections saw %d chat messages in the same order\n", connections, len(orders[0]))
+}
@@ -8,11 +8,14 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"buildprize-game/internal/models"
 )
 
 const (
 	API_BASE = "http://localhost:8080/api/v1"
 	HEALTH_URL = "http://localhost:8080/health"
+	WS_URL = "ws://localhost:8080/ws"
 )
 
 var testClient *TestClient
@@ -102,6 +105,35 @@ func TestHealthEndpoint(t *testing.T) {
 	fmt.Println("Health check passed")
 }
 
+func TestReadyEndpoint(t *testing.T) {
+	fmt.Println("\nTesting readiness endpoint...")
+
+	var health struct {
+		Status   string `json:"status"`
+		Database struct {
+			Status string `json:"status"`
+		} `json:"database"`
+	}
+	if err := testClient.GetJSON("/health", &health); err != nil {
+		t.Fatalf("Health check failed: %v", err)
+	}
+	if health.Database.Status != "ok" {
+		t.Fatalf("Expected database status ok, got %q", health.Database.Status)
+	}
+
+	resp, err := testClient.Get("/ready")
+	if err != nil {
+		t.Fatalf("Readiness check failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	fmt.Println("Readiness check passed")
+}
+
 func TestCreateLobby(t *testing.T) {
 	fmt.Println("\nTesting lobby creation...")
 	
@@ -137,13 +169,13 @@ func TestCreateLobby(t *testing.T) {
 func TestListLobbies(t *testing.T) {
 	fmt.Println("\nTesting lobby listing...")
 	
-	var lobbies []LobbyResponse
-	err := testClient.GetJSON("/lobbies", &lobbies)
+	var page LobbyListResponse
+	err := testClient.GetJSON("/lobbies", &page)
 	if err != nil {
 		t.Fatalf("Failed to list lobbies: %v", err)
 	}
 	
-	fmt.Printf("Found %d lobbies\n", len(lobbies))
+	fmt.Printf("Found %d lobbies\n", len(page.Lobbies))
 }
 
 func TestJoinLobby(t *testing.T) {
@@ -176,6 +208,7 @@ func TestJoinLobby(t *testing.T) {
 	
 	// Store player ID for other tests
 	t.Setenv("TEST_PLAYER1_ID", response.Player.ID)
+	t.Setenv("TEST_PLAYER1_SESSION", response.SessionToken)
 }
 
 func TestJoinSecondPlayer(t *testing.T) {
@@ -208,6 +241,7 @@ func TestJoinSecondPlayer(t *testing.T) {
 	
 	// Store player ID for other tests
 	t.Setenv("TEST_PLAYER2_ID", response.Player.ID)
+	t.Setenv("TEST_PLAYER2_SESSION", response.SessionToken)
 }
 
 func TestStartGame(t *testing.T) {
@@ -250,7 +284,7 @@ func TestSubmitAnswers(t *testing.T) {
 	}
 	
 	var response1 MessageResponse
-	err := testClient.PostJSON(fmt.Sprintf("/lobbies/%s/answer", lobbyID), req1, &response1)
+	err := testClient.PostJSONAs(fmt.Sprintf("/lobbies/%s/answer", lobbyID), os.Getenv("TEST_PLAYER1_SESSION"), req1, &response1)
 	if err != nil {
 		t.Fatalf("Player1 answer submission failed: %v", err)
 	}
@@ -265,7 +299,7 @@ func TestSubmitAnswers(t *testing.T) {
 	}
 	
 	var response2 MessageResponse
-	err = testClient.PostJSON(fmt.Sprintf("/lobbies/%s/answer", lobbyID), req2, &response2)
+	err = testClient.PostJSONAs(fmt.Sprintf("/lobbies/%s/answer", lobbyID), os.Getenv("TEST_PLAYER2_SESSION"), req2, &response2)
 	if err != nil {
 		t.Fatalf("Player2 answer submission failed: %v", err)
 	}
@@ -273,6 +307,155 @@ func TestSubmitAnswers(t *testing.T) {
 	fmt.Println("Player2 answer submitted")
 }
 
+// TestDuplicateAnswerRejected is a regression test: a second submission for
+// the same question used to be scored again.
+func TestDuplicateAnswerRejected(t *testing.T) {
+	fmt.Println("\nTesting duplicate answer rejection...")
+
+	var lobby LobbyResponse
+	if err := testClient.PostJSON("/lobbies", CreateLobbyRequest{Name: "Duplicate Answer Test", MaxRounds: 3}, &lobby); err != nil {
+		t.Fatalf("Failed to create lobby: %v", err)
+	}
+
+	var player1, player2 JoinLobbyResponse
+	if err := testClient.PostJSON(fmt.Sprintf("/lobbies/%s/join", lobby.ID), JoinLobbyRequest{Username: "dup1"}, &player1); err != nil {
+		t.Fatalf("Failed to join lobby: %v", err)
+	}
+	if err := testClient.PostJSON(fmt.Sprintf("/lobbies/%s/join", lobby.ID), JoinLobbyRequest{Username: "dup2"}, &player2); err != nil {
+		t.Fatalf("Failed to join lobby: %v", err)
+	}
+	if err := testClient.PostJSON(fmt.Sprintf("/lobbies/%s/start", lobby.ID), nil, nil); err != nil {
+		t.Fatalf("Failed to start game: %v", err)
+	}
+
+	// Wait for the first question
+	var state LobbyResponse
+	for i := 0; i < 20 && state.CurrentQ == nil; i++ {
+		time.Sleep(250 * time.Millisecond)
+		if err := testClient.GetJSON(fmt.Sprintf("/lobbies/%s", lobby.ID), &state); err != nil {
+			t.Fatalf("Failed to get lobby state: %v", err)
+		}
+	}
+	if state.CurrentQ == nil {
+		t.Fatal("No question became active")
+	}
+
+	req := SubmitAnswerRequest{PlayerID: player1.Player.ID, Answer: validAnswer(state.CurrentQ), ResponseTime: 1000}
+	if err := testClient.PostJSONAs(fmt.Sprintf("/lobbies/%s/answer", lobby.ID), player1.SessionToken, req, nil); err != nil {
+		t.Fatalf("First answer submission failed: %v", err)
+	}
+	if err := testClient.GetJSON(fmt.Sprintf("/lobbies/%s", lobby.ID), &state); err != nil {
+		t.Fatalf("Failed to get lobby state: %v", err)
+	}
+	scoreAfterFirst := playerScore(state, player1.Player.ID)
+
+	err := testClient.PostJSONAs(fmt.Sprintf("/lobbies/%s/answer", lobby.ID), player1.SessionToken, req, nil)
+	if err == nil || !strings.Contains(err.Error(), "already answered") {
+		t.Fatalf("Expected duplicate answer to be rejected, got: %v", err)
+	}
+
+	if err := testClient.GetJSON(fmt.Sprintf("/lobbies/%s", lobby.ID), &state); err != nil {
+		t.Fatalf("Failed to get lobby state: %v", err)
+	}
+	if score := playerScore(state, player1.Player.ID); score != scoreAfterFirst {
+		t.Fatalf("Score changed after duplicate answer: %d -> %d", scoreAfterFirst, score)
+	}
+
+	fmt.Println("Duplicate answer rejected")
+}
+
+func TestPauseResume(t *testing.T) {
+	fmt.Println("\nTesting pause and resume...")
+
+	var lobby LobbyResponse
+	if err := testClient.PostJSON("/lobbies", CreateLobbyRequest{Name: "Pause Test", MaxRounds: 3}, &lobby); err != nil {
+		t.Fatalf("Failed to create lobby: %v", err)
+	}
+
+	var host, guest JoinLobbyResponse
+	if err := testClient.PostJSON(fmt.Sprintf("/lobbies/%s/join", lobby.ID), JoinLobbyRequest{Username: "pausehost"}, &host); err != nil {
+		t.Fatalf("Failed to join lobby: %v", err)
+	}
+	if err := testClient.PostJSON(fmt.Sprintf("/lobbies/%s/join", lobby.ID), JoinLobbyRequest{Username: "pauseguest"}, &guest); err != nil {
+		t.Fatalf("Failed to join lobby: %v", err)
+	}
+	if err := testClient.PostJSON(fmt.Sprintf("/lobbies/%s/start", lobby.ID), nil, nil); err != nil {
+		t.Fatalf("Failed to start game: %v", err)
+	}
+
+	var state LobbyResponse
+	for i := 0; i < 20 && state.CurrentQ == nil; i++ {
+		time.Sleep(250 * time.Millisecond)
+		if err := testClient.GetJSON(fmt.Sprintf("/lobbies/%s", lobby.ID), &state); err != nil {
+			t.Fatalf("Failed to get lobby state: %v", err)
+		}
+	}
+	if state.CurrentQ == nil {
+		t.Fatal("No question became active")
+	}
+
+	err := testClient.PostJSON(fmt.Sprintf("/lobbies/%s/pause", lobby.ID), PauseRequest{PlayerID: guest.Player.ID}, nil)
+	if err == nil || !strings.Contains(err.Error(), "only the host") {
+		t.Fatalf("Expected non-host pause to be rejected, got: %v", err)
+	}
+	if err := testClient.PostJSON(fmt.Sprintf("/lobbies/%s/pause", lobby.ID), PauseRequest{PlayerID: host.Player.ID}, nil); err != nil {
+		t.Fatalf("Failed to pause game: %v", err)
+	}
+
+	if err := testClient.GetJSON(fmt.Sprintf("/lobbies/%s", lobby.ID), &state); err != nil {
+		t.Fatalf("Failed to get lobby state: %v", err)
+	}
+	if !state.Paused || state.RemainingMs <= 0 {
+		t.Fatalf("Expected paused lobby with time remaining, got paused=%v remaining_ms=%d", state.Paused, state.RemainingMs)
+	}
+	remaining := state.RemainingMs
+
+	req := SubmitAnswerRequest{PlayerID: host.Player.ID, Answer: validAnswer(state.CurrentQ)}
+	err = testClient.PostJSONAs(fmt.Sprintf("/lobbies/%s/answer", lobby.ID), host.SessionToken, req, nil)
+	if err == nil || !strings.Contains(err.Error(), "no active question") {
+		t.Fatalf("Expected answer during pause to be rejected, got: %v", err)
+	}
+
+	// The timer must not run while paused
+	time.Sleep(2 * time.Second)
+	if err := testClient.GetJSON(fmt.Sprintf("/lobbies/%s", lobby.ID), &state); err != nil {
+		t.Fatalf("Failed to get lobby state: %v", err)
+	}
+	if state.RemainingMs != remaining || state.CurrentQ == nil {
+		t.Fatalf("Paused question changed: remaining_ms %d -> %d", remaining, state.RemainingMs)
+	}
+
+	if err := testClient.PostJSON(fmt.Sprintf("/lobbies/%s/resume", lobby.ID), PauseRequest{PlayerID: host.Player.ID}, nil); err != nil {
+		t.Fatalf("Failed to resume game: %v", err)
+	}
+	if err := testClient.PostJSONAs(fmt.Sprintf("/lobbies/%s/answer", lobby.ID), host.SessionToken, req, nil); err != nil {
+		t.Fatalf("Answer after resume failed: %v", err)
+	}
+
+	fmt.Println("Pause and resume work")
+}
+
+// validAnswer returns a well-formed (not necessarily correct) answer for q.
+func validAnswer(q *models.Question) interface{} {
+	switch q.QuestionType() {
+	case models.FreeText:
+		return "test answer"
+	case models.MultiSelect:
+		return []int{0}
+	default:
+		return 0
+	}
+}
+
+func playerScore(lobby LobbyResponse, playerID string) int {
+	for _, player := range lobby.Players {
+		if player.ID == playerID {
+			return player.Score
+		}
+	}
+	return -1
+}
+
 func TestLobbyState(t *testing.T) {
 	fmt.Println("\nTesting lobby state retrieval...")
 	
@@ -318,7 +501,7 @@ func TestLeaveLobby(t *testing.T) {
 	}
 	
 	var response MessageResponse
-	err := testClient.PostJSON(fmt.Sprintf("/lobbies/%s/leave", lobbyID), req, &response)
+	err := testClient.PostJSONAs(fmt.Sprintf("/lobbies/%s/leave", lobbyID), os.Getenv("TEST_PLAYER1_SESSION"), req, &response)
 	if err != nil {
 		t.Fatalf("Failed to leave lobby: %v", err)
 	}
@@ -0,0 +1,163 @@
+// Package stress hammers lobby state from many goroutines at once, the way
+// HTTP handlers, WebSocket handlers and game timers do in production. Run it
+// with the race detector:
+//
+//	go test -race ./internal/testing/stress/
+package stress
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"buildprize-game/internal/hub"
+	"buildprize-game/internal/models"
+	"buildprize-game/internal/repository"
+	"buildprize-game/internal/services"
+)
+
+// newService runs a game service against the in-memory repository, whose
+// saves encode the lobby so unsynchronised reads show up in the race
+// detector like real saves would.
+func newService(t *testing.T) (*services.GameService, *hub.Hub, *repository.InMemoryRepository) {
+	t.Helper()
+	gameHub := hub.NewHub()
+	repo := repository.NewInMemoryRepository()
+	return services.NewGameService(gameHub, repo, 50), gameHub, repo
+}
+
+func TestConcurrentJoinsAndLeaves(t *testing.T) {
+	gs, gameHub, _ := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Join Race", MaxRounds: 1, MaxPlayers: 40})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	lobbyHub := gameHub.GetLobbyHub(lobby.ID)
+
+	// Keep one player seated so the lobby isn't removed when others leave
+	if _, _, err := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: "anchor"}); err != nil {
+		t.Fatalf("JoinLobby: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, player, err := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: fmt.Sprintf("player%d", i)})
+			if err != nil {
+				t.Errorf("JoinLobby: %v", err)
+				return
+			}
+			if i%2 == 0 {
+				if err := gs.LeaveLobby(lobby.ID, player.ID); err != nil {
+					t.Errorf("LeaveLobby: %v", err)
+				}
+			}
+		}(i)
+	}
+	// Readers, like GET /lobbies/:id, run alongside the writers
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				lobbyHub.GetLobby().Snapshot()
+			}
+		}()
+	}
+	wg.Wait()
+
+	var state models.Lobby
+	if err := json.Unmarshal(lobbyHub.GetLobby().Snapshot(), &state); err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	if len(state.Players) != 16 {
+		t.Fatalf("Expected 16 players after joins and leaves, got %d", len(state.Players))
+	}
+}
+
+func TestConcurrentAnswers(t *testing.T) {
+	gs, gameHub, repo := newService(t)
+	lobby, err := gs.CreateLobby(services.LobbyOptions{Name: "Answer Race", MaxRounds: 1, MaxPlayers: 20})
+	if err != nil {
+		t.Fatalf("CreateLobby: %v", err)
+	}
+	lobbyHub := gameHub.GetLobbyHub(lobby.ID)
+
+	var playerIDs []string
+	for i := 0; i < 20; i++ {
+		_, player, err := gs.JoinLobby(lobby.ID, services.JoinOptions{Username: fmt.Sprintf("player%d", i)})
+		if err != nil {
+			t.Fatalf("JoinLobby: %v", err)
+		}
+		playerIDs = append(playerIDs, player.ID)
+	}
+	if err := gs.StartGame(lobby.ID); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+
+	current := lobbyHub.GetLobby()
+	current.Lock()
+	answer := models.SubmittedAnswer{Choice: 0}
+	switch current.CurrentQ.QuestionType() {
+	case models.FreeText:
+		answer = models.SubmittedAnswer{Text: "an answer"}
+	case models.MultiSelect:
+		answer = models.SubmittedAnswer{Choices: []int{0}}
+	}
+	current.Unlock()
+
+	// Every player submits three times at once; only one may count
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	accepted := make(map[string]int)
+	for _, playerID := range playerIDs {
+		for attempt := 0; attempt < 3; attempt++ {
+			wg.Add(1)
+			go func(playerID string) {
+				defer wg.Done()
+				if err := gs.SubmitAnswer(lobby.ID, playerID, answer); err == nil {
+					mu.Lock()
+					accepted[playerID]++
+					mu.Unlock()
+				}
+			}(playerID)
+		}
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 50; j++ {
+			lobbyHub.GetLobby().Snapshot()
+		}
+	}()
+	wg.Wait()
+
+	for _, playerID := range playerIDs {
+		if accepted[playerID] != 1 {
+			t.Fatalf("Player %s had %d answers accepted, want 1", playerID, accepted[playerID])
+		}
+	}
+
+	// Everyone answered, so the round ends early and the single-round game finishes
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		var state models.Lobby
+		json.Unmarshal(lobbyHub.GetLobby().Snapshot(), &state)
+		if state.State == models.Finished {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Game did not finish, state %s", state.State)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	answers, _ := repo.GetLobbyAnswers(lobby.ID)
+	if len(answers) != len(playerIDs) {
+		t.Fatalf("Expected %d saved answers, got %d", len(playerIDs), len(answers))
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"io"
 	"net/http"
 	"time"
+
+	"github.com/gorilla/websocket"
 )
 
 type TestClient struct {
@@ -28,16 +30,26 @@ func (tc *TestClient) Get(path string) (*http.Response, error) {
 }
 
 func (tc *TestClient) Post(path string, body interface{}) (*http.Response, error) {
+	return tc.PostAs(path, "", body)
+}
+
+// PostAs posts with a player's session token, as calls that act as the
+// player need.
+func (tc *TestClient) PostAs(path, sessionToken string, body interface{}) (*http.Response, error) {
 	jsonBody, err := json.Marshal(body)
 	if err != nil {
 		return nil, err
 	}
-	
-	return tc.client.Post(
-		tc.baseURL+path,
-		"application/json",
-		bytes.NewBuffer(jsonBody),
-	)
+
+	req, err := http.NewRequest(http.MethodPost, tc.baseURL+path, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if sessionToken != "" {
+		req.Header.Set("X-Session-Token", sessionToken)
+	}
+	return tc.client.Do(req)
 }
 
 func (tc *TestClient) GetJSON(path string, target interface{}) error {
@@ -60,7 +72,11 @@ func (tc *TestClient) GetJSON(path string, target interface{}) error {
 }
 
 func (tc *TestClient) PostJSON(path string, body interface{}, target interface{}) error {
-	resp, err := tc.Post(path, body)
+	return tc.PostJSONAs(path, "", body, target)
+}
+
+func (tc *TestClient) PostJSONAs(path, sessionToken string, body interface{}, target interface{}) error {
+	resp, err := tc.PostAs(path, sessionToken, body)
 	if err != nil {
 		return err
 	}
@@ -81,3 +97,23 @@ func (tc *TestClient) PostJSON(path string, body interface{}, target interface{}
 	
 	return nil
 }
+
+// DialLobby opens a WebSocket connection and joins the lobby as username,
+// taking back the seat a REST join returned sessionToken for.
+func DialLobby(wsURL, lobbyID, username, sessionToken string) (*websocket.Conn, error) {
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = conn.WriteJSON(map[string]interface{}{
+		"type":     "join_lobby",
+		"lobby_id": lobbyID,
+		"data":     map[string]interface{}{"username": username, "session_token": sessionToken},
+	})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
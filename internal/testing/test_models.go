@@ -1,6 +1,10 @@
 package testing
 
-import "buildprize-game/internal/models"
+import (
+	"time"
+
+	"buildprize-game/internal/models"
+)
 
 // Test data structures
 type CreateLobbyRequest struct {
@@ -17,25 +21,53 @@ type LeaveLobbyRequest struct {
 }
 
 type SubmitAnswerRequest struct {
-	PlayerID     string `json:"player_id"`
-	Answer       int    `json:"answer"`
-	ResponseTime int    `json:"response_time"`
+	PlayerID     string      `json:"player_id"`
+	Answer       interface{} `json:"answer"` // choice index, list of indexes, or text
+	ResponseTime int         `json:"response_time"`
 }
 
 type LobbyResponse struct {
-	ID        string           `json:"id"`
-	Name      string           `json:"name"`
-	Players   []models.Player  `json:"players"`
-	State     string           `json:"state"`
-	Round     int              `json:"round"`
-	MaxRounds int              `json:"max_rounds"`
-	CurrentQ  *models.Question `json:"current_question,omitempty"`
-	CreatedAt string           `json:"created_at"`
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Players     []models.Player  `json:"players"`
+	State       string           `json:"state"`
+	Phase       string           `json:"phase"`
+	Round       int              `json:"round"`
+	MaxRounds   int              `json:"max_rounds"`
+	CurrentQ    *models.Question `json:"current_question,omitempty"`
+	CreatedAt   string           `json:"created_at"`
+	Paused      bool             `json:"paused,omitempty"`
+	RemainingMs int64            `json:"remaining_ms,omitempty"`
+}
+
+type LobbyListResponse struct {
+	Lobbies []LobbyResponse `json:"lobbies"`
+	Total   int             `json:"total"`
+	Limit   int             `json:"limit"`
+	Offset  int             `json:"offset"`
+}
+
+type PauseRequest struct {
+	PlayerID string `json:"player_id"`
 }
 
 type JoinLobbyResponse struct {
-	Lobby  LobbyResponse `json:"lobby"`
-	Player models.Player `json:"player"`
+	Lobby        LobbyResponse `json:"lobby"`
+	Player       models.Player `json:"player"`
+	SessionToken string        `json:"session_token"`
+}
+
+type ChatMessageRequest struct {
+	PlayerID string `json:"player_id"`
+	Message  string `json:"message"`
+}
+
+type EventResponse struct {
+	Type      string                 `json:"type"`
+	LobbyID   string                 `json:"lobby_id"`
+	Seq       uint64                 `json:"seq"`
+	Data      map[string]interface{} `json:"data"`
+	Timestamp time.Time              `json:"timestamp"`
 }
 
 type MessageResponse struct {
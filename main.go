@@ -1,16 +1,38 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"os"
 
 	"buildprize-game/internal/server"
 	"buildprize-game/internal/config"
+	"buildprize-game/internal/smoke"
 )
 
 func main() {
+	// "main smoke -url ..." checks a live deployment end to end instead
+	if len(os.Args) > 1 && os.Args[1] == "smoke" {
+		os.Exit(smoke.Main(os.Args[2:]))
+	}
+
+	flags := flag.NewFlagSet("main", flag.ExitOnError)
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "YAML or TOML config file; environment variables override it")
+	printConfig := flags.Bool("print-config", false, "print the effective configuration and exit")
+	flags.Parse(os.Args[1:])
+
 	// Load configuration
-	cfg := config.Load()
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+	if *printConfig {
+		cfg.Print(os.Stdout, false)
+		return
+	}
+	log.Printf("Effective configuration (settings changed from their defaults):")
+	cfg.Print(log.Writer(), true)
+
 	srv := server.NewServer(cfg)
 	log.Printf("Starting server on port %s", cfg.Port)
 	if err := srv.Start(); err != nil {